@@ -0,0 +1,157 @@
+package adjustmentModel
+
+import (
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Reason string
+
+const (
+	WrongItem       Reason = "wrong_item"
+	MissingItem     Reason = "missing_item"
+	QualityIssue    Reason = "quality_issue"
+	ItemUnavailable Reason = "item_unavailable"
+	LateDelivery    Reason = "late_delivery"
+	Goodwill        Reason = "goodwill"
+)
+
+type Status string
+
+const (
+	PendingApproval Status = "pending_approval"
+	Approved        Status = "approved"
+	Applied         Status = "applied"
+	Rejected        Status = "rejected"
+)
+
+type Role string
+
+const (
+	Staff   Role = "staff"
+	Manager Role = "manager"
+	Admin   Role = "admin"
+)
+
+// ApprovalLimits is the largest adjustment each role may approve on its own, in basis points
+// of the order total. Anything larger waits for someone with a higher limit.
+var ApprovalLimits = map[Role]int64{
+	Staff:   2000,
+	Manager: 5000,
+	Admin:   10000,
+}
+
+// transitions lists the moves allowed from each state. Applied and rejected adjustments are
+// final; the database refuses any other change to a stored adjustment.
+var transitions = map[Status][]Status{
+	PendingApproval: {Approved, Rejected},
+	Approved:        {Applied},
+}
+
+// Adjustment gives back part or all of an order's total. Amount is the sum of the lines, in
+// minor units of the order currency, with each line's share of discounts and tax included.
+type Adjustment struct {
+	Id          uuid.UUID  `json:"id"`
+	OrderId     uuid.UUID  `json:"orderId"`
+	Reason      Reason     `json:"reason"`
+	Note        string     `json:"note"`
+	Full        bool       `json:"full"`
+	Lines       []Line     `json:"lines"`
+	Refunds     []Refund   `json:"refunds"`
+	Amount      int64      `json:"amount"`
+	Currency    string     `json:"currency"`
+	Status      Status     `json:"status"`
+	RequestedBy uuid.UUID  `json:"requestedBy"`
+	DecidedBy   *uuid.UUID `json:"decidedBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	DecidedAt   *time.Time `json:"decidedAt,omitempty"`
+	AppliedAt   *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Line refunds Quantity units of one order item. Restock puts the units back into the menu
+// item's counted stock, for food that was never served.
+type Line struct {
+	OrderItemId int64 `json:"orderItemId"`
+	MenuId      int64 `json:"menuId"`
+	Quantity    int   `json:"quantity"`
+	Amount      int64 `json:"amount"`
+	Restock     bool  `json:"restock"`
+}
+
+// Refund is the part of the adjustment paid back through one of the order's payments. The
+// split is fixed when the adjustment is approved so applying it again refunds nothing twice.
+type Refund struct {
+	PaymentId uuid.UUID `json:"paymentId"`
+	Amount    int64     `json:"amount"`
+}
+
+// Actor is the staff member asking for or deciding on an adjustment.
+type Actor struct {
+	UserId uuid.UUID `validate:"required"`
+	Role   Role      `validate:"required,oneof=staff manager admin"`
+}
+
+// AdjustmentRequest refunds the listed lines, or everything not yet refunded when Full is set.
+type AdjustmentRequest struct {
+	Reason Reason        `json:"reason" validate:"required,oneof=wrong_item missing_item quality_issue item_unavailable late_delivery goodwill"`
+	Note   string        `json:"note" validate:"max=500"`
+	Full   bool          `json:"full"`
+	Lines  []LineRequest `json:"lines" validate:"required_without=Full,dive"`
+}
+
+type LineRequest struct {
+	OrderItemId int64 `json:"orderItemId" validate:"required"`
+	Quantity    int   `json:"quantity" validate:"required,min=1"`
+	Restock     bool  `json:"restock"`
+}
+
+// Reconciliation compares what an order was charged with what was given back. Refunded is
+// what the payments refunded for adjustments and Other what they refunded outside them, such
+// as an overpaid bill share. Balanced is false when Refunded disagrees with the applied
+// adjustments.
+type Reconciliation struct {
+	OrderId  uuid.UUID `json:"orderId"`
+	Currency string    `json:"currency"`
+	Total    int64     `json:"total"`
+	Applied  int64     `json:"applied"`
+	Pending  int64     `json:"pending"`
+	Refunded int64     `json:"refunded"`
+	Other    int64     `json:"other"`
+	Net      int64     `json:"net"`
+	Balanced bool      `json:"balanced"`
+}
+
+func (a *Actor) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(a)
+}
+
+func (a *AdjustmentRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(a)
+}
+
+// CanApprove reports whether role may approve amount on an order of the given total.
+func CanApprove(role Role, amount, total int64) bool {
+	limit, ok := ApprovalLimits[role]
+	if !ok {
+		return false
+	}
+	return amount*10000 <= total*limit
+}
+
+func CanTransition(from, to Status) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Counts reports whether the adjustment holds part of the order total, either because it was
+// applied or because it may still be.
+func (a *Adjustment) Counts() bool {
+	return a.Status != Rejected
+}
@@ -0,0 +1,34 @@
+package adjustmentModel
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCanApprove(t *testing.T) {
+	assert.True(t, CanApprove(Staff, 2000, 10000))
+	assert.False(t, CanApprove(Staff, 2001, 10000))
+	assert.True(t, CanApprove(Manager, 5000, 10000))
+	assert.True(t, CanApprove(Admin, 10000, 10000))
+	assert.False(t, CanApprove(Admin, 10001, 10000))
+	assert.False(t, CanApprove("chef", 1, 10000))
+}
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, CanTransition(PendingApproval, Approved))
+	assert.True(t, CanTransition(Approved, Applied))
+	assert.False(t, CanTransition(PendingApproval, Applied))
+	assert.False(t, CanTransition(Rejected, Approved))
+	assert.False(t, CanTransition(Applied, Rejected))
+}
+
+func TestAdjustmentRequest_ValidateInput(t *testing.T) {
+	full := AdjustmentRequest{Reason: Goodwill, Full: true}
+	assert.Nil(t, full.ValidateInput())
+
+	noLines := AdjustmentRequest{Reason: Goodwill}
+	assert.NotNil(t, noLines.ValidateInput())
+
+	badReason := AdjustmentRequest{Reason: "changed_mind", Lines: []LineRequest{{OrderItemId: 1, Quantity: 1}}}
+	assert.NotNil(t, badReason.ValidateInput())
+}
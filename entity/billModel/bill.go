@@ -0,0 +1,116 @@
+package billModel
+
+import (
+	"fmt"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Method string
+
+const (
+	ByItem Method = "by_item"
+	Equal  Method = "equal"
+	Custom Method = "custom"
+)
+
+type Status string
+
+const (
+	Open    Status = "open"
+	Settled Status = "settled"
+)
+
+// Bill is an order's total split between payers. Each share is paid separately, possibly
+// in several partial payments, and the bill settles when every share is paid.
+type Bill struct {
+	Id        uuid.UUID  `json:"id"`
+	OrderId   uuid.UUID  `json:"orderId"`
+	Method    Method     `json:"method"`
+	Currency  string     `json:"currency"`
+	Total     int64      `json:"total"`
+	Status    Status     `json:"status"`
+	Shares    []Share    `json:"shares"`
+	CreatedAt time.Time  `json:"createdAt"`
+	SettledAt *time.Time `json:"settledAt,omitempty"`
+}
+
+// Share is one payer's part of the bill. Amount is their part of the order total; tips are
+// on top of it and tracked separately.
+type Share struct {
+	Id       uuid.UUID  `json:"id"`
+	BillId   uuid.UUID  `json:"billId"`
+	Name     string     `json:"name"`
+	UserId   *uuid.UUID `json:"userId,omitempty"`
+	Amount   int64      `json:"amount"`
+	Paid     int64      `json:"paid"`
+	Tip      int64      `json:"tip"`
+	Payments []Payment  `json:"payments"`
+}
+
+// Payment links one captured payment to the share it paid towards.
+type Payment struct {
+	PaymentId uuid.UUID `json:"paymentId"`
+	ShareId   uuid.UUID `json:"shareId"`
+	Amount    int64     `json:"amount"`
+	Tip       int64     `json:"tip"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type SplitRequest struct {
+	Method Method         `json:"method" validate:"required,oneof=by_item equal custom"`
+	Payers []PayerRequest `json:"payers" validate:"required,min=1,max=50,dive"`
+}
+
+// PayerRequest describes one payer. Amount is only used by custom splits and Items, the
+// order item ids the payer had, only by item splits.
+type PayerRequest struct {
+	Name   string     `json:"name" validate:"required,max=100"`
+	UserId *uuid.UUID `json:"userId"`
+	Amount int64      `json:"amount" validate:"min=0"`
+	Items  []int64    `json:"items"`
+}
+
+type PayShareRequest struct {
+	Amount         int64  `json:"amount" validate:"gt=0"`
+	Tip            int64  `json:"tip" validate:"min=0"`
+	SourceToken    string `json:"sourceToken" validate:"required"`
+	IdempotencyKey string `json:"idempotencyKey" validate:"required,max=200"`
+}
+
+func (s *SplitRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(s)
+}
+
+func (p *PayShareRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(p)
+}
+
+func (s *Share) Remaining() int64 {
+	return s.Amount - s.Paid
+}
+
+func (s *Share) IsPaid() bool {
+	return s.Paid >= s.Amount
+}
+
+func (b *Bill) Share(id uuid.UUID) (*Share, error) {
+	for i := range b.Shares {
+		if b.Shares[i].Id == id {
+			return &b.Shares[i], nil
+		}
+	}
+	return nil, fmt.Errorf("share %v is not part of bill %v", id, b.Id)
+}
+
+// Outstanding is what is still owed across all shares, tips excluded.
+func (b *Bill) Outstanding() int64 {
+	var owed int64
+	for _, s := range b.Shares {
+		owed += s.Remaining()
+	}
+	return owed
+}
@@ -0,0 +1,172 @@
+package brandModel
+
+import (
+	"errors"
+	"fmt"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"math/big"
+	"rsm/entity/menuModel"
+	"sort"
+	"time"
+)
+
+// ErrForbidden means the user has no role at the brand or location high enough for the action.
+var ErrForbidden = errors.New("not allowed for this brand")
+
+type Role string
+
+const (
+	Owner   Role = "owner"
+	Admin   Role = "admin"
+	Manager Role = "manager"
+	Staff   Role = "staff"
+)
+
+// ranks orders the roles; each may do everything the ones below it can.
+var ranks = map[Role]int{Staff: 1, Manager: 2, Admin: 3, Owner: 4}
+
+// Brand is an organisation running one or more restaurant locations under one master menu.
+type Brand struct {
+	Id        uuid.UUID `json:"id"`
+	Name      string    `json:"name" validate:"required,max=100"`
+	OwnerId   uuid.UUID `json:"ownerId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Member gives a user a role across the brand, or only at Locations when any are listed.
+// The brand's owner is a member with the Owner role at every location.
+type Member struct {
+	BrandId   uuid.UUID   `json:"brandId"`
+	UserId    uuid.UUID   `json:"userId" validate:"required"`
+	Role      Role        `json:"role" validate:"required,oneof=admin manager staff"`
+	Locations []uuid.UUID `json:"locations"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// MasterItem is an item on the brand's menu. Every location serves it at Price unless it
+// overrides the price or marks it unavailable. Allergens is nil until the brand declares
+// them; an empty list declares none.
+type MasterItem struct {
+	Id        uuid.UUID              `json:"id"`
+	BrandId   uuid.UUID              `json:"brandId"`
+	Item      string                 `json:"item" validate:"required"`
+	ItemType  string                 `json:"itemType" validate:"required"`
+	Price     string                 `json:"price" validate:"required,numeric"`
+	Allergens []menuModel.Allergen   `json:"allergens"`
+	Dietary   []menuModel.DietaryTag `json:"dietary"`
+	CreatedAt time.Time              `json:"createdAt"`
+	UpdatedAt time.Time              `json:"updatedAt"`
+}
+
+// Override changes one master item at one location. An empty Price keeps the brand price.
+type Override struct {
+	RestaurantId uuid.UUID `json:"restaurantId" validate:"required"`
+	MasterItemId uuid.UUID `json:"masterItemId" validate:"required"`
+	Price        string    `json:"price" validate:"omitempty,numeric"`
+	Unavailable  bool      `json:"unavailable"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// LocationItem is a master item as one location serves it.
+type LocationItem struct {
+	MasterItemId uuid.UUID              `json:"masterItemId"`
+	Item         string                 `json:"item"`
+	ItemType     string                 `json:"itemType"`
+	Price        string                 `json:"price"`
+	Allergens    []menuModel.Allergen   `json:"allergens"`
+	Dietary      []menuModel.DietaryTag `json:"dietary"`
+	Available    bool                   `json:"available"`
+	Overridden   bool                   `json:"overridden"`
+}
+
+func (b *Brand) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(b)
+}
+
+// ValidateInput does not accept Owner: a brand has exactly one owner, set when it is created.
+func (m *Member) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(m)
+}
+
+func (m *MasterItem) ValidateInput() error {
+	validate := validator.New()
+	err := validate.Struct(m)
+	if err != nil {
+		return err
+	}
+	if err = checkPrice(m.Price); err != nil {
+		return err
+	}
+	info := menuModel.DietaryInfo{Allergens: m.Allergens, Dietary: m.Dietary}
+	return info.ValidateInput()
+}
+
+func (o *Override) ValidateInput() error {
+	validate := validator.New()
+	err := validate.Struct(o)
+	if err != nil {
+		return err
+	}
+	if o.Price == "" {
+		return nil
+	}
+	return checkPrice(o.Price)
+}
+
+// AtLeast reports whether the role may do what need may.
+func (r Role) AtLeast(need Role) bool {
+	return ranks[r] >= ranks[need]
+}
+
+// Covers reports whether the member's role applies at the location.
+func (m *Member) Covers(restaurantId uuid.UUID) bool {
+	if len(m.Locations) == 0 {
+		return true
+	}
+	for _, id := range m.Locations {
+		if id == restaurantId {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply works out a location's menu from the brand's items and that location's overrides,
+// in item type then item order.
+func Apply(items []MasterItem, overrides []Override) []LocationItem {
+	byItem := make(map[uuid.UUID]Override, len(overrides))
+	for _, o := range overrides {
+		byItem[o.MasterItemId] = o
+	}
+	located := make([]LocationItem, 0, len(items))
+	for _, m := range items {
+		l := LocationItem{MasterItemId: m.Id, Item: m.Item, ItemType: m.ItemType, Price: m.Price,
+			Allergens: m.Allergens, Dietary: m.Dietary, Available: true}
+		if o, ok := byItem[m.Id]; ok {
+			l.Overridden = true
+			l.Available = !o.Unavailable
+			if o.Price != "" {
+				l.Price = o.Price
+			}
+		}
+		located = append(located, l)
+	}
+	sort.SliceStable(located, func(i, j int) bool {
+		if located[i].ItemType != located[j].ItemType {
+			return located[i].ItemType < located[j].ItemType
+		}
+		return located[i].Item < located[j].Item
+	})
+	return located
+}
+
+func checkPrice(price string) error {
+	p, ok := new(big.Rat).SetString(price)
+	if !ok || p.Sign() < 0 {
+		return fmt.Errorf("invalid price %q", price)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package brandModel
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/menuModel"
+	"testing"
+)
+
+func TestRole_AtLeast(t *testing.T) {
+	assert.True(t, Owner.AtLeast(Admin))
+	assert.True(t, Manager.AtLeast(Manager))
+	assert.False(t, Staff.AtLeast(Manager))
+	assert.False(t, Role("chef").AtLeast(Staff))
+}
+
+func TestMember_Covers(t *testing.T) {
+	here, there := uuid.New(), uuid.New()
+	everywhere := Member{Role: Admin}
+	assert.True(t, everywhere.Covers(here))
+
+	local := Member{Role: Manager, Locations: []uuid.UUID{here}}
+	assert.True(t, local.Covers(here))
+	assert.False(t, local.Covers(there))
+}
+
+func TestMember_ValidateInput(t *testing.T) {
+	manager := Member{UserId: uuid.New(), Role: Manager}
+	assert.Nil(t, manager.ValidateInput())
+
+	owner := Member{UserId: uuid.New(), Role: Owner}
+	assert.NotNil(t, owner.ValidateInput())
+}
+
+func TestMasterItem_ValidateInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		item    MasterItem
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			item: MasterItem{Item: "Jollof", ItemType: "Main", Price: "12.50",
+				Allergens: []menuModel.Allergen{menuModel.Celery}},
+		}, {
+			name:    "negative price",
+			item:    MasterItem{Item: "Jollof", ItemType: "Main", Price: "-1"},
+			wantErr: true,
+		}, {
+			name:    "unknown allergen",
+			item:    MasterItem{Item: "Jollof", ItemType: "Main", Price: "12", Allergens: []menuModel.Allergen{"dust"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.item.ValidateInput()
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestOverride_ValidateInput(t *testing.T) {
+	keepPrice := Override{RestaurantId: uuid.New(), MasterItemId: uuid.New(), Unavailable: true}
+	assert.Nil(t, keepPrice.ValidateInput())
+
+	badPrice := Override{RestaurantId: uuid.New(), MasterItemId: uuid.New(), Price: "-3"}
+	assert.NotNil(t, badPrice.ValidateInput())
+}
+
+func TestApply(t *testing.T) {
+	rice, soup, cake := uuid.New(), uuid.New(), uuid.New()
+	items := []MasterItem{
+		{Id: cake, Item: "Cake", ItemType: "Dessert", Price: "5"},
+		{Id: soup, Item: "Soup", ItemType: "Main", Price: "9"},
+		{Id: rice, Item: "Rice", ItemType: "Main", Price: "8", Allergens: []menuModel.Allergen{}},
+	}
+	overrides := []Override{
+		{MasterItemId: soup, Price: "10.50"},
+		{MasterItemId: cake, Unavailable: true},
+	}
+
+	// Rice declares no allergens while the others declare nothing yet; locations keep the difference.
+	located := Apply(items, overrides)
+	assert.Equal(t, []LocationItem{
+		{MasterItemId: cake, Item: "Cake", ItemType: "Dessert", Price: "5", Overridden: true},
+		{MasterItemId: rice, Item: "Rice", ItemType: "Main", Price: "8", Allergens: []menuModel.Allergen{},
+			Available: true},
+		{MasterItemId: soup, Item: "Soup", ItemType: "Main", Price: "10.50", Available: true, Overridden: true},
+	}, located)
+}
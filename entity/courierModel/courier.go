@@ -0,0 +1,71 @@
+package courierModel
+
+import (
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"rsm/location/geoUtils"
+	"time"
+)
+
+type Status string
+
+const (
+	Offline   Status = "offline"
+	Available Status = "available"
+	Busy      Status = "busy"
+)
+
+// Courier is a user who delivers orders. Latitude and Longitude are the last position the
+// courier reported, at PositionAt.
+type Courier struct {
+	Id         uuid.UUID  `json:"id"`
+	UserId     uuid.UUID  `json:"userId"`
+	Status     Status     `json:"status"`
+	Latitude   float64    `json:"latitude"`
+	Longitude  float64    `json:"longitude"`
+	PositionAt *time.Time `json:"positionAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type NearbyCourier struct {
+	Courier    Courier `json:"courier"`
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+// NearbyQuery finds available couriers around Center. Couriers in Exclude, couriers with an
+// open offer and couriers whose position is older than FreshSince are left out.
+type NearbyQuery struct {
+	Center     geoUtils.Point
+	RadiusKm   float64
+	Exclude    []uuid.UUID
+	FreshSince time.Time
+	Limit      int
+}
+
+// AvailabilityRequest is sent by couriers going on or off shift. Busy is set by dispatch.
+type AvailabilityRequest struct {
+	Status Status `json:"status" validate:"required,oneof=offline available"`
+}
+
+type PositionRequest struct {
+	Latitude  float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude float64 `json:"longitude" validate:"min=-180,max=180"`
+}
+
+func (p *PositionRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(p)
+}
+
+func (p *PositionRequest) Point() geoUtils.Point {
+	return geoUtils.Point{Latitude: p.Latitude, Longitude: p.Longitude}
+}
+
+func (a *AvailabilityRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(a)
+}
+
+func (c *Courier) Position() geoUtils.Point {
+	return geoUtils.Point{Latitude: c.Latitude, Longitude: c.Longitude}
+}
@@ -0,0 +1,112 @@
+package deliveryModel
+
+import (
+	"errors"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"rsm/location/geoUtils"
+	"time"
+)
+
+type Status string
+
+const (
+	Unassigned Status = "unassigned"
+	Offered    Status = "offered"
+	Assigned   Status = "assigned"
+	PickedUp   Status = "picked_up"
+	Delivered  Status = "delivered"
+	// Cancelled closes the delivery of an order that was cancelled before it was picked up.
+	Cancelled Status = "cancelled"
+)
+
+type OfferStatus string
+
+const (
+	Open     OfferStatus = "open"
+	Accepted OfferStatus = "accepted"
+	Declined OfferStatus = "declined"
+	Expired  OfferStatus = "expired"
+	// Withdrawn closes an offer whose order was cancelled.
+	Withdrawn OfferStatus = "withdrawn"
+)
+
+const (
+	// OfferTimeout is how long a courier has to answer before the offer moves on.
+	OfferTimeout = 60 * time.Second
+	// DispatchRadiusKm bounds how far from the restaurant couriers are looked for.
+	DispatchRadiusKm = 15.0
+	// PositionMaxAge is how recent a courier's position must be for them to get offers.
+	PositionMaxAge = 5 * time.Minute
+	// ReofferAfter is how long a courier who declined or missed an offer is skipped for
+	// that delivery.
+	ReofferAfter = 5 * time.Minute
+)
+
+// transitions lists the moves allowed from each state. An offer that is declined or expires
+// puts the delivery back to unassigned until the next offer goes out.
+var transitions = map[Status][]Status{
+	Unassigned: {Offered, Cancelled},
+	Offered:    {Unassigned, Assigned, Cancelled},
+	Assigned:   {PickedUp, Cancelled},
+	PickedUp:   {Delivered},
+}
+
+// Delivery takes an order from its restaurant to the dropoff point. CourierId is set once a
+// courier accepts an offer.
+type Delivery struct {
+	Id           uuid.UUID      `json:"id"`
+	OrderId      uuid.UUID      `json:"orderId"`
+	RestaurantId uuid.UUID      `json:"restaurantId"`
+	CourierId    *uuid.UUID     `json:"courierId,omitempty"`
+	Status       Status         `json:"status"`
+	Pickup       geoUtils.Point `json:"pickup"`
+	Dropoff      geoUtils.Point `json:"dropoff"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	PickedUpAt   *time.Time     `json:"pickedUpAt,omitempty"`
+	DeliveredAt  *time.Time     `json:"deliveredAt,omitempty"`
+}
+
+// Offer asks one courier to take a delivery. Only one offer per delivery is open at a time.
+type Offer struct {
+	Id          uuid.UUID   `json:"id"`
+	DeliveryId  uuid.UUID   `json:"deliveryId"`
+	CourierId   uuid.UUID   `json:"courierId"`
+	Status      OfferStatus `json:"status"`
+	DistanceKm  float64     `json:"distanceKm"`
+	ExpiresAt   time.Time   `json:"expiresAt"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	RespondedAt *time.Time  `json:"respondedAt,omitempty"`
+}
+
+type DeliveryRequest struct {
+	OrderId uuid.UUID      `json:"orderId" validate:"required"`
+	Dropoff geoUtils.Point `json:"dropoff"`
+}
+
+// ValidateInput also rejects a missing dropoff, which would otherwise decode as 0,0.
+func (d *DeliveryRequest) ValidateInput() error {
+	validate := validator.New()
+	err := validate.Struct(d)
+	if err != nil {
+		return err
+	}
+	if d.Dropoff == (geoUtils.Point{}) {
+		return errors.New("dropoff is required")
+	}
+	return nil
+}
+
+func CanTransition(from, to Status) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Offer) IsExpired(now time.Time) bool {
+	return !now.Before(o.ExpiresAt)
+}
@@ -0,0 +1,32 @@
+package favoriteModel
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Favorites is everything a user saved, most recently saved first.
+type Favorites struct {
+	Restaurants []Restaurant `json:"restaurants"`
+	Items       []Item       `json:"items"`
+}
+
+type Restaurant struct {
+	RestaurantId uuid.UUID `json:"restaurantId"`
+	Name         string    `json:"name"`
+	ImageRef     string    `json:"imageRef,omitempty"`
+	SavedAt      time.Time `json:"savedAt"`
+}
+
+// Item is a saved menu item as it is listed now. Items the restaurant stopped serving stay
+// saved with Available false, in case they come back.
+type Item struct {
+	MenuId       int64     `json:"menuId"`
+	RestaurantId uuid.UUID `json:"restaurantId"`
+	Item         string    `json:"item"`
+	ItemType     string    `json:"itemType"`
+	Price        string    `json:"price"`
+	ImageRef     string    `json:"imageRef,omitempty"`
+	Available    bool      `json:"available"`
+	SavedAt      time.Time `json:"savedAt"`
+}
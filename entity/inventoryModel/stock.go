@@ -0,0 +1,184 @@
+package inventoryModel
+
+import (
+	"fmt"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"rsm/entity/orderModel"
+	"sort"
+	"time"
+)
+
+type Mode string
+
+const (
+	// Untracked items never sell out.
+	Untracked Mode = "untracked"
+	// Count items sell Servings until none are left; restocking adds to Servings.
+	Count Mode = "count"
+	// Daily items sell up to DailyLimit a day. The day starts at midnight UTC.
+	Daily Mode = "daily"
+
+	DefaultLowStockAt = 5
+)
+
+// Stock is the inventory of one menu item. SoldToday counts the servings sold on SoldOn
+// and only matters for Daily items.
+type Stock struct {
+	MenuId       int64     `json:"menuId"`
+	RestaurantId uuid.UUID `json:"restaurantId"`
+	Item         string    `json:"item"`
+	Mode         Mode      `json:"mode"`
+	Servings     int       `json:"servings"`
+	DailyLimit   int       `json:"dailyLimit"`
+	SoldToday    int       `json:"soldToday"`
+	SoldOn       time.Time `json:"soldOn"`
+	LowStockAt   int       `json:"lowStockAt"`
+}
+
+// Line is a quantity of one menu item to take from or return to stock.
+type Line struct {
+	MenuId   int64
+	Quantity int
+}
+
+type StockRequest struct {
+	Mode       Mode `json:"mode" validate:"required,oneof=untracked count daily"`
+	Servings   int  `json:"servings" validate:"min=0"`
+	DailyLimit int  `json:"dailyLimit" validate:"min=0,required_if=Mode daily"`
+	LowStockAt *int `json:"lowStockAt" validate:"omitempty,min=0"`
+}
+
+type RestockRequest struct {
+	Quantity int `json:"quantity" validate:"min=1"`
+}
+
+// OutOfStockError is returned when an order asks for more than is left of an item.
+type OutOfStockError struct {
+	MenuId    int64
+	Item      string
+	Requested int
+	Remaining int
+}
+
+func (e *OutOfStockError) Error() string {
+	if e.Remaining == 0 {
+		return fmt.Sprintf("%v is sold out", e.Item)
+	}
+	return fmt.Sprintf("only %d of %v left, %d requested", e.Remaining, e.Item, e.Requested)
+}
+
+func (s *StockRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(s)
+}
+
+func (r *RestockRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+// Day returns the start of t's day, which is when daily limits reset.
+func Day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// Remaining returns how many servings can still be sold at now. The second result is
+// false for untracked items, which have no limit.
+func (s *Stock) Remaining(now time.Time) (int, bool) {
+	switch s.Mode {
+	case Count:
+		return maxInt(s.Servings, 0), true
+	case Daily:
+		if !Day(s.SoldOn).Equal(Day(now)) {
+			return s.DailyLimit, true
+		}
+		return maxInt(s.DailyLimit-s.SoldToday, 0), true
+	}
+	return 0, false
+}
+
+// Available reports whether the item can be ordered at all. Items become unavailable by
+// themselves when they run out, and available again when restocked or on a new day.
+func (s *Stock) Available(now time.Time) bool {
+	remaining, tracked := s.Remaining(now)
+	return !tracked || remaining > 0
+}
+
+// Low reports whether a tracked item is at or below its low-stock threshold.
+func (s *Stock) Low(now time.Time) bool {
+	remaining, tracked := s.Remaining(now)
+	return tracked && remaining <= s.LowStockAt
+}
+
+// Take sells quantity servings, or returns an OutOfStockError leaving the stock unchanged.
+func (s *Stock) Take(quantity int, now time.Time) error {
+	remaining, tracked := s.Remaining(now)
+	if !tracked {
+		return nil
+	}
+	if quantity > remaining {
+		return &OutOfStockError{MenuId: s.MenuId, Item: s.Item, Requested: quantity, Remaining: remaining}
+	}
+	switch s.Mode {
+	case Count:
+		s.Servings -= quantity
+	case Daily:
+		if !Day(s.SoldOn).Equal(Day(now)) {
+			s.SoldToday, s.SoldOn = 0, Day(now)
+		}
+		s.SoldToday += quantity
+	}
+	return nil
+}
+
+// Return puts back servings taken for an order that did not go ahead. Servings sold on an
+// earlier day do not count against today's limit, so they are not returned to it.
+func (s *Stock) Return(quantity int, now time.Time) {
+	switch s.Mode {
+	case Count:
+		s.Servings += quantity
+	case Daily:
+		if Day(s.SoldOn).Equal(Day(now)) {
+			s.SoldToday = maxInt(s.SoldToday-quantity, 0)
+		}
+	}
+}
+
+// Configure switches the item to the requested mode. Counts from the previous mode are
+// dropped; the day's sales are kept so changing a daily limit does not reset it.
+func (s *Stock) Configure(request StockRequest) {
+	s.Mode = request.Mode
+	s.Servings, s.DailyLimit = 0, 0
+	switch request.Mode {
+	case Count:
+		s.Servings = request.Servings
+	case Daily:
+		s.DailyLimit = request.DailyLimit
+	}
+	if request.LowStockAt != nil {
+		s.LowStockAt = *request.LowStockAt
+	}
+}
+
+// Lines totals an order's items per menu item, ordered by menu id so rows are always
+// locked in the same order.
+func Lines(items []orderModel.OrderItem) []Line {
+	quantities := map[int64]int{}
+	for _, i := range items {
+		quantities[i.MenuId] += i.Quantity
+	}
+	lines := make([]Line, 0, len(quantities))
+	for menuId, quantity := range quantities {
+		lines = append(lines, Line{MenuId: menuId, Quantity: quantity})
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].MenuId < lines[j].MenuId })
+	return lines
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,76 @@
+package inventoryModel
+
+import (
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/orderModel"
+	"testing"
+	"time"
+)
+
+func TestStock_Take(t *testing.T) {
+	monday := time.Date(2022, time.July, 4, 20, 0, 0, 0, time.UTC)
+	tuesday := monday.Add(6 * time.Hour)
+
+	tests := []struct {
+		name          string
+		stock         Stock
+		quantity      int
+		at            time.Time
+		wantErr       bool
+		wantRemaining int
+		wantAvailable bool
+	}{
+		{name: "untracked", stock: Stock{Mode: Untracked}, quantity: 100, at: monday, wantAvailable: true},
+		{name: "count", stock: Stock{Mode: Count, Servings: 5}, quantity: 3, at: monday,
+			wantRemaining: 2, wantAvailable: true},
+		{name: "count sells out", stock: Stock{Mode: Count, Servings: 3}, quantity: 3, at: monday,
+			wantRemaining: 0},
+		{name: "count short", stock: Stock{Mode: Count, Servings: 2}, quantity: 3, at: monday,
+			wantErr: true, wantRemaining: 2, wantAvailable: true},
+		{name: "daily", stock: Stock{Mode: Daily, DailyLimit: 10, SoldToday: 4, SoldOn: Day(monday)},
+			quantity: 6, at: monday, wantRemaining: 0},
+		{name: "daily over limit", stock: Stock{Mode: Daily, DailyLimit: 10, SoldToday: 8, SoldOn: Day(monday)},
+			quantity: 3, at: monday, wantErr: true, wantRemaining: 2, wantAvailable: true},
+		{name: "daily resets at midnight", stock: Stock{Mode: Daily, DailyLimit: 10, SoldToday: 10, SoldOn: Day(monday)},
+			quantity: 3, at: tuesday, wantRemaining: 7, wantAvailable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.stock
+			err := s.Take(tt.quantity, tt.at)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Take() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			remaining, _ := s.Remaining(tt.at)
+			assert.Equal(t, tt.wantRemaining, remaining)
+			assert.Equal(t, tt.wantAvailable, s.Available(tt.at))
+		})
+	}
+}
+
+func TestStock_Return(t *testing.T) {
+	monday := time.Date(2022, time.July, 4, 20, 0, 0, 0, time.UTC)
+	count := Stock{Mode: Count, Servings: 0}
+	count.Return(2, monday)
+	assert.Equal(t, 2, count.Servings)
+
+	daily := Stock{Mode: Daily, DailyLimit: 10, SoldToday: 10, SoldOn: Day(monday)}
+	daily.Return(3, monday)
+	assert.Equal(t, 7, daily.SoldToday)
+	daily.Return(3, monday.Add(24*time.Hour))
+	assert.Equal(t, 7, daily.SoldToday)
+}
+
+func TestOutOfStockError(t *testing.T) {
+	s := Stock{MenuId: 7, Item: "Jollof", Mode: Count, Servings: 1}
+	assert.EqualError(t, s.Take(2, time.Now()), "only 1 of Jollof left, 2 requested")
+	s.Servings = 0
+	assert.EqualError(t, s.Take(1, time.Now()), "Jollof is sold out")
+}
+
+func TestLines(t *testing.T) {
+	lines := Lines([]orderModel.OrderItem{
+		{MenuId: 9, Quantity: 1}, {MenuId: 3, Quantity: 2}, {MenuId: 9, Quantity: 2},
+	})
+	assert.Equal(t, []Line{{MenuId: 3, Quantity: 2}, {MenuId: 9, Quantity: 3}}, lines)
+}
@@ -0,0 +1,200 @@
+package kitchenModel
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"rsm/entity/orderModel"
+	"sort"
+	"strings"
+	"time"
+)
+
+type TicketStatus string
+
+const (
+	Queued    TicketStatus = "queued"
+	Preparing TicketStatus = "preparing"
+	Done      TicketStatus = "done"
+)
+
+type Action string
+
+const (
+	Start  Action = "start"
+	Bump   Action = "bump"
+	Recall Action = "recall"
+
+	// Added and Cleared only appear in events, when an order enters or leaves the queue.
+	Added   Action = "added"
+	Cleared Action = "cleared"
+)
+
+const (
+	// DefaultStation takes items that have no item type.
+	DefaultStation = "general"
+	// LateAfter is how long a ticket may wait, from the order being placed, before it is
+	// shown as late.
+	LateAfter = 20 * time.Minute
+)
+
+// QueueStatuses are the order states the kitchen still has work for, or may recall.
+var QueueStatuses = []orderModel.Status{orderModel.Accepted, orderModel.Preparing, orderModel.Ready}
+
+// moves lists where each action takes a ticket from each state. Bumping a queued ticket
+// skips preparing for items that need no work.
+var moves = map[Action]map[TicketStatus]TicketStatus{
+	Start:  {Queued: Preparing},
+	Bump:   {Queued: Done, Preparing: Done},
+	Recall: {Done: Preparing},
+}
+
+// TicketState is what the kitchen recorded for one station's part of an order. No record
+// means the ticket is still queued.
+type TicketState struct {
+	OrderId   uuid.UUID    `json:"orderId"`
+	Station   string       `json:"station"`
+	Status    TicketStatus `json:"status"`
+	StartedAt *time.Time   `json:"startedAt,omitempty"`
+	BumpedAt  *time.Time   `json:"bumpedAt,omitempty"`
+}
+
+// Ticket is one station's part of an order: the items it has to make and how long they have
+// taken so far.
+type Ticket struct {
+	TicketState
+	RestaurantId uuid.UUID              `json:"restaurantId"`
+	OrderStatus  orderModel.Status      `json:"orderStatus"`
+	Items        []orderModel.OrderItem `json:"items"`
+	ReceivedAt   time.Time              `json:"receivedAt"`
+	Timer        Timer                  `json:"timer"`
+}
+
+// Timer is measured when the ticket is read. Preparing counts from the first start until
+// the ticket was bumped, or until now if it is still being made.
+type Timer struct {
+	WaitingSeconds   int64 `json:"waitingSeconds"`
+	PreparingSeconds int64 `json:"preparingSeconds"`
+	Late             bool  `json:"late"`
+}
+
+type Station struct {
+	Name    string   `json:"name"`
+	Tickets []Ticket `json:"tickets"`
+}
+
+// TicketEvent is pushed to kitchen screens whenever a ticket or its order changes.
+type TicketEvent struct {
+	Action Action    `json:"action"`
+	Ticket Ticket    `json:"ticket"`
+	At     time.Time `json:"at"`
+}
+
+// StationFor derives the station that prepares an item from its item type.
+func StationFor(itemType string) string {
+	station := strings.ToLower(strings.TrimSpace(itemType))
+	if station == "" {
+		return DefaultStation
+	}
+	return station
+}
+
+// Next returns the state action moves a ticket to from status.
+func Next(status TicketStatus, action Action) (TicketStatus, error) {
+	next, ok := moves[action][status]
+	if !ok {
+		return "", fmt.Errorf("cannot %v a %v ticket", action, status)
+	}
+	return next, nil
+}
+
+// Tickets splits an order into one ticket per station, applying whatever state the kitchen
+// has recorded for each. Tickets come back sorted by station.
+func Tickets(order orderModel.Order, states []TicketState, now time.Time) []Ticket {
+	byStation := map[string]*Ticket{}
+	var stations []string
+	for _, item := range order.Items {
+		station := StationFor(item.ItemType)
+		t, ok := byStation[station]
+		if !ok {
+			t = &Ticket{
+				TicketState:  TicketState{OrderId: order.Id, Station: station, Status: Queued},
+				RestaurantId: order.RestaurantId,
+				OrderStatus:  order.Status,
+				ReceivedAt:   order.CreatedAt,
+			}
+			byStation[station] = t
+			stations = append(stations, station)
+		}
+		t.Items = append(t.Items, item)
+	}
+	for _, s := range states {
+		if t, ok := byStation[s.Station]; ok && s.OrderId == order.Id {
+			t.TicketState = s
+		}
+	}
+
+	sort.Strings(stations)
+	tickets := make([]Ticket, 0, len(stations))
+	for _, station := range stations {
+		t := byStation[station]
+		t.Timer = t.timer(now)
+		tickets = append(tickets, *t)
+	}
+	return tickets
+}
+
+func (t *Ticket) timer(now time.Time) Timer {
+	end := now
+	if t.Status == Done && t.BumpedAt != nil {
+		end = *t.BumpedAt
+	}
+	timer := Timer{WaitingSeconds: int64(end.Sub(t.ReceivedAt) / time.Second)}
+	if t.StartedAt != nil {
+		timer.PreparingSeconds = int64(end.Sub(*t.StartedAt) / time.Second)
+	}
+	timer.Late = t.Status != Done && now.Sub(t.ReceivedAt) > LateAfter
+	return timer
+}
+
+// OrderStatus is the state an accepted order should be in given its tickets: ready once
+// every ticket is done, preparing once any work has started.
+func OrderStatus(tickets []Ticket) orderModel.Status {
+	done, started := 0, 0
+	for _, t := range tickets {
+		switch t.Status {
+		case Done:
+			done++
+			started++
+		case Preparing:
+			started++
+		}
+	}
+	switch {
+	case len(tickets) > 0 && done == len(tickets):
+		return orderModel.Ready
+	case started > 0:
+		return orderModel.Preparing
+	default:
+		return orderModel.Accepted
+	}
+}
+
+// Group arranges tickets by station, each station's tickets oldest first.
+func Group(tickets []Ticket) []Station {
+	index := map[string]int{}
+	var stations []Station
+	for _, t := range tickets {
+		i, ok := index[t.Station]
+		if !ok {
+			i = len(stations)
+			index[t.Station] = i
+			stations = append(stations, Station{Name: t.Station})
+		}
+		stations[i].Tickets = append(stations[i].Tickets, t)
+	}
+	sort.Slice(stations, func(i, j int) bool { return stations[i].Name < stations[j].Name })
+	for _, s := range stations {
+		sort.SliceStable(s.Tickets, func(i, j int) bool { return s.Tickets[i].ReceivedAt.Before(s.Tickets[j].ReceivedAt) })
+	}
+	return stations
+}
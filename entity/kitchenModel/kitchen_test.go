@@ -0,0 +1,83 @@
+package kitchenModel
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/orderModel"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	next, err := Next(Queued, Start)
+	assert.Nil(t, err)
+	assert.Equal(t, Preparing, next)
+
+	next, err = Next(Queued, Bump)
+	assert.Nil(t, err)
+	assert.Equal(t, Done, next)
+
+	next, err = Next(Done, Recall)
+	assert.Nil(t, err)
+	assert.Equal(t, Preparing, next)
+
+	_, err = Next(Preparing, Recall)
+	assert.Equal(t, fmt.Errorf("cannot recall a preparing ticket"), err)
+}
+
+func TestTickets(t *testing.T) {
+	received := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	started := received.Add(5 * time.Minute)
+	bumped := received.Add(9 * time.Minute)
+	now := received.Add(25 * time.Minute)
+	order := orderModel.Order{Id: uuid.New(), Status: orderModel.Preparing, CreatedAt: received,
+		Items: []orderModel.OrderItem{
+			{Id: 1, ItemType: "Grill"},
+			{Id: 2, ItemType: "drinks"},
+			{Id: 3, ItemType: " grill "},
+			{Id: 4, ItemType: ""},
+		}}
+	states := []TicketState{
+		{OrderId: order.Id, Station: "drinks", Status: Done, StartedAt: &started, BumpedAt: &bumped},
+		{OrderId: order.Id, Station: "grill", Status: Preparing, StartedAt: &started},
+		{OrderId: uuid.New(), Station: "general", Status: Done},
+	}
+
+	tickets := Tickets(order, states, now)
+	assert.Len(t, tickets, 3)
+
+	drinks, general, grill := tickets[0], tickets[1], tickets[2]
+	assert.Equal(t, "drinks", drinks.Station)
+	assert.Equal(t, Timer{WaitingSeconds: 540, PreparingSeconds: 240}, drinks.Timer)
+
+	assert.Equal(t, DefaultStation, general.Station)
+	assert.Equal(t, Queued, general.Status)
+	assert.Equal(t, Timer{WaitingSeconds: 1500, Late: true}, general.Timer)
+
+	assert.Equal(t, "grill", grill.Station)
+	assert.Len(t, grill.Items, 2)
+	assert.Equal(t, Timer{WaitingSeconds: 1500, PreparingSeconds: 1200, Late: true}, grill.Timer)
+}
+
+func TestOrderStatus(t *testing.T) {
+	ticket := func(status TicketStatus) Ticket {
+		return Ticket{TicketState: TicketState{Status: status}}
+	}
+	assert.Equal(t, orderModel.Accepted, OrderStatus([]Ticket{ticket(Queued), ticket(Queued)}))
+	assert.Equal(t, orderModel.Preparing, OrderStatus([]Ticket{ticket(Done), ticket(Queued)}))
+	assert.Equal(t, orderModel.Preparing, OrderStatus([]Ticket{ticket(Preparing), ticket(Queued)}))
+	assert.Equal(t, orderModel.Ready, OrderStatus([]Ticket{ticket(Done), ticket(Done)}))
+}
+
+func TestGroup(t *testing.T) {
+	early := time.Now().Add(-time.Hour)
+	late := time.Now()
+	stations := Group([]Ticket{
+		{TicketState: TicketState{Station: "grill"}, ReceivedAt: late},
+		{TicketState: TicketState{Station: "bar"}, ReceivedAt: late},
+		{TicketState: TicketState{Station: "grill"}, ReceivedAt: early},
+	})
+	assert.Equal(t, []string{"bar", "grill"}, []string{stations[0].Name, stations[1].Name})
+	assert.Equal(t, early, stations[1].Tickets[0].ReceivedAt)
+}
@@ -0,0 +1,105 @@
+package ledgerModel
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"rsm/entity/moneyModel"
+	"time"
+)
+
+type AccountType string
+
+const (
+	// Customer accounts hold what customers have paid in through the payment provider.
+	Customer AccountType = "customer"
+	// Restaurant accounts hold what the platform owes each restaurant.
+	Restaurant  AccountType = "restaurant"
+	PlatformFee AccountType = "platform_fee"
+	Tax         AccountType = "tax"
+	// Payout is the clearing account money leaves through when restaurants are paid.
+	Payout AccountType = "payout"
+)
+
+// Account is one balance in one currency. OwnerId is the user for customer accounts, the
+// restaurant for restaurant accounts and uuid.Nil for platform-wide accounts.
+type Account struct {
+	Id        uuid.UUID   `json:"id"`
+	Type      AccountType `json:"type"`
+	OwnerId   uuid.UUID   `json:"ownerId"`
+	Currency  string      `json:"currency"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// Posting moves Amount into (positive, debit) or out of (negative, credit) an account.
+type Posting struct {
+	AccountId uuid.UUID        `json:"accountId"`
+	Amount    moneyModel.Money `json:"amount"`
+}
+
+// JournalEntry is an immutable, balanced set of postings. EffectiveAt decides which
+// balances the entry counts towards; CreatedAt is when it was written. Reference names what
+// the entry is for and is unique, so the same thing is never posted twice.
+type JournalEntry struct {
+	Id          uuid.UUID `json:"id"`
+	Description string    `json:"description"`
+	Reference   string    `json:"reference"`
+	Postings    []Posting `json:"postings"`
+	EffectiveAt time.Time `json:"effectiveAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// PayoutBatch settles what a restaurant was owed at the end of a period.
+type PayoutBatch struct {
+	Id           uuid.UUID        `json:"id"`
+	RestaurantId uuid.UUID        `json:"restaurantId"`
+	PeriodStart  time.Time        `json:"periodStart"`
+	PeriodEnd    time.Time        `json:"periodEnd"`
+	Amount       moneyModel.Money `json:"amount"`
+	EntryId      uuid.UUID        `json:"entryId"`
+	CreatedAt    time.Time        `json:"createdAt"`
+}
+
+// Settlement splits a captured order payment between the restaurant, the platform fee and tax.
+type Settlement struct {
+	OrderId      uuid.UUID        `json:"orderId"`
+	CustomerId   uuid.UUID        `json:"customerId"`
+	RestaurantId uuid.UUID        `json:"restaurantId"`
+	Gross        moneyModel.Money `json:"gross"`
+	PlatformFee  moneyModel.Money `json:"platformFee"`
+	Tax          moneyModel.Money `json:"tax"`
+}
+
+// Validate checks the entry has a reference and at least two non-zero postings in one
+// currency that sum to zero.
+func (j *JournalEntry) Validate() error {
+	if j.Reference == "" {
+		return fmt.Errorf("journal entry needs a reference")
+	}
+	if len(j.Postings) < 2 {
+		return fmt.Errorf("journal entry needs at least two postings")
+	}
+	currency := j.Postings[0].Amount.Currency
+	var sum int64
+	for _, p := range j.Postings {
+		if p.Amount.Currency != currency {
+			return fmt.Errorf("journal entry mixes %v and %v", currency, p.Amount.Currency)
+		}
+		if p.Amount.IsZero() {
+			return fmt.Errorf("journal entry has a zero posting")
+		}
+		sum += p.Amount.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("journal entry is unbalanced by %v", moneyModel.New(sum, currency))
+	}
+	return nil
+}
+
+// RestaurantShare is what is left for the restaurant after fee and tax.
+func (s *Settlement) RestaurantShare() (moneyModel.Money, error) {
+	share, err := s.Gross.Sub(s.PlatformFee)
+	if err != nil {
+		return moneyModel.Money{}, err
+	}
+	return share.Sub(s.Tax)
+}
@@ -0,0 +1,52 @@
+package ledgerModel
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/moneyModel"
+	"testing"
+)
+
+func TestJournalEntry_Validate(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	ngn := func(amount int64) moneyModel.Money { return moneyModel.New(amount, "NGN") }
+
+	tests := []struct {
+		name     string
+		postings []Posting
+		wantErr  bool
+	}{
+		{
+			name:     "balanced pair",
+			postings: []Posting{{a, ngn(500)}, {b, ngn(-500)}},
+		}, {
+			name:     "balanced split",
+			postings: []Posting{{a, ngn(1000)}, {b, ngn(-850)}, {c, ngn(-150)}},
+		}, {
+			name:     "unbalanced",
+			postings: []Posting{{a, ngn(1000)}, {b, ngn(-999)}},
+			wantErr:  true,
+		}, {
+			name:     "single posting",
+			postings: []Posting{{a, ngn(0)}},
+			wantErr:  true,
+		}, {
+			name:     "zero posting",
+			postings: []Posting{{a, ngn(100)}, {b, ngn(-100)}, {c, ngn(0)}},
+			wantErr:  true,
+		}, {
+			name:     "mixed currency",
+			postings: []Posting{{a, ngn(100)}, {b, moneyModel.New(-100, "USD")}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := JournalEntry{Reference: "manual:1", Postings: tt.postings}
+			assert.Equal(t, tt.wantErr, entry.Validate() != nil)
+		})
+	}
+
+	unreferenced := JournalEntry{Postings: []Posting{{a, ngn(500)}, {b, ngn(-500)}}}
+	assert.NotNil(t, unreferenced.Validate())
+}
@@ -0,0 +1,187 @@
+package loyaltyModel
+
+import (
+	"errors"
+	"fmt"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"rsm/entity/promotionModel"
+	"strings"
+	"time"
+)
+
+// ErrInsufficientPoints means the user's balance does not cover the reward.
+var ErrInsufficientPoints = errors.New("not enough loyalty points")
+
+type Kind string
+
+const (
+	// Earn entries add points for a completed order. They are the lots later entries take
+	// points from, soonest expiring first.
+	Earn Kind = "earn"
+	// Redeem entries take points for a reward used on an order.
+	Redeem Kind = "redeem"
+	// Expire entries take what was left of a lot once it expired.
+	Expire Kind = "expire"
+	// Clawback entries take back points earned on an order that was refunded or cancelled,
+	// as far as they are still unspent.
+	Clawback Kind = "clawback"
+	// Restore entries give back the points spent on an order's reward once the order is
+	// cancelled or refunded in full. They are lots like earn entries, and expire when the
+	// soonest expiring of the lots the reward was paid from would have.
+	Restore Kind = "restore"
+)
+
+// Lots are the kinds of entries points are spent from.
+var Lots = []Kind{Earn, Restore}
+
+const (
+	DefaultLimit = 20
+	// ExpiringWindow is how far ahead the balance warns of points about to expire.
+	ExpiringWindow = 30 * 24 * time.Hour
+)
+
+type RewardType string
+
+const (
+	// Discount takes Value minor units off the order, never more than it costs.
+	Discount RewardType = "discount"
+	// FreeItem makes one unit of FreeMenuId free when it is in the order.
+	FreeItem RewardType = "free_item"
+)
+
+// EarnRule sets how many points orders at a restaurant, or at every location of a brand,
+// earn: Points for each full Per minor units of the order total in Currency. A restaurant's
+// own rule wins over its brand's. Points expire ExpiryDays after they are earned, or never
+// when it is zero.
+type EarnRule struct {
+	Id           uuid.UUID  `json:"id"`
+	RestaurantId *uuid.UUID `json:"restaurantId,omitempty" validate:"required_without=BrandId,excluded_with=BrandId"`
+	BrandId      *uuid.UUID `json:"brandId,omitempty"`
+	Currency     string     `json:"currency" validate:"required,len=3,alpha"`
+	Points       int64      `json:"points" validate:"min=1"`
+	Per          int64      `json:"per" validate:"min=1"`
+	ExpiryDays   int        `json:"expiryDays" validate:"min=0,max=3650"`
+	Active       bool       `json:"active"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// Reward is an item of the catalogue users spend points on at checkout. It can be used at
+// RestaurantId, at any location of BrandId, or anywhere when neither is set.
+type Reward struct {
+	Id           uuid.UUID  `json:"id"`
+	RestaurantId *uuid.UUID `json:"restaurantId,omitempty" validate:"excluded_with=BrandId"`
+	BrandId      *uuid.UUID `json:"brandId,omitempty"`
+	Name         string     `json:"name" validate:"required,max=100"`
+	Cost         int64      `json:"cost" validate:"min=1"`
+	Type         RewardType `json:"type" validate:"required,oneof=discount free_item"`
+	Value        int64      `json:"value" validate:"min=0"`
+	Currency     string     `json:"currency" validate:"required,len=3,alpha"`
+	FreeMenuId   *int64     `json:"freeMenuId,omitempty" validate:"required_if=Type free_item"`
+	Active       bool       `json:"active"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// Entry is one line of a user's points ledger. Points is positive for lots and
+// negative otherwise. Remaining is what is left of a lot's points to spend. A redeem entry
+// expires with the first lot it took from, so the points it restores do not outlive it.
+type Entry struct {
+	Id           uuid.UUID  `json:"id"`
+	UserId       uuid.UUID  `json:"userId"`
+	Kind         Kind       `json:"kind"`
+	Points       int64      `json:"points"`
+	Remaining    int64      `json:"remaining,omitempty"`
+	OrderId      *uuid.UUID `json:"orderId,omitempty"`
+	RestaurantId *uuid.UUID `json:"restaurantId,omitempty"`
+	RewardId     *uuid.UUID `json:"rewardId,omitempty"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// Balance is what a user can spend now. Expiring counts the points due to expire before
+// ExpiringBy.
+type Balance struct {
+	UserId     uuid.UUID `json:"userId"`
+	Points     int64     `json:"points"`
+	Expiring   int64     `json:"expiring"`
+	ExpiringBy time.Time `json:"expiringBy"`
+}
+
+func (r *EarnRule) ValidateInput() error {
+	r.Currency = strings.ToUpper(r.Currency)
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *Reward) ValidateInput() error {
+	r.Currency = strings.ToUpper(r.Currency)
+	validate := validator.New()
+	err := validate.Struct(r)
+	if err != nil {
+		return err
+	}
+	if r.Type == Discount && r.Value == 0 {
+		return fmt.Errorf("discount reward must be worth something")
+	}
+	return nil
+}
+
+// Earned is how many points the rule gives for an order total in currency. Orders in
+// another currency earn nothing.
+func (r *EarnRule) Earned(total int64, currency string) int64 {
+	if !r.Active || currency != r.Currency || total <= 0 {
+		return 0
+	}
+	return total / r.Per * r.Points
+}
+
+// Kept is how many of points earned on an order total are kept once refunded of it has
+// been refunded.
+func Kept(points, total, refunded int64) int64 {
+	if refunded <= 0 {
+		return points
+	}
+	if refunded >= total {
+		return 0
+	}
+	return points * (total - refunded) / total
+}
+
+// ExpiresAt is when points earned at the given time expire, or nil if they never do.
+func (r *EarnRule) ExpiresAt(earned time.Time) *time.Time {
+	if r.ExpiryDays == 0 {
+		return nil
+	}
+	at := earned.AddDate(0, 0, r.ExpiryDays)
+	return &at
+}
+
+// AppliesAt reports whether the reward can be used at the restaurant, which belongs to
+// brandId or to no brand when brandId is nil.
+func (r *Reward) AppliesAt(restaurantId uuid.UUID, brandId *uuid.UUID) bool {
+	switch {
+	case r.RestaurantId != nil:
+		return *r.RestaurantId == restaurantId
+	case r.BrandId != nil:
+		return brandId != nil && *r.BrandId == *brandId
+	}
+	return true
+}
+
+// Promotion is the reward as a promotion, so it is priced the same way as promotions are.
+func (r *Reward) Promotion() promotionModel.Promotion {
+	promotion := promotionModel.Promotion{
+		Id:           r.Id,
+		Name:         r.Name,
+		Type:         promotionModel.Fixed,
+		Value:        r.Value,
+		Currency:     r.Currency,
+		FreeMenuId:   r.FreeMenuId,
+		RestaurantId: r.RestaurantId,
+		Active:       r.Active,
+	}
+	if r.Type == FreeItem {
+		promotion.Type = promotionModel.FreeItem
+	}
+	return promotion
+}
@@ -0,0 +1,96 @@
+package loyaltyModel
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/promotionModel"
+	"testing"
+	"time"
+)
+
+func TestEarnRule_ValidateInput(t *testing.T) {
+	restaurantId, brandId := uuid.New(), uuid.New()
+	valid := EarnRule{RestaurantId: &restaurantId, Currency: "ngn", Points: 1, Per: 10000}
+	assert.Nil(t, valid.ValidateInput())
+	assert.Equal(t, "NGN", valid.Currency)
+
+	noScope := EarnRule{Currency: "NGN", Points: 1, Per: 10000}
+	assert.NotNil(t, noScope.ValidateInput())
+
+	bothScopes := valid
+	bothScopes.BrandId = &brandId
+	assert.NotNil(t, bothScopes.ValidateInput())
+
+	zeroPer := valid
+	zeroPer.Per = 0
+	assert.NotNil(t, zeroPer.ValidateInput())
+}
+
+func TestReward_ValidateInput(t *testing.T) {
+	menuId := int64(3)
+	discount := Reward{Name: "N500 off", Cost: 500, Type: Discount, Value: 50000, Currency: "NGN"}
+	assert.Nil(t, discount.ValidateInput())
+
+	worthless := discount
+	worthless.Value = 0
+	assert.NotNil(t, worthless.ValidateInput())
+
+	free := Reward{Name: "Free zobo", Cost: 200, Type: FreeItem, Currency: "NGN", FreeMenuId: &menuId}
+	assert.Nil(t, free.ValidateInput())
+	free.FreeMenuId = nil
+	assert.NotNil(t, free.ValidateInput())
+}
+
+func TestEarnRule_Earned(t *testing.T) {
+	rule := EarnRule{Currency: "NGN", Points: 2, Per: 10000, Active: true}
+	assert.Equal(t, int64(6), rule.Earned(39999, "NGN"))
+	assert.Equal(t, int64(0), rule.Earned(9999, "NGN"))
+	assert.Equal(t, int64(0), rule.Earned(39999, "USD"))
+
+	rule.Active = false
+	assert.Equal(t, int64(0), rule.Earned(39999, "NGN"))
+}
+
+func TestKept(t *testing.T) {
+	assert.Equal(t, int64(10), Kept(10, 40000, 0))
+	assert.Equal(t, int64(7), Kept(10, 40000, 10000))
+	assert.Equal(t, int64(0), Kept(10, 40000, 40000))
+	assert.Equal(t, int64(0), Kept(10, 40000, 50000))
+}
+
+func TestEarnRule_ExpiresAt(t *testing.T) {
+	earned := time.Date(2022, time.January, 31, 12, 0, 0, 0, time.UTC)
+	rule := EarnRule{ExpiryDays: 30}
+	assert.Equal(t, time.Date(2022, time.March, 2, 12, 0, 0, 0, time.UTC), *rule.ExpiresAt(earned))
+
+	rule.ExpiryDays = 0
+	assert.Nil(t, rule.ExpiresAt(earned))
+}
+
+func TestReward_AppliesAt(t *testing.T) {
+	restaurantId, brandId := uuid.New(), uuid.New()
+	other := uuid.New()
+
+	anywhere := Reward{}
+	assert.True(t, anywhere.AppliesAt(restaurantId, nil))
+
+	own := Reward{RestaurantId: &restaurantId}
+	assert.True(t, own.AppliesAt(restaurantId, nil))
+	assert.False(t, own.AppliesAt(other, nil))
+
+	brand := Reward{BrandId: &brandId}
+	assert.True(t, brand.AppliesAt(other, &brandId))
+	assert.False(t, brand.AppliesAt(restaurantId, nil))
+	assert.False(t, brand.AppliesAt(restaurantId, &other))
+}
+
+func TestReward_Promotion(t *testing.T) {
+	menuId := int64(3)
+	free := Reward{Id: uuid.New(), Name: "Free zobo", Type: FreeItem, Currency: "NGN", FreeMenuId: &menuId}
+	assert.Equal(t, promotionModel.FreeItem, free.Promotion().Type)
+	assert.Equal(t, free.Id, free.Promotion().Id)
+
+	discount := Reward{Type: Discount, Value: 500}
+	assert.Equal(t, promotionModel.Fixed, discount.Promotion().Type)
+	assert.Equal(t, int64(500), discount.Promotion().Value)
+}
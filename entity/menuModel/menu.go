@@ -0,0 +1,153 @@
+package menuModel
+
+import (
+	"fmt"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Allergen is one of the 14 allergens EU Regulation 1169/2011 requires menus to declare.
+type Allergen string
+
+const (
+	Celery      Allergen = "celery"
+	Gluten      Allergen = "gluten"
+	Crustaceans Allergen = "crustaceans"
+	Eggs        Allergen = "eggs"
+	Fish        Allergen = "fish"
+	Lupin       Allergen = "lupin"
+	Milk        Allergen = "milk"
+	Molluscs    Allergen = "molluscs"
+	Mustard     Allergen = "mustard"
+	Nuts        Allergen = "nuts"
+	Peanuts     Allergen = "peanuts"
+	Sesame      Allergen = "sesame"
+	Soybeans    Allergen = "soybeans"
+	Sulphites   Allergen = "sulphites"
+)
+
+// Allergens lists all 14 in the order the regulation does.
+var Allergens = []Allergen{Celery, Gluten, Crustaceans, Eggs, Fish, Lupin, Milk, Molluscs, Mustard, Nuts, Peanuts,
+	Sesame, Soybeans, Sulphites}
+
+type DietaryTag string
+
+const (
+	Vegan      DietaryTag = "vegan"
+	Vegetarian DietaryTag = "vegetarian"
+	Halal      DietaryTag = "halal"
+	GlutenFree DietaryTag = "gluten_free"
+)
+
+// ruledOut lists the allergens an item with the tag cannot contain. Vegetarian items may
+// still contain milk and eggs.
+var ruledOut = map[DietaryTag][]Allergen{
+	Vegan:      {Crustaceans, Eggs, Fish, Milk, Molluscs},
+	Vegetarian: {Crustaceans, Fish, Molluscs},
+	GlutenFree: {Gluten},
+}
+
+// Nutrition is per serving. An item's Servings says how many servings one ordered unit
+// holds, so the facts for an order line are these times Servings times its quantity.
+type Nutrition struct {
+	EnergyKcal    int     `json:"energyKcal" validate:"min=0"`
+	ProteinG      float64 `json:"proteinG" validate:"min=0"`
+	CarbohydrateG float64 `json:"carbohydrateG" validate:"min=0"`
+	SugarsG       float64 `json:"sugarsG" validate:"min=0,ltefield=CarbohydrateG"`
+	FatG          float64 `json:"fatG" validate:"min=0"`
+	SaturatesG    float64 `json:"saturatesG" validate:"min=0,ltefield=FatG"`
+	FibreG        float64 `json:"fibreG" validate:"min=0"`
+	SaltG         float64 `json:"saltG" validate:"min=0"`
+}
+
+// MenuItem is a row of the Menu table. Price is a decimal in major units of the
+// restaurant's currency, e.g. "12.50". Code is the restaurant's own item code, set by imports.
+// Allergens is nil until the restaurant declares them; an empty list declares none.
+type MenuItem struct {
+	Id           int64        `json:"id"`
+	RestaurantId uuid.UUID    `json:"restaurantId"`
+	Code         string       `json:"code,omitempty"`
+	Item         string       `json:"item"`
+	Price        string       `json:"price"`
+	ItemType     string       `json:"itemType"`
+	Allergens    []Allergen   `json:"allergens"`
+	Dietary      []DietaryTag `json:"dietary"`
+	Nutrition    *Nutrition   `json:"nutrition,omitempty"`
+	Servings     *int         `json:"servings,omitempty"`
+	ImageRef     string       `json:"imageRef,omitempty"`
+	CreatedAt    time.Time    `json:"createdAt"`
+}
+
+// DietaryInfo replaces everything an item declares about what is in it. Leaving Allergens
+// out withdraws the declaration; an empty list declares the item free of all of them.
+type DietaryInfo struct {
+	Allergens []Allergen   `json:"allergens" validate:"unique,dive,oneof=celery gluten crustaceans eggs fish lupin milk molluscs mustard nuts peanuts sesame soybeans sulphites"`
+	Dietary   []DietaryTag `json:"dietary" validate:"unique,dive,oneof=vegan vegetarian halal gluten_free"`
+	Nutrition *Nutrition   `json:"nutrition"`
+	Servings  *int         `json:"servings" validate:"omitempty,min=1"`
+}
+
+// MenuQuery lists a restaurant's menu. Items containing any of ExcludeAllergens are left
+// out, and so are items whose allergens were never declared, since they cannot be shown
+// to be free of them. Only items carrying every tag in Dietary are kept.
+type MenuQuery struct {
+	RestaurantId     uuid.UUID    `json:"restaurantId" validate:"required"`
+	ItemType         string       `json:"itemType"`
+	ExcludeAllergens []Allergen   `json:"excludeAllergens" validate:"dive,oneof=celery gluten crustaceans eggs fish lupin milk molluscs mustard nuts peanuts sesame soybeans sulphites"`
+	Dietary          []DietaryTag `json:"dietary" validate:"dive,oneof=vegan vegetarian halal gluten_free"`
+}
+
+// ValidateInput also rejects tags the allergens contradict, such as a vegan dish with milk.
+func (d *DietaryInfo) ValidateInput() error {
+	validate := validator.New()
+	err := validate.Struct(d)
+	if err != nil {
+		return err
+	}
+	for _, tag := range d.Dietary {
+		for _, a := range ruledOut[tag] {
+			if ContainsAllergen(d.Allergens, a) {
+				return fmt.Errorf("an item containing %v cannot be %v", a, tag)
+			}
+		}
+	}
+	return nil
+}
+
+func (q *MenuQuery) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(q)
+}
+
+// PerItem is the nutrition of one ordered unit: the per-serving facts times Servings.
+// Items without Servings are one serving.
+func (m *MenuItem) PerItem() *Nutrition {
+	if m.Nutrition == nil {
+		return nil
+	}
+	if m.Servings == nil {
+		n := *m.Nutrition
+		return &n
+	}
+	k := float64(*m.Servings)
+	return &Nutrition{
+		EnergyKcal:    m.Nutrition.EnergyKcal * *m.Servings,
+		ProteinG:      m.Nutrition.ProteinG * k,
+		CarbohydrateG: m.Nutrition.CarbohydrateG * k,
+		SugarsG:       m.Nutrition.SugarsG * k,
+		FatG:          m.Nutrition.FatG * k,
+		SaturatesG:    m.Nutrition.SaturatesG * k,
+		FibreG:        m.Nutrition.FibreG * k,
+		SaltG:         m.Nutrition.SaltG * k,
+	}
+}
+
+func ContainsAllergen(allergens []Allergen, a Allergen) bool {
+	for _, x := range allergens {
+		if x == a {
+			return true
+		}
+	}
+	return false
+}
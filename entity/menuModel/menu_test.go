@@ -0,0 +1,61 @@
+package menuModel
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDietaryInfo_ValidateInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    DietaryInfo
+		wantErr bool
+	}{
+		{name: "nothing declared", info: DietaryInfo{}},
+		{name: "vegan and gluten free", info: DietaryInfo{Allergens: []Allergen{Soybeans, Sesame},
+			Dietary: []DietaryTag{Vegan, GlutenFree, Halal}}},
+		{name: "vegetarian with milk and eggs", info: DietaryInfo{Allergens: []Allergen{Milk, Eggs},
+			Dietary: []DietaryTag{Vegetarian}}},
+		{name: "with nutrition", info: DietaryInfo{Nutrition: &Nutrition{EnergyKcal: 540, ProteinG: 21.5,
+			CarbohydrateG: 60, SugarsG: 4.2, FatG: 22, SaturatesG: 6.1, FibreG: 3, SaltG: 1.4}}},
+		{name: "vegan with milk", info: DietaryInfo{Allergens: []Allergen{Milk}, Dietary: []DietaryTag{Vegan}},
+			wantErr: true},
+		{name: "vegetarian with fish", info: DietaryInfo{Allergens: []Allergen{Fish},
+			Dietary: []DietaryTag{Vegetarian}}, wantErr: true},
+		{name: "gluten free with gluten", info: DietaryInfo{Allergens: []Allergen{Gluten},
+			Dietary: []DietaryTag{GlutenFree}}, wantErr: true},
+		{name: "not an EU allergen", info: DietaryInfo{Allergens: []Allergen{"strawberries"}}, wantErr: true},
+		{name: "repeated allergen", info: DietaryInfo{Allergens: []Allergen{Nuts, Nuts}}, wantErr: true},
+		{name: "unknown tag", info: DietaryInfo{Dietary: []DietaryTag{"paleo"}}, wantErr: true},
+		{name: "more sugar than carbohydrate", info: DietaryInfo{Nutrition: &Nutrition{CarbohydrateG: 10,
+			SugarsG: 12}}, wantErr: true},
+		{name: "negative salt", info: DietaryInfo{Nutrition: &Nutrition{SaltG: -1}}, wantErr: true},
+		{name: "no servings", info: DietaryInfo{Servings: new(int)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.info.ValidateInput()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateInput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAllergens(t *testing.T) {
+	assert.Len(t, Allergens, 14)
+	for _, a := range Allergens {
+		info := DietaryInfo{Allergens: []Allergen{a}}
+		assert.Nil(t, info.ValidateInput(), a)
+	}
+}
+
+func TestMenuItem_PerItem(t *testing.T) {
+	perServing := &Nutrition{EnergyKcal: 250, ProteinG: 8.5, SaltG: 0.4}
+	assert.Nil(t, (&MenuItem{}).PerItem())
+	assert.Equal(t, perServing, (&MenuItem{Nutrition: perServing}).PerItem())
+
+	servings := 4
+	assert.Equal(t, &Nutrition{EnergyKcal: 1000, ProteinG: 34, SaltG: 1.6},
+		(&MenuItem{Nutrition: perServing, Servings: &servings}).PerItem())
+}
@@ -0,0 +1,229 @@
+package menuModel
+
+import (
+	"errors"
+	"fmt"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"math/big"
+	"rsm/entity/restaurantModel"
+	"sort"
+	"time"
+)
+
+const DateLayout = "2006-01-02"
+
+var (
+	// ErrNotServed means the item is on none of the menus served at the time of ordering.
+	ErrNotServed = errors.New("item is not on the menu at this time")
+	// ErrOverlappingMenus means two of a restaurant's named menus would be served at once.
+	ErrOverlappingMenus = errors.New("named menus overlap")
+)
+
+type VersionStatus string
+
+const (
+	Draft     VersionStatus = "draft"
+	Published VersionStatus = "published"
+)
+
+// NamedMenu is a menu such as breakfast or a summer menu, served during Hours between
+// StartsOn and EndsOn inclusive. No hours means all day and no dates means all year. Hours
+// and dates are the restaurant's wall-clock time, as for its opening hours.
+type NamedMenu struct {
+	Id           uuid.UUID                      `json:"id"`
+	RestaurantId uuid.UUID                      `json:"restaurantId" validate:"required"`
+	Name         string                         `json:"name" validate:"required,max=100"`
+	Hours        []restaurantModel.OpeningHours `json:"hours"`
+	StartsOn     string                         `json:"startsOn" validate:"omitempty,datetime=2006-01-02"`
+	EndsOn       string                         `json:"endsOn" validate:"omitempty,datetime=2006-01-02"`
+	CreatedAt    time.Time                      `json:"createdAt"`
+	UpdatedAt    time.Time                      `json:"updatedAt"`
+}
+
+// Version is a snapshot of a named menu's items and prices. A draft can be rewritten until it
+// is published; a published version is served from EffectiveFrom until a later one takes over.
+type Version struct {
+	Id            uuid.UUID     `json:"id"`
+	NamedMenuId   uuid.UUID     `json:"namedMenuId"`
+	Number        int           `json:"number"`
+	Status        VersionStatus `json:"status"`
+	Items         []VersionItem `json:"items"`
+	EffectiveFrom *time.Time    `json:"effectiveFrom,omitempty"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	PublishedAt   *time.Time    `json:"publishedAt,omitempty"`
+}
+
+// VersionItem is a Menu row as the version serves it. Price is a decimal in major units, like
+// the Menu price column.
+type VersionItem struct {
+	MenuId   int64  `json:"menuId"`
+	Item     string `json:"item"`
+	ItemType string `json:"itemType"`
+	Price    string `json:"price"`
+}
+
+// DraftItem puts a Menu row on a draft. An empty Price keeps the item's current price.
+type DraftItem struct {
+	MenuId int64  `json:"menuId" validate:"required"`
+	Price  string `json:"price" validate:"omitempty,numeric"`
+}
+
+type DraftRequest struct {
+	Items []DraftItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// ItemChange is an item on both versions whose name, type or price differ.
+type ItemChange struct {
+	MenuId int64       `json:"menuId"`
+	Before VersionItem `json:"before"`
+	After  VersionItem `json:"after"`
+}
+
+type VersionDiff struct {
+	From    uuid.UUID     `json:"from"`
+	To      uuid.UUID     `json:"to"`
+	Added   []VersionItem `json:"added"`
+	Removed []VersionItem `json:"removed"`
+	Changed []ItemChange  `json:"changed"`
+}
+
+// Section is one named menu being served, with the version in effect.
+type Section struct {
+	Menu    NamedMenu `json:"menu"`
+	Version Version   `json:"version"`
+}
+
+// ActiveMenu is what a restaurant serves at a point in time. A restaurant without named menus
+// is not Scheduled and serves every item at its list price.
+type ActiveMenu struct {
+	RestaurantId uuid.UUID `json:"restaurantId"`
+	At           time.Time `json:"at"`
+	Scheduled    bool      `json:"scheduled"`
+	Sections     []Section `json:"sections"`
+}
+
+// ValidateInput also rejects overlapping hours and a date range that ends before it starts.
+func (n *NamedMenu) ValidateInput() error {
+	validate := validator.New()
+	err := validate.Struct(n)
+	if err != nil {
+		return err
+	}
+	err = restaurantModel.ValidateOpeningHours(n.Hours)
+	if err != nil {
+		return err
+	}
+	if n.StartsOn != "" && n.EndsOn != "" && n.EndsOn < n.StartsOn {
+		return fmt.Errorf("menu ends on %v before it starts on %v", n.EndsOn, n.StartsOn)
+	}
+	return nil
+}
+
+func (d *DraftRequest) ValidateInput() error {
+	validate := validator.New()
+	err := validate.Struct(d)
+	if err != nil {
+		return err
+	}
+	for i := range d.Items {
+		if price, ok := new(big.Rat).SetString(d.Items[i].Price); ok && price.Sign() < 0 {
+			return fmt.Errorf("menu item %d has a negative price", d.Items[i].MenuId)
+		}
+		for j := 0; j < i; j++ {
+			if d.Items[i].MenuId == d.Items[j].MenuId {
+				return fmt.Errorf("menu item %d is listed twice", d.Items[i].MenuId)
+			}
+		}
+	}
+	return nil
+}
+
+// ServedAt reports whether the menu's dates and hours cover at, read in at's own location.
+func (n *NamedMenu) ServedAt(at time.Time) bool {
+	day := at.Format(DateLayout)
+	if (n.StartsOn != "" && day < n.StartsOn) || (n.EndsOn != "" && day > n.EndsOn) {
+		return false
+	}
+	if len(n.Hours) == 0 {
+		return true
+	}
+	open := restaurantModel.NewOpenAt(at)
+	for _, h := range n.Hours {
+		if h.Contains(*open) {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps reports whether the two menus are ever served at the same time: their date ranges
+// meet and their hours share a minute on the same day. A menu without hours is served all day.
+func (n *NamedMenu) Overlaps(other *NamedMenu) bool {
+	if (n.EndsOn != "" && other.StartsOn != "" && n.EndsOn < other.StartsOn) ||
+		(other.EndsOn != "" && n.StartsOn != "" && other.EndsOn < n.StartsOn) {
+		return false
+	}
+	if len(n.Hours) == 0 || len(other.Hours) == 0 {
+		return true
+	}
+	for _, a := range n.Hours {
+		for _, b := range other.Hours {
+			if a.DayOfWeek == b.DayOfWeek && a.OpensAt < b.ClosesAt && b.OpensAt < a.ClosesAt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Find returns the item as served at the time. An item on more than one active menu is
+// served at its lowest price.
+func (a *ActiveMenu) Find(menuId int64) (VersionItem, bool) {
+	var found VersionItem
+	var ok bool
+	for _, s := range a.Sections {
+		for _, item := range s.Version.Items {
+			if item.MenuId == menuId && (!ok || cheaper(item.Price, found.Price)) {
+				found, ok = item, true
+			}
+		}
+	}
+	return found, ok
+}
+
+func cheaper(a, b string) bool {
+	x, okA := new(big.Rat).SetString(a)
+	y, okB := new(big.Rat).SetString(b)
+	return okA && okB && x.Cmp(y) < 0
+}
+
+// Diff lists what changed going from one version to another, each list in menu id order.
+func Diff(from, to Version) VersionDiff {
+	diff := VersionDiff{From: from.Id, To: to.Id, Added: []VersionItem{}, Removed: []VersionItem{},
+		Changed: []ItemChange{}}
+	before := make(map[int64]VersionItem, len(from.Items))
+	for _, item := range from.Items {
+		before[item.MenuId] = item
+	}
+	after := make(map[int64]VersionItem, len(to.Items))
+	for _, item := range to.Items {
+		after[item.MenuId] = item
+		old, ok := before[item.MenuId]
+		if !ok {
+			diff.Added = append(diff.Added, item)
+		} else if old != item {
+			diff.Changed = append(diff.Changed, ItemChange{MenuId: item.MenuId, Before: old, After: item})
+		}
+	}
+	for _, item := range from.Items {
+		if _, ok := after[item.MenuId]; !ok {
+			diff.Removed = append(diff.Removed, item)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].MenuId < diff.Added[j].MenuId })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].MenuId < diff.Removed[j].MenuId })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].MenuId < diff.Changed[j].MenuId })
+	return diff
+}
@@ -0,0 +1,156 @@
+package menuModel
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/restaurantModel"
+	"testing"
+	"time"
+)
+
+func TestNamedMenu_ValidateInput(t *testing.T) {
+	restaurantId := uuid.New()
+	tests := []struct {
+		name    string
+		menu    NamedMenu
+		wantErr bool
+	}{
+		{name: "all day, all year", menu: NamedMenu{RestaurantId: restaurantId, Name: "Drinks"}},
+		{name: "seasonal lunch", menu: NamedMenu{RestaurantId: restaurantId, Name: "Summer lunch",
+			Hours:    []restaurantModel.OpeningHours{{DayOfWeek: time.Saturday, OpensAt: 12 * 60, ClosesAt: 15 * 60}},
+			StartsOn: "2022-06-01", EndsOn: "2022-08-31"}},
+		{name: "one day only", menu: NamedMenu{RestaurantId: restaurantId, Name: "Christmas",
+			StartsOn: "2022-12-25", EndsOn: "2022-12-25"}},
+		{name: "no name", menu: NamedMenu{RestaurantId: restaurantId}, wantErr: true},
+		{name: "ends before it starts", menu: NamedMenu{RestaurantId: restaurantId, Name: "Winter",
+			StartsOn: "2022-12-01", EndsOn: "2022-11-30"}, wantErr: true},
+		{name: "not a date", menu: NamedMenu{RestaurantId: restaurantId, Name: "Winter", StartsOn: "01/12/2022"},
+			wantErr: true},
+		{name: "overlapping hours", menu: NamedMenu{RestaurantId: restaurantId, Name: "Brunch",
+			Hours: []restaurantModel.OpeningHours{
+				{DayOfWeek: time.Sunday, OpensAt: 9 * 60, ClosesAt: 12 * 60},
+				{DayOfWeek: time.Sunday, OpensAt: 11 * 60, ClosesAt: 14 * 60},
+			}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.menu.ValidateInput()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateInput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDraftRequest_ValidateInput(t *testing.T) {
+	valid := DraftRequest{Items: []DraftItem{{MenuId: 1}, {MenuId: 2, Price: "12.50"}}}
+	assert.Nil(t, valid.ValidateInput())
+
+	empty := DraftRequest{}
+	assert.NotNil(t, empty.ValidateInput())
+
+	twice := DraftRequest{Items: []DraftItem{{MenuId: 1}, {MenuId: 1, Price: "3"}}}
+	assert.NotNil(t, twice.ValidateInput())
+
+	negative := DraftRequest{Items: []DraftItem{{MenuId: 1, Price: "-3"}}}
+	assert.NotNil(t, negative.ValidateInput())
+
+	notANumber := DraftRequest{Items: []DraftItem{{MenuId: 1, Price: "free"}}}
+	assert.NotNil(t, notANumber.ValidateInput())
+}
+
+func TestNamedMenu_ServedAt(t *testing.T) {
+	breakfast := NamedMenu{Name: "Breakfast", Hours: []restaurantModel.OpeningHours{
+		{DayOfWeek: time.Monday, OpensAt: 7 * 60, ClosesAt: 11 * 60},
+		{DayOfWeek: time.Saturday, OpensAt: 8 * 60, ClosesAt: 12 * 60},
+	}}
+	summer := NamedMenu{Name: "Summer", StartsOn: "2022-06-01", EndsOn: "2022-08-31"}
+
+	tests := []struct {
+		name string
+		menu NamedMenu
+		at   time.Time
+		want bool
+	}{
+		{name: "monday breakfast", menu: breakfast, at: time.Date(2022, 7, 4, 7, 30, 0, 0, time.UTC), want: true},
+		{name: "monday lunch", menu: breakfast, at: time.Date(2022, 7, 4, 11, 0, 0, 0, time.UTC)},
+		{name: "tuesday breakfast", menu: breakfast, at: time.Date(2022, 7, 5, 8, 0, 0, 0, time.UTC)},
+		{name: "saturday late breakfast", menu: breakfast, at: time.Date(2022, 7, 9, 11, 59, 0, 0, time.UTC),
+			want: true},
+		{name: "first day of summer", menu: summer, at: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC), want: true},
+		{name: "last evening of summer", menu: summer, at: time.Date(2022, 8, 31, 23, 59, 0, 0, time.UTC),
+			want: true},
+		{name: "autumn", menu: summer, at: time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "spring", menu: summer, at: time.Date(2022, 5, 31, 23, 59, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.menu.ServedAt(tt.at))
+		})
+	}
+}
+
+func TestNamedMenu_Overlaps(t *testing.T) {
+	breakfast := NamedMenu{Name: "Breakfast", Hours: []restaurantModel.OpeningHours{
+		{DayOfWeek: time.Monday, OpensAt: 7 * 60, ClosesAt: 11 * 60}}}
+	lunch := NamedMenu{Name: "Lunch", Hours: []restaurantModel.OpeningHours{
+		{DayOfWeek: time.Monday, OpensAt: 11 * 60, ClosesAt: 15 * 60}}}
+	brunch := NamedMenu{Name: "Brunch", Hours: []restaurantModel.OpeningHours{
+		{DayOfWeek: time.Monday, OpensAt: 10 * 60, ClosesAt: 13 * 60}}}
+	allDay := NamedMenu{Name: "All day"}
+	summer := NamedMenu{Name: "Summer", StartsOn: "2022-06-01", EndsOn: "2022-08-31"}
+	winter := NamedMenu{Name: "Winter", StartsOn: "2022-12-01"}
+
+	assert.False(t, breakfast.Overlaps(&lunch))
+	assert.True(t, brunch.Overlaps(&breakfast))
+	assert.True(t, lunch.Overlaps(&brunch))
+	assert.True(t, allDay.Overlaps(&lunch))
+	assert.True(t, summer.Overlaps(&allDay))
+	assert.False(t, summer.Overlaps(&winter))
+	assert.False(t, winter.Overlaps(&summer))
+}
+
+func TestDiff(t *testing.T) {
+	from := Version{Id: uuid.New(), Items: []VersionItem{
+		{MenuId: 3, Item: "Jollof", ItemType: "main", Price: "2500"},
+		{MenuId: 1, Item: "Chapman", ItemType: "drink", Price: "800"},
+		{MenuId: 2, Item: "Puff puff", ItemType: "side", Price: "500"},
+	}}
+	to := Version{Id: uuid.New(), Items: []VersionItem{
+		{MenuId: 4, Item: "Zobo", ItemType: "drink", Price: "600"},
+		{MenuId: 3, Item: "Jollof", ItemType: "main", Price: "2800"},
+		{MenuId: 2, Item: "Puff puff", ItemType: "side", Price: "500"},
+	}}
+
+	diff := Diff(from, to)
+	assert.Equal(t, from.Id, diff.From)
+	assert.Equal(t, to.Id, diff.To)
+	assert.Equal(t, []VersionItem{to.Items[0]}, diff.Added)
+	assert.Equal(t, []VersionItem{from.Items[1]}, diff.Removed)
+	assert.Equal(t, []ItemChange{{MenuId: 3, Before: from.Items[0], After: to.Items[1]}}, diff.Changed)
+
+	same := Diff(to, to)
+	assert.Empty(t, same.Added)
+	assert.Empty(t, same.Removed)
+	assert.Empty(t, same.Changed)
+}
+
+func TestActiveMenu_Find(t *testing.T) {
+	active := ActiveMenu{Scheduled: true, Sections: []Section{
+		{Menu: NamedMenu{Name: "All day"}, Version: Version{Items: []VersionItem{
+			{MenuId: 1, Item: "Coffee", Price: "3.50"}, {MenuId: 2, Item: "Toast", Price: "4"}}}},
+		{Menu: NamedMenu{Name: "Happy hour"}, Version: Version{Items: []VersionItem{
+			{MenuId: 1, Item: "Coffee", Price: "2.00"}}}},
+	}}
+
+	coffee, ok := active.Find(1)
+	assert.True(t, ok)
+	assert.Equal(t, "2.00", coffee.Price)
+
+	toast, ok := active.Find(2)
+	assert.True(t, ok)
+	assert.Equal(t, "4", toast.Price)
+
+	_, ok = active.Find(3)
+	assert.False(t, ok)
+}
@@ -0,0 +1,299 @@
+package menuModel
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+
+	MaxImportRows = 2000
+	// listSeparator separates allergens and dietary tags within a CSV cell.
+	listSeparator = ";"
+	// noneDeclared is the allergens cell of an item declared free of every allergen. An empty
+	// cell means its allergens were never declared.
+	noneDeclared = "none"
+)
+
+// csvHeader is the column order the exporter writes. The importer matches columns by name.
+var csvHeader = []string{"code", "item", "item_type", "price", "allergens", "dietary"}
+
+// TransferRow is one menu item as imported and exported. Code is the restaurant's own item
+// code and is what an import matches existing items on. Allergens is nil when the row does
+// not declare them, and importing it then keeps whatever the item declared before. Nutrition
+// facts are not transferred.
+type TransferRow struct {
+	Code      string       `json:"code"`
+	Item      string       `json:"item"`
+	ItemType  string       `json:"itemType"`
+	Price     string       `json:"price"`
+	Allergens []Allergen   `json:"allergens"`
+	Dietary   []DietaryTag `json:"dietary"`
+}
+
+// ImportRow is a parsed row with the line of the file it started on.
+type ImportRow struct {
+	Line int
+	TransferRow
+}
+
+type RowError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// ImportReport says what an import did, or in a dry run would do. An import with any
+// errors changes nothing.
+type ImportReport struct {
+	DryRun  bool       `json:"dryRun"`
+	Rows    int        `json:"rows"`
+	Created int        `json:"created"`
+	Updated int        `json:"updated"`
+	Errors  []RowError `json:"errors"`
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Message)
+}
+
+// Parse reads a menu file. Rows that cannot be read at all are reported as errors; the rest
+// still need checking with ValidateRows.
+func Parse(format Format, data []byte) ([]ImportRow, []RowError) {
+	switch format {
+	case CSV:
+		return parseCSV(data)
+	case JSON:
+		return parseJSON(data)
+	default:
+		return nil, []RowError{{Line: 1, Message: fmt.Sprintf("unsupported format %q, expected csv or json", format)}}
+	}
+}
+
+// ValidateRows checks every row and that no code is used twice, reporting every problem
+// found rather than stopping at the first.
+func ValidateRows(rows []ImportRow) []RowError {
+	errs := []RowError{}
+	if len(rows) > MaxImportRows {
+		return append(errs, RowError{Line: rows[MaxImportRows].Line,
+			Message: fmt.Sprintf("a file can hold at most %d items", MaxImportRows)})
+	}
+	seen := map[string]int{}
+	for _, r := range rows {
+		fail := func(format string, args ...interface{}) {
+			errs = append(errs, RowError{Line: r.Line, Message: fmt.Sprintf(format, args...)})
+		}
+		switch {
+		case r.Code == "":
+			fail("code is required")
+		case len(r.Code) > 64:
+			fail("code is longer than 64 characters")
+		case seen[r.Code] != 0:
+			fail("code %q is already used on line %d", r.Code, seen[r.Code])
+		default:
+			seen[r.Code] = r.Line
+		}
+		if r.Item == "" {
+			fail("item is required")
+		}
+		if r.ItemType == "" {
+			fail("item type is required")
+		}
+		price, ok := new(big.Rat).SetString(r.Price)
+		if !ok {
+			fail("price %q is not a number", r.Price)
+		} else if price.Sign() < 0 {
+			fail("price %v is negative", r.Price)
+		}
+		info := DietaryInfo{Allergens: r.Allergens, Dietary: r.Dietary}
+		if err := info.ValidateInput(); err != nil {
+			fail("%v", err)
+		}
+	}
+	return errs
+}
+
+// Rows turns menu items into rows for export, in the order given.
+func Rows(items []MenuItem) []TransferRow {
+	rows := make([]TransferRow, 0, len(items))
+	for _, m := range items {
+		rows = append(rows, TransferRow{Code: m.Code, Item: m.Item, ItemType: m.ItemType, Price: m.Price,
+			Allergens: m.Allergens, Dietary: m.Dietary})
+	}
+	return rows
+}
+
+// Write exports rows in a format Parse reads back.
+func Write(format Format, w io.Writer, rows []TransferRow) error {
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		err := cw.Write(csvHeader)
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			err = cw.Write([]string{r.Code, r.Item, r.ItemType, r.Price, allergenCell(r.Allergens),
+				strings.Join(tagNames(r.Dietary), listSeparator)})
+			if err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rows)
+	default:
+		return fmt.Errorf("unsupported format %q, expected csv or json", format)
+	}
+}
+
+// parseCSV needs a header row naming at least the code, item, item_type and price columns.
+// Line numbers count the header, so the first item is on line 2.
+func parseCSV(data []byte) ([]ImportRow, []RowError) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, []RowError{{Line: 1, Message: fmt.Sprintf("cannot read header: %v", err)}}
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvHeader[:4] {
+		if _, ok := columns[name]; !ok {
+			return nil, []RowError{{Line: 1, Message: fmt.Sprintf("missing column %q", name)}}
+		}
+	}
+
+	rows := []ImportRow{}
+	errs := []RowError{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			errs = append(errs, RowError{Line: parseErr.StartLine, Message: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return rows, append(errs, RowError{Line: 1, Message: err.Error()})
+		}
+		line, _ := reader.FieldPos(0)
+		cell := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		row := ImportRow{Line: line, TransferRow: TransferRow{Code: cell("code"), Item: cell("item"),
+			ItemType: cell("item_type"), Price: cell("price"), Dietary: []DietaryTag{}}}
+		switch allergens := cell("allergens"); {
+		case strings.EqualFold(allergens, noneDeclared):
+			row.Allergens = []Allergen{}
+		case allergens != "":
+			for _, a := range splitList(allergens) {
+				row.Allergens = append(row.Allergens, Allergen(strings.ToLower(a)))
+			}
+		}
+		for _, d := range splitList(cell("dietary")) {
+			row.Dietary = append(row.Dietary, DietaryTag(strings.ToLower(d)))
+		}
+		rows = append(rows, row)
+	}
+	return rows, errs
+}
+
+// parseJSON reads an array of rows, noting the line each row's object starts on.
+func parseJSON(data []byte) ([]ImportRow, []RowError) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	token, err := decoder.Token()
+	if err != nil || token != json.Delim('[') {
+		return nil, []RowError{{Line: 1, Message: "expected an array of menu items"}}
+	}
+
+	rows := []ImportRow{}
+	errs := []RowError{}
+	for decoder.More() {
+		line := lineAt(data, decoder.InputOffset())
+		var row TransferRow
+		err = decoder.Decode(&row)
+		if _, ok := err.(*json.SyntaxError); ok || err == io.ErrUnexpectedEOF {
+			// The decoder cannot resynchronise after a syntax error, so stop at the first.
+			return rows, append(errs, RowError{Line: line, Message: err.Error()})
+		}
+		if err != nil {
+			errs = append(errs, RowError{Line: line, Message: err.Error()})
+			continue
+		}
+		if row.Dietary == nil {
+			row.Dietary = []DietaryTag{}
+		}
+		rows = append(rows, ImportRow{Line: line, TransferRow: row})
+	}
+	return rows, errs
+}
+
+// lineAt returns the line of the first value at or after offset, skipping the whitespace and
+// comma the decoder has not consumed yet.
+func lineAt(data []byte, offset int64) int {
+	i := int(offset)
+	for i < len(data) && strings.ContainsRune(" \t\r\n,", rune(data[i])) {
+		i++
+	}
+	return bytes.Count(data[:i], []byte("\n")) + 1
+}
+
+func splitList(cell string) []string {
+	var items []string
+	for _, s := range strings.Split(cell, listSeparator) {
+		if s = strings.TrimSpace(s); s != "" {
+			items = append(items, s)
+		}
+	}
+	return items
+}
+
+// allergenCell writes allergens the way parseCSV reads them back, keeping undeclared apart
+// from declared none.
+func allergenCell(allergens []Allergen) string {
+	if allergens == nil {
+		return ""
+	}
+	if len(allergens) == 0 {
+		return noneDeclared
+	}
+	return strings.Join(allergenNames(allergens), listSeparator)
+}
+
+func allergenNames(allergens []Allergen) []string {
+	names := make([]string, 0, len(allergens))
+	for _, a := range allergens {
+		names = append(names, string(a))
+	}
+	return names
+}
+
+func tagNames(tags []DietaryTag) []string {
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, string(t))
+	}
+	return names
+}
@@ -0,0 +1,120 @@
+package menuModel
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse_CSV(t *testing.T) {
+	data := []byte(`code,item,item_type,price,allergens,dietary
+J1,Jollof rice,main,25.00,None,vegan;gluten_free
+"P2","Puff puff
+(six pieces)",side,5,gluten; eggs,vegetarian
+Z3,Zobo,drink,6.50
+C4,Chapman,drink,8,,
+`)
+	rows, errs := Parse(CSV, data)
+	assert.Empty(t, errs)
+	assert.Equal(t, []ImportRow{
+		{Line: 2, TransferRow: TransferRow{Code: "J1", Item: "Jollof rice", ItemType: "main", Price: "25.00",
+			Allergens: []Allergen{}, Dietary: []DietaryTag{Vegan, GlutenFree}}},
+		{Line: 3, TransferRow: TransferRow{Code: "P2", Item: "Puff puff\n(six pieces)", ItemType: "side", Price: "5",
+			Allergens: []Allergen{Gluten, Eggs}, Dietary: []DietaryTag{Vegetarian}}},
+		{Line: 5, TransferRow: TransferRow{Code: "Z3", Item: "Zobo", ItemType: "drink", Price: "6.50",
+			Dietary: []DietaryTag{}}},
+		{Line: 6, TransferRow: TransferRow{Code: "C4", Item: "Chapman", ItemType: "drink", Price: "8",
+			Dietary: []DietaryTag{}}},
+	}, rows)
+
+	_, errs = Parse(CSV, []byte("code,item,price\nJ1,Jollof,25\n"))
+	assert.Equal(t, []RowError{{Line: 1, Message: `missing column "item_type"`}}, errs)
+
+	rows, errs = Parse(CSV, []byte("code,item,item_type,price\nJ1,Jollof,main,25\nP2,\"Puff \"puff,side,5\nZ3,Zobo,drink,6\n"))
+	assert.Len(t, rows, 2)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, 3, errs[0].Line)
+}
+
+func TestParse_JSON(t *testing.T) {
+	data := []byte(`[
+  {"code": "J1", "item": "Jollof rice", "itemType": "main", "price": "25.00", "dietary": ["vegan"]},
+  {
+    "code": "P2",
+    "item": "Puff puff",
+    "itemType": "side",
+    "price": "5",
+    "allergens": ["gluten", "eggs"]
+  },
+  {"code": "Z3", "item": "Zobo", "itemType": "drink", "price": 6.5}
+]`)
+	rows, errs := Parse(JSON, data)
+	assert.Equal(t, []RowError{{Line: 10, Message: errs[0].Message}}, errs)
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Equal(t, []DietaryTag{Vegan}, rows[0].Dietary)
+	assert.Nil(t, rows[0].Allergens)
+	assert.Equal(t, 3, rows[1].Line)
+	assert.Equal(t, []Allergen{Gluten, Eggs}, rows[1].Allergens)
+	assert.Equal(t, []DietaryTag{}, rows[1].Dietary)
+
+	_, errs = Parse(JSON, []byte(`{"code": "J1"}`))
+	assert.Len(t, errs, 1)
+
+	_, errs = Parse(JSON, []byte("[\n{\"code\": \"J1\",\n\"item\": }\n]"))
+	assert.Equal(t, 2, errs[0].Line)
+
+	_, errs = Parse("xlsx", nil)
+	assert.Len(t, errs, 1)
+}
+
+func TestValidateRows(t *testing.T) {
+	row := func(line int, code, price string, allergens []Allergen, dietary []DietaryTag) ImportRow {
+		return ImportRow{Line: line, TransferRow: TransferRow{Code: code, Item: "Dish", ItemType: "main",
+			Price: price, Allergens: allergens, Dietary: dietary}}
+	}
+	rows := []ImportRow{
+		row(2, "A1", "10", nil, nil),
+		row(3, "", "10", nil, nil),
+		row(4, "A1", "10", nil, nil),
+		row(5, "B2", "ten", nil, nil),
+		row(6, "C3", "-1", nil, nil),
+		row(7, "D4", "12.5", []Allergen{Milk}, []DietaryTag{Vegan}),
+		row(8, "E5", "12.5", []Allergen{"chocolate"}, nil),
+		{Line: 9, TransferRow: TransferRow{Code: "F6", Price: "1"}},
+	}
+
+	errs := ValidateRows(rows)
+	lines := []int{}
+	for _, e := range errs {
+		lines = append(lines, e.Line)
+	}
+	assert.Equal(t, []int{3, 4, 5, 6, 7, 8, 9, 9}, lines)
+	assert.Equal(t, `code "A1" is already used on line 2`, errs[1].Message)
+	assert.Empty(t, ValidateRows(rows[:1]))
+}
+
+func TestWrite_RoundTrip(t *testing.T) {
+	items := []MenuItem{
+		{Code: "J1", Item: "Jollof, smoky", ItemType: "main", Price: "25.00", Allergens: []Allergen{},
+			Dietary: []DietaryTag{Vegan, GlutenFree}},
+		{Code: "P2", Item: "Puff \"puff\"", ItemType: "side", Price: "5", Allergens: []Allergen{Gluten, Eggs},
+			Dietary: []DietaryTag{}},
+		// Allergens nobody declared stay undeclared rather than becoming none.
+		{Code: "Z3", Item: "Zobo", ItemType: "drink", Price: "6.5", Dietary: []DietaryTag{}},
+	}
+	for _, format := range []Format{CSV, JSON} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.Nil(t, Write(format, &buf, Rows(items)))
+			rows, errs := Parse(format, buf.Bytes())
+			assert.Empty(t, errs)
+			assert.Empty(t, ValidateRows(rows))
+			got := make([]TransferRow, 0, len(rows))
+			for _, r := range rows {
+				got = append(got, r.TransferRow)
+			}
+			assert.Equal(t, Rows(items), got)
+		})
+	}
+	assert.NotNil(t, Write("xlsx", &bytes.Buffer{}, nil))
+}
@@ -0,0 +1,118 @@
+package moneyModel
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Money is an amount in the minor unit of its currency, e.g. kobo for NGN or cents for USD.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// minorUnits lists currencies whose minor unit is not 1/100 of the major unit.
+var minorUnits = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"XOF": 0,
+	"XAF": 0,
+	"BHD": 3,
+	"KWD": 3,
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// Exponent returns the number of decimal places of the currency's minor unit.
+func Exponent(currency string) int {
+	if e, ok := minorUnits[strings.ToUpper(currency)]; ok {
+		return e
+	}
+	return 2
+}
+
+// Parse reads a decimal amount in major units, e.g. "12.50", as used by the Menu price column.
+func Parse(value, currency string) (Money, error) {
+	exp := Exponent(currency)
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)))
+	if !r.IsInt() {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimal places", value, exp)
+	}
+	if !r.Num().IsInt64() {
+		return Money{}, fmt.Errorf("amount %q is out of range", value)
+	}
+	return New(r.Num().Int64(), currency), nil
+}
+
+func (m Money) SameCurrency(o Money) bool {
+	return m.Currency == o.Currency
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if !m.SameCurrency(o) {
+		return Money{}, fmt.Errorf("currency mismatch: %v and %v", m.Currency, o.Currency)
+	}
+	return New(m.Amount+o.Amount, m.Currency), nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+func (m Money) Neg() Money {
+	return New(-m.Amount, m.Currency)
+}
+
+func (m Money) Multiply(n int64) Money {
+	return New(m.Amount*n, m.Currency)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Cmp compares two amounts of the same currency and returns -1, 0 or 1.
+func (m Money) Cmp(o Money) (int, error) {
+	if !m.SameCurrency(o) {
+		return 0, fmt.Errorf("currency mismatch: %v and %v", m.Currency, o.Currency)
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) String() string {
+	exp := Exponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if exp == 0 {
+		return fmt.Sprintf("%s %s%d", m.Currency, sign, amount)
+	}
+	div := int64(1)
+	for i := 0; i < exp; i++ {
+		div *= 10
+	}
+	return fmt.Sprintf("%s %s%d.%0*d", m.Currency, sign, amount/div, exp, amount%div)
+}
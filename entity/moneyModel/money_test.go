@@ -0,0 +1,54 @@
+package moneyModel
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{value: "12.50", currency: "ngn", want: Money{Amount: 1250, Currency: "NGN"}},
+		{value: "4500", currency: "NGN", want: Money{Amount: 450000, Currency: "NGN"}},
+		{value: " 0.5 ", currency: "USD", want: Money{Amount: 50, Currency: "USD"}},
+		{value: "1200", currency: "JPY", want: Money{Amount: 1200, Currency: "JPY"}},
+		{value: "1.234", currency: "KWD", want: Money{Amount: 1234, Currency: "KWD"}},
+		{value: "1.005", currency: "USD", wantErr: true},
+		{value: "12,50", currency: "USD", wantErr: true},
+		{value: "", currency: "USD", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value+" "+tt.currency, func(t *testing.T) {
+			got, err := Parse(tt.value, tt.currency)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a, b := New(1050, "NGN"), New(200, "NGN")
+
+	sum, err := a.Add(b)
+	assert.Nil(t, err)
+	assert.Equal(t, New(1250, "NGN"), sum)
+
+	diff, err := b.Sub(a)
+	assert.Nil(t, err)
+	assert.True(t, diff.IsNegative())
+	assert.Equal(t, "NGN -8.50", diff.String())
+
+	_, err = a.Add(New(1, "USD"))
+	assert.NotNil(t, err)
+
+	cmp, err := a.Cmp(b)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, cmp)
+
+	assert.Equal(t, "JPY 300", New(100, "JPY").Multiply(3).String())
+	assert.Equal(t, "USD 0.05", New(5, "USD").String())
+}
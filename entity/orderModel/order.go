@@ -0,0 +1,107 @@
+package orderModel
+
+import (
+	"fmt"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Status string
+
+const DefaultLimit = 20
+
+const (
+	Pending   Status = "pending"
+	Accepted  Status = "accepted"
+	Preparing Status = "preparing"
+	Ready     Status = "ready"
+	// OutForDelivery is set when a courier picks up a ready order.
+	OutForDelivery Status = "out_for_delivery"
+	Completed      Status = "completed"
+	Cancelled      Status = "cancelled"
+)
+
+// transitions lists the moves allowed from each state. A ready order can go back to
+// preparing when the kitchen recalls a ticket, and is completed either on collection or
+// once its delivery arrives.
+var transitions = map[Status][]Status{
+	Pending:        {Accepted, Cancelled},
+	Accepted:       {Preparing, Ready, Cancelled},
+	Preparing:      {Ready, Cancelled},
+	Ready:          {Preparing, OutForDelivery, Completed},
+	OutForDelivery: {Completed},
+}
+
+// Order is what a user ordered from one restaurant. Total is the amount due in minor units
+// after discounts, tax and service charge; SettledAt is set once it has been paid in full.
+// UserId is uuid.Nil for table orders started by a guest who was not signed in.
+type Order struct {
+	Id           uuid.UUID   `json:"id"`
+	UserId       uuid.UUID   `json:"userId"`
+	RestaurantId uuid.UUID   `json:"restaurantId"`
+	Status       Status      `json:"status"`
+	Currency     string      `json:"currency"`
+	Total        int64       `json:"total"`
+	Items        []OrderItem `json:"items"`
+	SettledAt    *time.Time  `json:"settledAt,omitempty"`
+	CreatedAt    time.Time   `json:"createdAt"`
+	UpdatedAt    time.Time   `json:"updatedAt"`
+}
+
+// OrderItem snapshots the menu item at ordering time. UnitPrice is in minor units of
+// the order currency.
+type OrderItem struct {
+	Id        int64     `json:"id"`
+	OrderId   uuid.UUID `json:"orderId"`
+	MenuId    int64     `json:"menuId"`
+	Item      string    `json:"item"`
+	ItemType  string    `json:"itemType"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice int64     `json:"unitPrice"`
+}
+
+// StatusEvent is published to the customer whenever one of their orders changes state.
+type StatusEvent struct {
+	OrderId      uuid.UUID `json:"orderId"`
+	RestaurantId uuid.UUID `json:"restaurantId"`
+	Previous     Status    `json:"previous"`
+	Status       Status    `json:"status"`
+	At           time.Time `json:"at"`
+}
+
+// HistoryQuery lists a user's orders, newest first. Only orders at RestaurantId, in one of
+// Statuses and placed from From up to but excluding To are kept when those are set.
+type HistoryQuery struct {
+	RestaurantId *uuid.UUID `json:"restaurantId"`
+	Statuses     []Status   `json:"statuses" validate:"dive,oneof=pending accepted preparing ready out_for_delivery completed cancelled"`
+	From         *time.Time `json:"from"`
+	To           *time.Time `json:"to"`
+	Limit        int        `json:"limit" validate:"min=0,max=100"`
+	Offset       int        `json:"offset" validate:"min=0"`
+}
+
+func (h *HistoryQuery) ValidateInput() error {
+	validate := validator.New()
+	err := validate.Struct(h)
+	if err != nil {
+		return err
+	}
+	if h.From != nil && h.To != nil && !h.To.After(*h.From) {
+		return fmt.Errorf("history must end after it starts")
+	}
+	return nil
+}
+
+func (i OrderItem) Gross() int64 {
+	return i.UnitPrice * int64(i.Quantity)
+}
+
+func CanTransition(from, to Status) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package paymentModel
+
+import (
+	"fmt"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"rsm/entity/moneyModel"
+	"time"
+)
+
+type Status string
+
+const (
+	Pending           Status = "pending"
+	Authorized        Status = "authorized"
+	Failed            Status = "failed"
+	Captured          Status = "captured"
+	Voided            Status = "voided"
+	PartiallyRefunded Status = "partially_refunded"
+	Refunded          Status = "refunded"
+)
+
+var transitions = map[Status][]Status{
+	Pending:           {Authorized, Failed},
+	Authorized:        {Captured, Voided},
+	Captured:          {PartiallyRefunded, Refunded},
+	PartiallyRefunded: {PartiallyRefunded, Refunded},
+}
+
+// Payment tracks one authorisation for an order and what has since been captured and refunded.
+// CapturedAmount and RefundedAmount are always in the currency of Amount.
+type Payment struct {
+	Id             uuid.UUID        `json:"id"`
+	OrderId        uuid.UUID        `json:"orderId"`
+	UserId         uuid.UUID        `json:"userId"`
+	IdempotencyKey string           `json:"-"`
+	Provider       string           `json:"provider"`
+	ProviderRef    string           `json:"providerRef"`
+	Status         Status           `json:"status"`
+	Amount         moneyModel.Money `json:"amount"`
+	CapturedAmount moneyModel.Money `json:"capturedAmount"`
+	RefundedAmount moneyModel.Money `json:"refundedAmount"`
+	FailureReason  string           `json:"failureReason,omitempty"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	UpdatedAt      time.Time        `json:"updatedAt"`
+}
+
+// OverpaidKey is the idempotency key of the refund of a payment made towards a bill share that
+// was already paid. It gives back money the order never kept, so it does not count as refunded
+// of the order.
+const OverpaidKey = "bill-overpaid"
+
+type Refund struct {
+	Id             uuid.UUID        `json:"id"`
+	PaymentId      uuid.UUID        `json:"paymentId"`
+	IdempotencyKey string           `json:"-"`
+	ProviderRef    string           `json:"providerRef"`
+	Amount         moneyModel.Money `json:"amount"`
+	CreatedAt      time.Time        `json:"createdAt"`
+}
+
+type AuthorizeRequest struct {
+	OrderId        uuid.UUID `json:"orderId" validate:"required"`
+	UserId         uuid.UUID `json:"userId" validate:"required"`
+	Amount         int64     `json:"amount" validate:"gt=0"`
+	Currency       string    `json:"currency" validate:"required,len=3,alpha"`
+	SourceToken    string    `json:"sourceToken" validate:"required"`
+	IdempotencyKey string    `json:"idempotencyKey" validate:"required,max=255"`
+}
+
+type RefundRequest struct {
+	Amount         int64  `json:"amount" validate:"gt=0"`
+	IdempotencyKey string `json:"idempotencyKey" validate:"required,max=255"`
+}
+
+func (a *AuthorizeRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(a)
+}
+
+func (r *RefundRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func CanTransition(from, to Status) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Refundable is how much of the captured amount has not been refunded yet.
+func (p *Payment) Refundable() moneyModel.Money {
+	return moneyModel.New(p.CapturedAmount.Amount-p.RefundedAmount.Amount, p.Amount.Currency)
+}
+
+// CheckCapture rejects a captured amount the payment cannot have: one in another currency,
+// or more than was authorised.
+func (p *Payment) CheckCapture(amount moneyModel.Money) error {
+	if !amount.SameCurrency(p.Amount) {
+		return fmt.Errorf("capture in %v for a %v payment", amount.Currency, p.Amount.Currency)
+	}
+	if amount.Amount <= 0 || amount.Amount > p.Amount.Amount {
+		return fmt.Errorf("capture of %v does not fit authorisation %v", amount, p.Amount)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package promotionModel
+
+import (
+	"fmt"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Type string
+
+const (
+	// Percentage discounts Value basis points (1/100 of a percent) off each scoped line.
+	Percentage Type = "percentage"
+	// Fixed takes Value minor units off the scoped lines, never more than they cost.
+	Fixed Type = "fixed"
+	// FreeItem makes one unit of FreeMenuId free when it is in the order.
+	FreeItem Type = "free_item"
+
+	MaxBasisPoints = 10000
+)
+
+// Promotion is applied automatically when Code is empty, otherwise only when the customer
+// enters the code. RestaurantId and ItemType narrow where it applies; zero values mean
+// everywhere. MaxRedemptions and MaxPerUser of zero mean unlimited.
+type Promotion struct {
+	Id             uuid.UUID  `json:"id"`
+	Code           string     `json:"code" validate:"omitempty,alphanum,max=32"`
+	Name           string     `json:"name" validate:"required"`
+	Type           Type       `json:"type" validate:"required,oneof=percentage fixed free_item"`
+	Value          int64      `json:"value" validate:"min=0"`
+	Currency       string     `json:"currency" validate:"required,len=3,alpha"`
+	FreeMenuId     *int64     `json:"freeMenuId,omitempty" validate:"required_if=Type free_item"`
+	MinOrderValue  int64      `json:"minOrderValue" validate:"min=0"`
+	RestaurantId   *uuid.UUID `json:"restaurantId,omitempty"`
+	ItemType       string     `json:"itemType"`
+	StartsAt       time.Time  `json:"startsAt" validate:"required"`
+	EndsAt         time.Time  `json:"endsAt" validate:"required,gtfield=StartsAt"`
+	MaxRedemptions int        `json:"maxRedemptions" validate:"min=0"`
+	MaxPerUser     int        `json:"maxPerUser" validate:"min=0"`
+	Stackable      bool       `json:"stackable"`
+	Priority       int        `json:"priority"`
+	Active         bool       `json:"active"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+type Redemption struct {
+	PromotionId uuid.UUID `json:"promotionId"`
+	UserId      uuid.UUID `json:"userId"`
+	OrderId     uuid.UUID `json:"orderId"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func (p *Promotion) ValidateInput() error {
+	validate := validator.New()
+	err := validate.Struct(p)
+	if err != nil {
+		return err
+	}
+	if p.Type == Percentage && (p.Value == 0 || p.Value > MaxBasisPoints) {
+		return fmt.Errorf("percentage must be between 1 and %d basis points", MaxBasisPoints)
+	}
+	if p.Type == Fixed && p.Value == 0 {
+		return fmt.Errorf("fixed discount must be positive")
+	}
+	return nil
+}
+
+// ActiveAt reports whether the promotion is switched on and inside its validity window.
+// The window includes StartsAt and excludes EndsAt.
+func (p *Promotion) ActiveAt(t time.Time) bool {
+	return p.Active && !t.Before(p.StartsAt) && t.Before(p.EndsAt)
+}
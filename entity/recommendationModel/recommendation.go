@@ -0,0 +1,71 @@
+package recommendationModel
+
+import (
+	"github.com/google/uuid"
+	"rsm/entity/menuModel"
+	"time"
+)
+
+type Reason string
+
+const (
+	// OrderedTogether items are often in the same orders as items the user ordered.
+	OrderedTogether Reason = "ordered_together"
+	// MatchesTaste items have the types and dietary tags the user tends to order.
+	MatchesTaste Reason = "matches_taste"
+	// Popular items fill in for users without enough history of their own.
+	Popular Reason = "popular"
+)
+
+const (
+	DefaultLimit = 10
+	// MaxStored is how many recommendations the batch job keeps per user, and the most a
+	// user can ask for.
+	MaxStored = 50
+	// History is how far back completed orders count.
+	History = 180 * 24 * time.Hour
+)
+
+// Purchase is a line of a completed order, with the dietary tags its item has now.
+type Purchase struct {
+	UserId       uuid.UUID
+	OrderId      uuid.UUID
+	RestaurantId uuid.UUID
+	MenuId       int64
+	ItemType     string
+	Dietary      []menuModel.DietaryTag
+}
+
+// Item is a menu item that can be recommended.
+type Item struct {
+	MenuId       int64
+	RestaurantId uuid.UUID
+	ItemType     string
+	Dietary      []menuModel.DietaryTag
+}
+
+// Score is how strongly an item is recommended, from 0 to 1.
+type Score struct {
+	MenuId int64
+	Score  float64
+	Reason Reason
+}
+
+// Batch is what one run of the batch job produces: each user's ranked items and, for
+// everyone else, each restaurant's most popular items.
+type Batch struct {
+	Users   map[uuid.UUID][]Score
+	Popular map[uuid.UUID][]Score
+}
+
+// Recommendation is a recommended item as it is listed now.
+type Recommendation struct {
+	MenuId       int64     `json:"menuId"`
+	RestaurantId uuid.UUID `json:"restaurantId"`
+	Item         string    `json:"item"`
+	ItemType     string    `json:"itemType"`
+	Price        string    `json:"price"`
+	ImageRef     string    `json:"imageRef,omitempty"`
+	Score        float64   `json:"score"`
+	Reason       Reason    `json:"reason"`
+}
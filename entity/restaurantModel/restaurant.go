@@ -0,0 +1,143 @@
+package restaurantModel
+
+import (
+	"fmt"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"rsm/location/geoUtils"
+	"time"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	MinutesInDay       = 24 * 60
+	// DefaultTimezone is used for restaurants created without one.
+	DefaultTimezone = "UTC"
+)
+
+// RestaurantModel is a restaurant. Latitude and Longitude are nil until the restaurant's
+// position is known, and opening hours are read in Timezone, an IANA zone name. OwnerId is
+// always the user who created the restaurant, never taken from the request body.
+type RestaurantModel struct {
+	Id          uuid.UUID  `json:"id" validate:"required"`
+	OwnerId     uuid.UUID  `json:"ownerId" validate:"required"`
+	Name        string     `json:"name" validate:"required"`
+	Location    string     `json:"location"`
+	Description string     `json:"description"`
+	Cuisine     string     `json:"cuisine"`
+	Latitude    *float64   `json:"latitude,omitempty" validate:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude   *float64   `json:"longitude,omitempty" validate:"required_with=Latitude,omitempty,min=-180,max=180"`
+	Timezone    string     `json:"timezone" validate:"omitempty,timezone"`
+	Status      bool       `json:"status"`
+	ImageRef    string     `json:"imageRef,omitempty"`
+	BrandId     *uuid.UUID `json:"brandId,omitempty"`
+	CreatedAt   time.Time  `json:"-"`
+}
+
+// OpeningHours is one opening window in the restaurant's local wall-clock time.
+// OpensAt and ClosesAt are minutes since midnight; a window that runs past
+// midnight is stored as two rows, one on each day.
+type OpeningHours struct {
+	DayOfWeek time.Weekday `json:"dayOfWeek" validate:"min=0,max=6"`
+	OpensAt   int          `json:"opensAt" validate:"min=0,max=1439"`
+	ClosesAt  int          `json:"closesAt" validate:"min=1,max=1440,gtfield=OpensAt"`
+}
+
+// NearbySearchRequest looks for active restaurants around a point. A zero RadiusKm
+// returns the nearest restaurants regardless of distance. OpenNow is checked against each
+// restaurant's own timezone.
+type NearbySearchRequest struct {
+	Latitude  float64   `json:"latitude" validate:"min=-90,max=90"`
+	Longitude float64   `json:"longitude" validate:"min=-180,max=180"`
+	RadiusKm  float64   `json:"radiusKm" validate:"min=0,max=500"`
+	Cuisine   string    `json:"cuisine"`
+	OpenNow   bool      `json:"openNow"`
+	At        time.Time `json:"-"`
+	Limit     int       `json:"limit" validate:"min=0,max=100"`
+	Offset    int       `json:"offset" validate:"min=0"`
+}
+
+// NearbyQuery is what the repository needs to run a nearby search.
+type NearbyQuery struct {
+	Center      geoUtils.Point
+	RadiusKm    float64
+	BoundingBox *geoUtils.BoundingBox
+	Cuisine     string
+	// OpenAt keeps restaurants open at that instant in their own timezone.
+	OpenAt *time.Time
+	Limit  int
+	Offset int
+}
+
+type OpenAt struct {
+	DayOfWeek time.Weekday
+	Minute    int
+}
+
+type NearbyRestaurant struct {
+	Restaurant RestaurantModel `json:"restaurant"`
+	DistanceKm float64         `json:"distanceKm"`
+}
+
+// Point is where the restaurant is, and false when its coordinates are not known.
+func (r *RestaurantModel) Point() (geoUtils.Point, bool) {
+	if r.Latitude == nil || r.Longitude == nil {
+		return geoUtils.Point{}, false
+	}
+	return geoUtils.Point{Latitude: *r.Latitude, Longitude: *r.Longitude}, true
+}
+
+// LocalTime is t on the restaurant's wall clock. Restaurants without a timezone, or with one
+// this system does not know, are on DefaultTimezone.
+func (r *RestaurantModel) LocalTime(t time.Time) time.Time {
+	location, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return t.UTC()
+	}
+	return t.In(location)
+}
+
+// NewOpenAt reads t's weekday and minute in t's own location.
+func NewOpenAt(t time.Time) *OpenAt {
+	return &OpenAt{DayOfWeek: t.Weekday(), Minute: t.Hour()*60 + t.Minute()}
+}
+
+// Contains reports whether the window is open at the given wall-clock minute.
+func (o OpeningHours) Contains(at OpenAt) bool {
+	return o.DayOfWeek == at.DayOfWeek && o.OpensAt <= at.Minute && at.Minute < o.ClosesAt
+}
+
+func (r *RestaurantModel) ValidateInput() error {
+	if r.Timezone == "" {
+		r.Timezone = DefaultTimezone
+	}
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (o *OpeningHours) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(o)
+}
+
+func (n *NearbySearchRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(n)
+}
+
+// ValidateOpeningHours checks every window and rejects overlapping windows on the same day.
+func ValidateOpeningHours(hours []OpeningHours) error {
+	for i := range hours {
+		if err := hours[i].ValidateInput(); err != nil {
+			return err
+		}
+		for j := 0; j < i; j++ {
+			if hours[i].DayOfWeek == hours[j].DayOfWeek &&
+				hours[i].OpensAt < hours[j].ClosesAt && hours[j].OpensAt < hours[i].ClosesAt {
+				return fmt.Errorf("overlapping opening hours on %v", hours[i].DayOfWeek)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package restaurantModel
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestValidateOpeningHours(t *testing.T) {
+	tests := []struct {
+		name    string
+		hours   []OpeningHours
+		wantErr bool
+	}{
+		{
+			name: "split shifts",
+			hours: []OpeningHours{
+				{DayOfWeek: time.Monday, OpensAt: 8 * 60, ClosesAt: 14 * 60},
+				{DayOfWeek: time.Monday, OpensAt: 17 * 60, ClosesAt: 22 * 60},
+			},
+		}, {
+			name: "until midnight",
+			hours: []OpeningHours{
+				{DayOfWeek: time.Friday, OpensAt: 18 * 60, ClosesAt: MinutesInDay},
+			},
+		}, {
+			name: "closes before it opens",
+			hours: []OpeningHours{
+				{DayOfWeek: time.Monday, OpensAt: 22 * 60, ClosesAt: 2 * 60},
+			},
+			wantErr: true,
+		}, {
+			name: "overlapping windows",
+			hours: []OpeningHours{
+				{DayOfWeek: time.Sunday, OpensAt: 8 * 60, ClosesAt: 14 * 60},
+				{DayOfWeek: time.Sunday, OpensAt: 13 * 60, ClosesAt: 20 * 60},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOpeningHours(tt.hours)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestNearbySearchRequest_ValidateInput(t *testing.T) {
+	valid := NearbySearchRequest{Latitude: 6.5, Longitude: 3.3, RadiusKm: 5, Limit: 10}
+	assert.Nil(t, valid.ValidateInput())
+
+	badLatitude := NearbySearchRequest{Latitude: 91, Longitude: 3.3}
+	assert.NotNil(t, badLatitude.ValidateInput())
+
+	hugeLimit := NearbySearchRequest{Latitude: 6.5, Longitude: 3.3, Limit: 1000}
+	assert.NotNil(t, hugeLimit.ValidateInput())
+}
+
+func TestNewOpenAt(t *testing.T) {
+	at := NewOpenAt(time.Date(2022, time.July, 4, 13, 45, 0, 0, time.UTC))
+	assert.Equal(t, time.Monday, at.DayOfWeek)
+	assert.Equal(t, 13*60+45, at.Minute)
+}
+
+func TestRestaurantModel_LocalTime(t *testing.T) {
+	at := time.Date(2022, time.July, 4, 23, 30, 0, 0, time.UTC)
+	lagos := RestaurantModel{Timezone: "Africa/Lagos"}
+	local := NewOpenAt(lagos.LocalTime(at))
+	assert.Equal(t, time.Tuesday, local.DayOfWeek)
+	assert.Equal(t, 30, local.Minute)
+
+	unset := RestaurantModel{}
+	assert.Equal(t, at, unset.LocalTime(at.In(time.FixedZone("WAT", 3600))))
+}
+
+func TestRestaurantModel_ValidateInput(t *testing.T) {
+	latitude := 6.5
+	r := RestaurantModel{Id: uuid.New(), OwnerId: uuid.New(), Name: "Mama Put"}
+	assert.Nil(t, r.ValidateInput())
+	assert.Equal(t, DefaultTimezone, r.Timezone)
+	_, ok := r.Point()
+	assert.False(t, ok)
+
+	r.Latitude = &latitude
+	assert.NotNil(t, r.ValidateInput(), "latitude without longitude")
+
+	r.Latitude, r.Timezone = nil, "Nowhere/Special"
+	assert.NotNil(t, r.ValidateInput())
+}
@@ -0,0 +1,109 @@
+package reviewModel
+
+import (
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Status string
+
+const (
+	Published Status = "published"
+	Flagged   Status = "flagged"
+	Rejected  Status = "rejected"
+
+	// FlagThreshold is the number of reports that takes a published review down for moderation.
+	FlagThreshold = 3
+	DefaultLimit  = 20
+)
+
+// transitions lists the moderation moves allowed from each state. Flagging only happens
+// through reports; moderators resolve flagged reviews and can reject any published one.
+var transitions = map[Status][]Status{
+	Published: {Flagged, Rejected},
+	Flagged:   {Published, Rejected},
+	Rejected:  {Published},
+}
+
+// Review targets a restaurant, or one of its menu items when MenuId is set.
+type Review struct {
+	Id           uuid.UUID `json:"id"`
+	UserId       uuid.UUID `json:"userId"`
+	OrderId      uuid.UUID `json:"orderId"`
+	RestaurantId uuid.UUID `json:"restaurantId"`
+	MenuId       *int64    `json:"menuId,omitempty"`
+	Rating       int       `json:"rating"`
+	Comment      string    `json:"comment"`
+	Status       Status    `json:"status"`
+	Reply        *Reply    `json:"reply,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// Reply is the restaurant's answer to a review. OwnerId is whoever replied for it: the owner
+// or one of its brand's managers.
+type Reply struct {
+	OwnerId   uuid.UUID `json:"ownerId"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ReviewRequest struct {
+	OrderId      uuid.UUID `json:"orderId" validate:"required"`
+	RestaurantId uuid.UUID `json:"restaurantId" validate:"required"`
+	MenuId       *int64    `json:"menuId" validate:"omitempty,min=1"`
+	Rating       int       `json:"rating" validate:"required,min=1,max=5"`
+	Comment      string    `json:"comment" validate:"max=2000"`
+}
+
+type ReplyRequest struct {
+	Body string `json:"body" validate:"required,max=2000"`
+}
+
+type ReportRequest struct {
+	Reason string `json:"reason" validate:"required,oneof=spam offensive irrelevant fake other"`
+	Note   string `json:"note" validate:"max=500"`
+}
+
+type Report struct {
+	ReviewId   uuid.UUID `json:"reviewId"`
+	ReporterId uuid.UUID `json:"reporterId"`
+	Reason     string    `json:"reason"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type RatingSummary struct {
+	Count   int     `json:"count"`
+	Average float64 `json:"average"`
+}
+
+func (r *ReviewRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *ReplyRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *ReportRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func CanTransition(from, to Status) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CountsTowardsRating reports whether a review in this state is part of the aggregates.
+func (s Status) CountsTowardsRating() bool {
+	return s == Published
+}
@@ -0,0 +1,38 @@
+package reviewModel
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		want     bool
+	}{
+		{Published, Flagged, true},
+		{Published, Rejected, true},
+		{Flagged, Published, true},
+		{Flagged, Rejected, true},
+		{Rejected, Published, true},
+		{Rejected, Flagged, false},
+		{Published, Published, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.want, CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestReviewRequest_ValidateInput(t *testing.T) {
+	menuId := int64(7)
+	valid := ReviewRequest{OrderId: uuid.New(), RestaurantId: uuid.New(), MenuId: &menuId, Rating: 5}
+	assert.Nil(t, valid.ValidateInput())
+
+	for _, rating := range []int{0, 6} {
+		r := ReviewRequest{OrderId: uuid.New(), RestaurantId: uuid.New(), Rating: rating}
+		assert.NotNil(t, r.ValidateInput())
+	}
+}
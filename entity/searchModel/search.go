@@ -0,0 +1,61 @@
+package searchModel
+
+import (
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"rsm/entity/menuModel"
+)
+
+type HitKind string
+
+const (
+	RestaurantHit HitKind = "restaurant"
+	MenuItemHit   HitKind = "item"
+
+	DefaultLimit = 20
+	MaxLimit     = 50
+
+	// HighlightStart and HighlightStop wrap every matched term in a snippet.
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+// SearchRequest finds restaurants and menu items. ExcludeAllergens and Dietary filter the
+// items as in menuModel.MenuQuery and do not affect restaurants.
+type SearchRequest struct {
+	Query            string                 `json:"query" validate:"required,min=2,max=200"`
+	Kinds            []HitKind              `json:"kinds" validate:"dive,oneof=restaurant item"`
+	ExcludeAllergens []menuModel.Allergen   `json:"excludeAllergens" validate:"dive,oneof=celery gluten crustaceans eggs fish lupin milk molluscs mustard nuts peanuts sesame soybeans sulphites"`
+	Dietary          []menuModel.DietaryTag `json:"dietary" validate:"dive,oneof=vegan vegetarian halal gluten_free"`
+	Limit            int                    `json:"limit" validate:"min=0,max=50"`
+	Offset           int                    `json:"offset" validate:"min=0"`
+}
+
+// SearchHit is either a restaurant or a menu item. MenuId and Price are only set for items.
+type SearchHit struct {
+	Kind         HitKind   `json:"kind"`
+	RestaurantId uuid.UUID `json:"restaurantId"`
+	MenuId       int64     `json:"menuId,omitempty"`
+	Title        string    `json:"title"`
+	Snippet      string    `json:"snippet"`
+	Price        string    `json:"price,omitempty"`
+	Rank         float64   `json:"rank"`
+}
+
+func (s *SearchRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(s)
+}
+
+// Includes reports whether hits of kind k were asked for; no kinds means all of them.
+func (s *SearchRequest) Includes(k HitKind) bool {
+	if len(s.Kinds) == 0 {
+		return true
+	}
+	for _, kind := range s.Kinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package tableModel
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"rsm/entity/orderModel"
+	"strings"
+	"time"
+)
+
+// ErrNotDiner means the diner id given is not one of the session's diners.
+var ErrNotDiner = errors.New("not a diner at this table")
+
+type SessionStatus string
+
+const (
+	Open   SessionStatus = "open"
+	Closed SessionStatus = "closed"
+)
+
+// Table is a physical table with a QR code. Token is the secret in the code's link; rotating
+// it makes printed codes stop working. Orders at the table are priced in Currency.
+type Table struct {
+	Id           uuid.UUID `json:"id"`
+	RestaurantId uuid.UUID `json:"restaurantId"`
+	Label        string    `json:"label"`
+	Seats        int       `json:"seats"`
+	Currency     string    `json:"currency"`
+	Token        string    `json:"token"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// Session is one sitting at a table, from the first scan until the bill is paid or staff close
+// it. Everyone who scans the code while it is open joins it and orders onto the one shared
+// order, which is created with the first item.
+type Session struct {
+	Id           uuid.UUID     `json:"id"`
+	TableId      uuid.UUID     `json:"tableId"`
+	RestaurantId uuid.UUID     `json:"restaurantId"`
+	Status       SessionStatus `json:"status"`
+	OrderId      *uuid.UUID    `json:"orderId,omitempty"`
+	Diners       []Diner       `json:"diners"`
+	OpenedAt     time.Time     `json:"openedAt"`
+	ClosedAt     *time.Time    `json:"closedAt,omitempty"`
+}
+
+// Diner is someone at the table. Id is returned only to the diner and identifies them on later
+// requests; UserId is set for diners who were signed in.
+type Diner struct {
+	Id        uuid.UUID  `json:"id"`
+	SessionId uuid.UUID  `json:"sessionId"`
+	UserId    *uuid.UUID `json:"userId,omitempty"`
+	Name      string     `json:"name"`
+	JoinedAt  time.Time  `json:"joinedAt"`
+}
+
+// Item is a line of the table's order with the diner who added it.
+type Item struct {
+	orderModel.OrderItem
+	DinerId uuid.UUID `json:"dinerId"`
+}
+
+type TableRequest struct {
+	Label    string `json:"label" validate:"required,max=20"`
+	Seats    int    `json:"seats" validate:"min=1,max=50"`
+	Currency string `json:"currency" validate:"required,len=3,alpha"`
+	Active   bool   `json:"active"`
+}
+
+type JoinRequest struct {
+	Name string `json:"name" validate:"max=100"`
+}
+
+type AddItemsRequest struct {
+	Lines []LineRequest `json:"lines" validate:"required,min=1,max=50,dive"`
+}
+
+type LineRequest struct {
+	MenuId   int64 `json:"menuId" validate:"required"`
+	Quantity int   `json:"quantity" validate:"min=1,max=50"`
+}
+
+func (t *TableRequest) ValidateInput() error {
+	t.Currency = strings.ToUpper(t.Currency)
+	validate := validator.New()
+	return validate.Struct(t)
+}
+
+func (j *JoinRequest) ValidateInput() error {
+	j.Name = strings.TrimSpace(j.Name)
+	validate := validator.New()
+	return validate.Struct(j)
+}
+
+func (a *AddItemsRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(a)
+}
+
+// NewToken returns a random, URL-safe table token.
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Payload is the link a table's QR code holds: baseURL followed by /t/ and the token.
+func Payload(baseURL, token string) string {
+	return fmt.Sprintf("%s/t/%s", strings.TrimSuffix(baseURL, "/"), token)
+}
+
+// Diner returns the session's diner with the given id.
+func (s *Session) Diner(id uuid.UUID) (*Diner, error) {
+	for i := range s.Diners {
+		if s.Diners[i].Id == id {
+			return &s.Diners[i], nil
+		}
+	}
+	return nil, ErrNotDiner
+}
@@ -0,0 +1,52 @@
+package tableModel
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTableRequest_ValidateInput(t *testing.T) {
+	valid := TableRequest{Label: "12", Seats: 4, Currency: "ngn"}
+	assert.Nil(t, valid.ValidateInput())
+	assert.Equal(t, "NGN", valid.Currency)
+
+	noSeats := TableRequest{Label: "12", Currency: "NGN"}
+	assert.NotNil(t, noSeats.ValidateInput())
+}
+
+func TestAddItemsRequest_ValidateInput(t *testing.T) {
+	valid := AddItemsRequest{Lines: []LineRequest{{MenuId: 3, Quantity: 2}}}
+	assert.Nil(t, valid.ValidateInput())
+
+	empty := AddItemsRequest{}
+	assert.NotNil(t, empty.ValidateInput())
+
+	zero := AddItemsRequest{Lines: []LineRequest{{MenuId: 3}}}
+	assert.NotNil(t, zero.ValidateInput())
+}
+
+func TestNewToken(t *testing.T) {
+	a, err := NewToken()
+	assert.Nil(t, err)
+	b, _ := NewToken()
+	assert.Len(t, a, 22)
+	assert.NotEqual(t, a, b)
+}
+
+func TestPayload(t *testing.T) {
+	assert.Equal(t, "https://order.example/t/abc", Payload("https://order.example/", "abc"))
+	assert.Equal(t, "https://order.example/t/abc", Payload("https://order.example", "abc"))
+}
+
+func TestSession_Diner(t *testing.T) {
+	ada := Diner{Id: uuid.New(), Name: "Ada"}
+	session := Session{Diners: []Diner{ada}}
+
+	diner, err := session.Diner(ada.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "Ada", diner.Name)
+
+	_, err = session.Diner(uuid.New())
+	assert.ErrorIs(t, err, ErrNotDiner)
+}
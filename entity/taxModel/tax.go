@@ -0,0 +1,59 @@
+package taxModel
+
+import (
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Rounding string
+
+const (
+	// PerLine rounds the tax of every line and adds the rounded amounts.
+	PerLine Rounding = "per_line"
+	// PerTotal adds the exact line taxes and rounds once.
+	PerTotal Rounding = "per_total"
+)
+
+type Method string
+
+const (
+	// HalfUp rounds halves away from zero.
+	HalfUp Method = "half_up"
+	// HalfEven rounds halves to the nearest even minor unit.
+	HalfEven Method = "half_even"
+
+	MaxBasisPoints = 10000
+)
+
+// TaxRules describes how a restaurant's jurisdiction taxes an order. Rates are basis points
+// (1/100 of a percent). Rates maps item_type to its rate; item types not listed use
+// DefaultRate. With Inclusive set, menu prices already contain tax.
+type TaxRules struct {
+	RestaurantId         uuid.UUID        `json:"restaurantId"`
+	Inclusive            bool             `json:"inclusive"`
+	DefaultRate          int64            `json:"defaultRate" validate:"min=0,max=10000"`
+	Rates                map[string]int64 `json:"rates" validate:"dive,keys,required,endkeys,min=0,max=10000"`
+	ServiceChargeRate    int64            `json:"serviceChargeRate" validate:"min=0,max=10000"`
+	ServiceChargeTaxable bool             `json:"serviceChargeTaxable"`
+	Rounding             Rounding         `json:"rounding" validate:"required,oneof=per_line per_total"`
+	Method               Method           `json:"method" validate:"required,oneof=half_up half_even"`
+	UpdatedAt            time.Time        `json:"updatedAt"`
+}
+
+func (t *TaxRules) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(t)
+}
+
+func (t *TaxRules) RateFor(itemType string) int64 {
+	if rate, ok := t.Rates[itemType]; ok {
+		return rate
+	}
+	return t.DefaultRate
+}
+
+// NoTax is used for restaurants that have not configured any tax rules.
+func NoTax(restaurantId uuid.UUID) TaxRules {
+	return TaxRules{RestaurantId: restaurantId, Rounding: PerLine, Method: HalfUp}
+}
@@ -0,0 +1,70 @@
+package userModel
+
+import (
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"rsm/location/geoUtils"
+	"time"
+)
+
+// MaxAddresses is how many addresses one user can save.
+const MaxAddresses = 20
+
+// Address is a saved delivery address. Label is the user's name for it, e.g. "home" or
+// "work", and is unique per user. Exactly one address per user is the default once they
+// have any.
+type Address struct {
+	Id           uuid.UUID `json:"id"`
+	UserId       uuid.UUID `json:"userId"`
+	Label        string    `json:"label"`
+	Line1        string    `json:"line1"`
+	Line2        string    `json:"line2,omitempty"`
+	City         string    `json:"city"`
+	State        string    `json:"state,omitempty"`
+	PostalCode   string    `json:"postalCode,omitempty"`
+	Country      string    `json:"country"`
+	Latitude     float64   `json:"latitude"`
+	Longitude    float64   `json:"longitude"`
+	Instructions string    `json:"instructions,omitempty"`
+	IsDefault    bool      `json:"isDefault"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// AddressRequest adds or replaces an address. Country is an ISO 3166-1 alpha-2 code.
+type AddressRequest struct {
+	Label        string  `json:"label" validate:"required,max=32"`
+	Line1        string  `json:"line1" validate:"required,max=200"`
+	Line2        string  `json:"line2" validate:"max=200"`
+	City         string  `json:"city" validate:"required,max=100"`
+	State        string  `json:"state" validate:"max=100"`
+	PostalCode   string  `json:"postalCode" validate:"max=20"`
+	Country      string  `json:"country" validate:"required,iso3166_1_alpha2"`
+	Latitude     float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude    float64 `json:"longitude" validate:"min=-180,max=180"`
+	Instructions string  `json:"instructions" validate:"max=500"`
+	Default      bool    `json:"default"`
+}
+
+func (a *AddressRequest) ValidateInput() error {
+	validate := validator.New()
+	return validate.Struct(a)
+}
+
+func (a *Address) Point() geoUtils.Point {
+	return geoUtils.Point{Latitude: a.Latitude, Longitude: a.Longitude}
+}
+
+// Apply copies the request onto the address, leaving its identity and default flag alone.
+func (a *Address) Apply(request AddressRequest) {
+	a.Label = request.Label
+	a.Line1 = request.Line1
+	a.Line2 = request.Line2
+	a.City = request.City
+	a.State = request.State
+	a.PostalCode = request.PostalCode
+	a.Country = request.Country
+	a.Latitude = request.Latitude
+	a.Longitude = request.Longitude
+	a.Instructions = request.Instructions
+}
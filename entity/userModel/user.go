@@ -3,15 +3,20 @@ package userModel
 import (
 	validator "github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"strings"
 	"time"
 )
 
+// UserModel is a registered user. Phone is in E.164 form, e.g. +2348031234567. AvatarRef
+// points at the user's profile picture in blob storage.
 type UserModel struct {
 	Id        uuid.UUID `json:"id" validate:"required"`
 	FirstName string    `json:"firstName" validate:"required"`
 	LastName  string    `json:"lastName" validate:"required"`
 	Email     string    `json:"email" validate:"required,email"`
 	Password  string    `json:"password" validate:"required,min=8,alphanum"`
+	Phone     string    `json:"phone,omitempty" validate:"omitempty,e164"`
+	AvatarRef string    `json:"avatarRef,omitempty" validate:"omitempty,max=512"`
 	CreatedAt time.Time `json:"-"`
 }
 
@@ -20,6 +25,21 @@ type UserAccessModel struct {
 	FirstName string    `json:"firstName" validate:"required"`
 	LastName  string    `json:"lastName" validate:"required"`
 	Email     string    `json:"email" validate:"required"`
+	Phone     string    `json:"phone,omitempty"`
+	AvatarRef string    `json:"avatarRef,omitempty"`
+}
+
+// Profile is the user with their saved addresses, the default one first.
+type Profile struct {
+	UserAccessModel
+	Addresses []Address `json:"addresses"`
+}
+
+type ProfileUpdateRequest struct {
+	FirstName string `json:"firstName" validate:"required"`
+	LastName  string `json:"lastName" validate:"required"`
+	Phone     string `json:"phone" validate:"omitempty,e164"`
+	AvatarRef string `json:"avatarRef" validate:"omitempty,max=512"`
 }
 
 type UserLoginRequest struct {
@@ -28,10 +48,31 @@ type UserLoginRequest struct {
 }
 
 func (u *UserModel) ValidateInput() error {
+	u.Phone = NormalizePhone(u.Phone)
 	validate := validator.New()
 	return validate.Struct(u)
 }
 
+// ValidateInput accepts phone numbers typed with spaces, dashes, dots or brackets and
+// stores them without.
+func (p *ProfileUpdateRequest) ValidateInput() error {
+	p.Phone = NormalizePhone(p.Phone)
+	validate := validator.New()
+	return validate.Struct(p)
+}
+
+// NormalizePhone strips the separators people type in phone numbers, so
+// "+234 (803) 123-4567" becomes "+2348031234567". It does not add a country code.
+func NormalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+}
+
 func (u *UserLoginRequest) ValidateInput() error {
 	validate := validator.New()
 	return validate.Struct(u)
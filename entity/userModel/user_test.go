@@ -89,3 +89,32 @@ func TestUserModel_WrongValidationInput(t *testing.T) {
 		})
 	}
 }
+
+func TestProfileUpdateRequest_Phone(t *testing.T) {
+	tests := []struct {
+		phone   string
+		want    string
+		wantErr bool
+	}{
+		{phone: "+2348031234567", want: "+2348031234567"},
+		{phone: " +44 20 7946.0018 ", want: "+442079460018"},
+		{phone: "+1 (415) 555-0100", want: "+14155550100"},
+		{phone: "", want: ""},
+		{phone: "08031234567", wantErr: true},
+		{phone: "+234", wantErr: true},
+		{phone: "+234803123456789012", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			p := &ProfileUpdateRequest{FirstName: "ade", LastName: "bayo", Phone: tt.phone}
+			err := p.ValidateInput()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateInput() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, p.Phone)
+			}
+		})
+	}
+}
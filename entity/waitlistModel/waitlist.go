@@ -0,0 +1,160 @@
+package waitlistModel
+
+import (
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"math"
+	"rsm/entity/userModel"
+	"time"
+)
+
+type Status string
+
+const (
+	Waiting Status = "waiting"
+	// Notified parties have been told their table is ready and keep their place until
+	// they are seated or given up on.
+	Notified  Status = "notified"
+	Seated    Status = "seated"
+	Cancelled Status = "cancelled"
+	NoShow    Status = "no_show"
+)
+
+const (
+	// DefaultTurnTime is assumed until a restaurant has finished turns to learn from.
+	DefaultTurnTime = 45 * time.Minute
+	// MinTurnSamples is how many turns of a party's size are needed before the estimate
+	// uses them instead of the turns of every size.
+	MinTurnSamples = 5
+	// RecentTurns is how far back turns count towards the estimate.
+	RecentTurns = 14 * 24 * time.Hour
+	// MaxRecentTurns caps how many of the latest turns are averaged.
+	MaxRecentTurns = 200
+)
+
+var transitions = map[Status][]Status{
+	Waiting:  {Notified, Seated, Cancelled},
+	Notified: {Seated, Cancelled, NoShow},
+}
+
+// Entry is a walk-in party waiting for a table. UserId is set when the customer joined from
+// their account; parties added by the host only have a name and maybe a phone number.
+// Position and EstimatedMinutes are worked out when the queue is read and only set while
+// the party is in it.
+type Entry struct {
+	Id               uuid.UUID  `json:"id"`
+	RestaurantId     uuid.UUID  `json:"restaurantId"`
+	UserId           *uuid.UUID `json:"userId,omitempty"`
+	Name             string     `json:"name"`
+	Phone            string     `json:"phone,omitempty"`
+	PartySize        int        `json:"partySize"`
+	Status           Status     `json:"status"`
+	Position         int        `json:"position,omitempty"`
+	EstimatedMinutes int        `json:"estimatedMinutes,omitempty"`
+	JoinedAt         time.Time  `json:"joinedAt"`
+	NotifiedAt       *time.Time `json:"notifiedAt,omitempty"`
+	SeatedAt         *time.Time `json:"seatedAt,omitempty"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+}
+
+// Turn is one party's stay at a table, from being seated until the table is cleared.
+// EntryId is nil for parties seated straight away without queueing.
+type Turn struct {
+	Id           uuid.UUID  `json:"id"`
+	RestaurantId uuid.UUID  `json:"restaurantId"`
+	EntryId      *uuid.UUID `json:"entryId,omitempty"`
+	PartySize    int        `json:"partySize"`
+	SeatedAt     time.Time  `json:"seatedAt"`
+	ClearedAt    *time.Time `json:"clearedAt,omitempty"`
+}
+
+type JoinRequest struct {
+	Name      string `json:"name" validate:"required,max=100"`
+	Phone     string `json:"phone" validate:"omitempty,e164"`
+	PartySize int    `json:"partySize" validate:"min=1,max=30"`
+}
+
+func (j *JoinRequest) ValidateInput() error {
+	j.Phone = userModel.NormalizePhone(j.Phone)
+	validate := validator.New()
+	return validate.Struct(j)
+}
+
+func CanTransition(from, to Status) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Queued reports whether the entry still holds a place in the queue.
+func (e *Entry) Queued() bool {
+	return e.Status == Waiting || e.Status == Notified
+}
+
+// TurnTime is the average time parties of about partySize spent at their table over the
+// given turns. Sizes are grouped in twos (1-2, 3-4, 5-6, 7 and up); when fewer than
+// MinTurnSamples turns fall in the party's group every finished turn is used, and with none
+// at all it is DefaultTurnTime.
+func TurnTime(turns []Turn, partySize int) time.Duration {
+	var group, all time.Duration
+	var inGroup, total int
+	for _, t := range turns {
+		if t.ClearedAt == nil {
+			continue
+		}
+		stay := t.ClearedAt.Sub(t.SeatedAt)
+		all += stay
+		total++
+		if sizeGroup(t.PartySize) == sizeGroup(partySize) {
+			group += stay
+			inGroup++
+		}
+	}
+	switch {
+	case inGroup >= MinTurnSamples:
+		return group / time.Duration(inGroup)
+	case total > 0:
+		return all / time.Duration(total)
+	}
+	return DefaultTurnTime
+}
+
+// Estimate sets the position and estimated wait of each entry in queue, which must be in
+// queue order. Each occupied table is expected to free up one turn time after it was seated
+// (now, if that has passed) and goes to the next party, who then holds it for their own
+// turn time. With no occupied tables known the queue shares a single table. Tables are not
+// matched to party sizes, so the estimate is the same for a couple as for a group of eight.
+func Estimate(queue []Entry, occupied, recent []Turn, now time.Time) {
+	free := make([]time.Time, 0, len(occupied)+1)
+	for _, t := range occupied {
+		at := t.SeatedAt.Add(TurnTime(recent, t.PartySize))
+		if at.Before(now) {
+			at = now
+		}
+		free = append(free, at)
+	}
+	if len(free) == 0 {
+		free = append(free, now)
+	}
+	for i := range queue {
+		next := 0
+		for j := range free {
+			if free[j].Before(free[next]) {
+				next = j
+			}
+		}
+		queue[i].Position = i + 1
+		queue[i].EstimatedMinutes = int(math.Ceil(free[next].Sub(now).Minutes()))
+		free[next] = free[next].Add(TurnTime(recent, queue[i].PartySize))
+	}
+}
+
+func sizeGroup(partySize int) int {
+	if partySize > 6 {
+		return 4
+	}
+	return (partySize + 1) / 2
+}
@@ -0,0 +1,72 @@
+package waitlistModel
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 3, 6, 19, 0, 0, 0, time.UTC)
+
+func turn(size int, seated, cleared time.Duration) Turn {
+	t := Turn{PartySize: size, SeatedAt: now.Add(-seated)}
+	if cleared > 0 {
+		at := now.Add(-cleared)
+		t.ClearedAt = &at
+	}
+	return t
+}
+
+func TestJoinRequest_ValidateInput(t *testing.T) {
+	valid := JoinRequest{Name: "Ada", Phone: "+234 803 123 4567", PartySize: 4}
+	assert.Nil(t, valid.ValidateInput())
+	assert.Equal(t, "+2348031234567", valid.Phone)
+
+	empty := JoinRequest{Name: "Ada"}
+	assert.NotNil(t, empty.ValidateInput())
+}
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, CanTransition(Waiting, Notified))
+	assert.True(t, CanTransition(Notified, NoShow))
+	assert.False(t, CanTransition(Waiting, NoShow))
+	assert.False(t, CanTransition(Seated, Cancelled))
+}
+
+func TestTurnTime(t *testing.T) {
+	assert.Equal(t, DefaultTurnTime, TurnTime(nil, 2))
+
+	// One finished turn of 30 minutes and one table still occupied.
+	few := []Turn{turn(2, 90*time.Minute, time.Hour), turn(2, 10*time.Minute, 0)}
+	assert.Equal(t, 30*time.Minute, TurnTime(few, 6))
+
+	var many []Turn
+	for i := 0; i < MinTurnSamples; i++ {
+		many = append(many, turn(6, 3*time.Hour, time.Hour), turn(2, 2*time.Hour, time.Hour))
+	}
+	assert.Equal(t, 2*time.Hour, TurnTime(many, 5))
+	assert.Equal(t, time.Hour, TurnTime(many, 1))
+}
+
+func TestEstimate(t *testing.T) {
+	// Every turn lasts an hour.
+	recent := []Turn{turn(2, 3*time.Hour, 2*time.Hour)}
+	occupied := []Turn{
+		turn(2, 40*time.Minute, 0),
+		turn(4, 2*time.Hour, 0),
+	}
+	queue := []Entry{{PartySize: 2}, {PartySize: 4}, {PartySize: 2}}
+
+	Estimate(queue, occupied, recent, now)
+	// The overdue table goes now, the other in 20 minutes, and the first party's in an hour.
+	assert.Equal(t, []int{1, 2, 3}, []int{queue[0].Position, queue[1].Position, queue[2].Position})
+	assert.Equal(t, []int{0, 20, 60},
+		[]int{queue[0].EstimatedMinutes, queue[1].EstimatedMinutes, queue[2].EstimatedMinutes})
+}
+
+func TestEstimate_NoOccupiedTables(t *testing.T) {
+	queue := []Entry{{PartySize: 2}, {PartySize: 2}}
+	Estimate(queue, nil, nil, now)
+	assert.Equal(t, 0, queue[0].EstimatedMinutes)
+	assert.Equal(t, int(DefaultTurnTime.Minutes()), queue[1].EstimatedMinutes)
+}
@@ -0,0 +1,66 @@
+package zoneModel
+
+import (
+	"errors"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"rsm/location/geoUtils"
+	"time"
+)
+
+// ErrOutside is returned for an address that none of the restaurant's zones cover.
+var ErrOutside = errors.New("address is outside the restaurant's delivery zones")
+
+// Zone is an area a restaurant delivers to. DeliveryFee and MinimumOrder are minor units of
+// Currency; MinimumOrder applies to the item subtotal before discounts.
+type Zone struct {
+	Id           uuid.UUID     `json:"id"`
+	RestaurantId uuid.UUID     `json:"restaurantId"`
+	Name         string        `json:"name" validate:"required"`
+	Area         geoUtils.Area `json:"area" validate:"required"`
+	DeliveryFee  int64         `json:"deliveryFee" validate:"min=0"`
+	MinimumOrder int64         `json:"minimumOrder" validate:"min=0"`
+	Currency     string        `json:"currency" validate:"required,len=3,alpha"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+func (z *Zone) ValidateInput() error {
+	validate := validator.New()
+	err := validate.Struct(z)
+	if err != nil {
+		return err
+	}
+	return z.Area.Validate()
+}
+
+// Match returns the zone that covers p. Where zones overlap the customer gets the cheapest
+// delivery fee, then the lowest minimum order, then the smallest zone, since a zone drawn
+// inside another is the more specific one. Zones that are still tied go by id, so the
+// result never depends on the order zones are listed in.
+func Match(zones []Zone, p geoUtils.Point) (*Zone, error) {
+	var best *Zone
+	for i := range zones {
+		z := &zones[i]
+		if z.Area.Contains(p) && (best == nil || z.before(best)) {
+			best = z
+		}
+	}
+	if best == nil {
+		return nil, ErrOutside
+	}
+	return best, nil
+}
+
+func (z *Zone) before(other *Zone) bool {
+	if z.DeliveryFee != other.DeliveryFee {
+		return z.DeliveryFee < other.DeliveryFee
+	}
+	if z.MinimumOrder != other.MinimumOrder {
+		return z.MinimumOrder < other.MinimumOrder
+	}
+	if size, otherSize := z.Area.Size(), other.Area.Size(); size != otherSize {
+		return size < otherSize
+	}
+	return z.Id.String() < other.Id.String()
+}
@@ -0,0 +1,47 @@
+package zoneModel
+
+import (
+	"github.com/stretchr/testify/assert"
+	"rsm/location/geoUtils"
+	"testing"
+)
+
+func square(minLat, minLng, maxLat, maxLng float64) geoUtils.Area {
+	return geoUtils.Area{geoUtils.Polygon{geoUtils.Ring{
+		{Latitude: minLat, Longitude: minLng},
+		{Latitude: minLat, Longitude: maxLng},
+		{Latitude: maxLat, Longitude: maxLng},
+		{Latitude: maxLat, Longitude: minLng},
+	}}}
+}
+
+func TestMatch(t *testing.T) {
+	zones := []Zone{
+		{Name: "outer", Area: square(0, 0, 10, 10), DeliveryFee: 1500, MinimumOrder: 5000},
+		{Name: "inner", Area: square(4, 4, 6, 6), DeliveryFee: 500, MinimumOrder: 5000},
+		{Name: "inner, no minimum", Area: square(4, 4, 5, 5), DeliveryFee: 500},
+		{Name: "corner", Area: square(8, 8, 10, 10), DeliveryFee: 1500, MinimumOrder: 5000},
+	}
+
+	tests := []struct {
+		name    string
+		p       geoUtils.Point
+		want    string
+		wantErr error
+	}{
+		{name: "outer only", p: geoUtils.Point{Latitude: 1, Longitude: 1}, want: "outer"},
+		{name: "cheapest fee wins", p: geoUtils.Point{Latitude: 5.5, Longitude: 5.5}, want: "inner"},
+		{name: "then lowest minimum", p: geoUtils.Point{Latitude: 4.5, Longitude: 4.5}, want: "inner, no minimum"},
+		{name: "then smallest zone", p: geoUtils.Point{Latitude: 9, Longitude: 9}, want: "corner"},
+		{name: "outside every zone", p: geoUtils.Point{Latitude: 20, Longitude: 20}, wantErr: ErrOutside},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Match(zones, tt.p)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, got.Name)
+			}
+		})
+	}
+}
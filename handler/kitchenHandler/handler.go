@@ -0,0 +1,195 @@
+package kitchenHandler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"rsm/entity/brandModel"
+	"rsm/entity/kitchenModel"
+	"rsm/pubsub"
+	"rsm/repository/orderRepo"
+	"rsm/service/brandService"
+	"rsm/service/kitchenService"
+	"rsm/service/orderService"
+	"rsm/sse"
+	"strconv"
+	"time"
+)
+
+// HeartbeatInterval keeps idle streams open through proxies that drop quiet connections.
+const HeartbeatInterval = 15 * time.Second
+
+// Authenticator returns the user making the request, or an error if there is none.
+type Authenticator func(r *http.Request) (uuid.UUID, error)
+
+// Handler serves the kitchen screens. Every endpoint is for staff of the restaurant only.
+type Handler struct {
+	log          *logrus.Logger
+	kitchen      kitchenService.ServiceInterface
+	orders       orderService.ServiceInterface
+	brands       brandService.ServiceInterface
+	broker       pubsub.Broker
+	authenticate Authenticator
+}
+
+type actionRequest struct {
+	RestaurantId uuid.UUID           `json:"restaurantId"`
+	OrderId      uuid.UUID           `json:"orderId"`
+	Station      string              `json:"station"`
+	Action       kitchenModel.Action `json:"action"`
+}
+
+func (h *Handler) Routes(mux *http.ServeMux) {
+	mux.HandleFunc("/kitchen/queue", h.Queue)
+	mux.HandleFunc("/kitchen/tickets", h.Act)
+	mux.HandleFunc("/kitchen/stream", h.Stream)
+}
+
+// Queue serves GET /kitchen/queue?restaurantId=...
+func (h *Handler) Queue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	restaurantId, err := uuid.Parse(r.URL.Query().Get("restaurantId"))
+	if err != nil {
+		http.Error(w, "invalid restaurantId", http.StatusBadRequest)
+		return
+	}
+	if !h.staff(w, r, restaurantId) {
+		return
+	}
+	stations, err := h.kitchen.Queue(restaurantId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, stations)
+}
+
+// Act serves POST /kitchen/tickets with a start, bump or recall for one station's ticket of
+// an order at the restaurant.
+func (h *Handler) Act(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request actionRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !h.staff(w, r, request.RestaurantId) {
+		return
+	}
+	order, err := h.orders.GetOrder(request.OrderId)
+	if errors.Is(err, orderRepo.ErrNotFound) || (err == nil && order.RestaurantId != request.RestaurantId) {
+		http.Error(w, "order not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ticket, err := h.kitchen.Act(request.OrderId, request.Station, request.Action)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	h.writeJSON(w, ticket)
+}
+
+// Stream serves GET /kitchen/stream?restaurantId=... as server-sent events. The current
+// queue is sent first as a "queue" event, then every change as a "ticket" event.
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	restaurantId, err := uuid.Parse(r.URL.Query().Get("restaurantId"))
+	if err != nil {
+		http.Error(w, "invalid restaurantId", http.StatusBadRequest)
+		return
+	}
+	if !h.staff(w, r, restaurantId) {
+		return
+	}
+
+	// Subscribe before reading the queue so no change falls between the two.
+	messages, err := h.broker.Subscribe(r.Context(), kitchenService.Topic(restaurantId))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	stations, err := h.kitchen.Queue(restaurantId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	snapshot, err := json.Marshal(stations)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	stream, err := sse.NewWriter(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = stream.Send(sse.Event{Type: "queue", Data: snapshot}); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			err = stream.Heartbeat()
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			err = stream.Send(sse.Event{Id: strconv.FormatInt(msg.Id, 10), Type: "ticket", Data: msg.Payload})
+		}
+		if err != nil {
+			h.log.Debugf("Kitchen stream closed: %v", err)
+			return
+		}
+	}
+}
+
+// staff reports whether the caller works at the restaurant, writing the error response when
+// they do not.
+func (h *Handler) staff(w http.ResponseWriter, r *http.Request, restaurantId uuid.UUID) bool {
+	userId, err := h.authenticate(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	role, err := h.brands.RoleAt(userId, restaurantId)
+	if errors.Is(err, brandModel.ErrForbidden) || (err == nil && !role.AtLeast(brandModel.Staff)) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		h.log.Errorf("Error Writing Response: %v", err)
+	}
+}
+
+func NewKitchenHandler(log *logrus.Logger, kitchen kitchenService.ServiceInterface, orders orderService.ServiceInterface, brands brandService.ServiceInterface, broker pubsub.Broker, authenticate Authenticator) *Handler {
+	return &Handler{log: log, kitchen: kitchen, orders: orders, brands: brands, broker: broker,
+		authenticate: authenticate}
+}
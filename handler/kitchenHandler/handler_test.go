@@ -0,0 +1,229 @@
+package kitchenHandler
+
+import (
+	"bufio"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"rsm/entity/brandModel"
+	"rsm/entity/kitchenModel"
+	"rsm/entity/orderModel"
+	"rsm/pricing"
+	"rsm/pubsub"
+	"rsm/service/kitchenService"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var log = logrus.New()
+
+type MockKitchenService struct {
+	mock.Mock
+}
+
+func (m *MockKitchenService) Queue(restaurantId uuid.UUID) ([]kitchenModel.Station, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]kitchenModel.Station), args.Error(1)
+}
+
+func (m *MockKitchenService) Accept(orderId uuid.UUID) ([]kitchenModel.Ticket, error) {
+	args := m.Called(orderId)
+	return args.Get(0).([]kitchenModel.Ticket), args.Error(1)
+}
+
+func (m *MockKitchenService) Act(orderId uuid.UUID, station string, action kitchenModel.Action) (*kitchenModel.Ticket, error) {
+	args := m.Called(orderId, station, action)
+	return args.Get(0).(*kitchenModel.Ticket), args.Error(1)
+}
+
+func (m *MockKitchenService) Complete(orderId uuid.UUID) error {
+	args := m.Called(orderId)
+	return args.Error(0)
+}
+
+func (m *MockKitchenService) Cancel(orderId uuid.UUID) error {
+	args := m.Called(orderId)
+	return args.Error(0)
+}
+
+type MockOrderService struct {
+	mock.Mock
+}
+
+func (m *MockOrderService) GetOrder(id uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(id)
+	return args.Get(0).(*orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) GetUserOrder(userId, orderId uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(userId, orderId)
+	order, _ := args.Get(0).(*orderModel.Order)
+	return order, args.Error(1)
+}
+
+func (m *MockOrderService) ListByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error) {
+	args := m.Called(restaurantId, statuses)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) UpdateStatus(order *orderModel.Order, to orderModel.Status) error {
+	args := m.Called(order, to)
+	return args.Error(0)
+}
+
+func (m *MockOrderService) Notify(order *orderModel.Order, to orderModel.Status) {
+	m.Called(order, to)
+	order.Status = to
+}
+
+func (m *MockOrderService) ListHistory(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error) {
+	args := m.Called(userId, query)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) Reorder(userId, orderId uuid.UUID) (*pricing.Reorder, error) {
+	args := m.Called(userId, orderId)
+	reorder, _ := args.Get(0).(*pricing.Reorder)
+	return reorder, args.Error(1)
+}
+
+type MockBrandService struct {
+	mock.Mock
+}
+
+func (m *MockBrandService) CreateBrand(ownerId uuid.UUID, brand *brandModel.Brand) error {
+	args := m.Called(ownerId, brand)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) AddLocation(userId, brandId, restaurantId uuid.UUID) error {
+	args := m.Called(userId, brandId, restaurantId)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) SetMember(userId uuid.UUID, member *brandModel.Member) error {
+	args := m.Called(userId, member)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) RemoveMember(userId, brandId, memberId uuid.UUID) error {
+	args := m.Called(userId, brandId, memberId)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) ListMembers(userId, brandId uuid.UUID) ([]brandModel.Member, error) {
+	args := m.Called(userId, brandId)
+	return args.Get(0).([]brandModel.Member), args.Error(1)
+}
+
+func (m *MockBrandService) RoleAt(userId, restaurantId uuid.UUID) (brandModel.Role, error) {
+	args := m.Called(userId, restaurantId)
+	return args.Get(0).(brandModel.Role), args.Error(1)
+}
+
+func (m *MockBrandService) SaveMasterItem(userId uuid.UUID, item *brandModel.MasterItem) error {
+	args := m.Called(userId, item)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) DeleteMasterItem(userId, brandId, id uuid.UUID) error {
+	args := m.Called(userId, brandId, id)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) SetOverride(userId uuid.UUID, override *brandModel.Override) error {
+	args := m.Called(userId, override)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) LocationMenu(restaurantId uuid.UUID) ([]brandModel.LocationItem, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]brandModel.LocationItem), args.Error(1)
+}
+
+// headerAuth trusts an X-User-Id header, which is enough to exercise the handler.
+func headerAuth(r *http.Request) (uuid.UUID, error) {
+	return uuid.Parse(r.Header.Get("X-User-Id"))
+}
+
+func TestHandler_Stream(t *testing.T) {
+	restaurantId, cookId := uuid.New(), uuid.New()
+	mockKitchen := new(MockKitchenService)
+	mockKitchen.On("Queue", restaurantId).Return([]kitchenModel.Station{}, nil)
+	mockBrands := new(MockBrandService)
+	mockBrands.On("RoleAt", cookId, restaurantId).Return(brandModel.Staff, nil)
+	broker := pubsub.NewMemoryBroker()
+
+	mux := http.NewServeMux()
+	NewKitchenHandler(log, mockKitchen, new(MockOrderService), mockBrands, broker, headerAuth).Routes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/kitchen/stream?restaurantId="+restaurantId.String(), nil)
+	req.Header.Set("X-User-Id", cookId.String())
+	res, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(res.Body)
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			assert.Nil(t, err)
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	assert.Equal(t, "event: queue\ndata: []\n", readEvent())
+
+	msg, _ := broker.Publish(kitchenService.Topic(restaurantId), []byte(`{"action":"bump"}`))
+	_, _ = broker.Publish(kitchenService.Topic(uuid.New()), []byte(`{"action":"start"}`))
+	assert.Equal(t, "id: "+strconv.FormatInt(msg.Id, 10)+"\nevent: ticket\ndata: {\"action\":\"bump\"}\n", readEvent())
+}
+
+func TestHandler_Queue_InvalidRestaurant(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewKitchenHandler(log, new(MockKitchenService), new(MockOrderService), new(MockBrandService),
+		pubsub.NewMemoryBroker(), headerAuth).
+		Queue(rec, httptest.NewRequest(http.MethodGet, "/kitchen/queue?restaurantId=nope", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_Act(t *testing.T) {
+	restaurantId, cookId, strangerId := uuid.New(), uuid.New(), uuid.New()
+	ours := &orderModel.Order{Id: uuid.New(), RestaurantId: restaurantId}
+	theirs := &orderModel.Order{Id: uuid.New(), RestaurantId: uuid.New()}
+	mockKitchen := new(MockKitchenService)
+	mockKitchen.On("Act", ours.Id, "grill", kitchenModel.Start).Return(&kitchenModel.Ticket{RestaurantId: restaurantId}, nil)
+	mockOrders := new(MockOrderService)
+	mockOrders.On("GetOrder", ours.Id).Return(ours, nil)
+	mockOrders.On("GetOrder", theirs.Id).Return(theirs, nil)
+	mockBrands := new(MockBrandService)
+	mockBrands.On("RoleAt", cookId, restaurantId).Return(brandModel.Staff, nil)
+	mockBrands.On("RoleAt", strangerId, restaurantId).Return(brandModel.Role(""), brandModel.ErrForbidden)
+	h := NewKitchenHandler(log, mockKitchen, mockOrders, mockBrands, pubsub.NewMemoryBroker(), headerAuth)
+
+	act := func(userId string, orderId uuid.UUID) int {
+		body := `{"restaurantId":"` + restaurantId.String() + `","orderId":"` + orderId.String() +
+			`","station":"grill","action":"start"}`
+		req := httptest.NewRequest(http.MethodPost, "/kitchen/tickets", strings.NewReader(body))
+		req.Header.Set("X-User-Id", userId)
+		rec := httptest.NewRecorder()
+		h.Act(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, act("", ours.Id))
+	assert.Equal(t, http.StatusForbidden, act(strangerId.String(), ours.Id))
+	assert.Equal(t, http.StatusNotFound, act(cookId.String(), theirs.Id))
+	assert.Equal(t, http.StatusOK, act(cookId.String(), ours.Id))
+	mockKitchen.AssertNumberOfCalls(t, "Act", 1)
+}
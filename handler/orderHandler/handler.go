@@ -0,0 +1,158 @@
+package orderHandler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"rsm/entity/orderModel"
+	"rsm/pubsub"
+	"rsm/repository/orderRepo"
+	"rsm/service/orderService"
+	"rsm/sse"
+	"strconv"
+	"time"
+)
+
+// HeartbeatInterval keeps idle streams open through proxies that drop quiet connections.
+const HeartbeatInterval = 15 * time.Second
+
+// Authenticator returns the user making the request, or an error if there is none.
+type Authenticator func(r *http.Request) (uuid.UUID, error)
+
+type Handler struct {
+	log          *logrus.Logger
+	orders       orderService.ServiceInterface
+	broker       pubsub.Broker
+	authenticate Authenticator
+}
+
+func (h *Handler) Routes(mux *http.ServeMux) {
+	mux.HandleFunc("/orders/stream", h.Stream)
+}
+
+// Stream serves GET /orders/stream as server-sent "status" events for the caller's orders,
+// or only for ?orderId=... when given. A client that reconnects with Last-Event-ID (or
+// ?lastEventId=... where it cannot set headers) first gets the events it missed, or a
+// "reset" event when there are too many to replay.
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.authenticate(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var orderId *uuid.UUID
+	if raw := r.URL.Query().Get("orderId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			http.Error(w, "invalid orderId", http.StatusBadRequest)
+			return
+		}
+		_, err = h.orders.GetUserOrder(userId, id)
+		if errors.Is(err, orderRepo.ErrNotFound) {
+			http.Error(w, "order not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		orderId = &id
+	}
+
+	lastId, err := lastEventId(r)
+	if err != nil {
+		http.Error(w, "invalid last event id", http.StatusBadRequest)
+		return
+	}
+
+	// Subscribe before replaying so nothing published in between is lost. Replayed ids are
+	// remembered so a message that arrives both ways is only sent once.
+	topic := orderService.UserTopic(userId)
+	messages, err := h.broker.Subscribe(r.Context(), topic)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	var missed []pubsub.Message
+	reset := false
+	if lastId > 0 {
+		missed, err = h.broker.Since(topic, lastId)
+		if errors.Is(err, pubsub.ErrTooFarBehind) {
+			missed, reset = nil, true
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	stream, err := sse.NewWriter(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// A client too far behind is told to reload its orders; the stream then carries on live.
+	if reset {
+		if err = stream.Send(sse.Event{Type: "reset"}); err != nil {
+			return
+		}
+	}
+	replayed := map[int64]bool{}
+	send := func(msg pubsub.Message) error {
+		if msg.Id <= lastId || replayed[msg.Id] {
+			return nil
+		}
+		if orderId != nil && !forOrder(msg, *orderId) {
+			return nil
+		}
+		return stream.Send(sse.Event{Id: strconv.FormatInt(msg.Id, 10), Type: "status", Data: msg.Payload})
+	}
+	for _, msg := range missed {
+		if err = send(msg); err != nil {
+			return
+		}
+		replayed[msg.Id] = true
+	}
+
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			err = stream.Heartbeat()
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			err = send(msg)
+		}
+		if err != nil {
+			h.log.Debugf("Order stream closed: %v", err)
+			return
+		}
+	}
+}
+
+func lastEventId(r *http.Request) (int64, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("lastEventId")
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(raw, 10, 64)
+}
+
+func forOrder(msg pubsub.Message, orderId uuid.UUID) bool {
+	var event orderModel.StatusEvent
+	return json.Unmarshal(msg.Payload, &event) == nil && event.OrderId == orderId
+}
+
+func NewOrderHandler(log *logrus.Logger, orders orderService.ServiceInterface, broker pubsub.Broker, authenticate Authenticator) *Handler {
+	return &Handler{log: log, orders: orders, broker: broker, authenticate: authenticate}
+}
@@ -0,0 +1,194 @@
+package orderHandler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"rsm/entity/orderModel"
+	"rsm/pricing"
+	"rsm/pubsub"
+	"rsm/repository/orderRepo"
+	"rsm/service/orderService"
+	"strings"
+	"testing"
+)
+
+var log = logrus.New()
+
+type MockOrderService struct {
+	mock.Mock
+}
+
+func (m *MockOrderService) GetOrder(id uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(id)
+	return args.Get(0).(*orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) GetUserOrder(userId, orderId uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(userId, orderId)
+	order, _ := args.Get(0).(*orderModel.Order)
+	return order, args.Error(1)
+}
+
+func (m *MockOrderService) ListByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error) {
+	args := m.Called(restaurantId, statuses)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) UpdateStatus(order *orderModel.Order, to orderModel.Status) error {
+	args := m.Called(order, to)
+	return args.Error(0)
+}
+
+func (m *MockOrderService) Notify(order *orderModel.Order, to orderModel.Status) {
+	m.Called(order, to)
+	order.Status = to
+}
+
+func (m *MockOrderService) ListHistory(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error) {
+	args := m.Called(userId, query)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) Reorder(userId, orderId uuid.UUID) (*pricing.Reorder, error) {
+	args := m.Called(userId, orderId)
+	reorder, _ := args.Get(0).(*pricing.Reorder)
+	return reorder, args.Error(1)
+}
+
+// headerAuth trusts an X-User-Id header, which is enough to exercise the handler.
+func headerAuth(r *http.Request) (uuid.UUID, error) {
+	return uuid.Parse(r.Header.Get("X-User-Id"))
+}
+
+func publish(broker pubsub.Broker, userId, orderId uuid.UUID, status orderModel.Status) *pubsub.Message {
+	payload, _ := json.Marshal(orderModel.StatusEvent{OrderId: orderId, Status: status})
+	msg, _ := broker.Publish(orderService.UserTopic(userId), payload)
+	return msg
+}
+
+type eventReader struct {
+	t      *testing.T
+	reader *bufio.Reader
+}
+
+// next returns the id and data of the next event, skipping heartbeats.
+func (e eventReader) next() (string, orderModel.StatusEvent) {
+	var id string
+	var event orderModel.StatusEvent
+	for {
+		line, err := e.reader.ReadString('\n')
+		assert.Nil(e.t, err)
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimSpace(strings.TrimPrefix(line, "id: "))
+		case strings.HasPrefix(line, "data: "):
+			assert.Nil(e.t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+		case line == "\n" && id != "":
+			return id, event
+		}
+	}
+}
+
+func TestHandler_Stream_Resume(t *testing.T) {
+	userId, orderId, otherOrder := uuid.New(), uuid.New(), uuid.New()
+	mockOrders := new(MockOrderService)
+	mockOrders.On("GetUserOrder", userId, orderId).Return(&orderModel.Order{Id: orderId, UserId: userId}, nil)
+	broker := pubsub.NewMemoryBroker()
+
+	mux := http.NewServeMux()
+	NewOrderHandler(log, mockOrders, broker, headerAuth).Routes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	seen := publish(broker, userId, orderId, orderModel.Accepted)
+	publish(broker, userId, otherOrder, orderModel.Accepted)
+	missed := publish(broker, userId, orderId, orderModel.Preparing)
+	publish(broker, uuid.New(), orderId, orderModel.Cancelled)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/orders/stream?orderId="+orderId.String(), nil)
+	req.Header.Set("X-User-Id", userId.String())
+	req.Header.Set("Last-Event-ID", fmt.Sprint(seen.Id))
+	res, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	events := eventReader{t: t, reader: bufio.NewReader(res.Body)}
+
+	id, event := events.next()
+	assert.Equal(t, fmt.Sprint(missed.Id), id)
+	assert.Equal(t, orderModel.Preparing, event.Status)
+
+	publish(broker, userId, otherOrder, orderModel.Ready)
+	live := publish(broker, userId, orderId, orderModel.Ready)
+	id, event = events.next()
+	assert.Equal(t, fmt.Sprint(live.Id), id)
+	assert.Equal(t, orderModel.Ready, event.Status)
+}
+
+func TestHandler_Stream_Rejects(t *testing.T) {
+	userId, orderId := uuid.New(), uuid.New()
+	mockOrders := new(MockOrderService)
+	mockOrders.On("GetUserOrder", userId, orderId).Return(nil, orderRepo.ErrNotFound)
+	h := NewOrderHandler(log, mockOrders, pubsub.NewMemoryBroker(), headerAuth)
+
+	rec := httptest.NewRecorder()
+	h.Stream(rec, httptest.NewRequest(http.MethodGet, "/orders/stream", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/orders/stream?orderId="+orderId.String(), nil)
+	req.Header.Set("X-User-Id", userId.String())
+	h.Stream(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/orders/stream", nil)
+	req.Header.Set("X-User-Id", userId.String())
+	req.Header.Set("Last-Event-ID", "abc")
+	h.Stream(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_Stream_Reset(t *testing.T) {
+	userId, orderId := uuid.New(), uuid.New()
+	broker := pubsub.NewMemoryBroker()
+
+	mux := http.NewServeMux()
+	NewOrderHandler(log, new(MockOrderService), broker, headerAuth).Routes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	seen := publish(broker, userId, orderId, orderModel.Accepted)
+	for i := 0; i <= pubsub.HistorySize; i++ {
+		publish(broker, userId, orderId, orderModel.Preparing)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/orders/stream", nil)
+	req.Header.Set("X-User-Id", userId.String())
+	req.Header.Set("Last-Event-ID", fmt.Sprint(seen.Id))
+	res, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	reader := bufio.NewReader(res.Body)
+
+	line, _ := reader.ReadString('\n')
+	assert.Equal(t, "event: reset\n", line)
+	_, _ = reader.ReadString('\n')
+	_, _ = reader.ReadString('\n')
+
+	live := publish(broker, userId, orderId, orderModel.Ready)
+	id, event := eventReader{t: t, reader: reader}.next()
+	assert.Equal(t, fmt.Sprint(live.Id), id)
+	assert.Equal(t, orderModel.Ready, event.Status)
+}
@@ -0,0 +1,72 @@
+package geoUtils
+
+import "math"
+
+// EarthRadiusKm is the mean earth radius used for all distance calculations.
+const EarthRadiusKm = 6371.0088
+
+type Point struct {
+	Latitude  float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude float64 `json:"longitude" validate:"min=-180,max=180"`
+}
+
+type BoundingBox struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// Haversine returns the great-circle distance between a and b in kilometres.
+func Haversine(a, b Point) float64 {
+	dLat := radians(b.Latitude - a.Latitude)
+	dLng := radians(b.Longitude - a.Longitude)
+	h := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(radians(a.Latitude))*math.Cos(radians(b.Latitude))*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBoxAround returns a box that contains every point within radiusKm of center.
+// The box is a superset of the circle and is only meant as a cheap prefilter; when it
+// would cross a pole or the antimeridian the longitude range is widened to the full globe.
+func BoundingBoxAround(center Point, radiusKm float64) BoundingBox {
+	angular := radiusKm / EarthRadiusKm
+	minLat := center.Latitude - degrees(angular)
+	maxLat := center.Latitude + degrees(angular)
+
+	if minLat <= -90 || maxLat >= 90 {
+		return BoundingBox{
+			MinLatitude:  math.Max(minLat, -90),
+			MaxLatitude:  math.Min(maxLat, 90),
+			MinLongitude: -180,
+			MaxLongitude: 180,
+		}
+	}
+
+	dLng := degrees(math.Asin(math.Sin(angular) / math.Cos(radians(center.Latitude))))
+	minLng := center.Longitude - dLng
+	maxLng := center.Longitude + dLng
+	if minLng < -180 || maxLng > 180 {
+		minLng, maxLng = -180, 180
+	}
+
+	return BoundingBox{
+		MinLatitude:  minLat,
+		MaxLatitude:  maxLat,
+		MinLongitude: minLng,
+		MaxLongitude: maxLng,
+	}
+}
+
+func (b BoundingBox) Contains(p Point) bool {
+	return p.Latitude >= b.MinLatitude && p.Latitude <= b.MaxLatitude &&
+		p.Longitude >= b.MinLongitude && p.Longitude <= b.MaxLongitude
+}
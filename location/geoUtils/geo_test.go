@@ -0,0 +1,43 @@
+package geoUtils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHaversine(t *testing.T) {
+	lagos := Point{Latitude: 6.5244, Longitude: 3.3792}
+	abuja := Point{Latitude: 9.0765, Longitude: 7.3986}
+
+	tests := []struct {
+		name string
+		a, b Point
+		want float64
+	}{
+		{name: "same point", a: lagos, b: lagos, want: 0},
+		{name: "lagos to abuja", a: lagos, b: abuja, want: 525.9},
+		{name: "symmetric", a: abuja, b: lagos, want: 525.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, Haversine(tt.a, tt.b), 1)
+		})
+	}
+}
+
+func TestBoundingBoxAround(t *testing.T) {
+	center := Point{Latitude: 6.5244, Longitude: 3.3792}
+	box := BoundingBoxAround(center, 10)
+
+	assert.True(t, box.Contains(center))
+	assert.True(t, box.Contains(Point{Latitude: 6.60, Longitude: 3.40}))
+	assert.False(t, box.Contains(Point{Latitude: 7.00, Longitude: 3.3792}))
+
+	polar := BoundingBoxAround(Point{Latitude: 89.99, Longitude: 0}, 50)
+	assert.Equal(t, 90.0, polar.MaxLatitude)
+	assert.Equal(t, -180.0, polar.MinLongitude)
+	assert.Equal(t, 180.0, polar.MaxLongitude)
+
+	antimeridian := BoundingBoxAround(Point{Latitude: 0, Longitude: 179.95}, 20)
+	assert.True(t, antimeridian.Contains(Point{Latitude: 0, Longitude: -179.95}))
+}
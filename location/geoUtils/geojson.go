@@ -0,0 +1,100 @@
+package geoUtils
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// geometry is a GeoJSON geometry object. Positions are [longitude, latitude], optionally
+// followed by an altitude that is ignored.
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates,omitempty"`
+	Geometry    *geometry       `json:"geometry,omitempty"`
+}
+
+// ParseGeoJSON reads a Polygon or MultiPolygon geometry, or a Feature holding one.
+func ParseGeoJSON(data []byte) (Area, error) {
+	var g geometry
+	err := json.Unmarshal(data, &g)
+	if err != nil {
+		return nil, err
+	}
+	if g.Type == "Feature" {
+		if g.Geometry == nil {
+			return nil, fmt.Errorf("feature has no geometry")
+		}
+		g = *g.Geometry
+	}
+
+	var polygons [][][][]float64
+	switch g.Type {
+	case "Polygon":
+		var polygon [][][]float64
+		err = json.Unmarshal(g.Coordinates, &polygon)
+		polygons = append(polygons, polygon)
+	case "MultiPolygon":
+		err = json.Unmarshal(g.Coordinates, &polygons)
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q, expected Polygon or MultiPolygon", g.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	area := make(Area, 0, len(polygons))
+	for i, polygon := range polygons {
+		pg := make(Polygon, 0, len(polygon))
+		for j, ring := range polygon {
+			if len(ring) < 4 {
+				return nil, fmt.Errorf("polygon %d ring %d needs at least 4 positions, has %d", i, j, len(ring))
+			}
+			r := make(Ring, 0, len(ring)-1)
+			for _, position := range ring {
+				if len(position) < 2 {
+					return nil, fmt.Errorf("polygon %d ring %d has a position without longitude and latitude", i, j)
+				}
+				r = append(r, Point{Latitude: position[1], Longitude: position[0]})
+			}
+			if r[0] != r[len(r)-1] {
+				return nil, fmt.Errorf("polygon %d ring %d is not closed", i, j)
+			}
+			pg = append(pg, r[:len(r)-1])
+		}
+		area = append(area, pg)
+	}
+	return area, area.Validate()
+}
+
+// MarshalJSON writes the area as a GeoJSON MultiPolygon with closed rings.
+func (a Area) MarshalJSON() ([]byte, error) {
+	polygons := make([][][][]float64, 0, len(a))
+	for _, pg := range a {
+		polygon := make([][][]float64, 0, len(pg))
+		for _, r := range pg {
+			ring := make([][]float64, 0, len(r)+1)
+			for _, p := range r {
+				ring = append(ring, []float64{p.Longitude, p.Latitude})
+			}
+			if len(r) > 0 {
+				ring = append(ring, []float64{r[0].Longitude, r[0].Latitude})
+			}
+			polygon = append(polygon, ring)
+		}
+		polygons = append(polygons, polygon)
+	}
+	coordinates, err := json.Marshal(polygons)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(geometry{Type: "MultiPolygon", Coordinates: coordinates})
+}
+
+func (a *Area) UnmarshalJSON(data []byte) error {
+	area, err := ParseGeoJSON(data)
+	if err != nil {
+		return err
+	}
+	*a = area
+	return nil
+}
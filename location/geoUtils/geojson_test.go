@@ -0,0 +1,55 @@
+package geoUtils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseGeoJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Area
+		wantErr bool
+	}{
+		{
+			name: "polygon",
+			data: `{"type":"Polygon","coordinates":[[[3.3,6.4],[3.5,6.4],[3.5,6.6],[3.3,6.4]]]}`,
+			want: Area{Polygon{Ring{{Latitude: 6.4, Longitude: 3.3}, {Latitude: 6.4, Longitude: 3.5}, {Latitude: 6.6, Longitude: 3.5}}}},
+		}, {
+			name: "feature with multipolygon",
+			data: `{"type":"Feature","properties":{},"geometry":{"type":"MultiPolygon","coordinates":[
+				[[[0,0],[1,0],[1,1],[0,0]]],[[[5,5,12],[6,5,12],[6,6,12],[5,5,12]]]]}}`,
+			want: Area{
+				Polygon{Ring{{Latitude: 0, Longitude: 0}, {Latitude: 0, Longitude: 1}, {Latitude: 1, Longitude: 1}}},
+				Polygon{Ring{{Latitude: 5, Longitude: 5}, {Latitude: 5, Longitude: 6}, {Latitude: 6, Longitude: 6}}},
+			},
+		},
+		{name: "point", data: `{"type":"Point","coordinates":[3.3,6.4]}`, wantErr: true},
+		{name: "open ring", data: `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}`, wantErr: true},
+		{name: "too few positions", data: `{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`, wantErr: true},
+		{name: "latitude out of range", data: `{"type":"Polygon","coordinates":[[[0,0],[1,95],[1,1],[0,0]]]}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGeoJSON([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseGeoJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestArea_JSONRoundTrip(t *testing.T) {
+	area := Area{Polygon{square(0, 0, 10, 10), square(4, 4, 6, 6)}}
+	data, err := area.MarshalJSON()
+	assert.Nil(t, err)
+
+	var got Area
+	assert.Nil(t, got.UnmarshalJSON(data))
+	assert.Equal(t, area, got)
+}
@@ -0,0 +1,141 @@
+package geoUtils
+
+import (
+	"fmt"
+	"math"
+)
+
+// Ring is a closed line of points; the last point joins back to the first, so it is not
+// repeated.
+type Ring []Point
+
+// Polygon is an outer ring followed by any holes cut out of it.
+type Polygon []Ring
+
+// Area is one or more polygons, the GeoJSON MultiPolygon.
+//
+// Containment treats latitude and longitude as plane coordinates. That is accurate enough
+// for city-sized areas, but an area must not cross the antimeridian.
+type Area []Polygon
+
+// contains reports whether p is inside the ring and whether it lies on its edge. It casts a
+// ray east from p and counts the edges it crosses (even-odd rule).
+func (r Ring) contains(p Point) (inside, onEdge bool) {
+	for i := range r {
+		a, b := r[i], r[(i+1)%len(r)]
+		if onSegment(p, a, b) {
+			return true, true
+		}
+		if (a.Latitude > p.Latitude) != (b.Latitude > p.Latitude) {
+			crossing := a.Longitude + (p.Latitude-a.Latitude)*(b.Longitude-a.Longitude)/(b.Latitude-a.Latitude)
+			if p.Longitude < crossing {
+				inside = !inside
+			}
+		}
+	}
+	return inside, false
+}
+
+// onSegment allows for the rounding error of coordinates that went through JSON.
+func onSegment(p, a, b Point) bool {
+	const epsilon = 1e-12
+	cross := (b.Longitude-a.Longitude)*(p.Latitude-a.Latitude) - (b.Latitude-a.Latitude)*(p.Longitude-a.Longitude)
+	if math.Abs(cross) > epsilon {
+		return false
+	}
+	return p.Longitude >= math.Min(a.Longitude, b.Longitude)-epsilon && p.Longitude <= math.Max(a.Longitude, b.Longitude)+epsilon &&
+		p.Latitude >= math.Min(a.Latitude, b.Latitude)-epsilon && p.Latitude <= math.Max(a.Latitude, b.Latitude)+epsilon
+}
+
+// Contains reports whether p is inside the outer ring and not inside a hole. Points on the
+// outer boundary, or on the boundary of a hole, count as inside.
+func (pg Polygon) Contains(p Point) bool {
+	if len(pg) == 0 {
+		return false
+	}
+	if inside, _ := pg[0].contains(p); !inside {
+		return false
+	}
+	for _, hole := range pg[1:] {
+		if inside, onEdge := hole.contains(p); inside && !onEdge {
+			return false
+		}
+	}
+	return true
+}
+
+func (a Area) Contains(p Point) bool {
+	for _, pg := range a {
+		if pg.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// size is the ring's area in square degrees, by the shoelace formula.
+func (r Ring) size() float64 {
+	sum := 0.0
+	for i := range r {
+		a, b := r[i], r[(i+1)%len(r)]
+		sum += a.Longitude*b.Latitude - b.Longitude*a.Latitude
+	}
+	return math.Abs(sum) / 2
+}
+
+// Size returns the area covered in square degrees, holes excluded. It is only meant for
+// comparing areas that lie close together.
+func (a Area) Size() float64 {
+	total := 0.0
+	for _, pg := range a {
+		for i, r := range pg {
+			if i == 0 {
+				total += r.size()
+			} else {
+				total -= r.size()
+			}
+		}
+	}
+	return total
+}
+
+// Bounds returns the smallest box around every outer ring.
+func (a Area) Bounds() BoundingBox {
+	box := BoundingBox{MinLatitude: 90, MaxLatitude: -90, MinLongitude: 180, MaxLongitude: -180}
+	for _, pg := range a {
+		if len(pg) == 0 {
+			continue
+		}
+		for _, p := range pg[0] {
+			box.MinLatitude = math.Min(box.MinLatitude, p.Latitude)
+			box.MaxLatitude = math.Max(box.MaxLatitude, p.Latitude)
+			box.MinLongitude = math.Min(box.MinLongitude, p.Longitude)
+			box.MaxLongitude = math.Max(box.MaxLongitude, p.Longitude)
+		}
+	}
+	return box
+}
+
+// Validate checks the area has at least one polygon, every ring has three distinct corners
+// and every point is a real coordinate.
+func (a Area) Validate() error {
+	if len(a) == 0 {
+		return fmt.Errorf("area has no polygons")
+	}
+	for i, pg := range a {
+		if len(pg) == 0 {
+			return fmt.Errorf("polygon %d has no rings", i)
+		}
+		for j, r := range pg {
+			if len(r) < 3 {
+				return fmt.Errorf("polygon %d ring %d needs at least 3 points, has %d", i, j, len(r))
+			}
+			for _, p := range r {
+				if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+					return fmt.Errorf("polygon %d ring %d has invalid point %v,%v", i, j, p.Latitude, p.Longitude)
+				}
+			}
+		}
+	}
+	return nil
+}
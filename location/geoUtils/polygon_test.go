@@ -0,0 +1,62 @@
+package geoUtils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func square(minLat, minLng, maxLat, maxLng float64) Ring {
+	return Ring{
+		{Latitude: minLat, Longitude: minLng},
+		{Latitude: minLat, Longitude: maxLng},
+		{Latitude: maxLat, Longitude: maxLng},
+		{Latitude: maxLat, Longitude: minLng},
+	}
+}
+
+func TestArea_Contains(t *testing.T) {
+	// A 10x10 block with a 2x2 hole in the middle, and a separate island to the east.
+	area := Area{
+		Polygon{square(0, 0, 10, 10), square(4, 4, 6, 6)},
+		Polygon{square(0, 20, 2, 22)},
+	}
+	concave := Area{Polygon{Ring{
+		{Latitude: 0, Longitude: 0}, {Latitude: 0, Longitude: 10}, {Latitude: 10, Longitude: 10},
+		{Latitude: 10, Longitude: 8}, {Latitude: 2, Longitude: 8}, {Latitude: 2, Longitude: 2},
+		{Latitude: 10, Longitude: 2}, {Latitude: 10, Longitude: 0},
+	}}}
+
+	tests := []struct {
+		name string
+		area Area
+		p    Point
+		want bool
+	}{
+		{name: "inside", area: area, p: Point{Latitude: 1, Longitude: 1}, want: true},
+		{name: "in the hole", area: area, p: Point{Latitude: 5, Longitude: 5}, want: false},
+		{name: "on the hole's edge", area: area, p: Point{Latitude: 4, Longitude: 5}, want: true},
+		{name: "on the outer edge", area: area, p: Point{Latitude: 0, Longitude: 5}, want: true},
+		{name: "on a corner", area: area, p: Point{Latitude: 10, Longitude: 10}, want: true},
+		{name: "outside", area: area, p: Point{Latitude: 11, Longitude: 5}, want: false},
+		{name: "level with a vertex", area: area, p: Point{Latitude: 10, Longitude: -1}, want: false},
+		{name: "second polygon", area: area, p: Point{Latitude: 1, Longitude: 21}, want: true},
+		{name: "between polygons", area: area, p: Point{Latitude: 1, Longitude: 15}, want: false},
+		{name: "concave arm", area: concave, p: Point{Latitude: 9, Longitude: 1}, want: true},
+		{name: "concave notch", area: concave, p: Point{Latitude: 9, Longitude: 5}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.area.Contains(tt.p))
+		})
+	}
+}
+
+func TestArea_Bounds(t *testing.T) {
+	area := Area{Polygon{square(0, 0, 10, 10)}, Polygon{square(-2, 20, 2, 22)}}
+	assert.Equal(t, BoundingBox{MinLatitude: -2, MaxLatitude: 10, MinLongitude: 0, MaxLongitude: 22}, area.Bounds())
+}
+
+func TestArea_Size(t *testing.T) {
+	area := Area{Polygon{square(0, 0, 10, 10), square(4, 4, 6, 6)}, Polygon{square(0, 20, 2, 22)}}
+	assert.Equal(t, 100.0-4+4, area.Size())
+}
@@ -26,3 +26,780 @@ CREATE TABLE IF NOT EXISTS "Menu" (
 );
 
 ALTER TABLE "Menu" ADD FOREIGN KEY ("restaurant_id") REFERENCES "Restaurants" ("id");
+
+ALTER TABLE "Restaurants" ADD COLUMN IF NOT EXISTS "cuisine" varchar;
+ALTER TABLE "Restaurants" ADD COLUMN IF NOT EXISTS "latitude" double precision;
+ALTER TABLE "Restaurants" ADD COLUMN IF NOT EXISTS "longitude" double precision;
+
+CREATE INDEX IF NOT EXISTS "restaurants_coordinates_idx" ON "Restaurants" ("latitude", "longitude");
+
+CREATE TABLE IF NOT EXISTS "RestaurantHours" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id") ON DELETE CASCADE,
+  "day_of_week" smallint NOT NULL CHECK ("day_of_week" BETWEEN 0 AND 6),
+  "opens_at" smallint NOT NULL CHECK ("opens_at" BETWEEN 0 AND 1439),
+  "closes_at" smallint NOT NULL CHECK ("closes_at" BETWEEN 1 AND 1440 AND "closes_at" > "opens_at")
+);
+
+CREATE INDEX IF NOT EXISTS "restaurant_hours_lookup_idx" ON "RestaurantHours" ("restaurant_id", "day_of_week");
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE "Restaurants" ADD COLUMN IF NOT EXISTS "search_vector" tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', coalesce("name", '')), 'A') ||
+  setweight(to_tsvector('english', coalesce("cuisine", '')), 'B') ||
+  setweight(to_tsvector('english', coalesce("description", '')), 'C')
+) STORED;
+
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "search_vector" tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', coalesce("item", '')), 'A') ||
+  setweight(to_tsvector('english', coalesce("item_type", '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS "restaurants_search_idx" ON "Restaurants" USING gin ("search_vector");
+CREATE INDEX IF NOT EXISTS "menu_search_idx" ON "Menu" USING gin ("search_vector");
+CREATE INDEX IF NOT EXISTS "restaurants_name_trgm_idx" ON "Restaurants" USING gin ("name" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS "menu_item_trgm_idx" ON "Menu" USING gin ("item" gin_trgm_ops);
+
+ALTER TABLE "Restaurants" ADD COLUMN IF NOT EXISTS "owner_id" uuid REFERENCES "User" ("id");
+ALTER TABLE "Restaurants" ADD COLUMN IF NOT EXISTS "rating_count" int NOT NULL DEFAULT 0;
+ALTER TABLE "Restaurants" ADD COLUMN IF NOT EXISTS "rating_total" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "rating_count" int NOT NULL DEFAULT 0;
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "rating_total" bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "Orders" (
+  "id" uuid PRIMARY KEY,
+  "user_id" uuid NOT NULL REFERENCES "User" ("id"),
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id"),
+  "status" varchar NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "orders_user_idx" ON "Orders" ("user_id", "created_at");
+CREATE INDEX IF NOT EXISTS "orders_restaurant_status_idx" ON "Orders" ("restaurant_id", "status");
+
+CREATE TABLE IF NOT EXISTS "OrderItems" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "order_id" uuid NOT NULL REFERENCES "Orders" ("id") ON DELETE CASCADE,
+  "menu_id" bigint NOT NULL REFERENCES "Menu" ("id"),
+  "item" varchar NOT NULL,
+  "item_type" varchar NOT NULL,
+  "quantity" int NOT NULL CHECK ("quantity" > 0),
+  "unit_price" bigint NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "order_items_order_idx" ON "OrderItems" ("order_id");
+
+CREATE TABLE IF NOT EXISTS "Reviews" (
+  "id" uuid PRIMARY KEY,
+  "user_id" uuid NOT NULL REFERENCES "User" ("id"),
+  "order_id" uuid NOT NULL REFERENCES "Orders" ("id"),
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id"),
+  "menu_id" bigint REFERENCES "Menu" ("id"),
+  "rating" smallint NOT NULL CHECK ("rating" BETWEEN 1 AND 5),
+  "comment" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL,
+  "reply_owner_id" uuid REFERENCES "User" ("id"),
+  "reply_body" varchar,
+  "reply_created_at" timestamptz,
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "reviews_one_per_target_idx"
+  ON "Reviews" ("user_id", "order_id", "restaurant_id", coalesce("menu_id", 0));
+CREATE INDEX IF NOT EXISTS "reviews_restaurant_idx" ON "Reviews" ("restaurant_id", "status", "created_at");
+CREATE INDEX IF NOT EXISTS "reviews_menu_idx" ON "Reviews" ("menu_id", "status", "created_at");
+
+CREATE TABLE IF NOT EXISTS "ReviewReports" (
+  "review_id" uuid NOT NULL REFERENCES "Reviews" ("id") ON DELETE CASCADE,
+  "reporter_id" uuid NOT NULL REFERENCES "User" ("id"),
+  "reason" varchar NOT NULL,
+  "note" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("review_id", "reporter_id")
+);
+
+CREATE TABLE IF NOT EXISTS "Payments" (
+  "id" uuid PRIMARY KEY,
+  "order_id" uuid NOT NULL REFERENCES "Orders" ("id"),
+  "user_id" uuid NOT NULL REFERENCES "User" ("id"),
+  "idempotency_key" varchar NOT NULL UNIQUE,
+  "provider" varchar NOT NULL,
+  "provider_ref" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "captured_amount" bigint NOT NULL DEFAULT 0,
+  "refunded_amount" bigint NOT NULL DEFAULT 0 CHECK ("refunded_amount" <= "captured_amount"),
+  "failure_reason" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "payments_order_idx" ON "Payments" ("order_id");
+CREATE INDEX IF NOT EXISTS "payments_provider_ref_idx" ON "Payments" ("provider", "provider_ref");
+
+CREATE TABLE IF NOT EXISTS "PaymentRefunds" (
+  "id" uuid PRIMARY KEY,
+  "payment_id" uuid NOT NULL REFERENCES "Payments" ("id"),
+  "idempotency_key" varchar NOT NULL,
+  "provider_ref" varchar NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "created_at" timestamptz NOT NULL,
+  UNIQUE ("payment_id", "idempotency_key")
+);
+
+CREATE TABLE IF NOT EXISTS "PaymentEvents" (
+  "id" varchar PRIMARY KEY,
+  "payment_id" uuid NOT NULL REFERENCES "Payments" ("id"),
+  "type" varchar NOT NULL,
+  "received_at" timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "LedgerAccounts" (
+  "id" uuid PRIMARY KEY,
+  "type" varchar NOT NULL,
+  "owner_id" uuid NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  UNIQUE ("type", "owner_id", "currency")
+);
+
+CREATE TABLE IF NOT EXISTS "JournalEntries" (
+  "id" uuid PRIMARY KEY,
+  "description" varchar NOT NULL,
+  "reference" varchar NOT NULL,
+  "effective_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "journal_entries_reference_idx" ON "JournalEntries" ("reference");
+CREATE INDEX IF NOT EXISTS "journal_entries_effective_idx" ON "JournalEntries" ("effective_at");
+
+CREATE TABLE IF NOT EXISTS "LedgerPostings" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "entry_id" uuid NOT NULL REFERENCES "JournalEntries" ("id"),
+  "account_id" uuid NOT NULL REFERENCES "LedgerAccounts" ("id"),
+  "currency" varchar(3) NOT NULL,
+  "amount" bigint NOT NULL CHECK ("amount" <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS "ledger_postings_account_idx" ON "LedgerPostings" ("account_id", "entry_id");
+
+CREATE TABLE IF NOT EXISTS "PayoutBatches" (
+  "id" uuid PRIMARY KEY,
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id"),
+  "period_start" timestamptz NOT NULL,
+  "period_end" timestamptz NOT NULL CHECK ("period_end" > "period_start"),
+  "currency" varchar(3) NOT NULL,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "entry_id" uuid NOT NULL REFERENCES "JournalEntries" ("id"),
+  "created_at" timestamptz NOT NULL,
+  UNIQUE ("restaurant_id", "currency", "period_end")
+);
+
+CREATE OR REPLACE FUNCTION ledger_immutable() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'ledger rows are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION ledger_entry_balanced() RETURNS trigger AS $$
+BEGIN
+  IF (SELECT sum("amount") FROM "LedgerPostings" WHERE "entry_id" = NEW."entry_id") <> 0 THEN
+    RAISE EXCEPTION 'journal entry % is unbalanced', NEW."entry_id";
+  END IF;
+  IF (SELECT count(DISTINCT "currency") FROM "LedgerPostings" WHERE "entry_id" = NEW."entry_id") > 1 THEN
+    RAISE EXCEPTION 'journal entry % mixes currencies', NEW."entry_id";
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS "journal_entries_immutable" ON "JournalEntries";
+CREATE TRIGGER "journal_entries_immutable" BEFORE UPDATE OR DELETE ON "JournalEntries"
+  FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
+
+DROP TRIGGER IF EXISTS "ledger_postings_immutable" ON "LedgerPostings";
+CREATE TRIGGER "ledger_postings_immutable" BEFORE UPDATE OR DELETE ON "LedgerPostings"
+  FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
+
+DROP TRIGGER IF EXISTS "ledger_postings_balanced" ON "LedgerPostings";
+CREATE CONSTRAINT TRIGGER "ledger_postings_balanced" AFTER INSERT ON "LedgerPostings"
+  DEFERRABLE INITIALLY DEFERRED FOR EACH ROW EXECUTE FUNCTION ledger_entry_balanced();
+
+CREATE TABLE IF NOT EXISTS "Promotions" (
+  "id" uuid PRIMARY KEY,
+  "code" varchar NOT NULL DEFAULT '',
+  "name" varchar NOT NULL,
+  "type" varchar NOT NULL,
+  "value" bigint NOT NULL DEFAULT 0,
+  "currency" varchar(3) NOT NULL,
+  "free_menu_id" bigint REFERENCES "Menu" ("id"),
+  "min_order_value" bigint NOT NULL DEFAULT 0,
+  "restaurant_id" uuid REFERENCES "Restaurants" ("id"),
+  "item_type" varchar NOT NULL DEFAULT '',
+  "starts_at" timestamptz NOT NULL,
+  "ends_at" timestamptz NOT NULL CHECK ("ends_at" > "starts_at"),
+  "max_redemptions" int NOT NULL DEFAULT 0,
+  "max_per_user" int NOT NULL DEFAULT 0,
+  "stackable" boolean NOT NULL DEFAULT false,
+  "priority" int NOT NULL DEFAULT 0,
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "promotions_code_idx" ON "Promotions" ("code") WHERE "code" <> '';
+CREATE INDEX IF NOT EXISTS "promotions_window_idx" ON "Promotions" ("starts_at", "ends_at") WHERE "active";
+
+CREATE TABLE IF NOT EXISTS "PromotionRedemptions" (
+  "promotion_id" uuid NOT NULL REFERENCES "Promotions" ("id"),
+  "user_id" uuid NOT NULL REFERENCES "User" ("id"),
+  "order_id" uuid NOT NULL REFERENCES "Orders" ("id"),
+  "amount" bigint NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("promotion_id", "order_id")
+);
+
+CREATE INDEX IF NOT EXISTS "promotion_redemptions_user_idx" ON "PromotionRedemptions" ("user_id", "promotion_id");
+
+CREATE TABLE IF NOT EXISTS "TaxRules" (
+  "restaurant_id" uuid PRIMARY KEY REFERENCES "Restaurants" ("id"),
+  "inclusive" boolean NOT NULL DEFAULT false,
+  "default_rate" int NOT NULL CHECK ("default_rate" BETWEEN 0 AND 10000),
+  "service_charge_rate" int NOT NULL DEFAULT 0 CHECK ("service_charge_rate" BETWEEN 0 AND 10000),
+  "service_charge_taxable" boolean NOT NULL DEFAULT false,
+  "rounding" varchar NOT NULL,
+  "method" varchar NOT NULL,
+  "updated_at" timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "TaxRates" (
+  "restaurant_id" uuid NOT NULL REFERENCES "TaxRules" ("restaurant_id") ON DELETE CASCADE,
+  "item_type" varchar NOT NULL,
+  "rate" int NOT NULL CHECK ("rate" BETWEEN 0 AND 10000),
+  PRIMARY KEY ("restaurant_id", "item_type")
+);
+
+ALTER TABLE "Orders" ADD COLUMN IF NOT EXISTS "total" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "Orders" ADD COLUMN IF NOT EXISTS "settled_at" timestamptz;
+
+CREATE TABLE IF NOT EXISTS "Bills" (
+  "id" uuid PRIMARY KEY,
+  "order_id" uuid NOT NULL UNIQUE REFERENCES "Orders" ("id"),
+  "method" varchar NOT NULL,
+  "currency" varchar(3) NOT NULL,
+  "total" bigint NOT NULL,
+  "status" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  "settled_at" timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS "BillShares" (
+  "id" uuid PRIMARY KEY,
+  "bill_id" uuid NOT NULL REFERENCES "Bills" ("id") ON DELETE CASCADE,
+  "position" int NOT NULL,
+  "name" varchar NOT NULL,
+  "user_id" uuid REFERENCES "User" ("id"),
+  "amount" bigint NOT NULL CHECK ("amount" >= 0),
+  "paid" bigint NOT NULL DEFAULT 0 CHECK ("paid" <= "amount"),
+  "tip" bigint NOT NULL DEFAULT 0 CHECK ("tip" >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS "bill_shares_bill_idx" ON "BillShares" ("bill_id", "position");
+
+CREATE TABLE IF NOT EXISTS "BillPayments" (
+  "payment_id" uuid PRIMARY KEY REFERENCES "Payments" ("id"),
+  "share_id" uuid NOT NULL REFERENCES "BillShares" ("id"),
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "tip" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "bill_payments_share_idx" ON "BillPayments" ("share_id");
+
+CREATE TABLE IF NOT EXISTS "Adjustments" (
+  "id" uuid PRIMARY KEY,
+  "order_id" uuid NOT NULL REFERENCES "Orders" ("id"),
+  "reason" varchar NOT NULL,
+  "note" varchar NOT NULL DEFAULT '',
+  "full_refund" boolean NOT NULL DEFAULT false,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "currency" varchar(3) NOT NULL,
+  "status" varchar NOT NULL,
+  "requested_by" uuid NOT NULL REFERENCES "User" ("id"),
+  "decided_by" uuid REFERENCES "User" ("id"),
+  "created_at" timestamptz NOT NULL,
+  "decided_at" timestamptz,
+  "applied_at" timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS "adjustments_order_idx" ON "Adjustments" ("order_id", "created_at");
+
+CREATE TABLE IF NOT EXISTS "AdjustmentLines" (
+  "adjustment_id" uuid NOT NULL REFERENCES "Adjustments" ("id"),
+  "order_item_id" bigint NOT NULL REFERENCES "OrderItems" ("id"),
+  "menu_id" bigint NOT NULL,
+  "quantity" int NOT NULL CHECK ("quantity" > 0),
+  "amount" bigint NOT NULL CHECK ("amount" >= 0),
+  "restock" boolean NOT NULL DEFAULT false,
+  PRIMARY KEY ("adjustment_id", "order_item_id")
+);
+
+CREATE TABLE IF NOT EXISTS "AdjustmentRefunds" (
+  "adjustment_id" uuid NOT NULL REFERENCES "Adjustments" ("id"),
+  "payment_id" uuid NOT NULL REFERENCES "Payments" ("id"),
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  PRIMARY KEY ("adjustment_id", "payment_id")
+);
+
+-- Adjustments are an audit trail: only the decision and the application may be recorded
+-- after insert, each once, and nothing is ever deleted.
+CREATE OR REPLACE FUNCTION adjustment_immutable() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'UPDATE' AND TG_TABLE_NAME = 'Adjustments' THEN
+    IF (OLD."status", NEW."status") IN (('pending_approval', 'approved'), ('pending_approval', 'rejected'), ('approved', 'applied'))
+      AND (OLD."id", OLD."order_id", OLD."reason", OLD."note", OLD."full_refund", OLD."amount", OLD."currency",
+        OLD."requested_by", OLD."created_at") IS NOT DISTINCT FROM
+        (NEW."id", NEW."order_id", NEW."reason", NEW."note", NEW."full_refund", NEW."amount", NEW."currency",
+        NEW."requested_by", NEW."created_at") THEN
+      RETURN NEW;
+    END IF;
+  END IF;
+  RAISE EXCEPTION 'adjustment records are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS "adjustments_immutable" ON "Adjustments";
+CREATE TRIGGER "adjustments_immutable" BEFORE UPDATE OR DELETE ON "Adjustments"
+  FOR EACH ROW EXECUTE FUNCTION adjustment_immutable();
+
+DROP TRIGGER IF EXISTS "adjustment_lines_immutable" ON "AdjustmentLines";
+CREATE TRIGGER "adjustment_lines_immutable" BEFORE UPDATE OR DELETE ON "AdjustmentLines"
+  FOR EACH ROW EXECUTE FUNCTION adjustment_immutable();
+
+DROP TRIGGER IF EXISTS "adjustment_refunds_immutable" ON "AdjustmentRefunds";
+CREATE TRIGGER "adjustment_refunds_immutable" BEFORE UPDATE OR DELETE ON "AdjustmentRefunds"
+  FOR EACH ROW EXECUTE FUNCTION adjustment_immutable();
+
+CREATE TABLE IF NOT EXISTS "KitchenTickets" (
+  "order_id" uuid NOT NULL REFERENCES "Orders" ("id") ON DELETE CASCADE,
+  "station" varchar NOT NULL,
+  "status" varchar NOT NULL,
+  "started_at" timestamptz,
+  "bumped_at" timestamptz,
+  PRIMARY KEY ("order_id", "station")
+);
+
+CREATE TABLE IF NOT EXISTS "EventLog" (
+  "id" bigserial PRIMARY KEY NOT NULL,
+  "topic" varchar NOT NULL,
+  "payload" bytea NOT NULL,
+  "created_at" timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "event_log_topic_idx" ON "EventLog" ("topic", "id");
+CREATE INDEX IF NOT EXISTS "event_log_created_idx" ON "EventLog" ("created_at");
+
+CREATE TABLE IF NOT EXISTS "Couriers" (
+  "id" uuid PRIMARY KEY NOT NULL,
+  "user_id" uuid UNIQUE NOT NULL REFERENCES "User" ("id") ON DELETE CASCADE,
+  "status" varchar NOT NULL,
+  "latitude" double precision,
+  "longitude" double precision,
+  "position_at" timestamptz,
+  "created_at" timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "couriers_available_idx" ON "Couriers" ("status", "latitude", "longitude");
+
+CREATE TABLE IF NOT EXISTS "Deliveries" (
+  "id" uuid PRIMARY KEY NOT NULL,
+  "order_id" uuid UNIQUE NOT NULL REFERENCES "Orders" ("id") ON DELETE CASCADE,
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id") ON DELETE CASCADE,
+  "courier_id" uuid REFERENCES "Couriers" ("id"),
+  "status" varchar NOT NULL,
+  "pickup_latitude" double precision NOT NULL,
+  "pickup_longitude" double precision NOT NULL,
+  "dropoff_latitude" double precision NOT NULL,
+  "dropoff_longitude" double precision NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL,
+  "picked_up_at" timestamptz,
+  "delivered_at" timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS "deliveries_status_idx" ON "Deliveries" ("status", "created_at");
+
+CREATE TABLE IF NOT EXISTS "DeliveryOffers" (
+  "id" uuid PRIMARY KEY NOT NULL,
+  "delivery_id" uuid NOT NULL REFERENCES "Deliveries" ("id") ON DELETE CASCADE,
+  "courier_id" uuid NOT NULL REFERENCES "Couriers" ("id") ON DELETE CASCADE,
+  "status" varchar NOT NULL,
+  "distance_km" double precision NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  "responded_at" timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS "delivery_offers_courier_idx" ON "DeliveryOffers" ("courier_id", "status");
+CREATE INDEX IF NOT EXISTS "delivery_offers_expiry_idx" ON "DeliveryOffers" ("status", "expires_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "delivery_offers_open_idx" ON "DeliveryOffers" ("delivery_id") WHERE "status" = 'open';
+
+CREATE TABLE IF NOT EXISTS "DeliveryZones" (
+  "id" uuid PRIMARY KEY NOT NULL,
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id") ON DELETE CASCADE,
+  "name" varchar NOT NULL,
+  "area" jsonb NOT NULL,
+  "delivery_fee" bigint NOT NULL CHECK ("delivery_fee" >= 0),
+  "minimum_order" bigint NOT NULL CHECK ("minimum_order" >= 0),
+  "currency" varchar(3) NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "delivery_zones_restaurant_idx" ON "DeliveryZones" ("restaurant_id");
+
+ALTER TABLE "User" ADD COLUMN IF NOT EXISTS "password" varchar;
+ALTER TABLE "User" ADD COLUMN IF NOT EXISTS "phone" varchar;
+ALTER TABLE "User" ADD COLUMN IF NOT EXISTS "avatar_ref" varchar;
+
+CREATE TABLE IF NOT EXISTS "UserAddresses" (
+  "id" uuid PRIMARY KEY NOT NULL,
+  "user_id" uuid NOT NULL REFERENCES "User" ("id") ON DELETE CASCADE,
+  "label" varchar NOT NULL,
+  "line1" varchar NOT NULL,
+  "line2" varchar NOT NULL DEFAULT '',
+  "city" varchar NOT NULL,
+  "state" varchar NOT NULL DEFAULT '',
+  "postal_code" varchar NOT NULL DEFAULT '',
+  "country" varchar(2) NOT NULL,
+  "latitude" double precision NOT NULL,
+  "longitude" double precision NOT NULL,
+  "instructions" varchar NOT NULL DEFAULT '',
+  "is_default" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "user_addresses_label_idx" ON "UserAddresses" ("user_id", lower("label"));
+CREATE UNIQUE INDEX IF NOT EXISTS "user_addresses_default_idx" ON "UserAddresses" ("user_id") WHERE "is_default";
+
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "daily_limit" int CHECK ("daily_limit" >= 0);
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "sold_today" int NOT NULL DEFAULT 0;
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "sold_on" date;
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "low_stock_at" int NOT NULL DEFAULT 5;
+
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "allergens" varchar[] NOT NULL DEFAULT '{}';
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "dietary" varchar[] NOT NULL DEFAULT '{}';
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "nutrition" jsonb;
+
+CREATE INDEX IF NOT EXISTS "menu_allergens_idx" ON "Menu" USING gin ("allergens");
+CREATE INDEX IF NOT EXISTS "menu_dietary_idx" ON "Menu" USING gin ("dietary");
+
+CREATE TABLE IF NOT EXISTS "NamedMenus" (
+  "id" uuid PRIMARY KEY,
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id") ON DELETE CASCADE,
+  "name" varchar NOT NULL,
+  "hours" jsonb NOT NULL DEFAULT '[]',
+  "starts_on" date,
+  "ends_on" date CHECK ("ends_on" >= "starts_on"),
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "named_menus_restaurant_idx" ON "NamedMenus" ("restaurant_id");
+
+CREATE TABLE IF NOT EXISTS "MenuVersions" (
+  "id" uuid PRIMARY KEY,
+  "named_menu_id" uuid NOT NULL REFERENCES "NamedMenus" ("id") ON DELETE CASCADE,
+  "number" int NOT NULL,
+  "status" varchar NOT NULL,
+  "items" jsonb NOT NULL,
+  "effective_from" timestamptz,
+  "created_at" timestamptz NOT NULL,
+  "published_at" timestamptz,
+  CHECK ("status" = 'draft' OR "effective_from" IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "menu_versions_number_idx" ON "MenuVersions" ("named_menu_id", "number");
+CREATE UNIQUE INDEX IF NOT EXISTS "menu_versions_draft_idx" ON "MenuVersions" ("named_menu_id") WHERE "status" = 'draft';
+CREATE INDEX IF NOT EXISTS "menu_versions_effective_idx" ON "MenuVersions" ("named_menu_id", "effective_from")
+  WHERE "status" = 'published';
+
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "code" varchar(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "menu_restaurant_code_idx" ON "Menu" ("restaurant_id", "code");
+
+ALTER TABLE "Restaurants" ADD COLUMN IF NOT EXISTS "image_ref" varchar(512);
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "image_ref" varchar(512);
+
+CREATE TABLE IF NOT EXISTS "Brands" (
+  "id" uuid PRIMARY KEY,
+  "name" varchar(100) NOT NULL,
+  "owner_id" uuid NOT NULL REFERENCES "User" ("id"),
+  "created_at" timestamptz NOT NULL
+);
+
+ALTER TABLE "Restaurants" ADD COLUMN IF NOT EXISTS "brand_id" uuid REFERENCES "Brands" ("id");
+
+CREATE INDEX IF NOT EXISTS "restaurants_brand_idx" ON "Restaurants" ("brand_id");
+
+CREATE TABLE IF NOT EXISTS "BrandMembers" (
+  "brand_id" uuid NOT NULL REFERENCES "Brands" ("id") ON DELETE CASCADE,
+  "user_id" uuid NOT NULL REFERENCES "User" ("id"),
+  "role" varchar NOT NULL CHECK ("role" IN ('owner', 'admin', 'manager', 'staff')),
+  "locations" uuid[] NOT NULL DEFAULT '{}',
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("brand_id", "user_id")
+);
+
+CREATE TABLE IF NOT EXISTS "BrandMenuItems" (
+  "id" uuid PRIMARY KEY,
+  "brand_id" uuid NOT NULL REFERENCES "Brands" ("id") ON DELETE CASCADE,
+  "item" varchar NOT NULL,
+  "item_type" varchar NOT NULL,
+  "price" varchar NOT NULL,
+  "allergens" varchar[] NOT NULL DEFAULT '{}',
+  "dietary" varchar[] NOT NULL DEFAULT '{}',
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "brand_menu_items_brand_idx" ON "BrandMenuItems" ("brand_id");
+
+CREATE TABLE IF NOT EXISTS "BrandMenuOverrides" (
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id") ON DELETE CASCADE,
+  "master_item_id" uuid NOT NULL REFERENCES "BrandMenuItems" ("id") ON DELETE CASCADE,
+  "price" varchar,
+  "unavailable" boolean NOT NULL DEFAULT false,
+  "updated_at" timestamptz NOT NULL,
+  PRIMARY KEY ("restaurant_id", "master_item_id")
+);
+
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "master_item_id" uuid;
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "available" boolean NOT NULL DEFAULT true;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "menu_restaurant_master_item_idx" ON "Menu" ("restaurant_id", "master_item_id");
+
+CREATE TABLE IF NOT EXISTS "Waitlist" (
+  "id" uuid PRIMARY KEY,
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id") ON DELETE CASCADE,
+  "user_id" uuid REFERENCES "User" ("id"),
+  "name" varchar(100) NOT NULL,
+  "phone" varchar(20),
+  "party_size" int NOT NULL CHECK ("party_size" > 0),
+  "status" varchar NOT NULL,
+  "joined_at" timestamptz NOT NULL,
+  "notified_at" timestamptz,
+  "seated_at" timestamptz,
+  "updated_at" timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "waitlist_queue_idx" ON "Waitlist" ("restaurant_id", "joined_at")
+  WHERE "status" IN ('waiting', 'notified');
+
+CREATE TABLE IF NOT EXISTS "TableTurns" (
+  "id" uuid PRIMARY KEY,
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id") ON DELETE CASCADE,
+  "entry_id" uuid REFERENCES "Waitlist" ("id") ON DELETE SET NULL,
+  "party_size" int NOT NULL CHECK ("party_size" > 0),
+  "seated_at" timestamptz NOT NULL,
+  "cleared_at" timestamptz CHECK ("cleared_at" >= "seated_at")
+);
+
+CREATE INDEX IF NOT EXISTS "table_turns_occupied_idx" ON "TableTurns" ("restaurant_id") WHERE "cleared_at" IS NULL;
+CREATE INDEX IF NOT EXISTS "table_turns_cleared_idx" ON "TableTurns" ("restaurant_id", "cleared_at");
+
+CREATE TABLE IF NOT EXISTS "Tables" (
+  "id" uuid PRIMARY KEY,
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id") ON DELETE CASCADE,
+  "label" varchar(20) NOT NULL,
+  "seats" int NOT NULL CHECK ("seats" > 0),
+  "currency" varchar(3) NOT NULL,
+  "token" varchar NOT NULL UNIQUE,
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "tables_restaurant_idx" ON "Tables" ("restaurant_id");
+
+ALTER TABLE "Orders" ALTER COLUMN "user_id" DROP NOT NULL;
+
+CREATE TABLE IF NOT EXISTS "TableSessions" (
+  "id" uuid PRIMARY KEY,
+  "table_id" uuid NOT NULL REFERENCES "Tables" ("id") ON DELETE CASCADE,
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id") ON DELETE CASCADE,
+  "status" varchar NOT NULL,
+  "order_id" uuid UNIQUE REFERENCES "Orders" ("id"),
+  "opened_at" timestamptz NOT NULL,
+  "closed_at" timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "table_sessions_open_idx" ON "TableSessions" ("table_id") WHERE "status" = 'open';
+
+CREATE TABLE IF NOT EXISTS "TableDiners" (
+  "id" uuid PRIMARY KEY,
+  "session_id" uuid NOT NULL REFERENCES "TableSessions" ("id") ON DELETE CASCADE,
+  "user_id" uuid REFERENCES "User" ("id"),
+  "name" varchar(100) NOT NULL DEFAULT '',
+  "joined_at" timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "table_diners_session_idx" ON "TableDiners" ("session_id");
+
+ALTER TABLE "OrderItems" ADD COLUMN IF NOT EXISTS "diner_id" uuid REFERENCES "TableDiners" ("id");
+
+CREATE TABLE IF NOT EXISTS "LoyaltyRules" (
+  "id" uuid PRIMARY KEY,
+  "restaurant_id" uuid REFERENCES "Restaurants" ("id") ON DELETE CASCADE,
+  "brand_id" uuid REFERENCES "Brands" ("id") ON DELETE CASCADE,
+  "currency" varchar(3) NOT NULL,
+  "points" bigint NOT NULL CHECK ("points" > 0),
+  "per" bigint NOT NULL CHECK ("per" > 0),
+  "expiry_days" int NOT NULL DEFAULT 0 CHECK ("expiry_days" >= 0),
+  "active" boolean NOT NULL DEFAULT true,
+  "updated_at" timestamptz NOT NULL,
+  CHECK (("restaurant_id" IS NULL) <> ("brand_id" IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "loyalty_rules_restaurant_idx" ON "LoyaltyRules" ("restaurant_id")
+  WHERE "restaurant_id" IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "loyalty_rules_brand_idx" ON "LoyaltyRules" ("brand_id") WHERE "brand_id" IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS "LoyaltyRewards" (
+  "id" uuid PRIMARY KEY,
+  "restaurant_id" uuid REFERENCES "Restaurants" ("id") ON DELETE CASCADE,
+  "brand_id" uuid REFERENCES "Brands" ("id") ON DELETE CASCADE,
+  "name" varchar(100) NOT NULL,
+  "cost" bigint NOT NULL CHECK ("cost" > 0),
+  "type" varchar NOT NULL,
+  "value" bigint NOT NULL DEFAULT 0 CHECK ("value" >= 0),
+  "currency" varchar(3) NOT NULL,
+  "free_menu_id" bigint,
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL,
+  CHECK ("restaurant_id" IS NULL OR "brand_id" IS NULL)
+);
+
+CREATE TABLE IF NOT EXISTS "LoyaltyEntries" (
+  "id" uuid PRIMARY KEY,
+  "user_id" uuid NOT NULL REFERENCES "User" ("id") ON DELETE CASCADE,
+  "kind" varchar NOT NULL,
+  "points" bigint NOT NULL,
+  "remaining" bigint NOT NULL DEFAULT 0 CHECK ("remaining" >= 0),
+  "order_id" uuid REFERENCES "Orders" ("id"),
+  "restaurant_id" uuid REFERENCES "Restaurants" ("id") ON DELETE SET NULL,
+  "reward_id" uuid REFERENCES "LoyaltyRewards" ("id"),
+  "expires_at" timestamptz,
+  "created_at" timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "loyalty_entries_order_idx" ON "LoyaltyEntries" ("order_id", "kind")
+  WHERE "order_id" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "loyalty_entries_user_idx" ON "LoyaltyEntries" ("user_id", "created_at");
+CREATE INDEX IF NOT EXISTS "loyalty_entries_lots_idx" ON "LoyaltyEntries" ("expires_at")
+  WHERE "kind" = 'earn' AND "remaining" > 0;
+
+CREATE TABLE IF NOT EXISTS "FavoriteRestaurants" (
+  "user_id" uuid NOT NULL REFERENCES "User" ("id") ON DELETE CASCADE,
+  "restaurant_id" uuid NOT NULL REFERENCES "Restaurants" ("id") ON DELETE CASCADE,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("user_id", "restaurant_id")
+);
+
+CREATE TABLE IF NOT EXISTS "FavoriteItems" (
+  "user_id" uuid NOT NULL REFERENCES "User" ("id") ON DELETE CASCADE,
+  "menu_id" bigint NOT NULL REFERENCES "Menu" ("id") ON DELETE CASCADE,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("user_id", "menu_id")
+);
+
+CREATE TABLE IF NOT EXISTS "Recommendations" (
+  "user_id" uuid NOT NULL REFERENCES "User" ("id") ON DELETE CASCADE,
+  "menu_id" bigint NOT NULL REFERENCES "Menu" ("id") ON DELETE CASCADE,
+  "score" double precision NOT NULL,
+  "reason" varchar NOT NULL,
+  "rank" int NOT NULL,
+  "computed_at" timestamptz NOT NULL,
+  PRIMARY KEY ("user_id", "menu_id")
+);
+
+CREATE INDEX IF NOT EXISTS "recommendations_rank_idx" ON "Recommendations" ("user_id", "rank");
+
+CREATE TABLE IF NOT EXISTS "PopularItems" (
+  "menu_id" bigint PRIMARY KEY REFERENCES "Menu" ("id") ON DELETE CASCADE,
+  "score" double precision NOT NULL,
+  "rank" int NOT NULL,
+  "computed_at" timestamptz NOT NULL
+);
+
+-- Restaurants without coordinates used to be stored at 0,0. Runs once, together with adding
+-- the timezone column, so restaurants saved at 0,0 since are left alone.
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+      WHERE table_name = 'Restaurants' AND column_name = 'timezone') THEN
+    ALTER TABLE "Restaurants" ADD COLUMN "timezone" varchar NOT NULL DEFAULT 'UTC';
+    UPDATE "Restaurants" SET "latitude" = NULL, "longitude" = NULL WHERE "latitude" = 0 AND "longitude" = 0;
+  END IF;
+END $$;
+
+DROP INDEX IF EXISTS "journal_entries_reference_idx";
+CREATE UNIQUE INDEX IF NOT EXISTS "journal_entries_reference_key" ON "JournalEntries" ("reference");
+
+-- Counted stock has its own column; "servings" keeps its original meaning. NULL means the
+-- item is not counted.
+ALTER TABLE "Menu" ADD COLUMN IF NOT EXISTS "stock" int CHECK ("stock" >= 0);
+
+-- Allergens are NULL until declared, so items nobody has checked are not shown as free of
+-- everything. Runs once: items still holding the old empty default had declared nothing.
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM information_schema.columns
+      WHERE table_name = 'Menu' AND column_name = 'allergens' AND is_nullable = 'NO') THEN
+    ALTER TABLE "Menu" ALTER COLUMN "allergens" DROP NOT NULL, ALTER COLUMN "allergens" DROP DEFAULT;
+    UPDATE "Menu" SET "allergens" = NULL WHERE "allergens" = '{}';
+  END IF;
+END $$;
+
+-- Items added before imports, or by brand syncs, have no code to match on when an export is
+-- imported back. They get one from their id.
+UPDATE "Menu" m SET "code" = 'item-' || m."id" WHERE m."code" IS NULL AND NOT EXISTS (
+  SELECT 1 FROM "Menu" o WHERE o."restaurant_id" = m."restaurant_id" AND o."code" = 'item-' || m."id");
+
+-- An order can be clawed back from once per refund; every other kind is recorded once.
+DROP INDEX IF EXISTS "loyalty_entries_order_idx";
+CREATE UNIQUE INDEX IF NOT EXISTS "loyalty_entries_order_kind_idx" ON "LoyaltyEntries" ("order_id", "kind")
+  WHERE "order_id" IS NOT NULL AND "kind" <> 'clawback';
+
+-- Reports made before a moderator's last decision on a review no longer count towards
+-- flagging it again.
+ALTER TABLE "Reviews" ADD COLUMN IF NOT EXISTS "moderated_at" timestamptz;
+
+-- in_stock is whether a menu item can still be sold today, following
+-- inventoryModel.Stock.Available. Menu listings and search both filter on it.
+CREATE OR REPLACE FUNCTION in_stock(m "Menu") RETURNS boolean AS $$
+  SELECT CASE WHEN m."stock" IS NOT NULL THEN m."stock" > 0
+    WHEN m."daily_limit" IS NOT NULL THEN m."sold_on" IS DISTINCT FROM (now() AT TIME ZONE 'UTC')::date
+      OR m."sold_today" < m."daily_limit"
+    ELSE true END;
+$$ LANGUAGE sql STABLE;
+
+-- Brand items' allergens are NULL until declared, as on "Menu". Runs once: master items still
+-- holding the old empty default had declared nothing, and neither had their locations' copies.
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM information_schema.columns
+      WHERE table_name = 'BrandMenuItems' AND column_name = 'allergens' AND is_nullable = 'NO') THEN
+    ALTER TABLE "BrandMenuItems" ALTER COLUMN "allergens" DROP NOT NULL, ALTER COLUMN "allergens" DROP DEFAULT;
+    UPDATE "BrandMenuItems" SET "allergens" = NULL WHERE "allergens" = '{}';
+    UPDATE "Menu" m SET "allergens" = NULL FROM "BrandMenuItems" b
+      WHERE m."master_item_id" = b."id" AND b."allergens" IS NULL;
+  END IF;
+END $$;
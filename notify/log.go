@@ -0,0 +1,23 @@
+package notify
+
+import "github.com/sirupsen/logrus"
+
+// LogNotifier writes notifications to the log. It is the notifier for local development
+// and for deployments without a delivery channel yet.
+type LogNotifier struct {
+	log *logrus.Logger
+}
+
+func NewLogNotifier(log *logrus.Logger) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+func (l *LogNotifier) Notify(n Notification) error {
+	l.log.WithFields(logrus.Fields{
+		"kind":         n.Kind,
+		"restaurantId": n.RestaurantId,
+		"userId":       n.UserId,
+		"data":         n.Data,
+	}).Infof("%v: %v", n.Title, n.Body)
+	return nil
+}
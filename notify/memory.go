@@ -0,0 +1,27 @@
+package notify
+
+import "sync"
+
+// MemoryNotifier keeps every notification it is sent, for tests.
+type MemoryNotifier struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+func (m *MemoryNotifier) Notify(n Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, n)
+	return nil
+}
+
+// Sent returns a copy of the notifications received so far, oldest first.
+func (m *MemoryNotifier) Sent() []Notification {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Notification{}, m.sent...)
+}
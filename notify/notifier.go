@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Kind string
+
+// Notification is a message for a restaurant's staff or for one user. Data carries the ids
+// a client needs to act on it.
+type Notification struct {
+	Kind         Kind              `json:"kind"`
+	RestaurantId *uuid.UUID        `json:"restaurantId,omitempty"`
+	UserId       *uuid.UUID        `json:"userId,omitempty"`
+	Title        string            `json:"title"`
+	Body         string            `json:"body"`
+	Data         map[string]string `json:"data,omitempty"`
+	At           time.Time         `json:"at"`
+}
+
+// Notifier delivers notifications, by push, SMS, email or whatever the deployment has.
+// Notify should not block for long; callers treat failures as non-fatal and only log them.
+type Notifier interface {
+	Notify(n Notification) error
+}
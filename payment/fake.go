@@ -0,0 +1,155 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"rsm/entity/moneyModel"
+	"sync"
+)
+
+const (
+	FakeProviderName = "fake"
+
+	// Source tokens the fake provider understands. Any other token is approved.
+	TokenDeclined          = "tok_declined"
+	TokenInsufficientFunds = "tok_insufficient_funds"
+)
+
+type fakeCharge struct {
+	authorized moneyModel.Money
+	captured   int64
+	refunded   int64
+	voided     bool
+}
+
+// FakeProvider is an in-process Provider for tests and local development. Provider
+// references are derived from the idempotency key, so the same inputs always produce the
+// same outputs, and webhooks are signed with HMAC-SHA256 over the raw payload.
+type FakeProvider struct {
+	mu      sync.Mutex
+	secret  []byte
+	charges map[string]*fakeCharge
+	results map[string]Result
+}
+
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{
+		secret:  []byte(secret),
+		charges: map[string]*fakeCharge{},
+		results: map[string]Result{},
+	}
+}
+
+func (f *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+func (f *FakeProvider) Authorize(request AuthorizeRequest) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := "authorize:" + request.IdempotencyKey
+	if res, ok := f.results[key]; ok {
+		return &res, nil
+	}
+
+	res := Result{ProviderRef: reference("ch", request.IdempotencyKey)}
+	switch request.SourceToken {
+	case TokenDeclined:
+		res.DeclineReason = "card_declined"
+	case TokenInsufficientFunds:
+		res.DeclineReason = "insufficient_funds"
+	default:
+		res.Approved = true
+		f.charges[res.ProviderRef] = &fakeCharge{authorized: request.Amount}
+	}
+	f.results[key] = res
+	return &res, nil
+}
+
+func (f *FakeProvider) Capture(providerRef string, amount moneyModel.Money, idempotencyKey string) (*Result, error) {
+	return f.apply("capture:"+idempotencyKey, providerRef, func(c *fakeCharge) error {
+		if c.voided || c.captured > 0 {
+			return fmt.Errorf("charge %v cannot be captured", providerRef)
+		}
+		if !amount.SameCurrency(c.authorized) || amount.Amount <= 0 || amount.Amount > c.authorized.Amount {
+			return fmt.Errorf("capture of %v exceeds authorisation %v", amount, c.authorized)
+		}
+		c.captured = amount.Amount
+		return nil
+	})
+}
+
+func (f *FakeProvider) Void(providerRef string, idempotencyKey string) (*Result, error) {
+	return f.apply("void:"+idempotencyKey, providerRef, func(c *fakeCharge) error {
+		if c.captured > 0 {
+			return fmt.Errorf("charge %v is already captured", providerRef)
+		}
+		c.voided = true
+		return nil
+	})
+}
+
+func (f *FakeProvider) Refund(providerRef string, amount moneyModel.Money, idempotencyKey string) (*Result, error) {
+	return f.apply("refund:"+idempotencyKey, providerRef, func(c *fakeCharge) error {
+		if !amount.SameCurrency(c.authorized) || amount.Amount <= 0 || c.refunded+amount.Amount > c.captured {
+			return fmt.Errorf("refund of %v exceeds captured amount", amount)
+		}
+		c.refunded += amount.Amount
+		return nil
+	})
+}
+
+func (f *FakeProvider) apply(key, providerRef string, change func(c *fakeCharge) error) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if res, ok := f.results[key]; ok {
+		return &res, nil
+	}
+	charge, ok := f.charges[providerRef]
+	if !ok {
+		return nil, fmt.Errorf("unknown charge %v", providerRef)
+	}
+	if err := change(charge); err != nil {
+		return nil, err
+	}
+	res := Result{ProviderRef: reference("op", key), Approved: true}
+	f.results[key] = res
+	return &res, nil
+}
+
+func (f *FakeProvider) VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, f.sign(payload)) {
+		return nil, ErrInvalidSignature
+	}
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	return &event, nil
+}
+
+// SignWebhook encodes an event the way the fake provider would deliver it.
+func (f *FakeProvider) SignWebhook(event WebhookEvent) ([]byte, string, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, hex.EncodeToString(f.sign(payload)), nil
+}
+
+func (f *FakeProvider) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func reference(prefix, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("fake_%s_%s", prefix, hex.EncodeToString(sum[:8]))
+}
@@ -0,0 +1,69 @@
+package payment
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/moneyModel"
+	"testing"
+)
+
+func TestFakeProvider_Flow(t *testing.T) {
+	f := NewFakeProvider("whsec")
+	amount := moneyModel.New(5000, "NGN")
+
+	auth, err := f.Authorize(AuthorizeRequest{PaymentId: uuid.New(), Amount: amount, SourceToken: "tok_ok", IdempotencyKey: "k1"})
+	assert.Nil(t, err)
+	assert.True(t, auth.Approved)
+
+	again, err := f.Authorize(AuthorizeRequest{PaymentId: uuid.New(), Amount: amount, SourceToken: "tok_ok", IdempotencyKey: "k1"})
+	assert.Nil(t, err)
+	assert.Equal(t, auth, again)
+
+	_, err = f.Capture(auth.ProviderRef, moneyModel.New(6000, "NGN"), "c1")
+	assert.NotNil(t, err)
+
+	capture, err := f.Capture(auth.ProviderRef, amount, "c2")
+	assert.Nil(t, err)
+	retried, err := f.Capture(auth.ProviderRef, amount, "c2")
+	assert.Nil(t, err)
+	assert.Equal(t, capture, retried)
+
+	_, err = f.Void(auth.ProviderRef, "v1")
+	assert.NotNil(t, err)
+
+	_, err = f.Refund(auth.ProviderRef, moneyModel.New(3000, "NGN"), "r1")
+	assert.Nil(t, err)
+	_, err = f.Refund(auth.ProviderRef, moneyModel.New(3000, "NGN"), "r2")
+	assert.NotNil(t, err)
+	_, err = f.Refund(auth.ProviderRef, moneyModel.New(3000, "NGN"), "r1")
+	assert.Nil(t, err)
+}
+
+func TestFakeProvider_Decline(t *testing.T) {
+	f := NewFakeProvider("whsec")
+	res, err := f.Authorize(AuthorizeRequest{Amount: moneyModel.New(100, "USD"), SourceToken: TokenDeclined, IdempotencyKey: "k"})
+	assert.Nil(t, err)
+	assert.False(t, res.Approved)
+	assert.Equal(t, "card_declined", res.DeclineReason)
+
+	_, err = f.Capture(res.ProviderRef, moneyModel.New(100, "USD"), "c")
+	assert.NotNil(t, err)
+}
+
+func TestFakeProvider_VerifyWebhook(t *testing.T) {
+	f := NewFakeProvider("whsec")
+	event := WebhookEvent{Id: "evt_1", Type: EventCaptured, ProviderRef: "fake_ch_1", Amount: moneyModel.New(100, "USD")}
+
+	payload, signature, err := f.SignWebhook(event)
+	assert.Nil(t, err)
+
+	got, err := f.VerifyWebhook(payload, signature)
+	assert.Nil(t, err)
+	assert.Equal(t, &event, got)
+
+	_, err = NewFakeProvider("other").VerifyWebhook(payload, signature)
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	_, err = f.VerifyWebhook(append(payload, ' '), signature)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
@@ -0,0 +1,52 @@
+package payment
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/moneyModel"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+type EventType string
+
+const (
+	EventAuthorized EventType = "payment.authorized"
+	EventCaptured   EventType = "payment.captured"
+	EventVoided     EventType = "payment.voided"
+	EventRefunded   EventType = "payment.refunded"
+	EventFailed     EventType = "payment.failed"
+)
+
+// Provider is a payment gateway. Every mutating call takes an idempotency key so a retried
+// call returns the original outcome instead of moving money twice.
+type Provider interface {
+	Name() string
+	Authorize(request AuthorizeRequest) (*Result, error)
+	Capture(providerRef string, amount moneyModel.Money, idempotencyKey string) (*Result, error)
+	Void(providerRef string, idempotencyKey string) (*Result, error)
+	Refund(providerRef string, amount moneyModel.Money, idempotencyKey string) (*Result, error)
+	VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
+
+type AuthorizeRequest struct {
+	PaymentId      uuid.UUID
+	Amount         moneyModel.Money
+	SourceToken    string
+	IdempotencyKey string
+}
+
+// Result is the provider's answer. A declined authorisation is not an error: Approved is
+// false and DeclineReason says why.
+type Result struct {
+	ProviderRef   string
+	Approved      bool
+	DeclineReason string
+}
+
+type WebhookEvent struct {
+	Id          string           `json:"id"`
+	Type        EventType        `json:"type"`
+	ProviderRef string           `json:"providerRef"`
+	Amount      moneyModel.Money `json:"amount"`
+}
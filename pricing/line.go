@@ -0,0 +1,88 @@
+package pricing
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"rsm/entity/moneyModel"
+	"rsm/location/geoUtils"
+)
+
+// Line is one row of a cart or order. UnitPrice is in the cart currency.
+type Line struct {
+	MenuId    int64            `json:"menuId"`
+	Item      string           `json:"item"`
+	ItemType  string           `json:"itemType"`
+	Quantity  int              `json:"quantity"`
+	UnitPrice moneyModel.Money `json:"unitPrice"`
+}
+
+// Cart is priced for delivery to Dropoff, or for collection when Dropoff is nil.
+type Cart struct {
+	RestaurantId uuid.UUID       `json:"restaurantId"`
+	Currency     string          `json:"currency"`
+	Lines        []Line          `json:"lines"`
+	Dropoff      *geoUtils.Point `json:"dropoff,omitempty"`
+}
+
+func (l Line) Total() moneyModel.Money {
+	return l.UnitPrice.Multiply(int64(l.Quantity))
+}
+
+// Validate checks every line has a positive quantity and a non-negative price in the cart currency.
+func (c Cart) Validate() error {
+	if len(c.Lines) == 0 {
+		return fmt.Errorf("cart is empty")
+	}
+	for i, l := range c.Lines {
+		if l.Quantity <= 0 {
+			return fmt.Errorf("line %d has quantity %d", i, l.Quantity)
+		}
+		if l.UnitPrice.Currency != c.Currency {
+			return fmt.Errorf("line %d is priced in %v, cart is in %v", i, l.UnitPrice.Currency, c.Currency)
+		}
+		if l.UnitPrice.IsNegative() {
+			return fmt.Errorf("line %d has a negative price", i)
+		}
+	}
+	return nil
+}
+
+func (c Cart) Subtotal() moneyModel.Money {
+	total := moneyModel.Zero(c.Currency)
+	for _, l := range c.Lines {
+		total.Amount += l.Total().Amount
+	}
+	return total
+}
+
+// allocate splits amount across weights in proportion, giving leftover minor units to the
+// largest remainders (earliest line first on ties) so the parts always sum to amount.
+func allocate(amount int64, weights []int64) []int64 {
+	parts := make([]int64, len(weights))
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 || amount == 0 {
+		return parts
+	}
+
+	remainders := make([]int64, len(weights))
+	var given int64
+	for i, w := range weights {
+		parts[i] = amount * w / total
+		remainders[i] = amount * w % total
+		given += parts[i]
+	}
+	for ; given < amount; given++ {
+		best := -1
+		for i := range weights {
+			if weights[i] > 0 && (best == -1 || remainders[i] > remainders[best]) {
+				best = i
+			}
+		}
+		parts[best]++
+		remainders[best] = -1
+	}
+	return parts
+}
@@ -0,0 +1,177 @@
+package pricing
+
+import (
+	"github.com/google/uuid"
+	"rsm/entity/moneyModel"
+	"rsm/entity/promotionModel"
+	"sort"
+	"time"
+)
+
+// LineDiscount is how much one promotion took off one cart line.
+type LineDiscount struct {
+	Line        int              `json:"line"`
+	PromotionId uuid.UUID        `json:"promotionId"`
+	Amount      moneyModel.Money `json:"amount"`
+}
+
+type AppliedPromotion struct {
+	PromotionId uuid.UUID        `json:"promotionId"`
+	Code        string           `json:"code,omitempty"`
+	Name        string           `json:"name"`
+	Total       moneyModel.Money `json:"total"`
+	Lines       []LineDiscount   `json:"lines"`
+}
+
+type Discounts struct {
+	Applied []AppliedPromotion `json:"applied"`
+	Total   moneyModel.Money   `json:"total"`
+}
+
+// LineTotals returns the discount on each cart line summed over all applied promotions.
+func (d Discounts) LineTotals(lines int) []int64 {
+	totals := make([]int64, lines)
+	for _, a := range d.Applied {
+		for _, l := range a.Lines {
+			totals[l.Line] += l.Amount.Amount
+		}
+	}
+	return totals
+}
+
+// ApplyPromotions picks the promotions that give the customer the biggest discount on the
+// cart at time at. Stackable promotions combine with each other; a non-stackable one only
+// ever applies alone. Promotions apply in priority order (highest first, then by id) and
+// each one only discounts what earlier ones left, so no line goes below zero.
+func ApplyPromotions(cart Cart, promotions []promotionModel.Promotion, at time.Time) Discounts {
+	eligible := make([]promotionModel.Promotion, 0, len(promotions))
+	subtotal := cart.Subtotal()
+	for _, p := range promotions {
+		if isEligible(cart, subtotal, p, at) {
+			eligible = append(eligible, p)
+		}
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		if eligible[i].Priority != eligible[j].Priority {
+			return eligible[i].Priority > eligible[j].Priority
+		}
+		return eligible[i].Id.String() < eligible[j].Id.String()
+	})
+
+	var candidates [][]promotionModel.Promotion
+	var stackable []promotionModel.Promotion
+	for _, p := range eligible {
+		if p.Stackable {
+			stackable = append(stackable, p)
+		} else {
+			candidates = append(candidates, []promotionModel.Promotion{p})
+		}
+	}
+	if len(stackable) > 0 {
+		candidates = append([][]promotionModel.Promotion{stackable}, candidates...)
+	}
+
+	best := Discounts{Applied: []AppliedPromotion{}, Total: moneyModel.Zero(cart.Currency)}
+	for _, set := range candidates {
+		d := applySet(cart, set)
+		if d.Total.Amount > best.Total.Amount {
+			best = d
+		}
+	}
+	return best
+}
+
+func isEligible(cart Cart, subtotal moneyModel.Money, p promotionModel.Promotion, at time.Time) bool {
+	if !p.ActiveAt(at) || p.Currency != cart.Currency || subtotal.Amount < p.MinOrderValue {
+		return false
+	}
+	if p.RestaurantId != nil && *p.RestaurantId != cart.RestaurantId {
+		return false
+	}
+	return true
+}
+
+func applySet(cart Cart, set []promotionModel.Promotion) Discounts {
+	remaining := make([]int64, len(cart.Lines))
+	for i, l := range cart.Lines {
+		remaining[i] = l.Total().Amount
+	}
+	return apply(cart, remaining, set)
+}
+
+// Stack applies p on top of discounts, taking only from what they left of each line. Loyalty
+// rewards are stacked this way since the customer chose to spend points on them.
+func Stack(cart Cart, discounts Discounts, p promotionModel.Promotion) Discounts {
+	taken := discounts.LineTotals(len(cart.Lines))
+	remaining := make([]int64, len(cart.Lines))
+	for i, l := range cart.Lines {
+		remaining[i] = l.Total().Amount - taken[i]
+	}
+	extra := apply(cart, remaining, []promotionModel.Promotion{p})
+	return Discounts{Applied: append(append([]AppliedPromotion{}, discounts.Applied...), extra.Applied...),
+		Total: moneyModel.New(discounts.Total.Amount+extra.Total.Amount, cart.Currency)}
+}
+
+// apply discounts the set in order, each promotion taking from what is left of each line.
+func apply(cart Cart, remaining []int64, set []promotionModel.Promotion) Discounts {
+	result := Discounts{Applied: []AppliedPromotion{}, Total: moneyModel.Zero(cart.Currency)}
+	for _, p := range set {
+		amounts := discountLines(cart, remaining, p)
+		applied := AppliedPromotion{PromotionId: p.Id, Code: p.Code, Name: p.Name,
+			Total: moneyModel.Zero(cart.Currency)}
+		for i, amount := range amounts {
+			if amount == 0 {
+				continue
+			}
+			remaining[i] -= amount
+			applied.Total.Amount += amount
+			applied.Lines = append(applied.Lines, LineDiscount{Line: i, PromotionId: p.Id,
+				Amount: moneyModel.New(amount, cart.Currency)})
+		}
+		if applied.Total.Amount > 0 {
+			result.Applied = append(result.Applied, applied)
+			result.Total.Amount += applied.Total.Amount
+		}
+	}
+	return result
+}
+
+// discountLines returns the discount p gives on each line, given what is left of each line.
+func discountLines(cart Cart, remaining []int64, p promotionModel.Promotion) []int64 {
+	amounts := make([]int64, len(cart.Lines))
+	scoped := make([]int64, len(cart.Lines))
+	for i, l := range cart.Lines {
+		if p.ItemType == "" || p.ItemType == l.ItemType {
+			scoped[i] = remaining[i]
+		}
+	}
+
+	switch p.Type {
+	case promotionModel.Percentage:
+		for i, r := range scoped {
+			amounts[i] = r * p.Value / promotionModel.MaxBasisPoints
+		}
+	case promotionModel.Fixed:
+		var available int64
+		for _, r := range scoped {
+			available += r
+		}
+		value := p.Value
+		if value > available {
+			value = available
+		}
+		amounts = allocate(value, scoped)
+	case promotionModel.FreeItem:
+		for i, l := range cart.Lines {
+			if p.FreeMenuId != nil && l.MenuId == *p.FreeMenuId && scoped[i] > 0 {
+				free := l.UnitPrice.Amount
+				if free > scoped[i] {
+					free = scoped[i]
+				}
+				amounts[i] = free
+				break
+			}
+		}
+	}
+	return amounts
+}
@@ -0,0 +1,141 @@
+package pricing
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/moneyModel"
+	"rsm/entity/promotionModel"
+	"testing"
+	"time"
+)
+
+func ngn(amount int64) moneyModel.Money {
+	return moneyModel.New(amount, "NGN")
+}
+
+func TestApplyPromotions(t *testing.T) {
+	restaurantId := uuid.New()
+	now := time.Date(2022, time.July, 4, 12, 0, 0, 0, time.UTC)
+	drinkId := int64(3)
+
+	cart := Cart{RestaurantId: restaurantId, Currency: "NGN", Lines: []Line{
+		{MenuId: 1, Item: "Jollof", ItemType: "main", Quantity: 2, UnitPrice: ngn(2500)},
+		{MenuId: 2, Item: "Suya", ItemType: "side", Quantity: 1, UnitPrice: ngn(1000)},
+		{MenuId: drinkId, Item: "Zobo", ItemType: "drink", Quantity: 2, UnitPrice: ngn(500)},
+	}}
+
+	promo := func(id string, mutate func(p *promotionModel.Promotion)) promotionModel.Promotion {
+		p := promotionModel.Promotion{
+			Id: uuid.MustParse(id), Name: id, Currency: "NGN", Active: true, Stackable: true,
+			StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour),
+		}
+		mutate(&p)
+		return p
+	}
+	tenPercentMains := promo("00000000-0000-0000-0000-000000000001", func(p *promotionModel.Promotion) {
+		p.Type, p.Value, p.ItemType = promotionModel.Percentage, 1000, "main"
+	})
+	fixed700 := promo("00000000-0000-0000-0000-000000000002", func(p *promotionModel.Promotion) {
+		p.Type, p.Value = promotionModel.Fixed, 700
+	})
+	freeDrink := promo("00000000-0000-0000-0000-000000000003", func(p *promotionModel.Promotion) {
+		p.Type, p.FreeMenuId, p.Priority = promotionModel.FreeItem, &drinkId, 5
+	})
+	bigSpender := promo("00000000-0000-0000-0000-000000000004", func(p *promotionModel.Promotion) {
+		p.Type, p.Value, p.Stackable = promotionModel.Percentage, 2500, false
+	})
+	expired := promo("00000000-0000-0000-0000-000000000005", func(p *promotionModel.Promotion) {
+		p.Type, p.Value, p.EndsAt = promotionModel.Fixed, 5000, now
+	})
+	minimum := promo("00000000-0000-0000-0000-000000000006", func(p *promotionModel.Promotion) {
+		p.Type, p.Value, p.MinOrderValue = promotionModel.Fixed, 5000, 10000
+	})
+	elsewhere := promo("00000000-0000-0000-0000-000000000007", func(p *promotionModel.Promotion) {
+		other := uuid.New()
+		p.Type, p.Value, p.RestaurantId = promotionModel.Fixed, 5000, &other
+	})
+
+	tests := []struct {
+		name       string
+		promotions []promotionModel.Promotion
+		wantTotal  int64
+		wantLines  []int64
+		wantIds    []uuid.UUID
+	}{
+		{
+			name:       "percentage scoped to item type",
+			promotions: []promotionModel.Promotion{tenPercentMains},
+			wantTotal:  500,
+			wantLines:  []int64{500, 0, 0},
+			wantIds:    []uuid.UUID{tenPercentMains.Id},
+		}, {
+			name:       "fixed is spread across lines in proportion",
+			promotions: []promotionModel.Promotion{fixed700},
+			wantTotal:  700,
+			wantLines:  []int64{500, 100, 100},
+			wantIds:    []uuid.UUID{fixed700.Id},
+		}, {
+			name:       "stackable promotions apply in priority order on what is left",
+			promotions: []promotionModel.Promotion{tenPercentMains, freeDrink},
+			wantTotal:  1000,
+			wantLines:  []int64{500, 0, 500},
+			wantIds:    []uuid.UUID{freeDrink.Id, tenPercentMains.Id},
+		}, {
+			name:       "non-stackable wins when it is the better deal",
+			promotions: []promotionModel.Promotion{tenPercentMains, freeDrink, bigSpender},
+			wantTotal:  1750,
+			wantLines:  []int64{1250, 250, 250},
+			wantIds:    []uuid.UUID{bigSpender.Id},
+		}, {
+			name:       "expired, below minimum and other restaurants are ignored",
+			promotions: []promotionModel.Promotion{expired, minimum, elsewhere},
+			wantTotal:  0,
+			wantLines:  []int64{0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyPromotions(cart, tt.promotions, now)
+			assert.Equal(t, ngn(tt.wantTotal), got.Total)
+			assert.Equal(t, tt.wantLines, got.LineTotals(len(cart.Lines)))
+
+			var ids []uuid.UUID
+			for _, a := range got.Applied {
+				ids = append(ids, a.PromotionId)
+			}
+			assert.Equal(t, tt.wantIds, ids)
+		})
+	}
+}
+
+func TestApplyPromotions_FixedNeverExceedsCart(t *testing.T) {
+	cart := Cart{Currency: "NGN", Lines: []Line{{MenuId: 1, ItemType: "side", Quantity: 1, UnitPrice: ngn(300)}}}
+	p := promotionModel.Promotion{Id: uuid.New(), Type: promotionModel.Fixed, Value: 1000, Currency: "NGN",
+		Active: true, StartsAt: time.Unix(0, 0), EndsAt: time.Now().Add(time.Hour)}
+
+	got := ApplyPromotions(cart, []promotionModel.Promotion{p}, time.Now())
+	assert.Equal(t, ngn(300), got.Total)
+}
+
+func TestStack(t *testing.T) {
+	cart := Cart{Currency: "NGN", Lines: []Line{
+		{MenuId: 1, ItemType: "main", Quantity: 1, UnitPrice: ngn(2000)},
+		{MenuId: 2, ItemType: "drink", Quantity: 1, UnitPrice: ngn(500)},
+	}}
+	half := promotionModel.Promotion{Id: uuid.New(), Type: promotionModel.Percentage, Value: 5000, Currency: "NGN",
+		Active: true, StartsAt: time.Unix(0, 0), EndsAt: time.Now().Add(time.Hour)}
+	discounts := ApplyPromotions(cart, []promotionModel.Promotion{half}, time.Now())
+
+	reward := promotionModel.Promotion{Id: uuid.New(), Type: promotionModel.Fixed, Value: 5000, Currency: "NGN"}
+	got := Stack(cart, discounts, reward)
+	assert.Len(t, got.Applied, 2)
+	assert.Equal(t, ngn(2500), got.Total)
+	assert.Equal(t, []int64{2000, 500}, got.LineTotals(2))
+	assert.Len(t, discounts.Applied, 1)
+}
+
+func TestAllocate(t *testing.T) {
+	assert.Equal(t, []int64{34, 33, 33}, allocate(100, []int64{1, 1, 1}))
+	assert.Equal(t, []int64{0, 10, 0}, allocate(10, []int64{0, 5, 0}))
+	assert.Equal(t, []int64{0, 0}, allocate(10, []int64{0, 0}))
+}
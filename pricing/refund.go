@@ -0,0 +1,31 @@
+package pricing
+
+import (
+	"fmt"
+	"rsm/entity/orderModel"
+)
+
+// ItemAmounts spreads the order total over its items in proportion to their price, so
+// discounts, tax and service charge are shared the same way SplitByItem shares them.
+func ItemAmounts(total int64, items []orderModel.OrderItem) []int64 {
+	weights := make([]int64, len(items))
+	for i, item := range items {
+		weights[i] = item.Gross()
+	}
+	return allocate(total, weights)
+}
+
+// RefundUnits is what refunding units more of an item is worth when refunded of its quantity
+// units were refunded before. Refunding every unit, in any number of steps, returns exactly
+// itemAmount.
+func RefundUnits(itemAmount int64, quantity, refunded, units int) (int64, error) {
+	if units <= 0 {
+		return 0, fmt.Errorf("refund at least one unit")
+	}
+	if refunded+units > quantity {
+		return 0, fmt.Errorf("only %d of %d units left to refund", quantity-refunded, quantity)
+	}
+	before := itemAmount * int64(refunded) / int64(quantity)
+	after := itemAmount * int64(refunded+units) / int64(quantity)
+	return after - before, nil
+}
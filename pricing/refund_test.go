@@ -0,0 +1,37 @@
+package pricing
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/orderModel"
+	"testing"
+)
+
+func TestItemAmounts(t *testing.T) {
+	items := []orderModel.OrderItem{
+		{Id: 1, Quantity: 1, UnitPrice: 3000},
+		{Id: 2, Quantity: 2, UnitPrice: 2000},
+		{Id: 3, Quantity: 1, UnitPrice: 1000},
+	}
+	assert.Equal(t, []int64{3750, 5000, 1250}, ItemAmounts(10000, items))
+	assert.Equal(t, []int64{1, 1, 0}, ItemAmounts(2, items))
+}
+
+func TestRefundUnits(t *testing.T) {
+	var refunded int64
+	for i := 0; i < 3; i++ {
+		amount, err := RefundUnits(1000, 3, i, 1)
+		assert.Nil(t, err)
+		refunded += amount
+	}
+	assert.Equal(t, int64(1000), refunded)
+
+	amount, err := RefundUnits(1000, 3, 1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(667), amount)
+
+	_, err = RefundUnits(1000, 3, 2, 2)
+	assert.Equal(t, fmt.Errorf("only 1 of 3 units left to refund"), err)
+	_, err = RefundUnits(1000, 3, 0, 0)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,53 @@
+package pricing
+
+import (
+	"rsm/entity/moneyModel"
+	"rsm/entity/orderModel"
+)
+
+// Repriced is a cart line whose price changed since it was last ordered.
+type Repriced struct {
+	Line     int              `json:"line"`
+	MenuId   int64            `json:"menuId"`
+	Item     string           `json:"item"`
+	Previous moneyModel.Money `json:"previous"`
+	Current  moneyModel.Money `json:"current"`
+}
+
+// Reorder is a past order rebuilt as a cart of what can be ordered again now. Unavailable
+// holds the items left out and Repriced the lines that cost something different now.
+type Reorder struct {
+	Cart        Cart                   `json:"cart"`
+	Unavailable []orderModel.OrderItem `json:"unavailable"`
+	Repriced    []Repriced             `json:"repriced"`
+}
+
+// Rebuild turns the order back into a cart. serving holds what the restaurant serves now by
+// menu id, priced in the order currency; the cart takes its names and prices, and items it
+// does not have are reported instead.
+func Rebuild(order orderModel.Order, serving map[int64]Line) Reorder {
+	reorder := Reorder{
+		Cart:        Cart{RestaurantId: order.RestaurantId, Currency: order.Currency, Lines: []Line{}},
+		Unavailable: []orderModel.OrderItem{},
+		Repriced:    []Repriced{},
+	}
+	for _, item := range order.Items {
+		current, ok := serving[item.MenuId]
+		if !ok {
+			reorder.Unavailable = append(reorder.Unavailable, item)
+			continue
+		}
+		current.Quantity = item.Quantity
+		if current.UnitPrice.Amount != item.UnitPrice {
+			reorder.Repriced = append(reorder.Repriced, Repriced{
+				Line:     len(reorder.Cart.Lines),
+				MenuId:   item.MenuId,
+				Item:     current.Item,
+				Previous: moneyModel.New(item.UnitPrice, order.Currency),
+				Current:  current.UnitPrice,
+			})
+		}
+		reorder.Cart.Lines = append(reorder.Cart.Lines, current)
+	}
+	return reorder
+}
@@ -0,0 +1,30 @@
+package pricing
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/orderModel"
+	"testing"
+)
+
+func TestRebuild(t *testing.T) {
+	order := orderModel.Order{Id: uuid.New(), RestaurantId: uuid.New(), Currency: "NGN", Items: []orderModel.OrderItem{
+		{MenuId: 1, Item: "Jollof", ItemType: "main", Quantity: 2, UnitPrice: 2500},
+		{MenuId: 2, Item: "Suya", ItemType: "side", Quantity: 1, UnitPrice: 1000},
+		{MenuId: 3, Item: "Zobo", ItemType: "drink", Quantity: 3, UnitPrice: 500},
+	}}
+	serving := map[int64]Line{
+		1: {MenuId: 1, Item: "Jollof", ItemType: "main", UnitPrice: ngn(2500)},
+		3: {MenuId: 3, Item: "Zobo (large)", ItemType: "drink", UnitPrice: ngn(600)},
+	}
+
+	got := Rebuild(order, serving)
+	assert.Equal(t, order.RestaurantId, got.Cart.RestaurantId)
+	assert.Equal(t, []Line{
+		{MenuId: 1, Item: "Jollof", ItemType: "main", Quantity: 2, UnitPrice: ngn(2500)},
+		{MenuId: 3, Item: "Zobo (large)", ItemType: "drink", Quantity: 3, UnitPrice: ngn(600)},
+	}, got.Cart.Lines)
+	assert.Equal(t, []orderModel.OrderItem{order.Items[1]}, got.Unavailable)
+	assert.Equal(t, []Repriced{{Line: 1, MenuId: 3, Item: "Zobo (large)", Previous: ngn(500), Current: ngn(600)}},
+		got.Repriced)
+}
@@ -0,0 +1,78 @@
+package pricing
+
+import (
+	"fmt"
+	"rsm/entity/orderModel"
+)
+
+// SplitEqual divides total into n shares that differ by at most one minor unit, the earlier
+// shares taking the extra units.
+func SplitEqual(total int64, n int) ([]int64, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("a split needs at least one payer")
+	}
+	weights := make([]int64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return allocate(total, weights), nil
+}
+
+// SplitCustom accepts amounts chosen by the payers as long as they cover total exactly.
+func SplitCustom(total int64, amounts []int64) ([]int64, error) {
+	if len(amounts) == 0 {
+		return nil, fmt.Errorf("a split needs at least one payer")
+	}
+	var sum int64
+	for _, a := range amounts {
+		if a < 0 {
+			return nil, fmt.Errorf("share amounts cannot be negative")
+		}
+		sum += a
+	}
+	if sum != total {
+		return nil, fmt.Errorf("shares add up to %d, order total is %d", sum, total)
+	}
+	return amounts, nil
+}
+
+// SplitByItem charges each payer for the items assigned to them. assignments[p] lists the
+// order item ids payer p had; an item assigned to several payers is shared equally. The
+// order total, which includes discounts, tax and service charge, is first spread over the
+// items in proportion to their price so the shares always add up to the total.
+func SplitByItem(total int64, items []orderModel.OrderItem, assignments [][]int64) ([]int64, error) {
+	if len(assignments) == 0 {
+		return nil, fmt.Errorf("a split needs at least one payer")
+	}
+
+	index := map[int64]int{}
+	weights := make([]int64, len(items))
+	for i, item := range items {
+		index[item.Id] = i
+		weights[i] = item.Gross()
+	}
+	payersOf := make([][]int, len(items))
+	for payer, ids := range assignments {
+		for _, id := range ids {
+			i, ok := index[id]
+			if !ok {
+				return nil, fmt.Errorf("item %d is not part of the order", id)
+			}
+			payersOf[i] = append(payersOf[i], payer)
+		}
+	}
+	for i, payers := range payersOf {
+		if len(payers) == 0 {
+			return nil, fmt.Errorf("item %d is not assigned to anyone", items[i].Id)
+		}
+	}
+
+	shares := make([]int64, len(assignments))
+	for i, lineAmount := range allocate(total, weights) {
+		parts, _ := SplitEqual(lineAmount, len(payersOf[i]))
+		for j, payer := range payersOf[i] {
+			shares[payer] += parts[j]
+		}
+	}
+	return shares, nil
+}
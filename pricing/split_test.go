@@ -0,0 +1,78 @@
+package pricing
+
+import (
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/orderModel"
+	"testing"
+)
+
+func TestSplitEqual(t *testing.T) {
+	shares, err := SplitEqual(1000, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{334, 333, 333}, shares)
+
+	_, err = SplitEqual(1000, 0)
+	assert.NotNil(t, err)
+}
+
+func TestSplitCustom(t *testing.T) {
+	shares, err := SplitCustom(1000, []int64{600, 400})
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{600, 400}, shares)
+
+	_, err = SplitCustom(1000, []int64{600, 300})
+	assert.NotNil(t, err)
+
+	_, err = SplitCustom(1000, []int64{1100, -100})
+	assert.NotNil(t, err)
+}
+
+func TestSplitByItem(t *testing.T) {
+	items := []orderModel.OrderItem{
+		{Id: 1, Quantity: 1, UnitPrice: 3000},
+		{Id: 2, Quantity: 2, UnitPrice: 1000},
+		{Id: 3, Quantity: 1, UnitPrice: 1000},
+	}
+
+	tests := []struct {
+		name        string
+		total       int64
+		assignments [][]int64
+		want        []int64
+		wantErr     bool
+	}{
+		{
+			name:        "each payer pays for their items",
+			total:       6000,
+			assignments: [][]int64{{1}, {2, 3}},
+			want:        []int64{3000, 3000},
+		}, {
+			name:        "tax and service are spread in proportion",
+			total:       6600,
+			assignments: [][]int64{{1}, {2, 3}},
+			want:        []int64{3300, 3300},
+		}, {
+			name:        "shared item is split equally",
+			total:       6001,
+			assignments: [][]int64{{1, 3}, {2, 3}},
+			want:        []int64{3501, 2500},
+		}, {
+			name:        "unassigned item",
+			total:       6000,
+			assignments: [][]int64{{1}, {2}},
+			wantErr:     true,
+		}, {
+			name:        "unknown item",
+			total:       6000,
+			assignments: [][]int64{{1, 2, 3, 9}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitByItem(tt.total, items, tt.assignments)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
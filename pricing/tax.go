@@ -0,0 +1,182 @@
+package pricing
+
+import (
+	"math/big"
+	"rsm/entity/moneyModel"
+	"rsm/entity/taxModel"
+)
+
+// LineBreakdown is one priced cart line. Net excludes tax and discounts are already taken
+// off; Total is what the customer pays for the line.
+type LineBreakdown struct {
+	Line      int              `json:"line"`
+	MenuId    int64            `json:"menuId"`
+	Item      string           `json:"item"`
+	ItemType  string           `json:"itemType"`
+	Quantity  int              `json:"quantity"`
+	UnitPrice moneyModel.Money `json:"unitPrice"`
+	Gross     moneyModel.Money `json:"gross"`
+	Discount  moneyModel.Money `json:"discount"`
+	TaxRate   int64            `json:"taxRate"`
+	Net       moneyModel.Money `json:"net"`
+	Tax       moneyModel.Money `json:"tax"`
+	Total     moneyModel.Money `json:"total"`
+}
+
+type Breakdown struct {
+	Currency         string           `json:"currency"`
+	Inclusive        bool             `json:"inclusive"`
+	Lines            []LineBreakdown  `json:"lines"`
+	Subtotal         moneyModel.Money `json:"subtotal"`
+	Discount         moneyModel.Money `json:"discount"`
+	Net              moneyModel.Money `json:"net"`
+	LineTax          moneyModel.Money `json:"lineTax"`
+	ServiceCharge    moneyModel.Money `json:"serviceCharge"`
+	ServiceChargeTax moneyModel.Money `json:"serviceChargeTax"`
+	Tax              moneyModel.Money `json:"tax"`
+	DeliveryFee      moneyModel.Money `json:"deliveryFee"`
+	Total            moneyModel.Money `json:"total"`
+	Discounts        Discounts        `json:"discounts"`
+}
+
+// Price produces the itemised bill for a cart after discounts under the given tax rules.
+// It is deterministic: the same inputs always give the same breakdown, and every total is
+// the exact sum of its parts.
+//
+// Tax on a line is Net*rate for exclusive prices, or the tax contained in the discounted
+// price, price*rate/(1+rate), for inclusive ones. The service charge is a rate on the
+// order's net amount and, when taxable, is taxed at the default rate.
+func Price(cart Cart, discounts Discounts, rules taxModel.TaxRules) Breakdown {
+	currency := cart.Currency
+	money := func(amount int64) moneyModel.Money { return moneyModel.New(amount, currency) }
+	lineDiscounts := discounts.LineTotals(len(cart.Lines))
+
+	exact := make([]*big.Rat, len(cart.Lines))
+	breakdown := Breakdown{Currency: currency, Inclusive: rules.Inclusive, Discounts: discounts}
+	for i, l := range cart.Lines {
+		gross := l.Total().Amount
+		payable := gross - lineDiscounts[i]
+		rate := rules.RateFor(l.ItemType)
+
+		exact[i] = new(big.Rat).SetFrac64(payable*rate, taxModel.MaxBasisPoints)
+		if rules.Inclusive {
+			exact[i] = new(big.Rat).SetFrac64(payable*rate, taxModel.MaxBasisPoints+rate)
+		}
+
+		breakdown.Lines = append(breakdown.Lines, LineBreakdown{
+			Line:      i,
+			MenuId:    l.MenuId,
+			Item:      l.Item,
+			ItemType:  l.ItemType,
+			Quantity:  l.Quantity,
+			UnitPrice: l.UnitPrice,
+			Gross:     money(gross),
+			Discount:  money(lineDiscounts[i]),
+			TaxRate:   rate,
+		})
+	}
+
+	taxes := roundLines(exact, rules)
+	var subtotal, discount, net, lineTax, total int64
+	for i := range breakdown.Lines {
+		l := &breakdown.Lines[i]
+		payable := l.Gross.Amount - l.Discount.Amount
+		l.Tax = money(taxes[i])
+		if rules.Inclusive {
+			l.Net = money(payable - taxes[i])
+			l.Total = money(payable)
+		} else {
+			l.Net = money(payable)
+			l.Total = money(payable + taxes[i])
+		}
+		subtotal += l.Gross.Amount
+		discount += l.Discount.Amount
+		net += l.Net.Amount
+		lineTax += l.Tax.Amount
+		total += l.Total.Amount
+	}
+
+	serviceCharge := round(new(big.Rat).SetFrac64(net*rules.ServiceChargeRate, taxModel.MaxBasisPoints), rules.Method)
+	var serviceTax int64
+	if rules.ServiceChargeTaxable {
+		serviceTax = round(new(big.Rat).SetFrac64(serviceCharge*rules.DefaultRate, taxModel.MaxBasisPoints), rules.Method)
+	}
+
+	breakdown.Subtotal = money(subtotal)
+	breakdown.Discount = money(discount)
+	breakdown.Net = money(net)
+	breakdown.LineTax = money(lineTax)
+	breakdown.ServiceCharge = money(serviceCharge)
+	breakdown.ServiceChargeTax = money(serviceTax)
+	breakdown.Tax = money(lineTax + serviceTax)
+	breakdown.DeliveryFee = money(0)
+	breakdown.Total = money(total + serviceCharge + serviceTax)
+	return breakdown
+}
+
+// AddDeliveryFee charges the fee on top of the total. Delivery fees are not taxed.
+func (b *Breakdown) AddDeliveryFee(fee int64) {
+	b.DeliveryFee.Amount += fee
+	b.Total.Amount += fee
+}
+
+// roundLines turns exact line taxes into minor units. PerTotal rounds the sum once and then
+// hands the rounded total back to the lines, truncated amounts first and the leftover units
+// to the largest fractions, so the lines still add up to the total.
+func roundLines(exact []*big.Rat, rules taxModel.TaxRules) []int64 {
+	rounded := make([]int64, len(exact))
+	if rules.Rounding == taxModel.PerLine {
+		for i, r := range exact {
+			rounded[i] = round(r, rules.Method)
+		}
+		return rounded
+	}
+
+	sum := new(big.Rat)
+	fractions := make([]*big.Rat, len(exact))
+	var truncated int64
+	for i, r := range exact {
+		sum.Add(sum, r)
+		rounded[i] = new(big.Int).Quo(r.Num(), r.Denom()).Int64()
+		fractions[i] = new(big.Rat).Sub(r, new(big.Rat).SetInt64(rounded[i]))
+		truncated += rounded[i]
+	}
+
+	for leftover := round(sum, rules.Method) - truncated; leftover > 0; leftover-- {
+		best := -1
+		for i, f := range fractions {
+			if f.Sign() > 0 && (best == -1 || f.Cmp(fractions[best]) > 0) {
+				best = i
+			}
+		}
+		if best == -1 {
+			break
+		}
+		rounded[best]++
+		fractions[best] = new(big.Rat)
+	}
+	return rounded
+}
+
+// round converts an exact amount to whole minor units using the given method.
+func round(r *big.Rat, method taxModel.Method) int64 {
+	quo, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+
+	away := false
+	switch twice.Cmp(r.Denom()) {
+	case 1:
+		away = true
+	case 0:
+		away = method == taxModel.HalfUp || quo.Bit(0) == 1
+	}
+	if away {
+		if r.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo.Int64()
+}
@@ -0,0 +1,165 @@
+package pricing
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/taxModel"
+	"testing"
+)
+
+func TestPrice(t *testing.T) {
+	line := func(itemType string, quantity int, unit int64) Line {
+		return Line{ItemType: itemType, Quantity: quantity, UnitPrice: ngn(unit)}
+	}
+	rules := func(mutate func(r *taxModel.TaxRules)) taxModel.TaxRules {
+		r := taxModel.TaxRules{Rounding: taxModel.PerLine, Method: taxModel.HalfUp}
+		mutate(&r)
+		return r
+	}
+	discount := func(line int, amount int64) Discounts {
+		return Discounts{Applied: []AppliedPromotion{{Lines: []LineDiscount{{Line: line, Amount: ngn(amount)}}}}}
+	}
+
+	tests := []struct {
+		name      string
+		lines     []Line
+		discounts Discounts
+		rules     taxModel.TaxRules
+		wantTaxes []int64
+		wantTax   int64
+		wantTotal int64
+	}{
+		{
+			name:      "per line half up rounds every half cent up",
+			lines:     []Line{line("side", 1, 10), line("side", 1, 10), line("side", 1, 10)},
+			rules:     rules(func(r *taxModel.TaxRules) { r.DefaultRate = 500 }),
+			wantTaxes: []int64{1, 1, 1},
+			wantTax:   3,
+			wantTotal: 33,
+		}, {
+			name:  "per line half even rounds halves to zero",
+			lines: []Line{line("side", 1, 10), line("side", 1, 10), line("side", 1, 10)},
+			rules: rules(func(r *taxModel.TaxRules) {
+				r.DefaultRate, r.Method = 500, taxModel.HalfEven
+			}),
+			wantTaxes: []int64{0, 0, 0},
+			wantTax:   0,
+			wantTotal: 30,
+		}, {
+			name:  "per total rounds the sum once and spreads it over lines",
+			lines: []Line{line("side", 1, 10), line("side", 1, 10), line("side", 1, 10)},
+			rules: rules(func(r *taxModel.TaxRules) {
+				r.DefaultRate, r.Rounding = 500, taxModel.PerTotal
+			}),
+			wantTaxes: []int64{1, 1, 0},
+			wantTax:   2,
+			wantTotal: 32,
+		}, {
+			name:  "half even rounds an odd half up",
+			lines: []Line{line("side", 1, 30), line("side", 1, 50)},
+			rules: rules(func(r *taxModel.TaxRules) {
+				r.DefaultRate, r.Method = 500, taxModel.HalfEven
+			}),
+			wantTaxes: []int64{2, 2},
+			wantTax:   4,
+			wantTotal: 84,
+		}, {
+			name:      "half up on the same lines",
+			lines:     []Line{line("side", 1, 30), line("side", 1, 50)},
+			rules:     rules(func(r *taxModel.TaxRules) { r.DefaultRate = 500 }),
+			wantTaxes: []int64{2, 3},
+			wantTax:   5,
+			wantTotal: 85,
+		}, {
+			name:  "inclusive price with exact tax",
+			lines: []Line{line("main", 1, 1075)},
+			rules: rules(func(r *taxModel.TaxRules) {
+				r.DefaultRate, r.Inclusive = 750, true
+			}),
+			wantTaxes: []int64{75},
+			wantTax:   75,
+			wantTotal: 1075,
+		}, {
+			name:  "inclusive price with rounded tax",
+			lines: []Line{line("main", 2, 500)},
+			rules: rules(func(r *taxModel.TaxRules) {
+				r.DefaultRate, r.Inclusive = 750, true
+			}),
+			wantTaxes: []int64{70},
+			wantTax:   70,
+			wantTotal: 1000,
+		}, {
+			name:  "rates per item type",
+			lines: []Line{line("main", 1, 1000), line("drink", 2, 300)},
+			rules: rules(func(r *taxModel.TaxRules) {
+				r.DefaultRate, r.Rates = 750, map[string]int64{"drink": 0}
+			}),
+			wantTaxes: []int64{75, 0},
+			wantTax:   75,
+			wantTotal: 1675,
+		}, {
+			name:      "tax applies after discount",
+			lines:     []Line{line("main", 1, 2000)},
+			discounts: discount(0, 500),
+			rules:     rules(func(r *taxModel.TaxRules) { r.DefaultRate = 750 }),
+			wantTaxes: []int64{113},
+			wantTax:   113,
+			wantTotal: 1613,
+		}, {
+			name:      "discounted half even",
+			lines:     []Line{line("main", 1, 2000)},
+			discounts: discount(0, 500),
+			rules: rules(func(r *taxModel.TaxRules) {
+				r.DefaultRate, r.Method = 750, taxModel.HalfEven
+			}),
+			wantTaxes: []int64{112},
+			wantTax:   112,
+			wantTotal: 1612,
+		}, {
+			name:  "taxable service charge",
+			lines: []Line{line("main", 1, 1050)},
+			rules: rules(func(r *taxModel.TaxRules) {
+				r.DefaultRate, r.ServiceChargeRate, r.ServiceChargeTaxable = 750, 1000, true
+			}),
+			wantTaxes: []int64{79},
+			wantTax:   87,
+			wantTotal: 1050 + 79 + 105 + 8,
+		}, {
+			name:  "service charge on inclusive prices uses the net amount",
+			lines: []Line{line("main", 1, 1075)},
+			rules: rules(func(r *taxModel.TaxRules) {
+				r.DefaultRate, r.Inclusive, r.ServiceChargeRate = 750, true, 1250
+			}),
+			wantTaxes: []int64{75},
+			wantTax:   75,
+			wantTotal: 1075 + 125,
+		}, {
+			name:      "no tax rules",
+			lines:     []Line{line("main", 3, 333)},
+			rules:     taxModel.NoTax(uuid.Nil),
+			wantTaxes: []int64{0},
+			wantTax:   0,
+			wantTotal: 999,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart := Cart{Currency: "NGN", Lines: tt.lines}
+			got := Price(cart, tt.discounts, tt.rules)
+
+			var taxes []int64
+			var lineTotals, net int64
+			for _, l := range got.Lines {
+				taxes = append(taxes, l.Tax.Amount)
+				lineTotals += l.Total.Amount
+				net += l.Net.Amount
+			}
+			assert.Equal(t, tt.wantTaxes, taxes)
+			assert.Equal(t, ngn(tt.wantTax), got.Tax)
+			assert.Equal(t, ngn(tt.wantTotal), got.Total)
+			assert.Equal(t, got.Total.Amount, lineTotals+got.ServiceCharge.Amount+got.ServiceChargeTax.Amount)
+			assert.Equal(t, got.Net.Amount, net)
+			assert.Equal(t, got, Price(cart, tt.discounts, tt.rules))
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrClosed is returned when publishing to or subscribing on a broker that was shut down.
+	ErrClosed = errors.New("broker closed")
+	// ErrTooFarBehind is returned by Since when the broker can no longer replay everything
+	// after the given id. The subscriber should reload its state instead.
+	ErrTooFarBehind = errors.New("too many missed messages to replay")
+)
+
+// SubscriberBuffer is how many undelivered messages a subscriber may have before it is
+// dropped.
+const SubscriberBuffer = 64
+
+// Message is one published payload. Ids increase across the whole broker and messages are
+// delivered in id order, so a subscriber can resume a topic from the last id it saw.
+type Message struct {
+	Id      int64
+	Topic   string
+	Payload []byte
+}
+
+// Broker fans messages out to everyone subscribed to a topic. Subscriptions end when ctx is
+// cancelled; a subscriber that falls too far behind has its channel closed and should
+// subscribe again, using Since to catch up on what it missed.
+type Broker interface {
+	Publish(topic string, payload []byte) (*Message, error)
+	Subscribe(ctx context.Context, topic string) (<-chan Message, error)
+	Since(topic string, afterId int64) ([]Message, error)
+}
+
+// hub delivers messages to the subscribers in this process. Both brokers use it once they
+// know a message's id.
+type hub struct {
+	mu     sync.Mutex
+	closed bool
+	topics map[string]map[chan Message]struct{}
+}
+
+func newHub() hub {
+	return hub{topics: map[string]map[chan Message]struct{}{}}
+}
+
+func (h *hub) subscribe(ctx context.Context, topic string) (<-chan Message, error) {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil, ErrClosed
+	}
+	ch := make(chan Message, SubscriberBuffer)
+	if h.topics[topic] == nil {
+		h.topics[topic] = map[chan Message]struct{}{}
+	}
+	h.topics[topic][ch] = struct{}{}
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(topic, ch)
+	}()
+	return ch, nil
+}
+
+func (h *hub) deliver(msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.topics[msg.Topic] {
+		select {
+		case ch <- msg:
+		default:
+			h.remove(msg.Topic, ch)
+		}
+	}
+}
+
+// close ends every subscription and refuses new ones.
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for topic, subscribers := range h.topics {
+		for ch := range subscribers {
+			h.remove(topic, ch)
+		}
+	}
+}
+
+// remove must be called with mu held. It is safe to call more than once for a channel.
+func (h *hub) remove(topic string, ch chan Message) {
+	if _, ok := h.topics[topic][ch]; !ok {
+		return
+	}
+	delete(h.topics[topic], ch)
+	if len(h.topics[topic]) == 0 {
+		delete(h.topics, topic)
+	}
+	close(ch)
+}
@@ -0,0 +1,58 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// HistorySize is how many recent messages per topic the memory broker keeps for Since.
+const HistorySize = 256
+
+type memoryBroker struct {
+	hub
+	mu      sync.Mutex
+	lastId  int64
+	history map[string][]Message
+	// dropped is the id of the newest message each topic's history no longer holds.
+	dropped map[string]int64
+}
+
+// NewMemoryBroker returns a broker that only reaches subscribers in the same process and
+// only remembers the last HistorySize messages of each topic.
+func NewMemoryBroker() Broker {
+	return &memoryBroker{hub: newHub(), history: map[string][]Message{}, dropped: map[string]int64{}}
+}
+
+func (m *memoryBroker) Publish(topic string, payload []byte) (*Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastId++
+	msg := Message{Id: m.lastId, Topic: topic, Payload: payload}
+	history := append(m.history[topic], msg)
+	if len(history) > HistorySize {
+		m.dropped[topic] = history[len(history)-HistorySize-1].Id
+		history = history[len(history)-HistorySize:]
+	}
+	m.history[topic] = history
+	m.deliver(msg)
+	return &msg, nil
+}
+
+func (m *memoryBroker) Subscribe(ctx context.Context, topic string) (<-chan Message, error) {
+	return m.subscribe(ctx, topic)
+}
+
+func (m *memoryBroker) Since(topic string, afterId int64) ([]Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if afterId < m.dropped[topic] {
+		return nil, ErrTooFarBehind
+	}
+	messages := []Message{}
+	for _, msg := range m.history[topic] {
+		if msg.Id > afterId {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
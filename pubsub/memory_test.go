@@ -0,0 +1,69 @@
+package pubsub
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	b := NewMemoryBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	kitchen, err := b.Subscribe(ctx, "kitchen")
+	assert.Nil(t, err)
+	other, err := b.Subscribe(context.Background(), "other")
+	assert.Nil(t, err)
+
+	first, _ := b.Publish("kitchen", []byte("one"))
+	second, _ := b.Publish("kitchen", []byte("two"))
+	assert.Less(t, first.Id, second.Id)
+
+	assert.Equal(t, "one", string((<-kitchen).Payload))
+	assert.Equal(t, "two", string((<-kitchen).Payload))
+	assert.Len(t, other, 0)
+
+	cancel()
+	_, open := <-kitchen
+	assert.False(t, open)
+}
+
+func TestMemoryBroker_DropsSlowSubscribers(t *testing.T) {
+	b := NewMemoryBroker()
+	slow, _ := b.Subscribe(context.Background(), "kitchen")
+
+	for i := 0; i <= SubscriberBuffer; i++ {
+		_, err := b.Publish("kitchen", []byte("tick"))
+		assert.Nil(t, err)
+	}
+
+	received := 0
+	for range slow {
+		received++
+	}
+	assert.Equal(t, SubscriberBuffer, received)
+}
+
+func TestMemoryBroker_Since(t *testing.T) {
+	b := NewMemoryBroker()
+	first, _ := b.Publish("orders", []byte("placed"))
+	_, _ = b.Publish("other", []byte("ignored"))
+	third, _ := b.Publish("orders", []byte("ready"))
+
+	missed, err := b.Since("orders", first.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, []Message{*third}, missed)
+
+	all, _ := b.Since("orders", 0)
+	assert.Len(t, all, 2)
+
+	for i := 0; i < HistorySize+10; i++ {
+		_, _ = b.Publish("busy", nil)
+	}
+	_, err = b.Since("busy", 0)
+	assert.Equal(t, ErrTooFarBehind, err)
+	last, _ := b.Publish("busy", nil)
+	kept, err := b.Since("busy", last.Id-HistorySize)
+	assert.Nil(t, err)
+	assert.Len(t, kept, HistorySize)
+}
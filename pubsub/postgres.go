@@ -0,0 +1,217 @@
+package pubsub
+
+import (
+	"context"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// Channel is the Postgres notification channel every published message is announced on.
+	Channel = "rsm_events"
+	// ReplayLimit caps how many missed messages Since returns; clients further behind get
+	// ErrTooFarBehind and should reload their state instead of replaying.
+	ReplayLimit = 500
+	// ReconnectDelay is how long the listener waits before reconnecting after its connection
+	// fails. It doubles on every failed attempt up to MaxReconnectDelay.
+	ReconnectDelay    = time.Second
+	MaxReconnectDelay = 30 * time.Second
+	// publishLock is the advisory lock publishers hold until they commit, so messages become
+	// visible in id order.
+	publishLock = 0x72736d01
+)
+
+// listener is the connection notifications are received on. *pgx.Conn is one.
+type listener interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
+	Close(ctx context.Context) error
+}
+
+type postgresBroker struct {
+	hub
+	log     *logrus.Logger
+	mu      sync.Mutex
+	conn    *pgx.Conn
+	connect func(ctx context.Context) (listener, error)
+	retry   time.Duration
+	// lastId is the last message delivered to local subscribers. Only the listener uses it.
+	lastId int64
+}
+
+// NewPostgresBroker stores messages in "EventLog" and announces them with NOTIFY, so every
+// process listening on the database delivers them to its own subscribers. conn is used to
+// publish and replay; connect opens the connection notifications are received on, again
+// whenever it fails. Subscriptions end when ctx is cancelled.
+func NewPostgresBroker(ctx context.Context, log *logrus.Logger, conn *pgx.Conn, connect func(ctx context.Context) (*pgx.Conn, error)) (Broker, error) {
+	return newPostgresBroker(ctx, log, conn, func(ctx context.Context) (listener, error) {
+		return connect(ctx)
+	}, ReconnectDelay)
+}
+
+func newPostgresBroker(ctx context.Context, log *logrus.Logger, conn *pgx.Conn, connect func(ctx context.Context) (listener, error), retry time.Duration) (*postgresBroker, error) {
+	b := &postgresBroker{hub: newHub(), log: log, conn: conn, connect: connect, retry: retry}
+	l, err := b.listenOn(ctx)
+	if err == nil {
+		err = l.QueryRow(ctx, `SELECT coalesce(max(id), 0) FROM "EventLog"`).Scan(&b.lastId)
+	}
+	if err != nil {
+		log.Errorf("Error Listening For Events: %v", err)
+		return nil, err
+	}
+	go b.listen(ctx, l)
+	return b, nil
+}
+
+// Publish stores the message and notifies listeners in the same transaction, so the
+// notification is only sent once the message can be read back. Publishers take turns until
+// they commit, so a message never becomes visible after one with a higher id. The message
+// reaches local subscribers through the listener like everyone else's.
+func (p *postgresBroker) Publish(topic string, payload []byte) (*Message, error) {
+	msg := Message{Topic: topic, Payload: payload}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), `SELECT pg_advisory_xact_lock($1)`, publishLock)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRow(context.Background(), `INSERT INTO "EventLog" (topic, payload, created_at)
+			VALUES ($1, $2, $3) RETURNING id`, topic, payload, time.Now()).Scan(&msg.Id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(context.Background(), `SELECT pg_notify($1, $2)`, Channel, strconv.FormatInt(msg.Id, 10))
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Publishing Event: %v", err)
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (p *postgresBroker) Subscribe(ctx context.Context, topic string) (<-chan Message, error) {
+	return p.subscribe(ctx, topic)
+}
+
+// Since returns ErrTooFarBehind rather than a partial replay when more than ReplayLimit
+// messages were missed.
+func (p *postgresBroker) Since(topic string, afterId int64) ([]Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rows, err := p.conn.Query(context.Background(), `SELECT id, topic, payload FROM "EventLog"
+		WHERE topic = $1 AND id > $2 ORDER BY id LIMIT $3`, topic, afterId, ReplayLimit+1)
+	if err != nil {
+		p.log.Errorf("Error Replaying Events: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []Message{}
+	for rows.Next() {
+		var msg Message
+		if err = rows.Scan(&msg.Id, &msg.Topic, &msg.Payload); err != nil {
+			p.log.Errorf("Error Scanning Event: %v", err)
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(messages) > ReplayLimit {
+		return nil, ErrTooFarBehind
+	}
+	return messages, nil
+}
+
+// Prune deletes messages older than age. Clients that were away longer than that cannot
+// resume and have to reload.
+func (p *postgresBroker) Prune(age time.Duration) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tag, err := p.conn.Exec(context.Background(), `DELETE FROM "EventLog" WHERE created_at < $1`,
+		time.Now().Add(-age))
+	if err != nil {
+		p.log.Errorf("Error Pruning Events: %v", err)
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (p *postgresBroker) listenOn(ctx context.Context) (listener, error) {
+	l, err := p.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = l.Exec(ctx, "LISTEN "+Channel)
+	if err != nil {
+		_ = l.Close(context.Background())
+		return nil, err
+	}
+	return l, nil
+}
+
+// listen owns the listening connection. When it fails, listen reconnects with a growing
+// delay and carries on from the last message it delivered, so subscribers only notice a
+// pause.
+func (p *postgresBroker) listen(ctx context.Context, l listener) {
+	defer p.close()
+	for {
+		err := p.follow(ctx, l)
+		_ = l.Close(context.Background())
+		if ctx.Err() != nil {
+			return
+		}
+		p.log.Errorf("Error Waiting For Events: %v", err)
+
+		delay := p.retry
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			l, err = p.listenOn(ctx)
+			if err == nil {
+				break
+			}
+			p.log.Errorf("Error Reconnecting Event Listener: %v", err)
+			if delay *= 2; delay > MaxReconnectDelay {
+				delay = MaxReconnectDelay
+			}
+		}
+	}
+}
+
+// follow delivers every message after the last one delivered, then waits for the next
+// notification. Notifications only wake it up: messages are read back in id order, which
+// is the order they were committed in, so nothing published while the connection was down
+// is skipped.
+func (p *postgresBroker) follow(ctx context.Context, l listener) error {
+	for {
+		for {
+			var msg Message
+			err := l.QueryRow(ctx, `SELECT id, topic, payload FROM "EventLog" WHERE id > $1 ORDER BY id LIMIT 1`,
+				p.lastId).Scan(&msg.Id, &msg.Topic, &msg.Payload)
+			if err == pgx.ErrNoRows {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			p.lastId = msg.Id
+			p.deliver(msg)
+		}
+		_, err := l.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEventLog stands in for the "EventLog" table and its notifications.
+type fakeEventLog struct {
+	mu       sync.Mutex
+	messages []Message
+	notify   chan struct{}
+}
+
+func newFakeEventLog() *fakeEventLog {
+	return &fakeEventLog{notify: make(chan struct{}, 1)}
+}
+
+func (f *fakeEventLog) publish(topic, payload string) Message {
+	f.mu.Lock()
+	msg := Message{Id: int64(len(f.messages) + 1), Topic: topic, Payload: []byte(payload)}
+	f.messages = append(f.messages, msg)
+	f.mu.Unlock()
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+	return msg
+}
+
+type fakeListener struct {
+	log    *fakeEventLog
+	broken chan struct{}
+}
+
+func (l *fakeListener) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return nil, nil
+}
+
+func (l *fakeListener) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	select {
+	case <-l.broken:
+		return fakeRow{err: errors.New("connection lost")}
+	default:
+	}
+	l.log.mu.Lock()
+	defer l.log.mu.Unlock()
+	if strings.Contains(sql, "max(id)") {
+		return fakeRow{values: []interface{}{int64(len(l.log.messages))}}
+	}
+	for _, msg := range l.log.messages {
+		if msg.Id > args[0].(int64) {
+			return fakeRow{values: []interface{}{msg.Id, msg.Topic, msg.Payload}}
+		}
+	}
+	return fakeRow{err: pgx.ErrNoRows}
+}
+
+func (l *fakeListener) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.broken:
+		return nil, errors.New("connection lost")
+	case <-l.log.notify:
+		return &pgconn.Notification{Channel: Channel}, nil
+	}
+}
+
+func (l *fakeListener) Close(ctx context.Context) error {
+	return nil
+}
+
+type fakeRow struct {
+	values []interface{}
+	err    error
+}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	for i, d := range dest {
+		switch d := d.(type) {
+		case *int64:
+			*d = r.values[i].(int64)
+		case *string:
+			*d = r.values[i].(string)
+		case *[]byte:
+			*d = r.values[i].([]byte)
+		}
+	}
+	return nil
+}
+
+func next(t *testing.T, messages <-chan Message) Message {
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message delivered")
+		return Message{}
+	}
+}
+
+func TestPostgresBroker_Reconnects(t *testing.T) {
+	eventLog := newFakeEventLog()
+	eventLog.publish("orders", "before")
+
+	var mu sync.Mutex
+	attempts := 0
+	listeners := []*fakeListener{}
+	connect := func(ctx context.Context) (listener, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 2 {
+			return nil, errors.New("database is restarting")
+		}
+		l := &fakeListener{log: eventLog, broken: make(chan struct{})}
+		listeners = append(listeners, l)
+		return l, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b, err := newPostgresBroker(ctx, logrus.New(), nil, connect, time.Millisecond)
+	assert.Nil(t, err)
+	messages, _ := b.Subscribe(context.Background(), "orders")
+
+	// Only messages published after the broker started are delivered.
+	first := eventLog.publish("orders", "accepted")
+	assert.Equal(t, first, next(t, messages))
+
+	// Whatever is published while the connection is down arrives once it is back, in order.
+	mu.Lock()
+	close(listeners[0].broken)
+	mu.Unlock()
+	missed := eventLog.publish("orders", "preparing")
+	eventLog.publish("other", "ignored")
+	assert.Equal(t, missed, next(t, messages))
+	live := eventLog.publish("orders", "ready")
+	assert.Equal(t, live, next(t, messages))
+
+	mu.Lock()
+	assert.Equal(t, 3, attempts)
+	mu.Unlock()
+
+	cancel()
+	select {
+	case _, open := <-messages:
+		assert.False(t, open)
+	case <-time.After(time.Second):
+		t.Fatal("subscription not closed")
+	}
+}
+
+func TestPostgresBroker_FailsToStart(t *testing.T) {
+	connect := func(ctx context.Context) (listener, error) {
+		return nil, errors.New("connection refused")
+	}
+	_, err := newPostgresBroker(context.Background(), logrus.New(), nil, connect, time.Millisecond)
+	assert.Equal(t, errors.New("connection refused"), err)
+}
@@ -0,0 +1,34 @@
+package qrcode
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// QuietZone is the light border, in modules, scanners need around a code.
+const QuietZone = 4
+
+// Image draws the code with its quiet zone, each module scale pixels square.
+func (c *Code) Image(scale int) *image.Paletted {
+	if scale < 1 {
+		scale = 1
+	}
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for py := 0; py < side; py++ {
+		y := py/scale - QuietZone
+		for px := 0; px < side; px++ {
+			if c.Dark(px/scale-QuietZone, y) {
+				img.Pix[py*img.Stride+px] = 1
+			}
+		}
+	}
+	return img
+}
+
+// PNG writes the code as a two-colour PNG.
+func (c *Code) PNG(w io.Writer, scale int) error {
+	return png.Encode(w, c.Image(scale))
+}
@@ -0,0 +1,469 @@
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTooLong is returned for text that does not fit the largest supported version.
+var ErrTooLong = errors.New("text too long for a QR code")
+
+// block describes how a version's codewords are split at error correction level M: Short
+// blocks of ShortData data codewords followed by Long blocks with one more, each carrying
+// ECC error correction codewords.
+type block struct {
+	ECC       int
+	Short     int
+	ShortData int
+	Long      int
+}
+
+// versions lists versions 1 to 10 at level M, which holds up to 213 bytes and recovers from
+// about 15% damage. Table links and wifi-style payloads are far shorter than that.
+var versions = []block{
+	{ECC: 10, Short: 1, ShortData: 16},
+	{ECC: 16, Short: 1, ShortData: 28},
+	{ECC: 26, Short: 1, ShortData: 44},
+	{ECC: 18, Short: 2, ShortData: 32},
+	{ECC: 24, Short: 2, ShortData: 43},
+	{ECC: 16, Short: 4, ShortData: 27},
+	{ECC: 18, Short: 4, ShortData: 31},
+	{ECC: 22, Short: 2, ShortData: 38, Long: 2},
+	{ECC: 22, Short: 3, ShortData: 36, Long: 2},
+	{ECC: 26, Short: 4, ShortData: 43, Long: 1},
+}
+
+// alignments are the row and column centres of the alignment patterns of each version.
+var alignments = [][]int{
+	nil,
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+	{6, 28, 50},
+}
+
+const (
+	// levelM is the two format bits of error correction level M.
+	levelM = 0
+	// byteMode is the mode indicator for 8-bit data.
+	byteMode = 4
+
+	penaltyRun     = 3
+	penaltyBlock   = 3
+	penaltyFinder  = 40
+	penaltyBalance = 10
+)
+
+// Code is an encoded QR code: a square of Size by Size modules, without the quiet zone.
+type Code struct {
+	Version  int
+	Size     int
+	Mask     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Encode makes the smallest QR code holding text in byte mode at error correction level M,
+// with whichever of the eight masks scores best.
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+	version := 0
+	for v := 1; v <= len(versions); v++ {
+		if 4+countBits(v)+8*len(data) <= 8*versions[v-1].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d", ErrTooLong, len(data), Capacity())
+	}
+
+	code := newCode(version)
+	code.drawData(interleave(version, encodeData(version, data)))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		code.applyMask(mask)
+		code.drawFormat(mask)
+		penalty := code.penalty()
+		if bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		// Masking twice undoes it.
+		code.applyMask(mask)
+	}
+	code.applyMask(best)
+	code.drawFormat(best)
+	code.Mask = best
+	return code, nil
+}
+
+// Capacity is the longest text, in bytes, that Encode accepts.
+func Capacity() int {
+	v := len(versions)
+	return (8*versions[v-1].dataCodewords() - 4 - countBits(v)) / 8
+}
+
+// Dark reports whether the module at column x and row y is dark. Modules outside the code
+// are light, as the quiet zone around it must be.
+func (c *Code) Dark(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.Size && y < c.Size && c.modules[y][x]
+}
+
+func (b block) dataCodewords() int {
+	return b.Short*b.ShortData + b.Long*(b.ShortData+1)
+}
+
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// encodeData builds the data codewords: mode, length, the bytes, a terminator and padding.
+func encodeData(version int, data []byte) []byte {
+	capacity := versions[version-1].dataCodewords()
+	var bits bitBuffer
+	bits.append(byteMode, 4)
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	terminator := 8*capacity - bits.len()
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-bits.len()%8)%8)
+
+	codewords := bits.bytes()
+	for pad := byte(0xec); len(codewords) < capacity; pad ^= 0xec ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+	return codewords
+}
+
+// interleave splits the data codewords into blocks, adds each block's error correction and
+// returns the codewords in the order they are placed: the first codeword of every block,
+// then the second, and so on, then the error correction the same way.
+func interleave(version int, data []byte) []byte {
+	b := versions[version-1]
+	var blocks [][]byte
+	for i := 0; i < b.Short+b.Long; i++ {
+		size := b.ShortData
+		if i >= b.Short {
+			size++
+		}
+		blocks = append(blocks, data[:size])
+		data = data[size:]
+	}
+
+	var result []byte
+	for i := 0; i <= b.ShortData; i++ {
+		for _, blk := range blocks {
+			if i < len(blk) {
+				result = append(result, blk[i])
+			}
+		}
+	}
+	ecc := make([][]byte, len(blocks))
+	for i, blk := range blocks {
+		ecc[i] = errorCorrection(blk, b.ECC)
+	}
+	for i := 0; i < b.ECC; i++ {
+		for _, e := range ecc {
+			result = append(result, e[i])
+		}
+	}
+	return result
+}
+
+func newCode(version int) *Code {
+	size := 17 + 4*version
+	c := &Code{Version: version, Size: size, modules: grid(size), function: grid(size)}
+
+	for i := 0; i < size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+	c.drawFinder(3, 3)
+	c.drawFinder(size-4, 3)
+	c.drawFinder(3, size-4)
+
+	centres := alignments[version-1]
+	last := len(centres) - 1
+	for i, y := range centres {
+		for j, x := range centres {
+			// These three would sit on the finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignment(x, y)
+		}
+	}
+
+	// Reserve the format areas; the real bits are drawn once the mask is chosen.
+	c.drawFormat(0)
+	c.drawVersion()
+	return c
+}
+
+func grid(size int) [][]bool {
+	g := make([][]bool, size)
+	for i := range g {
+		g[i] = make([]bool, size)
+	}
+	return g
+}
+
+// set draws a function module, which holds no data and is never masked.
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+// drawFinder draws a finder pattern centred on x, y with its light separator.
+func (c *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= c.Size || yy >= c.Size {
+				continue
+			}
+			distance := maxAbs(dx, dy)
+			c.set(xx, yy, distance != 2 && distance != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.set(x+dx, y+dy, maxAbs(dx, dy) != 1)
+		}
+	}
+}
+
+// drawFormat draws both copies of the level and mask bits, and the dark module.
+func (c *Code) drawFormat(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return bits>>uint(i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.set(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.Size-15+i, bit(i))
+	}
+	c.set(8, c.Size-8, true)
+}
+
+// formatBits returns the 15 format bits: level and mask protected by a BCH code, then masked
+// so they are never all light.
+func formatBits(mask int) int {
+	data := levelM<<3 | mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = remainder<<1 ^ (remainder>>9)*0x537
+	}
+	return (data<<10 | remainder) ^ 0x5412
+}
+
+// drawVersion draws both copies of the version bits, which only versions 7 and up have.
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	bits := versionBits(c.Version)
+	for i := 0; i < 18; i++ {
+		dark := bits>>uint(i)&1 == 1
+		a, b := c.Size-11+i%3, i/3
+		c.set(a, b, dark)
+		c.set(b, a, dark)
+	}
+}
+
+func versionBits(version int) int {
+	remainder := version
+	for i := 0; i < 12; i++ {
+		remainder = remainder<<1 ^ (remainder>>11)*0x1f25
+	}
+	return version<<12 | remainder
+}
+
+// drawData places the codewords, most significant bit first, in two-module wide columns that
+// zigzag up and down from the bottom right corner, skipping function modules and the
+// vertical timing pattern. Modules left over stay light.
+func (c *Code) drawData(codewords []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vertical := 0; vertical < c.Size; vertical++ {
+			y := vertical
+			if upward {
+				y = c.Size - 1 - vertical
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				c.modules[y][x] = codewords[i/8]>>uint(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules the mask pattern selects.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.function[y][x] && masked(mask, x, y) {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// penalty scores how hard the code is to scan under the four rules of the standard: long
+// runs of one colour, 2x2 blocks, patterns that look like finders, and an uneven balance of
+// dark and light. Lower is better.
+func (c *Code) penalty() int {
+	total, dark := 0, 0
+	for i := 0; i < c.Size; i++ {
+		row := func(j int) bool { return c.modules[i][j] }
+		column := func(j int) bool { return c.modules[j][i] }
+		total += c.linePenalty(row) + c.linePenalty(column)
+	}
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				m := c.modules[y][x]
+				if c.modules[y][x+1] == m && c.modules[y+1][x] == m && c.modules[y+1][x+1] == m {
+					total += penaltyBlock
+				}
+			}
+		}
+	}
+	modules := c.Size * c.Size
+	k := (abs(dark*20-modules*10)+modules-1)/modules - 1
+	return total + k*penaltyBalance
+}
+
+// finderLike is dark-light-dark-dark-dark-light-dark with four light modules on one side.
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+func (c *Code) linePenalty(at func(int) bool) int {
+	penalty, run := 0, 1
+	for j := 1; j <= c.Size; j++ {
+		if j < c.Size && at(j) == at(j-1) {
+			run++
+			continue
+		}
+		if run >= 5 {
+			penalty += penaltyRun + run - 5
+		}
+		run = 1
+	}
+	for j := 0; j+11 <= c.Size; j++ {
+		for _, pattern := range finderLike {
+			matches := true
+			for k, dark := range pattern {
+				if at(j+k) != dark {
+					matches = false
+					break
+				}
+			}
+			if matches {
+				penalty += penaltyFinder
+			}
+		}
+	}
+	return penalty
+}
+
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		b.bits = append(b.bits, value>>uint(i)&1 == 1)
+	}
+}
+
+func (b *bitBuffer) len() int {
+	return len(b.bits)
+}
+
+func (b *bitBuffer) bytes() []byte {
+	out := make([]byte, (len(b.bits)+7)/8)
+	for i, bit := range b.bits {
+		if bit {
+			out[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+	return out
+}
+
+func maxAbs(a, b int) int {
+	a, b = abs(a), abs(b)
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -0,0 +1,171 @@
+package qrcode
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestErrorCorrection(t *testing.T) {
+	// The worked example of the standard: "01234567" as version 1-M.
+	data := []byte{0x10, 0x20, 0x0c, 0x56, 0x61, 0x80, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11}
+	assert.Equal(t, []byte{0xa5, 0x24, 0xd4, 0xc1, 0xed, 0x36, 0xc7, 0x87, 0x2c, 0x55}, errorCorrection(data, 10))
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	assert.Equal(t, 0b101010000010010, formatBits(0))
+	assert.Equal(t, 0b100000011001110, formatBits(5))
+	assert.Equal(t, 0b100101010100000, formatBits(7))
+	assert.Equal(t, 0x07c94, versionBits(7))
+	assert.Equal(t, 0x0a4d3, versionBits(10))
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		version int
+	}{
+		{name: "short", text: "hello", version: 1},
+		{name: "table link", text: "https://rsm.example/t/q3Jx0c8yRkWb2mVd7nAz1w", version: 4},
+		{name: "version information", text: strings.Repeat("a", 120), version: 7},
+		{name: "mixed block sizes", text: strings.Repeat("b", 150), version: 8},
+		{name: "largest", text: strings.Repeat("c", Capacity()), version: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Encode(tt.text)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.version, code.Version)
+			assert.Equal(t, 17+4*tt.version, code.Size)
+			assert.Equal(t, tt.text, readBack(t, code))
+
+			// Finder centres, the dark module and the timing pattern.
+			assert.True(t, code.Dark(3, 3) && code.Dark(code.Size-4, 3) && code.Dark(3, code.Size-4))
+			assert.True(t, code.Dark(8, code.Size-8))
+			assert.False(t, code.Dark(9, 6))
+			assert.Equal(t, formatBits(code.Mask), readFormat(code))
+		})
+	}
+}
+
+func TestEncode_TooLong(t *testing.T) {
+	_, err := Encode(strings.Repeat("x", Capacity()+1))
+	assert.ErrorIs(t, err, ErrTooLong)
+}
+
+func TestCode_PNG(t *testing.T) {
+	code, err := Encode("hello")
+	assert.Nil(t, err)
+	var buf bytes.Buffer
+	assert.Nil(t, code.PNG(&buf, 4))
+
+	img, err := png.Decode(&buf)
+	assert.Nil(t, err)
+	side := (code.Size + 2*QuietZone) * 4
+	assert.Equal(t, side, img.Bounds().Dx())
+	r, _, _, _ := img.At(QuietZone*4, QuietZone*4).RGBA()
+	assert.Equal(t, uint32(0), r)
+	r, _, _, _ = img.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0xffff), r)
+}
+
+// readFormat reads the copy of the format bits around the top left finder.
+func readFormat(code *Code) int {
+	bits := 0
+	at := func(i int, x, y int) {
+		if code.Dark(x, y) {
+			bits |= 1 << uint(i)
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		at(i, 8, i)
+	}
+	at(6, 8, 7)
+	at(7, 8, 8)
+	at(8, 7, 8)
+	for i := 9; i < 15; i++ {
+		at(i, 14-i, 8)
+	}
+	return bits
+}
+
+// readBack decodes the code the way a scanner would once it has found the modules: unmask,
+// read the zigzag, check each block's error correction and parse the byte mode segment.
+func readBack(t *testing.T, code *Code) string {
+	var bits bitBuffer
+	for right := code.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vertical := 0; vertical < code.Size; vertical++ {
+			y := vertical
+			if upward {
+				y = code.Size - 1 - vertical
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if code.function[y][x] {
+					continue
+				}
+				dark := code.Dark(x, y) != masked(code.Mask, x, y)
+				value := 0
+				if dark {
+					value = 1
+				}
+				bits.append(value, 1)
+			}
+		}
+	}
+	codewords := bits.bytes()
+
+	b := versions[code.Version-1]
+	count := b.Short + b.Long
+	blocks := make([][]byte, count)
+	i := 0
+	for k := 0; k <= b.ShortData; k++ {
+		for n := range blocks {
+			if k < b.ShortData || n >= b.Short {
+				blocks[n] = append(blocks[n], codewords[i])
+				i++
+			}
+		}
+	}
+	var data []byte
+	for k := 0; k < b.ECC; k++ {
+		for n := range blocks {
+			blocks[n] = append(blocks[n], codewords[i])
+			i++
+		}
+	}
+	for _, blk := range blocks {
+		size := len(blk) - b.ECC
+		assert.Equal(t, blk[size:], errorCorrection(blk[:size], b.ECC))
+		data = append(data, blk[:size]...)
+	}
+
+	assert.Equal(t, byte(byteMode<<4), data[0]&0xf0)
+	var stream bitBuffer
+	for _, d := range data {
+		stream.append(int(d), 8)
+	}
+	read := func(from, length int) int {
+		v := 0
+		for _, bit := range stream.bits[from : from+length] {
+			v <<= 1
+			if bit {
+				v |= 1
+			}
+		}
+		return v
+	}
+	length := read(4, countBits(code.Version))
+	text := make([]byte, length)
+	for n := range text {
+		text[n] = byte(read(4+countBits(code.Version)+8*n, 8))
+	}
+	return string(text)
+}
@@ -0,0 +1,66 @@
+package qrcode
+
+// QR codes use Reed-Solomon codes over GF(256) with the polynomial x^8+x^4+x^3+x^2+1.
+const primitive = 0x11d
+
+var expTable, logTable = galoisTables()
+
+func galoisTables() ([512]byte, [256]byte) {
+	var exp [512]byte
+	var log [256]byte
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= primitive
+		}
+	}
+	// Doubling the table lets multiply skip the modulo.
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}
+
+func multiply(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+// generator returns the coefficients of (x - a^0)(x - a^1)...(x - a^(degree-1)), highest
+// power first with the leading 1 left out.
+func generator(degree int) []byte {
+	g := make([]byte, degree)
+	g[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := 0; j < degree; j++ {
+			g[j] = multiply(g[j], root)
+			if j+1 < degree {
+				g[j] ^= g[j+1]
+			}
+		}
+		root = multiply(root, 2)
+	}
+	return g
+}
+
+// errorCorrection returns the degree error correction codewords for data: the remainder of
+// data shifted up by degree, divided by the generator.
+func errorCorrection(data []byte, degree int) []byte {
+	g := generator(degree)
+	remainder := make([]byte, degree)
+	for _, b := range data {
+		factor := b ^ remainder[0]
+		copy(remainder, remainder[1:])
+		remainder[degree-1] = 0
+		for i := range remainder {
+			remainder[i] ^= multiply(g[i], factor)
+		}
+	}
+	return remainder
+}
@@ -0,0 +1,205 @@
+package recommend
+
+import (
+	"github.com/google/uuid"
+	"math"
+	"rsm/entity/menuModel"
+	"rsm/entity/recommendationModel"
+	"sort"
+)
+
+const (
+	// CoWeight and TasteWeight split a score between how often the item is ordered with the
+	// user's items and how well it matches their taste.
+	CoWeight    = 0.7
+	TasteWeight = 0.3
+)
+
+// history is what the batch job knows about one user.
+type history struct {
+	// orders counts the user's orders containing each item.
+	orders      map[int64]int
+	restaurants map[uuid.UUID]bool
+	taste       map[string]float64
+}
+
+// Build ranks up to n items for every user with purchases, and the n most popular items of
+// every restaurant.
+//
+// Two items are similar by how often they are ordered together, the cosine of the sets of
+// orders containing them. An item's co-occurrence score for a user is its similarity to the
+// items they ordered, weighted by how many orders they were in. The user's taste is how often
+// each item type and dietary tag appears in their orders, and an item's taste score is the
+// cosine between that and the item's own type and tags. Only items the user has not ordered
+// yet, from restaurants they ordered from or ordered with their items, are ranked. Guest
+// orders, with no user, count towards similarity and popularity only.
+func Build(purchases []recommendationModel.Purchase, items []recommendationModel.Item, n int) recommendationModel.Batch {
+	catalogue := make(map[int64]recommendationModel.Item, len(items))
+	menus := map[uuid.UUID][]int64{}
+	for _, item := range items {
+		catalogue[item.MenuId] = item
+		menus[item.RestaurantId] = append(menus[item.RestaurantId], item.MenuId)
+	}
+
+	baskets := map[uuid.UUID]map[int64]bool{}
+	users := map[uuid.UUID]*history{}
+	for _, p := range purchases {
+		if baskets[p.OrderId] == nil {
+			baskets[p.OrderId] = map[int64]bool{}
+		}
+		if baskets[p.OrderId][p.MenuId] {
+			continue
+		}
+		baskets[p.OrderId][p.MenuId] = true
+		if p.UserId == uuid.Nil {
+			continue
+		}
+		u := users[p.UserId]
+		if u == nil {
+			u = &history{orders: map[int64]int{}, restaurants: map[uuid.UUID]bool{}, taste: map[string]float64{}}
+			users[p.UserId] = u
+		}
+		u.restaurants[p.RestaurantId] = true
+		u.orders[p.MenuId]++
+		for _, f := range features(p.ItemType, p.Dietary) {
+			u.taste[f]++
+		}
+	}
+
+	orders := map[int64]int{}
+	together := map[int64]map[int64]int{}
+	for _, basket := range baskets {
+		for i := range basket {
+			orders[i]++
+			for j := range basket {
+				if i == j {
+					continue
+				}
+				if together[i] == nil {
+					together[i] = map[int64]int{}
+				}
+				together[i][j]++
+			}
+		}
+	}
+
+	batch := recommendationModel.Batch{Users: make(map[uuid.UUID][]recommendationModel.Score, len(users)),
+		Popular: popular(orders, catalogue, n)}
+	for userId, u := range users {
+		scores := rank(u, orders, together, catalogue, menus, n)
+		if len(scores) > 0 {
+			batch.Users[userId] = scores
+		}
+	}
+	return batch
+}
+
+func rank(u *history, orders map[int64]int, together map[int64]map[int64]int, catalogue map[int64]recommendationModel.Item,
+	menus map[uuid.UUID][]int64, n int) []recommendationModel.Score {
+	var ordered int
+	co := map[int64]float64{}
+	for _, i := range sortedIds(u.orders) {
+		ordered += u.orders[i]
+		for _, j := range sortedIds(together[i]) {
+			similarity := float64(together[i][j]) / math.Sqrt(float64(orders[i]*orders[j]))
+			co[j] += float64(u.orders[i]) * similarity
+		}
+	}
+
+	candidates := map[int64]bool{}
+	for j := range co {
+		candidates[j] = true
+	}
+	for restaurantId := range u.restaurants {
+		for _, j := range menus[restaurantId] {
+			candidates[j] = true
+		}
+	}
+
+	var scores []recommendationModel.Score
+	for j := range candidates {
+		item, ok := catalogue[j]
+		if !ok || u.orders[j] > 0 {
+			continue
+		}
+		byOrders := CoWeight * co[j] / float64(ordered)
+		byTaste := TasteWeight * cosine(u.taste, features(item.ItemType, item.Dietary))
+		if byOrders+byTaste <= 0 {
+			continue
+		}
+		reason := recommendationModel.OrderedTogether
+		if byTaste > byOrders {
+			reason = recommendationModel.MatchesTaste
+		}
+		scores = append(scores, recommendationModel.Score{MenuId: j, Score: byOrders + byTaste, Reason: reason})
+	}
+	return top(scores, n)
+}
+
+// popular scores each restaurant's items by how many orders they were in, relative to the
+// restaurant's most ordered item.
+func popular(orders map[int64]int, catalogue map[int64]recommendationModel.Item, n int) map[uuid.UUID][]recommendationModel.Score {
+	most := map[uuid.UUID]int{}
+	for i, count := range orders {
+		if item, ok := catalogue[i]; ok && count > most[item.RestaurantId] {
+			most[item.RestaurantId] = count
+		}
+	}
+	scores := map[uuid.UUID][]recommendationModel.Score{}
+	for i, count := range orders {
+		if item, ok := catalogue[i]; ok {
+			scores[item.RestaurantId] = append(scores[item.RestaurantId], recommendationModel.Score{MenuId: i,
+				Score: float64(count) / float64(most[item.RestaurantId]), Reason: recommendationModel.Popular})
+		}
+	}
+	for restaurantId := range scores {
+		scores[restaurantId] = top(scores[restaurantId], n)
+	}
+	return scores
+}
+
+// top sorts by score, highest first and then by menu id, and keeps the first n.
+func top(scores []recommendationModel.Score, n int) []recommendationModel.Score {
+	sort.Slice(scores, func(a, b int) bool {
+		if scores[a].Score != scores[b].Score {
+			return scores[a].Score > scores[b].Score
+		}
+		return scores[a].MenuId < scores[b].MenuId
+	})
+	if len(scores) > n {
+		scores = scores[:n]
+	}
+	return scores
+}
+
+func features(itemType string, dietary []menuModel.DietaryTag) []string {
+	f := []string{"type:" + itemType}
+	for _, tag := range dietary {
+		f = append(f, "diet:"+string(tag))
+	}
+	return f
+}
+
+// cosine compares a taste with an item that has each of its features once.
+func cosine(taste map[string]float64, item []string) float64 {
+	var dot, norm float64
+	for _, w := range taste {
+		norm += w * w
+	}
+	for _, f := range item {
+		dot += taste[f]
+	}
+	if dot == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(norm) * math.Sqrt(float64(len(item))))
+}
+
+func sortedIds(m map[int64]int) []int64 {
+	ids := make([]int64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+	return ids
+}
@@ -0,0 +1,100 @@
+package recommend
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"rsm/entity/menuModel"
+	"rsm/entity/recommendationModel"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	mamas, greens := uuid.New(), uuid.New()
+	ada, bayo, chi := uuid.New(), uuid.New(), uuid.New()
+	vegan := []menuModel.DietaryTag{menuModel.Vegan}
+	const (
+		jollof int64 = iota + 1
+		plantain
+		zobo
+		suya
+		salad
+		tofu
+	)
+	items := []recommendationModel.Item{
+		{MenuId: jollof, RestaurantId: mamas, ItemType: "main"},
+		{MenuId: plantain, RestaurantId: mamas, ItemType: "side", Dietary: vegan},
+		{MenuId: zobo, RestaurantId: mamas, ItemType: "drink", Dietary: vegan},
+		{MenuId: suya, RestaurantId: mamas, ItemType: "main"},
+		{MenuId: salad, RestaurantId: greens, ItemType: "main", Dietary: vegan},
+		{MenuId: tofu, RestaurantId: greens, ItemType: "main", Dietary: vegan},
+	}
+	order := func(userId, restaurantId uuid.UUID, menuIds ...int64) []recommendationModel.Purchase {
+		orderId := uuid.New()
+		var lines []recommendationModel.Purchase
+		for _, id := range menuIds {
+			line := recommendationModel.Purchase{UserId: userId, OrderId: orderId, RestaurantId: restaurantId,
+				MenuId: id}
+			for _, item := range items {
+				if item.MenuId == id {
+					line.ItemType, line.Dietary = item.ItemType, item.Dietary
+				}
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+	var purchases []recommendationModel.Purchase
+	purchases = append(purchases, order(bayo, mamas, jollof, plantain)...)
+	purchases = append(purchases, order(bayo, mamas, jollof, plantain, zobo)...)
+	purchases = append(purchases, order(chi, mamas, jollof, plantain)...)
+	purchases = append(purchases, order(chi, greens, salad)...)
+	purchases = append(purchases, order(ada, mamas, jollof, jollof)...)
+	purchases = append(purchases, order(uuid.Nil, greens, tofu)...)
+
+	batch := Build(purchases, items, 3)
+
+	// Each restaurant's items are ranked against its own most ordered item.
+	assert.Equal(t, []int64{jollof, plantain, zobo}, ids(batch.Popular[mamas]))
+	assert.Equal(t, 1.0, batch.Popular[mamas][0].Score)
+	assert.Equal(t, recommendationModel.Popular, batch.Popular[mamas][0].Reason)
+	assert.Equal(t, []int64{salad, tofu}, ids(batch.Popular[greens]))
+	assert.Equal(t, 1.0, batch.Popular[greens][1].Score)
+
+	// Ada only ever had jollof, which most people have with plantain.
+	forAda := batch.Users[ada]
+	assert.Equal(t, []int64{plantain, zobo, suya}, ids(forAda))
+	assert.Equal(t, recommendationModel.OrderedTogether, forAda[0].Reason)
+	assert.Equal(t, recommendationModel.MatchesTaste, forAda[2].Reason)
+
+	// Chi eats vegan at Greens too, and nothing ordered is offered again.
+	forChi := batch.Users[chi]
+	assert.Equal(t, zobo, forChi[0].MenuId)
+	assert.Contains(t, ids(forChi), tofu)
+	assert.NotContains(t, ids(forChi), salad)
+	for _, s := range forChi {
+		assert.True(t, s.Score > 0 && s.Score <= 1)
+	}
+
+	assert.NotContains(t, batch.Users, uuid.Nil)
+	assert.Equal(t, batch, Build(purchases, items, 3))
+}
+
+func TestBuild_OnlyCatalogueItems(t *testing.T) {
+	restaurantId, userId := uuid.New(), uuid.New()
+	orderId := uuid.New()
+	purchases := []recommendationModel.Purchase{
+		{UserId: userId, OrderId: orderId, RestaurantId: restaurantId, MenuId: 1, ItemType: "main"},
+		{UserId: userId, OrderId: orderId, RestaurantId: restaurantId, MenuId: 2, ItemType: "main"},
+	}
+	batch := Build(purchases, []recommendationModel.Item{{MenuId: 1, RestaurantId: restaurantId, ItemType: "main"}}, 5)
+	assert.Equal(t, []int64{1}, ids(batch.Popular[restaurantId]))
+	assert.Empty(t, batch.Users)
+}
+
+func ids(scores []recommendationModel.Score) []int64 {
+	var result []int64
+	for _, s := range scores {
+		result = append(result, s.MenuId)
+	}
+	return result
+}
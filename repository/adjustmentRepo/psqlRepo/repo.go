@@ -0,0 +1,223 @@
+package psqlRepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/adjustmentModel"
+	"rsm/repository/adjustmentRepo"
+)
+
+const adjustmentColumns = `id, order_id, reason, note, full_refund, amount, currency, status, requested_by,
+	decided_by, created_at, decided_at, applied_at`
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+// Persist saves a new adjustment. The order row is locked while the adjustments that still
+// count are summed, so concurrent requests cannot together refund more than the order total.
+func (p *psql) Persist(adjustment *adjustmentModel.Adjustment) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		var total, adjusted int64
+		err := tx.QueryRow(context.Background(), `SELECT total FROM "Orders" WHERE id = $1 FOR UPDATE`,
+			adjustment.OrderId).Scan(&total)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRow(context.Background(), `SELECT coalesce(sum(amount), 0) FROM "Adjustments"
+			WHERE order_id = $1 AND status <> $2`, adjustment.OrderId, adjustmentModel.Rejected).Scan(&adjusted)
+		if err != nil {
+			return err
+		}
+		if adjusted+adjustment.Amount > total {
+			return adjustmentRepo.ErrExceedsTotal
+		}
+		_, err = tx.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "Adjustments" (%s)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`, adjustmentColumns),
+			adjustment.Id, adjustment.OrderId, adjustment.Reason, adjustment.Note, adjustment.Full, adjustment.Amount,
+			adjustment.Currency, adjustment.Status, adjustment.RequestedBy, adjustment.DecidedBy, adjustment.CreatedAt,
+			adjustment.DecidedAt, adjustment.AppliedAt)
+		if err != nil {
+			return err
+		}
+		for _, l := range adjustment.Lines {
+			_, err = tx.Exec(context.Background(), `INSERT INTO "AdjustmentLines" (adjustment_id, order_item_id,
+				menu_id, quantity, amount, restock) VALUES ($1, $2, $3, $4, $5, $6)`, adjustment.Id, l.OrderItemId,
+				l.MenuId, l.Quantity, l.Amount, l.Restock)
+			if err != nil {
+				return err
+			}
+		}
+		return insertRefunds(tx, adjustment)
+	})
+	if err != nil {
+		p.log.Errorf("Error Persisting Adjustment: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindById(id uuid.UUID) (*adjustmentModel.Adjustment, error) {
+	adjustments, err := p.find(`id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(adjustments) == 0 {
+		return nil, adjustmentRepo.ErrNotFound
+	}
+	return &adjustments[0], nil
+}
+
+func (p *psql) FindByOrder(orderId uuid.UUID) ([]adjustmentModel.Adjustment, error) {
+	return p.find(`order_id = $1`, orderId)
+}
+
+// Decide records the approval or rejection of a pending adjustment, along with the refund
+// split when it was approved.
+func (p *psql) Decide(adjustment *adjustmentModel.Adjustment) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(), `UPDATE "Adjustments" SET status = $3, decided_by = $4,
+			decided_at = $5 WHERE id = $1 AND status = $2`, adjustment.Id, adjustmentModel.PendingApproval,
+			adjustment.Status, adjustment.DecidedBy, adjustment.DecidedAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return adjustmentRepo.ErrStaleStatus
+		}
+		return insertRefunds(tx, adjustment)
+	})
+	if err != nil {
+		p.log.Errorf("Error Deciding Adjustment: %v", err)
+	}
+	return err
+}
+
+// MarkApplied closes an approved adjustment once its refunds went through and applies its
+// side effects on the menu: restocked lines go back into counted stock, and unavailable
+// items are taken off the menu so nobody else can order them.
+func (p *psql) MarkApplied(adjustment *adjustmentModel.Adjustment) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(), `UPDATE "Adjustments" SET status = $3, applied_at = $4
+			WHERE id = $1 AND status = $2`, adjustment.Id, adjustmentModel.Approved, adjustmentModel.Applied,
+			adjustment.AppliedAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return adjustmentRepo.ErrStaleStatus
+		}
+		for _, l := range adjustment.Lines {
+			if l.Restock {
+				_, err = tx.Exec(context.Background(), `UPDATE "Menu" SET stock = stock + $2
+					WHERE id = $1 AND stock IS NOT NULL`, l.MenuId, l.Quantity)
+				if err != nil {
+					return err
+				}
+			}
+			if adjustment.Reason == adjustmentModel.ItemUnavailable {
+				_, err = tx.Exec(context.Background(), `UPDATE "Menu" SET available = false WHERE id = $1`, l.MenuId)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		p.log.Errorf("Error Applying Adjustment: %v", err)
+	}
+	return err
+}
+
+func insertRefunds(tx pgx.Tx, adjustment *adjustmentModel.Adjustment) error {
+	for _, r := range adjustment.Refunds {
+		_, err := tx.Exec(context.Background(), `INSERT INTO "AdjustmentRefunds" (adjustment_id, payment_id, amount)
+			VALUES ($1, $2, $3)`, adjustment.Id, r.PaymentId, r.Amount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *psql) find(condition string, args ...interface{}) ([]adjustmentModel.Adjustment, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "Adjustments" WHERE %s
+		ORDER BY created_at`, adjustmentColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Adjustments: %v", err)
+		return nil, err
+	}
+	var adjustments []adjustmentModel.Adjustment
+	index := map[uuid.UUID]int{}
+	for rows.Next() {
+		var a adjustmentModel.Adjustment
+		err = rows.Scan(&a.Id, &a.OrderId, &a.Reason, &a.Note, &a.Full, &a.Amount, &a.Currency, &a.Status,
+			&a.RequestedBy, &a.DecidedBy, &a.CreatedAt, &a.DecidedAt, &a.AppliedAt)
+		if err != nil {
+			rows.Close()
+			p.log.Errorf("Error Scanning Adjustment: %v", err)
+			return nil, err
+		}
+		a.Lines, a.Refunds = []adjustmentModel.Line{}, []adjustmentModel.Refund{}
+		index[a.Id] = len(adjustments)
+		adjustments = append(adjustments, a)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil || len(adjustments) == 0 {
+		return adjustments, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(adjustments))
+	for _, a := range adjustments {
+		ids = append(ids, a.Id)
+	}
+	rows, err = p.conn.Query(context.Background(), `SELECT adjustment_id, order_item_id, menu_id, quantity, amount,
+		restock FROM "AdjustmentLines" WHERE adjustment_id = ANY($1) ORDER BY order_item_id`, ids)
+	if err != nil {
+		p.log.Errorf("Error Finding Adjustment Lines: %v", err)
+		return nil, err
+	}
+	for rows.Next() {
+		var id uuid.UUID
+		var l adjustmentModel.Line
+		err = rows.Scan(&id, &l.OrderItemId, &l.MenuId, &l.Quantity, &l.Amount, &l.Restock)
+		if err != nil {
+			rows.Close()
+			p.log.Errorf("Error Scanning Adjustment Line: %v", err)
+			return nil, err
+		}
+		a := &adjustments[index[id]]
+		a.Lines = append(a.Lines, l)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = p.conn.Query(context.Background(), `SELECT adjustment_id, payment_id, amount FROM "AdjustmentRefunds"
+		WHERE adjustment_id = ANY($1)`, ids)
+	if err != nil {
+		p.log.Errorf("Error Finding Adjustment Refunds: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uuid.UUID
+		var r adjustmentModel.Refund
+		if err = rows.Scan(&id, &r.PaymentId, &r.Amount); err != nil {
+			p.log.Errorf("Error Scanning Adjustment Refund: %v", err)
+			return nil, err
+		}
+		a := &adjustments[index[id]]
+		a.Refunds = append(a.Refunds, r)
+	}
+	return adjustments, rows.Err()
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) adjustmentRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
@@ -0,0 +1,23 @@
+package adjustmentRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/adjustmentModel"
+)
+
+var (
+	ErrNotFound = errors.New("adjustment not found")
+	// ErrStaleStatus means the adjustment was decided or applied since it was read.
+	ErrStaleStatus = errors.New("adjustment status changed concurrently")
+	// ErrExceedsTotal means the adjustments on the order would refund more than its total.
+	ErrExceedsTotal = errors.New("adjustments exceed the order total")
+)
+
+type RepoInterface interface {
+	Persist(adjustment *adjustmentModel.Adjustment) error
+	FindById(id uuid.UUID) (*adjustmentModel.Adjustment, error)
+	FindByOrder(orderId uuid.UUID) ([]adjustmentModel.Adjustment, error)
+	Decide(adjustment *adjustmentModel.Adjustment) error
+	MarkApplied(adjustment *adjustmentModel.Adjustment) error
+}
@@ -0,0 +1,182 @@
+package psqlRepo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/billModel"
+	"rsm/repository/billRepo"
+	"time"
+)
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+// Persist locks the order while the bill is written, so items cannot be added to a table's
+// order between checking its total and the bill existing.
+func (p *psql) Persist(bill *billModel.Bill) (*billModel.Bill, error) {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		var total int64
+		err := tx.QueryRow(context.Background(), `SELECT total FROM "Orders" WHERE id = $1 FOR UPDATE`,
+			bill.OrderId).Scan(&total)
+		if err != nil {
+			return err
+		}
+		if total != bill.Total {
+			return billRepo.ErrStaleTotal
+		}
+		_, err = tx.Exec(context.Background(), `INSERT INTO "Bills" (id, order_id, method, currency, total, status,
+			created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`, bill.Id, bill.OrderId, bill.Method, bill.Currency,
+			bill.Total, bill.Status, bill.CreatedAt)
+		if err != nil {
+			return err
+		}
+		for i, s := range bill.Shares {
+			_, err = tx.Exec(context.Background(), `INSERT INTO "BillShares" (id, bill_id, position, name, user_id,
+				amount, paid, tip) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`, s.Id, bill.Id, i, s.Name, s.UserId,
+				s.Amount, s.Paid, s.Tip)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if err != billRepo.ErrStaleTotal {
+			p.log.Errorf("Error Persisting Bill: %v", err)
+		}
+		return nil, err
+	}
+	return bill, nil
+}
+
+func (p *psql) FindByOrder(orderId uuid.UUID) (*billModel.Bill, error) {
+	return p.findOne(p.conn, `b.order_id = $1`, orderId)
+}
+
+func (p *psql) FindByShare(shareId uuid.UUID) (*billModel.Bill, error) {
+	return p.findOne(p.conn, `b.id = (SELECT bill_id FROM "BillShares" WHERE id = $1)`, shareId)
+}
+
+// RecordPayment adds a captured payment to its share while holding a lock on the bill, so
+// what is left on the share is checked against payments recorded concurrently. When
+// nothing is left to pay the bill and its order are marked settled, and the order's table
+// session if it has one closed, in the same transaction. Recording the same payment twice
+// changes nothing.
+func (p *psql) RecordPayment(billId uuid.UUID, payment billModel.Payment) (*billModel.Bill, error) {
+	var bill *billModel.Bill
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		var orderId uuid.UUID
+		err := tx.QueryRow(context.Background(), `SELECT order_id FROM "Bills" WHERE id = $1 FOR UPDATE`, billId).
+			Scan(&orderId)
+		if err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(context.Background(), `INSERT INTO "BillPayments" (payment_id, share_id, amount, tip,
+			created_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (payment_id) DO NOTHING`, payment.PaymentId,
+			payment.ShareId, payment.Amount, payment.Tip, payment.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 1 {
+			tag, err = tx.Exec(context.Background(), `UPDATE "BillShares" SET paid = paid + $2, tip = tip + $3
+				WHERE id = $1 AND paid + $2 <= amount`, payment.ShareId, payment.Amount, payment.Tip)
+			if err != nil {
+				return err
+			}
+			if tag.RowsAffected() == 0 {
+				return billRepo.ErrOverpaid
+			}
+		}
+
+		bill, err = p.findOne(tx, `b.id = $1`, billId)
+		if err != nil {
+			return err
+		}
+		if bill.Status == billModel.Open && bill.Outstanding() <= 0 {
+			now := time.Now()
+			bill.Status, bill.SettledAt = billModel.Settled, &now
+			_, err = tx.Exec(context.Background(), `UPDATE "Bills" SET status = $2, settled_at = $3 WHERE id = $1`,
+				billId, bill.Status, now)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(context.Background(), `UPDATE "Orders" SET settled_at = $2, updated_at = $2 WHERE id = $1`,
+				orderId, now)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(context.Background(), `UPDATE "TableSessions" SET status = 'closed', closed_at = $2
+				WHERE order_id = $1 AND status = 'open'`, orderId, now)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if err != billRepo.ErrOverpaid {
+			p.log.Errorf("Error Recording Bill Payment: %v", err)
+		}
+		return nil, err
+	}
+	return bill, nil
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+func (p *psql) findOne(db querier, condition string, args ...interface{}) (*billModel.Bill, error) {
+	var bill billModel.Bill
+	err := db.QueryRow(context.Background(), `SELECT b.id, b.order_id, b.method, b.currency, b.total, b.status,
+		b.created_at, b.settled_at FROM "Bills" b WHERE `+condition, args...).Scan(&bill.Id, &bill.OrderId,
+		&bill.Method, &bill.Currency, &bill.Total, &bill.Status, &bill.CreatedAt, &bill.SettledAt)
+	if err == pgx.ErrNoRows {
+		return nil, billRepo.ErrNotFound
+	}
+	if err != nil {
+		p.log.Errorf("Error Finding Bill: %v", err)
+		return nil, err
+	}
+
+	rows, err := db.Query(context.Background(), `SELECT s.id, s.name, s.user_id, s.amount, s.paid, s.tip,
+		bp.payment_id, bp.amount, bp.tip, bp.created_at
+		FROM "BillShares" s LEFT JOIN "BillPayments" bp ON bp.share_id = s.id
+		WHERE s.bill_id = $1 ORDER BY s.position, bp.created_at`, bill.Id)
+	if err != nil {
+		p.log.Errorf("Error Finding Bill Shares: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s billModel.Share
+		var paymentId *uuid.UUID
+		var amount, tip *int64
+		var createdAt *time.Time
+		err = rows.Scan(&s.Id, &s.Name, &s.UserId, &s.Amount, &s.Paid, &s.Tip, &paymentId, &amount, &tip, &createdAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Bill Share: %v", err)
+			return nil, err
+		}
+		if n := len(bill.Shares); n == 0 || bill.Shares[n-1].Id != s.Id {
+			s.BillId = bill.Id
+			s.Payments = []billModel.Payment{}
+			bill.Shares = append(bill.Shares, s)
+		}
+		if paymentId != nil {
+			last := &bill.Shares[len(bill.Shares)-1]
+			last.Payments = append(last.Payments, billModel.Payment{PaymentId: *paymentId, ShareId: s.Id,
+				Amount: *amount, Tip: *tip, CreatedAt: *createdAt})
+		}
+	}
+	return &bill, rows.Err()
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) billRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
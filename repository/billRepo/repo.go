@@ -0,0 +1,25 @@
+package billRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/billModel"
+)
+
+var (
+	ErrNotFound = errors.New("bill not found")
+	// ErrOverpaid means the payment is more than is left on its share.
+	ErrOverpaid = errors.New("payment exceeds what is left on the share")
+	// ErrStaleTotal means items were added to the order after its total was split.
+	ErrStaleTotal = errors.New("order total changed while splitting the bill")
+)
+
+type RepoInterface interface {
+	// Persist fails with ErrStaleTotal unless the order total is still the bill's.
+	Persist(bill *billModel.Bill) (*billModel.Bill, error)
+	FindByOrder(orderId uuid.UUID) (*billModel.Bill, error)
+	FindByShare(shareId uuid.UUID) (*billModel.Bill, error)
+	// RecordPayment fails with ErrOverpaid, recording nothing, when the payment is more than
+	// is left on its share at the time it is recorded.
+	RecordPayment(billId uuid.UUID, payment billModel.Payment) (*billModel.Bill, error)
+}
@@ -0,0 +1,367 @@
+package psqlRepo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/brandModel"
+	"rsm/entity/menuModel"
+	"rsm/repository/brandRepo"
+	"sort"
+	"time"
+)
+
+const (
+	memberColumns     = `brand_id, user_id, role, locations, created_at`
+	masterItemColumns = `id, brand_id, item, item_type, price, allergens, dietary, created_at, updated_at`
+)
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) PersistBrand(brand *brandModel.Brand) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), `INSERT INTO "Brands" (id, name, owner_id, created_at)
+			VALUES ($1, $2, $3, $4)`, brand.Id, brand.Name, brand.OwnerId, brand.CreatedAt)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(context.Background(), `INSERT INTO "BrandMembers" (brand_id, user_id, role, locations,
+			created_at) VALUES ($1, $2, $3, '{}', $4)`, brand.Id, brand.OwnerId, brandModel.Owner, brand.CreatedAt)
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Persisting Brand: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindBrand(id uuid.UUID) (*brandModel.Brand, error) {
+	var b brandModel.Brand
+	err := p.conn.QueryRow(context.Background(), `SELECT id, name, owner_id, created_at FROM "Brands" WHERE id = $1`,
+		id).Scan(&b.Id, &b.Name, &b.OwnerId, &b.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, brandRepo.ErrNotFound
+	}
+	if err != nil {
+		p.log.Errorf("Error Finding Brand: %v", err)
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (p *psql) AttachLocation(brandId, restaurantId uuid.UUID) error {
+	var current *uuid.UUID
+	err := p.conn.QueryRow(context.Background(), `SELECT brand_id FROM "Restaurants" WHERE id = $1`, restaurantId).
+		Scan(&current)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("restaurant not found")
+	}
+	if err != nil {
+		p.log.Errorf("Error Finding Restaurant Brand: %v", err)
+		return err
+	}
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "Restaurants" SET brand_id = $1
+		WHERE id = $2 AND (brand_id IS NULL OR brand_id = $1)`, brandId, restaurantId)
+	if err != nil {
+		p.log.Errorf("Error Attaching Location: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return brandRepo.ErrLocationTaken
+	}
+	return nil
+}
+
+func (p *psql) FindLocations(brandId uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := p.conn.Query(context.Background(), `SELECT id FROM "Restaurants" WHERE brand_id = $1 ORDER BY id`,
+		brandId)
+	if err != nil {
+		p.log.Errorf("Error Finding Brand Locations: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (p *psql) FindLocationBrand(restaurantId uuid.UUID) (uuid.UUID, error) {
+	var brandId *uuid.UUID
+	err := p.conn.QueryRow(context.Background(), `SELECT brand_id FROM "Restaurants" WHERE id = $1`, restaurantId).
+		Scan(&brandId)
+	if err != nil && err != pgx.ErrNoRows {
+		p.log.Errorf("Error Finding Restaurant Brand: %v", err)
+		return uuid.Nil, err
+	}
+	if brandId == nil {
+		return uuid.Nil, brandRepo.ErrNotFound
+	}
+	return *brandId, nil
+}
+
+func (p *psql) SaveMember(member *brandModel.Member) error {
+	tag, err := p.conn.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "BrandMembers" (%s)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (brand_id, user_id) DO UPDATE SET role = EXCLUDED.role, locations = EXCLUDED.locations
+		WHERE "BrandMembers".role <> $6`, memberColumns),
+		member.BrandId, member.UserId, member.Role, member.Locations, member.CreatedAt, brandModel.Owner)
+	if err != nil {
+		p.log.Errorf("Error Saving Brand Member: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return brandModel.ErrForbidden
+	}
+	return nil
+}
+
+func (p *psql) DeleteMember(brandId, userId uuid.UUID) error {
+	tag, err := p.conn.Exec(context.Background(), `DELETE FROM "BrandMembers"
+		WHERE brand_id = $1 AND user_id = $2 AND role <> $3`, brandId, userId, brandModel.Owner)
+	if err != nil {
+		p.log.Errorf("Error Deleting Brand Member: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return brandRepo.ErrMemberNotFound
+	}
+	return nil
+}
+
+func (p *psql) FindMember(brandId, userId uuid.UUID) (*brandModel.Member, error) {
+	members, err := p.findMembers(`brand_id = $1 AND user_id = $2`, brandId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, brandRepo.ErrMemberNotFound
+	}
+	return &members[0], nil
+}
+
+func (p *psql) FindMembers(brandId uuid.UUID) ([]brandModel.Member, error) {
+	return p.findMembers(`brand_id = $1 ORDER BY created_at, user_id`, brandId)
+}
+
+func (p *psql) PersistMasterItem(item *brandModel.MasterItem) error {
+	_, err := p.conn.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "BrandMenuItems" (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`, masterItemColumns),
+		item.Id, item.BrandId, item.Item, item.ItemType, item.Price, allergenNames(item.Allergens),
+		tagNames(item.Dietary), item.CreatedAt, item.UpdatedAt)
+	if err != nil {
+		p.log.Errorf("Error Persisting Master Item: %v", err)
+	}
+	return err
+}
+
+func (p *psql) UpdateMasterItem(item *brandModel.MasterItem) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "BrandMenuItems" SET item = $3, item_type = $4,
+		price = $5, allergens = $6, dietary = $7, updated_at = $8 WHERE id = $1 AND brand_id = $2`,
+		item.Id, item.BrandId, item.Item, item.ItemType, item.Price, allergenNames(item.Allergens),
+		tagNames(item.Dietary), item.UpdatedAt)
+	if err != nil {
+		p.log.Errorf("Error Updating Master Item: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return brandRepo.ErrMasterItemNotFound
+	}
+	return nil
+}
+
+func (p *psql) DeleteMasterItem(brandId, id uuid.UUID) error {
+	tag, err := p.conn.Exec(context.Background(), `DELETE FROM "BrandMenuItems" WHERE id = $1 AND brand_id = $2`,
+		id, brandId)
+	if err != nil {
+		p.log.Errorf("Error Deleting Master Item: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return brandRepo.ErrMasterItemNotFound
+	}
+	return nil
+}
+
+func (p *psql) FindMasterItems(brandId uuid.UUID) ([]brandModel.MasterItem, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "BrandMenuItems"
+		WHERE brand_id = $1 ORDER BY item_type, item, id`, masterItemColumns), brandId)
+	if err != nil {
+		p.log.Errorf("Error Finding Master Items: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []brandModel.MasterItem{}
+	for rows.Next() {
+		var m brandModel.MasterItem
+		var allergens, dietary []string
+		err = rows.Scan(&m.Id, &m.BrandId, &m.Item, &m.ItemType, &m.Price, &allergens, &dietary, &m.CreatedAt,
+			&m.UpdatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Master Item: %v", err)
+			return nil, err
+		}
+		if allergens != nil {
+			m.Allergens = make([]menuModel.Allergen, 0, len(allergens))
+			for _, a := range allergens {
+				m.Allergens = append(m.Allergens, menuModel.Allergen(a))
+			}
+		}
+		m.Dietary = make([]menuModel.DietaryTag, 0, len(dietary))
+		for _, d := range dietary {
+			m.Dietary = append(m.Dietary, menuModel.DietaryTag(d))
+		}
+		items = append(items, m)
+	}
+	return items, rows.Err()
+}
+
+func (p *psql) SaveOverride(override *brandModel.Override) error {
+	_, err := p.conn.Exec(context.Background(), `INSERT INTO "BrandMenuOverrides"
+		(restaurant_id, master_item_id, price, unavailable, updated_at) VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+		ON CONFLICT (restaurant_id, master_item_id) DO UPDATE SET price = EXCLUDED.price,
+		unavailable = EXCLUDED.unavailable, updated_at = EXCLUDED.updated_at`,
+		override.RestaurantId, override.MasterItemId, override.Price, override.Unavailable, override.UpdatedAt)
+	if err != nil {
+		p.log.Errorf("Error Saving Menu Override: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindOverrides(restaurantId uuid.UUID) ([]brandModel.Override, error) {
+	rows, err := p.conn.Query(context.Background(), `SELECT restaurant_id, master_item_id, coalesce(price, ''),
+		unavailable, updated_at FROM "BrandMenuOverrides" WHERE restaurant_id = $1`, restaurantId)
+	if err != nil {
+		p.log.Errorf("Error Finding Menu Overrides: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := []brandModel.Override{}
+	for rows.Next() {
+		var o brandModel.Override
+		err = rows.Scan(&o.RestaurantId, &o.MasterItemId, &o.Price, &o.Unavailable, &o.UpdatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Menu Override: %v", err)
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+// SyncLocations upserts on the restaurant and master item so each location keeps one Menu row
+// per master item, and orders, stock and reviews stay attached to it across changes. New rows
+// get a code from their id so the location's menu can be exported and imported back.
+// Locations are written in id order so concurrent syncs lock their rows in the same order.
+func (p *psql) SyncLocations(menus map[uuid.UUID][]brandModel.LocationItem) error {
+	restaurantIds := make([]uuid.UUID, 0, len(menus))
+	for id := range menus {
+		restaurantIds = append(restaurantIds, id)
+	}
+	sort.Slice(restaurantIds, func(i, j int) bool {
+		return bytes.Compare(restaurantIds[i][:], restaurantIds[j][:]) < 0
+	})
+
+	now := time.Now()
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		for _, restaurantId := range restaurantIds {
+			err := syncLocation(tx, restaurantId, menus[restaurantId], now)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		p.log.Errorf("Error Syncing Location Menu: %v", err)
+	}
+	return err
+}
+
+func syncLocation(tx pgx.Tx, restaurantId uuid.UUID, items []brandModel.LocationItem, now time.Time) error {
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, l := range items {
+		_, err := tx.Exec(context.Background(), `INSERT INTO "Menu"
+			(restaurant_id, master_item_id, item, price, item_type, allergens, dietary, available, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (restaurant_id, master_item_id) DO UPDATE SET item = EXCLUDED.item,
+			price = EXCLUDED.price, item_type = EXCLUDED.item_type, allergens = EXCLUDED.allergens,
+			dietary = EXCLUDED.dietary, available = EXCLUDED.available`,
+			restaurantId, l.MasterItemId, l.Item, l.Price, l.ItemType, allergenNames(l.Allergens),
+			tagNames(l.Dietary), l.Available, now)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, l.MasterItemId)
+	}
+	_, err := tx.Exec(context.Background(), `UPDATE "Menu" SET available = false
+		WHERE restaurant_id = $1 AND (master_item_id IS NULL OR NOT (master_item_id = ANY($2)))`,
+		restaurantId, ids)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(context.Background(), `UPDATE "Menu" m SET code = 'item-' || m.id
+		WHERE m.restaurant_id = $1 AND m.code IS NULL AND NOT EXISTS (SELECT 1 FROM "Menu" o
+		WHERE o.restaurant_id = m.restaurant_id AND o.code = 'item-' || m.id)`, restaurantId)
+	return err
+}
+
+func (p *psql) findMembers(condition string, args ...interface{}) ([]brandModel.Member, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "BrandMembers" WHERE %s`,
+		memberColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Brand Members: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []brandModel.Member{}
+	for rows.Next() {
+		var m brandModel.Member
+		err = rows.Scan(&m.BrandId, &m.UserId, &m.Role, &m.Locations, &m.CreatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Brand Member: %v", err)
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// allergenNames keeps nil apart from empty, so undeclared allergens are stored as NULL.
+func allergenNames(allergens []menuModel.Allergen) []string {
+	if allergens == nil {
+		return nil
+	}
+	names := make([]string, 0, len(allergens))
+	for _, a := range allergens {
+		names = append(names, string(a))
+	}
+	return names
+}
+
+func tagNames(tags []menuModel.DietaryTag) []string {
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, string(t))
+	}
+	return names
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) brandRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
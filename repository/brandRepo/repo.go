@@ -0,0 +1,44 @@
+package brandRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/brandModel"
+)
+
+var (
+	ErrNotFound           = errors.New("brand not found")
+	ErrMemberNotFound     = errors.New("brand member not found")
+	ErrMasterItemNotFound = errors.New("master menu item not found")
+	// ErrLocationTaken means the restaurant already belongs to another brand.
+	ErrLocationTaken = errors.New("restaurant belongs to another brand")
+)
+
+type RepoInterface interface {
+	// PersistBrand stores the brand with its owner as a member, in one transaction.
+	PersistBrand(brand *brandModel.Brand) error
+	FindBrand(id uuid.UUID) (*brandModel.Brand, error)
+	// AttachLocation fails with ErrLocationTaken if the restaurant is in another brand.
+	AttachLocation(brandId, restaurantId uuid.UUID) error
+	FindLocations(brandId uuid.UUID) ([]uuid.UUID, error)
+	// FindLocationBrand returns ErrNotFound for a restaurant outside any brand.
+	FindLocationBrand(restaurantId uuid.UUID) (uuid.UUID, error)
+
+	// SaveMember adds the member or changes their role; it never changes the owner's.
+	SaveMember(member *brandModel.Member) error
+	DeleteMember(brandId, userId uuid.UUID) error
+	FindMember(brandId, userId uuid.UUID) (*brandModel.Member, error)
+	FindMembers(brandId uuid.UUID) ([]brandModel.Member, error)
+
+	PersistMasterItem(item *brandModel.MasterItem) error
+	UpdateMasterItem(item *brandModel.MasterItem) error
+	DeleteMasterItem(brandId, id uuid.UUID) error
+	FindMasterItems(brandId uuid.UUID) ([]brandModel.MasterItem, error)
+	SaveOverride(override *brandModel.Override) error
+	FindOverrides(restaurantId uuid.UUID) ([]brandModel.Override, error)
+
+	// SyncLocations writes each location's menu into its Menu rows, one per master item, and
+	// marks its other rows unavailable: those of master items no longer on the brand menu and
+	// the location's own items from before it joined. All locations change in one transaction.
+	SyncLocations(menus map[uuid.UUID][]brandModel.LocationItem) error
+}
@@ -0,0 +1,124 @@
+package psqlRepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/courierModel"
+	"rsm/entity/deliveryModel"
+	"rsm/location/geoUtils"
+	"rsm/repository/courierRepo"
+	"time"
+)
+
+const courierColumns = `c.id, c.user_id, c.status, coalesce(c.latitude, 0) AS latitude,
+	coalesce(c.longitude, 0) AS longitude, c.position_at, c.created_at`
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) Persist(courier *courierModel.Courier) error {
+	_, err := p.conn.Exec(context.Background(), `INSERT INTO "Couriers" (id, user_id, status, created_at)
+		VALUES ($1, $2, $3, $4)`, courier.Id, courier.UserId, courier.Status, courier.CreatedAt)
+	if err != nil {
+		p.log.Errorf("Error Persisting Courier: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindById(id uuid.UUID) (*courierModel.Courier, error) {
+	return p.findOne(`c.id = $1`, id)
+}
+
+func (p *psql) FindByUser(userId uuid.UUID) (*courierModel.Courier, error) {
+	return p.findOne(`c.user_id = $1`, userId)
+}
+
+func (p *psql) findOne(condition string, args ...interface{}) (*courierModel.Courier, error) {
+	var c courierModel.Courier
+	err := p.conn.QueryRow(context.Background(), fmt.Sprintf(`SELECT %s FROM "Couriers" c WHERE %s`,
+		courierColumns, condition), args...).Scan(&c.Id, &c.UserId, &c.Status, &c.Latitude, &c.Longitude,
+		&c.PositionAt, &c.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, courierRepo.ErrNotFound
+	}
+	if err != nil {
+		p.log.Errorf("Error Finding Courier: %v", err)
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (p *psql) UpdateStatus(id uuid.UUID, from, to courierModel.Status) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "Couriers" SET status = $3 WHERE id = $1 AND status = $2`,
+		id, from, to)
+	if err != nil {
+		p.log.Errorf("Error Updating Courier Status: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return courierRepo.ErrStaleStatus
+	}
+	return nil
+}
+
+func (p *psql) UpdatePosition(id uuid.UUID, position geoUtils.Point, at time.Time) error {
+	_, err := p.conn.Exec(context.Background(), `UPDATE "Couriers" SET latitude = $2, longitude = $3,
+		position_at = $4 WHERE id = $1`, id, position.Latitude, position.Longitude, at)
+	if err != nil {
+		p.log.Errorf("Error Updating Courier Position: %v", err)
+	}
+	return err
+}
+
+// FindAvailableNear orders available couriers by haversine distance from the query centre,
+// prefiltering on a bounding box around the radius.
+func (p *psql) FindAvailableNear(query courierModel.NearbyQuery) ([]courierModel.NearbyCourier, error) {
+	box := geoUtils.BoundingBoxAround(query.Center, query.RadiusKm)
+	lat, lng := query.Center.Latitude, query.Center.Longitude
+	stmt := fmt.Sprintf(`SELECT * FROM (
+		SELECT %s, 2 * %v * asin(least(1, sqrt(
+			power(sin(radians(c.latitude - $1) / 2), 2) +
+			cos(radians($1)) * cos(radians(c.latitude)) * power(sin(radians(c.longitude - $2) / 2), 2)))) AS distance_km
+		FROM "Couriers" c
+		WHERE c.status = $3 AND c.position_at >= $4 AND NOT c.id = ANY($5)
+			AND c.latitude BETWEEN $6 AND $7 AND c.longitude BETWEEN $8 AND $9
+			AND NOT EXISTS (SELECT 1 FROM "DeliveryOffers" o WHERE o.courier_id = c.id AND o.status = $10)
+	) nearby WHERE nearby.distance_km <= $11 ORDER BY nearby.distance_km, nearby.id LIMIT $12`,
+		courierColumns, geoUtils.EarthRadiusKm)
+
+	exclude := query.Exclude
+	if exclude == nil {
+		exclude = []uuid.UUID{}
+	}
+	rows, err := p.conn.Query(context.Background(), stmt, lat, lng, courierModel.Available, query.FreshSince,
+		exclude, box.MinLatitude, box.MaxLatitude, box.MinLongitude, box.MaxLongitude, deliveryModel.Open,
+		query.RadiusKm, query.Limit)
+	if err != nil {
+		p.log.Errorf("Error Finding Nearby Couriers: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []courierModel.NearbyCourier
+	for rows.Next() {
+		var n courierModel.NearbyCourier
+		c := &n.Courier
+		err = rows.Scan(&c.Id, &c.UserId, &c.Status, &c.Latitude, &c.Longitude, &c.PositionAt, &c.CreatedAt,
+			&n.DistanceKm)
+		if err != nil {
+			p.log.Errorf("Error Scanning Nearby Courier: %v", err)
+			return nil, err
+		}
+		results = append(results, n)
+	}
+	return results, rows.Err()
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) courierRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
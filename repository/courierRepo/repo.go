@@ -0,0 +1,24 @@
+package courierRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/courierModel"
+	"rsm/location/geoUtils"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("courier not found")
+	// ErrStaleStatus means the courier's status changed since it was read.
+	ErrStaleStatus = errors.New("courier status changed concurrently")
+)
+
+type RepoInterface interface {
+	Persist(courier *courierModel.Courier) error
+	FindById(id uuid.UUID) (*courierModel.Courier, error)
+	FindByUser(userId uuid.UUID) (*courierModel.Courier, error)
+	UpdateStatus(id uuid.UUID, from, to courierModel.Status) error
+	UpdatePosition(id uuid.UUID, position geoUtils.Point, at time.Time) error
+	FindAvailableNear(query courierModel.NearbyQuery) ([]courierModel.NearbyCourier, error)
+}
@@ -0,0 +1,288 @@
+package psqlRepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/courierModel"
+	"rsm/entity/deliveryModel"
+	"rsm/entity/orderModel"
+	"rsm/repository/deliveryRepo"
+	"time"
+)
+
+const deliveryColumns = `id, order_id, restaurant_id, courier_id, status, pickup_latitude, pickup_longitude,
+	dropoff_latitude, dropoff_longitude, created_at, updated_at, picked_up_at, delivered_at`
+
+const offerColumns = `id, delivery_id, courier_id, status, distance_km, expires_at, created_at, responded_at`
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) Persist(d *deliveryModel.Delivery) error {
+	_, err := p.conn.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "Deliveries" (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`, deliveryColumns),
+		d.Id, d.OrderId, d.RestaurantId, d.CourierId, d.Status, d.Pickup.Latitude, d.Pickup.Longitude,
+		d.Dropoff.Latitude, d.Dropoff.Longitude, d.CreatedAt, d.UpdatedAt, d.PickedUpAt, d.DeliveredAt)
+	if err != nil {
+		p.log.Errorf("Error Persisting Delivery: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindById(id uuid.UUID) (*deliveryModel.Delivery, error) {
+	return p.findOne(`id = $1`, id)
+}
+
+func (p *psql) FindByOrder(orderId uuid.UUID) (*deliveryModel.Delivery, error) {
+	return p.findOne(`order_id = $1`, orderId)
+}
+
+func (p *psql) findOne(condition string, args ...interface{}) (*deliveryModel.Delivery, error) {
+	deliveries, err := p.find(condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, deliveryRepo.ErrNotFound
+	}
+	return &deliveries[0], nil
+}
+
+func (p *psql) FindUnassigned() ([]deliveryModel.Delivery, error) {
+	return p.find(`status = $1`, deliveryModel.Unassigned)
+}
+
+func (p *psql) find(condition string, args ...interface{}) ([]deliveryModel.Delivery, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "Deliveries" WHERE %s
+		ORDER BY created_at`, deliveryColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Deliveries: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []deliveryModel.Delivery
+	for rows.Next() {
+		var d deliveryModel.Delivery
+		err = rows.Scan(&d.Id, &d.OrderId, &d.RestaurantId, &d.CourierId, &d.Status, &d.Pickup.Latitude,
+			&d.Pickup.Longitude, &d.Dropoff.Latitude, &d.Dropoff.Longitude, &d.CreatedAt, &d.UpdatedAt,
+			&d.PickedUpAt, &d.DeliveredAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Delivery: %v", err)
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// UpdateStatus writes the delivery and its order only if both are still in the expected
+// state. A delivered order frees its courier in the same transaction.
+func (p *psql) UpdateStatus(d *deliveryModel.Delivery, expected deliveryModel.Status, order *orderModel.Order, orderStatus orderModel.Status) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(), `UPDATE "Deliveries" SET status = $3, courier_id = $4,
+			updated_at = $5, picked_up_at = $6, delivered_at = $7 WHERE id = $1 AND status = $2`, d.Id, expected,
+			d.Status, d.CourierId, d.UpdatedAt, d.PickedUpAt, d.DeliveredAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return deliveryRepo.ErrStaleStatus
+		}
+		tag, err = tx.Exec(context.Background(), `UPDATE "Orders" SET status = $3, updated_at = $4
+			WHERE id = $1 AND status = $2`, order.Id, order.Status, orderStatus, d.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return deliveryRepo.ErrStaleStatus
+		}
+		if d.Status == deliveryModel.Delivered && d.CourierId != nil {
+			_, err = tx.Exec(context.Background(), `UPDATE "Couriers" SET status = $2 WHERE id = $1 AND status = $3`,
+				*d.CourierId, courierModel.Available, courierModel.Busy)
+		}
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Updating Delivery: %v", err)
+	}
+	return err
+}
+
+func (p *psql) CloseCancelled(now time.Time) (int64, error) {
+	var closed int64
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), `UPDATE "DeliveryOffers" o SET status = $2, responded_at = $3
+			FROM "Deliveries" d JOIN "Orders" r ON r.id = d.order_id
+			WHERE o.delivery_id = d.id AND o.status = $1 AND r.status = $4`, deliveryModel.Open,
+			deliveryModel.Withdrawn, now, orderModel.Cancelled)
+		if err != nil {
+			return err
+		}
+		rows, err := tx.Query(context.Background(), `UPDATE "Deliveries" d SET status = $1, updated_at = $2
+			FROM "Orders" r WHERE r.id = d.order_id AND r.status = $3 AND d.status = ANY($4)
+			RETURNING d.courier_id`, deliveryModel.Cancelled, now, orderModel.Cancelled,
+			[]string{string(deliveryModel.Unassigned), string(deliveryModel.Offered), string(deliveryModel.Assigned)})
+		if err != nil {
+			return err
+		}
+		var couriers []uuid.UUID
+		for rows.Next() {
+			var courierId *uuid.UUID
+			if err = rows.Scan(&courierId); err != nil {
+				rows.Close()
+				return err
+			}
+			closed++
+			if courierId != nil {
+				couriers = append(couriers, *courierId)
+			}
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil || len(couriers) == 0 {
+			return err
+		}
+		_, err = tx.Exec(context.Background(), `UPDATE "Couriers" SET status = $2 WHERE id = ANY($1) AND status = $3`,
+			couriers, courierModel.Available, courierModel.Busy)
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Closing Cancelled Deliveries: %v", err)
+		return 0, err
+	}
+	return closed, nil
+}
+
+func (p *psql) FindOffer(id uuid.UUID) (*deliveryModel.Offer, error) {
+	offers, err := p.findOffers(`id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(offers) == 0 {
+		return nil, deliveryRepo.ErrNotFound
+	}
+	return &offers[0], nil
+}
+
+func (p *psql) FindOffers(deliveryId uuid.UUID) ([]deliveryModel.Offer, error) {
+	return p.findOffers(`delivery_id = $1`, deliveryId)
+}
+
+func (p *psql) FindExpiredOffers(now time.Time) ([]deliveryModel.Offer, error) {
+	return p.findOffers(`status = $1 AND expires_at <= $2`, deliveryModel.Open, now)
+}
+
+func (p *psql) findOffers(condition string, args ...interface{}) ([]deliveryModel.Offer, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "DeliveryOffers" WHERE %s
+		ORDER BY created_at`, offerColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Delivery Offers: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var offers []deliveryModel.Offer
+	for rows.Next() {
+		var o deliveryModel.Offer
+		err = rows.Scan(&o.Id, &o.DeliveryId, &o.CourierId, &o.Status, &o.DistanceKm, &o.ExpiresAt, &o.CreatedAt,
+			&o.RespondedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Delivery Offer: %v", err)
+			return nil, err
+		}
+		offers = append(offers, o)
+	}
+	return offers, rows.Err()
+}
+
+// CreateOffer opens the offer and marks the delivery offered, failing if the delivery is no
+// longer waiting for a courier.
+func (p *psql) CreateOffer(o *deliveryModel.Offer) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(), `UPDATE "Deliveries" SET status = $3, updated_at = $4
+			WHERE id = $1 AND status = $2`, o.DeliveryId, deliveryModel.Unassigned, deliveryModel.Offered, o.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return deliveryRepo.ErrStaleStatus
+		}
+		_, err = tx.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "DeliveryOffers" (%s)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`, offerColumns), o.Id, o.DeliveryId, o.CourierId, o.Status,
+			o.DistanceKm, o.ExpiresAt, o.CreatedAt, o.RespondedAt)
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Creating Delivery Offer: %v", err)
+	}
+	return err
+}
+
+// AcceptOffer assigns the delivery to the courier and marks them busy. It fails if the offer
+// expired or was closed, or if the courier is no longer available.
+func (p *psql) AcceptOffer(o *deliveryModel.Offer) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(), `UPDATE "DeliveryOffers" SET status = $3, responded_at = $4
+			WHERE id = $1 AND status = $2 AND expires_at > $4`, o.Id, deliveryModel.Open, deliveryModel.Accepted,
+			o.RespondedAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return deliveryRepo.ErrStaleStatus
+		}
+		tag, err = tx.Exec(context.Background(), `UPDATE "Deliveries" SET status = $3, courier_id = $4, updated_at = $5
+			WHERE id = $1 AND status = $2`, o.DeliveryId, deliveryModel.Offered, deliveryModel.Assigned, o.CourierId,
+			o.RespondedAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return deliveryRepo.ErrStaleStatus
+		}
+		tag, err = tx.Exec(context.Background(), `UPDATE "Couriers" SET status = $2 WHERE id = $1 AND status = $3`,
+			o.CourierId, courierModel.Busy, courierModel.Available)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return deliveryRepo.ErrStaleStatus
+		}
+		return nil
+	})
+	if err != nil {
+		p.log.Errorf("Error Accepting Delivery Offer: %v", err)
+	}
+	return err
+}
+
+// CloseOffer records a declined or expired offer and puts the delivery back to unassigned.
+func (p *psql) CloseOffer(o *deliveryModel.Offer) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(), `UPDATE "DeliveryOffers" SET status = $3, responded_at = $4
+			WHERE id = $1 AND status = $2`, o.Id, deliveryModel.Open, o.Status, o.RespondedAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return deliveryRepo.ErrStaleStatus
+		}
+		_, err = tx.Exec(context.Background(), `UPDATE "Deliveries" SET status = $3, updated_at = $4
+			WHERE id = $1 AND status = $2`, o.DeliveryId, deliveryModel.Offered, deliveryModel.Unassigned,
+			o.RespondedAt)
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Closing Delivery Offer: %v", err)
+	}
+	return err
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) deliveryRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
@@ -0,0 +1,35 @@
+package deliveryRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/deliveryModel"
+	"rsm/entity/orderModel"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("delivery not found")
+	// ErrStaleStatus means the delivery or offer moved on since it was read.
+	ErrStaleStatus = errors.New("delivery status changed concurrently")
+)
+
+type RepoInterface interface {
+	Persist(delivery *deliveryModel.Delivery) error
+	FindById(id uuid.UUID) (*deliveryModel.Delivery, error)
+	FindByOrder(orderId uuid.UUID) (*deliveryModel.Delivery, error)
+	FindUnassigned() ([]deliveryModel.Delivery, error)
+	// UpdateStatus moves the delivery and its order together, failing with ErrStaleStatus if
+	// either moved since it was read.
+	UpdateStatus(delivery *deliveryModel.Delivery, expected deliveryModel.Status, order *orderModel.Order, orderStatus orderModel.Status) error
+	// CloseCancelled cancels the deliveries of cancelled orders that were not picked up yet,
+	// withdrawing their open offers and freeing assigned couriers.
+	CloseCancelled(now time.Time) (int64, error)
+
+	FindOffer(id uuid.UUID) (*deliveryModel.Offer, error)
+	FindOffers(deliveryId uuid.UUID) ([]deliveryModel.Offer, error)
+	FindExpiredOffers(now time.Time) ([]deliveryModel.Offer, error)
+	CreateOffer(offer *deliveryModel.Offer) error
+	AcceptOffer(offer *deliveryModel.Offer) error
+	CloseOffer(offer *deliveryModel.Offer) error
+}
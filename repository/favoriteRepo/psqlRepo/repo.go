@@ -0,0 +1,107 @@
+package psqlRepo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/favoriteModel"
+	"rsm/repository/favoriteRepo"
+	"time"
+)
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) SaveRestaurant(userId, restaurantId uuid.UUID, at time.Time) error {
+	_, err := p.conn.Exec(context.Background(), `INSERT INTO "FavoriteRestaurants" (user_id, restaurant_id,
+		created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, userId, restaurantId, at)
+	if err != nil {
+		p.log.Errorf("Error Saving Favorite Restaurant: %v", err)
+	}
+	return err
+}
+
+func (p *psql) DeleteRestaurant(userId, restaurantId uuid.UUID) error {
+	tag, err := p.conn.Exec(context.Background(), `DELETE FROM "FavoriteRestaurants" WHERE user_id = $1
+		AND restaurant_id = $2`, userId, restaurantId)
+	return p.deleted(tag, err)
+}
+
+func (p *psql) SaveItem(userId uuid.UUID, menuId int64, at time.Time) error {
+	_, err := p.conn.Exec(context.Background(), `INSERT INTO "FavoriteItems" (user_id, menu_id, created_at)
+		VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, userId, menuId, at)
+	if err != nil {
+		p.log.Errorf("Error Saving Favorite Item: %v", err)
+	}
+	return err
+}
+
+func (p *psql) DeleteItem(userId uuid.UUID, menuId int64) error {
+	tag, err := p.conn.Exec(context.Background(), `DELETE FROM "FavoriteItems" WHERE user_id = $1 AND menu_id = $2`,
+		userId, menuId)
+	return p.deleted(tag, err)
+}
+
+func (p *psql) FindByUser(userId uuid.UUID) (*favoriteModel.Favorites, error) {
+	favorites := &favoriteModel.Favorites{Restaurants: []favoriteModel.Restaurant{}, Items: []favoriteModel.Item{}}
+	rows, err := p.conn.Query(context.Background(), `SELECT r.id, r.name, coalesce(r.image_ref, ''), f.created_at
+		FROM "FavoriteRestaurants" f JOIN "Restaurants" r ON r.id = f.restaurant_id WHERE f.user_id = $1
+		ORDER BY f.created_at DESC, r.id`, userId)
+	if err != nil {
+		p.log.Errorf("Error Finding Favorite Restaurants: %v", err)
+		return nil, err
+	}
+	for rows.Next() {
+		var r favoriteModel.Restaurant
+		err = rows.Scan(&r.RestaurantId, &r.Name, &r.ImageRef, &r.SavedAt)
+		if err != nil {
+			rows.Close()
+			p.log.Errorf("Error Scanning Favorite Restaurant: %v", err)
+			return nil, err
+		}
+		favorites.Restaurants = append(favorites.Restaurants, r)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = p.conn.Query(context.Background(), `SELECT m.id, m.restaurant_id, m.item, m.item_type, m.price,
+		coalesce(m.image_ref, ''), m.available, f.created_at FROM "FavoriteItems" f JOIN "Menu" m ON m.id = f.menu_id
+		WHERE f.user_id = $1 ORDER BY f.created_at DESC, m.id`, userId)
+	if err != nil {
+		p.log.Errorf("Error Finding Favorite Items: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i favoriteModel.Item
+		err = rows.Scan(&i.MenuId, &i.RestaurantId, &i.Item, &i.ItemType, &i.Price, &i.ImageRef, &i.Available,
+			&i.SavedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Favorite Item: %v", err)
+			return nil, err
+		}
+		favorites.Items = append(favorites.Items, i)
+	}
+	return favorites, rows.Err()
+}
+
+func (p *psql) deleted(tag pgconn.CommandTag, err error) error {
+	if err != nil {
+		p.log.Errorf("Error Deleting Favorite: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return favoriteRepo.ErrNotFound
+	}
+	return nil
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) favoriteRepo.RepoInterface {
+	return &psql{log: log, conn: conn}
+}
@@ -0,0 +1,19 @@
+package favoriteRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/favoriteModel"
+	"time"
+)
+
+var ErrNotFound = errors.New("favorite not found")
+
+type RepoInterface interface {
+	// SaveRestaurant and SaveItem keep the original time of favorites saved before.
+	SaveRestaurant(userId, restaurantId uuid.UUID, at time.Time) error
+	DeleteRestaurant(userId, restaurantId uuid.UUID) error
+	SaveItem(userId uuid.UUID, menuId int64, at time.Time) error
+	DeleteItem(userId uuid.UUID, menuId int64) error
+	FindByUser(userId uuid.UUID) (*favoriteModel.Favorites, error)
+}
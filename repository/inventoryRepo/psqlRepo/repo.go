@@ -0,0 +1,195 @@
+package psqlRepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/inventoryModel"
+	"rsm/repository/inventoryRepo"
+	"time"
+)
+
+// Stock is only set for counted items and daily_limit only for daily ones; an item with
+// neither is untracked.
+const stockColumns = `id, restaurant_id, item, stock, daily_limit, sold_today, sold_on, low_stock_at`
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) FindByRestaurant(restaurantId uuid.UUID) ([]inventoryModel.Stock, error) {
+	stocks, err := find(p.conn, `restaurant_id = $1 ORDER BY id`, restaurantId)
+	if err != nil {
+		p.log.Errorf("Error Finding Stock: %v", err)
+	}
+	return stocks, err
+}
+
+func (p *psql) FindByMenu(menuId int64) (*inventoryModel.Stock, error) {
+	stocks, err := find(p.conn, `id = $1`, menuId)
+	if err != nil {
+		p.log.Errorf("Error Finding Stock: %v", err)
+		return nil, err
+	}
+	if len(stocks) == 0 {
+		return nil, inventoryRepo.ErrNotFound
+	}
+	return &stocks[0], nil
+}
+
+func (p *psql) Save(stock *inventoryModel.Stock) error {
+	tag, err := p.conn.Exec(context.Background(), saveStmt, saveArgs(stock)...)
+	if err != nil {
+		p.log.Errorf("Error Saving Stock: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return inventoryRepo.ErrNotFound
+	}
+	return nil
+}
+
+// Restock adds to a counted item's stock, which makes it available again if it had sold out.
+func (p *psql) Restock(menuId int64, quantity int) (*inventoryModel.Stock, error) {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "Menu" SET stock = stock + $2
+		WHERE id = $1 AND stock IS NOT NULL`, menuId, quantity)
+	if err != nil {
+		p.log.Errorf("Error Restocking: %v", err)
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		_, err = p.FindByMenu(menuId)
+		if err != nil {
+			return nil, err
+		}
+		return nil, inventoryRepo.ErrNotCounted
+	}
+	return p.FindByMenu(menuId)
+}
+
+// Reserve takes every line from stock in one transaction. The menu rows are locked in menu
+// id order, so concurrent orders for the same items queue up instead of overselling or
+// deadlocking. If any line is short nothing is taken and the *OutOfStockError is returned.
+func (p *psql) Reserve(lines []inventoryModel.Line, now time.Time) ([]inventoryModel.Stock, error) {
+	return p.adjust(lines, func(s *inventoryModel.Stock, quantity int) error {
+		return s.Take(quantity, now)
+	})
+}
+
+// Release puts back stock reserved for an order that did not go ahead.
+func (p *psql) Release(lines []inventoryModel.Line, now time.Time) ([]inventoryModel.Stock, error) {
+	return p.adjust(lines, func(s *inventoryModel.Stock, quantity int) error {
+		s.Return(quantity, now)
+		return nil
+	})
+}
+
+func (p *psql) adjust(lines []inventoryModel.Line, apply func(s *inventoryModel.Stock, quantity int) error) ([]inventoryModel.Stock, error) {
+	ids := make([]int64, 0, len(lines))
+	for _, l := range lines {
+		ids = append(ids, l.MenuId)
+	}
+
+	var stocks []inventoryModel.Stock
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		var err error
+		stocks, err = find(tx, `id = ANY($1) ORDER BY id FOR UPDATE`, ids)
+		if err != nil {
+			return err
+		}
+		byId := map[int64]*inventoryModel.Stock{}
+		for i := range stocks {
+			byId[stocks[i].MenuId] = &stocks[i]
+		}
+		for _, l := range lines {
+			s, ok := byId[l.MenuId]
+			if !ok {
+				return fmt.Errorf("menu item %d not found", l.MenuId)
+			}
+			err = apply(s, l.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+		for i := range stocks {
+			if stocks[i].Mode == inventoryModel.Untracked {
+				continue
+			}
+			_, err = tx.Exec(context.Background(), saveStmt, saveArgs(&stocks[i])...)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var short *inventoryModel.OutOfStockError
+		if !errors.As(err, &short) {
+			p.log.Errorf("Error Adjusting Stock: %v", err)
+		}
+		return nil, err
+	}
+	return stocks, nil
+}
+
+const saveStmt = `UPDATE "Menu" SET stock = $2, daily_limit = $3, sold_today = $4, sold_on = $5,
+	low_stock_at = $6 WHERE id = $1`
+
+func saveArgs(s *inventoryModel.Stock) []interface{} {
+	var stock, dailyLimit *int
+	switch s.Mode {
+	case inventoryModel.Count:
+		stock = &s.Servings
+	case inventoryModel.Daily:
+		dailyLimit = &s.DailyLimit
+	}
+	var soldOn *time.Time
+	if !s.SoldOn.IsZero() {
+		soldOn = &s.SoldOn
+	}
+	return []interface{}{s.MenuId, stock, dailyLimit, s.SoldToday, soldOn, s.LowStockAt}
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+func find(db querier, condition string, args ...interface{}) ([]inventoryModel.Stock, error) {
+	rows, err := db.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "Menu" WHERE %s`, stockColumns,
+		condition), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := []inventoryModel.Stock{}
+	for rows.Next() {
+		var s inventoryModel.Stock
+		var stock, dailyLimit *int
+		var soldOn *time.Time
+		err = rows.Scan(&s.MenuId, &s.RestaurantId, &s.Item, &stock, &dailyLimit, &s.SoldToday, &soldOn,
+			&s.LowStockAt)
+		if err != nil {
+			return nil, err
+		}
+		s.Mode = inventoryModel.Untracked
+		if stock != nil {
+			s.Mode, s.Servings = inventoryModel.Count, *stock
+		} else if dailyLimit != nil {
+			s.Mode, s.DailyLimit = inventoryModel.Daily, *dailyLimit
+		}
+		if soldOn != nil {
+			s.SoldOn = *soldOn
+		}
+		stocks = append(stocks, s)
+	}
+	return stocks, rows.Err()
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) inventoryRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
@@ -0,0 +1,23 @@
+package inventoryRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/inventoryModel"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("menu item not found")
+	// ErrNotCounted is returned when restocking an item that is not counted.
+	ErrNotCounted = errors.New("only counted items can be restocked")
+)
+
+type RepoInterface interface {
+	FindByRestaurant(restaurantId uuid.UUID) ([]inventoryModel.Stock, error)
+	FindByMenu(menuId int64) (*inventoryModel.Stock, error)
+	Save(stock *inventoryModel.Stock) error
+	Restock(menuId int64, quantity int) (*inventoryModel.Stock, error)
+	Reserve(lines []inventoryModel.Line, now time.Time) ([]inventoryModel.Stock, error)
+	Release(lines []inventoryModel.Line, now time.Time) ([]inventoryModel.Stock, error)
+}
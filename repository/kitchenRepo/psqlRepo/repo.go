@@ -0,0 +1,104 @@
+package psqlRepo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/kitchenModel"
+	"rsm/entity/orderModel"
+	"rsm/repository/kitchenRepo"
+	"time"
+)
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) FindStates(orderIds []uuid.UUID) ([]kitchenModel.TicketState, error) {
+	states, err := findStates(p.conn, orderIds)
+	if err != nil {
+		p.log.Errorf("Error Finding Kitchen Tickets: %v", err)
+	}
+	return states, err
+}
+
+// SaveState writes the ticket only if it is still in the expected state. Queued tickets have
+// no row yet, so the first move inserts one. The order row stays locked while the order's
+// tickets are read back and its status is moved to match them, so stations acting at the
+// same time each see the other's ticket.
+func (p *psql) SaveState(order orderModel.Order, state kitchenModel.TicketState, expected kitchenModel.TicketStatus) (previous, current orderModel.Status, err error) {
+	err = p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		err := tx.QueryRow(context.Background(), `SELECT status FROM "Orders" WHERE id = $1 FOR UPDATE`,
+			order.Id).Scan(&previous)
+		if err != nil {
+			return err
+		}
+
+		var tag pgconn.CommandTag
+		if expected == kitchenModel.Queued {
+			tag, err = tx.Exec(context.Background(), `INSERT INTO "KitchenTickets" (order_id, station, status,
+				started_at, bumped_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (order_id, station) DO NOTHING`,
+				state.OrderId, state.Station, state.Status, state.StartedAt, state.BumpedAt)
+		} else {
+			tag, err = tx.Exec(context.Background(), `UPDATE "KitchenTickets" SET status = $4, started_at = $5,
+				bumped_at = $6 WHERE order_id = $1 AND station = $2 AND status = $3`, state.OrderId, state.Station,
+				expected, state.Status, state.StartedAt, state.BumpedAt)
+		}
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return kitchenRepo.ErrStaleStatus
+		}
+
+		states, err := findStates(tx, []uuid.UUID{order.Id})
+		if err != nil {
+			return err
+		}
+		order.Status = previous
+		current = kitchenModel.OrderStatus(kitchenModel.Tickets(order, states, time.Now()))
+		if current == previous || !orderModel.CanTransition(previous, current) {
+			current = previous
+			return nil
+		}
+		_, err = tx.Exec(context.Background(), `UPDATE "Orders" SET status = $2, updated_at = $3 WHERE id = $1`,
+			order.Id, current, time.Now())
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Saving Kitchen Ticket: %v", err)
+		return "", "", err
+	}
+	return previous, current, nil
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+func findStates(db querier, orderIds []uuid.UUID) ([]kitchenModel.TicketState, error) {
+	rows, err := db.Query(context.Background(), `SELECT order_id, station, status, started_at, bumped_at
+		FROM "KitchenTickets" WHERE order_id = ANY($1)`, orderIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var states []kitchenModel.TicketState
+	for rows.Next() {
+		var s kitchenModel.TicketState
+		err = rows.Scan(&s.OrderId, &s.Station, &s.Status, &s.StartedAt, &s.BumpedAt)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, s)
+	}
+	return states, rows.Err()
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) kitchenRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
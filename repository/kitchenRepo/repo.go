@@ -0,0 +1,18 @@
+package kitchenRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/kitchenModel"
+	"rsm/entity/orderModel"
+)
+
+// ErrStaleStatus means another screen moved the ticket since it was read.
+var ErrStaleStatus = errors.New("ticket status changed concurrently")
+
+type RepoInterface interface {
+	FindStates(orderIds []uuid.UUID) ([]kitchenModel.TicketState, error)
+	// SaveState moves one ticket and, in the same transaction, the order to the status its
+	// tickets now add up to. It returns the order's status before and after.
+	SaveState(order orderModel.Order, state kitchenModel.TicketState, expected kitchenModel.TicketStatus) (previous, current orderModel.Status, err error)
+}
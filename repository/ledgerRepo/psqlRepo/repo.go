@@ -0,0 +1,178 @@
+package psqlRepo
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/ledgerModel"
+	"rsm/entity/moneyModel"
+	"rsm/repository/ledgerRepo"
+	"time"
+)
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) FindOrCreateAccount(accountType ledgerModel.AccountType, ownerId uuid.UUID, currency string) (*ledgerModel.Account, error) {
+	account := ledgerModel.Account{Id: uuid.New(), Type: accountType, OwnerId: ownerId, Currency: currency,
+		CreatedAt: time.Now()}
+	// The no-op update makes RETURNING yield the existing row when the account already exists.
+	err := p.conn.QueryRow(context.Background(), `INSERT INTO "LedgerAccounts" (id, type, owner_id, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (type, owner_id, currency) DO UPDATE SET type = EXCLUDED.type
+		RETURNING id, created_at`, account.Id, account.Type, account.OwnerId, account.Currency, account.CreatedAt).
+		Scan(&account.Id, &account.CreatedAt)
+	if err != nil {
+		p.log.Errorf("Error Finding Ledger Account: %v", err)
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (p *psql) FindAccountsByType(accountType ledgerModel.AccountType) ([]ledgerModel.Account, error) {
+	rows, err := p.conn.Query(context.Background(), `SELECT id, type, owner_id, currency, created_at
+		FROM "LedgerAccounts" WHERE type = $1 ORDER BY created_at`, accountType)
+	if err != nil {
+		p.log.Errorf("Error Finding Ledger Accounts: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []ledgerModel.Account
+	for rows.Next() {
+		var a ledgerModel.Account
+		if err = rows.Scan(&a.Id, &a.Type, &a.OwnerId, &a.Currency, &a.CreatedAt); err != nil {
+			p.log.Errorf("Error Scanning Ledger Account: %v", err)
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+func (p *psql) PostEntry(entry *ledgerModel.JournalEntry) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		return insertEntry(tx, entry)
+	})
+	if err != nil && !errors.Is(err, ledgerRepo.ErrDuplicateReference) {
+		p.log.Errorf("Error Posting Journal Entry: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindEntriesByReference(reference string) ([]ledgerModel.JournalEntry, error) {
+	rows, err := p.conn.Query(context.Background(), `SELECT e.id, e.description, e.reference, e.effective_at,
+		e.created_at, lp.account_id, lp.currency, lp.amount
+		FROM "JournalEntries" e JOIN "LedgerPostings" lp ON lp.entry_id = e.id
+		WHERE e.reference = $1 ORDER BY e.effective_at, e.id, lp.id`, reference)
+	if err != nil {
+		p.log.Errorf("Error Finding Journal Entries: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []ledgerModel.JournalEntry
+	for rows.Next() {
+		var e ledgerModel.JournalEntry
+		var posting ledgerModel.Posting
+		var currency string
+		var amount int64
+		err = rows.Scan(&e.Id, &e.Description, &e.Reference, &e.EffectiveAt, &e.CreatedAt, &posting.AccountId,
+			&currency, &amount)
+		if err != nil {
+			p.log.Errorf("Error Scanning Journal Entry: %v", err)
+			return nil, err
+		}
+		posting.Amount = moneyModel.New(amount, currency)
+		if n := len(entries); n > 0 && entries[n-1].Id == e.Id {
+			entries[n-1].Postings = append(entries[n-1].Postings, posting)
+			continue
+		}
+		e.Postings = []ledgerModel.Posting{posting}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Balance sums every posting to the account from entries effective at or before asOf.
+func (p *psql) Balance(accountId uuid.UUID, asOf time.Time) (moneyModel.Money, error) {
+	var currency string
+	var amount int64
+	err := p.conn.QueryRow(context.Background(), `SELECT a.currency, coalesce(sum(lp.amount), 0)
+		FROM "LedgerAccounts" a
+		LEFT JOIN "LedgerPostings" lp ON lp.account_id = a.id
+			AND lp.entry_id IN (SELECT id FROM "JournalEntries" WHERE effective_at <= $2)
+		WHERE a.id = $1 GROUP BY a.currency`, accountId, asOf).Scan(&currency, &amount)
+	if err == pgx.ErrNoRows {
+		return moneyModel.Money{}, ledgerRepo.ErrNotFound
+	}
+	if err != nil {
+		p.log.Errorf("Error Computing Balance: %v", err)
+		return moneyModel.Money{}, err
+	}
+	return moneyModel.New(amount, currency), nil
+}
+
+func (p *psql) LastPayout(restaurantId uuid.UUID, currency string) (*ledgerModel.PayoutBatch, error) {
+	var batch ledgerModel.PayoutBatch
+	var amount int64
+	err := p.conn.QueryRow(context.Background(), `SELECT id, restaurant_id, period_start, period_end, currency,
+		amount, entry_id, created_at FROM "PayoutBatches" WHERE restaurant_id = $1 AND currency = $2
+		ORDER BY period_end DESC LIMIT 1`, restaurantId, currency).Scan(&batch.Id, &batch.RestaurantId,
+		&batch.PeriodStart, &batch.PeriodEnd, &currency, &amount, &batch.EntryId, &batch.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, ledgerRepo.ErrNotFound
+	}
+	if err != nil {
+		p.log.Errorf("Error Finding Last Payout: %v", err)
+		return nil, err
+	}
+	batch.Amount = moneyModel.New(amount, currency)
+	return &batch, nil
+}
+
+func (p *psql) PersistPayout(batch *ledgerModel.PayoutBatch, entry *ledgerModel.JournalEntry) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		if err := insertEntry(tx, entry); err != nil {
+			return err
+		}
+		_, err := tx.Exec(context.Background(), `INSERT INTO "PayoutBatches"
+			(id, restaurant_id, period_start, period_end, currency, amount, entry_id, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`, batch.Id, batch.RestaurantId, batch.PeriodStart,
+			batch.PeriodEnd, batch.Amount.Currency, batch.Amount.Amount, batch.EntryId, batch.CreatedAt)
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Persisting Payout: %v", err)
+	}
+	return err
+}
+
+func insertEntry(tx pgx.Tx, entry *ledgerModel.JournalEntry) error {
+	tag, err := tx.Exec(context.Background(), `INSERT INTO "JournalEntries"
+		(id, description, reference, effective_at, created_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (reference) DO NOTHING`,
+		entry.Id, entry.Description, entry.Reference, entry.EffectiveAt, entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ledgerRepo.ErrDuplicateReference
+	}
+	for _, posting := range entry.Postings {
+		_, err = tx.Exec(context.Background(), `INSERT INTO "LedgerPostings" (entry_id, account_id, currency, amount)
+			VALUES ($1, $2, $3, $4)`, entry.Id, posting.AccountId, posting.Amount.Currency, posting.Amount.Amount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) ledgerRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
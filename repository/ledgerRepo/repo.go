@@ -0,0 +1,25 @@
+package ledgerRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/ledgerModel"
+	"rsm/entity/moneyModel"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("ledger record not found")
+	// ErrDuplicateReference means an entry with the same reference was already posted.
+	ErrDuplicateReference = errors.New("journal entry reference already posted")
+)
+
+type RepoInterface interface {
+	FindOrCreateAccount(accountType ledgerModel.AccountType, ownerId uuid.UUID, currency string) (*ledgerModel.Account, error)
+	FindAccountsByType(accountType ledgerModel.AccountType) ([]ledgerModel.Account, error)
+	PostEntry(entry *ledgerModel.JournalEntry) error
+	FindEntriesByReference(reference string) ([]ledgerModel.JournalEntry, error)
+	Balance(accountId uuid.UUID, asOf time.Time) (moneyModel.Money, error)
+	LastPayout(restaurantId uuid.UUID, currency string) (*ledgerModel.PayoutBatch, error)
+	PersistPayout(batch *ledgerModel.PayoutBatch, entry *ledgerModel.JournalEntry) error
+}
@@ -0,0 +1,369 @@
+package psqlRepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/loyaltyModel"
+	"rsm/repository/loyaltyRepo"
+	"time"
+)
+
+const (
+	ruleColumns   = `id, restaurant_id, brand_id, currency, points, per, expiry_days, active, updated_at`
+	rewardColumns = `id, restaurant_id, brand_id, name, cost, type, value, currency, free_menu_id, active,
+		created_at`
+	entryColumns = `id, user_id, kind, points, remaining, order_id, restaurant_id, reward_id, expires_at,
+		created_at`
+)
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) SaveRule(rule *loyaltyModel.EarnRule) error {
+	scope := "restaurant_id"
+	if rule.BrandId != nil {
+		scope = "brand_id"
+	}
+	err := p.conn.QueryRow(context.Background(), fmt.Sprintf(`INSERT INTO "LoyaltyRules" (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (%s) WHERE %s IS NOT NULL DO UPDATE
+		SET currency = $4, points = $5, per = $6, expiry_days = $7, active = $8, updated_at = $9
+		RETURNING id`, ruleColumns, scope, scope), rule.Id, rule.RestaurantId, rule.BrandId, rule.Currency,
+		rule.Points, rule.Per, rule.ExpiryDays, rule.Active, rule.UpdatedAt).Scan(&rule.Id)
+	if err != nil {
+		p.log.Errorf("Error Saving Loyalty Rule: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindRestaurantRule(restaurantId uuid.UUID) (*loyaltyModel.EarnRule, error) {
+	return p.findRule(`restaurant_id = $1`, restaurantId)
+}
+
+func (p *psql) FindBrandRule(brandId uuid.UUID) (*loyaltyModel.EarnRule, error) {
+	return p.findRule(`brand_id = $1`, brandId)
+}
+
+func (p *psql) PersistReward(reward *loyaltyModel.Reward) error {
+	_, err := p.conn.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "LoyaltyRewards" (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`, rewardColumns), reward.Id, reward.RestaurantId,
+		reward.BrandId, reward.Name, reward.Cost, reward.Type, reward.Value, reward.Currency, reward.FreeMenuId,
+		reward.Active, reward.CreatedAt)
+	if err != nil {
+		p.log.Errorf("Error Persisting Reward: %v", err)
+	}
+	return err
+}
+
+func (p *psql) UpdateReward(reward *loyaltyModel.Reward) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "LoyaltyRewards" SET name = $2, cost = $3, type = $4,
+		value = $5, currency = $6, free_menu_id = $7, active = $8 WHERE id = $1`, reward.Id, reward.Name,
+		reward.Cost, reward.Type, reward.Value, reward.Currency, reward.FreeMenuId, reward.Active)
+	if err != nil {
+		p.log.Errorf("Error Updating Reward: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return loyaltyRepo.ErrRewardNotFound
+	}
+	return nil
+}
+
+func (p *psql) FindReward(id uuid.UUID) (*loyaltyModel.Reward, error) {
+	rewards, err := p.findRewards(`id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rewards) == 0 {
+		return nil, loyaltyRepo.ErrRewardNotFound
+	}
+	return &rewards[0], nil
+}
+
+func (p *psql) FindRewards(restaurantId uuid.UUID, brandId *uuid.UUID) ([]loyaltyModel.Reward, error) {
+	return p.findRewards(`active AND (restaurant_id = $1 OR brand_id = $2
+		OR (restaurant_id IS NULL AND brand_id IS NULL)) ORDER BY cost, name, id`, restaurantId, brandId)
+}
+
+func (p *psql) Earn(entry *loyaltyModel.Entry) error {
+	tag, err := p.conn.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "LoyaltyEntries" (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (order_id, kind)
+		WHERE order_id IS NOT NULL AND kind <> 'clawback' DO NOTHING`, entryColumns), entry.Id, entry.UserId, entry.Kind, entry.Points, entry.Remaining,
+		entry.OrderId, entry.RestaurantId, entry.RewardId, entry.ExpiresAt, entry.CreatedAt)
+	if err != nil {
+		p.log.Errorf("Error Earning Loyalty Points: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return loyaltyRepo.ErrAlreadyRecorded
+	}
+	return nil
+}
+
+func (p *psql) Spend(entry *loyaltyModel.Entry) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "LoyaltyEntries" (%s)
+			VALUES ($1, $2, $3, $4, 0, $5, $6, $7, NULL, $8) ON CONFLICT (order_id, kind)
+			WHERE order_id IS NOT NULL AND kind <> 'clawback' DO NOTHING`, entryColumns), entry.Id, entry.UserId, entry.Kind,
+			entry.Points, entry.OrderId, entry.RestaurantId, entry.RewardId, entry.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return loyaltyRepo.ErrAlreadyRecorded
+		}
+
+		rows, err := tx.Query(context.Background(), `SELECT id, remaining, expires_at FROM "LoyaltyEntries"
+			WHERE user_id = $1 AND kind = ANY($2) AND remaining > 0 AND (expires_at IS NULL OR expires_at > $3)
+			ORDER BY expires_at NULLS LAST, created_at, id FOR UPDATE`, entry.UserId, loyaltyModel.Lots,
+			entry.CreatedAt)
+		if err != nil {
+			return err
+		}
+		var ids []uuid.UUID
+		var takes []int64
+		var expiresAt *time.Time
+		owed := -entry.Points
+		for owed > 0 && rows.Next() {
+			var id uuid.UUID
+			var remaining int64
+			var expires *time.Time
+			err = rows.Scan(&id, &remaining, &expires)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			// Lots come soonest expiring first, so the first one taken expires soonest.
+			if len(ids) == 0 {
+				expiresAt = expires
+			}
+			take := remaining
+			if take > owed {
+				take = owed
+			}
+			ids, takes = append(ids, id), append(takes, take)
+			owed -= take
+		}
+		rows.Close()
+		if rows.Err() != nil {
+			return rows.Err()
+		}
+		if owed > 0 {
+			return loyaltyModel.ErrInsufficientPoints
+		}
+
+		_, err = tx.Exec(context.Background(), `UPDATE "LoyaltyEntries" e SET remaining = e.remaining - t.take
+			FROM unnest($1::uuid[], $2::bigint[]) AS t(id, take) WHERE e.id = t.id`, ids, takes)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(context.Background(), `UPDATE "LoyaltyEntries" SET expires_at = $2 WHERE id = $1`,
+			entry.Id, expiresAt)
+		if err != nil {
+			return err
+		}
+		entry.ExpiresAt = expiresAt
+		return nil
+	})
+	if err != nil && err != loyaltyModel.ErrInsufficientPoints && err != loyaltyRepo.ErrAlreadyRecorded {
+		p.log.Errorf("Error Spending Loyalty Points: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindRedeemed(orderId uuid.UUID) (*loyaltyModel.Entry, error) {
+	var e loyaltyModel.Entry
+	err := p.conn.QueryRow(context.Background(), fmt.Sprintf(`SELECT %s FROM "LoyaltyEntries"
+		WHERE order_id = $1 AND kind = $2 AND NOT EXISTS (SELECT 1 FROM "LoyaltyEntries"
+		WHERE order_id = $1 AND kind = $3)`, entryColumns), orderId, loyaltyModel.Redeem, loyaltyModel.Restore).
+		Scan(&e.Id, &e.UserId, &e.Kind, &e.Points, &e.Remaining, &e.OrderId, &e.RestaurantId, &e.RewardId,
+			&e.ExpiresAt, &e.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		p.log.Errorf("Error Finding Redeemed Reward: %v", err)
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Reverse locks the order's earn entry first, so refunds applied at the same time each see
+// what the other clawed back.
+func (p *psql) Reverse(orderId uuid.UUID, total, refunded int64, at time.Time) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		var lot, userId uuid.UUID
+		var restaurantId *uuid.UUID
+		var points, remaining, clawed int64
+		err := tx.QueryRow(context.Background(), `SELECT id, user_id, restaurant_id, points, remaining
+			FROM "LoyaltyEntries" WHERE order_id = $1 AND kind = $2 FOR UPDATE`, orderId, loyaltyModel.Earn).
+			Scan(&lot, &userId, &restaurantId, &points, &remaining)
+		switch {
+		case err == pgx.ErrNoRows:
+		case err != nil:
+			return err
+		default:
+			err = tx.QueryRow(context.Background(), `SELECT coalesce(-sum(points), 0) FROM "LoyaltyEntries"
+				WHERE order_id = $1 AND kind = $2`, orderId, loyaltyModel.Clawback).Scan(&clawed)
+			if err != nil {
+				return err
+			}
+			take := points - loyaltyModel.Kept(points, total, refunded) - clawed
+			if take > remaining {
+				take = remaining
+			}
+			if take > 0 {
+				_, err = tx.Exec(context.Background(), `UPDATE "LoyaltyEntries" SET remaining = remaining - $2
+					WHERE id = $1`, lot, take)
+				if err != nil {
+					return err
+				}
+				_, err = tx.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "LoyaltyEntries" (%s)
+					VALUES ($1, $2, $3, $4, 0, $5, $6, NULL, NULL, $7)`, entryColumns), uuid.New(), userId,
+					loyaltyModel.Clawback, -take, orderId, restaurantId, at)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if refunded < total {
+			return nil
+		}
+
+		_, err = tx.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "LoyaltyEntries" (%s)
+			SELECT $1, user_id, $2, -points, -points, order_id, restaurant_id, reward_id, expires_at, $3
+			FROM "LoyaltyEntries" WHERE order_id = $4 AND kind = $5
+			ON CONFLICT (order_id, kind) WHERE order_id IS NOT NULL AND kind <> 'clawback' DO NOTHING`,
+			entryColumns), uuid.New(), loyaltyModel.Restore, at, orderId, loyaltyModel.Redeem)
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Reversing Loyalty Points: %v", err)
+	}
+	return err
+}
+
+func (p *psql) Expire(at time.Time) (int64, error) {
+	var expired int64
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		rows, err := tx.Query(context.Background(), `SELECT id, user_id, remaining FROM "LoyaltyEntries"
+			WHERE kind = ANY($1) AND remaining > 0 AND expires_at <= $2 FOR UPDATE`, loyaltyModel.Lots, at)
+		if err != nil {
+			return err
+		}
+		var lots, ids, users []uuid.UUID
+		var points []int64
+		for rows.Next() {
+			var lot, userId uuid.UUID
+			var remaining int64
+			err = rows.Scan(&lot, &userId, &remaining)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			lots, ids, users = append(lots, lot), append(ids, uuid.New()), append(users, userId)
+			points = append(points, -remaining)
+			expired += remaining
+		}
+		rows.Close()
+		if rows.Err() != nil || len(lots) == 0 {
+			return rows.Err()
+		}
+
+		_, err = tx.Exec(context.Background(), `UPDATE "LoyaltyEntries" SET remaining = 0 WHERE id = ANY($1)`, lots)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(context.Background(), `INSERT INTO "LoyaltyEntries" (id, user_id, kind, points, remaining,
+			created_at) SELECT id, user_id, $4, points, 0, $5 FROM unnest($1::uuid[], $2::uuid[], $3::bigint[])
+			AS t(id, user_id, points)`, ids, users, points, loyaltyModel.Expire, at)
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Expiring Loyalty Points: %v", err)
+		return 0, err
+	}
+	return expired, nil
+}
+
+func (p *psql) Balance(userId uuid.UUID, at, expiringBy time.Time) (*loyaltyModel.Balance, error) {
+	balance := &loyaltyModel.Balance{UserId: userId, ExpiringBy: expiringBy}
+	err := p.conn.QueryRow(context.Background(), `SELECT coalesce(sum(remaining), 0),
+		coalesce(sum(remaining) FILTER (WHERE expires_at < $4), 0) FROM "LoyaltyEntries"
+		WHERE user_id = $1 AND kind = ANY($2) AND remaining > 0 AND (expires_at IS NULL OR expires_at > $3)`,
+		userId, loyaltyModel.Lots, at, expiringBy).Scan(&balance.Points, &balance.Expiring)
+	if err != nil {
+		p.log.Errorf("Error Finding Loyalty Balance: %v", err)
+		return nil, err
+	}
+	return balance, nil
+}
+
+func (p *psql) FindEntries(userId uuid.UUID, limit, offset int) ([]loyaltyModel.Entry, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "LoyaltyEntries"
+		WHERE user_id = $1 ORDER BY created_at DESC, id LIMIT $2 OFFSET $3`, entryColumns), userId, limit, offset)
+	if err != nil {
+		p.log.Errorf("Error Finding Loyalty Entries: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []loyaltyModel.Entry{}
+	for rows.Next() {
+		var e loyaltyModel.Entry
+		err = rows.Scan(&e.Id, &e.UserId, &e.Kind, &e.Points, &e.Remaining, &e.OrderId, &e.RestaurantId,
+			&e.RewardId, &e.ExpiresAt, &e.CreatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Loyalty Entry: %v", err)
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (p *psql) findRule(condition string, args ...interface{}) (*loyaltyModel.EarnRule, error) {
+	var r loyaltyModel.EarnRule
+	err := p.conn.QueryRow(context.Background(), fmt.Sprintf(`SELECT %s FROM "LoyaltyRules" WHERE %s`,
+		ruleColumns, condition), args...).Scan(&r.Id, &r.RestaurantId, &r.BrandId, &r.Currency, &r.Points, &r.Per,
+		&r.ExpiryDays, &r.Active, &r.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, loyaltyRepo.ErrRuleNotFound
+	}
+	if err != nil {
+		p.log.Errorf("Error Finding Loyalty Rule: %v", err)
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (p *psql) findRewards(condition string, args ...interface{}) ([]loyaltyModel.Reward, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "LoyaltyRewards" WHERE %s`,
+		rewardColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Rewards: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	rewards := []loyaltyModel.Reward{}
+	for rows.Next() {
+		var r loyaltyModel.Reward
+		err = rows.Scan(&r.Id, &r.RestaurantId, &r.BrandId, &r.Name, &r.Cost, &r.Type, &r.Value, &r.Currency,
+			&r.FreeMenuId, &r.Active, &r.CreatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Reward: %v", err)
+			return nil, err
+		}
+		rewards = append(rewards, r)
+	}
+	return rewards, rows.Err()
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) loyaltyRepo.RepoInterface {
+	return &psql{log: log, conn: conn}
+}
@@ -0,0 +1,54 @@
+package loyaltyRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/loyaltyModel"
+	"time"
+)
+
+var (
+	ErrRuleNotFound   = errors.New("earn rule not found")
+	ErrRewardNotFound = errors.New("reward not found")
+	// ErrAlreadyRecorded means the order already has a ledger entry of that kind: it has
+	// earned its points, or a reward was already used on it.
+	ErrAlreadyRecorded = errors.New("order already has loyalty points recorded")
+)
+
+type RepoInterface interface {
+	// SaveRule replaces the rule of the rule's restaurant or brand.
+	SaveRule(rule *loyaltyModel.EarnRule) error
+	FindRestaurantRule(restaurantId uuid.UUID) (*loyaltyModel.EarnRule, error)
+	FindBrandRule(brandId uuid.UUID) (*loyaltyModel.EarnRule, error)
+
+	PersistReward(reward *loyaltyModel.Reward) error
+	UpdateReward(reward *loyaltyModel.Reward) error
+	FindReward(id uuid.UUID) (*loyaltyModel.Reward, error)
+	// FindRewards returns the active rewards usable at the restaurant: its own, its brand's
+	// when brandId is set, and those usable anywhere.
+	FindRewards(restaurantId uuid.UUID, brandId *uuid.UUID) ([]loyaltyModel.Reward, error)
+
+	// Earn adds an earn entry, failing with ErrAlreadyRecorded if its order already earned.
+	Earn(entry *loyaltyModel.Entry) error
+	// Spend adds a redeem entry in one transaction with taking its points from the user's
+	// lots, soonest expiring first. Lots expired by the entry's time do not count. It fails
+	// with loyaltyModel.ErrInsufficientPoints when they do not cover it, or ErrAlreadyRecorded
+	// if a reward was already used on the order. The entry expires with the first lot it took from.
+	Spend(entry *loyaltyModel.Entry) error
+	// FindRedeemed returns the redeem entry of the reward used on the order, or nil when none
+	// was used or its points were given back.
+	FindRedeemed(orderId uuid.UUID) (*loyaltyModel.Entry, error)
+	// Reverse brings the order's entries in line with refunded of its total having been
+	// refunded, in one transaction: what it earned beyond loyaltyModel.Kept is clawed back as
+	// far as it is unspent, and once it is refunded in full the points spent on its reward are
+	// restored, expiring when the redeem entry does. Running it again for the same amount
+	// changes nothing.
+	Reverse(orderId uuid.UUID, total, refunded int64, at time.Time) error
+	// Expire empties every lot that expired by at, writing an expire entry for each, and
+	// returns how many points expired.
+	Expire(at time.Time) (int64, error)
+	// Balance counts the points unexpired at at, and those of them expiring before expiringBy.
+	Balance(userId uuid.UUID, at, expiringBy time.Time) (*loyaltyModel.Balance, error)
+	// FindEntries returns the user's ledger, newest first.
+	FindEntries(userId uuid.UUID, limit, offset int) ([]loyaltyModel.Entry, error)
+}
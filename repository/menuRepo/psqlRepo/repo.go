@@ -0,0 +1,197 @@
+package psqlRepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/menuModel"
+	"rsm/repository/menuRepo"
+	"time"
+)
+
+const menuColumns = `id, restaurant_id, coalesce(code, ''), item, price, item_type, allergens, dietary, nutrition, servings, coalesce(image_ref, ''), created_at`
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) FindById(id int64) (*menuModel.MenuItem, error) {
+	items, err := p.find(`id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, menuRepo.ErrNotFound
+	}
+	return &items[0], nil
+}
+
+// FindByRestaurant filters allergens and dietary tags in the query with array operators:
+// when allergens are excluded an item is dropped if its allergens overlap them or were never
+// declared, and it is kept only if its tags contain every requested tag. Items a brand
+// location has switched off, and those sold out for the day, are left out.
+func (p *psql) FindByRestaurant(query menuModel.MenuQuery) ([]menuModel.MenuItem, error) {
+	return p.find(`restaurant_id = $1 AND available AND in_stock("Menu") AND ($2 = '' OR item_type = $2)
+		AND (coalesce(cardinality($3::varchar[]), 0) = 0 OR NOT allergens && $3::varchar[]) AND dietary @> $4::varchar[]
+		ORDER BY item_type, item, id`,
+		query.RestaurantId, query.ItemType, allergenNames(query.ExcludeAllergens), tagNames(query.Dietary))
+}
+
+func (p *psql) FindAll(restaurantId uuid.UUID) ([]menuModel.MenuItem, error) {
+	return p.find(`restaurant_id = $1 ORDER BY item_type, item, id`, restaurantId)
+}
+
+func (p *psql) UpdateDietaryInfo(id int64, info menuModel.DietaryInfo) error {
+	var nutrition []byte
+	if info.Nutrition != nil {
+		var err error
+		nutrition, err = json.Marshal(info.Nutrition)
+		if err != nil {
+			return err
+		}
+	}
+
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "Menu" SET allergens = $2, dietary = $3, nutrition = $4,
+		servings = $5 WHERE id = $1`, id, allergenNames(info.Allergens), tagNames(info.Dietary), nutrition, info.Servings)
+	if err != nil {
+		p.log.Errorf("Error Updating Dietary Info: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return menuRepo.ErrNotFound
+	}
+	return nil
+}
+
+func (p *psql) UpdateImage(id int64, previous, ref string) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "Menu" SET image_ref = NULLIF($3, '')
+		WHERE id = $1 AND coalesce(image_ref, '') = $2`, id, previous, ref)
+	if err != nil {
+		p.log.Errorf("Error Updating Menu Image: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		err = p.conn.QueryRow(context.Background(), `SELECT EXISTS (SELECT 1 FROM "Menu" WHERE id = $1)`,
+			id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return menuRepo.ErrNotFound
+		}
+		return menuRepo.ErrStaleImage
+	}
+	return nil
+}
+
+// Import upserts every row on the restaurant's item code in one transaction, so a failure
+// part way leaves the menu as it was. New items are stamped with at. A row without allergens
+// keeps the ones the item already declared.
+func (p *psql) Import(restaurantId uuid.UUID, rows []menuModel.TransferRow, at time.Time) (int, int, error) {
+	var created, updated int
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		for _, r := range rows {
+			var inserted bool
+			err := tx.QueryRow(context.Background(), `INSERT INTO "Menu"
+				(restaurant_id, code, item, price, item_type, allergens, dietary, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+				ON CONFLICT (restaurant_id, code) DO UPDATE SET item = EXCLUDED.item, price = EXCLUDED.price,
+				item_type = EXCLUDED.item_type, allergens = coalesce(EXCLUDED.allergens, "Menu".allergens),
+				dietary = EXCLUDED.dietary
+				RETURNING xmax = 0`,
+				restaurantId, r.Code, r.Item, r.Price, r.ItemType, allergenNames(r.Allergens), tagNames(r.Dietary),
+				at).Scan(&inserted)
+			if err != nil {
+				return fmt.Errorf("item %v: %w", r.Code, err)
+			}
+			if inserted {
+				created++
+			} else {
+				updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		p.log.Errorf("Error Importing Menu: %v", err)
+		return 0, 0, err
+	}
+	return created, updated, nil
+}
+
+func (p *psql) find(condition string, args ...interface{}) ([]menuModel.MenuItem, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "Menu" WHERE %s`, menuColumns,
+		condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Menu Items: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []menuModel.MenuItem{}
+	for rows.Next() {
+		var m menuModel.MenuItem
+		var allergens, dietary []string
+		var nutrition []byte
+		err = rows.Scan(&m.Id, &m.RestaurantId, &m.Code, &m.Item, &m.Price, &m.ItemType, &allergens, &dietary, &nutrition,
+			&m.Servings, &m.ImageRef, &m.CreatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Menu Item: %v", err)
+			return nil, err
+		}
+		m.Allergens = allergenList(allergens)
+		m.Dietary = make([]menuModel.DietaryTag, 0, len(dietary))
+		for _, d := range dietary {
+			m.Dietary = append(m.Dietary, menuModel.DietaryTag(d))
+		}
+		if nutrition != nil {
+			m.Nutrition = &menuModel.Nutrition{}
+			err = json.Unmarshal(nutrition, m.Nutrition)
+			if err != nil {
+				return nil, err
+			}
+		}
+		items = append(items, m)
+	}
+	return items, rows.Err()
+}
+
+// allergenNames keeps nil apart from empty, so undeclared allergens are stored as NULL.
+func allergenNames(allergens []menuModel.Allergen) []string {
+	if allergens == nil {
+		return nil
+	}
+	names := make([]string, 0, len(allergens))
+	for _, a := range allergens {
+		names = append(names, string(a))
+	}
+	return names
+}
+
+func allergenList(names []string) []menuModel.Allergen {
+	if names == nil {
+		return nil
+	}
+	allergens := make([]menuModel.Allergen, 0, len(names))
+	for _, a := range names {
+		allergens = append(allergens, menuModel.Allergen(a))
+	}
+	return allergens
+}
+
+func tagNames(tags []menuModel.DietaryTag) []string {
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, string(t))
+	}
+	return names
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) menuRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
@@ -0,0 +1,203 @@
+package psqlRepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"rsm/entity/menuModel"
+	"rsm/repository/menuRepo"
+	"time"
+)
+
+// Dates are read and written as text so a menu's date range stays a calendar date rather than
+// an instant in some time zone.
+const namedMenuColumns = `id, restaurant_id, name, hours, coalesce(to_char(starts_on, 'YYYY-MM-DD'), ''),
+	coalesce(to_char(ends_on, 'YYYY-MM-DD'), ''), created_at, updated_at`
+
+const versionColumns = `v.id, v.named_menu_id, v.number, v.status, v.items, v.effective_from, v.created_at,
+	v.published_at`
+
+func (p *psql) PersistMenu(menu *menuModel.NamedMenu) error {
+	hours, err := json.Marshal(menu.Hours)
+	if err != nil {
+		return err
+	}
+	_, err = p.conn.Exec(context.Background(), `INSERT INTO "NamedMenus"
+		(id, restaurant_id, name, hours, starts_on, ends_on, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5::text, '')::date, NULLIF($6::text, '')::date, $7, $8)`,
+		menu.Id, menu.RestaurantId, menu.Name, hours, menu.StartsOn, menu.EndsOn, menu.CreatedAt, menu.UpdatedAt)
+	if err != nil {
+		p.log.Errorf("Error Persisting Named Menu: %v", err)
+	}
+	return err
+}
+
+func (p *psql) UpdateMenu(menu *menuModel.NamedMenu) error {
+	hours, err := json.Marshal(menu.Hours)
+	if err != nil {
+		return err
+	}
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "NamedMenus" SET name = $3, hours = $4,
+		starts_on = NULLIF($5::text, '')::date, ends_on = NULLIF($6::text, '')::date, updated_at = $7
+		WHERE id = $1 AND restaurant_id = $2`,
+		menu.Id, menu.RestaurantId, menu.Name, hours, menu.StartsOn, menu.EndsOn, menu.UpdatedAt)
+	if err != nil {
+		p.log.Errorf("Error Updating Named Menu: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return menuRepo.ErrMenuNotFound
+	}
+	return nil
+}
+
+func (p *psql) FindMenu(id uuid.UUID) (*menuModel.NamedMenu, error) {
+	menus, err := p.findMenus(`id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(menus) == 0 {
+		return nil, menuRepo.ErrMenuNotFound
+	}
+	return &menus[0], nil
+}
+
+func (p *psql) FindMenus(restaurantId uuid.UUID) ([]menuModel.NamedMenu, error) {
+	return p.findMenus(`restaurant_id = $1 ORDER BY name, id`, restaurantId)
+}
+
+// SaveDraft numbers a new draft one past the menu's latest version. A menu has at most one
+// draft, so a save that finds one already there, such as a concurrent first save, rewrites it
+// and takes its id. The menu row is locked meanwhile so numbers are not handed out twice.
+// Re-saving a version that has since been published changes nothing and fails.
+func (p *psql) SaveDraft(version *menuModel.Version) error {
+	items, err := json.Marshal(version.Items)
+	if err != nil {
+		return err
+	}
+	err = p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), `SELECT 1 FROM "NamedMenus" WHERE id = $1 FOR UPDATE`,
+			version.NamedMenuId)
+		if err != nil {
+			return err
+		}
+		var published bool
+		err = tx.QueryRow(context.Background(), `SELECT EXISTS (SELECT 1 FROM "MenuVersions"
+			WHERE id = $1 AND status <> $2)`, version.Id, menuModel.Draft).Scan(&published)
+		if err != nil {
+			return err
+		}
+		if published {
+			return menuRepo.ErrVersionPublished
+		}
+		return tx.QueryRow(context.Background(), `INSERT INTO "MenuVersions"
+			(id, named_menu_id, number, status, items, created_at)
+			SELECT $1, $2, coalesce(max(number), 0) + 1, $3, $4, $5 FROM "MenuVersions" WHERE named_menu_id = $2
+			ON CONFLICT (named_menu_id) WHERE status = 'draft' DO UPDATE SET items = EXCLUDED.items
+			RETURNING id, number, created_at`,
+			version.Id, version.NamedMenuId, menuModel.Draft, items, version.CreatedAt).
+			Scan(&version.Id, &version.Number, &version.CreatedAt)
+	})
+	if err == menuRepo.ErrVersionPublished {
+		return err
+	}
+	if err != nil {
+		p.log.Errorf("Error Saving Menu Draft: %v", err)
+	}
+	return err
+}
+
+func (p *psql) Publish(id uuid.UUID, effectiveFrom, publishedAt time.Time) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "MenuVersions" SET status = $2, effective_from = $3,
+		published_at = $4 WHERE id = $1 AND status = $5`,
+		id, menuModel.Published, effectiveFrom, publishedAt, menuModel.Draft)
+	if err != nil {
+		p.log.Errorf("Error Publishing Menu Version: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return menuRepo.ErrVersionPublished
+	}
+	return nil
+}
+
+func (p *psql) FindVersion(id uuid.UUID) (*menuModel.Version, error) {
+	versions, err := p.findVersions(`v.id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, menuRepo.ErrVersionNotFound
+	}
+	return &versions[0], nil
+}
+
+func (p *psql) FindVersions(namedMenuId uuid.UUID) ([]menuModel.Version, error) {
+	return p.findVersions(`v.named_menu_id = $1 ORDER BY v.number`, namedMenuId)
+}
+
+func (p *psql) FindEffective(restaurantId uuid.UUID, at time.Time) ([]menuModel.Version, error) {
+	return p.findVersions(`v.id IN (SELECT DISTINCT ON (w.named_menu_id) w.id FROM "MenuVersions" w
+		JOIN "NamedMenus" n ON n.id = w.named_menu_id
+		WHERE n.restaurant_id = $1 AND w.status = $2 AND w.effective_from <= $3
+		ORDER BY w.named_menu_id, w.effective_from DESC, w.number DESC) ORDER BY v.named_menu_id`,
+		restaurantId, menuModel.Published, at)
+}
+
+func (p *psql) findMenus(condition string, args ...interface{}) ([]menuModel.NamedMenu, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "NamedMenus" WHERE %s`,
+		namedMenuColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Named Menus: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	menus := []menuModel.NamedMenu{}
+	for rows.Next() {
+		var m menuModel.NamedMenu
+		var hours []byte
+		err = rows.Scan(&m.Id, &m.RestaurantId, &m.Name, &hours, &m.StartsOn, &m.EndsOn, &m.CreatedAt,
+			&m.UpdatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Named Menu: %v", err)
+			return nil, err
+		}
+		err = json.Unmarshal(hours, &m.Hours)
+		if err != nil {
+			return nil, err
+		}
+		menus = append(menus, m)
+	}
+	return menus, rows.Err()
+}
+
+func (p *psql) findVersions(condition string, args ...interface{}) ([]menuModel.Version, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "MenuVersions" v WHERE %s`,
+		versionColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Menu Versions: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []menuModel.Version{}
+	for rows.Next() {
+		var v menuModel.Version
+		var items []byte
+		err = rows.Scan(&v.Id, &v.NamedMenuId, &v.Number, &v.Status, &items, &v.EffectiveFrom, &v.CreatedAt,
+			&v.PublishedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Menu Version: %v", err)
+			return nil, err
+		}
+		err = json.Unmarshal(items, &v.Items)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
@@ -0,0 +1,45 @@
+package menuRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/menuModel"
+	"time"
+)
+
+var (
+	ErrNotFound         = errors.New("menu item not found")
+	ErrMenuNotFound     = errors.New("named menu not found")
+	ErrVersionNotFound  = errors.New("menu version not found")
+	ErrVersionPublished = errors.New("menu version is already published")
+	// ErrStaleImage means the item's photo was replaced since it was read.
+	ErrStaleImage = errors.New("menu item image has changed")
+)
+
+type RepoInterface interface {
+	FindById(id int64) (*menuModel.MenuItem, error)
+	FindByRestaurant(query menuModel.MenuQuery) ([]menuModel.MenuItem, error)
+	// FindAll returns every item of the restaurant, including those switched off.
+	FindAll(restaurantId uuid.UUID) ([]menuModel.MenuItem, error)
+	UpdateDietaryInfo(id int64, info menuModel.DietaryInfo) error
+	// UpdateImage sets the blob key of the item's photo if it is still previous; an empty ref
+	// removes it. It fails with ErrStaleImage if the photo has changed.
+	UpdateImage(id int64, previous, ref string) error
+	// Import upserts the rows by item code and reports how many were created and updated.
+	Import(restaurantId uuid.UUID, rows []menuModel.TransferRow, at time.Time) (int, int, error)
+
+	PersistMenu(menu *menuModel.NamedMenu) error
+	UpdateMenu(menu *menuModel.NamedMenu) error
+	FindMenu(id uuid.UUID) (*menuModel.NamedMenu, error)
+	FindMenus(restaurantId uuid.UUID) ([]menuModel.NamedMenu, error)
+	// SaveDraft stores the version as the menu's draft, replacing the items of the draft already
+	// there if any, and sets the version's id and number to the stored draft's.
+	SaveDraft(version *menuModel.Version) error
+	// Publish fails with ErrVersionPublished unless the version is still a draft.
+	Publish(id uuid.UUID, effectiveFrom, publishedAt time.Time) error
+	FindVersion(id uuid.UUID) (*menuModel.Version, error)
+	FindVersions(namedMenuId uuid.UUID) ([]menuModel.Version, error)
+	// FindEffective returns, for each of the restaurant's named menus, the published version
+	// with the latest EffectiveFrom not after at.
+	FindEffective(restaurantId uuid.UUID, at time.Time) ([]menuModel.Version, error)
+}
@@ -0,0 +1,130 @@
+package psqlRepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/orderModel"
+	"rsm/repository/orderRepo"
+	"strings"
+	"time"
+)
+
+const orderColumns = `id, user_id, restaurant_id, status, currency, total, settled_at, created_at, updated_at`
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) FindById(id uuid.UUID) (*orderModel.Order, error) {
+	orders, err := p.find(`id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, orderRepo.ErrNotFound
+	}
+	return &orders[0], nil
+}
+
+// FindByRestaurant returns the restaurant's orders in the given states, oldest first.
+func (p *psql) FindByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error) {
+	return p.find(`restaurant_id = $1 AND status = ANY($2) ORDER BY created_at, id`, restaurantId, statuses)
+}
+
+// FindByUser returns the user's orders matching the query, newest first.
+func (p *psql) FindByUser(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error) {
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{fmt.Sprintf("user_id = %s", arg(userId))}
+	if query.RestaurantId != nil {
+		conditions = append(conditions, fmt.Sprintf("restaurant_id = %s", arg(*query.RestaurantId)))
+	}
+	if len(query.Statuses) > 0 {
+		conditions = append(conditions, fmt.Sprintf("status = ANY(%s)", arg(query.Statuses)))
+	}
+	if query.From != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= %s", arg(*query.From)))
+	}
+	if query.To != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at < %s", arg(*query.To)))
+	}
+	return p.find(fmt.Sprintf(`%s ORDER BY created_at DESC, id LIMIT %s OFFSET %s`,
+		strings.Join(conditions, " AND "), arg(query.Limit), arg(query.Offset)), args...)
+}
+
+// UpdateStatus moves the order only if it is still in the from state.
+func (p *psql) UpdateStatus(id uuid.UUID, from, to orderModel.Status) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "Orders" SET status = $3, updated_at = $4
+		WHERE id = $1 AND status = $2`, id, from, to, time.Now())
+	if err != nil {
+		p.log.Errorf("Error Updating Order Status: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return orderRepo.ErrStaleStatus
+	}
+	return nil
+}
+
+func (p *psql) find(condition string, args ...interface{}) ([]orderModel.Order, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "Orders" WHERE %s`,
+		orderColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Orders: %v", err)
+		return nil, err
+	}
+	var orders []orderModel.Order
+	index := map[uuid.UUID]int{}
+	for rows.Next() {
+		var o orderModel.Order
+		err = rows.Scan(&o.Id, &o.UserId, &o.RestaurantId, &o.Status, &o.Currency, &o.Total, &o.SettledAt,
+			&o.CreatedAt, &o.UpdatedAt)
+		if err != nil {
+			rows.Close()
+			p.log.Errorf("Error Scanning Order: %v", err)
+			return nil, err
+		}
+		index[o.Id] = len(orders)
+		orders = append(orders, o)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil || len(orders) == 0 {
+		return orders, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.Id)
+	}
+	rows, err = p.conn.Query(context.Background(), `SELECT id, order_id, menu_id, item, item_type, quantity,
+		unit_price FROM "OrderItems" WHERE order_id = ANY($1) ORDER BY id`, ids)
+	if err != nil {
+		p.log.Errorf("Error Finding Order Items: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i orderModel.OrderItem
+		err = rows.Scan(&i.Id, &i.OrderId, &i.MenuId, &i.Item, &i.ItemType, &i.Quantity, &i.UnitPrice)
+		if err != nil {
+			p.log.Errorf("Error Scanning Order Item: %v", err)
+			return nil, err
+		}
+		o := &orders[index[i.OrderId]]
+		o.Items = append(o.Items, i)
+	}
+	return orders, rows.Err()
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) orderRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
@@ -0,0 +1,20 @@
+package orderRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/orderModel"
+)
+
+var (
+	ErrNotFound = errors.New("order not found")
+	// ErrStaleStatus means the order moved on since it was read; reload and retry.
+	ErrStaleStatus = errors.New("order status changed concurrently")
+)
+
+type RepoInterface interface {
+	FindById(id uuid.UUID) (*orderModel.Order, error)
+	FindByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error)
+	FindByUser(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error)
+	UpdateStatus(id uuid.UUID, from, to orderModel.Status) error
+}
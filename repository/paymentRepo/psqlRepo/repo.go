@@ -0,0 +1,237 @@
+package psqlRepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/moneyModel"
+	"rsm/entity/paymentModel"
+	"rsm/repository/paymentRepo"
+	"time"
+)
+
+const paymentColumns = `id, order_id, user_id, idempotency_key, provider, provider_ref, status, currency,
+	amount, captured_amount, refunded_amount, failure_reason, created_at, updated_at`
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) Persist(payment *paymentModel.Payment) (*paymentModel.Payment, error) {
+	_, err := p.conn.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "Payments" (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`, paymentColumns),
+		payment.Id, payment.OrderId, payment.UserId, payment.IdempotencyKey, payment.Provider, payment.ProviderRef,
+		payment.Status, payment.Amount.Currency, payment.Amount.Amount, payment.CapturedAmount.Amount,
+		payment.RefundedAmount.Amount, payment.FailureReason, payment.CreatedAt, payment.UpdatedAt)
+	if err != nil {
+		p.log.Errorf("Error Persisting Payment: %v", err)
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (p *psql) FindById(id uuid.UUID) (*paymentModel.Payment, error) {
+	return p.findOne(`id = $1`, id)
+}
+
+func (p *psql) FindByIdempotencyKey(key string) (*paymentModel.Payment, error) {
+	return p.findOne(`idempotency_key = $1`, key)
+}
+
+func (p *psql) FindByProviderRef(provider, ref string) (*paymentModel.Payment, error) {
+	return p.findOne(`provider = $1 AND provider_ref = $2`, provider, ref)
+}
+
+func (p *psql) FindByOrder(orderId uuid.UUID) ([]paymentModel.Payment, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "Payments" WHERE order_id = $1
+		ORDER BY created_at`, paymentColumns), orderId)
+	if err != nil {
+		p.log.Errorf("Error Finding Payments By Order: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []paymentModel.Payment
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			p.log.Errorf("Error Scanning Payment: %v", err)
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+	return payments, rows.Err()
+}
+
+func (p *psql) findOne(condition string, args ...interface{}) (*paymentModel.Payment, error) {
+	stmt := fmt.Sprintf(`SELECT %s FROM "Payments" WHERE %s`, paymentColumns, condition)
+	payment, err := scanPayment(p.conn.QueryRow(context.Background(), stmt, args...))
+	if err == pgx.ErrNoRows {
+		return nil, paymentRepo.ErrNotFound
+	}
+	if err != nil {
+		p.log.Errorf("Error Finding Payment: %v", err)
+		return nil, err
+	}
+	return payment, nil
+}
+
+// Update writes the payment only if it is still in the expected status, so two concurrent
+// transitions from the same state cannot both succeed.
+func (p *psql) Update(payment *paymentModel.Payment, expected paymentModel.Status) error {
+	return p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		return update(tx, payment, expected)
+	})
+}
+
+func (p *psql) FindRefund(paymentId uuid.UUID, idempotencyKey string) (*paymentModel.Refund, error) {
+	var refund paymentModel.Refund
+	var currency string
+	var amount int64
+	err := p.conn.QueryRow(context.Background(), `SELECT id, payment_id, idempotency_key, provider_ref, currency,
+		amount, created_at FROM "PaymentRefunds" WHERE payment_id = $1 AND idempotency_key = $2`,
+		paymentId, idempotencyKey).Scan(&refund.Id, &refund.PaymentId, &refund.IdempotencyKey, &refund.ProviderRef,
+		&currency, &amount, &refund.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, paymentRepo.ErrNotFound
+	}
+	if err != nil {
+		p.log.Errorf("Error Finding Refund: %v", err)
+		return nil, err
+	}
+	refund.Amount = moneyModel.New(amount, currency)
+	return &refund, nil
+}
+
+func (p *psql) FindRefundsByOrder(orderId uuid.UUID) ([]paymentModel.Refund, error) {
+	rows, err := p.conn.Query(context.Background(), `SELECT r.id, r.payment_id, r.idempotency_key, r.provider_ref,
+		r.currency, r.amount, r.created_at FROM "PaymentRefunds" r JOIN "Payments" p ON p.id = r.payment_id
+		WHERE p.order_id = $1 ORDER BY r.created_at`, orderId)
+	if err != nil {
+		p.log.Errorf("Error Finding Refunds By Order: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []paymentModel.Refund{}
+	for rows.Next() {
+		var refund paymentModel.Refund
+		var currency string
+		var amount int64
+		err = rows.Scan(&refund.Id, &refund.PaymentId, &refund.IdempotencyKey, &refund.ProviderRef, &currency,
+			&amount, &refund.CreatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Refund: %v", err)
+			return nil, err
+		}
+		refund.Amount = moneyModel.New(amount, currency)
+		refunds = append(refunds, refund)
+	}
+	return refunds, rows.Err()
+}
+
+func (p *psql) ReserveRefund(paymentId uuid.UUID, amount int64, at time.Time) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "Payments" SET refunded_amount = refunded_amount + $2,
+		status = CASE WHEN refunded_amount + $2 = captured_amount THEN $3 ELSE $4 END, updated_at = $5
+		WHERE id = $1 AND status = ANY($6) AND refunded_amount + $2 <= captured_amount`, paymentId, amount,
+		paymentModel.Refunded, paymentModel.PartiallyRefunded, at,
+		[]paymentModel.Status{paymentModel.Captured, paymentModel.PartiallyRefunded})
+	if err != nil {
+		p.log.Errorf("Error Reserving Refund: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return paymentRepo.ErrNotRefundable
+	}
+	return nil
+}
+
+func (p *psql) ReleaseRefund(paymentId uuid.UUID, amount int64, at time.Time) error {
+	_, err := p.conn.Exec(context.Background(), `UPDATE "Payments" SET refunded_amount = refunded_amount - $2,
+		status = CASE WHEN refunded_amount = $2 THEN $3 ELSE $4 END, updated_at = $5
+		WHERE id = $1 AND refunded_amount >= $2`, paymentId, amount, paymentModel.Captured,
+		paymentModel.PartiallyRefunded, at)
+	if err != nil {
+		p.log.Errorf("Error Releasing Refund: %v", err)
+	}
+	return err
+}
+
+func (p *psql) RecordRefund(refund *paymentModel.Refund) error {
+	tag, err := p.conn.Exec(context.Background(), `INSERT INTO "PaymentRefunds"
+		(id, payment_id, idempotency_key, provider_ref, currency, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (payment_id, idempotency_key) DO NOTHING`, refund.Id,
+		refund.PaymentId, refund.IdempotencyKey, refund.ProviderRef, refund.Amount.Currency, refund.Amount.Amount,
+		refund.CreatedAt)
+	if err != nil {
+		p.log.Errorf("Error Recording Refund: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return paymentRepo.ErrDuplicateRefund
+	}
+	return nil
+}
+
+func (p *psql) RecordEvent(eventId string, eventType string, payment *paymentModel.Payment, updated bool, expected paymentModel.Status) (bool, error) {
+	var recorded bool
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(), `INSERT INTO "PaymentEvents" (id, payment_id, type, received_at)
+			VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO NOTHING`, eventId, payment.Id, eventType, time.Now())
+		if err != nil {
+			return err
+		}
+		recorded = tag.RowsAffected() == 1
+		if !recorded || !updated {
+			return nil
+		}
+		return update(tx, payment, expected)
+	})
+	if err != nil {
+		p.log.Errorf("Error Recording Payment Event: %v", err)
+		return false, err
+	}
+	return recorded, nil
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+}
+
+func update(db execer, payment *paymentModel.Payment, expected paymentModel.Status) error {
+	tag, err := db.Exec(context.Background(), `UPDATE "Payments" SET status = $3, provider_ref = $4,
+		captured_amount = $5, refunded_amount = $6, failure_reason = $7, updated_at = $8
+		WHERE id = $1 AND status = $2`, payment.Id, expected, payment.Status, payment.ProviderRef,
+		payment.CapturedAmount.Amount, payment.RefundedAmount.Amount, payment.FailureReason, payment.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return paymentRepo.ErrStaleStatus
+	}
+	return nil
+}
+
+func scanPayment(row pgx.Row) (*paymentModel.Payment, error) {
+	var payment paymentModel.Payment
+	var currency string
+	var amount, captured, refunded int64
+	err := row.Scan(&payment.Id, &payment.OrderId, &payment.UserId, &payment.IdempotencyKey, &payment.Provider,
+		&payment.ProviderRef, &payment.Status, &currency, &amount, &captured, &refunded, &payment.FailureReason,
+		&payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	payment.Amount = moneyModel.New(amount, currency)
+	payment.CapturedAmount = moneyModel.New(captured, currency)
+	payment.RefundedAmount = moneyModel.New(refunded, currency)
+	return &payment, nil
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) paymentRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
@@ -0,0 +1,40 @@
+package paymentRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/paymentModel"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("payment not found")
+	// ErrStaleStatus means the payment moved on since it was read; reload and retry.
+	ErrStaleStatus = errors.New("payment status changed concurrently")
+	// ErrNotRefundable means the payment is not captured or has less left to refund.
+	ErrNotRefundable = errors.New("refund exceeds the refundable amount")
+	// ErrDuplicateRefund means a refund with the same idempotency key was recorded first.
+	ErrDuplicateRefund = errors.New("refund already recorded")
+)
+
+type RepoInterface interface {
+	Persist(payment *paymentModel.Payment) (*paymentModel.Payment, error)
+	FindById(id uuid.UUID) (*paymentModel.Payment, error)
+	FindByIdempotencyKey(key string) (*paymentModel.Payment, error)
+	FindByProviderRef(provider, ref string) (*paymentModel.Payment, error)
+	FindByOrder(orderId uuid.UUID) ([]paymentModel.Payment, error)
+	Update(payment *paymentModel.Payment, expected paymentModel.Status) error
+	FindRefund(paymentId uuid.UUID, idempotencyKey string) (*paymentModel.Refund, error)
+	// ReserveRefund adds the amount to what the payment has refunded before the provider is
+	// asked to refund it, so concurrent refunds can never together refund more than was
+	// captured. ReleaseRefund takes it off again when the provider refund fails.
+	ReserveRefund(paymentId uuid.UUID, amount int64, at time.Time) error
+	ReleaseRefund(paymentId uuid.UUID, amount int64, at time.Time) error
+	// RecordRefund stores a refund whose amount was reserved.
+	RecordRefund(refund *paymentModel.Refund) error
+	FindRefundsByOrder(orderId uuid.UUID) ([]paymentModel.Refund, error)
+	// RecordEvent stores a webhook event together with the payment update it caused, if
+	// any, in one transaction. It reports false and changes nothing when the event was
+	// already recorded.
+	RecordEvent(eventId string, eventType string, payment *paymentModel.Payment, updated bool, expected paymentModel.Status) (bool, error)
+}
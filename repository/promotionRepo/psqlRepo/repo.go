@@ -0,0 +1,137 @@
+package psqlRepo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/promotionModel"
+	"rsm/repository/promotionRepo"
+	"time"
+)
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) Persist(promotion *promotionModel.Promotion) (*promotionModel.Promotion, error) {
+	_, err := p.conn.Exec(context.Background(), `INSERT INTO "Promotions" (id, code, name, type, value, currency,
+		free_menu_id, min_order_value, restaurant_id, item_type, starts_at, ends_at, max_redemptions, max_per_user,
+		stackable, priority, active, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`,
+		promotion.Id, promotion.Code, promotion.Name, promotion.Type, promotion.Value, promotion.Currency,
+		promotion.FreeMenuId, promotion.MinOrderValue, promotion.RestaurantId, promotion.ItemType,
+		promotion.StartsAt, promotion.EndsAt, promotion.MaxRedemptions, promotion.MaxPerUser, promotion.Stackable,
+		promotion.Priority, promotion.Active, promotion.CreatedAt)
+	if err != nil {
+		p.log.Errorf("Error Persisting Promotion: %v", err)
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// FindApplicable returns active promotions valid at the given time for the restaurant: every
+// automatic promotion plus those matching one of the codes. Promotions that have hit their
+// global redemption limit are left out.
+func (p *psql) FindApplicable(restaurantId uuid.UUID, codes []string, at time.Time) ([]promotionModel.Promotion, error) {
+	if codes == nil {
+		codes = []string{}
+	}
+	rows, err := p.conn.Query(context.Background(), `SELECT id, code, name, type, value, currency, free_menu_id,
+		min_order_value, restaurant_id, item_type, starts_at, ends_at, max_redemptions, max_per_user, stackable,
+		priority, active, created_at
+		FROM "Promotions" pr
+		WHERE pr.active AND pr.starts_at <= $2 AND pr.ends_at > $2
+		AND (pr.restaurant_id IS NULL OR pr.restaurant_id = $1)
+		AND (pr.code = '' OR pr.code = ANY($3))
+		AND (pr.max_redemptions = 0 OR
+			(SELECT count(*) FROM "PromotionRedemptions" r WHERE r.promotion_id = pr.id) < pr.max_redemptions)`,
+		restaurantId, at, codes)
+	if err != nil {
+		p.log.Errorf("Error Finding Promotions: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []promotionModel.Promotion
+	for rows.Next() {
+		var pr promotionModel.Promotion
+		err = rows.Scan(&pr.Id, &pr.Code, &pr.Name, &pr.Type, &pr.Value, &pr.Currency, &pr.FreeMenuId,
+			&pr.MinOrderValue, &pr.RestaurantId, &pr.ItemType, &pr.StartsAt, &pr.EndsAt, &pr.MaxRedemptions,
+			&pr.MaxPerUser, &pr.Stackable, &pr.Priority, &pr.Active, &pr.CreatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Promotion: %v", err)
+			return nil, err
+		}
+		promotions = append(promotions, pr)
+	}
+	return promotions, rows.Err()
+}
+
+func (p *psql) CountUserRedemptions(userId uuid.UUID, promotionIds []uuid.UUID) (map[uuid.UUID]int, error) {
+	rows, err := p.conn.Query(context.Background(), `SELECT promotion_id, count(*) FROM "PromotionRedemptions"
+		WHERE user_id = $1 AND promotion_id = ANY($2) GROUP BY promotion_id`, userId, promotionIds)
+	if err != nil {
+		p.log.Errorf("Error Counting Redemptions: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[uuid.UUID]int{}
+	for rows.Next() {
+		var id uuid.UUID
+		var count int
+		if err = rows.Scan(&id, &count); err != nil {
+			p.log.Errorf("Error Scanning Redemption Count: %v", err)
+			return nil, err
+		}
+		counts[id] = count
+	}
+	return counts, rows.Err()
+}
+
+// Redeem records all redemptions for an order in one transaction. Each promotion row is locked
+// while its limits are checked, so concurrent checkouts cannot push it past either limit.
+// Redeeming the same promotion for the same order again is a no-op.
+func (p *psql) Redeem(redemptions []promotionModel.Redemption) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		for _, r := range redemptions {
+			var maxRedemptions, maxPerUser, total, perUser int
+			var already bool
+			err := tx.QueryRow(context.Background(), `SELECT max_redemptions, max_per_user FROM "Promotions"
+				WHERE id = $1 FOR UPDATE`, r.PromotionId).Scan(&maxRedemptions, &maxPerUser)
+			if err != nil {
+				return err
+			}
+			err = tx.QueryRow(context.Background(), `SELECT count(*), count(*) FILTER (WHERE user_id = $2),
+				coalesce(bool_or(order_id = $3), false)
+				FROM "PromotionRedemptions" WHERE promotion_id = $1`, r.PromotionId, r.UserId, r.OrderId).
+				Scan(&total, &perUser, &already)
+			if err != nil {
+				return err
+			}
+			if already {
+				continue
+			}
+			if (maxRedemptions > 0 && total >= maxRedemptions) || (maxPerUser > 0 && perUser >= maxPerUser) {
+				return promotionRepo.ErrLimitReached
+			}
+			_, err = tx.Exec(context.Background(), `INSERT INTO "PromotionRedemptions"
+				(promotion_id, user_id, order_id, amount, currency, created_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+				r.PromotionId, r.UserId, r.OrderId, r.Amount, r.Currency, r.CreatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		p.log.Errorf("Error Redeeming Promotions: %v", err)
+	}
+	return err
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) promotionRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
@@ -0,0 +1,17 @@
+package promotionRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/promotionModel"
+	"time"
+)
+
+var ErrLimitReached = errors.New("promotion redemption limit reached")
+
+type RepoInterface interface {
+	Persist(promotion *promotionModel.Promotion) (*promotionModel.Promotion, error)
+	FindApplicable(restaurantId uuid.UUID, codes []string, at time.Time) ([]promotionModel.Promotion, error)
+	CountUserRedemptions(userId uuid.UUID, promotionIds []uuid.UUID) (map[uuid.UUID]int, error)
+	Redeem(redemptions []promotionModel.Redemption) error
+}
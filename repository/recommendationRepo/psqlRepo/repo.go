@@ -0,0 +1,180 @@
+package psqlRepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/menuModel"
+	"rsm/entity/orderModel"
+	"rsm/entity/recommendationModel"
+	"rsm/repository/recommendationRepo"
+	"time"
+)
+
+// listed keeps items that can be ordered now: available, at an active restaurant that is open
+// at $2 on its own wall clock, and at $3 when that is set.
+const listed = `m.available AND s.status AND ($3::uuid IS NULL OR m.restaurant_id = $3)
+	AND EXISTS (SELECT 1 FROM "RestaurantHours" h, LATERAL (SELECT $2::timestamptz AT TIME ZONE s.timezone AS t) l
+		WHERE h.restaurant_id = s.id AND h.day_of_week = extract(dow FROM l.t)
+		AND h.opens_at <= extract(hour FROM l.t) * 60 + extract(minute FROM l.t)
+		AND h.closes_at > extract(hour FROM l.t) * 60 + extract(minute FROM l.t))`
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) FindPurchases(since time.Time) ([]recommendationModel.Purchase, error) {
+	rows, err := p.conn.Query(context.Background(), `SELECT o.user_id, o.id, o.restaurant_id, i.menu_id,
+		m.item_type, m.dietary FROM "Orders" o JOIN "OrderItems" i ON i.order_id = o.id JOIN "Menu" m ON m.id = i.menu_id
+		WHERE o.status = $1 AND o.updated_at >= $2`, orderModel.Completed, since)
+	if err != nil {
+		p.log.Errorf("Error Finding Purchases: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	purchases := []recommendationModel.Purchase{}
+	for rows.Next() {
+		var r recommendationModel.Purchase
+		var dietary []string
+		err = rows.Scan(&r.UserId, &r.OrderId, &r.RestaurantId, &r.MenuId, &r.ItemType, &dietary)
+		if err != nil {
+			p.log.Errorf("Error Scanning Purchase: %v", err)
+			return nil, err
+		}
+		r.Dietary = tags(dietary)
+		purchases = append(purchases, r)
+	}
+	return purchases, rows.Err()
+}
+
+func (p *psql) FindItems() ([]recommendationModel.Item, error) {
+	rows, err := p.conn.Query(context.Background(), `SELECT id, restaurant_id, item_type, dietary FROM "Menu"
+		WHERE available ORDER BY id`)
+	if err != nil {
+		p.log.Errorf("Error Finding Recommendable Items: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []recommendationModel.Item{}
+	for rows.Next() {
+		var i recommendationModel.Item
+		var dietary []string
+		err = rows.Scan(&i.MenuId, &i.RestaurantId, &i.ItemType, &dietary)
+		if err != nil {
+			p.log.Errorf("Error Scanning Recommendable Item: %v", err)
+			return nil, err
+		}
+		i.Dietary = tags(dietary)
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// Replace writes the batch with one statement per table, the users' scores flattened into
+// arrays in rank order.
+func (p *psql) Replace(batch recommendationModel.Batch, at time.Time) error {
+	var userIds []uuid.UUID
+	var menuIds, ranks []int64
+	var scores []float64
+	var reasons []string
+	for userId, ranked := range batch.Users {
+		for rank, s := range ranked {
+			userIds = append(userIds, userId)
+			menuIds = append(menuIds, s.MenuId)
+			scores = append(scores, s.Score)
+			reasons = append(reasons, string(s.Reason))
+			ranks = append(ranks, int64(rank+1))
+		}
+	}
+	var popularIds, popularRanks []int64
+	var popularScores []float64
+	for _, ranked := range batch.Popular {
+		for rank, s := range ranked {
+			popularIds = append(popularIds, s.MenuId)
+			popularScores = append(popularScores, s.Score)
+			popularRanks = append(popularRanks, int64(rank+1))
+		}
+	}
+
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), `DELETE FROM "Recommendations"`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(context.Background(), `INSERT INTO "Recommendations" (user_id, menu_id, score, reason, rank,
+			computed_at) SELECT user_id, menu_id, score, reason, rank, $6
+			FROM unnest($1::uuid[], $2::bigint[], $3::float8[], $4::varchar[], $5::bigint[])
+			AS t(user_id, menu_id, score, reason, rank)`, userIds, menuIds, scores, reasons, ranks, at)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(context.Background(), `DELETE FROM "PopularItems"`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(context.Background(), `INSERT INTO "PopularItems" (menu_id, score, rank, computed_at)
+			SELECT menu_id, score, rank, $4 FROM unnest($1::bigint[], $2::float8[], $3::bigint[])
+			AS t(menu_id, score, rank)`, popularIds, popularScores, popularRanks, at)
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Replacing Recommendations: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindForUser(userId uuid.UUID, restaurantId *uuid.UUID, at time.Time, limit int) ([]recommendationModel.Recommendation, error) {
+	return p.find(fmt.Sprintf(`SELECT m.id, m.restaurant_id, m.item, m.item_type, m.price, coalesce(m.image_ref, ''),
+		r.score, r.reason FROM "Recommendations" r JOIN "Menu" m ON m.id = r.menu_id
+		JOIN "Restaurants" s ON s.id = m.restaurant_id WHERE r.user_id = $1 AND %s ORDER BY r.rank LIMIT $4`, listed),
+		userId, at, restaurantId, limit)
+}
+
+// FindPopular ranks are per restaurant, so without a restaurant every open restaurant's
+// favourite comes before any restaurant's second.
+func (p *psql) FindPopular(userId uuid.UUID, restaurantId *uuid.UUID, at time.Time, limit int) ([]recommendationModel.Recommendation, error) {
+	return p.find(fmt.Sprintf(`SELECT m.id, m.restaurant_id, m.item, m.item_type, m.price, coalesce(m.image_ref, ''),
+		r.score, $5::varchar FROM "PopularItems" r JOIN "Menu" m ON m.id = r.menu_id
+		JOIN "Restaurants" s ON s.id = m.restaurant_id WHERE %s AND NOT EXISTS (SELECT 1 FROM "Orders" o
+		JOIN "OrderItems" i ON i.order_id = o.id WHERE o.user_id = $1 AND i.menu_id = m.id AND o.status <> $6)
+		ORDER BY r.rank, r.score DESC, m.id LIMIT $4`, listed),
+		userId, at, restaurantId, limit, recommendationModel.Popular, orderModel.Cancelled)
+}
+
+func (p *psql) find(query string, args ...interface{}) ([]recommendationModel.Recommendation, error) {
+	rows, err := p.conn.Query(context.Background(), query, args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Recommendations: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	recommendations := []recommendationModel.Recommendation{}
+	for rows.Next() {
+		var r recommendationModel.Recommendation
+		err = rows.Scan(&r.MenuId, &r.RestaurantId, &r.Item, &r.ItemType, &r.Price, &r.ImageRef, &r.Score, &r.Reason)
+		if err != nil {
+			p.log.Errorf("Error Scanning Recommendation: %v", err)
+			return nil, err
+		}
+		recommendations = append(recommendations, r)
+	}
+	return recommendations, rows.Err()
+}
+
+func tags(names []string) []menuModel.DietaryTag {
+	dietary := make([]menuModel.DietaryTag, 0, len(names))
+	for _, name := range names {
+		dietary = append(dietary, menuModel.DietaryTag(name))
+	}
+	return dietary
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) recommendationRepo.RepoInterface {
+	return &psql{log: log, conn: conn}
+}
@@ -0,0 +1,24 @@
+package recommendationRepo
+
+import (
+	"github.com/google/uuid"
+	"rsm/entity/recommendationModel"
+	"time"
+)
+
+type RepoInterface interface {
+	// FindPurchases returns the lines of orders completed since the given time. Guest orders
+	// have a nil user id.
+	FindPurchases(since time.Time) ([]recommendationModel.Purchase, error)
+	// FindItems returns every menu item that can be ordered now.
+	FindItems() ([]recommendationModel.Item, error)
+	// Replace swaps every stored recommendation for the batch in one transaction.
+	Replace(batch recommendationModel.Batch, at time.Time) error
+	// FindForUser and FindPopular return stored recommendations best first, only at
+	// restaurantId when it is set. Items that stopped being available since they were
+	// computed, and those of restaurants that are inactive or closed at at, are left out.
+	FindForUser(userId uuid.UUID, restaurantId *uuid.UUID, at time.Time, limit int) ([]recommendationModel.Recommendation, error)
+	// FindPopular takes each restaurant's most popular items before its next ones, and leaves
+	// out items the user has ordered.
+	FindPopular(userId uuid.UUID, restaurantId *uuid.UUID, at time.Time, limit int) ([]recommendationModel.Recommendation, error)
+}
@@ -0,0 +1,188 @@
+package psqlRepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/restaurantModel"
+	"rsm/location/geoUtils"
+	"rsm/repository/restaurantRepo"
+	"strings"
+)
+
+const restaurantColumns = `r.id, coalesce(r.owner_id, '00000000-0000-0000-0000-000000000000') AS owner_id, r.name, coalesce(r.location, '') AS location,
+	coalesce(r.description, '') AS description, coalesce(r.cuisine, '') AS cuisine,
+	r.latitude, r.longitude, r.timezone, r.status, r.created_at, coalesce(r.image_ref, '') AS image_ref, r.brand_id`
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) Persist(restaurant *restaurantModel.RestaurantModel) (*restaurantModel.RestaurantModel, error) {
+	persistStmt := `INSERT INTO "Restaurants" (id, owner_id, name, location, description, cuisine, latitude, longitude, timezone, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := p.conn.Exec(context.Background(), persistStmt, restaurant.Id, restaurant.OwnerId, restaurant.Name, restaurant.Location,
+		restaurant.Description, restaurant.Cuisine, restaurant.Latitude, restaurant.Longitude, restaurant.Timezone,
+		restaurant.Status, restaurant.CreatedAt)
+	if err != nil {
+		p.log.Errorf("Error Persisting Restaurant: %v", err)
+		return nil, err
+	}
+	return restaurant, nil
+}
+
+func (p *psql) FindById(id uuid.UUID) (*restaurantModel.RestaurantModel, error) {
+	var restaurant restaurantModel.RestaurantModel
+	findByIdStmt := fmt.Sprintf(`SELECT %s FROM "Restaurants" r WHERE r.id = $1`, restaurantColumns)
+	err := p.conn.QueryRow(context.Background(), findByIdStmt, id).Scan(&restaurant.Id, &restaurant.OwnerId, &restaurant.Name,
+		&restaurant.Location, &restaurant.Description, &restaurant.Cuisine, &restaurant.Latitude,
+		&restaurant.Longitude, &restaurant.Timezone, &restaurant.Status, &restaurant.CreatedAt, &restaurant.ImageRef,
+		&restaurant.BrandId)
+	if err != nil {
+		p.log.Errorf("Error Finding Restaurant By Id: %v", err)
+		return nil, err
+	}
+	return &restaurant, nil
+}
+
+func (p *psql) UpdateCoordinates(id uuid.UUID, latitude, longitude float64) error {
+	updateStmt := `UPDATE "Restaurants" SET latitude = $2, longitude = $3 WHERE id = $1`
+	tag, err := p.conn.Exec(context.Background(), updateStmt, id, latitude, longitude)
+	if err != nil {
+		p.log.Errorf("Error Updating Restaurant Coordinates: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("restaurant not found")
+	}
+	return nil
+}
+
+func (p *psql) UpdateTimezone(id uuid.UUID, timezone string) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "Restaurants" SET timezone = $2 WHERE id = $1`, id, timezone)
+	if err != nil {
+		p.log.Errorf("Error Updating Restaurant Timezone: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("restaurant not found")
+	}
+	return nil
+}
+
+// UpdateImage reports a missing restaurant with pgx.ErrNoRows, as FindById does.
+func (p *psql) UpdateImage(id uuid.UUID, previous, ref string) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "Restaurants" SET image_ref = NULLIF($3, '')
+		WHERE id = $1 AND coalesce(image_ref, '') = $2`, id, previous, ref)
+	if err != nil {
+		p.log.Errorf("Error Updating Restaurant Image: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		err = p.conn.QueryRow(context.Background(), `SELECT EXISTS (SELECT 1 FROM "Restaurants" WHERE id = $1)`,
+			id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return pgx.ErrNoRows
+		}
+		return restaurantRepo.ErrStaleImage
+	}
+	return nil
+}
+
+func (p *psql) ReplaceOpeningHours(id uuid.UUID, hours []restaurantModel.OpeningHours) error {
+	return p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), `DELETE FROM "RestaurantHours" WHERE restaurant_id = $1`, id)
+		if err != nil {
+			p.log.Errorf("Error Clearing Opening Hours: %v", err)
+			return err
+		}
+		for _, h := range hours {
+			_, err = tx.Exec(context.Background(),
+				`INSERT INTO "RestaurantHours" (restaurant_id, day_of_week, opens_at, closes_at) VALUES ($1, $2, $3, $4)`,
+				id, int(h.DayOfWeek), h.OpensAt, h.ClosesAt)
+			if err != nil {
+				p.log.Errorf("Error Inserting Opening Hours: %v", err)
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindNearby orders active restaurants by haversine distance from the query centre. The
+// optional bounding box lets Postgres use the coordinates index before computing distances.
+func (p *psql) FindNearby(query restaurantModel.NearbyQuery) ([]restaurantModel.NearbyRestaurant, error) {
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	lat, lng := arg(query.Center.Latitude), arg(query.Center.Longitude)
+	distance := fmt.Sprintf(`2 * %v * asin(least(1, sqrt(
+		power(sin(radians(r.latitude - %s) / 2), 2) +
+		cos(radians(%s)) * cos(radians(r.latitude)) * power(sin(radians(r.longitude - %s) / 2), 2))))`,
+		geoUtils.EarthRadiusKm, lat, lat, lng)
+
+	conditions := []string{"r.status", "r.latitude IS NOT NULL", "r.longitude IS NOT NULL"}
+	if box := query.BoundingBox; box != nil {
+		conditions = append(conditions,
+			fmt.Sprintf("r.latitude BETWEEN %s AND %s", arg(box.MinLatitude), arg(box.MaxLatitude)),
+			fmt.Sprintf("r.longitude BETWEEN %s AND %s", arg(box.MinLongitude), arg(box.MaxLongitude)))
+	}
+	if query.Cuisine != "" {
+		conditions = append(conditions, fmt.Sprintf("lower(r.cuisine) = lower(%s)", arg(query.Cuisine)))
+	}
+	if query.OpenAt != nil {
+		// Each restaurant's hours are read on its own wall clock.
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM "RestaurantHours" h,
+			LATERAL (SELECT %s::timestamptz AT TIME ZONE r.timezone AS t) l
+			WHERE h.restaurant_id = r.id AND h.day_of_week = extract(dow FROM l.t)
+			AND h.opens_at <= extract(hour FROM l.t) * 60 + extract(minute FROM l.t)
+			AND h.closes_at > extract(hour FROM l.t) * 60 + extract(minute FROM l.t))`, arg(*query.OpenAt)))
+	}
+
+	radius := ""
+	if query.RadiusKm > 0 {
+		radius = fmt.Sprintf("WHERE nearby.distance_km <= %s", arg(query.RadiusKm))
+	}
+
+	findNearbyStmt := fmt.Sprintf(`SELECT * FROM (
+		SELECT %s, %s AS distance_km FROM "Restaurants" r WHERE %s
+	) nearby %s ORDER BY nearby.distance_km, nearby.id LIMIT %s OFFSET %s`,
+		restaurantColumns, distance, strings.Join(conditions, " AND "), radius,
+		arg(query.Limit), arg(query.Offset))
+
+	rows, err := p.conn.Query(context.Background(), findNearbyStmt, args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Nearby Restaurants: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []restaurantModel.NearbyRestaurant
+	for rows.Next() {
+		var n restaurantModel.NearbyRestaurant
+		r := &n.Restaurant
+		err = rows.Scan(&r.Id, &r.OwnerId, &r.Name, &r.Location, &r.Description, &r.Cuisine, &r.Latitude, &r.Longitude,
+			&r.Timezone, &r.Status, &r.CreatedAt, &r.ImageRef, &r.BrandId, &n.DistanceKm)
+		if err != nil {
+			p.log.Errorf("Error Scanning Nearby Restaurant: %v", err)
+			return nil, err
+		}
+		results = append(results, n)
+	}
+	return results, rows.Err()
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) restaurantRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
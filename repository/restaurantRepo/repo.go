@@ -0,0 +1,22 @@
+package restaurantRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/restaurantModel"
+)
+
+// ErrStaleImage means the restaurant's photo was replaced since it was read.
+var ErrStaleImage = errors.New("restaurant image has changed")
+
+type RepoInterface interface {
+	Persist(restaurant *restaurantModel.RestaurantModel) (*restaurantModel.RestaurantModel, error)
+	FindById(id uuid.UUID) (*restaurantModel.RestaurantModel, error)
+	UpdateCoordinates(id uuid.UUID, latitude, longitude float64) error
+	UpdateTimezone(id uuid.UUID, timezone string) error
+	// UpdateImage sets the blob key of the restaurant's photo if it is still previous; an empty
+	// ref removes it. It fails with ErrStaleImage if the photo has changed.
+	UpdateImage(id uuid.UUID, previous, ref string) error
+	ReplaceOpeningHours(id uuid.UUID, hours []restaurantModel.OpeningHours) error
+	FindNearby(query restaurantModel.NearbyQuery) ([]restaurantModel.NearbyRestaurant, error)
+}
@@ -0,0 +1,231 @@
+package psqlRepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/orderModel"
+	"rsm/entity/reviewModel"
+	"rsm/repository/reviewRepo"
+	"time"
+)
+
+const reviewColumns = `id, user_id, order_id, restaurant_id, menu_id, rating, comment, status,
+	reply_owner_id, reply_body, reply_created_at, created_at, updated_at`
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) HasCompletedOrder(userId, orderId, restaurantId uuid.UUID, menuId *int64) (bool, error) {
+	var ok bool
+	stmt := `SELECT EXISTS (SELECT 1 FROM "Orders" o
+		WHERE o.id = $1 AND o.user_id = $2 AND o.restaurant_id = $3 AND o.status = $4
+		AND ($5::bigint IS NULL OR EXISTS (SELECT 1 FROM "OrderItems" i WHERE i.order_id = o.id AND i.menu_id = $5)))`
+	err := p.conn.QueryRow(context.Background(), stmt, orderId, userId, restaurantId, orderModel.Completed, menuId).
+		Scan(&ok)
+	if err != nil {
+		p.log.Errorf("Error Checking Completed Order: %v", err)
+		return false, err
+	}
+	return ok, nil
+}
+
+func (p *psql) Persist(review *reviewModel.Review) (*reviewModel.Review, error) {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), `INSERT INTO "Reviews"
+			(id, user_id, order_id, restaurant_id, menu_id, rating, comment, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			review.Id, review.UserId, review.OrderId, review.RestaurantId, review.MenuId, review.Rating,
+			review.Comment, review.Status, review.CreatedAt, review.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if review.Status.CountsTowardsRating() {
+			return adjustRating(tx, review, 1)
+		}
+		return nil
+	})
+	if err != nil {
+		p.log.Errorf("Error Persisting Review: %v", err)
+		return nil, err
+	}
+	return review, nil
+}
+
+func (p *psql) FindById(id uuid.UUID) (*reviewModel.Review, error) {
+	stmt := fmt.Sprintf(`SELECT %s FROM "Reviews" WHERE id = $1`, reviewColumns)
+	review, err := scanReview(p.conn.QueryRow(context.Background(), stmt, id))
+	if err != nil {
+		p.log.Errorf("Error Finding Review By Id: %v", err)
+		return nil, err
+	}
+	return review, nil
+}
+
+func (p *psql) FindByRestaurant(restaurantId uuid.UUID, limit, offset int) ([]reviewModel.Review, error) {
+	stmt := fmt.Sprintf(`SELECT %s FROM "Reviews" WHERE restaurant_id = $1 AND menu_id IS NULL AND status = $2
+		ORDER BY created_at DESC LIMIT $3 OFFSET $4`, reviewColumns)
+	return p.findMany(stmt, restaurantId, reviewModel.Published, limit, offset)
+}
+
+func (p *psql) FindByMenuItem(menuId int64, limit, offset int) ([]reviewModel.Review, error) {
+	stmt := fmt.Sprintf(`SELECT %s FROM "Reviews" WHERE menu_id = $1 AND status = $2
+		ORDER BY created_at DESC LIMIT $3 OFFSET $4`, reviewColumns)
+	return p.findMany(stmt, menuId, reviewModel.Published, limit, offset)
+}
+
+func (p *psql) findMany(stmt string, args ...interface{}) ([]reviewModel.Review, error) {
+	rows, err := p.conn.Query(context.Background(), stmt, args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Reviews: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []reviewModel.Review
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			p.log.Errorf("Error Scanning Review: %v", err)
+			return nil, err
+		}
+		reviews = append(reviews, *review)
+	}
+	return reviews, rows.Err()
+}
+
+func (p *psql) SaveReply(reviewId uuid.UUID, reply reviewModel.Reply) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "Reviews"
+		SET reply_owner_id = $2, reply_body = $3, reply_created_at = $4, updated_at = $4 WHERE id = $1`,
+		reviewId, reply.OwnerId, reply.Body, reply.CreatedAt)
+	if err != nil {
+		p.log.Errorf("Error Saving Review Reply: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("review not found")
+	}
+	return nil
+}
+
+// AddReport records the report and returns how many distinct users have reported the review
+// since a moderator last decided on it. A user reporting the same review twice is not counted
+// again: the repeat returns 0.
+func (p *psql) AddReport(report reviewModel.Report) (int, error) {
+	var count int
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		tag, err := tx.Exec(context.Background(), `INSERT INTO "ReviewReports" (review_id, reporter_id, reason, note, created_at)
+			VALUES ($1, $2, $3, $4, $5) ON CONFLICT (review_id, reporter_id) DO NOTHING`,
+			report.ReviewId, report.ReporterId, report.Reason, report.Note, report.CreatedAt)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+		return tx.QueryRow(context.Background(), `SELECT count(*) FROM "ReviewReports" rr JOIN "Reviews" r
+			ON r.id = rr.review_id WHERE rr.review_id = $1 AND (r.moderated_at IS NULL OR rr.created_at > r.moderated_at)`,
+			report.ReviewId).Scan(&count)
+	})
+	if err != nil {
+		p.log.Errorf("Error Adding Review Report: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// UpdateStatus moves a review from one moderation state to another and keeps the rating
+// aggregates in step. It fails if the review is no longer in the expected state. Every move
+// other than flagging is a moderator's decision, and only reports made after it count.
+func (p *psql) UpdateStatus(id uuid.UUID, from, to reviewModel.Status) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		stmt := fmt.Sprintf(`UPDATE "Reviews" SET status = $3, updated_at = $4,
+			moderated_at = CASE WHEN $3 = $5 THEN moderated_at ELSE $4 END WHERE id = $1 AND status = $2
+			RETURNING %s`, reviewColumns)
+		review, err := scanReview(tx.QueryRow(context.Background(), stmt, id, from, to, time.Now(),
+			reviewModel.Flagged))
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("review is no longer %v", from)
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case from.CountsTowardsRating() && !to.CountsTowardsRating():
+			return adjustRating(tx, review, -1)
+		case !from.CountsTowardsRating() && to.CountsTowardsRating():
+			return adjustRating(tx, review, 1)
+		}
+		return nil
+	})
+	if err != nil {
+		p.log.Errorf("Error Updating Review Status: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindRatingSummary(restaurantId uuid.UUID, menuId *int64) (*reviewModel.RatingSummary, error) {
+	var count, total int64
+	var err error
+	if menuId == nil {
+		err = p.conn.QueryRow(context.Background(),
+			`SELECT rating_count, rating_total FROM "Restaurants" WHERE id = $1`, restaurantId).Scan(&count, &total)
+	} else {
+		err = p.conn.QueryRow(context.Background(),
+			`SELECT rating_count, rating_total FROM "Menu" WHERE id = $1 AND restaurant_id = $2`, *menuId, restaurantId).
+			Scan(&count, &total)
+	}
+	if err != nil {
+		p.log.Errorf("Error Finding Rating Summary: %v", err)
+		return nil, err
+	}
+
+	summary := reviewModel.RatingSummary{Count: int(count)}
+	if count > 0 {
+		summary.Average = float64(total) / float64(count)
+	}
+	return &summary, nil
+}
+
+// adjustRating adds (sign 1) or removes (sign -1) a review from its target's aggregates.
+func adjustRating(tx pgx.Tx, review *reviewModel.Review, sign int) error {
+	var err error
+	if review.MenuId == nil {
+		_, err = tx.Exec(context.Background(), `UPDATE "Restaurants"
+			SET rating_count = rating_count + $2, rating_total = rating_total + $3 WHERE id = $1`,
+			review.RestaurantId, sign, sign*review.Rating)
+	} else {
+		_, err = tx.Exec(context.Background(), `UPDATE "Menu"
+			SET rating_count = rating_count + $2, rating_total = rating_total + $3 WHERE id = $1`,
+			*review.MenuId, sign, sign*review.Rating)
+	}
+	return err
+}
+
+func scanReview(row pgx.Row) (*reviewModel.Review, error) {
+	var review reviewModel.Review
+	var replyOwner *uuid.UUID
+	var replyBody *string
+	var replyAt *time.Time
+	err := row.Scan(&review.Id, &review.UserId, &review.OrderId, &review.RestaurantId, &review.MenuId,
+		&review.Rating, &review.Comment, &review.Status, &replyOwner, &replyBody, &replyAt,
+		&review.CreatedAt, &review.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if replyBody != nil {
+		review.Reply = &reviewModel.Reply{Body: *replyBody}
+		if replyOwner != nil {
+			review.Reply.OwnerId = *replyOwner
+		}
+		if replyAt != nil {
+			review.Reply.CreatedAt = *replyAt
+		}
+	}
+	return &review, nil
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) reviewRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
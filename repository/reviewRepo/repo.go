@@ -0,0 +1,18 @@
+package reviewRepo
+
+import (
+	"github.com/google/uuid"
+	"rsm/entity/reviewModel"
+)
+
+type RepoInterface interface {
+	HasCompletedOrder(userId, orderId, restaurantId uuid.UUID, menuId *int64) (bool, error)
+	Persist(review *reviewModel.Review) (*reviewModel.Review, error)
+	FindById(id uuid.UUID) (*reviewModel.Review, error)
+	FindByRestaurant(restaurantId uuid.UUID, limit, offset int) ([]reviewModel.Review, error)
+	FindByMenuItem(menuId int64, limit, offset int) ([]reviewModel.Review, error)
+	SaveReply(reviewId uuid.UUID, reply reviewModel.Reply) error
+	AddReport(report reviewModel.Report) (int, error)
+	UpdateStatus(id uuid.UUID, from, to reviewModel.Status) error
+	FindRatingSummary(restaurantId uuid.UUID, menuId *int64) (*reviewModel.RatingSummary, error)
+}
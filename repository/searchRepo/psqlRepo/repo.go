@@ -0,0 +1,84 @@
+package psqlRepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/searchModel"
+	"rsm/repository/searchRepo"
+	"strings"
+)
+
+var headlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MinWords=10, MaxWords=30, MaxFragments=2",
+	searchModel.HighlightStart, searchModel.HighlightStop)
+
+// Each branch matches on the weighted tsvector or, for misspellings, on trigram word
+// similarity of the name. Rank adds both scores so exact matches sort first; hits with the
+// same rank sort by kind and id so pages neither repeat nor skip them. Sold out items
+// are left out by the in_stock function the migration defines.
+const restaurantSearch = `SELECT 'restaurant' AS kind, r.id AS restaurant_id, 0::bigint AS menu_id, r.name,
+		ts_headline('english', r.name || ' ' || coalesce(r.description, ''), q.query, $4),
+		'', ts_rank(r.search_vector, q.query) + word_similarity(q.term, r.name) AS rank
+	FROM "Restaurants" r CROSS JOIN q
+	WHERE r.status AND (r.search_vector @@ q.query OR q.term <% r.name)`
+
+const itemSearch = `SELECT 'item' AS kind, m.restaurant_id, m.id AS menu_id, m.item,
+		ts_headline('english', m.item || ' ' || m.item_type, q.query, $4),
+		m.price, ts_rank(m.search_vector, q.query) + word_similarity(q.term, m.item) AS rank
+	FROM "Menu" m JOIN "Restaurants" r ON r.id = m.restaurant_id CROSS JOIN q
+	WHERE r.status AND m.available AND in_stock(m) AND (m.search_vector @@ q.query OR q.term <% m.item)
+		AND (coalesce(cardinality($5::varchar[]), 0) = 0 OR NOT m.allergens && $5::varchar[]) AND m.dietary @> $6::varchar[]`
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) Search(request searchModel.SearchRequest) ([]searchModel.SearchHit, error) {
+	var branches []string
+	if request.Includes(searchModel.RestaurantHit) {
+		branches = append(branches, restaurantSearch)
+	}
+	if request.Includes(searchModel.MenuItemHit) {
+		branches = append(branches, itemSearch)
+	}
+
+	searchStmt := fmt.Sprintf(`WITH q AS (SELECT websearch_to_tsquery('english', $1) AS query, $1::text AS term)
+		SELECT * FROM (%s) hits ORDER BY rank DESC, kind, restaurant_id, menu_id LIMIT $2 OFFSET $3`, strings.Join(branches, " UNION ALL "))
+
+	args := []interface{}{request.Query, request.Limit, request.Offset, headlineOptions}
+	if request.Includes(searchModel.MenuItemHit) {
+		excluded := make([]string, 0, len(request.ExcludeAllergens))
+		for _, a := range request.ExcludeAllergens {
+			excluded = append(excluded, string(a))
+		}
+		dietary := make([]string, 0, len(request.Dietary))
+		for _, d := range request.Dietary {
+			dietary = append(dietary, string(d))
+		}
+		args = append(args, excluded, dietary)
+	}
+	rows, err := p.conn.Query(context.Background(), searchStmt, args...)
+	if err != nil {
+		p.log.Errorf("Error Searching: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []searchModel.SearchHit
+	for rows.Next() {
+		var hit searchModel.SearchHit
+		err = rows.Scan(&hit.Kind, &hit.RestaurantId, &hit.MenuId, &hit.Title, &hit.Snippet, &hit.Price, &hit.Rank)
+		if err != nil {
+			p.log.Errorf("Error Scanning Search Hit: %v", err)
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) searchRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
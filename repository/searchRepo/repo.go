@@ -0,0 +1,7 @@
+package searchRepo
+
+import "rsm/entity/searchModel"
+
+type RepoInterface interface {
+	Search(request searchModel.SearchRequest) ([]searchModel.SearchHit, error)
+}
@@ -0,0 +1,298 @@
+package psqlRepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/orderModel"
+	"rsm/entity/tableModel"
+	"rsm/repository/tableRepo"
+	"time"
+)
+
+const (
+	tableColumns   = `id, restaurant_id, label, seats, currency, token, active, created_at`
+	sessionColumns = `id, table_id, restaurant_id, status, order_id, opened_at, closed_at`
+)
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) Persist(table *tableModel.Table) error {
+	_, err := p.conn.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "Tables" (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`, tableColumns), table.Id, table.RestaurantId, table.Label,
+		table.Seats, table.Currency, table.Token, table.Active, table.CreatedAt)
+	if err != nil {
+		p.log.Errorf("Error Persisting Table: %v", err)
+	}
+	return err
+}
+
+func (p *psql) Update(table *tableModel.Table) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "Tables" SET label = $2, seats = $3, currency = $4,
+		token = $5, active = $6 WHERE id = $1`, table.Id, table.Label, table.Seats, table.Currency, table.Token,
+		table.Active)
+	if err != nil {
+		p.log.Errorf("Error Updating Table: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return tableRepo.ErrNotFound
+	}
+	return nil
+}
+
+func (p *psql) FindById(id uuid.UUID) (*tableModel.Table, error) {
+	return p.findOne(`id = $1`, id)
+}
+
+func (p *psql) FindByToken(token string) (*tableModel.Table, error) {
+	return p.findOne(`token = $1`, token)
+}
+
+func (p *psql) FindByRestaurant(restaurantId uuid.UUID) ([]tableModel.Table, error) {
+	return p.find(`restaurant_id = $1 ORDER BY label, id`, restaurantId)
+}
+
+func (p *psql) Join(session *tableModel.Session, diner *tableModel.Diner) (*tableModel.Session, error) {
+	var joined *tableModel.Session
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), `INSERT INTO "TableSessions" (id, table_id, restaurant_id, status,
+			opened_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (table_id) WHERE status = 'open' DO NOTHING`,
+			session.Id, session.TableId, session.RestaurantId, tableModel.Open, session.OpenedAt)
+		if err != nil {
+			return err
+		}
+		var sessionId uuid.UUID
+		err = tx.QueryRow(context.Background(), `SELECT id FROM "TableSessions" WHERE table_id = $1
+			AND status = 'open'`, session.TableId).Scan(&sessionId)
+		if err != nil {
+			return err
+		}
+		diner.SessionId = sessionId
+		_, err = tx.Exec(context.Background(), `INSERT INTO "TableDiners" (id, session_id, user_id, name, joined_at)
+			VALUES ($1, $2, $3, $4, $5)`, diner.Id, diner.SessionId, diner.UserId, diner.Name, diner.JoinedAt)
+		if err != nil {
+			return err
+		}
+		joined, err = findSession(tx, sessionId)
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Joining Table Session: %v", err)
+		return nil, err
+	}
+	return joined, nil
+}
+
+func (p *psql) FindSession(id uuid.UUID) (*tableModel.Session, error) {
+	session, err := findSession(p.conn, id)
+	if err != nil && err != tableRepo.ErrSessionNotFound {
+		p.log.Errorf("Error Finding Table Session: %v", err)
+	}
+	return session, err
+}
+
+func (p *psql) FindItems(sessionId uuid.UUID) ([]tableModel.Item, error) {
+	rows, err := p.conn.Query(context.Background(), `SELECT i.id, i.order_id, i.menu_id, i.item, i.item_type,
+		i.quantity, i.unit_price, i.diner_id FROM "OrderItems" i JOIN "TableSessions" s ON s.order_id = i.order_id
+		WHERE s.id = $1 ORDER BY i.id`, sessionId)
+	if err != nil {
+		p.log.Errorf("Error Finding Table Items: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []tableModel.Item{}
+	for rows.Next() {
+		var i tableModel.Item
+		err = rows.Scan(&i.Id, &i.OrderId, &i.MenuId, &i.Item, &i.ItemType, &i.Quantity, &i.UnitPrice, &i.DinerId)
+		if err != nil {
+			p.log.Errorf("Error Scanning Table Item: %v", err)
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+func (p *psql) AddItems(sessionId uuid.UUID, order *orderModel.Order, known int, items []tableModel.Item) (*tableModel.Session, error) {
+	var session *tableModel.Session
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		var status tableModel.SessionStatus
+		var orderId *uuid.UUID
+		err := tx.QueryRow(context.Background(), `SELECT status, order_id FROM "TableSessions" WHERE id = $1
+			FOR UPDATE`, sessionId).Scan(&status, &orderId)
+		if err == pgx.ErrNoRows {
+			return tableRepo.ErrSessionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if status != tableModel.Open {
+			return tableRepo.ErrSessionClosed
+		}
+
+		if orderId == nil {
+			if known != 0 {
+				return tableRepo.ErrStaleItems
+			}
+			_, err = tx.Exec(context.Background(), `INSERT INTO "Orders" (id, user_id, restaurant_id, status,
+				currency, total, created_at, updated_at) VALUES ($1, NULLIF($2, $3::uuid), $4, $5, $6, $7, $8, $8)`,
+				order.Id, order.UserId, uuid.Nil, order.RestaurantId, order.Status, order.Currency, order.Total,
+				order.CreatedAt)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(context.Background(), `UPDATE "TableSessions" SET order_id = $2 WHERE id = $1`,
+				sessionId, order.Id)
+			if err != nil {
+				return err
+			}
+			orderId = &order.Id
+		} else {
+			// Bills lock the order too, so a bill is never split from a total missing these items.
+			var orderStatus orderModel.Status
+			var billed bool
+			err = tx.QueryRow(context.Background(), `SELECT status, EXISTS (SELECT 1 FROM "Bills" WHERE order_id = $1)
+				FROM "Orders" WHERE id = $1 FOR UPDATE`, *orderId).Scan(&orderStatus, &billed)
+			if err != nil {
+				return err
+			}
+			if orderStatus != orderModel.Pending || billed {
+				return tableRepo.ErrOrderLocked
+			}
+			var count int
+			err = tx.QueryRow(context.Background(), `SELECT count(*) FROM "OrderItems" WHERE order_id = $1`,
+				*orderId).Scan(&count)
+			if err != nil {
+				return err
+			}
+			if count != known {
+				return tableRepo.ErrStaleItems
+			}
+			_, err = tx.Exec(context.Background(), `UPDATE "Orders" SET total = $2, updated_at = $3 WHERE id = $1`,
+				*orderId, order.Total, order.UpdatedAt)
+			if err != nil {
+				return err
+			}
+		}
+
+		for n := range items {
+			items[n].OrderId = *orderId
+			err = tx.QueryRow(context.Background(), `INSERT INTO "OrderItems" (order_id, menu_id, item, item_type,
+				quantity, unit_price, diner_id) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`, *orderId,
+				items[n].MenuId, items[n].Item, items[n].ItemType, items[n].Quantity, items[n].UnitPrice,
+				items[n].DinerId).Scan(&items[n].Id)
+			if err != nil {
+				return err
+			}
+		}
+		session, err = findSession(tx, sessionId)
+		return err
+	})
+	if err != nil && err != tableRepo.ErrSessionClosed && err != tableRepo.ErrStaleItems &&
+		err != tableRepo.ErrOrderLocked {
+		p.log.Errorf("Error Adding Table Items: %v", err)
+	}
+	return session, err
+}
+
+func (p *psql) Close(id uuid.UUID, at time.Time) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "TableSessions" SET status = $2, closed_at = $3
+		WHERE id = $1 AND status = 'open'`, id, tableModel.Closed, at)
+	if err != nil {
+		p.log.Errorf("Error Closing Table Session: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return tableRepo.ErrSessionClosed
+	}
+	return nil
+}
+
+func (p *psql) CloseByOrder(orderId uuid.UUID, at time.Time) error {
+	_, err := p.conn.Exec(context.Background(), `UPDATE "TableSessions" SET status = $2, closed_at = $3
+		WHERE order_id = $1 AND status = 'open' AND NOT EXISTS (SELECT 1 FROM "Bills" WHERE order_id = $1)`,
+		orderId, tableModel.Closed, at)
+	if err != nil {
+		p.log.Errorf("Error Closing Table Session By Order: %v", err)
+	}
+	return err
+}
+
+func (p *psql) findOne(condition string, args ...interface{}) (*tableModel.Table, error) {
+	tables, err := p.find(condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, tableRepo.ErrNotFound
+	}
+	return &tables[0], nil
+}
+
+func (p *psql) find(condition string, args ...interface{}) ([]tableModel.Table, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "Tables" WHERE %s`,
+		tableColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Tables: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []tableModel.Table{}
+	for rows.Next() {
+		var t tableModel.Table
+		err = rows.Scan(&t.Id, &t.RestaurantId, &t.Label, &t.Seats, &t.Currency, &t.Token, &t.Active, &t.CreatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Table: %v", err)
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+func findSession(db querier, id uuid.UUID) (*tableModel.Session, error) {
+	var s tableModel.Session
+	err := db.QueryRow(context.Background(), fmt.Sprintf(`SELECT %s FROM "TableSessions" WHERE id = $1`,
+		sessionColumns), id).Scan(&s.Id, &s.TableId, &s.RestaurantId, &s.Status, &s.OrderId, &s.OpenedAt,
+		&s.ClosedAt)
+	if err == pgx.ErrNoRows {
+		return nil, tableRepo.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(context.Background(), `SELECT id, session_id, user_id, name, joined_at FROM "TableDiners"
+		WHERE session_id = $1 ORDER BY joined_at, id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	s.Diners = []tableModel.Diner{}
+	for rows.Next() {
+		var d tableModel.Diner
+		err = rows.Scan(&d.Id, &d.SessionId, &d.UserId, &d.Name, &d.JoinedAt)
+		if err != nil {
+			return nil, err
+		}
+		s.Diners = append(s.Diners, d)
+	}
+	return &s, rows.Err()
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) tableRepo.RepoInterface {
+	return &psql{log: log, conn: conn}
+}
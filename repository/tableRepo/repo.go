@@ -0,0 +1,43 @@
+package tableRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/orderModel"
+	"rsm/entity/tableModel"
+	"time"
+)
+
+var (
+	ErrNotFound        = errors.New("table not found")
+	ErrSessionNotFound = errors.New("table session not found")
+	ErrSessionClosed   = errors.New("table session is closed")
+	// ErrStaleItems means someone else added to the table's order since it was priced.
+	ErrStaleItems = errors.New("table order changed concurrently")
+	// ErrOrderLocked means the table's order has moved past pending or its bill was split, so
+	// nothing more can be added to it.
+	ErrOrderLocked = errors.New("table order can no longer be added to")
+)
+
+type RepoInterface interface {
+	Persist(table *tableModel.Table) error
+	Update(table *tableModel.Table) error
+	FindById(id uuid.UUID) (*tableModel.Table, error)
+	FindByToken(token string) (*tableModel.Table, error)
+	FindByRestaurant(restaurantId uuid.UUID) ([]tableModel.Table, error)
+
+	// Join adds the diner to the table's open session, opening session if the table has none,
+	// and returns the session that was joined.
+	Join(session *tableModel.Session, diner *tableModel.Diner) (*tableModel.Session, error)
+	FindSession(id uuid.UUID) (*tableModel.Session, error)
+	FindItems(sessionId uuid.UUID) ([]tableModel.Item, error)
+	// AddItems adds items to the session's order and sets its total. The first items create
+	// the order from the given one. It fails with ErrStaleItems unless the order still has
+	// known items, the number the total was worked out with, and with ErrOrderLocked once the
+	// order is no longer pending or has a bill.
+	AddItems(sessionId uuid.UUID, order *orderModel.Order, known int, items []tableModel.Item) (*tableModel.Session, error)
+	Close(id uuid.UUID, at time.Time) error
+	// CloseByOrder closes the open session of an order paid without a bill. A billed order's
+	// session closes when its bill is settled, and an order with no session is left alone.
+	CloseByOrder(orderId uuid.UUID, at time.Time) error
+}
@@ -0,0 +1,86 @@
+package psqlRepo
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/taxModel"
+	"rsm/repository/taxRepo"
+)
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+// Save replaces the restaurant's rules and per item_type rates in one transaction.
+func (p *psql) Save(rules *taxModel.TaxRules) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), `INSERT INTO "TaxRules" (restaurant_id, inclusive, default_rate,
+			service_charge_rate, service_charge_taxable, rounding, method, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (restaurant_id) DO UPDATE SET inclusive = EXCLUDED.inclusive,
+			default_rate = EXCLUDED.default_rate, service_charge_rate = EXCLUDED.service_charge_rate,
+			service_charge_taxable = EXCLUDED.service_charge_taxable, rounding = EXCLUDED.rounding,
+			method = EXCLUDED.method, updated_at = EXCLUDED.updated_at`,
+			rules.RestaurantId, rules.Inclusive, rules.DefaultRate, rules.ServiceChargeRate,
+			rules.ServiceChargeTaxable, rules.Rounding, rules.Method, rules.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(context.Background(), `DELETE FROM "TaxRates" WHERE restaurant_id = $1`, rules.RestaurantId)
+		if err != nil {
+			return err
+		}
+		for itemType, rate := range rules.Rates {
+			_, err = tx.Exec(context.Background(), `INSERT INTO "TaxRates" (restaurant_id, item_type, rate)
+				VALUES ($1, $2, $3)`, rules.RestaurantId, itemType, rate)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		p.log.Errorf("Error Saving Tax Rules: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindByRestaurant(restaurantId uuid.UUID) (*taxModel.TaxRules, error) {
+	rules := taxModel.TaxRules{RestaurantId: restaurantId, Rates: map[string]int64{}}
+	err := p.conn.QueryRow(context.Background(), `SELECT inclusive, default_rate, service_charge_rate,
+		service_charge_taxable, rounding, method, updated_at FROM "TaxRules" WHERE restaurant_id = $1`, restaurantId).
+		Scan(&rules.Inclusive, &rules.DefaultRate, &rules.ServiceChargeRate, &rules.ServiceChargeTaxable,
+			&rules.Rounding, &rules.Method, &rules.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, taxRepo.ErrNotFound
+	}
+	if err != nil {
+		p.log.Errorf("Error Finding Tax Rules: %v", err)
+		return nil, err
+	}
+
+	rows, err := p.conn.Query(context.Background(), `SELECT item_type, rate FROM "TaxRates" WHERE restaurant_id = $1`,
+		restaurantId)
+	if err != nil {
+		p.log.Errorf("Error Finding Tax Rates: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var itemType string
+		var rate int64
+		if err = rows.Scan(&itemType, &rate); err != nil {
+			p.log.Errorf("Error Scanning Tax Rate: %v", err)
+			return nil, err
+		}
+		rules.Rates[itemType] = rate
+	}
+	return &rules, rows.Err()
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) taxRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
@@ -0,0 +1,14 @@
+package taxRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/taxModel"
+)
+
+var ErrNotFound = errors.New("tax rules not found")
+
+type RepoInterface interface {
+	Save(rules *taxModel.TaxRules) error
+	FindByRestaurant(restaurantId uuid.UUID) (*taxModel.TaxRules, error)
+}
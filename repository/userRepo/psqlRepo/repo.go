@@ -10,30 +10,34 @@ import (
 	"rsm/repository/userRepo"
 )
 
+const addressColumns = `id, user_id, label, line1, line2, city, state, postal_code, country, latitude, longitude,
+	instructions, is_default, created_at, updated_at`
+
 type psql struct {
 	log  *logrus.Logger
 	conn *pgx.Conn
 }
 
 func (p *psql) Update(user *userModel.UserModel) (*userModel.UserModel, error) {
-	persistStmt := fmt.Sprintf(
-		"UPDATE User SET id = %v, firstname =%v, lastname=%v, email=%v",
-		user.Id, user.FirstName, user.LastName, user.Email)
-
-	_, err := p.conn.Exec(context.Background(), persistStmt)
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "User" SET firstname = $2, lastname = $3, email = $4,
+		phone = NULLIF($5, ''), avatar_ref = NULLIF($6, '') WHERE id = $1`,
+		user.Id, user.FirstName, user.LastName, user.Email, user.Phone, user.AvatarRef)
 	if err != nil {
+		p.log.Errorf("Error Updating User: %v", err)
 		return nil, err
 	}
+	if tag.RowsAffected() == 0 {
+		return nil, userRepo.ErrNotFound
+	}
 	return user, nil
 }
 
 func (p *psql) Persist(user *userModel.UserModel) (*userModel.UserAccessModel, error) {
-	persistStmt := fmt.Sprintf(
-		"INSERT INTO User (id, firstname, lastname, email, created_at) VALUES(%v, %v, %v, %v, %v", user.Id,
-		user.FirstName, user.LastName, user.Email, user.CreatedAt)
-
-	_, err := p.conn.Exec(context.Background(), persistStmt)
+	_, err := p.conn.Exec(context.Background(), `INSERT INTO "User" (id, firstname, lastname, email, password,
+		phone, avatar_ref, created_at) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8)`,
+		user.Id, user.FirstName, user.LastName, user.Email, user.Password, user.Phone, user.AvatarRef, user.CreatedAt)
 	if err != nil {
+		p.log.Errorf("Error Persisting User: %v", err)
 		return nil, err
 	}
 	userAccess := userModel.UserAccessModel{
@@ -41,15 +45,17 @@ func (p *psql) Persist(user *userModel.UserModel) (*userModel.UserAccessModel, e
 		FirstName: user.FirstName,
 		LastName:  user.LastName,
 		Email:     user.Email,
+		Phone:     user.Phone,
+		AvatarRef: user.AvatarRef,
 	}
 	return &userAccess, nil
 
 }
 
 func (p *psql) Delete(id uuid.UUID) error {
-	deleteStmt := fmt.Sprintf("DELETE FROM User WHERE id=%v", id)
-	_, err := p.conn.Exec(context.Background(), deleteStmt)
+	_, err := p.conn.Exec(context.Background(), `DELETE FROM "User" WHERE id = $1`, id)
 	if err != nil {
+		p.log.Errorf("Error Deleting User: %v", err)
 		return err
 	}
 	return nil
@@ -57,34 +63,168 @@ func (p *psql) Delete(id uuid.UUID) error {
 
 func (p *psql) FindById(id uuid.UUID) (*userModel.UserAccessModel, error) {
 	var userAccess userModel.UserAccessModel
-	findByIdStmt := fmt.Sprintf("SELECT id, firstname, lastname, email FROM User WHERE id = %v", id)
-	queryRes, err := p.conn.Query(context.Background(), findByIdStmt)
-	if err != nil {
-		p.log.Errorf("Error Finding By Id: %v", err)
-		return nil, err
+	err := p.conn.QueryRow(context.Background(), `SELECT id, firstname, lastname, email, coalesce(phone, ''),
+		coalesce(avatar_ref, '') FROM "User" WHERE id = $1`, id).
+		Scan(&userAccess.Id, &userAccess.FirstName, &userAccess.LastName, &userAccess.Email, &userAccess.Phone,
+			&userAccess.AvatarRef)
+	if err == pgx.ErrNoRows {
+		return nil, userRepo.ErrNotFound
 	}
-	err = queryRes.Scan(userAccess.Id, userAccess.FirstName, userAccess.LastName, userAccess.Email)
 	if err != nil {
-		p.log.Errorf("Error Scanning into Access Model: %v", err)
+		p.log.Errorf("Error Finding By Id: %v", err)
 		return nil, err
 	}
 	return &userAccess, nil
 }
 
 func (p *psql) FindByEmail(email string) (*userModel.UserModel, error) {
-	var userAccess userModel.UserModel
-	findByEmailStmt := fmt.Sprintf("SELECT id, firstname, lastname, email, password FROM User WHERE email = %v", email)
-	queryRes, err := p.conn.Query(context.Background(), findByEmailStmt)
+	var user userModel.UserModel
+	err := p.conn.QueryRow(context.Background(), `SELECT id, firstname, lastname, email, coalesce(password, ''),
+		coalesce(phone, ''), coalesce(avatar_ref, ''), created_at FROM "User" WHERE email = $1`, email).
+		Scan(&user.Id, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.Phone, &user.AvatarRef,
+			&user.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, userRepo.ErrNotFound
+	}
 	if err != nil {
 		p.log.Errorf("Error Finding By Email: %v", err)
 		return nil, err
 	}
-	err = queryRes.Scan(userAccess.Id, userAccess.FirstName, userAccess.LastName, userAccess.Email, userAccess.Password)
+	return &user, nil
+}
+
+// PersistAddress saves a new address. The user's first address, or one flagged as default,
+// becomes the default in the same transaction.
+func (p *psql) PersistAddress(address *userModel.Address) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		var others int
+		err := tx.QueryRow(context.Background(), `SELECT count(*) FROM "UserAddresses" WHERE user_id = $1`,
+			address.UserId).Scan(&others)
+		if err != nil {
+			return err
+		}
+		if others == 0 {
+			address.IsDefault = true
+		}
+		if address.IsDefault {
+			_, err = tx.Exec(context.Background(), `UPDATE "UserAddresses" SET is_default = false
+				WHERE user_id = $1 AND is_default`, address.UserId)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "UserAddresses" (%s)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`, addressColumns),
+			address.Id, address.UserId, address.Label, address.Line1, address.Line2, address.City, address.State,
+			address.PostalCode, address.Country, address.Latitude, address.Longitude, address.Instructions,
+			address.IsDefault, address.CreatedAt, address.UpdatedAt)
+		return err
+	})
+	if err != nil {
+		p.log.Errorf("Error Persisting Address: %v", err)
+	}
+	return err
+}
+
+func (p *psql) UpdateAddress(address *userModel.Address) error {
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "UserAddresses" SET label = $3, line1 = $4, line2 = $5,
+		city = $6, state = $7, postal_code = $8, country = $9, latitude = $10, longitude = $11, instructions = $12,
+		updated_at = $13 WHERE id = $1 AND user_id = $2`,
+		address.Id, address.UserId, address.Label, address.Line1, address.Line2, address.City, address.State,
+		address.PostalCode, address.Country, address.Latitude, address.Longitude, address.Instructions,
+		address.UpdatedAt)
+	if err != nil {
+		p.log.Errorf("Error Updating Address: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return userRepo.ErrAddressNotFound
+	}
+	return nil
+}
+
+// DeleteAddress removes the address. If it was the default, the user's oldest remaining
+// address takes over.
+func (p *psql) DeleteAddress(userId, id uuid.UUID) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		var wasDefault bool
+		err := tx.QueryRow(context.Background(), `DELETE FROM "UserAddresses" WHERE id = $1 AND user_id = $2
+			RETURNING is_default`, id, userId).Scan(&wasDefault)
+		if err == pgx.ErrNoRows {
+			return userRepo.ErrAddressNotFound
+		}
+		if err != nil || !wasDefault {
+			return err
+		}
+		_, err = tx.Exec(context.Background(), `UPDATE "UserAddresses" SET is_default = true
+			WHERE id = (SELECT id FROM "UserAddresses" WHERE user_id = $1 ORDER BY created_at, id LIMIT 1)`, userId)
+		return err
+	})
+	if err != nil && err != userRepo.ErrAddressNotFound {
+		p.log.Errorf("Error Deleting Address: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindAddress(userId, id uuid.UUID) (*userModel.Address, error) {
+	addresses, err := p.findAddresses(`user_id = $1 AND id = $2`, userId, id)
 	if err != nil {
-		p.log.Errorf("Error Scanning into Access Model: %v", err)
 		return nil, err
 	}
-	return &userAccess, nil
+	if len(addresses) == 0 {
+		return nil, userRepo.ErrAddressNotFound
+	}
+	return &addresses[0], nil
+}
+
+func (p *psql) FindAddresses(userId uuid.UUID) ([]userModel.Address, error) {
+	return p.findAddresses(`user_id = $1`, userId)
+}
+
+func (p *psql) findAddresses(condition string, args ...interface{}) ([]userModel.Address, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "UserAddresses" WHERE %s
+		ORDER BY is_default DESC, created_at, id`, addressColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Addresses: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := []userModel.Address{}
+	for rows.Next() {
+		var a userModel.Address
+		err = rows.Scan(&a.Id, &a.UserId, &a.Label, &a.Line1, &a.Line2, &a.City, &a.State, &a.PostalCode,
+			&a.Country, &a.Latitude, &a.Longitude, &a.Instructions, &a.IsDefault, &a.CreatedAt, &a.UpdatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Address: %v", err)
+			return nil, err
+		}
+		addresses = append(addresses, a)
+	}
+	return addresses, rows.Err()
+}
+
+func (p *psql) SetDefaultAddress(userId, id uuid.UUID) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		_, err := tx.Exec(context.Background(), `UPDATE "UserAddresses" SET is_default = false
+			WHERE user_id = $1 AND is_default AND id <> $2`, userId, id)
+		if err != nil {
+			return err
+		}
+		tag, err := tx.Exec(context.Background(), `UPDATE "UserAddresses" SET is_default = true
+			WHERE id = $1 AND user_id = $2`, id, userId)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return userRepo.ErrAddressNotFound
+		}
+		return nil
+	})
+	if err != nil && err != userRepo.ErrAddressNotFound {
+		p.log.Errorf("Error Setting Default Address: %v", err)
+	}
+	return err
 }
 
 func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) userRepo.RepoInterface {
@@ -1,14 +1,27 @@
 package userRepo
 
 import (
+	"errors"
 	"github.com/google/uuid"
 	"rsm/entity/userModel"
 )
 
+var (
+	ErrNotFound        = errors.New("user not found")
+	ErrAddressNotFound = errors.New("address not found")
+)
+
 type RepoInterface interface {
 	Persist(user *userModel.UserModel) (*userModel.UserAccessModel, error)
 	Update(user *userModel.UserModel) (*userModel.UserModel, error)
 	Delete(id uuid.UUID) error
 	FindById(id uuid.UUID) (*userModel.UserAccessModel, error)
 	FindByEmail(email string) (*userModel.UserModel, error)
+
+	PersistAddress(address *userModel.Address) error
+	UpdateAddress(address *userModel.Address) error
+	DeleteAddress(userId, id uuid.UUID) error
+	FindAddress(userId, id uuid.UUID) (*userModel.Address, error)
+	FindAddresses(userId uuid.UUID) ([]userModel.Address, error)
+	SetDefaultAddress(userId, id uuid.UUID) error
 }
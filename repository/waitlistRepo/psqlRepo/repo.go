@@ -0,0 +1,178 @@
+package psqlRepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/waitlistModel"
+	"rsm/repository/waitlistRepo"
+	"time"
+)
+
+const (
+	entryColumns = `id, restaurant_id, user_id, name, coalesce(phone, ''), party_size, status, joined_at,
+		notified_at, seated_at, updated_at`
+	turnColumns = `id, restaurant_id, entry_id, party_size, seated_at, cleared_at`
+)
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+func (p *psql) Persist(entry *waitlistModel.Entry) error {
+	_, err := p.conn.Exec(context.Background(), `INSERT INTO "Waitlist" (id, restaurant_id, user_id, name, phone,
+		party_size, status, joined_at, updated_at) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)`,
+		entry.Id, entry.RestaurantId, entry.UserId, entry.Name, entry.Phone, entry.PartySize, entry.Status,
+		entry.JoinedAt, entry.UpdatedAt)
+	if err != nil {
+		p.log.Errorf("Error Persisting Waitlist Entry: %v", err)
+	}
+	return err
+}
+
+func (p *psql) FindById(id uuid.UUID) (*waitlistModel.Entry, error) {
+	entries, err := p.find(`id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, waitlistRepo.ErrNotFound
+	}
+	return &entries[0], nil
+}
+
+func (p *psql) FindQueue(restaurantId uuid.UUID) ([]waitlistModel.Entry, error) {
+	return p.find(`restaurant_id = $1 AND status = ANY($2) ORDER BY joined_at, id`, restaurantId,
+		[]waitlistModel.Status{waitlistModel.Waiting, waitlistModel.Notified})
+}
+
+func (p *psql) UpdateStatus(entry *waitlistModel.Entry, from waitlistModel.Status) error {
+	err := updateStatus(p.conn, entry, from)
+	if err != nil && err != waitlistRepo.ErrStaleStatus {
+		p.log.Errorf("Error Updating Waitlist Status: %v", err)
+	}
+	return err
+}
+
+func (p *psql) Seat(entry *waitlistModel.Entry, from waitlistModel.Status, turn *waitlistModel.Turn) error {
+	err := p.conn.BeginFunc(context.Background(), func(tx pgx.Tx) error {
+		err := updateStatus(tx, entry, from)
+		if err != nil {
+			return err
+		}
+		return persistTurn(tx, turn)
+	})
+	if err != nil && err != waitlistRepo.ErrStaleStatus {
+		p.log.Errorf("Error Seating Waitlist Entry: %v", err)
+	}
+	return err
+}
+
+func (p *psql) PersistTurn(turn *waitlistModel.Turn) error {
+	err := persistTurn(p.conn, turn)
+	if err != nil {
+		p.log.Errorf("Error Persisting Table Turn: %v", err)
+	}
+	return err
+}
+
+func (p *psql) ClearTurn(restaurantId, id uuid.UUID, at time.Time) (*waitlistModel.Turn, error) {
+	var t waitlistModel.Turn
+	err := p.conn.QueryRow(context.Background(), fmt.Sprintf(`UPDATE "TableTurns" SET cleared_at = $3
+		WHERE id = $1 AND restaurant_id = $2 AND cleared_at IS NULL RETURNING %s`, turnColumns),
+		id, restaurantId, at).Scan(&t.Id, &t.RestaurantId, &t.EntryId, &t.PartySize, &t.SeatedAt, &t.ClearedAt)
+	if err == pgx.ErrNoRows {
+		return nil, waitlistRepo.ErrTurnNotFound
+	}
+	if err != nil {
+		p.log.Errorf("Error Clearing Table Turn: %v", err)
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (p *psql) FindOccupied(restaurantId uuid.UUID) ([]waitlistModel.Turn, error) {
+	return p.findTurns(`restaurant_id = $1 AND cleared_at IS NULL ORDER BY seated_at`, restaurantId)
+}
+
+func (p *psql) FindRecentTurns(restaurantId uuid.UUID, since time.Time, limit int) ([]waitlistModel.Turn, error) {
+	return p.findTurns(`restaurant_id = $1 AND cleared_at >= $2 ORDER BY cleared_at DESC LIMIT $3`,
+		restaurantId, since, limit)
+}
+
+func (p *psql) find(condition string, args ...interface{}) ([]waitlistModel.Entry, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "Waitlist" WHERE %s`,
+		entryColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Waitlist Entries: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []waitlistModel.Entry{}
+	for rows.Next() {
+		var e waitlistModel.Entry
+		err = rows.Scan(&e.Id, &e.RestaurantId, &e.UserId, &e.Name, &e.Phone, &e.PartySize, &e.Status,
+			&e.JoinedAt, &e.NotifiedAt, &e.SeatedAt, &e.UpdatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Waitlist Entry: %v", err)
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (p *psql) findTurns(condition string, args ...interface{}) ([]waitlistModel.Turn, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "TableTurns" WHERE %s`,
+		turnColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Table Turns: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	turns := []waitlistModel.Turn{}
+	for rows.Next() {
+		var t waitlistModel.Turn
+		err = rows.Scan(&t.Id, &t.RestaurantId, &t.EntryId, &t.PartySize, &t.SeatedAt, &t.ClearedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Table Turn: %v", err)
+			return nil, err
+		}
+		turns = append(turns, t)
+	}
+	return turns, rows.Err()
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+}
+
+func updateStatus(db execer, entry *waitlistModel.Entry, from waitlistModel.Status) error {
+	tag, err := db.Exec(context.Background(), `UPDATE "Waitlist" SET status = $3, notified_at = $4, seated_at = $5,
+		updated_at = $6 WHERE id = $1 AND status = $2`, entry.Id, from, entry.Status, entry.NotifiedAt,
+		entry.SeatedAt, entry.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return waitlistRepo.ErrStaleStatus
+	}
+	return nil
+}
+
+func persistTurn(db execer, turn *waitlistModel.Turn) error {
+	_, err := db.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "TableTurns" (%s)
+		VALUES ($1, $2, $3, $4, $5, $6)`, turnColumns), turn.Id, turn.RestaurantId, turn.EntryId, turn.PartySize,
+		turn.SeatedAt, turn.ClearedAt)
+	return err
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) waitlistRepo.RepoInterface {
+	return &psql{log: log, conn: conn}
+}
@@ -0,0 +1,34 @@
+package waitlistRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/waitlistModel"
+	"time"
+)
+
+var (
+	ErrNotFound     = errors.New("waitlist entry not found")
+	ErrTurnNotFound = errors.New("table turn not found")
+	// ErrStaleStatus is returned when the entry was seated, notified or removed concurrently.
+	ErrStaleStatus = errors.New("waitlist entry status changed concurrently")
+)
+
+type RepoInterface interface {
+	Persist(entry *waitlistModel.Entry) error
+	FindById(id uuid.UUID) (*waitlistModel.Entry, error)
+	// FindQueue returns the restaurant's waiting and notified entries in the order they joined.
+	FindQueue(restaurantId uuid.UUID) ([]waitlistModel.Entry, error)
+	// UpdateStatus saves the entry's status and timestamps only if it is still in the from state.
+	UpdateStatus(entry *waitlistModel.Entry, from waitlistModel.Status) error
+	// Seat does UpdateStatus and starts the turn in one transaction.
+	Seat(entry *waitlistModel.Entry, from waitlistModel.Status, turn *waitlistModel.Turn) error
+
+	PersistTurn(turn *waitlistModel.Turn) error
+	// ClearTurn ends one of the restaurant's open turns.
+	ClearTurn(restaurantId, id uuid.UUID, at time.Time) (*waitlistModel.Turn, error)
+	// FindOccupied returns the restaurant's turns that have not been cleared.
+	FindOccupied(restaurantId uuid.UUID) ([]waitlistModel.Turn, error)
+	// FindRecentTurns returns up to limit finished turns cleared since the given time, newest first.
+	FindRecentTurns(restaurantId uuid.UUID, since time.Time, limit int) ([]waitlistModel.Turn, error)
+}
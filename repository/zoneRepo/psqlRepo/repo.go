@@ -0,0 +1,115 @@
+package psqlRepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/zoneModel"
+	"rsm/repository/zoneRepo"
+)
+
+const zoneColumns = `id, restaurant_id, name, area, delivery_fee, minimum_order, currency, created_at, updated_at`
+
+type psql struct {
+	log  *logrus.Logger
+	conn *pgx.Conn
+}
+
+// Persist stores the zone's area as its GeoJSON so it can be read back by other tools.
+func (p *psql) Persist(zone *zoneModel.Zone) error {
+	area, err := json.Marshal(zone.Area)
+	if err != nil {
+		return err
+	}
+	_, err = p.conn.Exec(context.Background(), fmt.Sprintf(`INSERT INTO "DeliveryZones" (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`, zoneColumns),
+		zone.Id, zone.RestaurantId, zone.Name, area, zone.DeliveryFee, zone.MinimumOrder, zone.Currency,
+		zone.CreatedAt, zone.UpdatedAt)
+	if err != nil {
+		p.log.Errorf("Error Persisting Delivery Zone: %v", err)
+	}
+	return err
+}
+
+func (p *psql) Update(zone *zoneModel.Zone) error {
+	area, err := json.Marshal(zone.Area)
+	if err != nil {
+		return err
+	}
+	tag, err := p.conn.Exec(context.Background(), `UPDATE "DeliveryZones" SET name = $3, area = $4,
+		delivery_fee = $5, minimum_order = $6, currency = $7, updated_at = $8
+		WHERE id = $1 AND restaurant_id = $2`,
+		zone.Id, zone.RestaurantId, zone.Name, area, zone.DeliveryFee, zone.MinimumOrder, zone.Currency, zone.UpdatedAt)
+	if err != nil {
+		p.log.Errorf("Error Updating Delivery Zone: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return zoneRepo.ErrNotFound
+	}
+	return nil
+}
+
+func (p *psql) Delete(restaurantId, id uuid.UUID) error {
+	tag, err := p.conn.Exec(context.Background(), `DELETE FROM "DeliveryZones" WHERE id = $1 AND restaurant_id = $2`,
+		id, restaurantId)
+	if err != nil {
+		p.log.Errorf("Error Deleting Delivery Zone: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return zoneRepo.ErrNotFound
+	}
+	return nil
+}
+
+func (p *psql) FindById(id uuid.UUID) (*zoneModel.Zone, error) {
+	zones, err := p.find(`id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(zones) == 0 {
+		return nil, zoneRepo.ErrNotFound
+	}
+	return &zones[0], nil
+}
+
+func (p *psql) FindByRestaurant(restaurantId uuid.UUID) ([]zoneModel.Zone, error) {
+	return p.find(`restaurant_id = $1`, restaurantId)
+}
+
+func (p *psql) find(condition string, args ...interface{}) ([]zoneModel.Zone, error) {
+	rows, err := p.conn.Query(context.Background(), fmt.Sprintf(`SELECT %s FROM "DeliveryZones" WHERE %s
+		ORDER BY created_at, id`, zoneColumns, condition), args...)
+	if err != nil {
+		p.log.Errorf("Error Finding Delivery Zones: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	zones := []zoneModel.Zone{}
+	for rows.Next() {
+		var z zoneModel.Zone
+		var area []byte
+		err = rows.Scan(&z.Id, &z.RestaurantId, &z.Name, &area, &z.DeliveryFee, &z.MinimumOrder, &z.Currency,
+			&z.CreatedAt, &z.UpdatedAt)
+		if err != nil {
+			p.log.Errorf("Error Scanning Delivery Zone: %v", err)
+			return nil, err
+		}
+		err = json.Unmarshal(area, &z.Area)
+		if err != nil {
+			p.log.Errorf("Error Reading Delivery Zone %v Area: %v", z.Id, err)
+			return nil, err
+		}
+		zones = append(zones, z)
+	}
+	return zones, rows.Err()
+}
+
+func NewPsqlService(conn *pgx.Conn, log *logrus.Logger) zoneRepo.RepoInterface {
+	return &psql{conn: conn, log: log}
+}
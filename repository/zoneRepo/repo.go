@@ -0,0 +1,17 @@
+package zoneRepo
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"rsm/entity/zoneModel"
+)
+
+var ErrNotFound = errors.New("delivery zone not found")
+
+type RepoInterface interface {
+	Persist(zone *zoneModel.Zone) error
+	Update(zone *zoneModel.Zone) error
+	Delete(restaurantId, id uuid.UUID) error
+	FindById(id uuid.UUID) (*zoneModel.Zone, error)
+	FindByRestaurant(restaurantId uuid.UUID) ([]zoneModel.Zone, error)
+}
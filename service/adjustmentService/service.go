@@ -0,0 +1,361 @@
+package adjustmentService
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/adjustmentModel"
+	"rsm/entity/orderModel"
+	"rsm/entity/paymentModel"
+	"rsm/pricing"
+	"rsm/repository/adjustmentRepo"
+	"rsm/repository/orderRepo"
+	"rsm/service/paymentService"
+	"time"
+)
+
+type ServiceInterface interface {
+	RequestAdjustment(actor adjustmentModel.Actor, orderId uuid.UUID, request adjustmentModel.AdjustmentRequest) (*adjustmentModel.Adjustment, error)
+	Approve(actor adjustmentModel.Actor, id uuid.UUID) (*adjustmentModel.Adjustment, error)
+	Reject(actor adjustmentModel.Actor, id uuid.UUID) (*adjustmentModel.Adjustment, error)
+	Apply(id uuid.UUID) (*adjustmentModel.Adjustment, error)
+	ListAdjustments(orderId uuid.UUID) ([]adjustmentModel.Adjustment, error)
+	Reconcile(orderId uuid.UUID) (*adjustmentModel.Reconciliation, error)
+}
+
+type adjustmentService struct {
+	log      *logrus.Logger
+	repo     adjustmentRepo.RepoInterface
+	orders   orderRepo.RepoInterface
+	payments paymentService.ServiceInterface
+}
+
+// RequestAdjustment prices the refund against what is left of the order. If the amount is
+// within the actor's approval limit it is approved and applied straight away, otherwise it
+// waits for someone allowed to approve it.
+func (a *adjustmentService) RequestAdjustment(actor adjustmentModel.Actor, orderId uuid.UUID, request adjustmentModel.AdjustmentRequest) (*adjustmentModel.Adjustment, error) {
+	err := actor.ValidateInput()
+	if err == nil {
+		err = request.ValidateInput()
+	}
+	if err != nil {
+		a.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+
+	order, err := a.orders.FindById(orderId)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := a.repo.FindByOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := priceLines(order, existing, request)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustment := adjustmentModel.Adjustment{
+		Id:          uuid.New(),
+		OrderId:     orderId,
+		Reason:      request.Reason,
+		Note:        request.Note,
+		Full:        request.Full,
+		Lines:       lines,
+		Refunds:     []adjustmentModel.Refund{},
+		Currency:    order.Currency,
+		Status:      adjustmentModel.PendingApproval,
+		RequestedBy: actor.UserId,
+		CreatedAt:   time.Now(),
+	}
+	for _, l := range lines {
+		adjustment.Amount += l.Amount
+	}
+	if adjustment.Amount == 0 {
+		return nil, fmt.Errorf("nothing left to refund")
+	}
+
+	if adjustmentModel.CanApprove(actor.Role, adjustment.Amount, order.Total) {
+		err = a.approve(&adjustment, actor, existing)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = a.repo.Persist(&adjustment)
+	if err != nil {
+		return nil, err
+	}
+	if adjustment.Status != adjustmentModel.Approved {
+		return &adjustment, nil
+	}
+	return a.apply(&adjustment)
+}
+
+// priceLines works out each line's refund from its share of the order total, leaving out
+// units that earlier adjustments already cover.
+func priceLines(order *orderModel.Order, existing []adjustmentModel.Adjustment, request adjustmentModel.AdjustmentRequest) ([]adjustmentModel.Line, error) {
+	refunded := map[int64]int{}
+	for _, adj := range existing {
+		if !adj.Counts() {
+			continue
+		}
+		for _, l := range adj.Lines {
+			refunded[l.OrderItemId] += l.Quantity
+		}
+	}
+
+	requested := request.Lines
+	if request.Full {
+		requested = nil
+		for _, item := range order.Items {
+			if left := item.Quantity - refunded[item.Id]; left > 0 {
+				requested = append(requested, adjustmentModel.LineRequest{OrderItemId: item.Id, Quantity: left})
+			}
+		}
+	}
+
+	amounts := pricing.ItemAmounts(order.Total, order.Items)
+	index := map[int64]int{}
+	for i, item := range order.Items {
+		index[item.Id] = i
+	}
+	lines := make([]adjustmentModel.Line, 0, len(requested))
+	for _, r := range requested {
+		i, ok := index[r.OrderItemId]
+		if !ok {
+			return nil, fmt.Errorf("item %d is not part of the order", r.OrderItemId)
+		}
+		item := order.Items[i]
+		amount, err := pricing.RefundUnits(amounts[i], item.Quantity, refunded[item.Id], r.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %v", item.Id, err)
+		}
+		refunded[item.Id] += r.Quantity
+		lines = append(lines, adjustmentModel.Line{
+			OrderItemId: item.Id,
+			MenuId:      item.MenuId,
+			Quantity:    r.Quantity,
+			Amount:      amount,
+			Restock:     r.Restock,
+		})
+	}
+	return lines, nil
+}
+
+// Approve lets someone other than the requester approve a pending adjustment within their
+// limit, then applies it.
+func (a *adjustmentService) Approve(actor adjustmentModel.Actor, id uuid.UUID) (*adjustmentModel.Adjustment, error) {
+	adjustment, order, err := a.pending(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if !adjustmentModel.CanApprove(actor.Role, adjustment.Amount, order.Total) {
+		return nil, fmt.Errorf("adjustment exceeds the %v approval limit", actor.Role)
+	}
+	existing, err := a.repo.FindByOrder(adjustment.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	err = a.approve(adjustment, actor, existing)
+	if err != nil {
+		return nil, err
+	}
+	err = a.repo.Decide(adjustment)
+	if err != nil {
+		return nil, err
+	}
+	return a.apply(adjustment)
+}
+
+func (a *adjustmentService) Reject(actor adjustmentModel.Actor, id uuid.UUID) (*adjustmentModel.Adjustment, error) {
+	adjustment, _, err := a.pending(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	adjustment.Status, adjustment.DecidedBy, adjustment.DecidedAt = adjustmentModel.Rejected, &actor.UserId, &now
+	err = a.repo.Decide(adjustment)
+	if err != nil {
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+func (a *adjustmentService) pending(actor adjustmentModel.Actor, id uuid.UUID) (*adjustmentModel.Adjustment, *orderModel.Order, error) {
+	err := actor.ValidateInput()
+	if err != nil {
+		a.log.Errorf("Validation Error: %v", err)
+		return nil, nil, fmt.Errorf("something went wrong while validation")
+	}
+	adjustment, err := a.repo.FindById(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if adjustment.Status != adjustmentModel.PendingApproval {
+		return nil, nil, fmt.Errorf("adjustment is already %v", adjustment.Status)
+	}
+	if adjustment.RequestedBy == actor.UserId {
+		return nil, nil, fmt.Errorf("cannot decide on your own adjustment")
+	}
+	order, err := a.orders.FindById(adjustment.OrderId)
+	if err != nil {
+		return nil, nil, err
+	}
+	return adjustment, order, nil
+}
+
+// approve fixes which payments the adjustment is refunded through, taking from the oldest
+// payment first. Refunds already promised to other approved adjustments are kept aside.
+func (a *adjustmentService) approve(adjustment *adjustmentModel.Adjustment, actor adjustmentModel.Actor, existing []adjustmentModel.Adjustment) error {
+	payments, err := a.payments.ListByOrder(adjustment.OrderId)
+	if err != nil {
+		return err
+	}
+	promised := map[uuid.UUID]int64{}
+	for _, adj := range existing {
+		if adj.Status == adjustmentModel.Approved && adj.Id != adjustment.Id {
+			for _, r := range adj.Refunds {
+				promised[r.PaymentId] += r.Amount
+			}
+		}
+	}
+
+	remaining := adjustment.Amount
+	for _, p := range payments {
+		if p.Status != paymentModel.Captured && p.Status != paymentModel.PartiallyRefunded {
+			continue
+		}
+		amount := p.Refundable().Amount - promised[p.Id]
+		if amount > remaining {
+			amount = remaining
+		}
+		if amount <= 0 {
+			continue
+		}
+		adjustment.Refunds = append(adjustment.Refunds, adjustmentModel.Refund{PaymentId: p.Id, Amount: amount})
+		remaining -= amount
+		if remaining == 0 {
+			break
+		}
+	}
+	if remaining > 0 {
+		return fmt.Errorf("payments cover only %d of the %d to refund", adjustment.Amount-remaining, adjustment.Amount)
+	}
+
+	now := time.Now()
+	adjustment.Status, adjustment.DecidedBy, adjustment.DecidedAt = adjustmentModel.Approved, &actor.UserId, &now
+	return nil
+}
+
+// Apply finishes an approved adjustment whose refunds did not all go through the first time.
+// For an applied one it runs the refunds again, which refunds nothing new but brings the
+// customer's loyalty points in line if that failed before.
+func (a *adjustmentService) Apply(id uuid.UUID) (*adjustmentModel.Adjustment, error) {
+	adjustment, err := a.repo.FindById(id)
+	if err != nil {
+		return nil, err
+	}
+	if adjustment.Status == adjustmentModel.Applied {
+		err = a.refund(adjustment)
+		if err != nil {
+			return nil, err
+		}
+		return adjustment, nil
+	}
+	if adjustment.Status != adjustmentModel.Approved {
+		return nil, fmt.Errorf("cannot apply a %v adjustment", adjustment.Status)
+	}
+	return a.apply(adjustment)
+}
+
+func (a *adjustmentService) apply(adjustment *adjustmentModel.Adjustment) (*adjustmentModel.Adjustment, error) {
+	err := a.refund(adjustment)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	adjustment.AppliedAt = &now
+	err = a.repo.MarkApplied(adjustment)
+	if err != nil {
+		return nil, err
+	}
+	adjustment.Status = adjustmentModel.Applied
+	return adjustment, nil
+}
+
+// refund refunds each payment with a key tied to the adjustment and the payment, so running it
+// again after a failure only refunds what has not been refunded yet. The payment service takes
+// back the loyalty points of whatever it refunds.
+func (a *adjustmentService) refund(adjustment *adjustmentModel.Adjustment) error {
+	for _, r := range adjustment.Refunds {
+		_, err := a.payments.Refund(r.PaymentId, paymentModel.RefundRequest{
+			Amount:         r.Amount,
+			IdempotencyKey: refundKey(adjustment.Id, r.PaymentId),
+		})
+		if err != nil {
+			a.log.Errorf("Error Refunding Adjustment %v: %v", adjustment.Id, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func refundKey(adjustmentId, paymentId uuid.UUID) string {
+	return fmt.Sprintf("adjustment:%v:%v", adjustmentId, paymentId)
+}
+
+func (a *adjustmentService) ListAdjustments(orderId uuid.UUID) ([]adjustmentModel.Adjustment, error) {
+	return a.repo.FindByOrder(orderId)
+}
+
+// Reconcile checks the adjustments against the order total and against what the payments
+// actually refunded for them.
+func (a *adjustmentService) Reconcile(orderId uuid.UUID) (*adjustmentModel.Reconciliation, error) {
+	order, err := a.orders.FindById(orderId)
+	if err != nil {
+		return nil, err
+	}
+	adjustments, err := a.repo.FindByOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := a.payments.ListByOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := a.payments.ListRefunds(orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	r := adjustmentModel.Reconciliation{OrderId: orderId, Currency: order.Currency, Total: order.Total}
+	keys := map[string]bool{}
+	for _, adj := range adjustments {
+		switch adj.Status {
+		case adjustmentModel.Applied:
+			r.Applied += adj.Amount
+		case adjustmentModel.PendingApproval, adjustmentModel.Approved:
+			r.Pending += adj.Amount
+		}
+		for _, refund := range adj.Refunds {
+			keys[refundKey(adj.Id, refund.PaymentId)] = true
+		}
+	}
+	for _, refund := range refunds {
+		if keys[refund.IdempotencyKey] {
+			r.Refunded += refund.Amount.Amount
+		}
+	}
+	for _, p := range payments {
+		r.Other += p.RefundedAmount.Amount
+	}
+	r.Other -= r.Refunded
+	r.Net = r.Total - r.Applied
+	r.Balanced = r.Refunded == r.Applied && r.Applied+r.Pending <= r.Total
+	return &r, nil
+}
+
+func NewAdjustmentService(log *logrus.Logger, repo adjustmentRepo.RepoInterface, orders orderRepo.RepoInterface, payments paymentService.ServiceInterface) ServiceInterface {
+	return &adjustmentService{log: log, repo: repo, orders: orders, payments: payments}
+}
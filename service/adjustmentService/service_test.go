@@ -0,0 +1,267 @@
+package adjustmentService
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/adjustmentModel"
+	"rsm/entity/moneyModel"
+	"rsm/entity/orderModel"
+	"rsm/entity/paymentModel"
+	"testing"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Persist(adjustment *adjustmentModel.Adjustment) error {
+	args := m.Called(adjustment)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindById(id uuid.UUID) (*adjustmentModel.Adjustment, error) {
+	args := m.Called(id)
+	return args.Get(0).(*adjustmentModel.Adjustment), args.Error(1)
+}
+
+func (m *MockRepository) FindByOrder(orderId uuid.UUID) ([]adjustmentModel.Adjustment, error) {
+	args := m.Called(orderId)
+	return args.Get(0).([]adjustmentModel.Adjustment), args.Error(1)
+}
+
+func (m *MockRepository) Decide(adjustment *adjustmentModel.Adjustment) error {
+	args := m.Called(adjustment)
+	return args.Error(0)
+}
+
+func (m *MockRepository) MarkApplied(adjustment *adjustmentModel.Adjustment) error {
+	args := m.Called(adjustment)
+	return args.Error(0)
+}
+
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepository) FindById(id uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(id)
+	return args.Get(0).(*orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) FindByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error) {
+	args := m.Called(restaurantId, statuses)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) FindByUser(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error) {
+	args := m.Called(userId, query)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) UpdateStatus(id uuid.UUID, from, to orderModel.Status) error {
+	args := m.Called(id, from, to)
+	return args.Error(0)
+}
+
+type MockPaymentService struct {
+	mock.Mock
+}
+
+func (m *MockPaymentService) Authorize(request paymentModel.AuthorizeRequest) (*paymentModel.Payment, error) {
+	args := m.Called(request)
+	return args.Get(0).(*paymentModel.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) Capture(paymentId uuid.UUID) (*paymentModel.Payment, error) {
+	args := m.Called(paymentId)
+	return args.Get(0).(*paymentModel.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) Void(paymentId uuid.UUID) (*paymentModel.Payment, error) {
+	args := m.Called(paymentId)
+	return args.Get(0).(*paymentModel.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) Refund(paymentId uuid.UUID, request paymentModel.RefundRequest) (*paymentModel.Refund, error) {
+	args := m.Called(paymentId, request)
+	return args.Get(0).(*paymentModel.Refund), args.Error(1)
+}
+
+func (m *MockPaymentService) HandleWebhook(payload []byte, signature string) error {
+	args := m.Called(payload, signature)
+	return args.Error(0)
+}
+
+func (m *MockPaymentService) GetPayment(id uuid.UUID) (*paymentModel.Payment, error) {
+	args := m.Called(id)
+	return args.Get(0).(*paymentModel.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) ListByOrder(orderId uuid.UUID) ([]paymentModel.Payment, error) {
+	args := m.Called(orderId)
+	return args.Get(0).([]paymentModel.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) ListRefunds(orderId uuid.UUID) ([]paymentModel.Refund, error) {
+	args := m.Called(orderId)
+	return args.Get(0).([]paymentModel.Refund), args.Error(1)
+}
+
+func captured(orderId uuid.UUID, amount, refunded int64) paymentModel.Payment {
+	return paymentModel.Payment{
+		Id:             uuid.New(),
+		OrderId:        orderId,
+		Status:         paymentModel.Captured,
+		Amount:         moneyModel.New(amount, "NGN"),
+		CapturedAmount: moneyModel.New(amount, "NGN"),
+		RefundedAmount: moneyModel.New(refunded, "NGN"),
+	}
+}
+
+func testOrder() *orderModel.Order {
+	return &orderModel.Order{Id: uuid.New(), Currency: "NGN", Total: 10000, Status: orderModel.Completed,
+		Items: []orderModel.OrderItem{
+			{Id: 1, MenuId: 11, Quantity: 1, UnitPrice: 3000},
+			{Id: 2, MenuId: 12, Quantity: 2, UnitPrice: 2000},
+			{Id: 3, MenuId: 13, Quantity: 1, UnitPrice: 1000},
+		}}
+}
+
+func Test_adjustmentService_RequestAdjustment(t *testing.T) {
+	order := testOrder()
+	first, second := captured(order.Id, 6000, 0), captured(order.Id, 4000, 0)
+	staff := adjustmentModel.Actor{UserId: uuid.New(), Role: adjustmentModel.Staff}
+
+	mockRepo := new(MockRepository)
+	mockOrders := new(MockOrderRepository)
+	mockPayments := new(MockPaymentService)
+	mockOrders.On("FindById", order.Id).Return(order, nil)
+	mockRepo.On("FindByOrder", order.Id).Return([]adjustmentModel.Adjustment{{
+		Status: adjustmentModel.Applied,
+		Amount: 2500,
+		Lines:  []adjustmentModel.Line{{OrderItemId: 2, Quantity: 1, Amount: 2500}},
+	}, {
+		Status: adjustmentModel.Rejected,
+		Lines:  []adjustmentModel.Line{{OrderItemId: 1, Quantity: 1, Amount: 3750}},
+	}}, nil)
+	mockRepo.On("Persist", mock.Anything).Return(nil)
+	mockRepo.On("MarkApplied", mock.Anything).Return(nil)
+	mockPayments.On("ListByOrder", order.Id).Return([]paymentModel.Payment{first, second}, nil)
+	mockPayments.On("Refund", mock.Anything, mock.Anything).Return(&paymentModel.Refund{}, nil)
+
+	a := NewAdjustmentService(log, mockRepo, mockOrders, mockPayments)
+
+	// The side dish is within staff limits and is applied straight away.
+	adjustment, err := a.RequestAdjustment(staff, order.Id, adjustmentModel.AdjustmentRequest{
+		Reason: adjustmentModel.WrongItem,
+		Lines:  []adjustmentModel.LineRequest{{OrderItemId: 3, Quantity: 1, Restock: true}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, adjustmentModel.Applied, adjustment.Status)
+	assert.Equal(t, int64(1250), adjustment.Amount)
+	assert.Equal(t, []adjustmentModel.Refund{{PaymentId: first.Id, Amount: 1250}}, adjustment.Refunds)
+	mockPayments.AssertCalled(t, "Refund", first.Id, paymentModel.RefundRequest{Amount: 1250,
+		IdempotencyKey: fmt.Sprintf("adjustment:%v:%v", adjustment.Id, first.Id)})
+
+	// A full refund skips the unit already refunded and needs a manager or above.
+	mockPayments.Calls = nil
+	adjustment, err = a.RequestAdjustment(staff, order.Id, adjustmentModel.AdjustmentRequest{
+		Reason: adjustmentModel.QualityIssue,
+		Full:   true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, adjustmentModel.PendingApproval, adjustment.Status)
+	assert.Equal(t, int64(7500), adjustment.Amount)
+	assert.Empty(t, adjustment.Refunds)
+	mockPayments.AssertNotCalled(t, "Refund", mock.Anything, mock.Anything)
+
+	_, err = a.RequestAdjustment(staff, order.Id, adjustmentModel.AdjustmentRequest{
+		Reason: adjustmentModel.MissingItem,
+		Lines:  []adjustmentModel.LineRequest{{OrderItemId: 2, Quantity: 2}},
+	})
+	assert.Equal(t, fmt.Errorf("item 2: only 1 of 2 units left to refund"), err)
+
+	_, err = a.RequestAdjustment(adjustmentModel.Actor{UserId: uuid.New(), Role: "chef"}, order.Id,
+		adjustmentModel.AdjustmentRequest{Reason: adjustmentModel.Goodwill, Full: true})
+	assert.Equal(t, fmt.Errorf("something went wrong while validation"), err)
+}
+
+func Test_adjustmentService_Approve(t *testing.T) {
+	order := testOrder()
+	payment := captured(order.Id, 10000, 0)
+	requester := uuid.New()
+	pending := &adjustmentModel.Adjustment{Id: uuid.New(), OrderId: order.Id, Amount: 7500,
+		Status: adjustmentModel.PendingApproval, RequestedBy: requester, Refunds: []adjustmentModel.Refund{},
+		Lines: []adjustmentModel.Line{{OrderItemId: 1, MenuId: 11, Quantity: 1, Amount: 3750}}}
+	promised := adjustmentModel.Adjustment{Id: uuid.New(), Status: adjustmentModel.Approved, Amount: 2000,
+		Refunds: []adjustmentModel.Refund{{PaymentId: payment.Id, Amount: 2000}}}
+
+	mockRepo := new(MockRepository)
+	mockOrders := new(MockOrderRepository)
+	mockPayments := new(MockPaymentService)
+	mockOrders.On("FindById", order.Id).Return(order, nil)
+	mockRepo.On("FindById", pending.Id).Return(pending, nil)
+	mockRepo.On("FindByOrder", order.Id).Return([]adjustmentModel.Adjustment{promised, *pending}, nil)
+	mockRepo.On("Decide", pending).Return(nil)
+	mockRepo.On("MarkApplied", pending).Return(nil)
+	mockPayments.On("ListByOrder", order.Id).Return([]paymentModel.Payment{payment}, nil)
+	mockPayments.On("Refund", payment.Id, mock.Anything).Return(&paymentModel.Refund{}, nil)
+
+	a := NewAdjustmentService(log, mockRepo, mockOrders, mockPayments)
+
+	_, err := a.Approve(adjustmentModel.Actor{UserId: requester, Role: adjustmentModel.Admin}, pending.Id)
+	assert.Equal(t, fmt.Errorf("cannot decide on your own adjustment"), err)
+
+	_, err = a.Approve(adjustmentModel.Actor{UserId: uuid.New(), Role: adjustmentModel.Manager}, pending.Id)
+	assert.Equal(t, fmt.Errorf("adjustment exceeds the manager approval limit"), err)
+
+	// Only 8000 is refundable once the other approved adjustment's 2000 is set aside.
+	pending.Amount = 9000
+	_, err = a.Approve(adjustmentModel.Actor{UserId: uuid.New(), Role: adjustmentModel.Admin}, pending.Id)
+	assert.Equal(t, fmt.Errorf("payments cover only 8000 of the 9000 to refund"), err)
+	mockRepo.AssertNotCalled(t, "Decide", mock.Anything)
+
+	pending.Amount, pending.Refunds = 7500, nil
+	admin := adjustmentModel.Actor{UserId: uuid.New(), Role: adjustmentModel.Admin}
+	adjustment, err := a.Approve(admin, pending.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, adjustmentModel.Applied, adjustment.Status)
+	assert.Equal(t, &admin.UserId, adjustment.DecidedBy)
+	assert.Equal(t, []adjustmentModel.Refund{{PaymentId: payment.Id, Amount: 7500}}, adjustment.Refunds)
+	mockPayments.AssertNumberOfCalls(t, "Refund", 1)
+}
+
+func Test_adjustmentService_Reconcile(t *testing.T) {
+	order := testOrder()
+	first, second := captured(order.Id, 6000, 1250), captured(order.Id, 4000, 500)
+	applied := adjustmentModel.Adjustment{Id: uuid.New(), Status: adjustmentModel.Applied, Amount: 1250,
+		Refunds: []adjustmentModel.Refund{{PaymentId: first.Id, Amount: 1250}}}
+
+	mockRepo := new(MockRepository)
+	mockOrders := new(MockOrderRepository)
+	mockPayments := new(MockPaymentService)
+	mockOrders.On("FindById", order.Id).Return(order, nil)
+	mockRepo.On("FindByOrder", order.Id).Return([]adjustmentModel.Adjustment{
+		applied,
+		{Id: uuid.New(), Status: adjustmentModel.PendingApproval, Amount: 3750},
+		{Id: uuid.New(), Status: adjustmentModel.Rejected, Amount: 5000},
+	}, nil)
+	mockPayments.On("ListByOrder", order.Id).Return([]paymentModel.Payment{first, second}, nil)
+	// The second payment's refund was made outside any adjustment, so it does not unbalance
+	// the order.
+	mockPayments.On("ListRefunds", order.Id).Return([]paymentModel.Refund{
+		{PaymentId: first.Id, IdempotencyKey: refundKey(applied.Id, first.Id), Amount: moneyModel.New(1250, "NGN")},
+		{PaymentId: second.Id, IdempotencyKey: paymentModel.OverpaidKey, Amount: moneyModel.New(500, "NGN")},
+	}, nil)
+
+	a := NewAdjustmentService(log, mockRepo, mockOrders, mockPayments)
+	r, err := a.Reconcile(order.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, &adjustmentModel.Reconciliation{OrderId: order.Id, Currency: "NGN", Total: 10000, Applied: 1250,
+		Pending: 3750, Refunded: 1250, Other: 500, Net: 8750, Balanced: true}, r)
+}
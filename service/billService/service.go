@@ -0,0 +1,211 @@
+package billService
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/billModel"
+	"rsm/entity/orderModel"
+	"rsm/entity/paymentModel"
+	"rsm/pricing"
+	"rsm/repository/billRepo"
+	"rsm/repository/orderRepo"
+	"rsm/service/paymentService"
+	"time"
+)
+
+type ServiceInterface interface {
+	SplitOrder(orderId uuid.UUID, request billModel.SplitRequest) (*billModel.Bill, error)
+	PayShare(shareId uuid.UUID, request billModel.PayShareRequest) (*billModel.Bill, error)
+	GetBill(orderId uuid.UUID) (*billModel.Bill, error)
+}
+
+type billService struct {
+	log      *logrus.Logger
+	repo     billRepo.RepoInterface
+	orders   orderRepo.RepoInterface
+	payments paymentService.ServiceInterface
+}
+
+// SplitOrder divides the order total between the payers. An order can only be split once.
+func (b *billService) SplitOrder(orderId uuid.UUID, request billModel.SplitRequest) (*billModel.Bill, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		b.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+
+	order, err := b.orders.FindById(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == orderModel.Cancelled {
+		return nil, fmt.Errorf("cannot split a cancelled order")
+	}
+	if order.SettledAt != nil {
+		return nil, fmt.Errorf("order is already settled")
+	}
+	_, err = b.repo.FindByOrder(orderId)
+	if err == nil {
+		return nil, fmt.Errorf("order already has a bill")
+	}
+	if !errors.Is(err, billRepo.ErrNotFound) {
+		return nil, err
+	}
+
+	amounts, err := split(order, request)
+	if err != nil {
+		return nil, err
+	}
+
+	bill := billModel.Bill{
+		Id:        uuid.New(),
+		OrderId:   orderId,
+		Method:    request.Method,
+		Currency:  order.Currency,
+		Total:     order.Total,
+		Status:    billModel.Open,
+		CreatedAt: time.Now(),
+	}
+	for i, payer := range request.Payers {
+		bill.Shares = append(bill.Shares, billModel.Share{
+			Id:       uuid.New(),
+			BillId:   bill.Id,
+			Name:     payer.Name,
+			UserId:   payer.UserId,
+			Amount:   amounts[i],
+			Payments: []billModel.Payment{},
+		})
+	}
+	return b.repo.Persist(&bill)
+}
+
+func split(order *orderModel.Order, request billModel.SplitRequest) ([]int64, error) {
+	switch request.Method {
+	case billModel.Equal:
+		return pricing.SplitEqual(order.Total, len(request.Payers))
+	case billModel.Custom:
+		amounts := make([]int64, len(request.Payers))
+		for i, payer := range request.Payers {
+			amounts[i] = payer.Amount
+		}
+		return pricing.SplitCustom(order.Total, amounts)
+	default:
+		assignments := make([][]int64, len(request.Payers))
+		for i, payer := range request.Payers {
+			assignments[i] = payer.Items
+		}
+		return pricing.SplitByItem(order.Total, order.Items, assignments)
+	}
+}
+
+// PayShare charges a payer for part or all of what is left on their share, plus any tip.
+// The payment is authorised and captured straight away; retrying with the same idempotency
+// key does not charge the payer again and returns the bill once the payment is recorded. If
+// another payment covers the share while this one is being captured, this one is refunded.
+func (b *billService) PayShare(shareId uuid.UUID, request billModel.PayShareRequest) (*billModel.Bill, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		b.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+
+	bill, err := b.repo.FindByShare(shareId)
+	if err != nil {
+		return nil, err
+	}
+	share, err := bill.Share(shareId)
+	if err != nil {
+		return nil, err
+	}
+	done, err := b.recorded(bill.OrderId, share, request.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if done {
+		return bill, nil
+	}
+	if request.Amount > share.Remaining() {
+		return nil, fmt.Errorf("payment exceeds the %d left on this share", share.Remaining())
+	}
+
+	order, err := b.orders.FindById(bill.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	payerId := order.UserId
+	if share.UserId != nil {
+		payerId = *share.UserId
+	}
+	if payerId == uuid.Nil {
+		return nil, fmt.Errorf("a guest's share must be claimed by a signed-in user or paid to staff")
+	}
+
+	payment, err := b.payments.Authorize(paymentModel.AuthorizeRequest{
+		OrderId:        bill.OrderId,
+		UserId:         payerId,
+		Amount:         request.Amount + request.Tip,
+		Currency:       bill.Currency,
+		SourceToken:    request.SourceToken,
+		IdempotencyKey: request.IdempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status == paymentModel.Failed {
+		return nil, fmt.Errorf("payment declined: %v", payment.FailureReason)
+	}
+	payment, err = b.payments.Capture(payment.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded, err := b.repo.RecordPayment(bill.Id, billModel.Payment{
+		PaymentId: payment.Id,
+		ShareId:   shareId,
+		Amount:    request.Amount,
+		Tip:       request.Tip,
+		CreatedAt: time.Now(),
+	})
+	if errors.Is(err, billRepo.ErrOverpaid) {
+		_, refundErr := b.payments.Refund(payment.Id, paymentModel.RefundRequest{
+			Amount:         payment.CapturedAmount.Amount,
+			IdempotencyKey: paymentModel.OverpaidKey,
+		})
+		if refundErr != nil {
+			b.log.Errorf("Error Refunding Overpayment %v: %v", payment.Id, refundErr)
+			return nil, fmt.Errorf("share was paid by another payment and the refund failed, contact support")
+		}
+		return nil, fmt.Errorf("share was paid by another payment, this payment has been refunded")
+	}
+	return recorded, err
+}
+
+// recorded reports whether the payment made with the idempotency key is already recorded on
+// the share.
+func (b *billService) recorded(orderId uuid.UUID, share *billModel.Share, idempotencyKey string) (bool, error) {
+	payments, err := b.payments.ListByOrder(orderId)
+	if err != nil {
+		return false, err
+	}
+	for _, payment := range payments {
+		if payment.IdempotencyKey != idempotencyKey {
+			continue
+		}
+		for _, paid := range share.Payments {
+			if paid.PaymentId == payment.Id {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (b *billService) GetBill(orderId uuid.UUID) (*billModel.Bill, error) {
+	return b.repo.FindByOrder(orderId)
+}
+
+func NewBillService(log *logrus.Logger, repo billRepo.RepoInterface, orders orderRepo.RepoInterface, payments paymentService.ServiceInterface) ServiceInterface {
+	return &billService{log: log, repo: repo, orders: orders, payments: payments}
+}
@@ -0,0 +1,300 @@
+package billService
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/billModel"
+	"rsm/entity/moneyModel"
+	"rsm/entity/orderModel"
+	"rsm/entity/paymentModel"
+	"rsm/repository/billRepo"
+	"testing"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Persist(bill *billModel.Bill) (*billModel.Bill, error) {
+	args := m.Called(bill)
+	return args.Get(0).(*billModel.Bill), args.Error(1)
+}
+
+func (m *MockRepository) FindByOrder(orderId uuid.UUID) (*billModel.Bill, error) {
+	args := m.Called(orderId)
+	bill, _ := args.Get(0).(*billModel.Bill)
+	return bill, args.Error(1)
+}
+
+func (m *MockRepository) FindByShare(shareId uuid.UUID) (*billModel.Bill, error) {
+	args := m.Called(shareId)
+	bill, _ := args.Get(0).(*billModel.Bill)
+	return bill, args.Error(1)
+}
+
+func (m *MockRepository) RecordPayment(billId uuid.UUID, payment billModel.Payment) (*billModel.Bill, error) {
+	args := m.Called(billId, payment)
+	bill, _ := args.Get(0).(*billModel.Bill)
+	return bill, args.Error(1)
+}
+
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepository) FindById(id uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(id)
+	return args.Get(0).(*orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) FindByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error) {
+	args := m.Called(restaurantId, statuses)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) FindByUser(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error) {
+	args := m.Called(userId, query)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) UpdateStatus(id uuid.UUID, from, to orderModel.Status) error {
+	args := m.Called(id, from, to)
+	return args.Error(0)
+}
+
+type MockPaymentService struct {
+	mock.Mock
+}
+
+func (m *MockPaymentService) Authorize(request paymentModel.AuthorizeRequest) (*paymentModel.Payment, error) {
+	args := m.Called(request)
+	return args.Get(0).(*paymentModel.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) Capture(paymentId uuid.UUID) (*paymentModel.Payment, error) {
+	args := m.Called(paymentId)
+	return args.Get(0).(*paymentModel.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) Void(paymentId uuid.UUID) (*paymentModel.Payment, error) {
+	args := m.Called(paymentId)
+	return args.Get(0).(*paymentModel.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) Refund(paymentId uuid.UUID, request paymentModel.RefundRequest) (*paymentModel.Refund, error) {
+	args := m.Called(paymentId, request)
+	return args.Get(0).(*paymentModel.Refund), args.Error(1)
+}
+
+func (m *MockPaymentService) HandleWebhook(payload []byte, signature string) error {
+	args := m.Called(payload, signature)
+	return args.Error(0)
+}
+
+func (m *MockPaymentService) GetPayment(id uuid.UUID) (*paymentModel.Payment, error) {
+	args := m.Called(id)
+	return args.Get(0).(*paymentModel.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) ListByOrder(orderId uuid.UUID) ([]paymentModel.Payment, error) {
+	args := m.Called(orderId)
+	return args.Get(0).([]paymentModel.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) ListRefunds(orderId uuid.UUID) ([]paymentModel.Refund, error) {
+	args := m.Called(orderId)
+	return args.Get(0).([]paymentModel.Refund), args.Error(1)
+}
+
+func Test_billService_SplitOrder(t *testing.T) {
+	orderId, billedId := uuid.New(), uuid.New()
+	order := &orderModel.Order{Id: orderId, Currency: "NGN", Total: 10000, Status: orderModel.Completed,
+		Items: []orderModel.OrderItem{
+			{Id: 1, Quantity: 1, UnitPrice: 3000},
+			{Id: 2, Quantity: 2, UnitPrice: 2000},
+			{Id: 3, Quantity: 1, UnitPrice: 1000},
+		}}
+
+	mockRepo := new(MockRepository)
+	mockOrders := new(MockOrderRepository)
+	mockOrders.On("FindById", orderId).Return(order, nil)
+	mockOrders.On("FindById", billedId).Return(&orderModel.Order{Id: billedId, Total: 500}, nil)
+	mockRepo.On("FindByOrder", orderId).Return(nil, billRepo.ErrNotFound)
+	mockRepo.On("FindByOrder", billedId).Return(&billModel.Bill{}, nil)
+	mockRepo.On("Persist", mock.AnythingOfType("*billModel.Bill")).Return(&billModel.Bill{}, nil)
+
+	payers := func(items ...[]int64) []billModel.PayerRequest {
+		p := make([]billModel.PayerRequest, len(items))
+		for i := range items {
+			p[i] = billModel.PayerRequest{Name: fmt.Sprintf("payer %d", i), Items: items[i]}
+		}
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		orderId uuid.UUID
+		request billModel.SplitRequest
+		shares  []int64
+		err     error
+	}{
+		{
+			name:    "equal",
+			orderId: orderId,
+			request: billModel.SplitRequest{Method: billModel.Equal, Payers: payers(nil, nil, nil)},
+			shares:  []int64{3334, 3333, 3333},
+		}, {
+			name:    "by item with a shared item",
+			orderId: orderId,
+			request: billModel.SplitRequest{Method: billModel.ByItem, Payers: payers([]int64{1, 3}, []int64{2, 3})},
+			shares:  []int64{4375, 5625},
+		}, {
+			name:    "custom amounts must cover the total",
+			orderId: orderId,
+			request: billModel.SplitRequest{Method: billModel.Custom, Payers: []billModel.PayerRequest{
+				{Name: "a", Amount: 6000}, {Name: "b", Amount: 3000}}},
+			err: fmt.Errorf("shares add up to 9000, order total is 10000"),
+		}, {
+			name:    "already split",
+			orderId: billedId,
+			request: billModel.SplitRequest{Method: billModel.Equal, Payers: payers(nil)},
+			err:     fmt.Errorf("order already has a bill"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo.Calls = nil
+			b := NewBillService(log, mockRepo, mockOrders, new(MockPaymentService))
+			_, err := b.SplitOrder(tt.orderId, tt.request)
+			assert.Equal(t, tt.err, err)
+			if tt.err != nil {
+				mockRepo.AssertNotCalled(t, "Persist", mock.Anything)
+				return
+			}
+			bill := mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(0).(*billModel.Bill)
+			var shares []int64
+			for _, s := range bill.Shares {
+				shares = append(shares, s.Amount)
+			}
+			assert.Equal(t, tt.shares, shares)
+			assert.Equal(t, billModel.Open, bill.Status)
+		})
+	}
+}
+
+func Test_billService_PayShare(t *testing.T) {
+	orderId, ownerId, guestId := uuid.New(), uuid.New(), uuid.New()
+	paymentId, declinedId := uuid.New(), uuid.New()
+	bill := &billModel.Bill{Id: uuid.New(), OrderId: orderId, Currency: "NGN", Total: 5000, Status: billModel.Open,
+		Shares: []billModel.Share{
+			{Id: uuid.New(), Amount: 2500, Paid: 1000},
+			{Id: uuid.New(), UserId: &guestId, Amount: 2500},
+		}}
+	host, guest := bill.Shares[0].Id, bill.Shares[1].Id
+
+	mockRepo := new(MockRepository)
+	mockOrders := new(MockOrderRepository)
+	mockPayments := new(MockPaymentService)
+	mockRepo.On("FindByShare", mock.Anything).Return(bill, nil)
+	mockOrders.On("FindById", orderId).Return(&orderModel.Order{Id: orderId, UserId: ownerId}, nil)
+	mockPayments.On("ListByOrder", orderId).Return([]paymentModel.Payment{}, nil)
+	mockPayments.On("Authorize", mock.MatchedBy(func(r paymentModel.AuthorizeRequest) bool {
+		return r.UserId == ownerId && r.Amount == 1800 && r.Currency == "NGN"
+	})).Return(&paymentModel.Payment{Id: paymentId, Status: paymentModel.Authorized}, nil)
+	mockPayments.On("Authorize", mock.MatchedBy(func(r paymentModel.AuthorizeRequest) bool {
+		return r.UserId == guestId
+	})).Return(&paymentModel.Payment{Id: declinedId, Status: paymentModel.Failed, FailureReason: "card_declined"}, nil)
+	mockPayments.On("Capture", paymentId).Return(&paymentModel.Payment{Id: paymentId, Status: paymentModel.Captured}, nil)
+	mockRepo.On("RecordPayment", bill.Id, mock.MatchedBy(func(p billModel.Payment) bool {
+		return p.PaymentId == paymentId && p.ShareId == host && p.Amount == 1500 && p.Tip == 300
+	})).Return(bill, nil)
+
+	b := NewBillService(log, mockRepo, mockOrders, mockPayments)
+	request := func(amount, tip int64) billModel.PayShareRequest {
+		return billModel.PayShareRequest{Amount: amount, Tip: tip, SourceToken: "tok", IdempotencyKey: uuid.NewString()}
+	}
+
+	_, err := b.PayShare(host, request(1500, 300))
+	assert.Nil(t, err)
+
+	_, err = b.PayShare(host, request(1600, 0))
+	assert.Equal(t, fmt.Errorf("payment exceeds the 1500 left on this share"), err)
+
+	_, err = b.PayShare(guest, request(2500, 0))
+	assert.Equal(t, fmt.Errorf("payment declined: card_declined"), err)
+
+	mockPayments.AssertNumberOfCalls(t, "Capture", 1)
+	mockRepo.AssertNumberOfCalls(t, "RecordPayment", 1)
+}
+
+func Test_billService_PayShareGuestOrder(t *testing.T) {
+	orderId := uuid.New()
+	bill := &billModel.Bill{Id: uuid.New(), OrderId: orderId, Currency: "NGN", Total: 3000, Status: billModel.Open,
+		Shares: []billModel.Share{{Id: uuid.New(), Amount: 3000}}}
+
+	mockRepo := new(MockRepository)
+	mockOrders := new(MockOrderRepository)
+	mockPayments := new(MockPaymentService)
+	mockRepo.On("FindByShare", mock.Anything).Return(bill, nil)
+	mockOrders.On("FindById", orderId).Return(&orderModel.Order{Id: orderId}, nil)
+	mockPayments.On("ListByOrder", orderId).Return([]paymentModel.Payment{}, nil)
+
+	b := NewBillService(log, mockRepo, mockOrders, mockPayments)
+	_, err := b.PayShare(bill.Shares[0].Id, billModel.PayShareRequest{Amount: 3000, SourceToken: "tok",
+		IdempotencyKey: uuid.NewString()})
+	assert.NotNil(t, err)
+	mockPayments.AssertNotCalled(t, "Authorize", mock.Anything)
+}
+
+func Test_billService_PayShareRetried(t *testing.T) {
+	orderId, paymentId := uuid.New(), uuid.New()
+	bill := &billModel.Bill{Id: uuid.New(), OrderId: orderId, Currency: "NGN", Total: 3000, Status: billModel.Settled,
+		Shares: []billModel.Share{{Id: uuid.New(), Amount: 3000, Paid: 3000,
+			Payments: []billModel.Payment{{PaymentId: paymentId, Amount: 3000}}}}}
+
+	mockRepo := new(MockRepository)
+	mockPayments := new(MockPaymentService)
+	mockRepo.On("FindByShare", mock.Anything).Return(bill, nil)
+	mockPayments.On("ListByOrder", orderId).Return([]paymentModel.Payment{
+		{Id: paymentId, IdempotencyKey: "pay-1", Status: paymentModel.Captured}}, nil)
+
+	b := NewBillService(log, mockRepo, new(MockOrderRepository), mockPayments)
+	got, err := b.PayShare(bill.Shares[0].Id, billModel.PayShareRequest{Amount: 3000, SourceToken: "tok",
+		IdempotencyKey: "pay-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, bill, got)
+	mockPayments.AssertNotCalled(t, "Authorize", mock.Anything)
+}
+
+func Test_billService_PayShareOverpaid(t *testing.T) {
+	orderId, userId, paymentId := uuid.New(), uuid.New(), uuid.New()
+	bill := &billModel.Bill{Id: uuid.New(), OrderId: orderId, Currency: "NGN", Total: 3000, Status: billModel.Open,
+		Shares: []billModel.Share{{Id: uuid.New(), Amount: 3000}}}
+	captured := &paymentModel.Payment{Id: paymentId, Status: paymentModel.Captured,
+		CapturedAmount: moneyModel.New(3200, "NGN")}
+
+	// Another payment covered the share between the check and the recording.
+	mockRepo := new(MockRepository)
+	mockOrders := new(MockOrderRepository)
+	mockPayments := new(MockPaymentService)
+	mockRepo.On("FindByShare", mock.Anything).Return(bill, nil)
+	mockOrders.On("FindById", orderId).Return(&orderModel.Order{Id: orderId, UserId: userId}, nil)
+	mockPayments.On("ListByOrder", orderId).Return([]paymentModel.Payment{}, nil)
+	mockPayments.On("Authorize", mock.Anything).Return(&paymentModel.Payment{Id: paymentId,
+		Status: paymentModel.Authorized}, nil)
+	mockPayments.On("Capture", paymentId).Return(captured, nil)
+	mockRepo.On("RecordPayment", bill.Id, mock.Anything).Return(nil, billRepo.ErrOverpaid)
+	mockPayments.On("Refund", paymentId, paymentModel.RefundRequest{Amount: 3200, IdempotencyKey: "bill-overpaid"}).
+		Return(&paymentModel.Refund{}, nil)
+
+	b := NewBillService(log, mockRepo, mockOrders, mockPayments)
+	_, err := b.PayShare(bill.Shares[0].Id, billModel.PayShareRequest{Amount: 3000, Tip: 200, SourceToken: "tok",
+		IdempotencyKey: "pay-2"})
+	assert.NotNil(t, err)
+	mockPayments.AssertCalled(t, "Refund", paymentId, mock.Anything)
+}
@@ -0,0 +1,322 @@
+package brandService
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/brandModel"
+	"rsm/repository/brandRepo"
+	"rsm/repository/restaurantRepo"
+	"time"
+)
+
+type ServiceInterface interface {
+	CreateBrand(ownerId uuid.UUID, brand *brandModel.Brand) error
+	AddLocation(userId, brandId, restaurantId uuid.UUID) error
+	SetMember(userId uuid.UUID, member *brandModel.Member) error
+	RemoveMember(userId, brandId, memberId uuid.UUID) error
+	ListMembers(userId, brandId uuid.UUID) ([]brandModel.Member, error)
+	// RoleAt returns the user's role at a restaurant, or brandModel.ErrForbidden if they have
+	// none. The owner of a restaurant outside any brand is its Owner.
+	RoleAt(userId, restaurantId uuid.UUID) (brandModel.Role, error)
+	SaveMasterItem(userId uuid.UUID, item *brandModel.MasterItem) error
+	DeleteMasterItem(userId, brandId, id uuid.UUID) error
+	SetOverride(userId uuid.UUID, override *brandModel.Override) error
+	LocationMenu(restaurantId uuid.UUID) ([]brandModel.LocationItem, error)
+}
+
+type brandService struct {
+	log         *logrus.Logger
+	repo        brandRepo.RepoInterface
+	restaurants restaurantRepo.RepoInterface
+}
+
+func (b *brandService) CreateBrand(ownerId uuid.UUID, brand *brandModel.Brand) error {
+	err := brand.ValidateInput()
+	if err != nil {
+		b.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	brand.Id, brand.OwnerId, brand.CreatedAt = uuid.New(), ownerId, time.Now()
+	return b.repo.PersistBrand(brand)
+}
+
+// AddLocation brings one of the user's own restaurants into a brand they administer, and
+// replaces its menu with the brand's: the items it had before are marked unavailable.
+func (b *brandService) AddLocation(userId, brandId, restaurantId uuid.UUID) error {
+	_, err := b.brandRole(userId, brandId, brandModel.Admin)
+	if err != nil {
+		return err
+	}
+	restaurant, err := b.restaurants.FindById(restaurantId)
+	if err != nil {
+		return err
+	}
+	if restaurant.OwnerId != userId {
+		return brandModel.ErrForbidden
+	}
+	err = b.repo.AttachLocation(brandId, restaurantId)
+	if err != nil {
+		return err
+	}
+	return b.syncLocation(brandId, restaurantId)
+}
+
+// SetMember adds or changes a member. Users can only hand out, or change, roles below their
+// own, so an admin can appoint managers but not other admins.
+func (b *brandService) SetMember(userId uuid.UUID, member *brandModel.Member) error {
+	err := member.ValidateInput()
+	if err != nil {
+		b.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	actor, err := b.brandRole(userId, member.BrandId, brandModel.Admin)
+	if err != nil {
+		return err
+	}
+	if !outranks(actor.Role, member.Role) {
+		return brandModel.ErrForbidden
+	}
+	existing, err := b.repo.FindMember(member.BrandId, member.UserId)
+	if err == nil && !outranks(actor.Role, existing.Role) {
+		return brandModel.ErrForbidden
+	}
+	if err != nil && !errors.Is(err, brandRepo.ErrMemberNotFound) {
+		return err
+	}
+
+	locations, err := b.repo.FindLocations(member.BrandId)
+	if err != nil {
+		return err
+	}
+	for _, id := range member.Locations {
+		if !contains(locations, id) {
+			return fmt.Errorf("restaurant %v is not a location of this brand", id)
+		}
+	}
+	member.CreatedAt = time.Now()
+	if existing != nil {
+		member.CreatedAt = existing.CreatedAt
+	}
+	return b.repo.SaveMember(member)
+}
+
+func (b *brandService) RemoveMember(userId, brandId, memberId uuid.UUID) error {
+	actor, err := b.brandRole(userId, brandId, brandModel.Admin)
+	if err != nil {
+		return err
+	}
+	existing, err := b.repo.FindMember(brandId, memberId)
+	if err != nil {
+		return err
+	}
+	if !outranks(actor.Role, existing.Role) {
+		return brandModel.ErrForbidden
+	}
+	return b.repo.DeleteMember(brandId, memberId)
+}
+
+// ListMembers is open to anyone in the brand.
+func (b *brandService) ListMembers(userId, brandId uuid.UUID) ([]brandModel.Member, error) {
+	_, err := b.repo.FindMember(brandId, userId)
+	if errors.Is(err, brandRepo.ErrMemberNotFound) {
+		return nil, brandModel.ErrForbidden
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b.repo.FindMembers(brandId)
+}
+
+func (b *brandService) RoleAt(userId, restaurantId uuid.UUID) (brandModel.Role, error) {
+	brandId, err := b.repo.FindLocationBrand(restaurantId)
+	if errors.Is(err, brandRepo.ErrNotFound) {
+		restaurant, err := b.restaurants.FindById(restaurantId)
+		if err != nil {
+			return "", err
+		}
+		if restaurant.OwnerId != userId {
+			return "", brandModel.ErrForbidden
+		}
+		return brandModel.Owner, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	member, err := b.repo.FindMember(brandId, userId)
+	if errors.Is(err, brandRepo.ErrMemberNotFound) || (err == nil && !member.Covers(restaurantId)) {
+		return "", brandModel.ErrForbidden
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// SaveMasterItem creates the item when it has no id and updates it otherwise, then brings
+// every location's menu up to date.
+func (b *brandService) SaveMasterItem(userId uuid.UUID, item *brandModel.MasterItem) error {
+	err := item.ValidateInput()
+	if err != nil {
+		b.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	_, err = b.brandRole(userId, item.BrandId, brandModel.Manager)
+	if err != nil {
+		return err
+	}
+	item.UpdatedAt = time.Now()
+	if item.Id == uuid.Nil {
+		item.Id, item.CreatedAt = uuid.New(), item.UpdatedAt
+		err = b.repo.PersistMasterItem(item)
+	} else {
+		err = b.repo.UpdateMasterItem(item)
+	}
+	if err != nil {
+		return err
+	}
+	return b.syncBrand(item.BrandId)
+}
+
+// DeleteMasterItem takes the item off the brand menu. Locations keep its Menu row, marked
+// unavailable, so past orders still point at it.
+func (b *brandService) DeleteMasterItem(userId, brandId, id uuid.UUID) error {
+	_, err := b.brandRole(userId, brandId, brandModel.Manager)
+	if err != nil {
+		return err
+	}
+	err = b.repo.DeleteMasterItem(brandId, id)
+	if err != nil {
+		return err
+	}
+	return b.syncBrand(brandId)
+}
+
+// SetOverride changes the price or availability of a master item at one location. Managers
+// limited to some locations may only change those.
+func (b *brandService) SetOverride(userId uuid.UUID, override *brandModel.Override) error {
+	err := override.ValidateInput()
+	if err != nil {
+		b.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	role, err := b.RoleAt(userId, override.RestaurantId)
+	if err != nil {
+		return err
+	}
+	if !role.AtLeast(brandModel.Manager) {
+		return brandModel.ErrForbidden
+	}
+	brandId, err := b.repo.FindLocationBrand(override.RestaurantId)
+	if err != nil {
+		return err
+	}
+	items, err := b.repo.FindMasterItems(brandId)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, item := range items {
+		found = found || item.Id == override.MasterItemId
+	}
+	if !found {
+		return brandRepo.ErrMasterItemNotFound
+	}
+
+	override.UpdatedAt = time.Now()
+	err = b.repo.SaveOverride(override)
+	if err != nil {
+		return err
+	}
+	return b.sync(override.RestaurantId, items)
+}
+
+func (b *brandService) LocationMenu(restaurantId uuid.UUID) ([]brandModel.LocationItem, error) {
+	brandId, err := b.repo.FindLocationBrand(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	items, err := b.repo.FindMasterItems(brandId)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := b.repo.FindOverrides(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	return brandModel.Apply(items, overrides), nil
+}
+
+// brandRole requires a role of at least need that applies across the whole brand.
+func (b *brandService) brandRole(userId, brandId uuid.UUID, need brandModel.Role) (*brandModel.Member, error) {
+	member, err := b.repo.FindMember(brandId, userId)
+	if errors.Is(err, brandRepo.ErrMemberNotFound) {
+		return nil, brandModel.ErrForbidden
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !member.Role.AtLeast(need) || len(member.Locations) > 0 {
+		return nil, brandModel.ErrForbidden
+	}
+	return member, nil
+}
+
+// syncBrand brings every location's menu up to date in one transaction, so a change to the
+// master menu reaches all locations or none.
+func (b *brandService) syncBrand(brandId uuid.UUID) error {
+	locations, err := b.repo.FindLocations(brandId)
+	if err != nil {
+		return err
+	}
+	items, err := b.repo.FindMasterItems(brandId)
+	if err != nil {
+		return err
+	}
+	menus := make(map[uuid.UUID][]brandModel.LocationItem, len(locations))
+	for _, restaurantId := range locations {
+		overrides, err := b.repo.FindOverrides(restaurantId)
+		if err != nil {
+			return err
+		}
+		menus[restaurantId] = brandModel.Apply(items, overrides)
+	}
+	return b.repo.SyncLocations(menus)
+}
+
+func (b *brandService) syncLocation(brandId, restaurantId uuid.UUID) error {
+	items, err := b.repo.FindMasterItems(brandId)
+	if err != nil {
+		return err
+	}
+	return b.sync(restaurantId, items)
+}
+
+func (b *brandService) sync(restaurantId uuid.UUID, items []brandModel.MasterItem) error {
+	overrides, err := b.repo.FindOverrides(restaurantId)
+	if err != nil {
+		return err
+	}
+	return b.repo.SyncLocations(map[uuid.UUID][]brandModel.LocationItem{
+		restaurantId: brandModel.Apply(items, overrides),
+	})
+}
+
+// outranks reports whether a user with the role may grant or change role other.
+func outranks(role, other brandModel.Role) bool {
+	return role.AtLeast(other) && role != other
+}
+
+func contains(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+func NewBrandService(log *logrus.Logger, repo brandRepo.RepoInterface, restaurants restaurantRepo.RepoInterface) ServiceInterface {
+	return &brandService{log: log, repo: repo, restaurants: restaurants}
+}
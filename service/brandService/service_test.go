@@ -0,0 +1,284 @@
+package brandService
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/brandModel"
+	"rsm/entity/restaurantModel"
+	"rsm/repository/brandRepo"
+	"testing"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) PersistBrand(brand *brandModel.Brand) error {
+	args := m.Called(brand)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindBrand(id uuid.UUID) (*brandModel.Brand, error) {
+	args := m.Called(id)
+	brand, _ := args.Get(0).(*brandModel.Brand)
+	return brand, args.Error(1)
+}
+
+func (m *MockRepository) AttachLocation(brandId, restaurantId uuid.UUID) error {
+	args := m.Called(brandId, restaurantId)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindLocations(brandId uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(brandId)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) FindLocationBrand(restaurantId uuid.UUID) (uuid.UUID, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) SaveMember(member *brandModel.Member) error {
+	args := m.Called(member)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteMember(brandId, userId uuid.UUID) error {
+	args := m.Called(brandId, userId)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindMember(brandId, userId uuid.UUID) (*brandModel.Member, error) {
+	args := m.Called(brandId, userId)
+	member, _ := args.Get(0).(*brandModel.Member)
+	return member, args.Error(1)
+}
+
+func (m *MockRepository) FindMembers(brandId uuid.UUID) ([]brandModel.Member, error) {
+	args := m.Called(brandId)
+	return args.Get(0).([]brandModel.Member), args.Error(1)
+}
+
+func (m *MockRepository) PersistMasterItem(item *brandModel.MasterItem) error {
+	args := m.Called(item)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateMasterItem(item *brandModel.MasterItem) error {
+	args := m.Called(item)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteMasterItem(brandId, id uuid.UUID) error {
+	args := m.Called(brandId, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindMasterItems(brandId uuid.UUID) ([]brandModel.MasterItem, error) {
+	args := m.Called(brandId)
+	return args.Get(0).([]brandModel.MasterItem), args.Error(1)
+}
+
+func (m *MockRepository) SaveOverride(override *brandModel.Override) error {
+	args := m.Called(override)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindOverrides(restaurantId uuid.UUID) ([]brandModel.Override, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]brandModel.Override), args.Error(1)
+}
+
+func (m *MockRepository) SyncLocations(menus map[uuid.UUID][]brandModel.LocationItem) error {
+	args := m.Called(menus)
+	return args.Error(0)
+}
+
+type MockRestaurantRepository struct {
+	mock.Mock
+}
+
+func (m *MockRestaurantRepository) Persist(restaurant *restaurantModel.RestaurantModel) (*restaurantModel.RestaurantModel, error) {
+	args := m.Called(restaurant)
+	return args.Get(0).(*restaurantModel.RestaurantModel), args.Error(1)
+}
+
+func (m *MockRestaurantRepository) FindById(id uuid.UUID) (*restaurantModel.RestaurantModel, error) {
+	args := m.Called(id)
+	restaurant, _ := args.Get(0).(*restaurantModel.RestaurantModel)
+	return restaurant, args.Error(1)
+}
+
+func (m *MockRestaurantRepository) UpdateImage(id uuid.UUID, previous, ref string) error {
+	args := m.Called(id, previous, ref)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) UpdateCoordinates(id uuid.UUID, latitude, longitude float64) error {
+	args := m.Called(id, latitude, longitude)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) UpdateTimezone(id uuid.UUID, timezone string) error {
+	args := m.Called(id, timezone)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) ReplaceOpeningHours(id uuid.UUID, hours []restaurantModel.OpeningHours) error {
+	args := m.Called(id, hours)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) FindNearby(query restaurantModel.NearbyQuery) ([]restaurantModel.NearbyRestaurant, error) {
+	args := m.Called(query)
+	return args.Get(0).([]restaurantModel.NearbyRestaurant), args.Error(1)
+}
+
+func Test_brandService_RoleAt(t *testing.T) {
+	brandId, owner, manager := uuid.New(), uuid.New(), uuid.New()
+	downtown, airport, independent := uuid.New(), uuid.New(), uuid.New()
+
+	repo := new(MockRepository)
+	restaurants := new(MockRestaurantRepository)
+	repo.On("FindLocationBrand", downtown).Return(brandId, nil)
+	repo.On("FindLocationBrand", airport).Return(brandId, nil)
+	repo.On("FindLocationBrand", independent).Return(uuid.Nil, brandRepo.ErrNotFound)
+	repo.On("FindMember", brandId, manager).
+		Return(&brandModel.Member{Role: brandModel.Manager, Locations: []uuid.UUID{downtown}}, nil)
+	repo.On("FindMember", brandId, owner).Return(nil, brandRepo.ErrMemberNotFound)
+	restaurants.On("FindById", independent).Return(&restaurantModel.RestaurantModel{OwnerId: owner}, nil)
+	service := NewBrandService(log, repo, restaurants)
+
+	tests := []struct {
+		name         string
+		userId       uuid.UUID
+		restaurantId uuid.UUID
+		want         brandModel.Role
+		wantErr      bool
+	}{
+		{name: "manager at their location", userId: manager, restaurantId: downtown, want: brandModel.Manager},
+		{name: "manager elsewhere", userId: manager, restaurantId: airport, wantErr: true},
+		{name: "not a member", userId: owner, restaurantId: downtown, wantErr: true},
+		{name: "owner of an independent restaurant", userId: owner, restaurantId: independent, want: brandModel.Owner},
+		{name: "stranger at an independent restaurant", userId: manager, restaurantId: independent, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.RoleAt(tt.userId, tt.restaurantId)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_brandService_SetMember(t *testing.T) {
+	brandId, admin, regional, location := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	otherAdmin, newcomer := uuid.New(), uuid.New()
+
+	repo := new(MockRepository)
+	repo.On("FindMember", brandId, admin).Return(&brandModel.Member{Role: brandModel.Admin}, nil)
+	repo.On("FindMember", brandId, regional).
+		Return(&brandModel.Member{Role: brandModel.Manager, Locations: []uuid.UUID{location}}, nil)
+	repo.On("FindMember", brandId, otherAdmin).Return(&brandModel.Member{Role: brandModel.Admin}, nil)
+	repo.On("FindMember", brandId, newcomer).Return(nil, brandRepo.ErrMemberNotFound)
+	repo.On("FindLocations", brandId).Return([]uuid.UUID{location}, nil)
+	repo.On("SaveMember", mock.Anything).Return(nil)
+	service := NewBrandService(log, repo, new(MockRestaurantRepository))
+
+	tests := []struct {
+		name    string
+		actor   uuid.UUID
+		member  brandModel.Member
+		wantErr bool
+	}{
+		{
+			name:   "admin appoints a local manager",
+			actor:  admin,
+			member: brandModel.Member{UserId: newcomer, Role: brandModel.Manager, Locations: []uuid.UUID{location}},
+		}, {
+			name:    "admin cannot appoint an admin",
+			actor:   admin,
+			member:  brandModel.Member{UserId: newcomer, Role: brandModel.Admin},
+			wantErr: true,
+		}, {
+			name:    "admin cannot demote another admin",
+			actor:   admin,
+			member:  brandModel.Member{UserId: otherAdmin, Role: brandModel.Staff},
+			wantErr: true,
+		}, {
+			name:    "local managers cannot manage members",
+			actor:   regional,
+			member:  brandModel.Member{UserId: newcomer, Role: brandModel.Staff},
+			wantErr: true,
+		}, {
+			name:    "location outside the brand",
+			actor:   admin,
+			member:  brandModel.Member{UserId: newcomer, Role: brandModel.Staff, Locations: []uuid.UUID{uuid.New()}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.member.BrandId = brandId
+			err := service.SetMember(tt.actor, &tt.member)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+	repo.AssertNumberOfCalls(t, "SaveMember", 1)
+}
+
+func Test_brandService_SetOverride(t *testing.T) {
+	brandId, manager, staff := uuid.New(), uuid.New(), uuid.New()
+	location, rice := uuid.New(), uuid.New()
+	items := []brandModel.MasterItem{{Id: rice, BrandId: brandId, Item: "Rice", ItemType: "Main", Price: "8"}}
+
+	repo := new(MockRepository)
+	repo.On("FindLocationBrand", location).Return(brandId, nil)
+	repo.On("FindMember", brandId, manager).
+		Return(&brandModel.Member{Role: brandModel.Manager, Locations: []uuid.UUID{location}}, nil)
+	repo.On("FindMember", brandId, staff).Return(&brandModel.Member{Role: brandModel.Staff}, nil)
+	repo.On("FindMasterItems", brandId).Return(items, nil)
+	repo.On("SaveOverride", mock.Anything).Return(nil)
+	repo.On("FindOverrides", location).Return([]brandModel.Override{{MasterItemId: rice, Price: "9.50"}}, nil)
+	repo.On("SyncLocations", map[uuid.UUID][]brandModel.LocationItem{location: {
+		{MasterItemId: rice, Item: "Rice", ItemType: "Main", Price: "9.50", Available: true, Overridden: true},
+	}}).Return(nil)
+	service := NewBrandService(log, repo, new(MockRestaurantRepository))
+
+	err := service.SetOverride(manager, &brandModel.Override{RestaurantId: location, MasterItemId: rice, Price: "9.50"})
+	assert.Nil(t, err)
+	repo.AssertNumberOfCalls(t, "SyncLocations", 1)
+
+	err = service.SetOverride(staff, &brandModel.Override{RestaurantId: location, MasterItemId: rice, Price: "1"})
+	assert.ErrorIs(t, err, brandModel.ErrForbidden)
+
+	err = service.SetOverride(manager, &brandModel.Override{RestaurantId: location, MasterItemId: uuid.New(), Unavailable: true})
+	assert.ErrorIs(t, err, brandRepo.ErrMasterItemNotFound)
+	repo.AssertNumberOfCalls(t, "SaveOverride", 1)
+}
+
+func Test_brandService_SaveMasterItem(t *testing.T) {
+	brandId, admin, first, second := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	repo := new(MockRepository)
+	repo.On("FindMember", brandId, admin).Return(&brandModel.Member{Role: brandModel.Admin}, nil)
+	repo.On("PersistMasterItem", mock.Anything).Return(nil)
+	repo.On("FindLocations", brandId).Return([]uuid.UUID{first, second}, nil)
+	repo.On("FindMasterItems", brandId).Return([]brandModel.MasterItem{}, nil)
+	repo.On("FindOverrides", mock.Anything).Return([]brandModel.Override{}, nil)
+	repo.On("SyncLocations", mock.Anything).Return(nil)
+	service := NewBrandService(log, repo, new(MockRestaurantRepository))
+
+	item := brandModel.MasterItem{BrandId: brandId, Item: "Rice", ItemType: "Main", Price: "8"}
+	err := service.SaveMasterItem(admin, &item)
+	assert.Nil(t, err)
+	assert.NotEqual(t, uuid.Nil, item.Id)
+	// Both locations are synced together.
+	repo.AssertNumberOfCalls(t, "SyncLocations", 1)
+	repo.AssertCalled(t, "SyncLocations", map[uuid.UUID][]brandModel.LocationItem{first: {}, second: {}})
+}
@@ -0,0 +1,93 @@
+package courierService
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/courierModel"
+	"rsm/repository/courierRepo"
+	"time"
+)
+
+type ServiceInterface interface {
+	Register(userId uuid.UUID) (*courierModel.Courier, error)
+	GetCourier(userId uuid.UUID) (*courierModel.Courier, error)
+	SetAvailability(userId uuid.UUID, request courierModel.AvailabilityRequest) (*courierModel.Courier, error)
+	ReportPosition(userId uuid.UUID, request courierModel.PositionRequest) error
+}
+
+type courierService struct {
+	log  *logrus.Logger
+	repo courierRepo.RepoInterface
+}
+
+// Register makes the user a courier, starting offline. Registering twice returns the
+// existing courier.
+func (c *courierService) Register(userId uuid.UUID) (*courierModel.Courier, error) {
+	existing, err := c.repo.FindByUser(userId)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, courierRepo.ErrNotFound) {
+		return nil, err
+	}
+	courier := courierModel.Courier{
+		Id:        uuid.New(),
+		UserId:    userId,
+		Status:    courierModel.Offline,
+		CreatedAt: time.Now(),
+	}
+	err = c.repo.Persist(&courier)
+	if err != nil {
+		return nil, err
+	}
+	return &courier, nil
+}
+
+func (c *courierService) GetCourier(userId uuid.UUID) (*courierModel.Courier, error) {
+	return c.repo.FindByUser(userId)
+}
+
+// SetAvailability puts the courier on or off shift. A courier on a delivery stays busy
+// until it is delivered.
+func (c *courierService) SetAvailability(userId uuid.UUID, request courierModel.AvailabilityRequest) (*courierModel.Courier, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		c.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	courier, err := c.repo.FindByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	if courier.Status == courierModel.Busy {
+		return nil, fmt.Errorf("cannot change availability during a delivery")
+	}
+	if courier.Status == request.Status {
+		return courier, nil
+	}
+	err = c.repo.UpdateStatus(courier.Id, courier.Status, request.Status)
+	if err != nil {
+		return nil, err
+	}
+	courier.Status = request.Status
+	return courier, nil
+}
+
+func (c *courierService) ReportPosition(userId uuid.UUID, request courierModel.PositionRequest) error {
+	err := request.ValidateInput()
+	if err != nil {
+		c.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	courier, err := c.repo.FindByUser(userId)
+	if err != nil {
+		return err
+	}
+	return c.repo.UpdatePosition(courier.Id, request.Point(), time.Now())
+}
+
+func NewCourierService(log *logrus.Logger, repo courierRepo.RepoInterface) ServiceInterface {
+	return &courierService{log: log, repo: repo}
+}
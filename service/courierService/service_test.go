@@ -0,0 +1,126 @@
+package courierService
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/courierModel"
+	"rsm/location/geoUtils"
+	"rsm/repository/courierRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Persist(courier *courierModel.Courier) error {
+	args := m.Called(courier)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindById(id uuid.UUID) (*courierModel.Courier, error) {
+	args := m.Called(id)
+	courier, _ := args.Get(0).(*courierModel.Courier)
+	return courier, args.Error(1)
+}
+
+func (m *MockRepository) FindByUser(userId uuid.UUID) (*courierModel.Courier, error) {
+	args := m.Called(userId)
+	courier, _ := args.Get(0).(*courierModel.Courier)
+	return courier, args.Error(1)
+}
+
+func (m *MockRepository) UpdateStatus(id uuid.UUID, from, to courierModel.Status) error {
+	args := m.Called(id, from, to)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdatePosition(id uuid.UUID, position geoUtils.Point, at time.Time) error {
+	args := m.Called(id, position, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindAvailableNear(query courierModel.NearbyQuery) ([]courierModel.NearbyCourier, error) {
+	args := m.Called(query)
+	return args.Get(0).([]courierModel.NearbyCourier), args.Error(1)
+}
+
+func Test_courierService_Register(t *testing.T) {
+	existing := &courierModel.Courier{Id: uuid.New(), UserId: uuid.New(), Status: courierModel.Available}
+	newUser := uuid.New()
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindByUser", existing.UserId).Return(existing, nil)
+	mockRepo.On("FindByUser", newUser).Return(nil, courierRepo.ErrNotFound)
+	mockRepo.On("Persist", mock.Anything).Return(nil)
+	c := NewCourierService(log, mockRepo)
+
+	got, err := c.Register(existing.UserId)
+	assert.Nil(t, err)
+	assert.Equal(t, existing, got)
+
+	got, err = c.Register(newUser)
+	assert.Nil(t, err)
+	assert.Equal(t, newUser, got.UserId)
+	assert.Equal(t, courierModel.Offline, got.Status)
+	mockRepo.AssertNumberOfCalls(t, "Persist", 1)
+}
+
+func Test_courierService_SetAvailability(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  courierModel.Status
+		request courierModel.AvailabilityRequest
+		update  bool
+		wantErr bool
+	}{
+		{name: "going on shift", status: courierModel.Offline,
+			request: courierModel.AvailabilityRequest{Status: courierModel.Available}, update: true},
+		{name: "already available", status: courierModel.Available,
+			request: courierModel.AvailabilityRequest{Status: courierModel.Available}},
+		{name: "busy couriers stay busy", status: courierModel.Busy,
+			request: courierModel.AvailabilityRequest{Status: courierModel.Offline}, wantErr: true},
+		{name: "cannot set busy", status: courierModel.Available,
+			request: courierModel.AvailabilityRequest{Status: courierModel.Busy}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			courier := &courierModel.Courier{Id: uuid.New(), UserId: uuid.New(), Status: tt.status}
+			mockRepo := new(MockRepository)
+			mockRepo.On("FindByUser", courier.UserId).Return(courier, nil)
+			mockRepo.On("UpdateStatus", courier.Id, tt.status, tt.request.Status).Return(nil)
+			c := NewCourierService(log, mockRepo)
+
+			got, err := c.SetAvailability(courier.UserId, tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetAvailability() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.update {
+				mockRepo.AssertCalled(t, "UpdateStatus", courier.Id, tt.status, tt.request.Status)
+			} else {
+				mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.request.Status, got.Status)
+			}
+		})
+	}
+}
+
+func Test_courierService_ReportPosition(t *testing.T) {
+	courier := &courierModel.Courier{Id: uuid.New(), UserId: uuid.New(), Status: courierModel.Available}
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindByUser", courier.UserId).Return(courier, nil)
+	mockRepo.On("UpdatePosition", courier.Id, geoUtils.Point{Latitude: 6.5, Longitude: 3.4}, mock.Anything).Return(nil)
+	c := NewCourierService(log, mockRepo)
+
+	assert.Nil(t, c.ReportPosition(courier.UserId, courierModel.PositionRequest{Latitude: 6.5, Longitude: 3.4}))
+	assert.NotNil(t, c.ReportPosition(courier.UserId, courierModel.PositionRequest{Latitude: 96.5, Longitude: 3.4}))
+	mockRepo.AssertNumberOfCalls(t, "UpdatePosition", 1)
+}
@@ -0,0 +1,327 @@
+package deliveryService
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/courierModel"
+	"rsm/entity/deliveryModel"
+	"rsm/entity/orderModel"
+	"rsm/repository/courierRepo"
+	"rsm/repository/deliveryRepo"
+	"rsm/repository/restaurantRepo"
+	"rsm/service/loyaltyService"
+	"rsm/service/orderService"
+	"rsm/service/zoneService"
+	"time"
+)
+
+type ServiceInterface interface {
+	RequestDelivery(request deliveryModel.DeliveryRequest) (*deliveryModel.Delivery, error)
+	GetDelivery(orderId uuid.UUID) (*deliveryModel.Delivery, error)
+	Accept(userId, offerId uuid.UUID) (*deliveryModel.Delivery, error)
+	Decline(userId, offerId uuid.UUID) (*deliveryModel.Delivery, error)
+	PickUp(userId, deliveryId uuid.UUID) (*deliveryModel.Delivery, error)
+	Deliver(userId, deliveryId uuid.UUID) (*deliveryModel.Delivery, error)
+	Sweep(now time.Time) error
+}
+
+type deliveryService struct {
+	log         *logrus.Logger
+	repo        deliveryRepo.RepoInterface
+	couriers    courierRepo.RepoInterface
+	restaurants restaurantRepo.RepoInterface
+	orders      orderService.ServiceInterface
+	zones       zoneService.ServiceInterface
+	loyalty     loyaltyService.ServiceInterface
+}
+
+// RequestDelivery creates the order's delivery and offers it to the nearest available
+// courier. If nobody is available it stays unassigned until a later Sweep finds someone.
+// A dropoff outside the restaurant's delivery zones fails with zoneModel.ErrOutside.
+func (d *deliveryService) RequestDelivery(request deliveryModel.DeliveryRequest) (*deliveryModel.Delivery, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		d.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+
+	order, err := d.orders.GetOrder(request.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	switch order.Status {
+	case orderModel.Cancelled, orderModel.Completed, orderModel.OutForDelivery:
+		return nil, fmt.Errorf("cannot deliver a %v order", order.Status)
+	}
+	_, err = d.repo.FindByOrder(order.Id)
+	if err == nil {
+		return nil, fmt.Errorf("order already has a delivery")
+	}
+	if !errors.Is(err, deliveryRepo.ErrNotFound) {
+		return nil, err
+	}
+
+	restaurant, err := d.restaurants.FindById(order.RestaurantId)
+	if err != nil {
+		return nil, err
+	}
+	pickup, ok := restaurant.Point()
+	if !ok {
+		return nil, fmt.Errorf("restaurant has no coordinates")
+	}
+	_, err = d.zones.Locate(order.RestaurantId, request.Dropoff)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery := deliveryModel.Delivery{
+		Id:           uuid.New(),
+		OrderId:      order.Id,
+		RestaurantId: order.RestaurantId,
+		Status:       deliveryModel.Unassigned,
+		Pickup:       pickup,
+		Dropoff:      request.Dropoff,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	err = d.repo.Persist(&delivery)
+	if err != nil {
+		return nil, err
+	}
+	err = d.offerNext(&delivery, now)
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (d *deliveryService) GetDelivery(orderId uuid.UUID) (*deliveryModel.Delivery, error) {
+	return d.repo.FindByOrder(orderId)
+}
+
+// offerNext offers an unassigned delivery to the nearest available courier who has not
+// recently turned it down.
+func (d *deliveryService) offerNext(delivery *deliveryModel.Delivery, now time.Time) error {
+	offers, err := d.repo.FindOffers(delivery.Id)
+	if err != nil {
+		return err
+	}
+	exclude := []uuid.UUID{}
+	for _, o := range offers {
+		if o.RespondedAt != nil && now.Sub(*o.RespondedAt) < deliveryModel.ReofferAfter {
+			exclude = append(exclude, o.CourierId)
+		}
+	}
+
+	nearby, err := d.couriers.FindAvailableNear(courierModel.NearbyQuery{
+		Center:     delivery.Pickup,
+		RadiusKm:   deliveryModel.DispatchRadiusKm,
+		Exclude:    exclude,
+		FreshSince: now.Add(-deliveryModel.PositionMaxAge),
+		Limit:      1,
+	})
+	if err != nil {
+		return err
+	}
+	if len(nearby) == 0 {
+		d.log.Infof("No courier available for delivery %v", delivery.Id)
+		return nil
+	}
+
+	offer := deliveryModel.Offer{
+		Id:         uuid.New(),
+		DeliveryId: delivery.Id,
+		CourierId:  nearby[0].Courier.Id,
+		Status:     deliveryModel.Open,
+		DistanceKm: nearby[0].DistanceKm,
+		ExpiresAt:  now.Add(deliveryModel.OfferTimeout),
+		CreatedAt:  now,
+	}
+	err = d.repo.CreateOffer(&offer)
+	if errors.Is(err, deliveryRepo.ErrStaleStatus) {
+		// Another dispatcher got there first.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	delivery.Status, delivery.UpdatedAt = deliveryModel.Offered, now
+	return nil
+}
+
+// Accept assigns the delivery to the courier if their offer is still open.
+func (d *deliveryService) Accept(userId, offerId uuid.UUID) (*deliveryModel.Delivery, error) {
+	offer, err := d.openOffer(userId, offerId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if offer.IsExpired(now) {
+		return nil, fmt.Errorf("offer expired")
+	}
+	offer.Status, offer.RespondedAt = deliveryModel.Accepted, &now
+	err = d.repo.AcceptOffer(offer)
+	if err != nil {
+		return nil, err
+	}
+	return d.repo.FindById(offer.DeliveryId)
+}
+
+// Decline closes the courier's offer and passes the delivery on to the next courier.
+func (d *deliveryService) Decline(userId, offerId uuid.UUID) (*deliveryModel.Delivery, error) {
+	offer, err := d.openOffer(userId, offerId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	offer.Status, offer.RespondedAt = deliveryModel.Declined, &now
+	err = d.repo.CloseOffer(offer)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := d.repo.FindById(offer.DeliveryId)
+	if err != nil {
+		return nil, err
+	}
+	err = d.offerNext(delivery, now)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (d *deliveryService) openOffer(userId, offerId uuid.UUID) (*deliveryModel.Offer, error) {
+	courier, err := d.couriers.FindByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	offer, err := d.repo.FindOffer(offerId)
+	if err != nil {
+		return nil, err
+	}
+	if offer.CourierId != courier.Id {
+		return nil, deliveryRepo.ErrNotFound
+	}
+	if offer.Status != deliveryModel.Open {
+		return nil, fmt.Errorf("offer is already %v", offer.Status)
+	}
+	return offer, nil
+}
+
+// PickUp records the courier collecting a ready order, which puts the order out for
+// delivery in the same transaction.
+func (d *deliveryService) PickUp(userId, deliveryId uuid.UUID) (*deliveryModel.Delivery, error) {
+	delivery, err := d.courierDelivery(userId, deliveryId, deliveryModel.PickedUp)
+	if err != nil {
+		return nil, err
+	}
+	order, err := d.orders.GetOrder(delivery.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != orderModel.Ready {
+		return nil, fmt.Errorf("order is not ready for pickup")
+	}
+
+	now := time.Now()
+	delivery.Status, delivery.UpdatedAt, delivery.PickedUpAt = deliveryModel.PickedUp, now, &now
+	err = d.repo.UpdateStatus(delivery, deliveryModel.Assigned, order, orderModel.OutForDelivery)
+	if err != nil {
+		return nil, err
+	}
+	d.orders.Notify(order, orderModel.OutForDelivery)
+	return delivery, nil
+}
+
+// Deliver completes the delivery and its order together, frees the courier for the next
+// one and credits the customer's loyalty points.
+func (d *deliveryService) Deliver(userId, deliveryId uuid.UUID) (*deliveryModel.Delivery, error) {
+	delivery, err := d.courierDelivery(userId, deliveryId, deliveryModel.Delivered)
+	if err != nil {
+		return nil, err
+	}
+	order, err := d.orders.GetOrder(delivery.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if !orderModel.CanTransition(order.Status, orderModel.Completed) {
+		return nil, fmt.Errorf("cannot complete a %v order", order.Status)
+	}
+
+	now := time.Now()
+	delivery.Status, delivery.UpdatedAt, delivery.DeliveredAt = deliveryModel.Delivered, now, &now
+	err = d.repo.UpdateStatus(delivery, deliveryModel.PickedUp, order, orderModel.Completed)
+	if err != nil {
+		return nil, err
+	}
+	d.orders.Notify(order, orderModel.Completed)
+	_, err = d.loyalty.EarnForOrder(order)
+	if err != nil {
+		d.log.Errorf("Error Earning Loyalty Points For Order %v: %v", order.Id, err)
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (d *deliveryService) courierDelivery(userId, deliveryId uuid.UUID, to deliveryModel.Status) (*deliveryModel.Delivery, error) {
+	courier, err := d.couriers.FindByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := d.repo.FindById(deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.CourierId == nil || *delivery.CourierId != courier.Id {
+		return nil, deliveryRepo.ErrNotFound
+	}
+	if !deliveryModel.CanTransition(delivery.Status, to) {
+		return nil, fmt.Errorf("cannot mark a %v delivery %v", delivery.Status, to)
+	}
+	return delivery, nil
+}
+
+// Sweep closes the deliveries of cancelled orders, expires offers nobody answered in time
+// and offers every unassigned delivery again. It is meant to run every few seconds.
+func (d *deliveryService) Sweep(now time.Time) error {
+	closed, err := d.repo.CloseCancelled(now)
+	if err != nil {
+		return err
+	}
+	if closed > 0 {
+		d.log.Infof("Closed %d deliveries of cancelled orders", closed)
+	}
+
+	expired, err := d.repo.FindExpiredOffers(now)
+	if err != nil {
+		return err
+	}
+	for i := range expired {
+		offer := &expired[i]
+		offer.Status, offer.RespondedAt = deliveryModel.Expired, &now
+		err = d.repo.CloseOffer(offer)
+		if err != nil && !errors.Is(err, deliveryRepo.ErrStaleStatus) {
+			d.log.Errorf("Error Expiring Offer %v: %v", offer.Id, err)
+		}
+	}
+
+	unassigned, err := d.repo.FindUnassigned()
+	if err != nil {
+		return err
+	}
+	for i := range unassigned {
+		err = d.offerNext(&unassigned[i], now)
+		if err != nil {
+			d.log.Errorf("Error Offering Delivery %v: %v", unassigned[i].Id, err)
+		}
+	}
+	return nil
+}
+
+func NewDeliveryService(log *logrus.Logger, repo deliveryRepo.RepoInterface, couriers courierRepo.RepoInterface, restaurants restaurantRepo.RepoInterface, orders orderService.ServiceInterface, zones zoneService.ServiceInterface, loyalty loyaltyService.ServiceInterface) ServiceInterface {
+	return &deliveryService{log: log, repo: repo, couriers: couriers, restaurants: restaurants, orders: orders, zones: zones,
+		loyalty: loyalty}
+}
@@ -0,0 +1,557 @@
+package deliveryService
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/courierModel"
+	"rsm/entity/deliveryModel"
+	"rsm/entity/loyaltyModel"
+	"rsm/entity/orderModel"
+	"rsm/entity/restaurantModel"
+	"rsm/entity/zoneModel"
+	"rsm/location/geoUtils"
+	"rsm/pricing"
+	"rsm/repository/deliveryRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Persist(delivery *deliveryModel.Delivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindById(id uuid.UUID) (*deliveryModel.Delivery, error) {
+	args := m.Called(id)
+	delivery, _ := args.Get(0).(*deliveryModel.Delivery)
+	return delivery, args.Error(1)
+}
+
+func (m *MockRepository) FindByOrder(orderId uuid.UUID) (*deliveryModel.Delivery, error) {
+	args := m.Called(orderId)
+	delivery, _ := args.Get(0).(*deliveryModel.Delivery)
+	return delivery, args.Error(1)
+}
+
+func (m *MockRepository) FindUnassigned() ([]deliveryModel.Delivery, error) {
+	args := m.Called()
+	return args.Get(0).([]deliveryModel.Delivery), args.Error(1)
+}
+
+func (m *MockRepository) UpdateStatus(delivery *deliveryModel.Delivery, expected deliveryModel.Status, order *orderModel.Order, orderStatus orderModel.Status) error {
+	args := m.Called(delivery, expected, order, orderStatus)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CloseCancelled(now time.Time) (int64, error) {
+	args := m.Called(now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) FindOffer(id uuid.UUID) (*deliveryModel.Offer, error) {
+	args := m.Called(id)
+	offer, _ := args.Get(0).(*deliveryModel.Offer)
+	return offer, args.Error(1)
+}
+
+func (m *MockRepository) FindOffers(deliveryId uuid.UUID) ([]deliveryModel.Offer, error) {
+	args := m.Called(deliveryId)
+	return args.Get(0).([]deliveryModel.Offer), args.Error(1)
+}
+
+func (m *MockRepository) FindExpiredOffers(now time.Time) ([]deliveryModel.Offer, error) {
+	args := m.Called(now)
+	return args.Get(0).([]deliveryModel.Offer), args.Error(1)
+}
+
+func (m *MockRepository) CreateOffer(offer *deliveryModel.Offer) error {
+	args := m.Called(offer)
+	return args.Error(0)
+}
+
+func (m *MockRepository) AcceptOffer(offer *deliveryModel.Offer) error {
+	args := m.Called(offer)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CloseOffer(offer *deliveryModel.Offer) error {
+	args := m.Called(offer)
+	return args.Error(0)
+}
+
+type MockCourierRepository struct {
+	mock.Mock
+}
+
+func (m *MockCourierRepository) Persist(courier *courierModel.Courier) error {
+	args := m.Called(courier)
+	return args.Error(0)
+}
+
+func (m *MockCourierRepository) FindById(id uuid.UUID) (*courierModel.Courier, error) {
+	args := m.Called(id)
+	return args.Get(0).(*courierModel.Courier), args.Error(1)
+}
+
+func (m *MockCourierRepository) FindByUser(userId uuid.UUID) (*courierModel.Courier, error) {
+	args := m.Called(userId)
+	return args.Get(0).(*courierModel.Courier), args.Error(1)
+}
+
+func (m *MockCourierRepository) UpdateStatus(id uuid.UUID, from, to courierModel.Status) error {
+	args := m.Called(id, from, to)
+	return args.Error(0)
+}
+
+func (m *MockCourierRepository) UpdatePosition(id uuid.UUID, position geoUtils.Point, at time.Time) error {
+	args := m.Called(id, position, at)
+	return args.Error(0)
+}
+
+func (m *MockCourierRepository) FindAvailableNear(query courierModel.NearbyQuery) ([]courierModel.NearbyCourier, error) {
+	args := m.Called(query)
+	return args.Get(0).([]courierModel.NearbyCourier), args.Error(1)
+}
+
+type MockRestaurantRepository struct {
+	mock.Mock
+}
+
+func (m *MockRestaurantRepository) Persist(restaurant *restaurantModel.RestaurantModel) (*restaurantModel.RestaurantModel, error) {
+	args := m.Called(restaurant)
+	return args.Get(0).(*restaurantModel.RestaurantModel), args.Error(1)
+}
+
+func (m *MockRestaurantRepository) FindById(id uuid.UUID) (*restaurantModel.RestaurantModel, error) {
+	args := m.Called(id)
+	return args.Get(0).(*restaurantModel.RestaurantModel), args.Error(1)
+}
+
+func (m *MockRestaurantRepository) UpdateImage(id uuid.UUID, previous, ref string) error {
+	args := m.Called(id, previous, ref)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) UpdateCoordinates(id uuid.UUID, latitude, longitude float64) error {
+	args := m.Called(id, latitude, longitude)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) UpdateTimezone(id uuid.UUID, timezone string) error {
+	args := m.Called(id, timezone)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) ReplaceOpeningHours(id uuid.UUID, hours []restaurantModel.OpeningHours) error {
+	args := m.Called(id, hours)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) FindNearby(query restaurantModel.NearbyQuery) ([]restaurantModel.NearbyRestaurant, error) {
+	args := m.Called(query)
+	return args.Get(0).([]restaurantModel.NearbyRestaurant), args.Error(1)
+}
+
+type MockOrderService struct {
+	mock.Mock
+}
+
+func (m *MockOrderService) GetOrder(id uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(id)
+	return args.Get(0).(*orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) GetUserOrder(userId, orderId uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(userId, orderId)
+	return args.Get(0).(*orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) ListByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error) {
+	args := m.Called(restaurantId, statuses)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) UpdateStatus(order *orderModel.Order, to orderModel.Status) error {
+	args := m.Called(order, to)
+	if args.Error(0) == nil {
+		order.Status = to
+	}
+	return args.Error(0)
+}
+
+func (m *MockOrderService) Notify(order *orderModel.Order, to orderModel.Status) {
+	m.Called(order, to)
+	order.Status = to
+}
+
+func (m *MockOrderService) ListHistory(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error) {
+	args := m.Called(userId, query)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) Reorder(userId, orderId uuid.UUID) (*pricing.Reorder, error) {
+	args := m.Called(userId, orderId)
+	reorder, _ := args.Get(0).(*pricing.Reorder)
+	return reorder, args.Error(1)
+}
+
+type MockZoneService struct {
+	mock.Mock
+}
+
+func (m *MockZoneService) CreateZone(zone *zoneModel.Zone) error {
+	args := m.Called(zone)
+	return args.Error(0)
+}
+
+func (m *MockZoneService) UpdateZone(zone *zoneModel.Zone) error {
+	args := m.Called(zone)
+	return args.Error(0)
+}
+
+func (m *MockZoneService) DeleteZone(restaurantId, id uuid.UUID) error {
+	args := m.Called(restaurantId, id)
+	return args.Error(0)
+}
+
+func (m *MockZoneService) ListZones(restaurantId uuid.UUID) ([]zoneModel.Zone, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]zoneModel.Zone), args.Error(1)
+}
+
+func (m *MockZoneService) Locate(restaurantId uuid.UUID, p geoUtils.Point) (*zoneModel.Zone, error) {
+	args := m.Called(restaurantId, p)
+	zone, _ := args.Get(0).(*zoneModel.Zone)
+	return zone, args.Error(1)
+}
+
+func nearby(courier courierModel.Courier, distanceKm float64) []courierModel.NearbyCourier {
+	return []courierModel.NearbyCourier{{Courier: courier, DistanceKm: distanceKm}}
+}
+
+type MockLoyaltyService struct {
+	mock.Mock
+}
+
+func (m *MockLoyaltyService) SaveRule(rule *loyaltyModel.EarnRule) error {
+	args := m.Called(rule)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) CreateReward(reward *loyaltyModel.Reward) error {
+	args := m.Called(reward)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) UpdateReward(reward *loyaltyModel.Reward) error {
+	args := m.Called(reward)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) Rewards(restaurantId uuid.UUID) ([]loyaltyModel.Reward, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]loyaltyModel.Reward), args.Error(1)
+}
+
+func (m *MockLoyaltyService) EarnForOrder(order *orderModel.Order) (*loyaltyModel.Entry, error) {
+	args := m.Called(order)
+	entry, _ := args.Get(0).(*loyaltyModel.Entry)
+	return entry, args.Error(1)
+}
+
+func (m *MockLoyaltyService) QuoteReward(userId, rewardId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Breakdown, error) {
+	args := m.Called(userId, rewardId, cart, codes)
+	breakdown, _ := args.Get(0).(*pricing.Breakdown)
+	return breakdown, args.Error(1)
+}
+
+func (m *MockLoyaltyService) Redeem(order *orderModel.Order, rewardId uuid.UUID, priced *pricing.Breakdown) (*loyaltyModel.Entry, error) {
+	args := m.Called(order, rewardId, priced)
+	entry, _ := args.Get(0).(*loyaltyModel.Entry)
+	return entry, args.Error(1)
+}
+
+func (m *MockLoyaltyService) OrderReward(orderId uuid.UUID) (*loyaltyModel.Reward, error) {
+	args := m.Called(orderId)
+	reward, _ := args.Get(0).(*loyaltyModel.Reward)
+	return reward, args.Error(1)
+}
+
+func (m *MockLoyaltyService) Reverse(order *orderModel.Order, refunded int64) error {
+	args := m.Called(order, refunded)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) ExpirePoints(at time.Time) (int64, error) {
+	args := m.Called(at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoyaltyService) Balance(userId uuid.UUID) (*loyaltyModel.Balance, error) {
+	args := m.Called(userId)
+	balance, _ := args.Get(0).(*loyaltyModel.Balance)
+	return balance, args.Error(1)
+}
+
+func (m *MockLoyaltyService) History(userId uuid.UUID, limit, offset int) ([]loyaltyModel.Entry, error) {
+	args := m.Called(userId, limit, offset)
+	return args.Get(0).([]loyaltyModel.Entry), args.Error(1)
+}
+
+func Test_deliveryService_RequestDelivery(t *testing.T) {
+	latitude, longitude := 6.52, 3.38
+	restaurant := &restaurantModel.RestaurantModel{Id: uuid.New(), Latitude: &latitude, Longitude: &longitude}
+	dropoff := geoUtils.Point{Latitude: 6.45, Longitude: 3.40}
+	courier := courierModel.Courier{Id: uuid.New(), Status: courierModel.Available}
+
+	tests := []struct {
+		name       string
+		status     orderModel.Status
+		existing   bool
+		noDropoff  bool
+		outside    bool
+		candidates []courierModel.NearbyCourier
+		want       deliveryModel.Status
+		wantErr    bool
+	}{
+		{name: "offered to nearest courier", status: orderModel.Preparing,
+			candidates: nearby(courier, 1.4), want: deliveryModel.Offered},
+		{name: "nobody available", status: orderModel.Accepted,
+			candidates: []courierModel.NearbyCourier{}, want: deliveryModel.Unassigned},
+		{name: "already has a delivery", status: orderModel.Ready, existing: true, wantErr: true},
+		{name: "cancelled order", status: orderModel.Cancelled, wantErr: true},
+		{name: "outside the delivery zones", status: orderModel.Accepted, outside: true, wantErr: true},
+		{name: "no dropoff", status: orderModel.Accepted, noDropoff: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &orderModel.Order{Id: uuid.New(), RestaurantId: restaurant.Id, Status: tt.status}
+			mockRepo := new(MockRepository)
+			if tt.existing {
+				mockRepo.On("FindByOrder", order.Id).Return(&deliveryModel.Delivery{Id: uuid.New()}, nil)
+			} else {
+				mockRepo.On("FindByOrder", order.Id).Return(nil, deliveryRepo.ErrNotFound)
+			}
+			mockRepo.On("Persist", mock.Anything).Return(nil)
+			mockRepo.On("FindOffers", mock.Anything).Return([]deliveryModel.Offer{}, nil)
+			mockRepo.On("CreateOffer", mock.Anything).Return(nil)
+			mockCouriers := new(MockCourierRepository)
+			mockCouriers.On("FindAvailableNear", mock.MatchedBy(func(q courierModel.NearbyQuery) bool {
+				return q.Center == geoUtils.Point{Latitude: latitude, Longitude: longitude} && q.RadiusKm == deliveryModel.DispatchRadiusKm && q.Limit == 1
+			})).Return(tt.candidates, nil)
+			mockRestaurants := new(MockRestaurantRepository)
+			mockRestaurants.On("FindById", restaurant.Id).Return(restaurant, nil)
+			mockOrders := new(MockOrderService)
+			mockOrders.On("GetOrder", order.Id).Return(order, nil)
+			mockZones := new(MockZoneService)
+			if tt.outside {
+				mockZones.On("Locate", restaurant.Id, dropoff).Return(nil, zoneModel.ErrOutside)
+			} else {
+				mockZones.On("Locate", restaurant.Id, dropoff).Return(nil, nil)
+			}
+			d := NewDeliveryService(log, mockRepo, mockCouriers, mockRestaurants, mockOrders, mockZones,
+				new(MockLoyaltyService))
+
+			request := deliveryModel.DeliveryRequest{OrderId: order.Id, Dropoff: dropoff}
+			if tt.noDropoff {
+				request.Dropoff = geoUtils.Point{}
+			}
+			got, err := d.RequestDelivery(request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RequestDelivery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				mockRepo.AssertNotCalled(t, "Persist", mock.Anything)
+				return
+			}
+			assert.Equal(t, tt.want, got.Status)
+			assert.Equal(t, dropoff, got.Dropoff)
+			if tt.want == deliveryModel.Offered {
+				offer := mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(0).(*deliveryModel.Offer)
+				assert.Equal(t, courier.Id, offer.CourierId)
+				assert.Equal(t, got.Id, offer.DeliveryId)
+				assert.Equal(t, deliveryModel.OfferTimeout, offer.ExpiresAt.Sub(offer.CreatedAt))
+			} else {
+				mockRepo.AssertNotCalled(t, "CreateOffer", mock.Anything)
+			}
+		})
+	}
+}
+
+func Test_deliveryService_Accept(t *testing.T) {
+	courier := &courierModel.Courier{Id: uuid.New(), UserId: uuid.New()}
+	other := &courierModel.Courier{Id: uuid.New(), UserId: uuid.New()}
+	delivery := &deliveryModel.Delivery{Id: uuid.New(), Status: deliveryModel.Assigned, CourierId: &courier.Id}
+
+	tests := []struct {
+		name    string
+		courier *courierModel.Courier
+		offer   deliveryModel.Offer
+		wantErr bool
+	}{
+		{name: "open offer", courier: courier, offer: deliveryModel.Offer{
+			CourierId: courier.Id, Status: deliveryModel.Open, ExpiresAt: time.Now().Add(time.Minute)}},
+		{name: "someone else's offer", courier: other, offer: deliveryModel.Offer{
+			CourierId: courier.Id, Status: deliveryModel.Open, ExpiresAt: time.Now().Add(time.Minute)}, wantErr: true},
+		{name: "expired", courier: courier, offer: deliveryModel.Offer{
+			CourierId: courier.Id, Status: deliveryModel.Open, ExpiresAt: time.Now().Add(-time.Second)}, wantErr: true},
+		{name: "already declined", courier: courier, offer: deliveryModel.Offer{
+			CourierId: courier.Id, Status: deliveryModel.Declined, ExpiresAt: time.Now().Add(time.Minute)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offer := tt.offer
+			offer.Id, offer.DeliveryId = uuid.New(), delivery.Id
+			mockRepo := new(MockRepository)
+			mockRepo.On("FindOffer", offer.Id).Return(&offer, nil)
+			mockRepo.On("AcceptOffer", mock.Anything).Return(nil)
+			mockRepo.On("FindById", delivery.Id).Return(delivery, nil)
+			mockCouriers := new(MockCourierRepository)
+			mockCouriers.On("FindByUser", tt.courier.UserId).Return(tt.courier, nil)
+			d := NewDeliveryService(log, mockRepo, mockCouriers, new(MockRestaurantRepository), new(MockOrderService),
+				new(MockZoneService), new(MockLoyaltyService))
+
+			got, err := d.Accept(tt.courier.UserId, offer.Id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Accept() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				mockRepo.AssertNotCalled(t, "AcceptOffer", mock.Anything)
+				return
+			}
+			assert.Equal(t, delivery, got)
+			assert.Equal(t, deliveryModel.Accepted, offer.Status)
+			assert.NotNil(t, offer.RespondedAt)
+		})
+	}
+}
+
+func Test_deliveryService_Decline(t *testing.T) {
+	first := &courierModel.Courier{Id: uuid.New(), UserId: uuid.New()}
+	second := courierModel.Courier{Id: uuid.New()}
+	delivery := &deliveryModel.Delivery{Id: uuid.New(), Status: deliveryModel.Unassigned,
+		Pickup: geoUtils.Point{Latitude: 6.52, Longitude: 3.38}}
+	offer := &deliveryModel.Offer{Id: uuid.New(), DeliveryId: delivery.Id, CourierId: first.Id,
+		Status: deliveryModel.Open, ExpiresAt: time.Now().Add(time.Minute)}
+	stale := time.Now().Add(-time.Hour)
+	earlier := deliveryModel.Offer{Id: uuid.New(), DeliveryId: delivery.Id, CourierId: uuid.New(),
+		Status: deliveryModel.Expired, RespondedAt: &stale}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindOffer", offer.Id).Return(offer, nil)
+	mockRepo.On("CloseOffer", offer).Return(nil)
+	mockRepo.On("FindById", delivery.Id).Return(delivery, nil)
+	just := time.Now()
+	declined := deliveryModel.Offer{Id: offer.Id, DeliveryId: delivery.Id, CourierId: first.Id,
+		Status: deliveryModel.Declined, RespondedAt: &just}
+	mockRepo.On("FindOffers", delivery.Id).Return([]deliveryModel.Offer{earlier, declined}, nil)
+	mockRepo.On("CreateOffer", mock.Anything).Return(nil)
+	mockCouriers := new(MockCourierRepository)
+	mockCouriers.On("FindByUser", first.UserId).Return(first, nil)
+	mockCouriers.On("FindAvailableNear", mock.MatchedBy(func(q courierModel.NearbyQuery) bool {
+		// The courier who just declined is skipped, the one from an hour ago is not.
+		return len(q.Exclude) == 1 && q.Exclude[0] == first.Id
+	})).Return(nearby(second, 2.1), nil)
+	d := NewDeliveryService(log, mockRepo, mockCouriers, new(MockRestaurantRepository), new(MockOrderService),
+		new(MockZoneService), new(MockLoyaltyService))
+
+	got, err := d.Decline(first.UserId, offer.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, deliveryModel.Declined, offer.Status)
+	assert.Equal(t, deliveryModel.Offered, got.Status)
+	next := mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(0).(*deliveryModel.Offer)
+	assert.Equal(t, second.Id, next.CourierId)
+}
+
+func Test_deliveryService_PickUpAndDeliver(t *testing.T) {
+	courier := &courierModel.Courier{Id: uuid.New(), UserId: uuid.New()}
+	tests := []struct {
+		name    string
+		order   orderModel.Status
+		userId  uuid.UUID
+		wantErr bool
+	}{
+		{name: "ready order", order: orderModel.Ready, userId: courier.UserId},
+		{name: "still preparing", order: orderModel.Preparing, userId: courier.UserId, wantErr: true},
+		{name: "another courier", order: orderModel.Ready, userId: uuid.New(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &orderModel.Order{Id: uuid.New(), Status: tt.order}
+			delivery := &deliveryModel.Delivery{Id: uuid.New(), OrderId: order.Id,
+				Status: deliveryModel.Assigned, CourierId: &courier.Id}
+			mockRepo := new(MockRepository)
+			mockRepo.On("FindById", delivery.Id).Return(delivery, nil)
+			mockRepo.On("UpdateStatus", delivery, mock.Anything, order, mock.Anything).Return(nil)
+			mockCouriers := new(MockCourierRepository)
+			mockCouriers.On("FindByUser", courier.UserId).Return(courier, nil)
+			mockCouriers.On("FindByUser", mock.Anything).Return(&courierModel.Courier{Id: uuid.New()}, nil)
+			mockOrders := new(MockOrderService)
+			mockOrders.On("GetOrder", order.Id).Return(order, nil)
+			mockOrders.On("Notify", order, mock.Anything).Return()
+			mockLoyalty := new(MockLoyaltyService)
+			mockLoyalty.On("EarnForOrder", order).Return(&loyaltyModel.Entry{}, nil)
+			d := NewDeliveryService(log, mockRepo, mockCouriers, new(MockRestaurantRepository), mockOrders,
+				new(MockZoneService), mockLoyalty)
+
+			_, err := d.PickUp(tt.userId, delivery.Id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PickUp() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			mockRepo.AssertCalled(t, "UpdateStatus", delivery, deliveryModel.Assigned, order, orderModel.OutForDelivery)
+			assert.Equal(t, orderModel.OutForDelivery, order.Status)
+			assert.NotNil(t, delivery.PickedUpAt)
+
+			got, err := d.Deliver(tt.userId, delivery.Id)
+			assert.Nil(t, err)
+			assert.Equal(t, deliveryModel.Delivered, got.Status)
+			mockRepo.AssertCalled(t, "UpdateStatus", delivery, deliveryModel.PickedUp, order, orderModel.Completed)
+			assert.Equal(t, orderModel.Completed, order.Status)
+			mockLoyalty.AssertNumberOfCalls(t, "EarnForOrder", 1)
+
+			_, err = d.Deliver(tt.userId, delivery.Id)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func Test_deliveryService_Sweep(t *testing.T) {
+	now := time.Now()
+	missed := deliveryModel.Offer{Id: uuid.New(), DeliveryId: uuid.New(), CourierId: uuid.New(),
+		Status: deliveryModel.Open, ExpiresAt: now.Add(-time.Second)}
+	waiting := deliveryModel.Delivery{Id: missed.DeliveryId, Status: deliveryModel.Unassigned}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("CloseCancelled", now).Return(int64(1), nil)
+	mockRepo.On("FindExpiredOffers", now).Return([]deliveryModel.Offer{missed}, nil)
+	mockRepo.On("CloseOffer", mock.Anything).Return(nil)
+	mockRepo.On("FindUnassigned").Return([]deliveryModel.Delivery{waiting}, nil)
+	mockRepo.On("FindOffers", waiting.Id).Return([]deliveryModel.Offer{}, nil)
+	mockCouriers := new(MockCourierRepository)
+	mockCouriers.On("FindAvailableNear", mock.Anything).Return([]courierModel.NearbyCourier{}, nil)
+	d := NewDeliveryService(log, mockRepo, mockCouriers, new(MockRestaurantRepository), new(MockOrderService),
+		new(MockZoneService), new(MockLoyaltyService))
+
+	assert.Nil(t, d.Sweep(now))
+	mockRepo.AssertCalled(t, "CloseCancelled", now)
+	closed := mockRepo.Calls[2].Arguments.Get(0).(*deliveryModel.Offer)
+	assert.Equal(t, deliveryModel.Expired, closed.Status)
+	assert.Equal(t, now, *closed.RespondedAt)
+	mockCouriers.AssertNumberOfCalls(t, "FindAvailableNear", 1)
+	mockRepo.AssertNotCalled(t, "CreateOffer", mock.Anything)
+}
@@ -0,0 +1,59 @@
+package favoriteService
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/favoriteModel"
+	"rsm/repository/favoriteRepo"
+	"rsm/repository/menuRepo"
+	"rsm/repository/restaurantRepo"
+	"time"
+)
+
+type ServiceInterface interface {
+	// AddRestaurant and AddItem do nothing if the user already saved it.
+	AddRestaurant(userId, restaurantId uuid.UUID) error
+	RemoveRestaurant(userId, restaurantId uuid.UUID) error
+	AddItem(userId uuid.UUID, menuId int64) error
+	RemoveItem(userId uuid.UUID, menuId int64) error
+	List(userId uuid.UUID) (*favoriteModel.Favorites, error)
+}
+
+type favoriteService struct {
+	log         *logrus.Logger
+	repo        favoriteRepo.RepoInterface
+	restaurants restaurantRepo.RepoInterface
+	menus       menuRepo.RepoInterface
+}
+
+func (f *favoriteService) AddRestaurant(userId, restaurantId uuid.UUID) error {
+	_, err := f.restaurants.FindById(restaurantId)
+	if err != nil {
+		return err
+	}
+	return f.repo.SaveRestaurant(userId, restaurantId, time.Now())
+}
+
+func (f *favoriteService) RemoveRestaurant(userId, restaurantId uuid.UUID) error {
+	return f.repo.DeleteRestaurant(userId, restaurantId)
+}
+
+func (f *favoriteService) AddItem(userId uuid.UUID, menuId int64) error {
+	_, err := f.menus.FindById(menuId)
+	if err != nil {
+		return err
+	}
+	return f.repo.SaveItem(userId, menuId, time.Now())
+}
+
+func (f *favoriteService) RemoveItem(userId uuid.UUID, menuId int64) error {
+	return f.repo.DeleteItem(userId, menuId)
+}
+
+func (f *favoriteService) List(userId uuid.UUID) (*favoriteModel.Favorites, error) {
+	return f.repo.FindByUser(userId)
+}
+
+func NewFavoriteService(log *logrus.Logger, repo favoriteRepo.RepoInterface, restaurants restaurantRepo.RepoInterface, menus menuRepo.RepoInterface) ServiceInterface {
+	return &favoriteService{log: log, repo: repo, restaurants: restaurants, menus: menus}
+}
@@ -0,0 +1,199 @@
+package favoriteService
+
+import (
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/favoriteModel"
+	"rsm/entity/menuModel"
+	"rsm/entity/restaurantModel"
+	"rsm/repository/favoriteRepo"
+	"rsm/repository/menuRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) SaveRestaurant(userId, restaurantId uuid.UUID, at time.Time) error {
+	args := m.Called(userId, restaurantId, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteRestaurant(userId, restaurantId uuid.UUID) error {
+	args := m.Called(userId, restaurantId)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SaveItem(userId uuid.UUID, menuId int64, at time.Time) error {
+	args := m.Called(userId, menuId, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteItem(userId uuid.UUID, menuId int64) error {
+	args := m.Called(userId, menuId)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindByUser(userId uuid.UUID) (*favoriteModel.Favorites, error) {
+	args := m.Called(userId)
+	favorites, _ := args.Get(0).(*favoriteModel.Favorites)
+	return favorites, args.Error(1)
+}
+
+type MockRestaurantRepository struct {
+	mock.Mock
+}
+
+func (m *MockRestaurantRepository) Persist(restaurant *restaurantModel.RestaurantModel) (*restaurantModel.RestaurantModel, error) {
+	args := m.Called(restaurant)
+	return args.Get(0).(*restaurantModel.RestaurantModel), args.Error(1)
+}
+
+func (m *MockRestaurantRepository) FindById(id uuid.UUID) (*restaurantModel.RestaurantModel, error) {
+	args := m.Called(id)
+	restaurant, _ := args.Get(0).(*restaurantModel.RestaurantModel)
+	return restaurant, args.Error(1)
+}
+
+func (m *MockRestaurantRepository) UpdateImage(id uuid.UUID, previous, ref string) error {
+	args := m.Called(id, previous, ref)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) UpdateCoordinates(id uuid.UUID, latitude, longitude float64) error {
+	args := m.Called(id, latitude, longitude)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) UpdateTimezone(id uuid.UUID, timezone string) error {
+	args := m.Called(id, timezone)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) ReplaceOpeningHours(id uuid.UUID, hours []restaurantModel.OpeningHours) error {
+	args := m.Called(id, hours)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) FindNearby(query restaurantModel.NearbyQuery) ([]restaurantModel.NearbyRestaurant, error) {
+	args := m.Called(query)
+	return args.Get(0).([]restaurantModel.NearbyRestaurant), args.Error(1)
+}
+
+type MockMenuRepository struct {
+	mock.Mock
+}
+
+func (m *MockMenuRepository) FindById(id int64) (*menuModel.MenuItem, error) {
+	args := m.Called(id)
+	item, _ := args.Get(0).(*menuModel.MenuItem)
+	return item, args.Error(1)
+}
+
+func (m *MockMenuRepository) FindByRestaurant(query menuModel.MenuQuery) ([]menuModel.MenuItem, error) {
+	args := m.Called(query)
+	return args.Get(0).([]menuModel.MenuItem), args.Error(1)
+}
+
+func (m *MockMenuRepository) FindAll(restaurantId uuid.UUID) ([]menuModel.MenuItem, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]menuModel.MenuItem), args.Error(1)
+}
+
+func (m *MockMenuRepository) UpdateImage(id int64, previous, ref string) error {
+	args := m.Called(id, previous, ref)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) UpdateDietaryInfo(id int64, info menuModel.DietaryInfo) error {
+	args := m.Called(id, info)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) Import(restaurantId uuid.UUID, rows []menuModel.TransferRow, at time.Time) (int, int, error) {
+	args := m.Called(restaurantId, rows, at)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockMenuRepository) PersistMenu(menu *menuModel.NamedMenu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) UpdateMenu(menu *menuModel.NamedMenu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) FindMenu(id uuid.UUID) (*menuModel.NamedMenu, error) {
+	args := m.Called(id)
+	menu, _ := args.Get(0).(*menuModel.NamedMenu)
+	return menu, args.Error(1)
+}
+
+func (m *MockMenuRepository) FindMenus(restaurantId uuid.UUID) ([]menuModel.NamedMenu, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]menuModel.NamedMenu), args.Error(1)
+}
+
+func (m *MockMenuRepository) SaveDraft(version *menuModel.Version) error {
+	args := m.Called(version)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) Publish(id uuid.UUID, effectiveFrom, publishedAt time.Time) error {
+	args := m.Called(id, effectiveFrom, publishedAt)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) FindVersion(id uuid.UUID) (*menuModel.Version, error) {
+	args := m.Called(id)
+	version, _ := args.Get(0).(*menuModel.Version)
+	return version, args.Error(1)
+}
+
+func (m *MockMenuRepository) FindVersions(namedMenuId uuid.UUID) ([]menuModel.Version, error) {
+	args := m.Called(namedMenuId)
+	return args.Get(0).([]menuModel.Version), args.Error(1)
+}
+
+func (m *MockMenuRepository) FindEffective(restaurantId uuid.UUID, at time.Time) ([]menuModel.Version, error) {
+	args := m.Called(restaurantId, at)
+	return args.Get(0).([]menuModel.Version), args.Error(1)
+}
+
+func Test_favoriteService_AddRestaurant(t *testing.T) {
+	userId, known, unknown := uuid.New(), uuid.New(), uuid.New()
+	repo := new(MockRepository)
+	repo.On("SaveRestaurant", userId, known, mock.Anything).Return(nil)
+	restaurants := new(MockRestaurantRepository)
+	restaurants.On("FindById", known).Return(&restaurantModel.RestaurantModel{Id: known}, nil)
+	restaurants.On("FindById", unknown).Return(nil, pgx.ErrNoRows)
+	f := NewFavoriteService(log, repo, restaurants, new(MockMenuRepository))
+
+	assert.Nil(t, f.AddRestaurant(userId, known))
+	assert.Equal(t, pgx.ErrNoRows, f.AddRestaurant(userId, unknown))
+	repo.AssertNumberOfCalls(t, "SaveRestaurant", 1)
+}
+
+func Test_favoriteService_AddItem(t *testing.T) {
+	userId := uuid.New()
+	repo := new(MockRepository)
+	repo.On("SaveItem", userId, int64(3), mock.Anything).Return(nil)
+	repo.On("DeleteItem", userId, int64(4)).Return(favoriteRepo.ErrNotFound)
+	menus := new(MockMenuRepository)
+	menus.On("FindById", int64(3)).Return(&menuModel.MenuItem{Id: 3}, nil)
+	menus.On("FindById", int64(4)).Return(nil, menuRepo.ErrNotFound)
+	f := NewFavoriteService(log, repo, new(MockRestaurantRepository), menus)
+
+	assert.Nil(t, f.AddItem(userId, 3))
+	assert.Equal(t, menuRepo.ErrNotFound, f.AddItem(userId, 4))
+	assert.Equal(t, favoriteRepo.ErrNotFound, f.RemoveItem(userId, 4))
+}
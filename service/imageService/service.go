@@ -0,0 +1,169 @@
+package imageService
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"io"
+	"rsm/entity/menuModel"
+	"rsm/entity/restaurantModel"
+	"rsm/repository/menuRepo"
+	"rsm/repository/restaurantRepo"
+	"rsm/storage"
+)
+
+// maxSwapAttempts bounds how often an upload retries when other uploads keep replacing the
+// same image.
+const maxSwapAttempts = 3
+
+type ServiceInterface interface {
+	SetRestaurantImage(restaurantId uuid.UUID, body io.Reader) (*restaurantModel.RestaurantModel, error)
+	SetMenuItemImage(restaurantId uuid.UUID, menuId int64, body io.Reader) (*menuModel.MenuItem, error)
+	// Open reads an image by its ref, at full size when size is 0 or as one of the
+	// storage.ThumbnailSizes.
+	Open(ref string, size int) (io.ReadCloser, *storage.Object, error)
+}
+
+type imageService struct {
+	log         *logrus.Logger
+	store       storage.BlobStore
+	restaurants restaurantRepo.RepoInterface
+	menus       menuRepo.RepoInterface
+}
+
+// SetRestaurantImage stores the photo with its thumbnails and makes it the restaurant's image.
+// The photo it replaces is deleted.
+func (i *imageService) SetRestaurantImage(restaurantId uuid.UUID, body io.Reader) (*restaurantModel.RestaurantModel, error) {
+	restaurant, err := i.restaurants.FindById(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := i.upload(fmt.Sprintf("restaurants/%s", restaurantId), body)
+	if err != nil {
+		return nil, err
+	}
+	err = i.swap(restaurant.ImageRef, ref, restaurantRepo.ErrStaleImage, func(previous string) error {
+		return i.restaurants.UpdateImage(restaurantId, previous, ref)
+	}, func() (string, error) {
+		restaurant, err = i.restaurants.FindById(restaurantId)
+		if err != nil {
+			return "", err
+		}
+		return restaurant.ImageRef, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	restaurant.ImageRef = ref
+	return restaurant, nil
+}
+
+// SetMenuItemImage does the same for one of the restaurant's menu items.
+func (i *imageService) SetMenuItemImage(restaurantId uuid.UUID, menuId int64, body io.Reader) (*menuModel.MenuItem, error) {
+	item, err := i.menus.FindById(menuId)
+	if err != nil {
+		return nil, err
+	}
+	if item.RestaurantId != restaurantId {
+		return nil, menuRepo.ErrNotFound
+	}
+	ref, err := i.upload(fmt.Sprintf("menu/%s/%d", restaurantId, menuId), body)
+	if err != nil {
+		return nil, err
+	}
+	err = i.swap(item.ImageRef, ref, menuRepo.ErrStaleImage, func(previous string) error {
+		return i.menus.UpdateImage(menuId, previous, ref)
+	}, func() (string, error) {
+		item, err = i.menus.FindById(menuId)
+		if err != nil {
+			return "", err
+		}
+		return item.ImageRef, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	item.ImageRef = ref
+	return item, nil
+}
+
+// swap replaces the image previous with ref. When another upload got there first, update
+// fails with stale and swap retries against the image current reads back, so the photo it
+// deletes is always the one it replaced. If it gives up, ref is deleted instead.
+func (i *imageService) swap(previous, ref string, stale error, update func(previous string) error, current func() (string, error)) error {
+	err := update(previous)
+	for attempt := 1; err == stale && attempt < maxSwapAttempts; attempt++ {
+		previous, err = current()
+		if err == nil {
+			err = update(previous)
+		}
+	}
+	if err != nil {
+		i.remove(ref)
+		return err
+	}
+	i.remove(previous)
+	return nil
+}
+
+func (i *imageService) Open(ref string, size int) (io.ReadCloser, *storage.Object, error) {
+	if size == 0 {
+		return i.store.Get(ref)
+	}
+	for _, s := range storage.ThumbnailSizes {
+		if s == size {
+			return i.store.Get(storage.ThumbnailKey(ref, size))
+		}
+	}
+	return nil, nil, fmt.Errorf("no %dpx thumbnail, sizes are %v", size, storage.ThumbnailSizes)
+}
+
+// upload checks the image, then stores it under a new key below prefix along with a thumbnail
+// for each of storage.ThumbnailSizes. The key of the full-size image is its ref.
+func (i *imageService) upload(prefix string, body io.Reader) (string, error) {
+	img, err := storage.ReadImage(body, storage.MaxImageBytes)
+	if err != nil {
+		return "", err
+	}
+	ref := fmt.Sprintf("%s/%s%s", prefix, uuid.New(), img.Extension)
+	_, err = i.store.Put(ref, bytes.NewReader(img.Data), img.ContentType)
+	if err != nil {
+		i.log.Errorf("Error Storing Image: %v", err)
+		return "", err
+	}
+	for _, size := range storage.ThumbnailSizes {
+		var buf bytes.Buffer
+		contentType, err := storage.Encode(&buf, storage.Thumbnail(img.Decoded, size), img.ContentType)
+		if err == nil {
+			_, err = i.store.Put(storage.ThumbnailKey(ref, size), &buf, contentType)
+		}
+		if err != nil {
+			i.log.Errorf("Error Storing Thumbnail: %v", err)
+			i.remove(ref)
+			return "", err
+		}
+	}
+	return ref, nil
+}
+
+// remove deletes an image and its thumbnails. Failures only leave unreferenced blobs behind,
+// so they are logged rather than returned.
+func (i *imageService) remove(ref string) {
+	if ref == "" {
+		return
+	}
+	keys := []string{ref}
+	for _, size := range storage.ThumbnailSizes {
+		keys = append(keys, storage.ThumbnailKey(ref, size))
+	}
+	for _, key := range keys {
+		if err := i.store.Delete(key); err != nil {
+			i.log.Errorf("Error Deleting Image %v: %v", key, err)
+		}
+	}
+}
+
+func NewImageService(log *logrus.Logger, store storage.BlobStore, restaurants restaurantRepo.RepoInterface, menus menuRepo.RepoInterface) ServiceInterface {
+	return &imageService{log: log, store: store, restaurants: restaurants, menus: menus}
+}
@@ -0,0 +1,266 @@
+package imageService
+
+import (
+	"bytes"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"image"
+	"image/png"
+	"io"
+	"rsm/entity/menuModel"
+	"rsm/entity/restaurantModel"
+	"rsm/repository/menuRepo"
+	"rsm/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRestaurantRepository struct {
+	mock.Mock
+}
+
+func (m *MockRestaurantRepository) Persist(restaurant *restaurantModel.RestaurantModel) (*restaurantModel.RestaurantModel, error) {
+	args := m.Called(restaurant)
+	return args.Get(0).(*restaurantModel.RestaurantModel), args.Error(1)
+}
+
+func (m *MockRestaurantRepository) FindById(id uuid.UUID) (*restaurantModel.RestaurantModel, error) {
+	args := m.Called(id)
+	return args.Get(0).(*restaurantModel.RestaurantModel), args.Error(1)
+}
+
+func (m *MockRestaurantRepository) UpdateImage(id uuid.UUID, previous, ref string) error {
+	args := m.Called(id, previous, ref)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) UpdateCoordinates(id uuid.UUID, latitude, longitude float64) error {
+	args := m.Called(id, latitude, longitude)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) UpdateTimezone(id uuid.UUID, timezone string) error {
+	args := m.Called(id, timezone)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) ReplaceOpeningHours(id uuid.UUID, hours []restaurantModel.OpeningHours) error {
+	args := m.Called(id, hours)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) FindNearby(query restaurantModel.NearbyQuery) ([]restaurantModel.NearbyRestaurant, error) {
+	args := m.Called(query)
+	return args.Get(0).([]restaurantModel.NearbyRestaurant), args.Error(1)
+}
+
+type MockMenuRepository struct {
+	mock.Mock
+}
+
+func (m *MockMenuRepository) FindById(id int64) (*menuModel.MenuItem, error) {
+	args := m.Called(id)
+	item, _ := args.Get(0).(*menuModel.MenuItem)
+	return item, args.Error(1)
+}
+
+func (m *MockMenuRepository) FindByRestaurant(query menuModel.MenuQuery) ([]menuModel.MenuItem, error) {
+	args := m.Called(query)
+	return args.Get(0).([]menuModel.MenuItem), args.Error(1)
+}
+
+func (m *MockMenuRepository) FindAll(restaurantId uuid.UUID) ([]menuModel.MenuItem, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]menuModel.MenuItem), args.Error(1)
+}
+
+func (m *MockMenuRepository) UpdateImage(id int64, previous, ref string) error {
+	args := m.Called(id, previous, ref)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) UpdateDietaryInfo(id int64, info menuModel.DietaryInfo) error {
+	args := m.Called(id, info)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) Import(restaurantId uuid.UUID, rows []menuModel.TransferRow, at time.Time) (int, int, error) {
+	args := m.Called(restaurantId, rows, at)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockMenuRepository) PersistMenu(menu *menuModel.NamedMenu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) UpdateMenu(menu *menuModel.NamedMenu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) FindMenu(id uuid.UUID) (*menuModel.NamedMenu, error) {
+	args := m.Called(id)
+	menu, _ := args.Get(0).(*menuModel.NamedMenu)
+	return menu, args.Error(1)
+}
+
+func (m *MockMenuRepository) FindMenus(restaurantId uuid.UUID) ([]menuModel.NamedMenu, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]menuModel.NamedMenu), args.Error(1)
+}
+
+func (m *MockMenuRepository) SaveDraft(version *menuModel.Version) error {
+	args := m.Called(version)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) Publish(id uuid.UUID, effectiveFrom, publishedAt time.Time) error {
+	args := m.Called(id, effectiveFrom, publishedAt)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) FindVersion(id uuid.UUID) (*menuModel.Version, error) {
+	args := m.Called(id)
+	version, _ := args.Get(0).(*menuModel.Version)
+	return version, args.Error(1)
+}
+
+func (m *MockMenuRepository) FindVersions(namedMenuId uuid.UUID) ([]menuModel.Version, error) {
+	args := m.Called(namedMenuId)
+	return args.Get(0).([]menuModel.Version), args.Error(1)
+}
+
+func (m *MockMenuRepository) FindEffective(restaurantId uuid.UUID, at time.Time) ([]menuModel.Version, error) {
+	args := m.Called(restaurantId, at)
+	return args.Get(0).([]menuModel.Version), args.Error(1)
+}
+
+func photo(t *testing.T) []byte {
+	var buf bytes.Buffer
+	assert.Nil(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1200, 800))))
+	return buf.Bytes()
+}
+
+func Test_imageService_SetRestaurantImage(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	assert.Nil(t, err)
+	restaurantId := uuid.New()
+	old := "restaurants/" + restaurantId.String() + "/old.png"
+	_, err = store.Put(old, strings.NewReader("old"), "image/png")
+	assert.Nil(t, err)
+
+	mockRestaurants := new(MockRestaurantRepository)
+	mockRestaurants.On("FindById", restaurantId).Return(&restaurantModel.RestaurantModel{Id: restaurantId,
+		ImageRef: old}, nil)
+	mockRestaurants.On("UpdateImage", restaurantId, old, mock.Anything).Return(nil)
+	i := NewImageService(log, store, mockRestaurants, new(MockMenuRepository))
+
+	got, err := i.SetRestaurantImage(restaurantId, bytes.NewReader(photo(t)))
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(got.ImageRef, "restaurants/"+restaurantId.String()+"/"))
+	assert.True(t, strings.HasSuffix(got.ImageRef, ".png"))
+	mockRestaurants.AssertCalled(t, "UpdateImage", restaurantId, old, got.ImageRef)
+	_, err = store.Head(old)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	body, object, err := i.Open(got.ImageRef, 160)
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", object.ContentType)
+	thumb, err := png.DecodeConfig(body)
+	body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, 160, thumb.Width)
+	assert.Equal(t, 106, thumb.Height)
+
+	_, _, err = i.Open(got.ImageRef, 300)
+	assert.NotNil(t, err)
+}
+
+func Test_imageService_SetMenuItemImage(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	assert.Nil(t, err)
+	restaurantId := uuid.New()
+
+	mockMenus := new(MockMenuRepository)
+	mockMenus.On("FindById", int64(1)).Return(&menuModel.MenuItem{Id: 1, RestaurantId: restaurantId}, nil)
+	mockMenus.On("UpdateImage", int64(1), "", mock.Anything).Return(nil)
+	i := NewImageService(log, store, new(MockRestaurantRepository), mockMenus)
+
+	_, err = i.SetMenuItemImage(uuid.New(), 1, bytes.NewReader(photo(t)))
+	assert.ErrorIs(t, err, menuRepo.ErrNotFound)
+	_, err = i.SetMenuItemImage(restaurantId, 1, strings.NewReader("GIF89a but not really"))
+	assert.NotNil(t, err)
+	_, err = i.SetMenuItemImage(restaurantId, 1, strings.NewReader("%PDF-1.4 menu.pdf"))
+	assert.NotNil(t, err)
+	mockMenus.AssertNotCalled(t, "UpdateImage", mock.Anything, mock.Anything, mock.Anything)
+
+	got, err := i.SetMenuItemImage(restaurantId, 1, bytes.NewReader(photo(t)))
+	assert.Nil(t, err)
+	body, object, err := i.Open(got.ImageRef, 0)
+	assert.Nil(t, err)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, photo(t), data)
+	assert.Equal(t, "image/png", object.ContentType)
+	for _, size := range storage.ThumbnailSizes {
+		_, err = store.Head(storage.ThumbnailKey(got.ImageRef, size))
+		assert.Nil(t, err)
+	}
+}
+
+func Test_imageService_UpdateFails(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	assert.Nil(t, err)
+	restaurantId := uuid.New()
+
+	mockMenus := new(MockMenuRepository)
+	mockMenus.On("FindById", int64(1)).Return(&menuModel.MenuItem{Id: 1, RestaurantId: restaurantId}, nil)
+	mockMenus.On("UpdateImage", int64(1), "", mock.Anything).Return(menuRepo.ErrNotFound)
+	i := NewImageService(log, store, new(MockRestaurantRepository), mockMenus)
+
+	_, err = i.SetMenuItemImage(restaurantId, 1, bytes.NewReader(photo(t)))
+	assert.ErrorIs(t, err, menuRepo.ErrNotFound)
+	ref := mockMenus.Calls[1].Arguments.String(2)
+	for _, key := range []string{ref, storage.ThumbnailKey(ref, 160), storage.ThumbnailKey(ref, 640)} {
+		_, err = store.Head(key)
+		assert.ErrorIs(t, err, storage.ErrNotFound, key)
+	}
+}
+
+func Test_imageService_ReplacedMeanwhile(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	assert.Nil(t, err)
+	restaurantId := uuid.New()
+	first, second := "menu/first.png", "menu/second.png"
+	for _, ref := range []string{first, second} {
+		_, err = store.Put(ref, strings.NewReader(ref), "image/png")
+		assert.Nil(t, err)
+	}
+
+	// Another upload replaced first with second after the item was read.
+	mockMenus := new(MockMenuRepository)
+	mockMenus.On("FindById", int64(1)).Return(&menuModel.MenuItem{Id: 1, RestaurantId: restaurantId,
+		ImageRef: first}, nil).Once()
+	mockMenus.On("FindById", int64(1)).Return(&menuModel.MenuItem{Id: 1, RestaurantId: restaurantId,
+		ImageRef: second}, nil)
+	mockMenus.On("UpdateImage", int64(1), first, mock.Anything).Return(menuRepo.ErrStaleImage)
+	mockMenus.On("UpdateImage", int64(1), second, mock.Anything).Return(nil)
+	i := NewImageService(log, store, new(MockRestaurantRepository), mockMenus)
+
+	got, err := i.SetMenuItemImage(restaurantId, 1, bytes.NewReader(photo(t)))
+	assert.Nil(t, err)
+	mockMenus.AssertCalled(t, "UpdateImage", int64(1), second, got.ImageRef)
+	_, err = store.Head(second)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	// first belongs to the other upload, which deletes it.
+	_, err = store.Head(first)
+	assert.Nil(t, err)
+	_, err = store.Head(got.ImageRef)
+	assert.Nil(t, err)
+}
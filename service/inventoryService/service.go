@@ -0,0 +1,143 @@
+package inventoryService
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/inventoryModel"
+	"rsm/entity/orderModel"
+	"rsm/notify"
+	"rsm/repository/inventoryRepo"
+	"strconv"
+	"time"
+)
+
+const (
+	// LowStock is sent when an item drops to its low-stock threshold.
+	LowStock notify.Kind = "low_stock"
+	// SoldOut is sent when the last serving of an item is sold.
+	SoldOut notify.Kind = "sold_out"
+)
+
+type ServiceInterface interface {
+	ListStock(restaurantId uuid.UUID) ([]inventoryModel.Stock, error)
+	Configure(restaurantId uuid.UUID, menuId int64, request inventoryModel.StockRequest) (*inventoryModel.Stock, error)
+	Restock(restaurantId uuid.UUID, menuId int64, request inventoryModel.RestockRequest) (*inventoryModel.Stock, error)
+	Reserve(order *orderModel.Order) error
+	Release(order *orderModel.Order) error
+}
+
+type inventoryService struct {
+	log      *logrus.Logger
+	repo     inventoryRepo.RepoInterface
+	notifier notify.Notifier
+}
+
+func (i *inventoryService) ListStock(restaurantId uuid.UUID) ([]inventoryModel.Stock, error) {
+	return i.repo.FindByRestaurant(restaurantId)
+}
+
+// Configure switches an item between untracked, counted and daily-limited stock.
+func (i *inventoryService) Configure(restaurantId uuid.UUID, menuId int64, request inventoryModel.StockRequest) (*inventoryModel.Stock, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		i.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	stock, err := i.restaurantStock(restaurantId, menuId)
+	if err != nil {
+		return nil, err
+	}
+	stock.Configure(request)
+	err = i.repo.Save(stock)
+	if err != nil {
+		return nil, err
+	}
+	return stock, nil
+}
+
+func (i *inventoryService) Restock(restaurantId uuid.UUID, menuId int64, request inventoryModel.RestockRequest) (*inventoryModel.Stock, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		i.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	_, err = i.restaurantStock(restaurantId, menuId)
+	if err != nil {
+		return nil, err
+	}
+	return i.repo.Restock(menuId, request.Quantity)
+}
+
+func (i *inventoryService) restaurantStock(restaurantId uuid.UUID, menuId int64) (*inventoryModel.Stock, error) {
+	stock, err := i.repo.FindByMenu(menuId)
+	if err != nil {
+		return nil, err
+	}
+	if stock.RestaurantId != restaurantId {
+		return nil, inventoryRepo.ErrNotFound
+	}
+	return stock, nil
+}
+
+// Reserve takes the order's items out of stock. It fails with an
+// *inventoryModel.OutOfStockError, taking nothing, if any item is short. Staff are notified
+// about items that this order sold out or brought down to their low-stock threshold.
+func (i *inventoryService) Reserve(order *orderModel.Order) error {
+	lines := inventoryModel.Lines(order.Items)
+	now := time.Now()
+	stocks, err := i.repo.Reserve(lines, now)
+	if err != nil {
+		return err
+	}
+
+	taken := map[int64]int{}
+	for _, l := range lines {
+		taken[l.MenuId] = l.Quantity
+	}
+	for _, s := range stocks {
+		remaining, tracked := s.Remaining(now)
+		before := remaining + taken[s.MenuId]
+		switch {
+		case !tracked:
+		case remaining == 0:
+			i.notify(SoldOut, s, remaining, now)
+		case remaining <= s.LowStockAt && before > s.LowStockAt:
+			i.notify(LowStock, s, remaining, now)
+		}
+	}
+	return nil
+}
+
+// Release puts back the stock reserved for an order that is not going ahead.
+func (i *inventoryService) Release(order *orderModel.Order) error {
+	_, err := i.repo.Release(inventoryModel.Lines(order.Items), time.Now())
+	return err
+}
+
+func (i *inventoryService) notify(kind notify.Kind, s inventoryModel.Stock, remaining int, now time.Time) {
+	restaurantId := s.RestaurantId
+	n := notify.Notification{
+		Kind:         kind,
+		RestaurantId: &restaurantId,
+		Title:        fmt.Sprintf("%v is running low", s.Item),
+		Body:         fmt.Sprintf("%d left", remaining),
+		Data:         map[string]string{"menuId": strconv.FormatInt(s.MenuId, 10), "remaining": strconv.Itoa(remaining)},
+		At:           now,
+	}
+	if kind == SoldOut {
+		n.Title = fmt.Sprintf("%v sold out", s.Item)
+		n.Body = "It is unavailable until restocked."
+		if s.Mode == inventoryModel.Daily {
+			n.Body = "It is unavailable until tomorrow."
+		}
+	}
+	err := i.notifier.Notify(n)
+	if err != nil {
+		i.log.Errorf("Error Sending %v Notification: %v", kind, err)
+	}
+}
+
+func NewInventoryService(log *logrus.Logger, repo inventoryRepo.RepoInterface, notifier notify.Notifier) ServiceInterface {
+	return &inventoryService{log: log, repo: repo, notifier: notifier}
+}
@@ -0,0 +1,169 @@
+package inventoryService
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/inventoryModel"
+	"rsm/entity/orderModel"
+	"rsm/notify"
+	"rsm/repository/inventoryRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) FindByRestaurant(restaurantId uuid.UUID) ([]inventoryModel.Stock, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]inventoryModel.Stock), args.Error(1)
+}
+
+func (m *MockRepository) FindByMenu(menuId int64) (*inventoryModel.Stock, error) {
+	args := m.Called(menuId)
+	stock, _ := args.Get(0).(*inventoryModel.Stock)
+	return stock, args.Error(1)
+}
+
+func (m *MockRepository) Save(stock *inventoryModel.Stock) error {
+	args := m.Called(stock)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Restock(menuId int64, quantity int) (*inventoryModel.Stock, error) {
+	args := m.Called(menuId, quantity)
+	stock, _ := args.Get(0).(*inventoryModel.Stock)
+	return stock, args.Error(1)
+}
+
+func (m *MockRepository) Reserve(lines []inventoryModel.Line, now time.Time) ([]inventoryModel.Stock, error) {
+	args := m.Called(lines, now)
+	stocks, _ := args.Get(0).([]inventoryModel.Stock)
+	return stocks, args.Error(1)
+}
+
+func (m *MockRepository) Release(lines []inventoryModel.Line, now time.Time) ([]inventoryModel.Stock, error) {
+	args := m.Called(lines, now)
+	stocks, _ := args.Get(0).([]inventoryModel.Stock)
+	return stocks, args.Error(1)
+}
+
+func Test_inventoryService_Reserve(t *testing.T) {
+	restaurantId := uuid.New()
+	order := &orderModel.Order{Id: uuid.New(), RestaurantId: restaurantId, Items: []orderModel.OrderItem{
+		{MenuId: 1, Quantity: 2}, {MenuId: 2, Quantity: 1}, {MenuId: 3, Quantity: 1}, {MenuId: 4, Quantity: 1},
+		{MenuId: 2, Quantity: 1},
+	}}
+	today := inventoryModel.Day(time.Now())
+	after := []inventoryModel.Stock{
+		// Crossed the threshold with this order.
+		{MenuId: 1, RestaurantId: restaurantId, Item: "Suya", Mode: inventoryModel.Count, Servings: 4, LowStockAt: 5},
+		// Sold its last servings.
+		{MenuId: 2, RestaurantId: restaurantId, Item: "Zobo", Mode: inventoryModel.Daily, DailyLimit: 20,
+			SoldToday: 20, SoldOn: today, LowStockAt: 5},
+		// Was already low, nothing new to say.
+		{MenuId: 3, RestaurantId: restaurantId, Item: "Puff puff", Mode: inventoryModel.Count, Servings: 2, LowStockAt: 5},
+		{MenuId: 4, RestaurantId: restaurantId, Item: "Water", Mode: inventoryModel.Untracked},
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Reserve", []inventoryModel.Line{
+		{MenuId: 1, Quantity: 2}, {MenuId: 2, Quantity: 2}, {MenuId: 3, Quantity: 1}, {MenuId: 4, Quantity: 1},
+	}, mock.Anything).Return(after, nil)
+	notifier := notify.NewMemoryNotifier()
+	i := NewInventoryService(log, mockRepo, notifier)
+
+	assert.Nil(t, i.Reserve(order))
+	sent := notifier.Sent()
+	assert.Len(t, sent, 2)
+	assert.Equal(t, LowStock, sent[0].Kind)
+	assert.Equal(t, "Suya is running low", sent[0].Title)
+	assert.Equal(t, "4", sent[0].Data["remaining"])
+	assert.Equal(t, &restaurantId, sent[0].RestaurantId)
+	assert.Equal(t, SoldOut, sent[1].Kind)
+	assert.Equal(t, "2", sent[1].Data["menuId"])
+	assert.Equal(t, "It is unavailable until tomorrow.", sent[1].Body)
+}
+
+func Test_inventoryService_ReserveShort(t *testing.T) {
+	order := &orderModel.Order{Id: uuid.New(), Items: []orderModel.OrderItem{{MenuId: 1, Quantity: 3}}}
+	shortage := &inventoryModel.OutOfStockError{MenuId: 1, Item: "Suya", Requested: 3, Remaining: 1}
+	mockRepo := new(MockRepository)
+	mockRepo.On("Reserve", mock.Anything, mock.Anything).Return(nil, shortage)
+	notifier := notify.NewMemoryNotifier()
+	i := NewInventoryService(log, mockRepo, notifier)
+
+	err := i.Reserve(order)
+	assert.Equal(t, shortage, err)
+	assert.Empty(t, notifier.Sent())
+}
+
+func Test_inventoryService_Configure(t *testing.T) {
+	restaurantId := uuid.New()
+	threshold := 2
+	tests := []struct {
+		name         string
+		restaurantId uuid.UUID
+		request      inventoryModel.StockRequest
+		want         inventoryModel.Stock
+		wantErr      bool
+	}{
+		{name: "count", restaurantId: restaurantId,
+			request: inventoryModel.StockRequest{Mode: inventoryModel.Count, Servings: 30, LowStockAt: &threshold},
+			want: inventoryModel.Stock{MenuId: 1, RestaurantId: restaurantId, Mode: inventoryModel.Count,
+				Servings: 30, SoldToday: 3, LowStockAt: 2}},
+		{name: "daily", restaurantId: restaurantId,
+			request: inventoryModel.StockRequest{Mode: inventoryModel.Daily, DailyLimit: 40},
+			want: inventoryModel.Stock{MenuId: 1, RestaurantId: restaurantId, Mode: inventoryModel.Daily,
+				DailyLimit: 40, SoldToday: 3, LowStockAt: 5}},
+		{name: "daily without a limit", restaurantId: restaurantId,
+			request: inventoryModel.StockRequest{Mode: inventoryModel.Daily}, wantErr: true},
+		{name: "another restaurant's item", restaurantId: uuid.New(),
+			request: inventoryModel.StockRequest{Mode: inventoryModel.Untracked}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("FindByMenu", int64(1)).Return(&inventoryModel.Stock{MenuId: 1, RestaurantId: restaurantId,
+				Mode: inventoryModel.Count, Servings: 8, SoldToday: 3, LowStockAt: 5}, nil)
+			mockRepo.On("Save", mock.Anything).Return(nil)
+			i := NewInventoryService(log, mockRepo, notify.NewMemoryNotifier())
+
+			got, err := i.Configure(tt.restaurantId, 1, tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Configure() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				mockRepo.AssertNotCalled(t, "Save", mock.Anything)
+				return
+			}
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
+
+func Test_inventoryService_Restock(t *testing.T) {
+	restaurantId := uuid.New()
+	restocked := &inventoryModel.Stock{MenuId: 1, RestaurantId: restaurantId, Mode: inventoryModel.Count, Servings: 12}
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindByMenu", int64(1)).Return(&inventoryModel.Stock{MenuId: 1, RestaurantId: restaurantId}, nil)
+	mockRepo.On("FindByMenu", int64(2)).Return(nil, inventoryRepo.ErrNotFound)
+	mockRepo.On("Restock", int64(1), 12).Return(restocked, nil)
+	i := NewInventoryService(log, mockRepo, notify.NewMemoryNotifier())
+
+	got, err := i.Restock(restaurantId, 1, inventoryModel.RestockRequest{Quantity: 12})
+	assert.Nil(t, err)
+	assert.True(t, got.Available(time.Now()))
+
+	_, err = i.Restock(restaurantId, 1, inventoryModel.RestockRequest{Quantity: 0})
+	assert.NotNil(t, err)
+
+	_, err = i.Restock(restaurantId, 2, inventoryModel.RestockRequest{Quantity: 5})
+	assert.ErrorIs(t, err, inventoryRepo.ErrNotFound)
+}
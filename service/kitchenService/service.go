@@ -0,0 +1,246 @@
+package kitchenService
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/kitchenModel"
+	"rsm/entity/orderModel"
+	"rsm/pubsub"
+	"rsm/repository/kitchenRepo"
+	"rsm/service/inventoryService"
+	"rsm/service/loyaltyService"
+	"rsm/service/orderService"
+	"time"
+)
+
+type ServiceInterface interface {
+	Queue(restaurantId uuid.UUID) ([]kitchenModel.Station, error)
+	Accept(orderId uuid.UUID) ([]kitchenModel.Ticket, error)
+	Act(orderId uuid.UUID, station string, action kitchenModel.Action) (*kitchenModel.Ticket, error)
+	Complete(orderId uuid.UUID) error
+	Cancel(orderId uuid.UUID) error
+}
+
+type kitchenService struct {
+	log       *logrus.Logger
+	repo      kitchenRepo.RepoInterface
+	orders    orderService.ServiceInterface
+	inventory inventoryService.ServiceInterface
+	loyalty   loyaltyService.ServiceInterface
+	broker    pubsub.Broker
+}
+
+// Topic is where a restaurant's ticket events are published.
+func Topic(restaurantId uuid.UUID) string {
+	return "kitchen:" + restaurantId.String()
+}
+
+// Queue lists the tickets of every order the kitchen is working on, by station.
+func (k *kitchenService) Queue(restaurantId uuid.UUID) ([]kitchenModel.Station, error) {
+	orders, err := k.orders.ListByRestaurant(restaurantId, kitchenModel.QueueStatuses)
+	if err != nil {
+		return nil, err
+	}
+	stations := []kitchenModel.Station{}
+	if len(orders) == 0 {
+		return stations, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.Id)
+	}
+	states, err := k.repo.FindStates(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var tickets []kitchenModel.Ticket
+	for _, o := range orders {
+		tickets = append(tickets, kitchenModel.Tickets(o, states, now)...)
+	}
+	return append(stations, kitchenModel.Group(tickets)...), nil
+}
+
+// Accept takes a pending order into the kitchen and puts its tickets on the screens. The
+// order's items are taken out of stock first; an order for something that sold out is
+// left pending with an *inventoryModel.OutOfStockError.
+func (k *kitchenService) Accept(orderId uuid.UUID) ([]kitchenModel.Ticket, error) {
+	order, err := k.orders.GetOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != orderModel.Pending {
+		return nil, fmt.Errorf("cannot accept a %v order", order.Status)
+	}
+	err = k.inventory.Reserve(order)
+	if err != nil {
+		return nil, err
+	}
+	err = k.orders.UpdateStatus(order, orderModel.Accepted)
+	if err != nil {
+		releaseErr := k.inventory.Release(order)
+		if releaseErr != nil {
+			k.log.Errorf("Error Releasing Stock For Order %v: %v", order.Id, releaseErr)
+		}
+		return nil, err
+	}
+
+	tickets := kitchenModel.Tickets(*order, nil, time.Now())
+	for _, t := range tickets {
+		k.publish(kitchenModel.Added, t)
+	}
+	return tickets, nil
+}
+
+// Act starts, bumps or recalls one station's ticket. The order follows its tickets: it is
+// preparing once work starts and ready when every station has bumped. The repository moves
+// the ticket and the order together.
+func (k *kitchenService) Act(orderId uuid.UUID, station string, action kitchenModel.Action) (*kitchenModel.Ticket, error) {
+	order, tickets, err := k.tickets(orderId)
+	if err != nil {
+		return nil, err
+	}
+	station = kitchenModel.StationFor(station)
+	index := -1
+	for i, t := range tickets {
+		if t.Station == station {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("order has nothing for the %v station", station)
+	}
+
+	ticket := tickets[index]
+	next, err := kitchenModel.Next(ticket.Status, action)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	state := ticket.TicketState
+	state.Status = next
+	switch action {
+	case kitchenModel.Start:
+		state.StartedAt = &now
+	case kitchenModel.Bump:
+		if state.StartedAt == nil {
+			state.StartedAt = &now
+		}
+		state.BumpedAt = &now
+	case kitchenModel.Recall:
+		state.BumpedAt = nil
+	}
+	previous, status, err := k.repo.SaveState(*order, state, ticket.Status)
+	if err != nil {
+		return nil, err
+	}
+	order.Status = previous
+	k.orders.Notify(order, status)
+
+	ticket = kitchenModel.Tickets(*order, []kitchenModel.TicketState{state}, now)[index]
+	k.publish(action, ticket)
+	return &ticket, nil
+}
+
+// Complete hands a ready order over, takes its tickets off the screens and credits the
+// customer's loyalty points.
+func (k *kitchenService) Complete(orderId uuid.UUID) error {
+	order, tickets, err := k.tickets(orderId)
+	if err != nil {
+		return err
+	}
+	if order.Status != orderModel.Ready {
+		return fmt.Errorf("cannot complete a %v order", order.Status)
+	}
+	err = k.orders.UpdateStatus(order, orderModel.Completed)
+	if err != nil {
+		return err
+	}
+	for _, t := range tickets {
+		t.OrderStatus = orderModel.Completed
+		k.publish(kitchenModel.Cleared, t)
+	}
+	_, err = k.loyalty.EarnForOrder(order)
+	if err != nil {
+		k.log.Errorf("Error Earning Loyalty Points For Order %v: %v", order.Id, err)
+		return err
+	}
+	return nil
+}
+
+// Cancel calls off an order the kitchen has not finished, giving back any loyalty points
+// spent on it. An accepted order had its items taken out of stock, so they are put back and
+// its tickets come off the screens.
+func (k *kitchenService) Cancel(orderId uuid.UUID) error {
+	order, err := k.orders.GetOrder(orderId)
+	if err != nil {
+		return err
+	}
+	previous := order.Status
+	err = k.orders.UpdateStatus(order, orderModel.Cancelled)
+	if err != nil {
+		return err
+	}
+	err = k.loyalty.Reverse(order, order.Total)
+	if err != nil {
+		k.log.Errorf("Error Restoring Loyalty Points For Order %v: %v", order.Id, err)
+		return err
+	}
+	if previous == orderModel.Pending {
+		return nil
+	}
+
+	err = k.inventory.Release(order)
+	if err != nil {
+		k.log.Errorf("Error Releasing Stock For Order %v: %v", order.Id, err)
+		return err
+	}
+	for _, t := range kitchenModel.Tickets(*order, nil, time.Now()) {
+		k.publish(kitchenModel.Cleared, t)
+	}
+	return nil
+}
+
+func (k *kitchenService) tickets(orderId uuid.UUID) (*orderModel.Order, []kitchenModel.Ticket, error) {
+	order, err := k.orders.GetOrder(orderId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !inQueue(order.Status) {
+		return nil, nil, fmt.Errorf("order is not in the kitchen queue")
+	}
+	states, err := k.repo.FindStates([]uuid.UUID{orderId})
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, kitchenModel.Tickets(*order, states, time.Now()), nil
+}
+
+func inQueue(status orderModel.Status) bool {
+	for _, s := range kitchenModel.QueueStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// publish pushes the change to the screens. The change is already saved, so a failure only
+// means screens catch up on their next reload.
+func (k *kitchenService) publish(action kitchenModel.Action, ticket kitchenModel.Ticket) {
+	payload, err := json.Marshal(kitchenModel.TicketEvent{Action: action, Ticket: ticket, At: time.Now()})
+	if err == nil {
+		_, err = k.broker.Publish(Topic(ticket.RestaurantId), payload)
+	}
+	if err != nil {
+		k.log.Errorf("Error Publishing Ticket Event: %v", err)
+	}
+}
+
+func NewKitchenService(log *logrus.Logger, repo kitchenRepo.RepoInterface, orders orderService.ServiceInterface, inventory inventoryService.ServiceInterface, loyalty loyaltyService.ServiceInterface, broker pubsub.Broker) ServiceInterface {
+	return &kitchenService{log: log, repo: repo, orders: orders, inventory: inventory, loyalty: loyalty, broker: broker}
+}
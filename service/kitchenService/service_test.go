@@ -0,0 +1,377 @@
+package kitchenService
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/inventoryModel"
+	"rsm/entity/kitchenModel"
+	"rsm/entity/loyaltyModel"
+	"rsm/entity/orderModel"
+	"rsm/pricing"
+	"rsm/pubsub"
+	"rsm/repository/orderRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) FindStates(orderIds []uuid.UUID) ([]kitchenModel.TicketState, error) {
+	args := m.Called(orderIds)
+	return args.Get(0).([]kitchenModel.TicketState), args.Error(1)
+}
+
+func (m *MockRepository) SaveState(order orderModel.Order, state kitchenModel.TicketState, expected kitchenModel.TicketStatus) (orderModel.Status, orderModel.Status, error) {
+	args := m.Called(order, state, expected)
+	previous, _ := args.Get(0).(orderModel.Status)
+	current, _ := args.Get(1).(orderModel.Status)
+	return previous, current, args.Error(2)
+}
+
+type MockOrderService struct {
+	mock.Mock
+}
+
+func (m *MockOrderService) GetOrder(id uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(id)
+	return args.Get(0).(*orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) GetUserOrder(userId, orderId uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(userId, orderId)
+	return args.Get(0).(*orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) ListByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error) {
+	args := m.Called(restaurantId, statuses)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) UpdateStatus(order *orderModel.Order, to orderModel.Status) error {
+	args := m.Called(order, to)
+	if args.Error(0) == nil {
+		order.Status = to
+	}
+	return args.Error(0)
+}
+
+func (m *MockOrderService) Notify(order *orderModel.Order, to orderModel.Status) {
+	m.Called(order, to)
+	order.Status = to
+}
+
+func (m *MockOrderService) ListHistory(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error) {
+	args := m.Called(userId, query)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderService) Reorder(userId, orderId uuid.UUID) (*pricing.Reorder, error) {
+	args := m.Called(userId, orderId)
+	reorder, _ := args.Get(0).(*pricing.Reorder)
+	return reorder, args.Error(1)
+}
+
+type MockInventoryService struct {
+	mock.Mock
+}
+
+func (m *MockInventoryService) ListStock(restaurantId uuid.UUID) ([]inventoryModel.Stock, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]inventoryModel.Stock), args.Error(1)
+}
+
+func (m *MockInventoryService) Configure(restaurantId uuid.UUID, menuId int64, request inventoryModel.StockRequest) (*inventoryModel.Stock, error) {
+	args := m.Called(restaurantId, menuId, request)
+	return args.Get(0).(*inventoryModel.Stock), args.Error(1)
+}
+
+func (m *MockInventoryService) Restock(restaurantId uuid.UUID, menuId int64, request inventoryModel.RestockRequest) (*inventoryModel.Stock, error) {
+	args := m.Called(restaurantId, menuId, request)
+	return args.Get(0).(*inventoryModel.Stock), args.Error(1)
+}
+
+func (m *MockInventoryService) Reserve(order *orderModel.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
+func (m *MockInventoryService) Release(order *orderModel.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
+type MockLoyaltyService struct {
+	mock.Mock
+}
+
+func (m *MockLoyaltyService) SaveRule(rule *loyaltyModel.EarnRule) error {
+	args := m.Called(rule)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) CreateReward(reward *loyaltyModel.Reward) error {
+	args := m.Called(reward)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) UpdateReward(reward *loyaltyModel.Reward) error {
+	args := m.Called(reward)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) Rewards(restaurantId uuid.UUID) ([]loyaltyModel.Reward, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]loyaltyModel.Reward), args.Error(1)
+}
+
+func (m *MockLoyaltyService) EarnForOrder(order *orderModel.Order) (*loyaltyModel.Entry, error) {
+	args := m.Called(order)
+	entry, _ := args.Get(0).(*loyaltyModel.Entry)
+	return entry, args.Error(1)
+}
+
+func (m *MockLoyaltyService) QuoteReward(userId, rewardId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Breakdown, error) {
+	args := m.Called(userId, rewardId, cart, codes)
+	breakdown, _ := args.Get(0).(*pricing.Breakdown)
+	return breakdown, args.Error(1)
+}
+
+func (m *MockLoyaltyService) Redeem(order *orderModel.Order, rewardId uuid.UUID, priced *pricing.Breakdown) (*loyaltyModel.Entry, error) {
+	args := m.Called(order, rewardId, priced)
+	entry, _ := args.Get(0).(*loyaltyModel.Entry)
+	return entry, args.Error(1)
+}
+
+func (m *MockLoyaltyService) OrderReward(orderId uuid.UUID) (*loyaltyModel.Reward, error) {
+	args := m.Called(orderId)
+	reward, _ := args.Get(0).(*loyaltyModel.Reward)
+	return reward, args.Error(1)
+}
+
+func (m *MockLoyaltyService) Reverse(order *orderModel.Order, refunded int64) error {
+	args := m.Called(order, refunded)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) ExpirePoints(at time.Time) (int64, error) {
+	args := m.Called(at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoyaltyService) Balance(userId uuid.UUID) (*loyaltyModel.Balance, error) {
+	args := m.Called(userId)
+	balance, _ := args.Get(0).(*loyaltyModel.Balance)
+	return balance, args.Error(1)
+}
+
+func (m *MockLoyaltyService) History(userId uuid.UUID, limit, offset int) ([]loyaltyModel.Entry, error) {
+	args := m.Called(userId, limit, offset)
+	return args.Get(0).([]loyaltyModel.Entry), args.Error(1)
+}
+
+func testOrder(status orderModel.Status) *orderModel.Order {
+	return &orderModel.Order{Id: uuid.New(), RestaurantId: uuid.New(), Status: status,
+		CreatedAt: time.Now().Add(-time.Minute),
+		Items: []orderModel.OrderItem{
+			{Id: 1, Item: "Suya", ItemType: "grill"},
+			{Id: 2, Item: "Zobo", ItemType: "drinks"},
+		}}
+}
+
+func nextEvent(t *testing.T, messages <-chan pubsub.Message) kitchenModel.TicketEvent {
+	var event kitchenModel.TicketEvent
+	select {
+	case msg := <-messages:
+		assert.Nil(t, json.Unmarshal(msg.Payload, &event))
+	case <-time.After(time.Second):
+		t.Fatal("no event published")
+	}
+	return event
+}
+
+func Test_kitchenService_Act(t *testing.T) {
+	order := testOrder(orderModel.Preparing)
+	started := time.Now().Add(-30 * time.Second)
+
+	mockRepo := new(MockRepository)
+	mockOrders := new(MockOrderService)
+	mockOrders.On("GetOrder", order.Id).Return(order, nil)
+	mockRepo.On("FindStates", []uuid.UUID{order.Id}).Return([]kitchenModel.TicketState{
+		{OrderId: order.Id, Station: "drinks", Status: kitchenModel.Done, StartedAt: &started, BumpedAt: &started},
+		{OrderId: order.Id, Station: "grill", Status: kitchenModel.Preparing, StartedAt: &started},
+	}, nil)
+	mockRepo.On("SaveState", mock.Anything, mock.Anything, kitchenModel.Preparing).
+		Return(orderModel.Preparing, orderModel.Ready, nil)
+	mockOrders.On("Notify", order, orderModel.Ready).Return()
+
+	broker := pubsub.NewMemoryBroker()
+	messages, _ := broker.Subscribe(context.Background(), Topic(order.RestaurantId))
+	mockLoyalty := new(MockLoyaltyService)
+	k := NewKitchenService(log, mockRepo, mockOrders, new(MockInventoryService), mockLoyalty, broker)
+
+	_, err := k.Act(order.Id, "pastry", kitchenModel.Bump)
+	assert.Equal(t, fmt.Errorf("order has nothing for the pastry station"), err)
+
+	_, err = k.Act(order.Id, "Grill", kitchenModel.Recall)
+	assert.Equal(t, fmt.Errorf("cannot recall a preparing ticket"), err)
+
+	// Bumping the last open station makes the order ready.
+	ticket, err := k.Act(order.Id, "Grill", kitchenModel.Bump)
+	assert.Nil(t, err)
+	assert.Equal(t, kitchenModel.Done, ticket.Status)
+	assert.Equal(t, orderModel.Ready, ticket.OrderStatus)
+	assert.Equal(t, &started, ticket.StartedAt)
+	assert.NotNil(t, ticket.BumpedAt)
+	assert.Equal(t, int64(30), ticket.Timer.PreparingSeconds)
+	mockOrders.AssertCalled(t, "Notify", order, orderModel.Ready)
+	mockOrders.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+
+	event := nextEvent(t, messages)
+	assert.Equal(t, kitchenModel.Bump, event.Action)
+	assert.Equal(t, "grill", event.Ticket.Station)
+	assert.Equal(t, orderModel.Ready, event.Ticket.OrderStatus)
+}
+
+func Test_kitchenService_Accept(t *testing.T) {
+	order := testOrder(orderModel.Pending)
+	accepted := testOrder(orderModel.Accepted)
+
+	mockOrders := new(MockOrderService)
+	mockOrders.On("GetOrder", order.Id).Return(order, nil)
+	mockOrders.On("GetOrder", accepted.Id).Return(accepted, nil)
+	mockOrders.On("UpdateStatus", order, orderModel.Accepted).Return(nil)
+	mockInventory := new(MockInventoryService)
+	mockInventory.On("Reserve", order).Return(nil)
+
+	broker := pubsub.NewMemoryBroker()
+	messages, _ := broker.Subscribe(context.Background(), Topic(order.RestaurantId))
+	mockLoyalty := new(MockLoyaltyService)
+	k := NewKitchenService(log, new(MockRepository), mockOrders, mockInventory, mockLoyalty, broker)
+
+	tickets, err := k.Accept(order.Id)
+	assert.Nil(t, err)
+	assert.Len(t, tickets, 2)
+	for _, station := range []string{"drinks", "grill"} {
+		event := nextEvent(t, messages)
+		assert.Equal(t, kitchenModel.Added, event.Action)
+		assert.Equal(t, station, event.Ticket.Station)
+		assert.Equal(t, kitchenModel.Queued, event.Ticket.Status)
+	}
+
+	_, err = k.Accept(accepted.Id)
+	assert.Equal(t, fmt.Errorf("cannot accept a accepted order"), err)
+	mockInventory.AssertNumberOfCalls(t, "Reserve", 1)
+}
+
+func Test_kitchenService_AcceptStock(t *testing.T) {
+	soldOut, raced := testOrder(orderModel.Pending), testOrder(orderModel.Pending)
+	shortage := &inventoryModel.OutOfStockError{MenuId: 1, Item: "Suya"}
+
+	mockOrders := new(MockOrderService)
+	mockOrders.On("GetOrder", soldOut.Id).Return(soldOut, nil)
+	mockOrders.On("GetOrder", raced.Id).Return(raced, nil)
+	mockOrders.On("UpdateStatus", raced, orderModel.Accepted).Return(orderRepo.ErrStaleStatus)
+	mockInventory := new(MockInventoryService)
+	mockInventory.On("Reserve", soldOut).Return(shortage)
+	mockInventory.On("Reserve", raced).Return(nil)
+	mockInventory.On("Release", raced).Return(nil)
+	mockLoyalty := new(MockLoyaltyService)
+	k := NewKitchenService(log, new(MockRepository), mockOrders, mockInventory, mockLoyalty, pubsub.NewMemoryBroker())
+
+	_, err := k.Accept(soldOut.Id)
+	assert.Equal(t, shortage, err)
+	mockOrders.AssertNotCalled(t, "UpdateStatus", soldOut, mock.Anything)
+
+	// Stock reserved for an order someone else moved on is put back.
+	_, err = k.Accept(raced.Id)
+	assert.ErrorIs(t, err, orderRepo.ErrStaleStatus)
+	mockInventory.AssertCalled(t, "Release", raced)
+}
+
+func Test_kitchenService_Complete(t *testing.T) {
+	order := testOrder(orderModel.Ready)
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindStates", []uuid.UUID{order.Id}).Return([]kitchenModel.TicketState{}, nil)
+	mockOrders := new(MockOrderService)
+	mockOrders.On("GetOrder", order.Id).Return(order, nil)
+	mockOrders.On("UpdateStatus", order, orderModel.Completed).Run(func(args mock.Arguments) {
+		order.Status = orderModel.Completed
+	}).Return(nil)
+	mockLoyalty := new(MockLoyaltyService)
+	mockLoyalty.On("EarnForOrder", order).Return(&loyaltyModel.Entry{}, nil)
+	k := NewKitchenService(log, mockRepo, mockOrders, new(MockInventoryService), mockLoyalty, pubsub.NewMemoryBroker())
+
+	assert.Nil(t, k.Complete(order.Id))
+	mockLoyalty.AssertCalled(t, "EarnForOrder", order)
+}
+
+func Test_kitchenService_Cancel(t *testing.T) {
+	pending, preparing, ready := testOrder(orderModel.Pending), testOrder(orderModel.Preparing),
+		testOrder(orderModel.Ready)
+
+	mockOrders := new(MockOrderService)
+	for _, o := range []*orderModel.Order{pending, preparing, ready} {
+		mockOrders.On("GetOrder", o.Id).Return(o, nil)
+	}
+	mockOrders.On("UpdateStatus", pending, orderModel.Cancelled).Return(nil)
+	mockOrders.On("UpdateStatus", preparing, orderModel.Cancelled).Return(nil)
+	mockOrders.On("UpdateStatus", ready, orderModel.Cancelled).Return(fmt.Errorf("cannot move a ready order to cancelled"))
+	mockInventory := new(MockInventoryService)
+	mockInventory.On("Release", preparing).Return(nil)
+	mockLoyalty := new(MockLoyaltyService)
+	mockLoyalty.On("Reverse", mock.Anything, mock.Anything).Return(nil)
+
+	broker := pubsub.NewMemoryBroker()
+	messages, _ := broker.Subscribe(context.Background(), Topic(preparing.RestaurantId))
+	k := NewKitchenService(log, new(MockRepository), mockOrders, mockInventory, mockLoyalty, broker)
+
+	// Nothing was taken from stock before the order was accepted.
+	assert.Nil(t, k.Cancel(pending.Id))
+	mockInventory.AssertNotCalled(t, "Release", mock.Anything)
+
+	assert.Nil(t, k.Cancel(preparing.Id))
+	mockInventory.AssertCalled(t, "Release", preparing)
+	event := nextEvent(t, messages)
+	assert.Equal(t, kitchenModel.Cleared, event.Action)
+	assert.Equal(t, orderModel.Cancelled, event.Ticket.OrderStatus)
+
+	assert.NotNil(t, k.Cancel(ready.Id))
+	mockInventory.AssertNumberOfCalls(t, "Release", 1)
+	// Points spent on either cancelled order are given back.
+	mockLoyalty.AssertCalled(t, "Reverse", pending, pending.Total)
+	mockLoyalty.AssertNumberOfCalls(t, "Reverse", 2)
+}
+
+func Test_kitchenService_Queue(t *testing.T) {
+	restaurantId := uuid.New()
+	first, second := testOrder(orderModel.Accepted), testOrder(orderModel.Preparing)
+	first.CreatedAt = second.CreatedAt.Add(-time.Minute)
+
+	mockRepo := new(MockRepository)
+	mockOrders := new(MockOrderService)
+	mockOrders.On("ListByRestaurant", restaurantId, kitchenModel.QueueStatuses).
+		Return([]orderModel.Order{*first, *second}, nil)
+	mockRepo.On("FindStates", []uuid.UUID{first.Id, second.Id}).Return([]kitchenModel.TicketState{
+		{OrderId: second.Id, Station: "grill", Status: kitchenModel.Preparing},
+	}, nil)
+
+	mockLoyalty := new(MockLoyaltyService)
+	k := NewKitchenService(log, mockRepo, mockOrders, new(MockInventoryService), mockLoyalty, pubsub.NewMemoryBroker())
+	stations, err := k.Queue(restaurantId)
+	assert.Nil(t, err)
+	assert.Equal(t, "drinks", stations[0].Name)
+	assert.Equal(t, "grill", stations[1].Name)
+	assert.Equal(t, first.Id, stations[1].Tickets[0].OrderId)
+	assert.Equal(t, kitchenModel.Queued, stations[1].Tickets[0].Status)
+	assert.Equal(t, kitchenModel.Preparing, stations[1].Tickets[1].Status)
+}
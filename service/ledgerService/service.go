@@ -0,0 +1,206 @@
+package ledgerService
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/ledgerModel"
+	"rsm/entity/moneyModel"
+	"rsm/repository/ledgerRepo"
+	"time"
+)
+
+// ErrAlreadyPaidOut is returned when a payout period overlaps one that was already generated.
+var ErrAlreadyPaidOut = errors.New("period overlaps an existing payout")
+
+type ServiceInterface interface {
+	Post(entry ledgerModel.JournalEntry) (*ledgerModel.JournalEntry, error)
+	RecordSettlement(settlement ledgerModel.Settlement) (*ledgerModel.JournalEntry, error)
+	Balance(accountType ledgerModel.AccountType, ownerId uuid.UUID, currency string, asOf time.Time) (moneyModel.Money, error)
+	GeneratePayout(restaurantId uuid.UUID, currency string, periodStart, periodEnd time.Time) (*ledgerModel.PayoutBatch, error)
+	GeneratePayouts(periodStart, periodEnd time.Time) ([]ledgerModel.PayoutBatch, error)
+}
+
+type ledgerService struct {
+	log  *logrus.Logger
+	repo ledgerRepo.RepoInterface
+}
+
+// Post writes a balanced entry effective now. Entries cannot be backdated so balances of
+// periods that were already paid out never change.
+func (l *ledgerService) Post(entry ledgerModel.JournalEntry) (*ledgerModel.JournalEntry, error) {
+	err := entry.Validate()
+	if err != nil {
+		l.log.Errorf("Validation Error: %v", err)
+		return nil, err
+	}
+	entry.Id = uuid.New()
+	entry.CreatedAt = time.Now()
+	entry.EffectiveAt = entry.CreatedAt
+	err = l.repo.PostEntry(&entry)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// RecordSettlement books a captured order payment: the customer's payment is split between
+// the restaurant, the platform fee and tax. Recording the same order twice, even at the same
+// time, returns the original entry: the reference is unique, so only one post wins.
+func (l *ledgerService) RecordSettlement(settlement ledgerModel.Settlement) (*ledgerModel.JournalEntry, error) {
+	reference := fmt.Sprintf("order:%v:settlement", settlement.OrderId)
+	existing, err := l.recorded(reference)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	share, err := settlement.RestaurantShare()
+	if err != nil {
+		return nil, err
+	}
+	if share.IsNegative() || settlement.PlatformFee.IsNegative() || settlement.Tax.IsNegative() {
+		return nil, fmt.Errorf("fee and tax cannot exceed the gross amount")
+	}
+
+	currency := settlement.Gross.Currency
+	legs := []struct {
+		accountType ledgerModel.AccountType
+		ownerId     uuid.UUID
+		amount      moneyModel.Money
+	}{
+		{ledgerModel.Customer, settlement.CustomerId, settlement.Gross},
+		{ledgerModel.Restaurant, settlement.RestaurantId, share.Neg()},
+		{ledgerModel.PlatformFee, uuid.Nil, settlement.PlatformFee.Neg()},
+		{ledgerModel.Tax, uuid.Nil, settlement.Tax.Neg()},
+	}
+
+	entry := ledgerModel.JournalEntry{Description: "order settlement", Reference: reference}
+	for _, leg := range legs {
+		if leg.amount.IsZero() {
+			continue
+		}
+		account, err := l.repo.FindOrCreateAccount(leg.accountType, leg.ownerId, currency)
+		if err != nil {
+			return nil, err
+		}
+		entry.Postings = append(entry.Postings, ledgerModel.Posting{AccountId: account.Id, Amount: leg.amount})
+	}
+	posted, err := l.Post(entry)
+	if errors.Is(err, ledgerRepo.ErrDuplicateReference) {
+		return l.recorded(reference)
+	}
+	return posted, err
+}
+
+// recorded returns the entry posted under the reference, or nil if there is none.
+func (l *ledgerService) recorded(reference string) (*ledgerModel.JournalEntry, error) {
+	existing, err := l.repo.FindEntriesByReference(reference)
+	if err != nil || len(existing) == 0 {
+		return nil, err
+	}
+	return &existing[0], nil
+}
+
+func (l *ledgerService) Balance(accountType ledgerModel.AccountType, ownerId uuid.UUID, currency string, asOf time.Time) (moneyModel.Money, error) {
+	account, err := l.repo.FindOrCreateAccount(accountType, ownerId, currency)
+	if err != nil {
+		return moneyModel.Money{}, err
+	}
+	return l.repo.Balance(account.Id, asOf)
+}
+
+// GeneratePayout pays a restaurant everything it was owed at periodEnd. The payout entry is
+// effective at periodEnd so the next period starts from a settled balance. Nothing is
+// generated when the restaurant is owed nothing; the balance carries forward.
+func (l *ledgerService) GeneratePayout(restaurantId uuid.UUID, currency string, periodStart, periodEnd time.Time) (*ledgerModel.PayoutBatch, error) {
+	if !periodStart.Before(periodEnd) {
+		return nil, fmt.Errorf("payout period must end after it starts")
+	}
+	if periodEnd.After(time.Now()) {
+		return nil, fmt.Errorf("payout period has not ended yet")
+	}
+
+	last, err := l.repo.LastPayout(restaurantId, currency)
+	if err != nil && !errors.Is(err, ledgerRepo.ErrNotFound) {
+		return nil, err
+	}
+	if last != nil && last.PeriodEnd.After(periodStart) {
+		return nil, ErrAlreadyPaidOut
+	}
+
+	account, err := l.repo.FindOrCreateAccount(ledgerModel.Restaurant, restaurantId, currency)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := l.repo.Balance(account.Id, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+	owed := balance.Neg()
+	if owed.Amount <= 0 {
+		return nil, nil
+	}
+
+	clearing, err := l.repo.FindOrCreateAccount(ledgerModel.Payout, uuid.Nil, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	batch := ledgerModel.PayoutBatch{
+		Id:           uuid.New(),
+		RestaurantId: restaurantId,
+		PeriodStart:  periodStart,
+		PeriodEnd:    periodEnd,
+		Amount:       owed,
+		CreatedAt:    now,
+	}
+	entry := ledgerModel.JournalEntry{
+		Id:          uuid.New(),
+		Description: "restaurant payout",
+		Reference:   fmt.Sprintf("payout:%v", batch.Id),
+		Postings: []ledgerModel.Posting{
+			{AccountId: account.Id, Amount: owed},
+			{AccountId: clearing.Id, Amount: owed.Neg()},
+		},
+		EffectiveAt: periodEnd,
+		CreatedAt:   now,
+	}
+	batch.EntryId = entry.Id
+
+	err = l.repo.PersistPayout(&batch, &entry)
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GeneratePayouts runs GeneratePayout for every restaurant account. Restaurants already paid
+// for the period are skipped, so the job can be re-run safely.
+func (l *ledgerService) GeneratePayouts(periodStart, periodEnd time.Time) ([]ledgerModel.PayoutBatch, error) {
+	accounts, err := l.repo.FindAccountsByType(ledgerModel.Restaurant)
+	if err != nil {
+		return nil, err
+	}
+
+	batches := []ledgerModel.PayoutBatch{}
+	for _, account := range accounts {
+		batch, err := l.GeneratePayout(account.OwnerId, account.Currency, periodStart, periodEnd)
+		if errors.Is(err, ErrAlreadyPaidOut) {
+			continue
+		}
+		if err != nil {
+			l.log.Errorf("Error Generating Payout For %v: %v", account.OwnerId, err)
+			return batches, err
+		}
+		if batch != nil {
+			batches = append(batches, *batch)
+		}
+	}
+	return batches, nil
+}
+
+func NewLedgerService(log *logrus.Logger, repo ledgerRepo.RepoInterface) ServiceInterface {
+	return &ledgerService{log: log, repo: repo}
+}
@@ -0,0 +1,173 @@
+package ledgerService
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/ledgerModel"
+	"rsm/entity/moneyModel"
+	"rsm/repository/ledgerRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) FindOrCreateAccount(accountType ledgerModel.AccountType, ownerId uuid.UUID, currency string) (*ledgerModel.Account, error) {
+	args := m.Called(accountType, ownerId, currency)
+	return args.Get(0).(*ledgerModel.Account), args.Error(1)
+}
+
+func (m *MockRepository) FindAccountsByType(accountType ledgerModel.AccountType) ([]ledgerModel.Account, error) {
+	args := m.Called(accountType)
+	return args.Get(0).([]ledgerModel.Account), args.Error(1)
+}
+
+func (m *MockRepository) PostEntry(entry *ledgerModel.JournalEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindEntriesByReference(reference string) ([]ledgerModel.JournalEntry, error) {
+	args := m.Called(reference)
+	return args.Get(0).([]ledgerModel.JournalEntry), args.Error(1)
+}
+
+func (m *MockRepository) Balance(accountId uuid.UUID, asOf time.Time) (moneyModel.Money, error) {
+	args := m.Called(accountId, asOf)
+	return args.Get(0).(moneyModel.Money), args.Error(1)
+}
+
+func (m *MockRepository) LastPayout(restaurantId uuid.UUID, currency string) (*ledgerModel.PayoutBatch, error) {
+	args := m.Called(restaurantId, currency)
+	batch, _ := args.Get(0).(*ledgerModel.PayoutBatch)
+	return batch, args.Error(1)
+}
+
+func (m *MockRepository) PersistPayout(batch *ledgerModel.PayoutBatch, entry *ledgerModel.JournalEntry) error {
+	args := m.Called(batch, entry)
+	return args.Error(0)
+}
+
+func account(accountType ledgerModel.AccountType, ownerId uuid.UUID) *ledgerModel.Account {
+	return &ledgerModel.Account{Id: uuid.New(), Type: accountType, OwnerId: ownerId, Currency: "NGN"}
+}
+
+func Test_ledgerService_RecordSettlement(t *testing.T) {
+	orderId, customerId, restaurantId := uuid.New(), uuid.New(), uuid.New()
+	customer := account(ledgerModel.Customer, customerId)
+	restaurant := account(ledgerModel.Restaurant, restaurantId)
+	fee := account(ledgerModel.PlatformFee, uuid.Nil)
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindEntriesByReference", "order:"+orderId.String()+":settlement").Return([]ledgerModel.JournalEntry{}, nil)
+	mockRepo.On("FindOrCreateAccount", ledgerModel.Customer, customerId, "NGN").Return(customer, nil)
+	mockRepo.On("FindOrCreateAccount", ledgerModel.Restaurant, restaurantId, "NGN").Return(restaurant, nil)
+	mockRepo.On("FindOrCreateAccount", ledgerModel.PlatformFee, uuid.Nil, "NGN").Return(fee, nil)
+	mockRepo.On("PostEntry", mock.AnythingOfType("*ledgerModel.JournalEntry")).Return(nil)
+
+	l := NewLedgerService(log, mockRepo)
+	entry, err := l.RecordSettlement(ledgerModel.Settlement{
+		OrderId:      orderId,
+		CustomerId:   customerId,
+		RestaurantId: restaurantId,
+		Gross:        moneyModel.New(10000, "NGN"),
+		PlatformFee:  moneyModel.New(1500, "NGN"),
+		Tax:          moneyModel.Zero("NGN"),
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []ledgerModel.Posting{
+		{AccountId: customer.Id, Amount: moneyModel.New(10000, "NGN")},
+		{AccountId: restaurant.Id, Amount: moneyModel.New(-8500, "NGN")},
+		{AccountId: fee.Id, Amount: moneyModel.New(-1500, "NGN")},
+	}, entry.Postings)
+	mockRepo.AssertNotCalled(t, "FindOrCreateAccount", ledgerModel.Tax, uuid.Nil, "NGN")
+}
+
+func Test_ledgerService_RecordSettlementTwice(t *testing.T) {
+	orderId := uuid.New()
+	recorded := ledgerModel.JournalEntry{Id: uuid.New(), Reference: "order:" + orderId.String() + ":settlement"}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindEntriesByReference", recorded.Reference).Return([]ledgerModel.JournalEntry{recorded}, nil)
+
+	l := NewLedgerService(log, mockRepo)
+	entry, err := l.RecordSettlement(ledgerModel.Settlement{OrderId: orderId, Gross: moneyModel.New(1, "NGN")})
+
+	assert.Nil(t, err)
+	assert.Equal(t, recorded.Id, entry.Id)
+	mockRepo.AssertNotCalled(t, "PostEntry", mock.Anything)
+}
+
+func Test_ledgerService_RecordSettlementConcurrently(t *testing.T) {
+	orderId, customerId := uuid.New(), uuid.New()
+	recorded := ledgerModel.JournalEntry{Id: uuid.New(), Reference: "order:" + orderId.String() + ":settlement"}
+	customer := &ledgerModel.Account{Id: uuid.New(), Type: ledgerModel.Customer, OwnerId: customerId, Currency: "NGN"}
+	restaurant := &ledgerModel.Account{Id: uuid.New(), Type: ledgerModel.Restaurant, Currency: "NGN"}
+
+	// The other settlement posts between the check and this one's post.
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindEntriesByReference", recorded.Reference).Return([]ledgerModel.JournalEntry{}, nil).Once()
+	mockRepo.On("FindEntriesByReference", recorded.Reference).Return([]ledgerModel.JournalEntry{recorded}, nil)
+	mockRepo.On("FindOrCreateAccount", ledgerModel.Customer, customerId, "NGN").Return(customer, nil)
+	mockRepo.On("FindOrCreateAccount", ledgerModel.Restaurant, uuid.Nil, "NGN").Return(restaurant, nil)
+	mockRepo.On("PostEntry", mock.Anything).Return(ledgerRepo.ErrDuplicateReference)
+
+	l := NewLedgerService(log, mockRepo)
+	entry, err := l.RecordSettlement(ledgerModel.Settlement{OrderId: orderId, CustomerId: customerId,
+		Gross: moneyModel.New(100, "NGN"), PlatformFee: moneyModel.Zero("NGN"), Tax: moneyModel.Zero("NGN")})
+
+	assert.Nil(t, err)
+	assert.Equal(t, recorded.Id, entry.Id)
+}
+
+func Test_ledgerService_GeneratePayouts(t *testing.T) {
+	start := time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2022, time.July, 1, 0, 0, 0, 0, time.UTC)
+	owed, settled, paid := uuid.New(), uuid.New(), uuid.New()
+	owedAccount, settledAccount := account(ledgerModel.Restaurant, owed), account(ledgerModel.Restaurant, settled)
+	clearing := account(ledgerModel.Payout, uuid.Nil)
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindAccountsByType", ledgerModel.Restaurant).Return([]ledgerModel.Account{
+		*owedAccount, *settledAccount, *account(ledgerModel.Restaurant, paid),
+	}, nil)
+	mockRepo.On("LastPayout", owed, "NGN").Return(nil, ledgerRepo.ErrNotFound)
+	mockRepo.On("LastPayout", settled, "NGN").Return(&ledgerModel.PayoutBatch{PeriodEnd: start}, nil)
+	mockRepo.On("LastPayout", paid, "NGN").Return(&ledgerModel.PayoutBatch{PeriodEnd: end}, nil)
+	mockRepo.On("FindOrCreateAccount", ledgerModel.Restaurant, owed, "NGN").Return(owedAccount, nil)
+	mockRepo.On("FindOrCreateAccount", ledgerModel.Restaurant, settled, "NGN").Return(settledAccount, nil)
+	mockRepo.On("FindOrCreateAccount", ledgerModel.Payout, uuid.Nil, "NGN").Return(clearing, nil)
+	mockRepo.On("Balance", owedAccount.Id, end).Return(moneyModel.New(-42000, "NGN"), nil)
+	mockRepo.On("Balance", settledAccount.Id, end).Return(moneyModel.Zero("NGN"), nil)
+	mockRepo.On("PersistPayout", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		entry := args.Get(1).(*ledgerModel.JournalEntry)
+		assert.Nil(t, entry.Validate())
+		assert.Equal(t, end, entry.EffectiveAt)
+		assert.Equal(t, []ledgerModel.Posting{
+			{AccountId: owedAccount.Id, Amount: moneyModel.New(42000, "NGN")},
+			{AccountId: clearing.Id, Amount: moneyModel.New(-42000, "NGN")},
+		}, entry.Postings)
+	})
+
+	l := NewLedgerService(log, mockRepo)
+	batches, err := l.GeneratePayouts(start, end)
+
+	assert.Nil(t, err)
+	assert.Len(t, batches, 1)
+	assert.Equal(t, owed, batches[0].RestaurantId)
+	assert.Equal(t, moneyModel.New(42000, "NGN"), batches[0].Amount)
+	mockRepo.AssertNumberOfCalls(t, "PersistPayout", 1)
+}
+
+func Test_ledgerService_GeneratePayoutRejectsOpenPeriod(t *testing.T) {
+	l := NewLedgerService(log, new(MockRepository))
+	_, err := l.GeneratePayout(uuid.New(), "NGN", time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	assert.NotNil(t, err)
+}
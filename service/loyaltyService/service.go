@@ -0,0 +1,298 @@
+package loyaltyService
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/loyaltyModel"
+	"rsm/entity/orderModel"
+	"rsm/pricing"
+	"rsm/repository/brandRepo"
+	"rsm/repository/loyaltyRepo"
+	"rsm/service/pricingService"
+	"time"
+)
+
+type ServiceInterface interface {
+	// SaveRule sets the earn rule of the rule's restaurant or brand.
+	SaveRule(rule *loyaltyModel.EarnRule) error
+	CreateReward(reward *loyaltyModel.Reward) error
+	UpdateReward(reward *loyaltyModel.Reward) error
+	// Rewards is the catalogue a customer of the restaurant can choose from.
+	Rewards(restaurantId uuid.UUID) ([]loyaltyModel.Reward, error)
+
+	// EarnForOrder credits the customer for a completed order. It returns nil when the order
+	// earns nothing, and the existing credit is never added again.
+	EarnForOrder(order *orderModel.Order) (*loyaltyModel.Entry, error)
+	// QuoteReward prices the cart like pricingService.Quote with the reward taken off too.
+	// Nothing is spent; call Redeem with the quote when the order is placed.
+	QuoteReward(userId, rewardId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Breakdown, error)
+	// Redeem spends the reward's points on a pending order priced by QuoteReward.
+	Redeem(order *orderModel.Order, rewardId uuid.UUID, priced *pricing.Breakdown) (*loyaltyModel.Entry, error)
+	// OrderReward returns the reward used on the order, or nil if none is, so the order can be
+	// priced with it again.
+	OrderReward(orderId uuid.UUID) (*loyaltyModel.Reward, error)
+	// Reverse follows a refund or cancellation of the order, refunded being everything
+	// refunded of it so far: the points it earned on that part are taken back, and once all of
+	// it is refunded the points spent on its reward are given back.
+	Reverse(order *orderModel.Order, refunded int64) error
+	// ExpirePoints is the expiry job: it expires every point due by at and returns how many.
+	ExpirePoints(at time.Time) (int64, error)
+
+	Balance(userId uuid.UUID) (*loyaltyModel.Balance, error)
+	History(userId uuid.UUID, limit, offset int) ([]loyaltyModel.Entry, error)
+}
+
+type loyaltyService struct {
+	log     *logrus.Logger
+	repo    loyaltyRepo.RepoInterface
+	brands  brandRepo.RepoInterface
+	pricing pricingService.ServiceInterface
+}
+
+func (l *loyaltyService) SaveRule(rule *loyaltyModel.EarnRule) error {
+	err := rule.ValidateInput()
+	if err != nil {
+		l.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	rule.Id, rule.UpdatedAt = uuid.New(), time.Now()
+	return l.repo.SaveRule(rule)
+}
+
+func (l *loyaltyService) CreateReward(reward *loyaltyModel.Reward) error {
+	err := reward.ValidateInput()
+	if err != nil {
+		l.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	reward.Id, reward.CreatedAt = uuid.New(), time.Now()
+	return l.repo.PersistReward(reward)
+}
+
+// UpdateReward keeps where the reward can be used; only what it is and costs change.
+func (l *loyaltyService) UpdateReward(reward *loyaltyModel.Reward) error {
+	existing, err := l.repo.FindReward(reward.Id)
+	if err != nil {
+		return err
+	}
+	reward.RestaurantId, reward.BrandId, reward.CreatedAt = existing.RestaurantId, existing.BrandId, existing.CreatedAt
+	err = reward.ValidateInput()
+	if err != nil {
+		l.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	return l.repo.UpdateReward(reward)
+}
+
+func (l *loyaltyService) Rewards(restaurantId uuid.UUID) ([]loyaltyModel.Reward, error) {
+	brandId, err := l.brandOf(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	return l.repo.FindRewards(restaurantId, brandId)
+}
+
+// EarnForOrder uses the restaurant's own rule, or its brand's when it has none. Guest orders
+// have no one to credit.
+func (l *loyaltyService) EarnForOrder(order *orderModel.Order) (*loyaltyModel.Entry, error) {
+	if order.Status != orderModel.Completed {
+		return nil, fmt.Errorf("only completed orders earn points, order is %v", order.Status)
+	}
+	if order.UserId == uuid.Nil {
+		return nil, nil
+	}
+	rule, err := l.rule(order.RestaurantId)
+	if err != nil || rule == nil {
+		return nil, err
+	}
+	points := rule.Earned(order.Total, order.Currency)
+	if points == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	entry := &loyaltyModel.Entry{
+		Id:           uuid.New(),
+		UserId:       order.UserId,
+		Kind:         loyaltyModel.Earn,
+		Points:       points,
+		Remaining:    points,
+		OrderId:      &order.Id,
+		RestaurantId: &order.RestaurantId,
+		ExpiresAt:    rule.ExpiresAt(now),
+		CreatedAt:    now,
+	}
+	err = l.repo.Earn(entry)
+	if errors.Is(err, loyaltyRepo.ErrAlreadyRecorded) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (l *loyaltyService) QuoteReward(userId, rewardId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Breakdown, error) {
+	reward, err := l.usableReward(rewardId, cart.RestaurantId, cart.Currency)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := l.Balance(userId)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Points < reward.Cost {
+		return nil, loyaltyModel.ErrInsufficientPoints
+	}
+	breakdown, err := l.pricing.QuoteWithReward(userId, cart, codes, reward.Promotion())
+	if err != nil {
+		return nil, err
+	}
+	if !rewarded(breakdown.Discounts, reward.Id) {
+		return nil, fmt.Errorf("reward %v takes nothing off this order", reward.Name)
+	}
+	return breakdown, nil
+}
+
+// Redeem refuses a quote the reward took nothing off, so points are never spent for no
+// discount. An order takes one reward.
+func (l *loyaltyService) Redeem(order *orderModel.Order, rewardId uuid.UUID, priced *pricing.Breakdown) (*loyaltyModel.Entry, error) {
+	if order.UserId == uuid.Nil {
+		return nil, fmt.Errorf("only signed-in customers can use rewards")
+	}
+	if order.Status != orderModel.Pending {
+		return nil, fmt.Errorf("rewards can only be used on pending orders, order is %v", order.Status)
+	}
+	reward, err := l.usableReward(rewardId, order.RestaurantId, order.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if !rewarded(priced.Discounts, reward.Id) {
+		return nil, fmt.Errorf("reward %v was not applied to the order", reward.Name)
+	}
+	entry := &loyaltyModel.Entry{
+		Id:           uuid.New(),
+		UserId:       order.UserId,
+		Kind:         loyaltyModel.Redeem,
+		Points:       -reward.Cost,
+		OrderId:      &order.Id,
+		RestaurantId: &order.RestaurantId,
+		RewardId:     &reward.Id,
+		CreatedAt:    time.Now(),
+	}
+	err = l.repo.Spend(entry)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// OrderReward still returns a reward that has since been switched off: the order already
+// paid points for it.
+func (l *loyaltyService) OrderReward(orderId uuid.UUID) (*loyaltyModel.Reward, error) {
+	entry, err := l.repo.FindRedeemed(orderId)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	reward, err := l.repo.FindReward(*entry.RewardId)
+	if err != nil {
+		return nil, err
+	}
+	reward.Active = true
+	return reward, nil
+}
+
+// Reverse does nothing for guest orders, which neither earn nor spend.
+func (l *loyaltyService) Reverse(order *orderModel.Order, refunded int64) error {
+	if order.UserId == uuid.Nil {
+		return nil
+	}
+	return l.repo.Reverse(order.Id, order.Total, refunded, time.Now())
+}
+
+func (l *loyaltyService) ExpirePoints(at time.Time) (int64, error) {
+	expired, err := l.repo.Expire(at)
+	if err != nil {
+		return 0, err
+	}
+	if expired > 0 {
+		l.log.Infof("Expired %d loyalty points due by %v", expired, at)
+	}
+	return expired, nil
+}
+
+// Balance also counts the points that will expire within loyaltyModel.ExpiringWindow.
+func (l *loyaltyService) Balance(userId uuid.UUID) (*loyaltyModel.Balance, error) {
+	now := time.Now()
+	return l.repo.Balance(userId, now, now.Add(loyaltyModel.ExpiringWindow))
+}
+
+func (l *loyaltyService) History(userId uuid.UUID, limit, offset int) ([]loyaltyModel.Entry, error) {
+	if limit <= 0 {
+		limit = loyaltyModel.DefaultLimit
+	}
+	return l.repo.FindEntries(userId, limit, offset)
+}
+
+// rule returns the rule orders at the restaurant earn under, or nil if there is none.
+func (l *loyaltyService) rule(restaurantId uuid.UUID) (*loyaltyModel.EarnRule, error) {
+	rule, err := l.repo.FindRestaurantRule(restaurantId)
+	if !errors.Is(err, loyaltyRepo.ErrRuleNotFound) {
+		return rule, err
+	}
+	brandId, err := l.brandOf(restaurantId)
+	if err != nil || brandId == nil {
+		return nil, err
+	}
+	rule, err = l.repo.FindBrandRule(*brandId)
+	if errors.Is(err, loyaltyRepo.ErrRuleNotFound) {
+		return nil, nil
+	}
+	return rule, err
+}
+
+// usableReward hides rewards that are switched off or belong elsewhere as not found.
+func (l *loyaltyService) usableReward(rewardId, restaurantId uuid.UUID, currency string) (*loyaltyModel.Reward, error) {
+	reward, err := l.repo.FindReward(rewardId)
+	if err != nil {
+		return nil, err
+	}
+	brandId, err := l.brandOf(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	if !reward.Active || !reward.AppliesAt(restaurantId, brandId) {
+		return nil, loyaltyRepo.ErrRewardNotFound
+	}
+	if reward.Currency != currency {
+		return nil, fmt.Errorf("reward %v is in %v, order is in %v", reward.Name, reward.Currency, currency)
+	}
+	return reward, nil
+}
+
+// brandOf returns the restaurant's brand, or nil if it is not in one.
+func (l *loyaltyService) brandOf(restaurantId uuid.UUID) (*uuid.UUID, error) {
+	brandId, err := l.brands.FindLocationBrand(restaurantId)
+	if errors.Is(err, brandRepo.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &brandId, nil
+}
+
+func rewarded(discounts pricing.Discounts, rewardId uuid.UUID) bool {
+	for _, applied := range discounts.Applied {
+		if applied.PromotionId == rewardId {
+			return true
+		}
+	}
+	return false
+}
+
+func NewLoyaltyService(log *logrus.Logger, repo loyaltyRepo.RepoInterface, brands brandRepo.RepoInterface, pricing pricingService.ServiceInterface) ServiceInterface {
+	return &loyaltyService{log: log, repo: repo, brands: brands, pricing: pricing}
+}
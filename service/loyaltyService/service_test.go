@@ -0,0 +1,427 @@
+package loyaltyService
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/brandModel"
+	"rsm/entity/loyaltyModel"
+	"rsm/entity/moneyModel"
+	"rsm/entity/orderModel"
+	"rsm/entity/promotionModel"
+	"rsm/entity/taxModel"
+	"rsm/pricing"
+	"rsm/repository/brandRepo"
+	"rsm/repository/loyaltyRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) SaveRule(rule *loyaltyModel.EarnRule) error {
+	args := m.Called(rule)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindRestaurantRule(restaurantId uuid.UUID) (*loyaltyModel.EarnRule, error) {
+	args := m.Called(restaurantId)
+	rule, _ := args.Get(0).(*loyaltyModel.EarnRule)
+	return rule, args.Error(1)
+}
+
+func (m *MockRepository) FindBrandRule(brandId uuid.UUID) (*loyaltyModel.EarnRule, error) {
+	args := m.Called(brandId)
+	rule, _ := args.Get(0).(*loyaltyModel.EarnRule)
+	return rule, args.Error(1)
+}
+
+func (m *MockRepository) PersistReward(reward *loyaltyModel.Reward) error {
+	args := m.Called(reward)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateReward(reward *loyaltyModel.Reward) error {
+	args := m.Called(reward)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindReward(id uuid.UUID) (*loyaltyModel.Reward, error) {
+	args := m.Called(id)
+	reward, _ := args.Get(0).(*loyaltyModel.Reward)
+	return reward, args.Error(1)
+}
+
+func (m *MockRepository) FindRewards(restaurantId uuid.UUID, brandId *uuid.UUID) ([]loyaltyModel.Reward, error) {
+	args := m.Called(restaurantId, brandId)
+	return args.Get(0).([]loyaltyModel.Reward), args.Error(1)
+}
+
+func (m *MockRepository) Earn(entry *loyaltyModel.Entry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Spend(entry *loyaltyModel.Entry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindRedeemed(orderId uuid.UUID) (*loyaltyModel.Entry, error) {
+	args := m.Called(orderId)
+	entry, _ := args.Get(0).(*loyaltyModel.Entry)
+	return entry, args.Error(1)
+}
+
+func (m *MockRepository) Reverse(orderId uuid.UUID, total, refunded int64, at time.Time) error {
+	args := m.Called(orderId, total, refunded, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Expire(at time.Time) (int64, error) {
+	args := m.Called(at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) Balance(userId uuid.UUID, at, expiringBy time.Time) (*loyaltyModel.Balance, error) {
+	args := m.Called(userId, at, expiringBy)
+	balance, _ := args.Get(0).(*loyaltyModel.Balance)
+	return balance, args.Error(1)
+}
+
+func (m *MockRepository) FindEntries(userId uuid.UUID, limit, offset int) ([]loyaltyModel.Entry, error) {
+	args := m.Called(userId, limit, offset)
+	return args.Get(0).([]loyaltyModel.Entry), args.Error(1)
+}
+
+type MockBrandRepository struct {
+	mock.Mock
+}
+
+func (m *MockBrandRepository) PersistBrand(brand *brandModel.Brand) error {
+	args := m.Called(brand)
+	return args.Error(0)
+}
+
+func (m *MockBrandRepository) FindBrand(id uuid.UUID) (*brandModel.Brand, error) {
+	args := m.Called(id)
+	brand, _ := args.Get(0).(*brandModel.Brand)
+	return brand, args.Error(1)
+}
+
+func (m *MockBrandRepository) AttachLocation(brandId, restaurantId uuid.UUID) error {
+	args := m.Called(brandId, restaurantId)
+	return args.Error(0)
+}
+
+func (m *MockBrandRepository) FindLocations(brandId uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(brandId)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockBrandRepository) FindLocationBrand(restaurantId uuid.UUID) (uuid.UUID, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockBrandRepository) SaveMember(member *brandModel.Member) error {
+	args := m.Called(member)
+	return args.Error(0)
+}
+
+func (m *MockBrandRepository) DeleteMember(brandId, userId uuid.UUID) error {
+	args := m.Called(brandId, userId)
+	return args.Error(0)
+}
+
+func (m *MockBrandRepository) FindMember(brandId, userId uuid.UUID) (*brandModel.Member, error) {
+	args := m.Called(brandId, userId)
+	member, _ := args.Get(0).(*brandModel.Member)
+	return member, args.Error(1)
+}
+
+func (m *MockBrandRepository) FindMembers(brandId uuid.UUID) ([]brandModel.Member, error) {
+	args := m.Called(brandId)
+	return args.Get(0).([]brandModel.Member), args.Error(1)
+}
+
+func (m *MockBrandRepository) PersistMasterItem(item *brandModel.MasterItem) error {
+	args := m.Called(item)
+	return args.Error(0)
+}
+
+func (m *MockBrandRepository) UpdateMasterItem(item *brandModel.MasterItem) error {
+	args := m.Called(item)
+	return args.Error(0)
+}
+
+func (m *MockBrandRepository) DeleteMasterItem(brandId, id uuid.UUID) error {
+	args := m.Called(brandId, id)
+	return args.Error(0)
+}
+
+func (m *MockBrandRepository) FindMasterItems(brandId uuid.UUID) ([]brandModel.MasterItem, error) {
+	args := m.Called(brandId)
+	return args.Get(0).([]brandModel.MasterItem), args.Error(1)
+}
+
+func (m *MockBrandRepository) SaveOverride(override *brandModel.Override) error {
+	args := m.Called(override)
+	return args.Error(0)
+}
+
+func (m *MockBrandRepository) FindOverrides(restaurantId uuid.UUID) ([]brandModel.Override, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]brandModel.Override), args.Error(1)
+}
+
+func (m *MockBrandRepository) SyncLocations(menus map[uuid.UUID][]brandModel.LocationItem) error {
+	args := m.Called(menus)
+	return args.Error(0)
+}
+
+type MockPricingService struct {
+	mock.Mock
+}
+
+func (m *MockPricingService) SetTaxRules(rules *taxModel.TaxRules) error {
+	args := m.Called(rules)
+	return args.Error(0)
+}
+
+func (m *MockPricingService) GetTaxRules(restaurantId uuid.UUID) (*taxModel.TaxRules, error) {
+	args := m.Called(restaurantId)
+	rules, _ := args.Get(0).(*taxModel.TaxRules)
+	return rules, args.Error(1)
+}
+
+func (m *MockPricingService) Quote(userId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Breakdown, error) {
+	args := m.Called(userId, cart, codes)
+	breakdown, _ := args.Get(0).(*pricing.Breakdown)
+	return breakdown, args.Error(1)
+}
+
+func (m *MockPricingService) QuoteWithReward(userId uuid.UUID, cart pricing.Cart, codes []string, reward promotionModel.Promotion) (*pricing.Breakdown, error) {
+	args := m.Called(userId, cart, codes, reward)
+	breakdown, _ := args.Get(0).(*pricing.Breakdown)
+	return breakdown, args.Error(1)
+}
+
+func Test_loyaltyService_EarnForOrder(t *testing.T) {
+	own, branded, plain, brandId := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	userId := uuid.New()
+	order := func(restaurantId uuid.UUID, status orderModel.Status, userId uuid.UUID) *orderModel.Order {
+		return &orderModel.Order{Id: uuid.New(), UserId: userId, RestaurantId: restaurantId, Status: status,
+			Currency: "NGN", Total: 45000}
+	}
+
+	repo := new(MockRepository)
+	repo.On("FindRestaurantRule", own).Return(&loyaltyModel.EarnRule{RestaurantId: &own, Currency: "NGN", Points: 1,
+		Per: 10000, ExpiryDays: 90, Active: true}, nil)
+	repo.On("FindRestaurantRule", mock.Anything).Return(nil, loyaltyRepo.ErrRuleNotFound)
+	repo.On("FindBrandRule", brandId).Return(&loyaltyModel.EarnRule{BrandId: &brandId, Currency: "NGN", Points: 2,
+		Per: 10000, Active: true}, nil)
+	repo.On("Earn", mock.Anything).Return(nil)
+	brands := new(MockBrandRepository)
+	brands.On("FindLocationBrand", branded).Return(brandId, nil)
+	brands.On("FindLocationBrand", plain).Return(uuid.Nil, brandRepo.ErrNotFound)
+
+	tests := []struct {
+		name       string
+		order      *orderModel.Order
+		wantPoints int64
+		wantExpiry bool
+		wantErr    bool
+	}{
+		{name: "restaurant rule", order: order(own, orderModel.Completed, userId), wantPoints: 4, wantExpiry: true},
+		{name: "brand rule", order: order(branded, orderModel.Completed, userId), wantPoints: 8},
+		{name: "no rule", order: order(plain, orderModel.Completed, userId)},
+		{name: "guest order", order: order(own, orderModel.Completed, uuid.Nil)},
+		{name: "not completed", order: order(own, orderModel.Ready, userId), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLoyaltyService(log, repo, brands, new(MockPricingService))
+			got, err := l.EarnForOrder(tt.order)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EarnForOrder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantPoints == 0 {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tt.wantPoints, got.Points)
+			assert.Equal(t, tt.wantPoints, got.Remaining)
+			assert.Equal(t, tt.order.Id, *got.OrderId)
+			assert.Equal(t, tt.wantExpiry, got.ExpiresAt != nil)
+		})
+	}
+}
+
+func Test_loyaltyService_EarnForOrderTwice(t *testing.T) {
+	restaurantId := uuid.New()
+	repo := new(MockRepository)
+	repo.On("FindRestaurantRule", restaurantId).Return(&loyaltyModel.EarnRule{Currency: "NGN", Points: 1,
+		Per: 100, Active: true}, nil)
+	repo.On("Earn", mock.Anything).Return(loyaltyRepo.ErrAlreadyRecorded)
+	l := NewLoyaltyService(log, repo, new(MockBrandRepository), new(MockPricingService))
+
+	got, err := l.EarnForOrder(&orderModel.Order{Id: uuid.New(), UserId: uuid.New(), RestaurantId: restaurantId,
+		Status: orderModel.Completed, Currency: "NGN", Total: 1000})
+	assert.Nil(t, err)
+	assert.Nil(t, got)
+}
+
+func Test_loyaltyService_QuoteReward(t *testing.T) {
+	userId, restaurantId, elsewhere := uuid.New(), uuid.New(), uuid.New()
+	menuId := int64(3)
+	cart := pricing.Cart{RestaurantId: restaurantId, Currency: "NGN", Lines: []pricing.Line{
+		{MenuId: 1, Quantity: 1, UnitPrice: moneyModel.New(2000, "NGN")}}}
+	discount := &loyaltyModel.Reward{Id: uuid.New(), Name: "N5 off", Cost: 300, Type: loyaltyModel.Discount,
+		Value: 500, Currency: "NGN", Active: true}
+	dear := &loyaltyModel.Reward{Id: uuid.New(), Name: "N50 off", Cost: 3000, Type: loyaltyModel.Discount,
+		Value: 5000, Currency: "NGN", Active: true}
+	freeZobo := &loyaltyModel.Reward{Id: uuid.New(), Name: "Free zobo", Cost: 100, Type: loyaltyModel.FreeItem,
+		FreeMenuId: &menuId, Currency: "NGN", Active: true}
+	otherRestaurant := &loyaltyModel.Reward{Id: uuid.New(), Name: "Theirs", Cost: 100, RestaurantId: &elsewhere,
+		Type: loyaltyModel.Discount, Value: 500, Currency: "NGN", Active: true}
+	dollars := &loyaltyModel.Reward{Id: uuid.New(), Name: "$1 off", Cost: 100, Type: loyaltyModel.Discount,
+		Value: 100, Currency: "USD", Active: true}
+
+	repo := new(MockRepository)
+	for _, r := range []*loyaltyModel.Reward{discount, dear, freeZobo, otherRestaurant, dollars} {
+		repo.On("FindReward", r.Id).Return(r, nil)
+	}
+	repo.On("Balance", userId, mock.Anything, mock.Anything).Return(&loyaltyModel.Balance{UserId: userId, Points: 1000}, nil)
+	brands := new(MockBrandRepository)
+	brands.On("FindLocationBrand", restaurantId).Return(uuid.Nil, brandRepo.ErrNotFound)
+	prices := new(MockPricingService)
+	prices.On("QuoteWithReward", userId, cart, []string(nil), discount.Promotion()).Return(&pricing.Breakdown{
+		Discounts: pricing.Discounts{Applied: []pricing.AppliedPromotion{{PromotionId: discount.Id}}},
+		Total:     moneyModel.New(1500, "NGN"),
+	}, nil)
+	prices.On("QuoteWithReward", userId, cart, []string(nil), freeZobo.Promotion()).Return(&pricing.Breakdown{
+		Discounts: pricing.Discounts{Applied: []pricing.AppliedPromotion{}}, Total: moneyModel.New(2000, "NGN"),
+	}, nil)
+
+	tests := []struct {
+		name     string
+		rewardId uuid.UUID
+		want     error
+		wantErr  bool
+	}{
+		{name: "discount", rewardId: discount.Id},
+		{name: "cannot afford", rewardId: dear.Id, want: loyaltyModel.ErrInsufficientPoints, wantErr: true},
+		{name: "item not in cart", rewardId: freeZobo.Id, wantErr: true},
+		{name: "another restaurant's", rewardId: otherRestaurant.Id, want: loyaltyRepo.ErrRewardNotFound, wantErr: true},
+		{name: "other currency", rewardId: dollars.Id, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLoyaltyService(log, repo, brands, prices)
+			got, err := l.QuoteReward(userId, tt.rewardId, cart, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("QuoteReward() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.want != nil {
+				assert.ErrorIs(t, err, tt.want)
+			}
+			if !tt.wantErr {
+				assert.Equal(t, moneyModel.New(1500, "NGN"), got.Total)
+			}
+		})
+	}
+}
+
+func Test_loyaltyService_Redeem(t *testing.T) {
+	restaurantId := uuid.New()
+	reward := &loyaltyModel.Reward{Id: uuid.New(), Name: "N5 off", Cost: 300, Type: loyaltyModel.Discount,
+		Value: 500, Currency: "NGN", Active: true}
+	order := &orderModel.Order{Id: uuid.New(), UserId: uuid.New(), RestaurantId: restaurantId,
+		Status: orderModel.Pending, Currency: "NGN"}
+	priced := &pricing.Breakdown{Discounts: pricing.Discounts{Applied: []pricing.AppliedPromotion{
+		{PromotionId: reward.Id}}}}
+
+	repo := new(MockRepository)
+	repo.On("FindReward", reward.Id).Return(reward, nil)
+	repo.On("Spend", mock.Anything).Return(nil).Once()
+	repo.On("Spend", mock.Anything).Return(loyaltyModel.ErrInsufficientPoints)
+	brands := new(MockBrandRepository)
+	brands.On("FindLocationBrand", restaurantId).Return(uuid.Nil, brandRepo.ErrNotFound)
+	l := NewLoyaltyService(log, repo, brands, new(MockPricingService))
+
+	entry, err := l.Redeem(order, reward.Id, priced)
+	assert.Nil(t, err)
+	assert.Equal(t, loyaltyModel.Redeem, entry.Kind)
+	assert.Equal(t, int64(-300), entry.Points)
+	assert.Equal(t, reward.Id, *entry.RewardId)
+
+	_, err = l.Redeem(order, reward.Id, priced)
+	assert.ErrorIs(t, err, loyaltyModel.ErrInsufficientPoints)
+
+	guest := *order
+	guest.UserId = uuid.Nil
+	_, err = l.Redeem(&guest, reward.Id, priced)
+	assert.NotNil(t, err)
+
+	accepted := *order
+	accepted.Status = orderModel.Accepted
+	_, err = l.Redeem(&accepted, reward.Id, priced)
+	assert.NotNil(t, err)
+
+	// A quote the reward took nothing off spends nothing.
+	_, err = l.Redeem(order, reward.Id, &pricing.Breakdown{})
+	assert.NotNil(t, err)
+	repo.AssertNumberOfCalls(t, "Spend", 2)
+}
+
+func Test_loyaltyService_OrderReward(t *testing.T) {
+	orderId, rewardId := uuid.New(), uuid.New()
+	repo := new(MockRepository)
+	repo.On("FindRedeemed", orderId).Return(&loyaltyModel.Entry{RewardId: &rewardId}, nil)
+	repo.On("FindReward", rewardId).Return(&loyaltyModel.Reward{Id: rewardId, Active: false}, nil)
+	none := uuid.New()
+	repo.On("FindRedeemed", none).Return(nil, nil)
+	l := NewLoyaltyService(log, repo, new(MockBrandRepository), new(MockPricingService))
+
+	// The order paid for the reward, so it keeps it after the reward is switched off.
+	reward, err := l.OrderReward(orderId)
+	assert.Nil(t, err)
+	assert.True(t, reward.Active)
+
+	reward, err = l.OrderReward(none)
+	assert.Nil(t, err)
+	assert.Nil(t, reward)
+}
+
+func Test_loyaltyService_Reverse(t *testing.T) {
+	order := &orderModel.Order{Id: uuid.New(), UserId: uuid.New(), Total: 40000}
+	repo := new(MockRepository)
+	repo.On("Reverse", order.Id, int64(40000), int64(10000), mock.Anything).Return(nil)
+	l := NewLoyaltyService(log, repo, new(MockBrandRepository), new(MockPricingService))
+
+	assert.Nil(t, l.Reverse(order, 10000))
+
+	guest := *order
+	guest.UserId = uuid.Nil
+	assert.Nil(t, l.Reverse(&guest, 40000))
+	repo.AssertNumberOfCalls(t, "Reverse", 1)
+}
+
+func Test_loyaltyService_History(t *testing.T) {
+	userId := uuid.New()
+	repo := new(MockRepository)
+	repo.On("FindEntries", userId, loyaltyModel.DefaultLimit, 0).Return([]loyaltyModel.Entry{}, nil)
+	l := NewLoyaltyService(log, repo, new(MockBrandRepository), new(MockPricingService))
+
+	_, err := l.History(userId, 0, 0)
+	assert.Nil(t, err)
+	repo.AssertExpectations(t)
+}
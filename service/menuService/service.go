@@ -0,0 +1,344 @@
+package menuService
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"io"
+	"rsm/entity/menuModel"
+	"rsm/repository/menuRepo"
+	"rsm/repository/restaurantRepo"
+	"sort"
+	"time"
+)
+
+type ServiceInterface interface {
+	ListMenu(query menuModel.MenuQuery) ([]menuModel.MenuItem, error)
+	GetItem(id int64) (*menuModel.MenuItem, error)
+	SetDietaryInfo(restaurantId uuid.UUID, menuId int64, info menuModel.DietaryInfo) (*menuModel.MenuItem, error)
+
+	CreateMenu(menu *menuModel.NamedMenu) error
+	UpdateMenu(menu *menuModel.NamedMenu) error
+	ListMenus(restaurantId uuid.UUID) ([]menuModel.NamedMenu, error)
+	SaveDraft(restaurantId, namedMenuId uuid.UUID, request menuModel.DraftRequest) (*menuModel.Version, error)
+	Publish(restaurantId, versionId uuid.UUID, effectiveFrom time.Time) (*menuModel.Version, error)
+	ListVersions(restaurantId, namedMenuId uuid.UUID) ([]menuModel.Version, error)
+	DiffVersions(restaurantId, fromId, toId uuid.UUID) (*menuModel.VersionDiff, error)
+	ActiveMenu(restaurantId uuid.UUID, at time.Time) (*menuModel.ActiveMenu, error)
+
+	Import(restaurantId uuid.UUID, format menuModel.Format, data []byte, dryRun bool) (*menuModel.ImportReport, error)
+	Export(restaurantId uuid.UUID, format menuModel.Format, w io.Writer) error
+}
+
+type menuService struct {
+	log         *logrus.Logger
+	repo        menuRepo.RepoInterface
+	restaurants restaurantRepo.RepoInterface
+}
+
+// ListMenu returns the restaurant's items on sale, without those sold out or containing any
+// excluded allergen.
+func (m *menuService) ListMenu(query menuModel.MenuQuery) ([]menuModel.MenuItem, error) {
+	err := query.ValidateInput()
+	if err != nil {
+		m.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	return m.repo.FindByRestaurant(query)
+}
+
+func (m *menuService) GetItem(id int64) (*menuModel.MenuItem, error) {
+	return m.repo.FindById(id)
+}
+
+// SetDietaryInfo replaces the item's allergens, dietary tags, nutrition facts and servings.
+func (m *menuService) SetDietaryInfo(restaurantId uuid.UUID, menuId int64, info menuModel.DietaryInfo) (*menuModel.MenuItem, error) {
+	err := info.ValidateInput()
+	if err != nil {
+		m.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	item, err := m.repo.FindById(menuId)
+	if err != nil {
+		return nil, err
+	}
+	if item.RestaurantId != restaurantId {
+		return nil, menuRepo.ErrNotFound
+	}
+	err = m.repo.UpdateDietaryInfo(menuId, info)
+	if err != nil {
+		return nil, err
+	}
+	item.Allergens, item.Dietary, item.Nutrition = info.Allergens, info.Dietary, info.Nutrition
+	item.Servings = info.Servings
+	return item, nil
+}
+
+func (m *menuService) CreateMenu(menu *menuModel.NamedMenu) error {
+	err := menu.ValidateInput()
+	if err != nil {
+		m.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	menu.Id = uuid.New()
+	err = m.checkOverlap(menu)
+	if err != nil {
+		return err
+	}
+	menu.CreatedAt = time.Now()
+	menu.UpdatedAt = menu.CreatedAt
+	return m.repo.PersistMenu(menu)
+}
+
+// UpdateMenu renames or reschedules a named menu. Its versions are left as they are.
+func (m *menuService) UpdateMenu(menu *menuModel.NamedMenu) error {
+	err := menu.ValidateInput()
+	if err != nil {
+		m.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	err = m.checkOverlap(menu)
+	if err != nil {
+		return err
+	}
+	menu.UpdatedAt = time.Now()
+	return m.repo.UpdateMenu(menu)
+}
+
+// checkOverlap keeps a restaurant's named menus apart, so at any time at most one of them is
+// served and an item's price is never ambiguous.
+func (m *menuService) checkOverlap(menu *menuModel.NamedMenu) error {
+	menus, err := m.repo.FindMenus(menu.RestaurantId)
+	if err != nil {
+		return err
+	}
+	for i := range menus {
+		if menus[i].Id != menu.Id && menu.Overlaps(&menus[i]) {
+			return fmt.Errorf("%w: %v is served at the same time", menuModel.ErrOverlappingMenus, menus[i].Name)
+		}
+	}
+	return nil
+}
+
+func (m *menuService) ListMenus(restaurantId uuid.UUID) ([]menuModel.NamedMenu, error) {
+	return m.repo.FindMenus(restaurantId)
+}
+
+// SaveDraft sets the items of the menu's draft, starting a new draft if there is none. Items
+// are copied from the restaurant's Menu rows so later edits there do not change the version.
+func (m *menuService) SaveDraft(restaurantId, namedMenuId uuid.UUID, request menuModel.DraftRequest) (*menuModel.Version, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		m.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	_, err = m.ownMenu(restaurantId, namedMenuId)
+	if err != nil {
+		return nil, err
+	}
+	items, err := m.repo.FindByRestaurant(menuModel.MenuQuery{RestaurantId: restaurantId})
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int64]menuModel.MenuItem, len(items))
+	for _, item := range items {
+		byId[item.Id] = item
+	}
+
+	version := &menuModel.Version{Id: uuid.New(), NamedMenuId: namedMenuId, Status: menuModel.Draft,
+		CreatedAt: time.Now()}
+	versions, err := m.repo.FindVersions(namedMenuId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		if versions[i].Status == menuModel.Draft {
+			version = &versions[i]
+			break
+		}
+	}
+
+	version.Items = make([]menuModel.VersionItem, 0, len(request.Items))
+	for _, d := range request.Items {
+		item, ok := byId[d.MenuId]
+		if !ok {
+			return nil, fmt.Errorf("menu item %d: %w", d.MenuId, menuRepo.ErrNotFound)
+		}
+		price := item.Price
+		if d.Price != "" {
+			price = d.Price
+		}
+		version.Items = append(version.Items, menuModel.VersionItem{MenuId: item.Id, Item: item.Item,
+			ItemType: item.ItemType, Price: price})
+	}
+	err = m.repo.SaveDraft(version)
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// Publish makes a draft the version served from effectiveFrom, or from now if it is zero.
+// Versions cannot be published into the past, so what was served before stays on record.
+func (m *menuService) Publish(restaurantId, versionId uuid.UUID, effectiveFrom time.Time) (*menuModel.Version, error) {
+	version, err := m.ownVersion(restaurantId, versionId)
+	if err != nil {
+		return nil, err
+	}
+	if version.Status != menuModel.Draft {
+		return nil, menuRepo.ErrVersionPublished
+	}
+	now := time.Now()
+	if effectiveFrom.IsZero() {
+		effectiveFrom = now
+	}
+	if effectiveFrom.Before(now) {
+		return nil, fmt.Errorf("effective date %v is in the past", effectiveFrom.Format(time.RFC3339))
+	}
+	err = m.repo.Publish(versionId, effectiveFrom, now)
+	if err != nil {
+		return nil, err
+	}
+	version.Status, version.EffectiveFrom, version.PublishedAt = menuModel.Published, &effectiveFrom, &now
+	return version, nil
+}
+
+func (m *menuService) ListVersions(restaurantId, namedMenuId uuid.UUID) ([]menuModel.Version, error) {
+	_, err := m.ownMenu(restaurantId, namedMenuId)
+	if err != nil {
+		return nil, err
+	}
+	return m.repo.FindVersions(namedMenuId)
+}
+
+func (m *menuService) DiffVersions(restaurantId, fromId, toId uuid.UUID) (*menuModel.VersionDiff, error) {
+	from, err := m.ownVersion(restaurantId, fromId)
+	if err != nil {
+		return nil, err
+	}
+	to, err := m.ownVersion(restaurantId, toId)
+	if err != nil {
+		return nil, err
+	}
+	diff := menuModel.Diff(*from, *to)
+	return &diff, nil
+}
+
+// ActiveMenu returns the named menus served at the given time, each with its version in
+// effect then. Menu hours and dates are read on the restaurant's wall clock. Menus with no
+// version in effect yet are left out.
+func (m *menuService) ActiveMenu(restaurantId uuid.UUID, at time.Time) (*menuModel.ActiveMenu, error) {
+	menus, err := m.repo.FindMenus(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	active := &menuModel.ActiveMenu{RestaurantId: restaurantId, At: at, Scheduled: len(menus) > 0,
+		Sections: []menuModel.Section{}}
+	if !active.Scheduled {
+		return active, nil
+	}
+	restaurant, err := m.restaurants.FindById(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := m.repo.FindEffective(restaurantId, at)
+	if err != nil {
+		return nil, err
+	}
+	local := restaurant.LocalTime(at)
+	effective := make(map[uuid.UUID]menuModel.Version, len(versions))
+	for _, v := range versions {
+		effective[v.NamedMenuId] = v
+	}
+	for _, menu := range menus {
+		version, ok := effective[menu.Id]
+		if ok && menu.ServedAt(local) {
+			active.Sections = append(active.Sections, menuModel.Section{Menu: menu, Version: version})
+		}
+	}
+	return active, nil
+}
+
+// Import reads a CSV or JSON menu and upserts its items by code. Every row is checked first and
+// all problems are listed in the report; if there are any, or on a dry run, nothing is saved.
+func (m *menuService) Import(restaurantId uuid.UUID, format menuModel.Format, data []byte, dryRun bool) (*menuModel.ImportReport, error) {
+	rows, errs := menuModel.Parse(format, data)
+	errs = append(errs, menuModel.ValidateRows(rows)...)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	report := &menuModel.ImportReport{DryRun: dryRun, Rows: len(rows), Errors: errs}
+	if len(errs) > 0 {
+		return report, nil
+	}
+
+	transfer := make([]menuModel.TransferRow, 0, len(rows))
+	for _, r := range rows {
+		transfer = append(transfer, r.TransferRow)
+	}
+	if !dryRun {
+		var err error
+		report.Created, report.Updated, err = m.repo.Import(restaurantId, transfer, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return report, nil
+	}
+
+	items, err := m.repo.FindAll(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(items))
+	for _, item := range items {
+		existing[item.Code] = true
+	}
+	for _, r := range transfer {
+		if existing[r.Code] {
+			report.Updated++
+		} else {
+			report.Created++
+		}
+	}
+	return report, nil
+}
+
+// Export writes the restaurant's whole menu in a format Import reads back. Items added by hand
+// have no code and need one before the file can be imported again.
+func (m *menuService) Export(restaurantId uuid.UUID, format menuModel.Format, w io.Writer) error {
+	items, err := m.repo.FindAll(restaurantId)
+	if err != nil {
+		return err
+	}
+	return menuModel.Write(format, w, menuModel.Rows(items))
+}
+
+// ownMenu hides other restaurants' menus as not found.
+func (m *menuService) ownMenu(restaurantId, id uuid.UUID) (*menuModel.NamedMenu, error) {
+	menu, err := m.repo.FindMenu(id)
+	if err != nil {
+		return nil, err
+	}
+	if menu.RestaurantId != restaurantId {
+		return nil, menuRepo.ErrMenuNotFound
+	}
+	return menu, nil
+}
+
+func (m *menuService) ownVersion(restaurantId, id uuid.UUID) (*menuModel.Version, error) {
+	version, err := m.repo.FindVersion(id)
+	if err != nil {
+		return nil, err
+	}
+	_, err = m.ownMenu(restaurantId, version.NamedMenuId)
+	if errors.Is(err, menuRepo.ErrMenuNotFound) {
+		return nil, menuRepo.ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+func NewMenuService(log *logrus.Logger, repo menuRepo.RepoInterface, restaurants restaurantRepo.RepoInterface) ServiceInterface {
+	return &menuService{log: log, repo: repo, restaurants: restaurants}
+}
@@ -0,0 +1,412 @@
+package menuService
+
+import (
+	"bytes"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/menuModel"
+	"rsm/entity/restaurantModel"
+	"rsm/repository/menuRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) FindById(id int64) (*menuModel.MenuItem, error) {
+	args := m.Called(id)
+	item, _ := args.Get(0).(*menuModel.MenuItem)
+	return item, args.Error(1)
+}
+
+func (m *MockRepository) FindByRestaurant(query menuModel.MenuQuery) ([]menuModel.MenuItem, error) {
+	args := m.Called(query)
+	return args.Get(0).([]menuModel.MenuItem), args.Error(1)
+}
+
+func (m *MockRepository) FindAll(restaurantId uuid.UUID) ([]menuModel.MenuItem, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]menuModel.MenuItem), args.Error(1)
+}
+
+func (m *MockRepository) UpdateImage(id int64, previous, ref string) error {
+	args := m.Called(id, previous, ref)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateDietaryInfo(id int64, info menuModel.DietaryInfo) error {
+	args := m.Called(id, info)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Import(restaurantId uuid.UUID, rows []menuModel.TransferRow, at time.Time) (int, int, error) {
+	args := m.Called(restaurantId, rows, at)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockRepository) PersistMenu(menu *menuModel.NamedMenu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateMenu(menu *menuModel.NamedMenu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindMenu(id uuid.UUID) (*menuModel.NamedMenu, error) {
+	args := m.Called(id)
+	menu, _ := args.Get(0).(*menuModel.NamedMenu)
+	return menu, args.Error(1)
+}
+
+func (m *MockRepository) FindMenus(restaurantId uuid.UUID) ([]menuModel.NamedMenu, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]menuModel.NamedMenu), args.Error(1)
+}
+
+func (m *MockRepository) SaveDraft(version *menuModel.Version) error {
+	args := m.Called(version)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Publish(id uuid.UUID, effectiveFrom, publishedAt time.Time) error {
+	args := m.Called(id, effectiveFrom, publishedAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindVersion(id uuid.UUID) (*menuModel.Version, error) {
+	args := m.Called(id)
+	version, _ := args.Get(0).(*menuModel.Version)
+	return version, args.Error(1)
+}
+
+func (m *MockRepository) FindVersions(namedMenuId uuid.UUID) ([]menuModel.Version, error) {
+	args := m.Called(namedMenuId)
+	return args.Get(0).([]menuModel.Version), args.Error(1)
+}
+
+func (m *MockRepository) FindEffective(restaurantId uuid.UUID, at time.Time) ([]menuModel.Version, error) {
+	args := m.Called(restaurantId, at)
+	return args.Get(0).([]menuModel.Version), args.Error(1)
+}
+
+type MockRestaurantRepository struct {
+	mock.Mock
+}
+
+func (m *MockRestaurantRepository) Persist(restaurant *restaurantModel.RestaurantModel) (*restaurantModel.RestaurantModel, error) {
+	args := m.Called(restaurant)
+	return args.Get(0).(*restaurantModel.RestaurantModel), args.Error(1)
+}
+
+func (m *MockRestaurantRepository) FindById(id uuid.UUID) (*restaurantModel.RestaurantModel, error) {
+	args := m.Called(id)
+	restaurant, _ := args.Get(0).(*restaurantModel.RestaurantModel)
+	return restaurant, args.Error(1)
+}
+
+func (m *MockRestaurantRepository) UpdateImage(id uuid.UUID, previous, ref string) error {
+	args := m.Called(id, previous, ref)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) UpdateCoordinates(id uuid.UUID, latitude, longitude float64) error {
+	args := m.Called(id, latitude, longitude)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) UpdateTimezone(id uuid.UUID, timezone string) error {
+	args := m.Called(id, timezone)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) ReplaceOpeningHours(id uuid.UUID, hours []restaurantModel.OpeningHours) error {
+	args := m.Called(id, hours)
+	return args.Error(0)
+}
+
+func (m *MockRestaurantRepository) FindNearby(query restaurantModel.NearbyQuery) ([]restaurantModel.NearbyRestaurant, error) {
+	args := m.Called(query)
+	return args.Get(0).([]restaurantModel.NearbyRestaurant), args.Error(1)
+}
+
+func Test_menuService_ListMenu(t *testing.T) {
+	restaurantId := uuid.New()
+	query := menuModel.MenuQuery{RestaurantId: restaurantId,
+		ExcludeAllergens: []menuModel.Allergen{menuModel.Peanuts, menuModel.Nuts},
+		Dietary:          []menuModel.DietaryTag{menuModel.Halal}}
+	found := []menuModel.MenuItem{{Id: 1, RestaurantId: restaurantId, Item: "Suya",
+		Dietary: []menuModel.DietaryTag{menuModel.Halal}}}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindByRestaurant", query).Return(found, nil)
+	m := NewMenuService(log, mockRepo, new(MockRestaurantRepository))
+
+	got, err := m.ListMenu(query)
+	assert.Nil(t, err)
+	assert.Equal(t, found, got)
+
+	_, err = m.ListMenu(menuModel.MenuQuery{RestaurantId: restaurantId,
+		ExcludeAllergens: []menuModel.Allergen{"chocolate"}})
+	assert.NotNil(t, err)
+	mockRepo.AssertNumberOfCalls(t, "FindByRestaurant", 1)
+}
+
+func Test_menuService_SetDietaryInfo(t *testing.T) {
+	restaurantId := uuid.New()
+	servings := 2
+	info := menuModel.DietaryInfo{Allergens: []menuModel.Allergen{menuModel.Peanuts},
+		Dietary: []menuModel.DietaryTag{menuModel.Halal}, Nutrition: &menuModel.Nutrition{EnergyKcal: 420},
+		Servings: &servings}
+
+	tests := []struct {
+		name         string
+		restaurantId uuid.UUID
+		info         menuModel.DietaryInfo
+		wantErr      bool
+	}{
+		{name: "own item", restaurantId: restaurantId, info: info},
+		{name: "another restaurant's item", restaurantId: uuid.New(), info: info, wantErr: true},
+		{name: "contradicting tags", restaurantId: restaurantId, info: menuModel.DietaryInfo{
+			Allergens: []menuModel.Allergen{menuModel.Eggs}, Dietary: []menuModel.DietaryTag{menuModel.Vegan}},
+			wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("FindById", int64(1)).Return(&menuModel.MenuItem{Id: 1, RestaurantId: restaurantId,
+				Item: "Suya"}, nil)
+			mockRepo.On("UpdateDietaryInfo", int64(1), tt.info).Return(nil)
+			m := NewMenuService(log, mockRepo, new(MockRestaurantRepository))
+
+			got, err := m.SetDietaryInfo(tt.restaurantId, 1, tt.info)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetDietaryInfo() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				mockRepo.AssertNotCalled(t, "UpdateDietaryInfo", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Equal(t, tt.info.Allergens, got.Allergens)
+			assert.Equal(t, 420, got.Nutrition.EnergyKcal)
+			assert.Equal(t, 840, got.PerItem().EnergyKcal)
+		})
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindById", int64(2)).Return(nil, menuRepo.ErrNotFound)
+	_, err := NewMenuService(log, mockRepo, new(MockRestaurantRepository)).SetDietaryInfo(restaurantId, 2, info)
+	assert.ErrorIs(t, err, menuRepo.ErrNotFound)
+}
+
+func Test_menuService_CreateMenu(t *testing.T) {
+	restaurantId := uuid.New()
+	breakfast := menuModel.NamedMenu{Id: uuid.New(), RestaurantId: restaurantId, Name: "Breakfast",
+		Hours: []restaurantModel.OpeningHours{{DayOfWeek: time.Monday, OpensAt: 7 * 60, ClosesAt: 11 * 60}}}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindMenus", restaurantId).Return([]menuModel.NamedMenu{breakfast}, nil)
+	mockRepo.On("PersistMenu", mock.Anything).Return(nil)
+	mockRepo.On("UpdateMenu", mock.Anything).Return(nil)
+	m := NewMenuService(log, mockRepo, new(MockRestaurantRepository))
+
+	lunch := &menuModel.NamedMenu{RestaurantId: restaurantId, Name: "Lunch",
+		Hours: []restaurantModel.OpeningHours{{DayOfWeek: time.Monday, OpensAt: 11 * 60, ClosesAt: 15 * 60}}}
+	assert.Nil(t, m.CreateMenu(lunch))
+
+	brunch := &menuModel.NamedMenu{RestaurantId: restaurantId, Name: "Brunch",
+		Hours: []restaurantModel.OpeningHours{{DayOfWeek: time.Monday, OpensAt: 10 * 60, ClosesAt: 13 * 60}}}
+	assert.ErrorIs(t, m.CreateMenu(brunch), menuModel.ErrOverlappingMenus)
+	mockRepo.AssertNumberOfCalls(t, "PersistMenu", 1)
+
+	// A menu does not overlap itself when it is rescheduled.
+	breakfast.Hours[0].ClosesAt = 10 * 60
+	assert.Nil(t, m.UpdateMenu(&breakfast))
+}
+
+func Test_menuService_SaveDraft(t *testing.T) {
+	restaurantId, menuId := uuid.New(), uuid.New()
+	lunch := &menuModel.NamedMenu{Id: menuId, RestaurantId: restaurantId, Name: "Lunch"}
+	items := []menuModel.MenuItem{
+		{Id: 1, RestaurantId: restaurantId, Item: "Jollof", ItemType: "main", Price: "2500"},
+		{Id: 2, RestaurantId: restaurantId, Item: "Zobo", ItemType: "drink", Price: "600"},
+	}
+	published := menuModel.Version{Id: uuid.New(), NamedMenuId: menuId, Number: 1, Status: menuModel.Published}
+	draft := menuModel.Version{Id: uuid.New(), NamedMenuId: menuId, Number: 2, Status: menuModel.Draft}
+
+	tests := []struct {
+		name     string
+		versions []menuModel.Version
+		request  menuModel.DraftRequest
+		want     []menuModel.VersionItem
+		wantId   *uuid.UUID
+		wantErr  bool
+	}{
+		{name: "new draft", versions: []menuModel.Version{published},
+			request: menuModel.DraftRequest{Items: []menuModel.DraftItem{{MenuId: 1, Price: "2800"}, {MenuId: 2}}},
+			want: []menuModel.VersionItem{{MenuId: 1, Item: "Jollof", ItemType: "main", Price: "2800"},
+				{MenuId: 2, Item: "Zobo", ItemType: "drink", Price: "600"}}},
+		{name: "rewrites the open draft", versions: []menuModel.Version{published, draft},
+			request: menuModel.DraftRequest{Items: []menuModel.DraftItem{{MenuId: 2}}},
+			want:    []menuModel.VersionItem{{MenuId: 2, Item: "Zobo", ItemType: "drink", Price: "600"}},
+			wantId:  &draft.Id},
+		{name: "another restaurant's item", versions: []menuModel.Version{published},
+			request: menuModel.DraftRequest{Items: []menuModel.DraftItem{{MenuId: 9}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("FindMenu", menuId).Return(lunch, nil)
+			mockRepo.On("FindByRestaurant", menuModel.MenuQuery{RestaurantId: restaurantId}).Return(items, nil)
+			mockRepo.On("FindVersions", menuId).Return(tt.versions, nil)
+			mockRepo.On("SaveDraft", mock.Anything).Return(nil)
+			m := NewMenuService(log, mockRepo, new(MockRestaurantRepository))
+
+			got, err := m.SaveDraft(restaurantId, menuId, tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SaveDraft() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				mockRepo.AssertNotCalled(t, "SaveDraft", mock.Anything)
+				return
+			}
+			assert.Equal(t, menuModel.Draft, got.Status)
+			assert.Equal(t, tt.want, got.Items)
+			if tt.wantId != nil {
+				assert.Equal(t, *tt.wantId, got.Id)
+			}
+		})
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindMenu", menuId).Return(lunch, nil)
+	_, err := NewMenuService(log, mockRepo, new(MockRestaurantRepository)).SaveDraft(uuid.New(), menuId,
+		menuModel.DraftRequest{Items: []menuModel.DraftItem{{MenuId: 1}}})
+	assert.ErrorIs(t, err, menuRepo.ErrMenuNotFound)
+}
+
+func Test_menuService_Publish(t *testing.T) {
+	restaurantId, menuId := uuid.New(), uuid.New()
+	draft := menuModel.Version{Id: uuid.New(), NamedMenuId: menuId, Number: 2, Status: menuModel.Draft}
+	published := menuModel.Version{Id: uuid.New(), NamedMenuId: menuId, Number: 1, Status: menuModel.Published}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindMenu", menuId).Return(&menuModel.NamedMenu{Id: menuId, RestaurantId: restaurantId}, nil)
+	mockRepo.On("FindVersion", draft.Id).Return(&draft, nil)
+	mockRepo.On("FindVersion", published.Id).Return(&published, nil)
+	mockRepo.On("Publish", draft.Id, mock.Anything, mock.Anything).Return(nil)
+	m := NewMenuService(log, mockRepo, new(MockRestaurantRepository))
+
+	_, err := m.Publish(restaurantId, draft.Id, time.Now().Add(-time.Hour))
+	assert.NotNil(t, err)
+	_, err = m.Publish(restaurantId, published.Id, time.Time{})
+	assert.ErrorIs(t, err, menuRepo.ErrVersionPublished)
+	_, err = m.Publish(uuid.New(), draft.Id, time.Time{})
+	assert.ErrorIs(t, err, menuRepo.ErrVersionNotFound)
+	mockRepo.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything)
+
+	monday := time.Now().Add(72 * time.Hour)
+	got, err := m.Publish(restaurantId, draft.Id, monday)
+	assert.Nil(t, err)
+	assert.Equal(t, menuModel.Published, got.Status)
+	assert.Equal(t, monday, *got.EffectiveFrom)
+	mockRepo.AssertCalled(t, "Publish", draft.Id, monday, mock.Anything)
+}
+
+func Test_menuService_ActiveMenu(t *testing.T) {
+	restaurantId, unscheduled := uuid.New(), uuid.New()
+	breakfast := menuModel.NamedMenu{Id: uuid.New(), RestaurantId: restaurantId, Name: "Breakfast",
+		Hours: []restaurantModel.OpeningHours{{DayOfWeek: time.Monday, OpensAt: 7 * 60, ClosesAt: 11 * 60}}}
+	dinner := menuModel.NamedMenu{Id: uuid.New(), RestaurantId: restaurantId, Name: "Dinner",
+		Hours: []restaurantModel.OpeningHours{{DayOfWeek: time.Monday, OpensAt: 17 * 60, ClosesAt: 22 * 60}}}
+	drinks := menuModel.NamedMenu{Id: uuid.New(), RestaurantId: restaurantId, Name: "Drinks"}
+	breakfastV1 := menuModel.Version{Id: uuid.New(), NamedMenuId: breakfast.Id, Number: 1,
+		Status: menuModel.Published}
+	dinnerV3 := menuModel.Version{Id: uuid.New(), NamedMenuId: dinner.Id, Number: 3, Status: menuModel.Published}
+	// Lagos is an hour ahead of UTC, so 06:30 UTC is already breakfast time there.
+	morning := time.Date(2022, 7, 4, 6, 30, 0, 0, time.UTC)
+	evening := time.Date(2022, 7, 4, 18, 0, 0, 0, time.UTC)
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindMenus", restaurantId).Return([]menuModel.NamedMenu{breakfast, dinner, drinks}, nil)
+	mockRepo.On("FindMenus", unscheduled).Return([]menuModel.NamedMenu{}, nil)
+	mockRepo.On("FindEffective", restaurantId, mock.Anything).
+		Return([]menuModel.Version{breakfastV1, dinnerV3}, nil)
+	mockRestaurants := new(MockRestaurantRepository)
+	mockRestaurants.On("FindById", restaurantId).Return(&restaurantModel.RestaurantModel{Id: restaurantId,
+		Timezone: "Africa/Lagos"}, nil)
+	m := NewMenuService(log, mockRepo, mockRestaurants)
+
+	got, err := m.ActiveMenu(restaurantId, morning)
+	assert.Nil(t, err)
+	assert.True(t, got.Scheduled)
+	assert.Equal(t, []menuModel.Section{{Menu: breakfast, Version: breakfastV1}}, got.Sections)
+
+	got, err = m.ActiveMenu(restaurantId, evening)
+	assert.Nil(t, err)
+	assert.Equal(t, []menuModel.Section{{Menu: dinner, Version: dinnerV3}}, got.Sections)
+
+	got, err = m.ActiveMenu(unscheduled, morning)
+	assert.Nil(t, err)
+	assert.False(t, got.Scheduled)
+	mockRepo.AssertNotCalled(t, "FindEffective", unscheduled, mock.Anything)
+}
+
+func Test_menuService_Import(t *testing.T) {
+	restaurantId := uuid.New()
+	existing := []menuModel.MenuItem{{Id: 1, RestaurantId: restaurantId, Code: "J1", Item: "Jollof"}}
+	valid := []byte("code,item,item_type,price\nJ1,Jollof rice,main,25\nZ3,Zobo,drink,6.50\n")
+	invalid := []byte("code,item,item_type,price,dietary\nJ1,Jollof rice,main,25,paleo\nJ1,Zobo,drink,6.50,\n")
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindAll", restaurantId).Return(existing, nil)
+	mockRepo.On("Import", restaurantId, mock.Anything, mock.Anything).Return(1, 1, nil)
+	m := NewMenuService(log, mockRepo, new(MockRestaurantRepository))
+
+	report, err := m.Import(restaurantId, menuModel.CSV, valid, true)
+	assert.Nil(t, err)
+	assert.Equal(t, &menuModel.ImportReport{DryRun: true, Rows: 2, Created: 1, Updated: 1,
+		Errors: []menuModel.RowError{}}, report)
+	mockRepo.AssertNotCalled(t, "Import", mock.Anything, mock.Anything, mock.Anything)
+
+	report, err = m.Import(restaurantId, menuModel.CSV, invalid, false)
+	assert.Nil(t, err)
+	assert.Len(t, report.Errors, 2)
+	assert.Equal(t, 2, report.Errors[0].Line)
+	assert.Equal(t, 3, report.Errors[1].Line)
+	mockRepo.AssertNotCalled(t, "Import", mock.Anything, mock.Anything, mock.Anything)
+
+	report, err = m.Import(restaurantId, menuModel.CSV, valid, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	mockRepo.AssertCalled(t, "Import", restaurantId, []menuModel.TransferRow{
+		{Code: "J1", Item: "Jollof rice", ItemType: "main", Price: "25", Dietary: []menuModel.DietaryTag{}},
+		{Code: "Z3", Item: "Zobo", ItemType: "drink", Price: "6.50", Dietary: []menuModel.DietaryTag{}},
+	}, mock.Anything)
+}
+
+func Test_menuService_Export(t *testing.T) {
+	restaurantId := uuid.New()
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindAll", restaurantId).Return([]menuModel.MenuItem{
+		{Id: 1, RestaurantId: restaurantId, Code: "J1", Item: "Jollof rice", ItemType: "main", Price: "25",
+			Allergens: []menuModel.Allergen{menuModel.Celery}, Dietary: []menuModel.DietaryTag{menuModel.Vegan,
+				menuModel.Halal}},
+	}, nil)
+	m := NewMenuService(log, mockRepo, new(MockRestaurantRepository))
+
+	var buf bytes.Buffer
+	assert.Nil(t, m.Export(restaurantId, menuModel.CSV, &buf))
+	assert.Equal(t, "code,item,item_type,price,allergens,dietary\nJ1,Jollof rice,main,25,celery,vegan;halal\n",
+		buf.String())
+}
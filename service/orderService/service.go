@@ -0,0 +1,171 @@
+package orderService
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/menuModel"
+	"rsm/entity/moneyModel"
+	"rsm/entity/orderModel"
+	"rsm/pricing"
+	"rsm/pubsub"
+	"rsm/repository/orderRepo"
+	"rsm/service/inventoryService"
+	"rsm/service/menuService"
+	"time"
+)
+
+type ServiceInterface interface {
+	GetOrder(id uuid.UUID) (*orderModel.Order, error)
+	GetUserOrder(userId, orderId uuid.UUID) (*orderModel.Order, error)
+	ListByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error)
+	UpdateStatus(order *orderModel.Order, to orderModel.Status) error
+	// Notify tells the customer about a status change another repository already saved
+	// alongside its own writes.
+	Notify(order *orderModel.Order, to orderModel.Status)
+	ListHistory(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error)
+	// Reorder rebuilds one of the user's orders as a cart priced as the restaurant serves it
+	// now, reporting what is no longer served or costs something different.
+	Reorder(userId, orderId uuid.UUID) (*pricing.Reorder, error)
+}
+
+type orderService struct {
+	log       *logrus.Logger
+	repo      orderRepo.RepoInterface
+	broker    pubsub.Broker
+	menus     menuService.ServiceInterface
+	inventory inventoryService.ServiceInterface
+}
+
+// UserTopic is where status changes of a user's orders are published.
+func UserTopic(userId uuid.UUID) string {
+	return "orders:" + userId.String()
+}
+
+func (o *orderService) GetOrder(id uuid.UUID) (*orderModel.Order, error) {
+	return o.repo.FindById(id)
+}
+
+// GetUserOrder only returns the order to the user who placed it. Anyone else is told it does
+// not exist.
+func (o *orderService) GetUserOrder(userId, orderId uuid.UUID) (*orderModel.Order, error) {
+	order, err := o.repo.FindById(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserId != userId {
+		return nil, orderRepo.ErrNotFound
+	}
+	return order, nil
+}
+
+func (o *orderService) ListByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error) {
+	return o.repo.FindByRestaurant(restaurantId, statuses)
+}
+
+// UpdateStatus moves the order on from the status it was read with and tells the customer.
+// It fails with orderRepo.ErrStaleStatus if someone else moved the order first.
+func (o *orderService) UpdateStatus(order *orderModel.Order, to orderModel.Status) error {
+	if !orderModel.CanTransition(order.Status, to) {
+		return fmt.Errorf("cannot move a %v order to %v", order.Status, to)
+	}
+	err := o.repo.UpdateStatus(order.Id, order.Status, to)
+	if err != nil {
+		return err
+	}
+	o.Notify(order, to)
+	return nil
+}
+
+func (o *orderService) Notify(order *orderModel.Order, to orderModel.Status) {
+	event := orderModel.StatusEvent{
+		OrderId:      order.Id,
+		RestaurantId: order.RestaurantId,
+		Previous:     order.Status,
+		Status:       to,
+		At:           time.Now(),
+	}
+	order.Status, order.UpdatedAt = to, event.At
+	if order.UserId == uuid.Nil || event.Previous == to {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err == nil {
+		_, err = o.broker.Publish(UserTopic(order.UserId), payload)
+	}
+	if err != nil {
+		// The status is saved; the customer sees it on their next reload.
+		o.log.Errorf("Error Publishing Order Status: %v", err)
+	}
+}
+
+func (o *orderService) ListHistory(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error) {
+	err := query.ValidateInput()
+	if err != nil {
+		o.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	if query.Limit == 0 {
+		query.Limit = orderModel.DefaultLimit
+	}
+	return o.repo.FindByUser(userId, query)
+}
+
+// Reorder only offers items still on the menu and not sold out, and for restaurants with
+// scheduled menus only those served right now, at the price they are served at.
+func (o *orderService) Reorder(userId, orderId uuid.UUID) (*pricing.Reorder, error) {
+	order, err := o.GetUserOrder(userId, orderId)
+	if err != nil {
+		return nil, err
+	}
+	items, err := o.menus.ListMenu(menuModel.MenuQuery{RestaurantId: order.RestaurantId})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	active, err := o.menus.ActiveMenu(order.RestaurantId, now)
+	if err != nil {
+		return nil, err
+	}
+	stocks, err := o.inventory.ListStock(order.RestaurantId)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make(map[int64]bool, len(order.Items))
+	for _, i := range order.Items {
+		ordered[i.MenuId] = true
+	}
+	for _, s := range stocks {
+		if !s.Available(now) {
+			delete(ordered, s.MenuId)
+		}
+	}
+	serving := make(map[int64]pricing.Line, len(ordered))
+	for _, m := range items {
+		if !ordered[m.Id] {
+			continue
+		}
+		name, itemType, price := m.Item, m.ItemType, m.Price
+		if active.Scheduled {
+			served, ok := active.Find(m.Id)
+			if !ok {
+				continue
+			}
+			name, itemType, price = served.Item, served.ItemType, served.Price
+		}
+		unitPrice, err := moneyModel.Parse(price, order.Currency)
+		if err != nil {
+			return nil, err
+		}
+		serving[m.Id] = pricing.Line{MenuId: m.Id, Item: name, ItemType: itemType, UnitPrice: unitPrice}
+	}
+	reorder := pricing.Rebuild(*order, serving)
+	return &reorder, nil
+}
+
+func NewOrderService(log *logrus.Logger, repo orderRepo.RepoInterface, broker pubsub.Broker, menus menuService.ServiceInterface, inventory inventoryService.ServiceInterface) ServiceInterface {
+	return &orderService{log: log, repo: repo, broker: broker, menus: menus, inventory: inventory}
+}
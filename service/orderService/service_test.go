@@ -0,0 +1,285 @@
+package orderService
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"rsm/entity/inventoryModel"
+	"rsm/entity/menuModel"
+	"rsm/entity/moneyModel"
+	"rsm/entity/orderModel"
+	"rsm/pubsub"
+	"rsm/repository/orderRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) FindById(id uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(id)
+	order, _ := args.Get(0).(*orderModel.Order)
+	return order, args.Error(1)
+}
+
+func (m *MockRepository) FindByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error) {
+	args := m.Called(restaurantId, statuses)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockRepository) FindByUser(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error) {
+	args := m.Called(userId, query)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockRepository) UpdateStatus(id uuid.UUID, from, to orderModel.Status) error {
+	args := m.Called(id, from, to)
+	return args.Error(0)
+}
+
+type MockMenuService struct {
+	mock.Mock
+}
+
+func (m *MockMenuService) ListMenu(query menuModel.MenuQuery) ([]menuModel.MenuItem, error) {
+	args := m.Called(query)
+	return args.Get(0).([]menuModel.MenuItem), args.Error(1)
+}
+
+func (m *MockMenuService) GetItem(id int64) (*menuModel.MenuItem, error) {
+	args := m.Called(id)
+	item, _ := args.Get(0).(*menuModel.MenuItem)
+	return item, args.Error(1)
+}
+
+func (m *MockMenuService) SetDietaryInfo(restaurantId uuid.UUID, menuId int64, info menuModel.DietaryInfo) (*menuModel.MenuItem, error) {
+	args := m.Called(restaurantId, menuId, info)
+	item, _ := args.Get(0).(*menuModel.MenuItem)
+	return item, args.Error(1)
+}
+
+func (m *MockMenuService) CreateMenu(menu *menuModel.NamedMenu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockMenuService) UpdateMenu(menu *menuModel.NamedMenu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockMenuService) ListMenus(restaurantId uuid.UUID) ([]menuModel.NamedMenu, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]menuModel.NamedMenu), args.Error(1)
+}
+
+func (m *MockMenuService) SaveDraft(restaurantId, namedMenuId uuid.UUID, request menuModel.DraftRequest) (*menuModel.Version, error) {
+	args := m.Called(restaurantId, namedMenuId, request)
+	version, _ := args.Get(0).(*menuModel.Version)
+	return version, args.Error(1)
+}
+
+func (m *MockMenuService) Publish(restaurantId, versionId uuid.UUID, effectiveFrom time.Time) (*menuModel.Version, error) {
+	args := m.Called(restaurantId, versionId, effectiveFrom)
+	version, _ := args.Get(0).(*menuModel.Version)
+	return version, args.Error(1)
+}
+
+func (m *MockMenuService) ListVersions(restaurantId, namedMenuId uuid.UUID) ([]menuModel.Version, error) {
+	args := m.Called(restaurantId, namedMenuId)
+	return args.Get(0).([]menuModel.Version), args.Error(1)
+}
+
+func (m *MockMenuService) DiffVersions(restaurantId, fromId, toId uuid.UUID) (*menuModel.VersionDiff, error) {
+	args := m.Called(restaurantId, fromId, toId)
+	diff, _ := args.Get(0).(*menuModel.VersionDiff)
+	return diff, args.Error(1)
+}
+
+func (m *MockMenuService) ActiveMenu(restaurantId uuid.UUID, at time.Time) (*menuModel.ActiveMenu, error) {
+	args := m.Called(restaurantId, at)
+	active, _ := args.Get(0).(*menuModel.ActiveMenu)
+	return active, args.Error(1)
+}
+
+func (m *MockMenuService) Import(restaurantId uuid.UUID, format menuModel.Format, data []byte, dryRun bool) (*menuModel.ImportReport, error) {
+	args := m.Called(restaurantId, format, data, dryRun)
+	report, _ := args.Get(0).(*menuModel.ImportReport)
+	return report, args.Error(1)
+}
+
+func (m *MockMenuService) Export(restaurantId uuid.UUID, format menuModel.Format, w io.Writer) error {
+	args := m.Called(restaurantId, format, w)
+	return args.Error(0)
+}
+
+type MockInventoryService struct {
+	mock.Mock
+}
+
+func (m *MockInventoryService) ListStock(restaurantId uuid.UUID) ([]inventoryModel.Stock, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]inventoryModel.Stock), args.Error(1)
+}
+
+func (m *MockInventoryService) Configure(restaurantId uuid.UUID, menuId int64, request inventoryModel.StockRequest) (*inventoryModel.Stock, error) {
+	args := m.Called(restaurantId, menuId, request)
+	return args.Get(0).(*inventoryModel.Stock), args.Error(1)
+}
+
+func (m *MockInventoryService) Restock(restaurantId uuid.UUID, menuId int64, request inventoryModel.RestockRequest) (*inventoryModel.Stock, error) {
+	args := m.Called(restaurantId, menuId, request)
+	return args.Get(0).(*inventoryModel.Stock), args.Error(1)
+}
+
+func (m *MockInventoryService) Reserve(order *orderModel.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
+func (m *MockInventoryService) Release(order *orderModel.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
+func Test_orderService_UpdateStatus(t *testing.T) {
+	order := &orderModel.Order{Id: uuid.New(), UserId: uuid.New(), RestaurantId: uuid.New(), Status: orderModel.Preparing}
+	stale := &orderModel.Order{Id: uuid.New(), UserId: order.UserId, Status: orderModel.Accepted}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("UpdateStatus", order.Id, orderModel.Preparing, orderModel.Ready).Return(nil)
+	mockRepo.On("UpdateStatus", stale.Id, orderModel.Accepted, orderModel.Preparing).Return(orderRepo.ErrStaleStatus)
+
+	broker := pubsub.NewMemoryBroker()
+	messages, _ := broker.Subscribe(context.Background(), UserTopic(order.UserId))
+	o := NewOrderService(log, mockRepo, broker, new(MockMenuService), new(MockInventoryService))
+
+	assert.Nil(t, o.UpdateStatus(order, orderModel.Ready))
+	assert.Equal(t, orderModel.Ready, order.Status)
+
+	var event orderModel.StatusEvent
+	assert.Nil(t, json.Unmarshal((<-messages).Payload, &event))
+	assert.Equal(t, order.Id, event.OrderId)
+	assert.Equal(t, orderModel.Preparing, event.Previous)
+	assert.Equal(t, orderModel.Ready, event.Status)
+
+	assert.Equal(t, fmt.Errorf("cannot move a ready order to pending"), o.UpdateStatus(order, orderModel.Pending))
+	assert.Equal(t, orderRepo.ErrStaleStatus, o.UpdateStatus(stale, orderModel.Preparing))
+	assert.Equal(t, orderModel.Accepted, stale.Status)
+	assert.Len(t, messages, 0)
+}
+
+func Test_orderService_GetUserOrder(t *testing.T) {
+	order := &orderModel.Order{Id: uuid.New(), UserId: uuid.New()}
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindById", order.Id).Return(order, nil)
+
+	o := NewOrderService(log, mockRepo, pubsub.NewMemoryBroker(), new(MockMenuService), new(MockInventoryService))
+
+	found, err := o.GetUserOrder(order.UserId, order.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, order, found)
+
+	_, err = o.GetUserOrder(uuid.New(), order.Id)
+	assert.Equal(t, orderRepo.ErrNotFound, err)
+}
+
+func Test_orderService_ListHistory(t *testing.T) {
+	userId := uuid.New()
+	from := time.Date(2022, time.May, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindByUser", userId, mock.Anything).Return([]orderModel.Order{}, nil)
+	o := NewOrderService(log, mockRepo, pubsub.NewMemoryBroker(), new(MockMenuService), new(MockInventoryService))
+
+	tests := []struct {
+		name    string
+		query   orderModel.HistoryQuery
+		wantErr bool
+	}{
+		{name: "everything", query: orderModel.HistoryQuery{}},
+		{name: "filtered", query: orderModel.HistoryQuery{Statuses: []orderModel.Status{orderModel.Completed},
+			From: &from, To: &to}},
+		{name: "unknown status", query: orderModel.HistoryQuery{Statuses: []orderModel.Status{"lost"}}, wantErr: true},
+		{name: "ends before it starts", query: orderModel.HistoryQuery{From: &to, To: &from}, wantErr: true},
+		{name: "too many", query: orderModel.HistoryQuery{Limit: 500}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := o.ListHistory(userId, tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ListHistory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	query := mockRepo.Calls[0].Arguments.Get(1).(orderModel.HistoryQuery)
+	assert.Equal(t, orderModel.DefaultLimit, query.Limit)
+}
+
+func Test_orderService_Reorder(t *testing.T) {
+	userId, restaurantId := uuid.New(), uuid.New()
+	order := &orderModel.Order{Id: uuid.New(), UserId: userId, RestaurantId: restaurantId, Currency: "NGN",
+		Status: orderModel.Completed, Items: []orderModel.OrderItem{
+			{MenuId: 1, Item: "Jollof", ItemType: "main", Quantity: 2, UnitPrice: 2500},
+			{MenuId: 2, Item: "Suya", ItemType: "side", Quantity: 1, UnitPrice: 1000},
+			{MenuId: 3, Item: "Zobo", ItemType: "drink", Quantity: 1, UnitPrice: 500},
+		}}
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindById", order.Id).Return(order, nil)
+	// Suya has sold out, and an item nobody ordered has a price that does not parse.
+	listed := []menuModel.MenuItem{
+		{Id: 1, Item: "Jollof", ItemType: "main", Price: "25.00"},
+		{Id: 2, Item: "Suya", ItemType: "side", Price: "10.00"},
+		{Id: 3, Item: "Zobo", ItemType: "drink", Price: "6.00"},
+		{Id: 4, Item: "Chapman", ItemType: "drink", Price: "market price"},
+	}
+	inventory := new(MockInventoryService)
+	inventory.On("ListStock", restaurantId).Return([]inventoryModel.Stock{
+		{MenuId: 1, Mode: inventoryModel.Untracked},
+		{MenuId: 2, Mode: inventoryModel.Count, Servings: 0},
+		{MenuId: 3, Mode: inventoryModel.Count, Servings: 4},
+	}, nil)
+
+	t.Run("list prices", func(t *testing.T) {
+		menus := new(MockMenuService)
+		menus.On("ListMenu", menuModel.MenuQuery{RestaurantId: restaurantId}).Return(listed, nil)
+		menus.On("ActiveMenu", restaurantId, mock.Anything).Return(&menuModel.ActiveMenu{}, nil)
+		o := NewOrderService(log, mockRepo, pubsub.NewMemoryBroker(), menus, inventory)
+
+		got, err := o.Reorder(userId, order.Id)
+		assert.Nil(t, err)
+		assert.Len(t, got.Cart.Lines, 2)
+		assert.Equal(t, 2, got.Cart.Lines[0].Quantity)
+		assert.Equal(t, []orderModel.OrderItem{order.Items[1]}, got.Unavailable)
+		assert.Len(t, got.Repriced, 1)
+		assert.Equal(t, moneyModel.New(600, "NGN"), got.Repriced[0].Current)
+	})
+
+	t.Run("scheduled menu", func(t *testing.T) {
+		menus := new(MockMenuService)
+		menus.On("ListMenu", menuModel.MenuQuery{RestaurantId: restaurantId}).Return(listed, nil)
+		menus.On("ActiveMenu", restaurantId, mock.Anything).Return(&menuModel.ActiveMenu{Scheduled: true,
+			Sections: []menuModel.Section{{Version: menuModel.Version{Items: []menuModel.VersionItem{
+				{MenuId: 1, Item: "Jollof", ItemType: "main", Price: "20.00"}}}}}}, nil)
+		o := NewOrderService(log, mockRepo, pubsub.NewMemoryBroker(), menus, inventory)
+
+		got, err := o.Reorder(userId, order.Id)
+		assert.Nil(t, err)
+		assert.Len(t, got.Cart.Lines, 1)
+		assert.Equal(t, moneyModel.New(2000, "NGN"), got.Cart.Lines[0].UnitPrice)
+		assert.Len(t, got.Unavailable, 2)
+		assert.Len(t, got.Repriced, 1)
+	})
+
+	_, err := NewOrderService(log, mockRepo, pubsub.NewMemoryBroker(), new(MockMenuService), new(MockInventoryService)).Reorder(uuid.New(), order.Id)
+	assert.Equal(t, orderRepo.ErrNotFound, err)
+}
@@ -0,0 +1,379 @@
+package paymentService
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/moneyModel"
+	"rsm/entity/paymentModel"
+	"rsm/payment"
+	"rsm/repository/orderRepo"
+	"rsm/repository/paymentRepo"
+	"rsm/repository/tableRepo"
+	"rsm/service/loyaltyService"
+	"time"
+)
+
+type ServiceInterface interface {
+	Authorize(request paymentModel.AuthorizeRequest) (*paymentModel.Payment, error)
+	Capture(paymentId uuid.UUID) (*paymentModel.Payment, error)
+	Void(paymentId uuid.UUID) (*paymentModel.Payment, error)
+	Refund(paymentId uuid.UUID, request paymentModel.RefundRequest) (*paymentModel.Refund, error)
+	HandleWebhook(payload []byte, signature string) error
+	GetPayment(id uuid.UUID) (*paymentModel.Payment, error)
+	ListByOrder(orderId uuid.UUID) ([]paymentModel.Payment, error)
+	// ListRefunds returns the refunds made through Refund on the order's payments, oldest
+	// first. Refunds only reported by the provider's webhooks are not among them.
+	ListRefunds(orderId uuid.UUID) ([]paymentModel.Refund, error)
+}
+
+type paymentService struct {
+	log      *logrus.Logger
+	repo     paymentRepo.RepoInterface
+	provider payment.Provider
+	tables   tableRepo.RepoInterface
+	orders   orderRepo.RepoInterface
+	loyalty  loyaltyService.ServiceInterface
+}
+
+// Authorize reserves the amount with the provider. Calling it again with the same
+// idempotency key returns the existing payment, retrying the provider only if the first
+// attempt never got an answer.
+func (p *paymentService) Authorize(request paymentModel.AuthorizeRequest) (*paymentModel.Payment, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		p.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	amount := moneyModel.New(request.Amount, request.Currency)
+
+	existing, err := p.repo.FindByIdempotencyKey(request.IdempotencyKey)
+	switch {
+	case err == nil:
+		if existing.OrderId != request.OrderId || existing.Amount != amount {
+			return nil, fmt.Errorf("idempotency key reused for a different payment")
+		}
+		if existing.Status != paymentModel.Pending {
+			return existing, nil
+		}
+	case errors.Is(err, paymentRepo.ErrNotFound):
+		now := time.Now()
+		existing, err = p.repo.Persist(&paymentModel.Payment{
+			Id:             uuid.New(),
+			OrderId:        request.OrderId,
+			UserId:         request.UserId,
+			IdempotencyKey: request.IdempotencyKey,
+			Provider:       p.provider.Name(),
+			Status:         paymentModel.Pending,
+			Amount:         amount,
+			CapturedAmount: moneyModel.Zero(amount.Currency),
+			RefundedAmount: moneyModel.Zero(amount.Currency),
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	res, err := p.provider.Authorize(payment.AuthorizeRequest{
+		PaymentId:      existing.Id,
+		Amount:         amount,
+		SourceToken:    request.SourceToken,
+		IdempotencyKey: request.IdempotencyKey,
+	})
+	if err != nil {
+		p.log.Errorf("Provider Authorize Error: %v", err)
+		return nil, fmt.Errorf("payment provider unavailable")
+	}
+
+	updated := *existing
+	updated.ProviderRef = res.ProviderRef
+	updated.Status = paymentModel.Authorized
+	if !res.Approved {
+		updated.Status = paymentModel.Failed
+		updated.FailureReason = res.DeclineReason
+	}
+	return p.transition(existing, &updated)
+}
+
+// Capture takes the full authorised amount. Capturing an already captured payment only
+// closes the order's table session, in case that did not happen the first time.
+func (p *paymentService) Capture(paymentId uuid.UUID) (*paymentModel.Payment, error) {
+	current, err := p.repo.FindById(paymentId)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == paymentModel.Captured {
+		p.settle(current)
+		return current, nil
+	}
+	if current.Status == paymentModel.PartiallyRefunded || current.Status == paymentModel.Refunded {
+		return current, nil
+	}
+	if !paymentModel.CanTransition(current.Status, paymentModel.Captured) {
+		return nil, fmt.Errorf("cannot capture a %v payment", current.Status)
+	}
+
+	_, err = p.provider.Capture(current.ProviderRef, current.Amount, "capture:"+current.Id.String())
+	if err != nil {
+		p.log.Errorf("Provider Capture Error: %v", err)
+		return nil, fmt.Errorf("capture failed")
+	}
+
+	updated := *current
+	updated.Status = paymentModel.Captured
+	updated.CapturedAmount = current.Amount
+	captured, err := p.transition(current, &updated)
+	if err != nil {
+		return nil, err
+	}
+	p.settle(captured)
+	return captured, nil
+}
+
+// Void releases an authorisation that was never captured. Voiding twice is a no-op.
+func (p *paymentService) Void(paymentId uuid.UUID) (*paymentModel.Payment, error) {
+	current, err := p.repo.FindById(paymentId)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == paymentModel.Voided {
+		return current, nil
+	}
+	if !paymentModel.CanTransition(current.Status, paymentModel.Voided) {
+		return nil, fmt.Errorf("cannot void a %v payment", current.Status)
+	}
+
+	_, err = p.provider.Void(current.ProviderRef, "void:"+current.Id.String())
+	if err != nil {
+		p.log.Errorf("Provider Void Error: %v", err)
+		return nil, fmt.Errorf("void failed")
+	}
+
+	updated := *current
+	updated.Status = paymentModel.Voided
+	return p.transition(current, &updated)
+}
+
+// Refund returns part or all of the captured amount. A retried request with the same
+// idempotency key returns the original refund. The amount is reserved on the payment before
+// the provider moves any money, so concurrent refunds cannot together exceed the capture.
+// Every refund, retried or not, brings the order's loyalty points in line with it.
+func (p *paymentService) Refund(paymentId uuid.UUID, request paymentModel.RefundRequest) (*paymentModel.Refund, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		p.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+
+	existing, err := p.repo.FindRefund(paymentId, request.IdempotencyKey)
+	if err == nil {
+		// Reversing again repairs points a failed attempt the first time left in place.
+		current, err := p.repo.FindById(paymentId)
+		if err != nil {
+			return nil, err
+		}
+		err = p.reverse(current.OrderId)
+		if err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, paymentRepo.ErrNotFound) {
+		return nil, err
+	}
+
+	current, err := p.repo.FindById(paymentId)
+	if err != nil {
+		return nil, err
+	}
+	if !paymentModel.CanTransition(current.Status, paymentModel.PartiallyRefunded) {
+		return nil, fmt.Errorf("cannot refund a %v payment", current.Status)
+	}
+	amount := moneyModel.New(request.Amount, current.Amount.Currency)
+	if request.Amount > current.Refundable().Amount {
+		return nil, fmt.Errorf("refund exceeds refundable amount %v", current.Refundable())
+	}
+
+	err = p.repo.ReserveRefund(paymentId, amount.Amount, time.Now())
+	if errors.Is(err, paymentRepo.ErrNotRefundable) {
+		return nil, fmt.Errorf("refund exceeds refundable amount")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The provider dedupes keys across all payments, so the client's key is scoped to this one.
+	res, err := p.provider.Refund(current.ProviderRef, amount, "refund:"+current.Id.String()+":"+request.IdempotencyKey)
+	if err != nil {
+		p.log.Errorf("Provider Refund Error: %v", err)
+		p.release(paymentId, amount.Amount)
+		return nil, fmt.Errorf("refund failed")
+	}
+
+	refund := paymentModel.Refund{
+		Id:             uuid.New(),
+		PaymentId:      paymentId,
+		IdempotencyKey: request.IdempotencyKey,
+		ProviderRef:    res.ProviderRef,
+		Amount:         amount,
+		CreatedAt:      time.Now(),
+	}
+	err = p.repo.RecordRefund(&refund)
+	if errors.Is(err, paymentRepo.ErrDuplicateRefund) {
+		// A concurrent retry with the same key got the same provider refund and recorded it
+		// first, so this reservation counts it twice.
+		p.release(paymentId, amount.Amount)
+		return p.repo.FindRefund(paymentId, request.IdempotencyKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = p.reverse(current.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// reverse takes back the loyalty points the order earned on everything refunded of it so far,
+// and gives back those spent on it once all of it is.
+func (p *paymentService) reverse(orderId uuid.UUID) error {
+	order, err := p.orders.FindById(orderId)
+	if err != nil {
+		return err
+	}
+	refunds, err := p.repo.FindRefundsByOrder(orderId)
+	if err != nil {
+		return err
+	}
+	var refunded int64
+	for _, r := range refunds {
+		if r.IdempotencyKey != paymentModel.OverpaidKey {
+			refunded += r.Amount.Amount
+		}
+	}
+	if refunded > order.Total {
+		refunded = order.Total
+	}
+	err = p.loyalty.Reverse(order, refunded)
+	if err != nil {
+		p.log.Errorf("Error Reversing Loyalty Points For Order %v: %v", orderId, err)
+	}
+	return err
+}
+
+// release takes back a refund reservation. Failing to leaves the payment showing more
+// refunded than it was, so it is logged for support to correct.
+func (p *paymentService) release(paymentId uuid.UUID, amount int64) {
+	err := p.repo.ReleaseRefund(paymentId, amount, time.Now())
+	if err != nil {
+		p.log.Errorf("Error Releasing Refund Of %d On Payment %v: %v", amount, paymentId, err)
+	}
+}
+
+// HandleWebhook applies a provider notification once: a replayed event is ignored. Events
+// that repeat the current state are recorded without a change, as are events that arrive
+// after the payment has already moved past them. A captured amount that does not fit the
+// payment is rejected. For refund events Amount is the total refunded so far, not the
+// latest refund.
+func (p *paymentService) HandleWebhook(payload []byte, signature string) error {
+	event, err := p.provider.VerifyWebhook(payload, signature)
+	if err != nil {
+		p.log.Errorf("Webhook Verification Error: %v", err)
+		return err
+	}
+
+	current, err := p.repo.FindByProviderRef(p.provider.Name(), event.ProviderRef)
+	if err != nil {
+		return err
+	}
+
+	updated := *current
+	switch event.Type {
+	case payment.EventAuthorized:
+		updated.Status = paymentModel.Authorized
+	case payment.EventFailed:
+		updated.Status = paymentModel.Failed
+	case payment.EventCaptured:
+		err = current.CheckCapture(event.Amount)
+		if err != nil {
+			p.log.Errorf("Webhook %v Rejected: %v", event.Id, err)
+			return err
+		}
+		updated.Status = paymentModel.Captured
+		updated.CapturedAmount = event.Amount
+	case payment.EventVoided:
+		updated.Status = paymentModel.Voided
+	case payment.EventRefunded:
+		if !event.Amount.SameCurrency(current.Amount) {
+			p.log.Errorf("Webhook %v Rejected: refund in %v for a %v payment", event.Id, event.Amount.Currency,
+				current.Amount.Currency)
+			return fmt.Errorf("refund currency does not match the payment")
+		}
+		if event.Amount.Amount > current.RefundedAmount.Amount {
+			updated.RefundedAmount = event.Amount
+			updated.Status = paymentModel.PartiallyRefunded
+			if event.Amount.Amount >= current.CapturedAmount.Amount {
+				updated.Status = paymentModel.Refunded
+			}
+		}
+	default:
+		p.log.Warnf("Ignoring unknown webhook event %v", event.Type)
+	}
+
+	changed := updated.Status != current.Status || updated.RefundedAmount != current.RefundedAmount
+	if changed && !paymentModel.CanTransition(current.Status, updated.Status) {
+		p.log.Warnf("Ignoring stale webhook %v for %v payment %v", event.Type, current.Status, current.Id)
+		updated, changed = *current, false
+	}
+	updated.UpdatedAt = time.Now()
+	recorded, err := p.repo.RecordEvent(event.Id, string(event.Type), &updated, changed, current.Status)
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		p.log.Infof("Ignoring replayed webhook %v", event.Id)
+	} else if changed && updated.Status == paymentModel.Captured {
+		p.settle(&updated)
+	}
+	return nil
+}
+
+func (p *paymentService) GetPayment(id uuid.UUID) (*paymentModel.Payment, error) {
+	return p.repo.FindById(id)
+}
+
+func (p *paymentService) ListByOrder(orderId uuid.UUID) ([]paymentModel.Payment, error) {
+	return p.repo.FindByOrder(orderId)
+}
+
+func (p *paymentService) ListRefunds(orderId uuid.UUID) ([]paymentModel.Refund, error) {
+	return p.repo.FindRefundsByOrder(orderId)
+}
+
+// settle closes the table session of an order paid in one captured payment, as settling a
+// split bill does. The payment stands if that fails, and staff can still close the session.
+func (p *paymentService) settle(payment *paymentModel.Payment) {
+	err := p.tables.CloseByOrder(payment.OrderId, time.Now())
+	if err != nil {
+		p.log.Errorf("Error Closing Table Session For Order %v: %v", payment.OrderId, err)
+	}
+}
+
+func (p *paymentService) transition(current, updated *paymentModel.Payment) (*paymentModel.Payment, error) {
+	updated.UpdatedAt = time.Now()
+	err := p.repo.Update(updated, current.Status)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func NewPaymentService(log *logrus.Logger, repo paymentRepo.RepoInterface, provider payment.Provider, tables tableRepo.RepoInterface, orders orderRepo.RepoInterface, loyalty loyaltyService.ServiceInterface) ServiceInterface {
+	return &paymentService{log: log, repo: repo, provider: provider, tables: tables, orders: orders, loyalty: loyalty}
+}
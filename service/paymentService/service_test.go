@@ -0,0 +1,584 @@
+package paymentService
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/loyaltyModel"
+	"rsm/entity/moneyModel"
+	"rsm/entity/orderModel"
+	"rsm/entity/paymentModel"
+	"rsm/entity/tableModel"
+	"rsm/payment"
+	"rsm/pricing"
+	"rsm/repository/paymentRepo"
+	"sync"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+var order = &orderModel.Order{Id: uuid.MustParse("0b9f7a4e-3f39-4d7e-9f1e-5f0a0c8a1d11"), Currency: "NGN", Total: 10000}
+
+type MockTableRepository struct {
+	mock.Mock
+}
+
+func (m *MockTableRepository) Persist(table *tableModel.Table) error {
+	args := m.Called(table)
+	return args.Error(0)
+}
+
+func (m *MockTableRepository) Update(table *tableModel.Table) error {
+	args := m.Called(table)
+	return args.Error(0)
+}
+
+func (m *MockTableRepository) FindById(id uuid.UUID) (*tableModel.Table, error) {
+	args := m.Called(id)
+	table, _ := args.Get(0).(*tableModel.Table)
+	return table, args.Error(1)
+}
+
+func (m *MockTableRepository) FindByToken(token string) (*tableModel.Table, error) {
+	args := m.Called(token)
+	table, _ := args.Get(0).(*tableModel.Table)
+	return table, args.Error(1)
+}
+
+func (m *MockTableRepository) FindByRestaurant(restaurantId uuid.UUID) ([]tableModel.Table, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]tableModel.Table), args.Error(1)
+}
+
+func (m *MockTableRepository) Join(session *tableModel.Session, diner *tableModel.Diner) (*tableModel.Session, error) {
+	args := m.Called(session, diner)
+	joined, _ := args.Get(0).(*tableModel.Session)
+	return joined, args.Error(1)
+}
+
+func (m *MockTableRepository) FindSession(id uuid.UUID) (*tableModel.Session, error) {
+	args := m.Called(id)
+	session, _ := args.Get(0).(*tableModel.Session)
+	return session, args.Error(1)
+}
+
+func (m *MockTableRepository) FindItems(sessionId uuid.UUID) ([]tableModel.Item, error) {
+	args := m.Called(sessionId)
+	return args.Get(0).([]tableModel.Item), args.Error(1)
+}
+
+func (m *MockTableRepository) AddItems(sessionId uuid.UUID, order *orderModel.Order, known int, items []tableModel.Item) (*tableModel.Session, error) {
+	args := m.Called(sessionId, order, known, items)
+	session, _ := args.Get(0).(*tableModel.Session)
+	return session, args.Error(1)
+}
+
+func (m *MockTableRepository) Close(id uuid.UUID, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+func (m *MockTableRepository) CloseByOrder(orderId uuid.UUID, at time.Time) error {
+	args := m.Called(orderId, at)
+	return args.Error(0)
+}
+
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepository) FindById(id uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(id)
+	return args.Get(0).(*orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) FindByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error) {
+	args := m.Called(restaurantId, statuses)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) FindByUser(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error) {
+	args := m.Called(userId, query)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) UpdateStatus(id uuid.UUID, from, to orderModel.Status) error {
+	args := m.Called(id, from, to)
+	return args.Error(0)
+}
+
+type MockLoyaltyService struct {
+	mock.Mock
+}
+
+func (m *MockLoyaltyService) SaveRule(rule *loyaltyModel.EarnRule) error {
+	args := m.Called(rule)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) CreateReward(reward *loyaltyModel.Reward) error {
+	args := m.Called(reward)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) UpdateReward(reward *loyaltyModel.Reward) error {
+	args := m.Called(reward)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) Rewards(restaurantId uuid.UUID) ([]loyaltyModel.Reward, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]loyaltyModel.Reward), args.Error(1)
+}
+
+func (m *MockLoyaltyService) EarnForOrder(order *orderModel.Order) (*loyaltyModel.Entry, error) {
+	args := m.Called(order)
+	entry, _ := args.Get(0).(*loyaltyModel.Entry)
+	return entry, args.Error(1)
+}
+
+func (m *MockLoyaltyService) QuoteReward(userId, rewardId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Breakdown, error) {
+	args := m.Called(userId, rewardId, cart, codes)
+	breakdown, _ := args.Get(0).(*pricing.Breakdown)
+	return breakdown, args.Error(1)
+}
+
+func (m *MockLoyaltyService) Redeem(order *orderModel.Order, rewardId uuid.UUID, priced *pricing.Breakdown) (*loyaltyModel.Entry, error) {
+	args := m.Called(order, rewardId, priced)
+	entry, _ := args.Get(0).(*loyaltyModel.Entry)
+	return entry, args.Error(1)
+}
+
+func (m *MockLoyaltyService) OrderReward(orderId uuid.UUID) (*loyaltyModel.Reward, error) {
+	args := m.Called(orderId)
+	reward, _ := args.Get(0).(*loyaltyModel.Reward)
+	return reward, args.Error(1)
+}
+
+func (m *MockLoyaltyService) Reverse(order *orderModel.Order, refunded int64) error {
+	args := m.Called(order, refunded)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) ExpirePoints(at time.Time) (int64, error) {
+	args := m.Called(at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoyaltyService) Balance(userId uuid.UUID) (*loyaltyModel.Balance, error) {
+	args := m.Called(userId)
+	balance, _ := args.Get(0).(*loyaltyModel.Balance)
+	return balance, args.Error(1)
+}
+
+func (m *MockLoyaltyService) History(userId uuid.UUID, limit, offset int) ([]loyaltyModel.Entry, error) {
+	args := m.Called(userId, limit, offset)
+	return args.Get(0).([]loyaltyModel.Entry), args.Error(1)
+}
+
+// memoryRepository keeps payments in a map and enforces the same expected-status and
+// refund reservation checks as the psql repository, so the whole flow can run against the fake provider.
+type memoryRepository struct {
+	mu       sync.Mutex
+	payments map[uuid.UUID]paymentModel.Payment
+	refunds  map[string]paymentModel.Refund
+	events   map[string]bool
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		payments: map[uuid.UUID]paymentModel.Payment{},
+		refunds:  map[string]paymentModel.Refund{},
+		events:   map[string]bool{},
+	}
+}
+
+func (m *memoryRepository) Persist(p *paymentModel.Payment) (*paymentModel.Payment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payments[p.Id] = *p
+	return p, nil
+}
+
+func (m *memoryRepository) find(match func(p paymentModel.Payment) bool) (*paymentModel.Payment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.payments {
+		if match(p) {
+			found := p
+			return &found, nil
+		}
+	}
+	return nil, paymentRepo.ErrNotFound
+}
+
+func (m *memoryRepository) FindById(id uuid.UUID) (*paymentModel.Payment, error) {
+	return m.find(func(p paymentModel.Payment) bool { return p.Id == id })
+}
+
+func (m *memoryRepository) FindByIdempotencyKey(key string) (*paymentModel.Payment, error) {
+	return m.find(func(p paymentModel.Payment) bool { return p.IdempotencyKey == key })
+}
+
+func (m *memoryRepository) FindByProviderRef(provider, ref string) (*paymentModel.Payment, error) {
+	return m.find(func(p paymentModel.Payment) bool { return p.Provider == provider && p.ProviderRef == ref })
+}
+
+func (m *memoryRepository) FindByOrder(orderId uuid.UUID) ([]paymentModel.Payment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var payments []paymentModel.Payment
+	for _, p := range m.payments {
+		if p.OrderId == orderId {
+			payments = append(payments, p)
+		}
+	}
+	return payments, nil
+}
+
+func (m *memoryRepository) Update(p *paymentModel.Payment, expected paymentModel.Status) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.payments[p.Id].Status != expected {
+		return paymentRepo.ErrStaleStatus
+	}
+	m.payments[p.Id] = *p
+	return nil
+}
+
+func (m *memoryRepository) FindRefund(paymentId uuid.UUID, key string) (*paymentModel.Refund, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.refunds[paymentId.String()+key]
+	if !ok {
+		return nil, paymentRepo.ErrNotFound
+	}
+	return &r, nil
+}
+
+func (m *memoryRepository) ReserveRefund(paymentId uuid.UUID, amount int64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.payments[paymentId]
+	if !paymentModel.CanTransition(p.Status, paymentModel.PartiallyRefunded) || amount > p.Refundable().Amount {
+		return paymentRepo.ErrNotRefundable
+	}
+	p.RefundedAmount = moneyModel.New(p.RefundedAmount.Amount+amount, p.RefundedAmount.Currency)
+	p.Status = paymentModel.PartiallyRefunded
+	if p.RefundedAmount == p.CapturedAmount {
+		p.Status = paymentModel.Refunded
+	}
+	p.UpdatedAt = at
+	m.payments[paymentId] = p
+	return nil
+}
+
+func (m *memoryRepository) ReleaseRefund(paymentId uuid.UUID, amount int64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.payments[paymentId]
+	p.RefundedAmount = moneyModel.New(p.RefundedAmount.Amount-amount, p.RefundedAmount.Currency)
+	p.Status = paymentModel.PartiallyRefunded
+	if p.RefundedAmount.Amount == 0 {
+		p.Status = paymentModel.Captured
+	}
+	p.UpdatedAt = at
+	m.payments[paymentId] = p
+	return nil
+}
+
+func (m *memoryRepository) RecordRefund(r *paymentModel.Refund) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.refunds[r.PaymentId.String()+r.IdempotencyKey]; ok {
+		return paymentRepo.ErrDuplicateRefund
+	}
+	m.refunds[r.PaymentId.String()+r.IdempotencyKey] = *r
+	return nil
+}
+
+func (m *memoryRepository) FindRefundsByOrder(orderId uuid.UUID) ([]paymentModel.Refund, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	refunds := []paymentModel.Refund{}
+	for _, r := range m.refunds {
+		if m.payments[r.PaymentId].OrderId == orderId {
+			refunds = append(refunds, r)
+		}
+	}
+	return refunds, nil
+}
+
+func (m *memoryRepository) RecordEvent(eventId string, _ string, p *paymentModel.Payment, updated bool, expected paymentModel.Status) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.events[eventId] {
+		return false, nil
+	}
+	if updated {
+		if m.payments[p.Id].Status != expected {
+			return false, paymentRepo.ErrStaleStatus
+		}
+		m.payments[p.Id] = *p
+	}
+	m.events[eventId] = true
+	return true, nil
+}
+
+func authorizeRequest(key, token string) paymentModel.AuthorizeRequest {
+	return paymentModel.AuthorizeRequest{
+		OrderId:        order.Id,
+		UserId:         uuid.MustParse("6c1f5b0e-9d3a-4c59-8a57-0d3c2b8e4f22"),
+		Amount:         10000,
+		Currency:       "NGN",
+		SourceToken:    token,
+		IdempotencyKey: key,
+	}
+}
+
+func TestPaymentFlow(t *testing.T) {
+	repo := newMemoryRepository()
+	tables := new(MockTableRepository)
+	tables.On("CloseByOrder", mock.Anything, mock.Anything).Return(nil)
+	orders, loyalty := new(MockOrderRepository), new(MockLoyaltyService)
+	orders.On("FindById", order.Id).Return(order, nil)
+	loyalty.On("Reverse", order, mock.Anything).Return(nil)
+	p := NewPaymentService(log, repo, payment.NewFakeProvider("whsec"), tables, orders, loyalty)
+
+	authorized, err := p.Authorize(authorizeRequest("order-1", "tok_visa"))
+	assert.Nil(t, err)
+	assert.Equal(t, paymentModel.Authorized, authorized.Status)
+
+	retried, err := p.Authorize(authorizeRequest("order-1", "tok_visa"))
+	assert.Nil(t, err)
+	assert.Equal(t, authorized.Id, retried.Id)
+
+	captured, err := p.Capture(authorized.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, paymentModel.Captured, captured.Status)
+	assert.Equal(t, moneyModel.New(10000, "NGN"), captured.CapturedAmount)
+
+	again, err := p.Capture(authorized.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, paymentModel.Captured, again.Status)
+	tables.AssertNumberOfCalls(t, "CloseByOrder", 2)
+	tables.AssertCalled(t, "CloseByOrder", authorized.OrderId, mock.Anything)
+
+	_, err = p.Void(authorized.Id)
+	assert.NotNil(t, err)
+
+	first, err := p.Refund(authorized.Id, paymentModel.RefundRequest{Amount: 4000, IdempotencyKey: "r1"})
+	assert.Nil(t, err)
+	dup, err := p.Refund(authorized.Id, paymentModel.RefundRequest{Amount: 4000, IdempotencyKey: "r1"})
+	assert.Nil(t, err)
+	assert.Equal(t, first.Id, dup.Id)
+	loyalty.AssertNumberOfCalls(t, "Reverse", 2)
+	loyalty.AssertCalled(t, "Reverse", order, int64(4000))
+
+	partial, _ := p.GetPayment(authorized.Id)
+	assert.Equal(t, paymentModel.PartiallyRefunded, partial.Status)
+
+	_, err = p.Refund(authorized.Id, paymentModel.RefundRequest{Amount: 7000, IdempotencyKey: "r2"})
+	assert.NotNil(t, err)
+
+	_, err = p.Refund(authorized.Id, paymentModel.RefundRequest{Amount: 6000, IdempotencyKey: "r3"})
+	assert.Nil(t, err)
+	final, _ := p.GetPayment(authorized.Id)
+	assert.Equal(t, paymentModel.Refunded, final.Status)
+	assert.Equal(t, moneyModel.New(10000, "NGN"), final.RefundedAmount)
+	loyalty.AssertCalled(t, "Reverse", order, int64(10000))
+}
+
+func TestPaymentDeclinedAndVoid(t *testing.T) {
+	repo := newMemoryRepository()
+	tables := new(MockTableRepository)
+	orders, loyalty := new(MockOrderRepository), new(MockLoyaltyService)
+	orders.On("FindById", order.Id).Return(order, nil)
+	loyalty.On("Reverse", order, mock.Anything).Return(nil)
+	p := NewPaymentService(log, repo, payment.NewFakeProvider("whsec"), tables, orders, loyalty)
+
+	declined, err := p.Authorize(authorizeRequest("order-2", payment.TokenInsufficientFunds))
+	assert.Nil(t, err)
+	assert.Equal(t, paymentModel.Failed, declined.Status)
+	assert.Equal(t, "insufficient_funds", declined.FailureReason)
+
+	_, err = p.Capture(declined.Id)
+	assert.NotNil(t, err)
+
+	authorized, err := p.Authorize(authorizeRequest("order-3", "tok_visa"))
+	assert.Nil(t, err)
+	voided, err := p.Void(authorized.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, paymentModel.Voided, voided.Status)
+
+	reused := authorizeRequest("order-3", "tok_visa")
+	reused.Amount = 1
+	_, err = p.Authorize(reused)
+	assert.NotNil(t, err)
+}
+
+func TestPaymentWebhook(t *testing.T) {
+	repo := newMemoryRepository()
+	provider := payment.NewFakeProvider("whsec")
+	tables := new(MockTableRepository)
+	tables.On("CloseByOrder", mock.Anything, mock.Anything).Return(nil)
+	orders, loyalty := new(MockOrderRepository), new(MockLoyaltyService)
+	orders.On("FindById", order.Id).Return(order, nil)
+	loyalty.On("Reverse", order, mock.Anything).Return(nil)
+	p := NewPaymentService(log, repo, provider, tables, orders, loyalty)
+
+	authorized, err := p.Authorize(authorizeRequest("order-4", "tok_visa"))
+	assert.Nil(t, err)
+
+	payload, signature, _ := provider.SignWebhook(payment.WebhookEvent{
+		Id: "evt_1", Type: payment.EventCaptured, ProviderRef: authorized.ProviderRef, Amount: authorized.Amount,
+	})
+	assert.Nil(t, p.HandleWebhook(payload, signature))
+	assert.Nil(t, p.HandleWebhook(payload, signature))
+
+	captured, _ := p.GetPayment(authorized.Id)
+	assert.Equal(t, paymentModel.Captured, captured.Status)
+	tables.AssertNumberOfCalls(t, "CloseByOrder", 1)
+
+	stale, staleSignature, _ := provider.SignWebhook(payment.WebhookEvent{
+		Id: "evt_0", Type: payment.EventAuthorized, ProviderRef: authorized.ProviderRef,
+	})
+	assert.Nil(t, p.HandleWebhook(stale, staleSignature))
+	stillCaptured, _ := p.GetPayment(authorized.Id)
+	assert.Equal(t, paymentModel.Captured, stillCaptured.Status)
+
+	assert.Equal(t, payment.ErrInvalidSignature, p.HandleWebhook(payload, "deadbeef"))
+
+	// A replayed refund event must not be applied on top of a later one.
+	refunded, refundedSignature, _ := provider.SignWebhook(payment.WebhookEvent{
+		Id: "evt_2", Type: payment.EventRefunded, ProviderRef: authorized.ProviderRef, Amount: moneyModel.New(4000, "NGN"),
+	})
+	assert.Nil(t, p.HandleWebhook(refunded, refundedSignature))
+	repo.mu.Lock()
+	replayed := repo.payments[authorized.Id]
+	replayed.Status, replayed.RefundedAmount = paymentModel.Captured, moneyModel.Zero("NGN")
+	repo.payments[authorized.Id] = replayed
+	repo.mu.Unlock()
+	assert.Nil(t, p.HandleWebhook(refunded, refundedSignature))
+	notReapplied, _ := p.GetPayment(authorized.Id)
+	assert.Equal(t, paymentModel.Captured, notReapplied.Status)
+}
+
+func TestPaymentWebhook_CaptureMustMatch(t *testing.T) {
+	repo := newMemoryRepository()
+	provider := payment.NewFakeProvider("whsec")
+	tables := new(MockTableRepository)
+	tables.On("CloseByOrder", mock.Anything, mock.Anything).Return(nil)
+	orders, loyalty := new(MockOrderRepository), new(MockLoyaltyService)
+	orders.On("FindById", order.Id).Return(order, nil)
+	loyalty.On("Reverse", order, mock.Anything).Return(nil)
+	p := NewPaymentService(log, repo, provider, tables, orders, loyalty)
+
+	authorized, err := p.Authorize(authorizeRequest("order-6", "tok_visa"))
+	assert.Nil(t, err)
+
+	for i, amount := range []moneyModel.Money{moneyModel.New(10000, "USD"), moneyModel.New(20000, "NGN")} {
+		payload, signature, _ := provider.SignWebhook(payment.WebhookEvent{
+			Id: fmt.Sprintf("evt_bad_%d", i), Type: payment.EventCaptured, ProviderRef: authorized.ProviderRef,
+			Amount: amount,
+		})
+		assert.NotNil(t, p.HandleWebhook(payload, signature))
+	}
+	stillAuthorized, _ := p.GetPayment(authorized.Id)
+	assert.Equal(t, paymentModel.Authorized, stillAuthorized.Status)
+}
+
+func TestPaymentRefund_KeyScopedToPayment(t *testing.T) {
+	repo := newMemoryRepository()
+	tables := new(MockTableRepository)
+	tables.On("CloseByOrder", mock.Anything, mock.Anything).Return(nil)
+	orders, loyalty := new(MockOrderRepository), new(MockLoyaltyService)
+	orders.On("FindById", order.Id).Return(order, nil)
+	loyalty.On("Reverse", order, mock.Anything).Return(nil)
+	p := NewPaymentService(log, repo, payment.NewFakeProvider("whsec"), tables, orders, loyalty)
+
+	var ids []uuid.UUID
+	for _, key := range []string{"order-7", "order-8"} {
+		authorized, err := p.Authorize(authorizeRequest(key, "tok_visa"))
+		assert.Nil(t, err)
+		_, err = p.Capture(authorized.Id)
+		assert.Nil(t, err)
+		ids = append(ids, authorized.Id)
+	}
+
+	first, err := p.Refund(ids[0], paymentModel.RefundRequest{Amount: 10000, IdempotencyKey: "refund-me"})
+	assert.Nil(t, err)
+	second, err := p.Refund(ids[1], paymentModel.RefundRequest{Amount: 10000, IdempotencyKey: "refund-me"})
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.ProviderRef, second.ProviderRef)
+}
+
+func TestPaymentRefund_Concurrent(t *testing.T) {
+	repo := newMemoryRepository()
+	tables := new(MockTableRepository)
+	tables.On("CloseByOrder", mock.Anything, mock.Anything).Return(nil)
+	orders, loyalty := new(MockOrderRepository), new(MockLoyaltyService)
+	orders.On("FindById", order.Id).Return(order, nil)
+	loyalty.On("Reverse", order, mock.Anything).Return(nil)
+	p := NewPaymentService(log, repo, payment.NewFakeProvider("whsec"), tables, orders, loyalty)
+
+	authorized, err := p.Authorize(authorizeRequest("order-9", "tok_visa"))
+	assert.Nil(t, err)
+	_, err = p.Capture(authorized.Id)
+	assert.Nil(t, err)
+
+	// Both refunds start from the same captured payment; only one of them fits.
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, key := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			_, errs[i] = p.Refund(authorized.Id, paymentModel.RefundRequest{Amount: 6000, IdempotencyKey: key})
+		}(i, key)
+	}
+	wg.Wait()
+
+	assert.True(t, (errs[0] == nil) != (errs[1] == nil))
+	refunded, _ := p.GetPayment(authorized.Id)
+	assert.Equal(t, paymentModel.PartiallyRefunded, refunded.Status)
+	assert.Equal(t, moneyModel.New(6000, "NGN"), refunded.RefundedAmount)
+	assert.Len(t, repo.refunds, 1)
+}
+
+func TestPaymentRefund_ReversesLoyalty(t *testing.T) {
+	repo := newMemoryRepository()
+	tables := new(MockTableRepository)
+	tables.On("CloseByOrder", mock.Anything, mock.Anything).Return(nil)
+	orders, loyalty := new(MockOrderRepository), new(MockLoyaltyService)
+	orders.On("FindById", order.Id).Return(order, nil)
+	loyalty.On("Reverse", order, int64(0)).Return(nil)
+	loyalty.On("Reverse", order, int64(2000)).Return(errors.New("connection reset")).Once()
+	loyalty.On("Reverse", order, int64(2000)).Return(nil)
+	p := NewPaymentService(log, repo, payment.NewFakeProvider("whsec"), tables, orders, loyalty)
+
+	authorized, err := p.Authorize(authorizeRequest("order-10", "tok_visa"))
+	assert.Nil(t, err)
+	_, err = p.Capture(authorized.Id)
+	assert.Nil(t, err)
+
+	// An overpayment refund gives back money the order never kept, so no points are taken.
+	_, err = p.Refund(authorized.Id, paymentModel.RefundRequest{Amount: 3000, IdempotencyKey: paymentModel.OverpaidKey})
+	assert.Nil(t, err)
+	loyalty.AssertCalled(t, "Reverse", order, int64(0))
+
+	// A retry of a refund whose points were not taken back takes them back.
+	request := paymentModel.RefundRequest{Amount: 2000, IdempotencyKey: "r1"}
+	_, err = p.Refund(authorized.Id, request)
+	assert.NotNil(t, err)
+	_, err = p.Refund(authorized.Id, request)
+	assert.Nil(t, err)
+	loyalty.AssertNumberOfCalls(t, "Reverse", 3)
+	refunded, _ := p.GetPayment(authorized.Id)
+	assert.Equal(t, moneyModel.New(5000, "NGN"), refunded.RefundedAmount)
+}
@@ -0,0 +1,137 @@
+package pricingService
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/menuModel"
+	"rsm/entity/moneyModel"
+	"rsm/entity/promotionModel"
+	"rsm/entity/taxModel"
+	"rsm/entity/zoneModel"
+	"rsm/pricing"
+	"rsm/repository/taxRepo"
+	"rsm/service/menuService"
+	"rsm/service/promotionService"
+	"rsm/service/zoneService"
+	"time"
+)
+
+type ServiceInterface interface {
+	SetTaxRules(rules *taxModel.TaxRules) error
+	GetTaxRules(restaurantId uuid.UUID) (*taxModel.TaxRules, error)
+	Quote(userId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Breakdown, error)
+	// QuoteWithReward prices the cart like Quote, then takes the loyalty reward off what the
+	// promotions left.
+	QuoteWithReward(userId uuid.UUID, cart pricing.Cart, codes []string, reward promotionModel.Promotion) (*pricing.Breakdown, error)
+}
+
+type pricingService struct {
+	log        *logrus.Logger
+	taxRepo    taxRepo.RepoInterface
+	promotions promotionService.ServiceInterface
+	zones      zoneService.ServiceInterface
+	menus      menuService.ServiceInterface
+}
+
+func (p *pricingService) SetTaxRules(rules *taxModel.TaxRules) error {
+	err := rules.ValidateInput()
+	if err != nil {
+		p.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	rules.UpdatedAt = time.Now()
+	return p.taxRepo.Save(rules)
+}
+
+// GetTaxRules returns the restaurant's rules, or rules that charge no tax if none are set.
+func (p *pricingService) GetTaxRules(restaurantId uuid.UUID) (*taxModel.TaxRules, error) {
+	rules, err := p.taxRepo.FindByRestaurant(restaurantId)
+	if errors.Is(err, taxRepo.ErrNotFound) {
+		noTax := taxModel.NoTax(restaurantId)
+		return &noTax, nil
+	}
+	return rules, err
+}
+
+// Quote prices a cart for the user: promotions are applied first and tax is worked out on
+// the discounted lines. Delivery carts must fall inside one of the restaurant's zones and
+// meet its minimum order, and pay its delivery fee.
+func (p *pricingService) Quote(userId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Breakdown, error) {
+	return p.quote(userId, cart, codes, nil)
+}
+
+func (p *pricingService) QuoteWithReward(userId uuid.UUID, cart pricing.Cart, codes []string, reward promotionModel.Promotion) (*pricing.Breakdown, error) {
+	return p.quote(userId, cart, codes, &reward)
+}
+
+func (p *pricingService) quote(userId uuid.UUID, cart pricing.Cart, codes []string, reward *promotionModel.Promotion) (*pricing.Breakdown, error) {
+	cart, err := p.serve(cart, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var zone *zoneModel.Zone
+	if cart.Dropoff != nil {
+		zone, err = p.zones.Locate(cart.RestaurantId, *cart.Dropoff)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if zone != nil {
+		if zone.Currency != cart.Currency {
+			return nil, fmt.Errorf("delivery zone %v charges in %v, cart is in %v", zone.Name, zone.Currency, cart.Currency)
+		}
+		subtotal := cart.Subtotal()
+		if subtotal.Amount < zone.MinimumOrder {
+			return nil, fmt.Errorf("minimum order for %v is %v, cart is %v",
+				zone.Name, moneyModel.New(zone.MinimumOrder, zone.Currency), subtotal)
+		}
+	}
+
+	discounts, err := p.promotions.PriceCart(userId, cart, codes)
+	if err != nil {
+		return nil, err
+	}
+	if reward != nil {
+		stacked := pricing.Stack(cart, *discounts, *reward)
+		discounts = &stacked
+	}
+	rules, err := p.GetTaxRules(cart.RestaurantId)
+	if err != nil {
+		return nil, err
+	}
+	breakdown := pricing.Price(cart, *discounts, *rules)
+	if zone != nil {
+		breakdown.AddDeliveryFee(zone.DeliveryFee)
+	}
+	return &breakdown, nil
+}
+
+// serve checks every line is on a menu the restaurant serves at the time and prices it as that
+// menu does. Restaurants without named menus serve every item at the price in the cart.
+func (p *pricingService) serve(cart pricing.Cart, at time.Time) (pricing.Cart, error) {
+	active, err := p.menus.ActiveMenu(cart.RestaurantId, at)
+	if err != nil || !active.Scheduled {
+		return cart, err
+	}
+	lines := make([]pricing.Line, len(cart.Lines))
+	for i, l := range cart.Lines {
+		item, ok := active.Find(l.MenuId)
+		if !ok {
+			return cart, fmt.Errorf("menu item %d: %w", l.MenuId, menuModel.ErrNotServed)
+		}
+		price, err := moneyModel.Parse(item.Price, cart.Currency)
+		if err != nil {
+			return cart, err
+		}
+		l.Item, l.ItemType, l.UnitPrice = item.Item, item.ItemType, price
+		lines[i] = l
+	}
+	cart.Lines = lines
+	return cart, nil
+}
+
+func NewPricingService(log *logrus.Logger, repo taxRepo.RepoInterface, promotions promotionService.ServiceInterface, zones zoneService.ServiceInterface, menus menuService.ServiceInterface) ServiceInterface {
+	return &pricingService{log: log, taxRepo: repo, promotions: promotions, zones: zones, menus: menus}
+}
@@ -0,0 +1,331 @@
+package pricingService
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"rsm/entity/menuModel"
+	"rsm/entity/moneyModel"
+	"rsm/entity/promotionModel"
+	"rsm/entity/taxModel"
+	"rsm/entity/zoneModel"
+	"rsm/location/geoUtils"
+	"rsm/pricing"
+	"rsm/repository/taxRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockTaxRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaxRepository) Save(rules *taxModel.TaxRules) error {
+	args := m.Called(rules)
+	return args.Error(0)
+}
+
+func (m *MockTaxRepository) FindByRestaurant(restaurantId uuid.UUID) (*taxModel.TaxRules, error) {
+	args := m.Called(restaurantId)
+	rules, _ := args.Get(0).(*taxModel.TaxRules)
+	return rules, args.Error(1)
+}
+
+type MockPromotionService struct {
+	mock.Mock
+}
+
+func (m *MockPromotionService) CreatePromotion(promotion *promotionModel.Promotion) (*promotionModel.Promotion, error) {
+	args := m.Called(promotion)
+	return args.Get(0).(*promotionModel.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionService) PriceCart(userId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Discounts, error) {
+	args := m.Called(userId, cart, codes)
+	return args.Get(0).(*pricing.Discounts), args.Error(1)
+}
+
+func (m *MockPromotionService) Redeem(userId, orderId uuid.UUID, discounts pricing.Discounts) error {
+	args := m.Called(userId, orderId, discounts)
+	return args.Error(0)
+}
+
+type MockZoneService struct {
+	mock.Mock
+}
+
+func (m *MockZoneService) CreateZone(zone *zoneModel.Zone) error {
+	args := m.Called(zone)
+	return args.Error(0)
+}
+
+func (m *MockZoneService) UpdateZone(zone *zoneModel.Zone) error {
+	args := m.Called(zone)
+	return args.Error(0)
+}
+
+func (m *MockZoneService) DeleteZone(restaurantId, id uuid.UUID) error {
+	args := m.Called(restaurantId, id)
+	return args.Error(0)
+}
+
+func (m *MockZoneService) ListZones(restaurantId uuid.UUID) ([]zoneModel.Zone, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]zoneModel.Zone), args.Error(1)
+}
+
+func (m *MockZoneService) Locate(restaurantId uuid.UUID, p geoUtils.Point) (*zoneModel.Zone, error) {
+	args := m.Called(restaurantId, p)
+	zone, _ := args.Get(0).(*zoneModel.Zone)
+	return zone, args.Error(1)
+}
+
+type MockMenuService struct {
+	mock.Mock
+}
+
+func (m *MockMenuService) ListMenu(query menuModel.MenuQuery) ([]menuModel.MenuItem, error) {
+	args := m.Called(query)
+	return args.Get(0).([]menuModel.MenuItem), args.Error(1)
+}
+
+func (m *MockMenuService) GetItem(id int64) (*menuModel.MenuItem, error) {
+	args := m.Called(id)
+	item, _ := args.Get(0).(*menuModel.MenuItem)
+	return item, args.Error(1)
+}
+
+func (m *MockMenuService) SetDietaryInfo(restaurantId uuid.UUID, menuId int64, info menuModel.DietaryInfo) (*menuModel.MenuItem, error) {
+	args := m.Called(restaurantId, menuId, info)
+	item, _ := args.Get(0).(*menuModel.MenuItem)
+	return item, args.Error(1)
+}
+
+func (m *MockMenuService) CreateMenu(menu *menuModel.NamedMenu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockMenuService) UpdateMenu(menu *menuModel.NamedMenu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockMenuService) ListMenus(restaurantId uuid.UUID) ([]menuModel.NamedMenu, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]menuModel.NamedMenu), args.Error(1)
+}
+
+func (m *MockMenuService) SaveDraft(restaurantId, namedMenuId uuid.UUID, request menuModel.DraftRequest) (*menuModel.Version, error) {
+	args := m.Called(restaurantId, namedMenuId, request)
+	version, _ := args.Get(0).(*menuModel.Version)
+	return version, args.Error(1)
+}
+
+func (m *MockMenuService) Publish(restaurantId, versionId uuid.UUID, effectiveFrom time.Time) (*menuModel.Version, error) {
+	args := m.Called(restaurantId, versionId, effectiveFrom)
+	version, _ := args.Get(0).(*menuModel.Version)
+	return version, args.Error(1)
+}
+
+func (m *MockMenuService) ListVersions(restaurantId, namedMenuId uuid.UUID) ([]menuModel.Version, error) {
+	args := m.Called(restaurantId, namedMenuId)
+	return args.Get(0).([]menuModel.Version), args.Error(1)
+}
+
+func (m *MockMenuService) DiffVersions(restaurantId, fromId, toId uuid.UUID) (*menuModel.VersionDiff, error) {
+	args := m.Called(restaurantId, fromId, toId)
+	diff, _ := args.Get(0).(*menuModel.VersionDiff)
+	return diff, args.Error(1)
+}
+
+func (m *MockMenuService) ActiveMenu(restaurantId uuid.UUID, at time.Time) (*menuModel.ActiveMenu, error) {
+	args := m.Called(restaurantId, at)
+	active, _ := args.Get(0).(*menuModel.ActiveMenu)
+	return active, args.Error(1)
+}
+
+func (m *MockMenuService) Import(restaurantId uuid.UUID, format menuModel.Format, data []byte, dryRun bool) (*menuModel.ImportReport, error) {
+	args := m.Called(restaurantId, format, data, dryRun)
+	report, _ := args.Get(0).(*menuModel.ImportReport)
+	return report, args.Error(1)
+}
+
+func (m *MockMenuService) Export(restaurantId uuid.UUID, format menuModel.Format, w io.Writer) error {
+	args := m.Called(restaurantId, format, w)
+	return args.Error(0)
+}
+
+// unscheduled serves every item at the price in the cart.
+func unscheduled() *MockMenuService {
+	menus := new(MockMenuService)
+	menus.On("ActiveMenu", mock.Anything, mock.Anything).Return(&menuModel.ActiveMenu{}, nil)
+	return menus
+}
+
+func Test_pricingService_Quote(t *testing.T) {
+	userId, taxed, untaxed := uuid.New(), uuid.New(), uuid.New()
+	lines := []pricing.Line{{MenuId: 1, ItemType: "main", Quantity: 1, UnitPrice: moneyModel.New(2000, "NGN")}}
+	taxedCart := pricing.Cart{RestaurantId: taxed, Currency: "NGN", Lines: lines}
+	untaxedCart := pricing.Cart{RestaurantId: untaxed, Currency: "NGN", Lines: lines}
+	discounts := &pricing.Discounts{
+		Applied: []pricing.AppliedPromotion{{Lines: []pricing.LineDiscount{{Line: 0, Amount: moneyModel.New(500, "NGN")}}}},
+		Total:   moneyModel.New(500, "NGN"),
+	}
+
+	mockTax := new(MockTaxRepository)
+	mockTax.On("FindByRestaurant", taxed).Return(&taxModel.TaxRules{
+		RestaurantId: taxed, DefaultRate: 750, Rounding: taxModel.PerLine, Method: taxModel.HalfUp,
+	}, nil)
+	mockTax.On("FindByRestaurant", untaxed).Return(nil, taxRepo.ErrNotFound)
+
+	mockPromotions := new(MockPromotionService)
+	mockPromotions.On("PriceCart", userId, taxedCart, []string{"SAVE"}).Return(discounts, nil)
+	mockPromotions.On("PriceCart", userId, untaxedCart, []string(nil)).Return(&pricing.Discounts{}, nil)
+
+	tests := []struct {
+		name      string
+		cart      pricing.Cart
+		codes     []string
+		wantTax   int64
+		wantTotal int64
+	}{
+		{name: "discount then tax", cart: taxedCart, codes: []string{"SAVE"}, wantTax: 113, wantTotal: 1613},
+		{name: "no tax rules", cart: untaxedCart, wantTax: 0, wantTotal: 2000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPricingService(log, mockTax, mockPromotions, new(MockZoneService), unscheduled())
+			got, err := p.Quote(userId, tt.cart, tt.codes)
+			assert.Nil(t, err)
+			assert.Equal(t, moneyModel.New(tt.wantTax, "NGN"), got.Tax)
+			assert.Equal(t, moneyModel.New(tt.wantTotal, "NGN"), got.Total)
+		})
+	}
+}
+
+func Test_pricingService_QuoteDelivery(t *testing.T) {
+	userId, restaurantId := uuid.New(), uuid.New()
+	near := geoUtils.Point{Latitude: 6.45, Longitude: 3.40}
+	far := geoUtils.Point{Latitude: 7.40, Longitude: 3.90}
+	zone := &zoneModel.Zone{Id: uuid.New(), Name: "Island", DeliveryFee: 800, MinimumOrder: 3000, Currency: "NGN"}
+	cart := func(quantity int, dropoff *geoUtils.Point) pricing.Cart {
+		return pricing.Cart{RestaurantId: restaurantId, Currency: "NGN", Dropoff: dropoff, Lines: []pricing.Line{
+			{MenuId: 1, ItemType: "main", Quantity: quantity, UnitPrice: moneyModel.New(2000, "NGN")}}}
+	}
+
+	mockTax := new(MockTaxRepository)
+	mockTax.On("FindByRestaurant", restaurantId).Return(nil, taxRepo.ErrNotFound)
+	mockPromotions := new(MockPromotionService)
+	mockPromotions.On("PriceCart", userId, mock.Anything, []string(nil)).
+		Return(&pricing.Discounts{Total: moneyModel.Zero("NGN")}, nil)
+	mockZones := new(MockZoneService)
+	mockZones.On("Locate", restaurantId, near).Return(zone, nil)
+	mockZones.On("Locate", restaurantId, far).Return(nil, zoneModel.ErrOutside)
+
+	tests := []struct {
+		name      string
+		cart      pricing.Cart
+		wantFee   int64
+		wantTotal int64
+		wantErr   bool
+	}{
+		{name: "collection", cart: cart(1, nil), wantFee: 0, wantTotal: 2000},
+		{name: "inside zone", cart: cart(2, &near), wantFee: 800, wantTotal: 4800},
+		{name: "below zone minimum", cart: cart(1, &near), wantErr: true},
+		{name: "outside every zone", cart: cart(2, &far), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPricingService(log, mockTax, mockPromotions, mockZones, unscheduled())
+			got, err := p.Quote(userId, tt.cart, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Quote() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, moneyModel.New(tt.wantFee, "NGN"), got.DeliveryFee)
+				assert.Equal(t, moneyModel.New(tt.wantTotal, "NGN"), got.Total)
+			}
+		})
+	}
+	_, err := NewPricingService(log, mockTax, mockPromotions, mockZones, unscheduled()).Quote(userId, cart(2, &far), nil)
+	assert.ErrorIs(t, err, zoneModel.ErrOutside)
+}
+
+func Test_pricingService_QuoteScheduledMenu(t *testing.T) {
+	userId, restaurantId := uuid.New(), uuid.New()
+	active := &menuModel.ActiveMenu{RestaurantId: restaurantId, Scheduled: true, Sections: []menuModel.Section{
+		{Menu: menuModel.NamedMenu{Name: "Lunch"}, Version: menuModel.Version{Items: []menuModel.VersionItem{
+			{MenuId: 1, Item: "Jollof", ItemType: "main", Price: "25.00"}}}},
+	}}
+	cart := func(menuId int64) pricing.Cart {
+		return pricing.Cart{RestaurantId: restaurantId, Currency: "NGN", Lines: []pricing.Line{
+			{MenuId: menuId, Quantity: 2, UnitPrice: moneyModel.New(100, "NGN")}}}
+	}
+
+	mockTax := new(MockTaxRepository)
+	mockTax.On("FindByRestaurant", restaurantId).Return(nil, taxRepo.ErrNotFound)
+	mockPromotions := new(MockPromotionService)
+	mockPromotions.On("PriceCart", userId, mock.Anything, []string(nil)).
+		Return(&pricing.Discounts{Total: moneyModel.Zero("NGN")}, nil)
+	mockMenus := new(MockMenuService)
+	mockMenus.On("ActiveMenu", restaurantId, mock.Anything).Return(active, nil)
+	p := NewPricingService(log, mockTax, mockPromotions, new(MockZoneService), mockMenus)
+
+	got, err := p.Quote(userId, cart(1), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, moneyModel.New(5000, "NGN"), got.Total)
+	priced := mockPromotions.Calls[0].Arguments.Get(1).(pricing.Cart)
+	assert.Equal(t, "Jollof", priced.Lines[0].Item)
+	assert.Equal(t, moneyModel.New(2500, "NGN"), priced.Lines[0].UnitPrice)
+
+	_, err = p.Quote(userId, cart(2), nil)
+	assert.ErrorIs(t, err, menuModel.ErrNotServed)
+}
+
+func Test_pricingService_QuoteWithReward(t *testing.T) {
+	userId, restaurantId := uuid.New(), uuid.New()
+	cart := pricing.Cart{RestaurantId: restaurantId, Currency: "NGN", Lines: []pricing.Line{
+		{MenuId: 1, ItemType: "main", Quantity: 1, UnitPrice: moneyModel.New(2000, "NGN")}}}
+	discounts := &pricing.Discounts{
+		Applied: []pricing.AppliedPromotion{{Lines: []pricing.LineDiscount{{Line: 0, Amount: moneyModel.New(1500, "NGN")}}}},
+		Total:   moneyModel.New(1500, "NGN"),
+	}
+
+	mockTax := new(MockTaxRepository)
+	mockTax.On("FindByRestaurant", restaurantId).Return(nil, taxRepo.ErrNotFound)
+	mockPromotions := new(MockPromotionService)
+	mockPromotions.On("PriceCart", userId, cart, []string{"SAVE"}).Return(discounts, nil)
+	p := NewPricingService(log, mockTax, mockPromotions, new(MockZoneService), unscheduled())
+
+	reward := promotionModel.Promotion{Id: uuid.New(), Type: promotionModel.Fixed, Value: 1000, Currency: "NGN"}
+	got, err := p.QuoteWithReward(userId, cart, []string{"SAVE"}, reward)
+	assert.Nil(t, err)
+	assert.Len(t, got.Discounts.Applied, 2)
+	assert.Equal(t, moneyModel.New(2000, "NGN"), got.Discount)
+	assert.Equal(t, moneyModel.Zero("NGN"), got.Total)
+}
+
+func Test_pricingService_SetTaxRules(t *testing.T) {
+	mockTax := new(MockTaxRepository)
+	mockTax.On("Save", mock.Anything).Return(nil)
+	p := NewPricingService(log, mockTax, new(MockPromotionService), new(MockZoneService), unscheduled())
+
+	valid := taxModel.TaxRules{RestaurantId: uuid.New(), DefaultRate: 750, Rates: map[string]int64{"drink": 0},
+		Rounding: taxModel.PerTotal, Method: taxModel.HalfEven}
+	assert.Nil(t, p.SetTaxRules(&valid))
+
+	badRate := valid
+	badRate.Rates = map[string]int64{"drink": 20000}
+	assert.NotNil(t, p.SetTaxRules(&badRate))
+
+	badRounding := valid
+	badRounding.Rounding = "per_item"
+	assert.NotNil(t, p.SetTaxRules(&badRounding))
+	mockTax.AssertNumberOfCalls(t, "Save", 1)
+}
@@ -0,0 +1,119 @@
+package promotionService
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/promotionModel"
+	"rsm/pricing"
+	"rsm/repository/promotionRepo"
+	"strings"
+	"time"
+)
+
+type ServiceInterface interface {
+	CreatePromotion(promotion *promotionModel.Promotion) (*promotionModel.Promotion, error)
+	PriceCart(userId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Discounts, error)
+	Redeem(userId, orderId uuid.UUID, discounts pricing.Discounts) error
+}
+
+type promotionService struct {
+	log  *logrus.Logger
+	repo promotionRepo.RepoInterface
+}
+
+func (p *promotionService) CreatePromotion(promotion *promotionModel.Promotion) (*promotionModel.Promotion, error) {
+	promotion.Code = strings.ToUpper(promotion.Code)
+	promotion.Currency = strings.ToUpper(promotion.Currency)
+	err := promotion.ValidateInput()
+	if err != nil {
+		p.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	promotion.Id = uuid.New()
+	promotion.CreatedAt = time.Now()
+	return p.repo.Persist(promotion)
+}
+
+// PriceCart works out the discounts the user would get on the cart right now with the given
+// codes. Nothing is reserved; call Redeem when the order is placed. Automatic promotions the
+// user has used up are left out, but a code the user has used up is an error so they know
+// why it no longer applies.
+func (p *promotionService) PriceCart(userId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Discounts, error) {
+	err := cart.Validate()
+	if err != nil {
+		return nil, err
+	}
+	normalised := make([]string, len(codes))
+	for i, code := range codes {
+		normalised[i] = strings.ToUpper(strings.TrimSpace(code))
+	}
+
+	now := time.Now()
+	promotions, err := p.repo.FindApplicable(cart.RestaurantId, normalised, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range normalised {
+		if !hasCode(promotions, code) {
+			return nil, fmt.Errorf("promotion code %v is not valid", code)
+		}
+	}
+
+	ids := make([]uuid.UUID, len(promotions))
+	for i, promotion := range promotions {
+		ids[i] = promotion.Id
+	}
+	used, err := p.repo.CountUserRedemptions(userId, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	available := promotions[:0]
+	for _, promotion := range promotions {
+		if promotion.MaxPerUser == 0 || used[promotion.Id] < promotion.MaxPerUser {
+			available = append(available, promotion)
+			continue
+		}
+		if promotion.Code != "" {
+			return nil, fmt.Errorf("promotion code %v already used: %w", promotion.Code, promotionRepo.ErrLimitReached)
+		}
+	}
+
+	discounts := pricing.ApplyPromotions(cart, available, now)
+	return &discounts, nil
+}
+
+// Redeem records the promotions applied to an order. It fails without recording anything if
+// any promotion has since reached its global or per-user limit.
+func (p *promotionService) Redeem(userId, orderId uuid.UUID, discounts pricing.Discounts) error {
+	now := time.Now()
+	redemptions := make([]promotionModel.Redemption, 0, len(discounts.Applied))
+	for _, applied := range discounts.Applied {
+		redemptions = append(redemptions, promotionModel.Redemption{
+			PromotionId: applied.PromotionId,
+			UserId:      userId,
+			OrderId:     orderId,
+			Amount:      applied.Total.Amount,
+			Currency:    applied.Total.Currency,
+			CreatedAt:   now,
+		})
+	}
+	if len(redemptions) == 0 {
+		return nil
+	}
+	return p.repo.Redeem(redemptions)
+}
+
+func hasCode(promotions []promotionModel.Promotion, code string) bool {
+	for _, promotion := range promotions {
+		if promotion.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func NewPromotionService(log *logrus.Logger, repo promotionRepo.RepoInterface) ServiceInterface {
+	return &promotionService{log: log, repo: repo}
+}
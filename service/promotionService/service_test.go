@@ -0,0 +1,118 @@
+package promotionService
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/moneyModel"
+	"rsm/entity/promotionModel"
+	"rsm/pricing"
+	"rsm/repository/promotionRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Persist(promotion *promotionModel.Promotion) (*promotionModel.Promotion, error) {
+	args := m.Called(promotion)
+	return args.Get(0).(*promotionModel.Promotion), args.Error(1)
+}
+
+func (m *MockRepository) FindApplicable(restaurantId uuid.UUID, codes []string, at time.Time) ([]promotionModel.Promotion, error) {
+	args := m.Called(restaurantId, codes, at)
+	return args.Get(0).([]promotionModel.Promotion), args.Error(1)
+}
+
+func (m *MockRepository) CountUserRedemptions(userId uuid.UUID, promotionIds []uuid.UUID) (map[uuid.UUID]int, error) {
+	args := m.Called(userId, promotionIds)
+	return args.Get(0).(map[uuid.UUID]int), args.Error(1)
+}
+
+func (m *MockRepository) Redeem(redemptions []promotionModel.Redemption) error {
+	args := m.Called(redemptions)
+	return args.Error(0)
+}
+
+func promotion(code string, value int64, maxPerUser int) promotionModel.Promotion {
+	return promotionModel.Promotion{
+		Id: uuid.New(), Code: code, Name: code, Type: promotionModel.Fixed, Value: value, Currency: "NGN",
+		MaxPerUser: maxPerUser, Stackable: true, Active: true,
+		StartsAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(time.Hour),
+	}
+}
+
+func Test_promotionService_PriceCart(t *testing.T) {
+	userId, restaurantId := uuid.New(), uuid.New()
+	cart := pricing.Cart{RestaurantId: restaurantId, Currency: "NGN", Lines: []pricing.Line{
+		{MenuId: 1, ItemType: "main", Quantity: 1, UnitPrice: moneyModel.New(5000, "NGN")},
+	}}
+	automatic := promotion("", 500, 0)
+	welcome := promotion("WELCOME", 1000, 1)
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindApplicable", restaurantId, []string{"WELCOME"}, mock.Anything).
+		Return([]promotionModel.Promotion{automatic, welcome}, nil)
+	mockRepo.On("FindApplicable", restaurantId, []string{"NOPE"}, mock.Anything).
+		Return([]promotionModel.Promotion{automatic}, nil)
+	mockRepo.On("CountUserRedemptions", userId, []uuid.UUID{automatic.Id, welcome.Id}).
+		Return(map[uuid.UUID]int{}, nil).Once()
+	mockRepo.On("CountUserRedemptions", userId, []uuid.UUID{automatic.Id, welcome.Id}).
+		Return(map[uuid.UUID]int{welcome.Id: 1}, nil).Once()
+
+	p := NewPromotionService(log, mockRepo)
+
+	discounts, err := p.PriceCart(userId, cart, []string{" welcome"})
+	assert.Nil(t, err)
+	assert.Equal(t, moneyModel.New(1500, "NGN"), discounts.Total)
+
+	_, err = p.PriceCart(userId, cart, []string{"WELCOME"})
+	assert.ErrorIs(t, err, promotionRepo.ErrLimitReached)
+
+	_, err = p.PriceCart(userId, cart, []string{"nope"})
+	assert.NotNil(t, err)
+}
+
+func Test_promotionService_Redeem(t *testing.T) {
+	userId, orderId, promotionId := uuid.New(), uuid.New(), uuid.New()
+	discounts := pricing.Discounts{Applied: []pricing.AppliedPromotion{
+		{PromotionId: promotionId, Total: moneyModel.New(700, "NGN")},
+	}}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Redeem", mock.MatchedBy(func(r []promotionModel.Redemption) bool {
+		return len(r) == 1 && r[0].PromotionId == promotionId && r[0].OrderId == orderId && r[0].Amount == 700
+	})).Return(promotionRepo.ErrLimitReached)
+
+	p := NewPromotionService(log, mockRepo)
+	assert.Equal(t, promotionRepo.ErrLimitReached, p.Redeem(userId, orderId, discounts))
+	assert.Nil(t, p.Redeem(userId, orderId, pricing.Discounts{}))
+	mockRepo.AssertNumberOfCalls(t, "Redeem", 1)
+}
+
+func Test_promotionService_CreatePromotion(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("Persist", mock.Anything).Return(&promotionModel.Promotion{}, nil)
+	p := NewPromotionService(log, mockRepo)
+
+	valid := promotion("summer", 1500, 0)
+	valid.Type = promotionModel.Percentage
+	_, err := p.CreatePromotion(&valid)
+	assert.Nil(t, err)
+	assert.Equal(t, "SUMMER", valid.Code)
+
+	tooMuch := promotion("", 20000, 0)
+	tooMuch.Type = promotionModel.Percentage
+	_, err = p.CreatePromotion(&tooMuch)
+	assert.NotNil(t, err)
+
+	noItem := promotion("", 0, 0)
+	noItem.Type = promotionModel.FreeItem
+	_, err = p.CreatePromotion(&noItem)
+	assert.NotNil(t, err)
+}
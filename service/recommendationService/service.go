@@ -0,0 +1,84 @@
+package recommendationService
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/recommendationModel"
+	"rsm/recommend"
+	"rsm/repository/recommendationRepo"
+	"time"
+)
+
+type ServiceInterface interface {
+	// Refresh is the batch job: it recomputes every recommendation from the orders completed
+	// within recommendationModel.History of at, and returns how many users got some.
+	Refresh(at time.Time) (int, error)
+	// ForUser returns the user's top n recommendations, only at restaurantId when it is set.
+	// Users with fewer, or none yet, get popular items they have not ordered to make up the
+	// rest. Only items of active restaurants open right now are recommended.
+	ForUser(userId uuid.UUID, restaurantId *uuid.UUID, n int) ([]recommendationModel.Recommendation, error)
+}
+
+type recommendationService struct {
+	log  *logrus.Logger
+	repo recommendationRepo.RepoInterface
+}
+
+func (r *recommendationService) Refresh(at time.Time) (int, error) {
+	purchases, err := r.repo.FindPurchases(at.Add(-recommendationModel.History))
+	if err != nil {
+		return 0, err
+	}
+	items, err := r.repo.FindItems()
+	if err != nil {
+		return 0, err
+	}
+	batch := recommend.Build(purchases, items, recommendationModel.MaxStored)
+	err = r.repo.Replace(batch, at)
+	if err != nil {
+		return 0, err
+	}
+	r.log.Infof("Computed recommendations for %d users from %d order lines", len(batch.Users), len(purchases))
+	return len(batch.Users), nil
+}
+
+func (r *recommendationService) ForUser(userId uuid.UUID, restaurantId *uuid.UUID, n int) ([]recommendationModel.Recommendation, error) {
+	if n <= 0 {
+		n = recommendationModel.DefaultLimit
+	}
+	if n > recommendationModel.MaxStored {
+		n = recommendationModel.MaxStored
+	}
+	now := time.Now()
+	recommendations, err := r.repo.FindForUser(userId, restaurantId, now, n)
+	if err != nil {
+		return nil, err
+	}
+	if len(recommendations) == n {
+		return recommendations, nil
+	}
+
+	// Popular items the user was already recommended would be listed twice, so ask for
+	// enough to skip them all.
+	popular, err := r.repo.FindPopular(userId, restaurantId, now, n+len(recommendations))
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[int64]bool, len(recommendations))
+	for _, rec := range recommendations {
+		listed[rec.MenuId] = true
+	}
+	for _, rec := range popular {
+		if len(recommendations) == n {
+			break
+		}
+		if !listed[rec.MenuId] {
+			recommendations = append(recommendations, rec)
+		}
+	}
+	return recommendations, nil
+}
+
+func NewRecommendationService(log *logrus.Logger, repo recommendationRepo.RepoInterface) ServiceInterface {
+	return &recommendationService{log: log, repo: repo}
+}
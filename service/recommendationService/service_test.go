@@ -0,0 +1,128 @@
+package recommendationService
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/recommendationModel"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) FindPurchases(since time.Time) ([]recommendationModel.Purchase, error) {
+	args := m.Called(since)
+	return args.Get(0).([]recommendationModel.Purchase), args.Error(1)
+}
+
+func (m *MockRepository) FindItems() ([]recommendationModel.Item, error) {
+	args := m.Called()
+	return args.Get(0).([]recommendationModel.Item), args.Error(1)
+}
+
+func (m *MockRepository) Replace(batch recommendationModel.Batch, at time.Time) error {
+	args := m.Called(batch, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindForUser(userId uuid.UUID, restaurantId *uuid.UUID, at time.Time, limit int) ([]recommendationModel.Recommendation, error) {
+	args := m.Called(userId, restaurantId, at, limit)
+	return args.Get(0).([]recommendationModel.Recommendation), args.Error(1)
+}
+
+func (m *MockRepository) FindPopular(userId uuid.UUID, restaurantId *uuid.UUID, at time.Time, limit int) ([]recommendationModel.Recommendation, error) {
+	args := m.Called(userId, restaurantId, at, limit)
+	return args.Get(0).([]recommendationModel.Recommendation), args.Error(1)
+}
+
+func Test_recommendationService_Refresh(t *testing.T) {
+	at := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+	userId, restaurantId, orderId := uuid.New(), uuid.New(), uuid.New()
+	purchases := []recommendationModel.Purchase{
+		{UserId: userId, OrderId: orderId, RestaurantId: restaurantId, MenuId: 1, ItemType: "main"},
+	}
+	items := []recommendationModel.Item{
+		{MenuId: 1, RestaurantId: restaurantId, ItemType: "main"},
+		{MenuId: 2, RestaurantId: restaurantId, ItemType: "main"},
+	}
+	repo := new(MockRepository)
+	repo.On("FindPurchases", at.Add(-recommendationModel.History)).Return(purchases, nil)
+	repo.On("FindItems").Return(items, nil)
+	repo.On("Replace", mock.MatchedBy(func(batch recommendationModel.Batch) bool {
+		return len(batch.Users[userId]) == 1 && batch.Users[userId][0].MenuId == 2 && len(batch.Popular[restaurantId]) == 1
+	}), at).Return(nil)
+	r := NewRecommendationService(log, repo)
+
+	users, err := r.Refresh(at)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, users)
+	repo.AssertExpectations(t)
+
+	failing := new(MockRepository)
+	failing.On("FindPurchases", mock.Anything).Return([]recommendationModel.Purchase{}, errors.New("boom"))
+	_, err = NewRecommendationService(log, failing).Refresh(at)
+	assert.NotNil(t, err)
+	failing.AssertNotCalled(t, "Replace", mock.Anything, mock.Anything)
+}
+
+func Test_recommendationService_ForUser(t *testing.T) {
+	known, newcomer, restaurantId := uuid.New(), uuid.New(), uuid.New()
+	everywhere := (*uuid.UUID)(nil)
+	rec := func(menuId int64, reason recommendationModel.Reason) recommendationModel.Recommendation {
+		return recommendationModel.Recommendation{MenuId: menuId, Reason: reason}
+	}
+	repo := new(MockRepository)
+	repo.On("FindForUser", known, everywhere, mock.Anything, 2).Return([]recommendationModel.Recommendation{
+		rec(5, recommendationModel.OrderedTogether), rec(6, recommendationModel.MatchesTaste)}, nil)
+	repo.On("FindForUser", known, everywhere, mock.Anything, 4).Return([]recommendationModel.Recommendation{
+		rec(5, recommendationModel.OrderedTogether), rec(6, recommendationModel.MatchesTaste)}, nil)
+	repo.On("FindForUser", newcomer, everywhere, mock.Anything, recommendationModel.DefaultLimit).Return([]recommendationModel.Recommendation{}, nil)
+	repo.On("FindForUser", newcomer, everywhere, mock.Anything, recommendationModel.MaxStored).Return([]recommendationModel.Recommendation{}, nil)
+	repo.On("FindForUser", known, &restaurantId, mock.Anything, 3).Return([]recommendationModel.Recommendation{
+		rec(5, recommendationModel.OrderedTogether)}, nil)
+	repo.On("FindPopular", known, everywhere, mock.Anything, 6).Return([]recommendationModel.Recommendation{
+		rec(1, recommendationModel.Popular), rec(5, recommendationModel.Popular), rec(2, recommendationModel.Popular)}, nil)
+	repo.On("FindPopular", known, &restaurantId, mock.Anything, 4).Return([]recommendationModel.Recommendation{
+		rec(7, recommendationModel.Popular), rec(8, recommendationModel.Popular)}, nil)
+	repo.On("FindPopular", newcomer, everywhere, mock.Anything, mock.Anything).Return([]recommendationModel.Recommendation{
+		rec(1, recommendationModel.Popular)}, nil)
+	r := NewRecommendationService(log, repo)
+
+	menuIds := func(recommendations []recommendationModel.Recommendation) []int64 {
+		var ids []int64
+		for _, rec := range recommendations {
+			ids = append(ids, rec.MenuId)
+		}
+		return ids
+	}
+	tests := []struct {
+		name         string
+		userId       uuid.UUID
+		restaurantId *uuid.UUID
+		n            int
+		want         []int64
+	}{
+		{"enough of their own", known, nil, 2, []int64{5, 6}},
+		{"topped up with popular items not listed yet", known, nil, 4, []int64{5, 6, 1, 2}},
+		{"cold start", newcomer, nil, 0, []int64{1}},
+		{"capped", newcomer, nil, 500, []int64{1}},
+		{"at one restaurant", known, &restaurantId, 3, []int64{5, 7, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.ForUser(tt.userId, tt.restaurantId, tt.n)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, menuIds(got))
+		})
+	}
+	repo.AssertNotCalled(t, "FindPopular", known, everywhere, mock.Anything, 2)
+	repo.AssertCalled(t, "FindPopular", newcomer, everywhere, mock.Anything, recommendationModel.DefaultLimit)
+	repo.AssertCalled(t, "FindPopular", newcomer, everywhere, mock.Anything, recommendationModel.MaxStored)
+}
@@ -0,0 +1,107 @@
+package restaurantService
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/restaurantModel"
+	"rsm/location/geoUtils"
+	"rsm/repository/restaurantRepo"
+	"time"
+)
+
+type ServiceInterface interface {
+	// CreateRestaurant creates a restaurant owned by ownerId, the authenticated user; any
+	// owner given in the model is ignored.
+	CreateRestaurant(ownerId uuid.UUID, model *restaurantModel.RestaurantModel) (*restaurantModel.RestaurantModel, error)
+	GetRestaurant(id uuid.UUID) (*restaurantModel.RestaurantModel, error)
+	SetCoordinates(id uuid.UUID, point geoUtils.Point) error
+	// SetTimezone sets the IANA timezone the restaurant's opening hours are read in.
+	SetTimezone(id uuid.UUID, timezone string) error
+	SetOpeningHours(id uuid.UUID, hours []restaurantModel.OpeningHours) error
+	SearchNearby(request restaurantModel.NearbySearchRequest) ([]restaurantModel.NearbyRestaurant, error)
+}
+
+type restaurantService struct {
+	log  *logrus.Logger
+	repo restaurantRepo.RepoInterface
+}
+
+func (r *restaurantService) CreateRestaurant(ownerId uuid.UUID, model *restaurantModel.RestaurantModel) (*restaurantModel.RestaurantModel, error) {
+	model.OwnerId = ownerId
+	err := model.ValidateInput()
+	if err != nil {
+		r.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	model.CreatedAt = time.Now()
+	return r.repo.Persist(model)
+}
+
+func (r *restaurantService) GetRestaurant(id uuid.UUID) (*restaurantModel.RestaurantModel, error) {
+	return r.repo.FindById(id)
+}
+
+func (r *restaurantService) SetCoordinates(id uuid.UUID, point geoUtils.Point) error {
+	if point.Latitude < -90 || point.Latitude > 90 || point.Longitude < -180 || point.Longitude > 180 {
+		return fmt.Errorf("invalid coordinates")
+	}
+	return r.repo.UpdateCoordinates(id, point.Latitude, point.Longitude)
+}
+
+func (r *restaurantService) SetTimezone(id uuid.UUID, timezone string) error {
+	_, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "" {
+		return fmt.Errorf("invalid timezone")
+	}
+	return r.repo.UpdateTimezone(id, timezone)
+}
+
+func (r *restaurantService) SetOpeningHours(id uuid.UUID, hours []restaurantModel.OpeningHours) error {
+	err := restaurantModel.ValidateOpeningHours(hours)
+	if err != nil {
+		r.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("invalid opening hours")
+	}
+	return r.repo.ReplaceOpeningHours(id, hours)
+}
+
+// SearchNearby returns active restaurants ordered by distance. With a radius the search is
+// limited to that circle, otherwise it returns the nearest Limit restaurants. OpenNow is
+// evaluated against request.At, which defaults to the current time, on each restaurant's
+// own clock.
+func (r *restaurantService) SearchNearby(request restaurantModel.NearbySearchRequest) ([]restaurantModel.NearbyRestaurant, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		r.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+
+	query := restaurantModel.NearbyQuery{
+		Center:   geoUtils.Point{Latitude: request.Latitude, Longitude: request.Longitude},
+		RadiusKm: request.RadiusKm,
+		Cuisine:  request.Cuisine,
+		Limit:    request.Limit,
+		Offset:   request.Offset,
+	}
+	if query.Limit == 0 {
+		query.Limit = restaurantModel.DefaultSearchLimit
+	}
+	if request.RadiusKm > 0 {
+		box := geoUtils.BoundingBoxAround(query.Center, request.RadiusKm)
+		query.BoundingBox = &box
+	}
+	if request.OpenNow {
+		at := request.At
+		if at.IsZero() {
+			at = time.Now()
+		}
+		query.OpenAt = &at
+	}
+
+	return r.repo.FindNearby(query)
+}
+
+func NewRestaurantService(log *logrus.Logger, repo restaurantRepo.RepoInterface) ServiceInterface {
+	return &restaurantService{log: log, repo: repo}
+}
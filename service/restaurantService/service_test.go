@@ -0,0 +1,150 @@
+package restaurantService
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/restaurantModel"
+	"rsm/location/geoUtils"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Persist(restaurant *restaurantModel.RestaurantModel) (*restaurantModel.RestaurantModel, error) {
+	args := m.Called(restaurant)
+	return args.Get(0).(*restaurantModel.RestaurantModel), args.Error(1)
+}
+
+func (m *MockRepository) FindById(id uuid.UUID) (*restaurantModel.RestaurantModel, error) {
+	args := m.Called(id)
+	return args.Get(0).(*restaurantModel.RestaurantModel), args.Error(1)
+}
+
+func (m *MockRepository) UpdateImage(id uuid.UUID, previous, ref string) error {
+	args := m.Called(id, previous, ref)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateCoordinates(id uuid.UUID, latitude, longitude float64) error {
+	args := m.Called(id, latitude, longitude)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateTimezone(id uuid.UUID, timezone string) error {
+	args := m.Called(id, timezone)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ReplaceOpeningHours(id uuid.UUID, hours []restaurantModel.OpeningHours) error {
+	args := m.Called(id, hours)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindNearby(query restaurantModel.NearbyQuery) ([]restaurantModel.NearbyRestaurant, error) {
+	args := m.Called(query)
+	return args.Get(0).([]restaurantModel.NearbyRestaurant), args.Error(1)
+}
+
+func Test_restaurantService_SearchNearby(t *testing.T) {
+	center := geoUtils.Point{Latitude: 6.5244, Longitude: 3.3792}
+	at := time.Date(2022, time.July, 4, 13, 45, 0, 0, time.UTC)
+	box := geoUtils.BoundingBoxAround(center, 5)
+	found := []restaurantModel.NearbyRestaurant{{
+		Restaurant: restaurantModel.RestaurantModel{Id: uuid.New(), Name: "Mama Put", Cuisine: "nigerian"},
+		DistanceKm: 1.2,
+	}}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindNearby", restaurantModel.NearbyQuery{
+		Center: center, RadiusKm: 5, BoundingBox: &box, Cuisine: "nigerian",
+		OpenAt: &at, Limit: restaurantModel.DefaultSearchLimit,
+	}).Return(found, nil)
+	mockRepo.On("FindNearby", restaurantModel.NearbyQuery{
+		Center: center, Limit: 3, Offset: 3,
+	}).Return([]restaurantModel.NearbyRestaurant{}, nil)
+
+	tests := []struct {
+		name    string
+		request restaurantModel.NearbySearchRequest
+		want    []restaurantModel.NearbyRestaurant
+		wantErr bool
+	}{
+		{
+			name: "within radius, open and matching cuisine",
+			request: restaurantModel.NearbySearchRequest{
+				Latitude: center.Latitude, Longitude: center.Longitude, RadiusKm: 5,
+				Cuisine: "nigerian", OpenNow: true, At: at,
+			},
+			want: found,
+		}, {
+			name: "nearest N second page",
+			request: restaurantModel.NearbySearchRequest{
+				Latitude: center.Latitude, Longitude: center.Longitude, Limit: 3, Offset: 3,
+			},
+			want: []restaurantModel.NearbyRestaurant{},
+		}, {
+			name:    "invalid coordinates",
+			request: restaurantModel.NearbySearchRequest{Latitude: 120, Longitude: 3},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRestaurantService(log, mockRepo)
+			got, err := r.SearchNearby(tt.request)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_restaurantService_SetOpeningHours(t *testing.T) {
+	id := uuid.New()
+	hours := []restaurantModel.OpeningHours{{DayOfWeek: time.Monday, OpensAt: 480, ClosesAt: 1320}}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("ReplaceOpeningHours", id, hours).Return(nil)
+
+	r := NewRestaurantService(log, mockRepo)
+	assert.Nil(t, r.SetOpeningHours(id, hours))
+
+	err := r.SetOpeningHours(id, []restaurantModel.OpeningHours{{DayOfWeek: time.Monday, OpensAt: 600, ClosesAt: 300}})
+	assert.Equal(t, fmt.Errorf("invalid opening hours"), err)
+	mockRepo.AssertNumberOfCalls(t, "ReplaceOpeningHours", 1)
+}
+
+func Test_restaurantService_SetTimezone(t *testing.T) {
+	id := uuid.New()
+	mockRepo := new(MockRepository)
+	mockRepo.On("UpdateTimezone", id, "Africa/Lagos").Return(nil)
+
+	r := NewRestaurantService(log, mockRepo)
+	assert.Nil(t, r.SetTimezone(id, "Africa/Lagos"))
+	assert.NotNil(t, r.SetTimezone(id, "Mars/Olympus_Mons"))
+	assert.NotNil(t, r.SetTimezone(id, ""))
+	mockRepo.AssertNumberOfCalls(t, "UpdateTimezone", 1)
+}
+
+func Test_restaurantService_CreateRestaurant(t *testing.T) {
+	ownerId := uuid.New()
+	restaurant := &restaurantModel.RestaurantModel{Id: uuid.New(), OwnerId: uuid.New(), Name: "Mama Put"}
+	mockRepo := new(MockRepository)
+	mockRepo.On("Persist", restaurant).Return(restaurant, nil)
+
+	r := NewRestaurantService(log, mockRepo)
+	got, err := r.CreateRestaurant(ownerId, restaurant)
+	assert.Nil(t, err)
+	assert.Equal(t, ownerId, got.OwnerId)
+
+	_, err = r.CreateRestaurant(uuid.Nil, &restaurantModel.RestaurantModel{Id: uuid.New(), Name: "Mama Put"})
+	assert.NotNil(t, err)
+	mockRepo.AssertNumberOfCalls(t, "Persist", 1)
+}
@@ -0,0 +1,152 @@
+package reviewService
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/brandModel"
+	"rsm/entity/reviewModel"
+	"rsm/repository/reviewRepo"
+	"rsm/service/brandService"
+	"time"
+)
+
+type ServiceInterface interface {
+	SubmitReview(userId uuid.UUID, request reviewModel.ReviewRequest) (*reviewModel.Review, error)
+	ReplyToReview(userId, reviewId uuid.UUID, request reviewModel.ReplyRequest) error
+	ReportReview(reporterId, reviewId uuid.UUID, request reviewModel.ReportRequest) error
+	ModerateReview(reviewId uuid.UUID, status reviewModel.Status) error
+	GetRestaurantReviews(restaurantId uuid.UUID, limit, offset int) ([]reviewModel.Review, error)
+	GetMenuItemReviews(menuId int64, limit, offset int) ([]reviewModel.Review, error)
+	GetRatingSummary(restaurantId uuid.UUID, menuId *int64) (*reviewModel.RatingSummary, error)
+}
+
+type reviewService struct {
+	log    *logrus.Logger
+	repo   reviewRepo.RepoInterface
+	brands brandService.ServiceInterface
+}
+
+// SubmitReview publishes a review of a restaurant, or of a menu item when MenuId is set.
+// The user must have a completed order from that restaurant, containing the item if one is given.
+func (r *reviewService) SubmitReview(userId uuid.UUID, request reviewModel.ReviewRequest) (*reviewModel.Review, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		r.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+
+	eligible, err := r.repo.HasCompletedOrder(userId, request.OrderId, request.RestaurantId, request.MenuId)
+	if err != nil {
+		return nil, err
+	}
+	if !eligible {
+		return nil, fmt.Errorf("reviews require a completed order")
+	}
+
+	now := time.Now()
+	review := reviewModel.Review{
+		Id:           uuid.New(),
+		UserId:       userId,
+		OrderId:      request.OrderId,
+		RestaurantId: request.RestaurantId,
+		MenuId:       request.MenuId,
+		Rating:       request.Rating,
+		Comment:      request.Comment,
+		Status:       reviewModel.Published,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	return r.repo.Persist(&review)
+}
+
+// ReplyToReview answers a review on behalf of the restaurant. The restaurant's owner and its
+// brand's managers may reply; staff may not.
+func (r *reviewService) ReplyToReview(userId, reviewId uuid.UUID, request reviewModel.ReplyRequest) error {
+	err := request.ValidateInput()
+	if err != nil {
+		r.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+
+	review, err := r.repo.FindById(reviewId)
+	if err != nil {
+		return err
+	}
+	role, err := r.brands.RoleAt(userId, review.RestaurantId)
+	if err != nil {
+		return err
+	}
+	if !role.AtLeast(brandModel.Manager) {
+		return brandModel.ErrForbidden
+	}
+
+	return r.repo.SaveReply(reviewId, reviewModel.Reply{OwnerId: userId, Body: request.Body, CreatedAt: time.Now()})
+}
+
+// ReportReview records a report; once FlagThreshold users have reported a published review
+// since it was last moderated, it is flagged and hidden until a moderator resolves it. A
+// repeated report changes nothing.
+func (r *reviewService) ReportReview(reporterId, reviewId uuid.UUID, request reviewModel.ReportRequest) error {
+	err := request.ValidateInput()
+	if err != nil {
+		r.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+
+	review, err := r.repo.FindById(reviewId)
+	if err != nil {
+		return err
+	}
+	if review.UserId == reporterId {
+		return fmt.Errorf("cannot report your own review")
+	}
+
+	count, err := r.repo.AddReport(reviewModel.Report{
+		ReviewId:   reviewId,
+		ReporterId: reporterId,
+		Reason:     request.Reason,
+		Note:       request.Note,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if count >= reviewModel.FlagThreshold && review.Status == reviewModel.Published {
+		return r.repo.UpdateStatus(reviewId, reviewModel.Published, reviewModel.Flagged)
+	}
+	return nil
+}
+
+func (r *reviewService) ModerateReview(reviewId uuid.UUID, status reviewModel.Status) error {
+	review, err := r.repo.FindById(reviewId)
+	if err != nil {
+		return err
+	}
+	if !reviewModel.CanTransition(review.Status, status) {
+		return fmt.Errorf("cannot move review from %v to %v", review.Status, status)
+	}
+	return r.repo.UpdateStatus(reviewId, review.Status, status)
+}
+
+func (r *reviewService) GetRestaurantReviews(restaurantId uuid.UUID, limit, offset int) ([]reviewModel.Review, error) {
+	if limit <= 0 {
+		limit = reviewModel.DefaultLimit
+	}
+	return r.repo.FindByRestaurant(restaurantId, limit, offset)
+}
+
+func (r *reviewService) GetMenuItemReviews(menuId int64, limit, offset int) ([]reviewModel.Review, error) {
+	if limit <= 0 {
+		limit = reviewModel.DefaultLimit
+	}
+	return r.repo.FindByMenuItem(menuId, limit, offset)
+}
+
+func (r *reviewService) GetRatingSummary(restaurantId uuid.UUID, menuId *int64) (*reviewModel.RatingSummary, error) {
+	return r.repo.FindRatingSummary(restaurantId, menuId)
+}
+
+func NewReviewService(log *logrus.Logger, repo reviewRepo.RepoInterface, brands brandService.ServiceInterface) ServiceInterface {
+	return &reviewService{log: log, repo: repo, brands: brands}
+}
@@ -0,0 +1,232 @@
+package reviewService
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/brandModel"
+	"rsm/entity/reviewModel"
+	"testing"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) HasCompletedOrder(userId, orderId, restaurantId uuid.UUID, menuId *int64) (bool, error) {
+	args := m.Called(userId, orderId, restaurantId, menuId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) Persist(review *reviewModel.Review) (*reviewModel.Review, error) {
+	args := m.Called(review)
+	return args.Get(0).(*reviewModel.Review), args.Error(1)
+}
+
+func (m *MockRepository) FindById(id uuid.UUID) (*reviewModel.Review, error) {
+	args := m.Called(id)
+	return args.Get(0).(*reviewModel.Review), args.Error(1)
+}
+
+func (m *MockRepository) FindByRestaurant(restaurantId uuid.UUID, limit, offset int) ([]reviewModel.Review, error) {
+	args := m.Called(restaurantId, limit, offset)
+	return args.Get(0).([]reviewModel.Review), args.Error(1)
+}
+
+func (m *MockRepository) FindByMenuItem(menuId int64, limit, offset int) ([]reviewModel.Review, error) {
+	args := m.Called(menuId, limit, offset)
+	return args.Get(0).([]reviewModel.Review), args.Error(1)
+}
+
+func (m *MockRepository) SaveReply(reviewId uuid.UUID, reply reviewModel.Reply) error {
+	args := m.Called(reviewId, reply)
+	return args.Error(0)
+}
+
+func (m *MockRepository) AddReport(report reviewModel.Report) (int, error) {
+	args := m.Called(report)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) UpdateStatus(id uuid.UUID, from, to reviewModel.Status) error {
+	args := m.Called(id, from, to)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindRatingSummary(restaurantId uuid.UUID, menuId *int64) (*reviewModel.RatingSummary, error) {
+	args := m.Called(restaurantId, menuId)
+	return args.Get(0).(*reviewModel.RatingSummary), args.Error(1)
+}
+
+type MockBrandService struct {
+	mock.Mock
+}
+
+func (m *MockBrandService) CreateBrand(ownerId uuid.UUID, brand *brandModel.Brand) error {
+	args := m.Called(ownerId, brand)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) AddLocation(userId, brandId, restaurantId uuid.UUID) error {
+	args := m.Called(userId, brandId, restaurantId)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) SetMember(userId uuid.UUID, member *brandModel.Member) error {
+	args := m.Called(userId, member)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) RemoveMember(userId, brandId, memberId uuid.UUID) error {
+	args := m.Called(userId, brandId, memberId)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) ListMembers(userId, brandId uuid.UUID) ([]brandModel.Member, error) {
+	args := m.Called(userId, brandId)
+	return args.Get(0).([]brandModel.Member), args.Error(1)
+}
+
+func (m *MockBrandService) RoleAt(userId, restaurantId uuid.UUID) (brandModel.Role, error) {
+	args := m.Called(userId, restaurantId)
+	return args.Get(0).(brandModel.Role), args.Error(1)
+}
+
+func (m *MockBrandService) SaveMasterItem(userId uuid.UUID, item *brandModel.MasterItem) error {
+	args := m.Called(userId, item)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) DeleteMasterItem(userId, brandId, id uuid.UUID) error {
+	args := m.Called(userId, brandId, id)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) SetOverride(userId uuid.UUID, override *brandModel.Override) error {
+	args := m.Called(userId, override)
+	return args.Error(0)
+}
+
+func (m *MockBrandService) LocationMenu(restaurantId uuid.UUID) ([]brandModel.LocationItem, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]brandModel.LocationItem), args.Error(1)
+}
+
+func Test_reviewService_SubmitReview(t *testing.T) {
+	userId, orderId, restaurantId := uuid.New(), uuid.New(), uuid.New()
+	menuId := int64(12)
+	otherOrderId := uuid.New()
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("HasCompletedOrder", userId, orderId, restaurantId, &menuId).Return(true, nil)
+	mockRepo.On("HasCompletedOrder", userId, otherOrderId, restaurantId, (*int64)(nil)).Return(false, nil)
+	mockRepo.On("Persist", mock.AnythingOfType("*reviewModel.Review")).Return(&reviewModel.Review{}, nil).
+		Run(func(args mock.Arguments) {
+			review := args.Get(0).(*reviewModel.Review)
+			assert.Equal(t, reviewModel.Published, review.Status)
+			assert.Equal(t, &menuId, review.MenuId)
+			assert.Equal(t, 4, review.Rating)
+		})
+
+	tests := []struct {
+		name    string
+		request reviewModel.ReviewRequest
+		err     error
+	}{
+		{
+			name:    "item from a completed order",
+			request: reviewModel.ReviewRequest{OrderId: orderId, RestaurantId: restaurantId, MenuId: &menuId, Rating: 4},
+		}, {
+			name:    "order not completed",
+			request: reviewModel.ReviewRequest{OrderId: otherOrderId, RestaurantId: restaurantId, Rating: 2},
+			err:     fmt.Errorf("reviews require a completed order"),
+		}, {
+			name:    "rating out of range",
+			request: reviewModel.ReviewRequest{OrderId: orderId, RestaurantId: restaurantId, Rating: 9},
+			err:     fmt.Errorf("something went wrong while validation"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReviewService(log, mockRepo, new(MockBrandService))
+			_, err := r.SubmitReview(userId, tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+	mockRepo.AssertNumberOfCalls(t, "Persist", 1)
+}
+
+func Test_reviewService_ReplyToReview(t *testing.T) {
+	ownerId, managerId, staffId, reviewId, restaurantId := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	stranger := uuid.New()
+
+	mockRepo := new(MockRepository)
+	mockBrands := new(MockBrandService)
+	mockRepo.On("FindById", reviewId).Return(&reviewModel.Review{Id: reviewId, RestaurantId: restaurantId}, nil)
+	mockBrands.On("RoleAt", ownerId, restaurantId).Return(brandModel.Owner, nil)
+	mockBrands.On("RoleAt", managerId, restaurantId).Return(brandModel.Manager, nil)
+	mockBrands.On("RoleAt", staffId, restaurantId).Return(brandModel.Staff, nil)
+	mockBrands.On("RoleAt", stranger, restaurantId).Return(brandModel.Role(""), brandModel.ErrForbidden)
+	mockRepo.On("SaveReply", reviewId, mock.AnythingOfType("reviewModel.Reply")).Return(nil)
+
+	r := NewReviewService(log, mockRepo, mockBrands)
+
+	assert.Nil(t, r.ReplyToReview(ownerId, reviewId, reviewModel.ReplyRequest{Body: "Thanks for visiting!"}))
+	assert.Nil(t, r.ReplyToReview(managerId, reviewId, reviewModel.ReplyRequest{Body: "Sorry about the wait."}))
+
+	err := r.ReplyToReview(staffId, reviewId, reviewModel.ReplyRequest{Body: "Come back soon"})
+	assert.Equal(t, brandModel.ErrForbidden, err)
+	err = r.ReplyToReview(stranger, reviewId, reviewModel.ReplyRequest{Body: "Not my restaurant"})
+	assert.Equal(t, brandModel.ErrForbidden, err)
+	mockRepo.AssertNumberOfCalls(t, "SaveReply", 2)
+}
+
+func Test_reviewService_ReportReview(t *testing.T) {
+	authorId := uuid.New()
+	published, alreadyFlagged, reinstated := uuid.New(), uuid.New(), uuid.New()
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindById", published).Return(&reviewModel.Review{Id: published, UserId: authorId,
+		Status: reviewModel.Published}, nil)
+	mockRepo.On("FindById", alreadyFlagged).Return(&reviewModel.Review{Id: alreadyFlagged, UserId: authorId,
+		Status: reviewModel.Flagged}, nil)
+	mockRepo.On("FindById", reinstated).Return(&reviewModel.Review{Id: reinstated, UserId: authorId,
+		Status: reviewModel.Published}, nil)
+	mockRepo.On("AddReport", mock.MatchedBy(func(r reviewModel.Report) bool { return r.ReviewId == published })).
+		Return(reviewModel.FlagThreshold, nil)
+	mockRepo.On("AddReport", mock.MatchedBy(func(r reviewModel.Report) bool { return r.ReviewId == alreadyFlagged })).
+		Return(reviewModel.FlagThreshold+1, nil)
+	// A repeat report is not recorded again, so it cannot flag a reinstated review.
+	mockRepo.On("AddReport", mock.MatchedBy(func(r reviewModel.Report) bool { return r.ReviewId == reinstated })).
+		Return(0, nil)
+	mockRepo.On("UpdateStatus", published, reviewModel.Published, reviewModel.Flagged).Return(nil)
+
+	r := NewReviewService(log, mockRepo, new(MockBrandService))
+	request := reviewModel.ReportRequest{Reason: "spam"}
+
+	assert.Nil(t, r.ReportReview(uuid.New(), published, request))
+	assert.Nil(t, r.ReportReview(uuid.New(), alreadyFlagged, request))
+	assert.Nil(t, r.ReportReview(uuid.New(), reinstated, request))
+	assert.Equal(t, fmt.Errorf("cannot report your own review"), r.ReportReview(authorId, published, request))
+	assert.NotNil(t, r.ReportReview(uuid.New(), published, reviewModel.ReportRequest{Reason: "boring"}))
+
+	mockRepo.AssertNumberOfCalls(t, "UpdateStatus", 1)
+}
+
+func Test_reviewService_ModerateReview(t *testing.T) {
+	flagged, rejected := uuid.New(), uuid.New()
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindById", flagged).Return(&reviewModel.Review{Id: flagged, Status: reviewModel.Flagged}, nil)
+	mockRepo.On("FindById", rejected).Return(&reviewModel.Review{Id: rejected, Status: reviewModel.Rejected}, nil)
+	mockRepo.On("UpdateStatus", flagged, reviewModel.Flagged, reviewModel.Published).Return(nil)
+
+	r := NewReviewService(log, mockRepo, new(MockBrandService))
+
+	assert.Nil(t, r.ModerateReview(flagged, reviewModel.Published))
+	assert.NotNil(t, r.ModerateReview(rejected, reviewModel.Flagged))
+}
@@ -0,0 +1,44 @@
+package searchService
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/searchModel"
+	"rsm/repository/searchRepo"
+	"strings"
+)
+
+type ServiceInterface interface {
+	Search(request searchModel.SearchRequest) ([]searchModel.SearchHit, error)
+}
+
+type searchService struct {
+	log  *logrus.Logger
+	repo searchRepo.RepoInterface
+}
+
+// Search returns restaurants and menu items matching the query, best match first.
+func (s *searchService) Search(request searchModel.SearchRequest) ([]searchModel.SearchHit, error) {
+	request.Query = strings.Join(strings.Fields(request.Query), " ")
+	err := request.ValidateInput()
+	if err != nil {
+		s.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	if request.Limit == 0 {
+		request.Limit = searchModel.DefaultLimit
+	}
+
+	hits, err := s.repo.Search(request)
+	if err != nil {
+		return nil, err
+	}
+	if hits == nil {
+		hits = []searchModel.SearchHit{}
+	}
+	return hits, nil
+}
+
+func NewSearchService(log *logrus.Logger, repo searchRepo.RepoInterface) ServiceInterface {
+	return &searchService{log: log, repo: repo}
+}
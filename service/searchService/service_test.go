@@ -0,0 +1,79 @@
+package searchService
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/searchModel"
+	"testing"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Search(request searchModel.SearchRequest) ([]searchModel.SearchHit, error) {
+	args := m.Called(request)
+	results := args.Get(0)
+	if results == nil {
+		return nil, args.Error(1)
+	}
+	return results.([]searchModel.SearchHit), args.Error(1)
+}
+
+func Test_searchService_Search(t *testing.T) {
+	restaurantId := uuid.New()
+	hits := []searchModel.SearchHit{
+		{Kind: searchModel.MenuItemHit, RestaurantId: restaurantId, MenuId: 4, Title: "Spicy Ramen",
+			Snippet: "<mark>Spicy</mark> <mark>Ramen</mark> noodles", Price: "4500", Rank: 1.4},
+		{Kind: searchModel.RestaurantHit, RestaurantId: restaurantId, Title: "Ramen House", Rank: 0.6},
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Search", searchModel.SearchRequest{Query: "spicy ramen", Limit: searchModel.DefaultLimit}).
+		Return(hits, nil)
+	mockRepo.On("Search", searchModel.SearchRequest{Query: "sushi", Limit: 5}).Return(nil, nil)
+	mockRepo.On("Search", searchModel.SearchRequest{Query: "broken", Limit: searchModel.DefaultLimit}).
+		Return(nil, fmt.Errorf("connection reset"))
+
+	tests := []struct {
+		name    string
+		request searchModel.SearchRequest
+		want    []searchModel.SearchHit
+		wantErr bool
+	}{
+		{
+			name:    "normalises whitespace and applies default limit",
+			request: searchModel.SearchRequest{Query: "  spicy   ramen "},
+			want:    hits,
+		}, {
+			name:    "no hits is an empty list",
+			request: searchModel.SearchRequest{Query: "sushi", Limit: 5},
+			want:    []searchModel.SearchHit{},
+		}, {
+			name:    "query too short",
+			request: searchModel.SearchRequest{Query: " a "},
+			wantErr: true,
+		}, {
+			name:    "unknown kind",
+			request: searchModel.SearchRequest{Query: "ramen", Kinds: []searchModel.HitKind{"drink"}},
+			wantErr: true,
+		}, {
+			name:    "repository error",
+			request: searchModel.SearchRequest{Query: "broken"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSearchService(log, mockRepo)
+			got, err := s.Search(tt.request)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
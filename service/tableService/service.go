@@ -0,0 +1,394 @@
+package tableService
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"io"
+	"rsm/entity/menuModel"
+	"rsm/entity/moneyModel"
+	"rsm/entity/orderModel"
+	"rsm/entity/tableModel"
+	"rsm/pricing"
+	"rsm/qrcode"
+	"rsm/repository/menuRepo"
+	"rsm/repository/orderRepo"
+	"rsm/repository/tableRepo"
+	"rsm/service/loyaltyService"
+	"rsm/service/pricingService"
+	"time"
+)
+
+// addAttempts is how many times adding items is priced again when another diner added to
+// the order at the same moment.
+const addAttempts = 3
+
+type ServiceInterface interface {
+	CreateTable(restaurantId uuid.UUID, request tableModel.TableRequest) (*tableModel.Table, error)
+	UpdateTable(restaurantId, tableId uuid.UUID, request tableModel.TableRequest) (*tableModel.Table, error)
+	// RotateToken gives the table a new token, so codes printed with the old one stop working.
+	RotateToken(restaurantId, tableId uuid.UUID) (*tableModel.Table, error)
+	ListTables(restaurantId uuid.UUID) ([]tableModel.Table, error)
+	// QRCode writes the table's code as a PNG, each module scale pixels square.
+	QRCode(restaurantId, tableId uuid.UUID, w io.Writer, scale int) error
+
+	// Join is what scanning the code does: it adds the diner, signed in when userId is set,
+	// to the table's open session or opens one.
+	Join(token string, userId *uuid.UUID, request tableModel.JoinRequest) (*tableModel.Session, *tableModel.Diner, error)
+	GetSession(sessionId, dinerId uuid.UUID) (*tableModel.Session, []tableModel.Item, error)
+	AddItems(sessionId, dinerId uuid.UUID, request tableModel.AddItemsRequest) ([]tableModel.Item, error)
+	// UseReward spends the diner's points on a loyalty reward for the table's order, and
+	// returns the order priced with it.
+	UseReward(sessionId, dinerId, rewardId uuid.UUID) (*pricing.Breakdown, error)
+	// Close ends a session whose bill was paid some other way, such as in cash. Sessions
+	// close by themselves when their order is paid, in full or by settling its split bill.
+	Close(restaurantId, sessionId uuid.UUID) error
+}
+
+type tableService struct {
+	log     *logrus.Logger
+	repo    tableRepo.RepoInterface
+	menus   menuRepo.RepoInterface
+	orders  orderRepo.RepoInterface
+	pricing pricingService.ServiceInterface
+	loyalty loyaltyService.ServiceInterface
+	baseURL string
+}
+
+func (t *tableService) CreateTable(restaurantId uuid.UUID, request tableModel.TableRequest) (*tableModel.Table, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		t.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	token, err := tableModel.NewToken()
+	if err != nil {
+		return nil, err
+	}
+	table := &tableModel.Table{
+		Id:           uuid.New(),
+		RestaurantId: restaurantId,
+		Label:        request.Label,
+		Seats:        request.Seats,
+		Currency:     request.Currency,
+		Token:        token,
+		Active:       request.Active,
+		CreatedAt:    time.Now(),
+	}
+	err = t.repo.Persist(table)
+	if err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func (t *tableService) UpdateTable(restaurantId, tableId uuid.UUID, request tableModel.TableRequest) (*tableModel.Table, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		t.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	table, err := t.ownTable(restaurantId, tableId)
+	if err != nil {
+		return nil, err
+	}
+	table.Label, table.Seats, table.Currency, table.Active = request.Label, request.Seats, request.Currency, request.Active
+	err = t.repo.Update(table)
+	if err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func (t *tableService) RotateToken(restaurantId, tableId uuid.UUID) (*tableModel.Table, error) {
+	table, err := t.ownTable(restaurantId, tableId)
+	if err != nil {
+		return nil, err
+	}
+	table.Token, err = tableModel.NewToken()
+	if err != nil {
+		return nil, err
+	}
+	err = t.repo.Update(table)
+	if err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func (t *tableService) ListTables(restaurantId uuid.UUID) ([]tableModel.Table, error) {
+	return t.repo.FindByRestaurant(restaurantId)
+}
+
+func (t *tableService) QRCode(restaurantId, tableId uuid.UUID, w io.Writer, scale int) error {
+	table, err := t.ownTable(restaurantId, tableId)
+	if err != nil {
+		return err
+	}
+	code, err := qrcode.Encode(tableModel.Payload(t.baseURL, table.Token))
+	if err != nil {
+		return err
+	}
+	return code.PNG(w, scale)
+}
+
+// Join treats inactive tables as unknown, so a code on a table taken out of service reads as
+// not found.
+func (t *tableService) Join(token string, userId *uuid.UUID, request tableModel.JoinRequest) (*tableModel.Session, *tableModel.Diner, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		t.log.Errorf("Validation Error: %v", err)
+		return nil, nil, fmt.Errorf("something went wrong while validation")
+	}
+	table, err := t.repo.FindByToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !table.Active {
+		return nil, nil, tableRepo.ErrNotFound
+	}
+	now := time.Now()
+	session := &tableModel.Session{
+		Id:           uuid.New(),
+		TableId:      table.Id,
+		RestaurantId: table.RestaurantId,
+		Status:       tableModel.Open,
+		OpenedAt:     now,
+	}
+	diner := &tableModel.Diner{Id: uuid.New(), UserId: userId, Name: request.Name, JoinedAt: now}
+	session, err = t.repo.Join(session, diner)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, diner, nil
+}
+
+func (t *tableService) GetSession(sessionId, dinerId uuid.UUID) (*tableModel.Session, []tableModel.Item, error) {
+	session, _, err := t.dinerSession(sessionId, dinerId)
+	if err != nil {
+		return nil, nil, err
+	}
+	items, err := t.repo.FindItems(sessionId)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, items, nil
+}
+
+// AddItems puts the diner's lines on the table's order. The whole order is priced again each
+// time, with the restaurant's tax, service charge and scheduled menus, to keep its total
+// right; the first items create the order, owned by the diner if they are signed in. Once
+// the kitchen has accepted the order or its bill has been split, it fails with
+// tableRepo.ErrOrderLocked.
+func (t *tableService) AddItems(sessionId, dinerId uuid.UUID, request tableModel.AddItemsRequest) ([]tableModel.Item, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		t.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	session, diner, err := t.dinerSession(sessionId, dinerId)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != tableModel.Open {
+		return nil, tableRepo.ErrSessionClosed
+	}
+	table, err := t.repo.FindById(session.TableId)
+	if err != nil {
+		return nil, err
+	}
+	menu, err := t.menus.FindByRestaurant(menuModel.MenuQuery{RestaurantId: session.RestaurantId})
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int64]menuModel.MenuItem, len(menu))
+	for _, m := range menu {
+		byId[m.Id] = m
+	}
+	var added []pricing.Line
+	for _, l := range request.Lines {
+		m, ok := byId[l.MenuId]
+		if !ok {
+			return nil, fmt.Errorf("menu item %d: %w", l.MenuId, menuRepo.ErrNotFound)
+		}
+		price, err := moneyModel.Parse(m.Price, table.Currency)
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, pricing.Line{MenuId: m.Id, Item: m.Item, ItemType: m.ItemType, Quantity: l.Quantity,
+			UnitPrice: price})
+	}
+
+	userId := uuid.Nil
+	if diner.UserId != nil {
+		userId = *diner.UserId
+	}
+	for attempt := 1; ; attempt++ {
+		items, _, err := t.add(session, table, userId, diner.Id, added)
+		if errors.Is(err, tableRepo.ErrStaleItems) && attempt < addAttempts {
+			continue
+		}
+		return items, err
+	}
+}
+
+// UseReward is for the diner who started the order signed in; the points are theirs. The
+// reward stays on the order as more is added to it. If the order cannot be priced with it,
+// because the kitchen accepted it meanwhile, the points are given back.
+func (t *tableService) UseReward(sessionId, dinerId, rewardId uuid.UUID) (*pricing.Breakdown, error) {
+	session, diner, err := t.dinerSession(sessionId, dinerId)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != tableModel.Open {
+		return nil, tableRepo.ErrSessionClosed
+	}
+	if session.OrderId == nil {
+		return nil, fmt.Errorf("nothing has been ordered at the table yet")
+	}
+	order, err := t.orders.FindById(*session.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if diner.UserId == nil || *diner.UserId != order.UserId {
+		return nil, fmt.Errorf("only the diner who started the order can use a reward on it")
+	}
+	table, err := t.repo.FindById(session.TableId)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := t.repo.FindItems(session.Id)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := t.loyalty.QuoteReward(order.UserId, rewardId, t.cart(session, table, existing), nil)
+	if err != nil {
+		return nil, err
+	}
+	_, err = t.loyalty.Redeem(order, rewardId, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		_, quote, err = t.add(session, table, order.UserId, diner.Id, nil)
+		if errors.Is(err, tableRepo.ErrStaleItems) && attempt < addAttempts {
+			continue
+		}
+		break
+	}
+	if err != nil {
+		if reverseErr := t.loyalty.Reverse(order, order.Total); reverseErr != nil {
+			t.log.Errorf("Error Restoring Reward Points For Order %v: %v", order.Id, reverseErr)
+		}
+		return nil, err
+	}
+	return quote, nil
+}
+
+func (t *tableService) Close(restaurantId, sessionId uuid.UUID) error {
+	session, err := t.repo.FindSession(sessionId)
+	if err != nil {
+		return err
+	}
+	if session.RestaurantId != restaurantId {
+		return tableRepo.ErrSessionNotFound
+	}
+	return t.repo.Close(sessionId, time.Now())
+}
+
+// add prices the order as it stands plus the new lines, with the reward used on it if any,
+// and saves them.
+func (t *tableService) add(session *tableModel.Session, table *tableModel.Table, userId, dinerId uuid.UUID, added []pricing.Line) ([]tableModel.Item, *pricing.Breakdown, error) {
+	existing, err := t.repo.FindItems(session.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	cart := t.cart(session, table, existing)
+	cart.Lines = append(cart.Lines, added...)
+	quote, err := t.quote(session, userId, cart)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	order := &orderModel.Order{
+		Id:           uuid.New(),
+		UserId:       userId,
+		RestaurantId: session.RestaurantId,
+		Status:       orderModel.Pending,
+		Currency:     table.Currency,
+		Total:        quote.Total.Amount,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	items := make([]tableModel.Item, len(added))
+	for n := range added {
+		priced := quote.Lines[len(existing)+n]
+		items[n] = tableModel.Item{
+			OrderItem: orderModel.OrderItem{MenuId: priced.MenuId, Item: priced.Item, ItemType: priced.ItemType,
+				Quantity: priced.Quantity, UnitPrice: priced.UnitPrice.Amount},
+			DinerId: dinerId,
+		}
+	}
+	_, err = t.repo.AddItems(session.Id, order, len(existing), items)
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, quote, nil
+}
+
+// cart is the table's order as it stands.
+func (t *tableService) cart(session *tableModel.Session, table *tableModel.Table, existing []tableModel.Item) pricing.Cart {
+	cart := pricing.Cart{RestaurantId: session.RestaurantId, Currency: table.Currency}
+	for _, i := range existing {
+		cart.Lines = append(cart.Lines, pricing.Line{MenuId: i.MenuId, Item: i.Item, ItemType: i.ItemType,
+			Quantity: i.Quantity, UnitPrice: moneyModel.New(i.UnitPrice, table.Currency)})
+	}
+	return cart
+}
+
+func (t *tableService) quote(session *tableModel.Session, userId uuid.UUID, cart pricing.Cart) (*pricing.Breakdown, error) {
+	if session.OrderId != nil {
+		reward, err := t.loyalty.OrderReward(*session.OrderId)
+		if err != nil {
+			return nil, err
+		}
+		if reward != nil {
+			return t.pricing.QuoteWithReward(userId, cart, nil, reward.Promotion())
+		}
+	}
+	return t.pricing.Quote(userId, cart, nil)
+}
+
+// dinerSession loads the session for one of its diners. Anyone else is told it does not exist.
+func (t *tableService) dinerSession(sessionId, dinerId uuid.UUID) (*tableModel.Session, *tableModel.Diner, error) {
+	session, err := t.repo.FindSession(sessionId)
+	if err != nil {
+		return nil, nil, err
+	}
+	diner, err := session.Diner(dinerId)
+	if err != nil {
+		return nil, nil, tableRepo.ErrSessionNotFound
+	}
+	return session, diner, nil
+}
+
+// ownTable hides other restaurants' tables as not found.
+func (t *tableService) ownTable(restaurantId, tableId uuid.UUID) (*tableModel.Table, error) {
+	table, err := t.repo.FindById(tableId)
+	if err != nil {
+		return nil, err
+	}
+	if table.RestaurantId != restaurantId {
+		return nil, tableRepo.ErrNotFound
+	}
+	return table, nil
+}
+
+// NewTableService makes codes that link to baseURL, the address of the ordering web app.
+func NewTableService(log *logrus.Logger, repo tableRepo.RepoInterface, menus menuRepo.RepoInterface, orders orderRepo.RepoInterface, pricing pricingService.ServiceInterface, loyalty loyaltyService.ServiceInterface, baseURL string) ServiceInterface {
+	return &tableService{log: log, repo: repo, menus: menus, orders: orders, pricing: pricing, loyalty: loyalty,
+		baseURL: baseURL}
+}
@@ -0,0 +1,479 @@
+package tableService
+
+import (
+	"bytes"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"image/png"
+	"rsm/entity/loyaltyModel"
+	"rsm/entity/menuModel"
+	"rsm/entity/moneyModel"
+	"rsm/entity/orderModel"
+	"rsm/entity/promotionModel"
+	"rsm/entity/tableModel"
+	"rsm/entity/taxModel"
+	"rsm/pricing"
+	"rsm/repository/menuRepo"
+	"rsm/repository/tableRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Persist(table *tableModel.Table) error {
+	args := m.Called(table)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Update(table *tableModel.Table) error {
+	args := m.Called(table)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindById(id uuid.UUID) (*tableModel.Table, error) {
+	args := m.Called(id)
+	table, _ := args.Get(0).(*tableModel.Table)
+	return table, args.Error(1)
+}
+
+func (m *MockRepository) FindByToken(token string) (*tableModel.Table, error) {
+	args := m.Called(token)
+	table, _ := args.Get(0).(*tableModel.Table)
+	return table, args.Error(1)
+}
+
+func (m *MockRepository) FindByRestaurant(restaurantId uuid.UUID) ([]tableModel.Table, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]tableModel.Table), args.Error(1)
+}
+
+func (m *MockRepository) Join(session *tableModel.Session, diner *tableModel.Diner) (*tableModel.Session, error) {
+	args := m.Called(session, diner)
+	joined, _ := args.Get(0).(*tableModel.Session)
+	return joined, args.Error(1)
+}
+
+func (m *MockRepository) FindSession(id uuid.UUID) (*tableModel.Session, error) {
+	args := m.Called(id)
+	session, _ := args.Get(0).(*tableModel.Session)
+	return session, args.Error(1)
+}
+
+func (m *MockRepository) FindItems(sessionId uuid.UUID) ([]tableModel.Item, error) {
+	args := m.Called(sessionId)
+	return args.Get(0).([]tableModel.Item), args.Error(1)
+}
+
+func (m *MockRepository) AddItems(sessionId uuid.UUID, order *orderModel.Order, known int, items []tableModel.Item) (*tableModel.Session, error) {
+	args := m.Called(sessionId, order, known, items)
+	session, _ := args.Get(0).(*tableModel.Session)
+	return session, args.Error(1)
+}
+
+func (m *MockRepository) Close(id uuid.UUID, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CloseByOrder(orderId uuid.UUID, at time.Time) error {
+	args := m.Called(orderId, at)
+	return args.Error(0)
+}
+
+type MockMenuRepository struct {
+	mock.Mock
+}
+
+func (m *MockMenuRepository) FindById(id int64) (*menuModel.MenuItem, error) {
+	args := m.Called(id)
+	item, _ := args.Get(0).(*menuModel.MenuItem)
+	return item, args.Error(1)
+}
+
+func (m *MockMenuRepository) FindByRestaurant(query menuModel.MenuQuery) ([]menuModel.MenuItem, error) {
+	args := m.Called(query)
+	return args.Get(0).([]menuModel.MenuItem), args.Error(1)
+}
+
+func (m *MockMenuRepository) FindAll(restaurantId uuid.UUID) ([]menuModel.MenuItem, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]menuModel.MenuItem), args.Error(1)
+}
+
+func (m *MockMenuRepository) UpdateImage(id int64, previous, ref string) error {
+	args := m.Called(id, previous, ref)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) UpdateDietaryInfo(id int64, info menuModel.DietaryInfo) error {
+	args := m.Called(id, info)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) Import(restaurantId uuid.UUID, rows []menuModel.TransferRow, at time.Time) (int, int, error) {
+	args := m.Called(restaurantId, rows, at)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockMenuRepository) PersistMenu(menu *menuModel.NamedMenu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) UpdateMenu(menu *menuModel.NamedMenu) error {
+	args := m.Called(menu)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) FindMenu(id uuid.UUID) (*menuModel.NamedMenu, error) {
+	args := m.Called(id)
+	menu, _ := args.Get(0).(*menuModel.NamedMenu)
+	return menu, args.Error(1)
+}
+
+func (m *MockMenuRepository) FindMenus(restaurantId uuid.UUID) ([]menuModel.NamedMenu, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]menuModel.NamedMenu), args.Error(1)
+}
+
+func (m *MockMenuRepository) SaveDraft(version *menuModel.Version) error {
+	args := m.Called(version)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) Publish(id uuid.UUID, effectiveFrom, publishedAt time.Time) error {
+	args := m.Called(id, effectiveFrom, publishedAt)
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) FindVersion(id uuid.UUID) (*menuModel.Version, error) {
+	args := m.Called(id)
+	version, _ := args.Get(0).(*menuModel.Version)
+	return version, args.Error(1)
+}
+
+func (m *MockMenuRepository) FindVersions(namedMenuId uuid.UUID) ([]menuModel.Version, error) {
+	args := m.Called(namedMenuId)
+	return args.Get(0).([]menuModel.Version), args.Error(1)
+}
+
+func (m *MockMenuRepository) FindEffective(restaurantId uuid.UUID, at time.Time) ([]menuModel.Version, error) {
+	args := m.Called(restaurantId, at)
+	return args.Get(0).([]menuModel.Version), args.Error(1)
+}
+
+type MockPricingService struct {
+	mock.Mock
+}
+
+func (m *MockPricingService) SetTaxRules(rules *taxModel.TaxRules) error {
+	args := m.Called(rules)
+	return args.Error(0)
+}
+
+func (m *MockPricingService) GetTaxRules(restaurantId uuid.UUID) (*taxModel.TaxRules, error) {
+	args := m.Called(restaurantId)
+	rules, _ := args.Get(0).(*taxModel.TaxRules)
+	return rules, args.Error(1)
+}
+
+func (m *MockPricingService) Quote(userId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Breakdown, error) {
+	args := m.Called(userId, cart, codes)
+	breakdown, _ := args.Get(0).(*pricing.Breakdown)
+	return breakdown, args.Error(1)
+}
+
+func (m *MockPricingService) QuoteWithReward(userId uuid.UUID, cart pricing.Cart, codes []string, reward promotionModel.Promotion) (*pricing.Breakdown, error) {
+	args := m.Called(userId, cart, codes, reward)
+	breakdown, _ := args.Get(0).(*pricing.Breakdown)
+	return breakdown, args.Error(1)
+}
+
+func ngn(amount int64) moneyModel.Money {
+	return moneyModel.New(amount, "NGN")
+}
+
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepository) FindById(id uuid.UUID) (*orderModel.Order, error) {
+	args := m.Called(id)
+	order, _ := args.Get(0).(*orderModel.Order)
+	return order, args.Error(1)
+}
+
+func (m *MockOrderRepository) FindByRestaurant(restaurantId uuid.UUID, statuses []orderModel.Status) ([]orderModel.Order, error) {
+	args := m.Called(restaurantId, statuses)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) FindByUser(userId uuid.UUID, query orderModel.HistoryQuery) ([]orderModel.Order, error) {
+	args := m.Called(userId, query)
+	return args.Get(0).([]orderModel.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) UpdateStatus(id uuid.UUID, from, to orderModel.Status) error {
+	args := m.Called(id, from, to)
+	return args.Error(0)
+}
+
+type MockLoyaltyService struct {
+	mock.Mock
+}
+
+func (m *MockLoyaltyService) SaveRule(rule *loyaltyModel.EarnRule) error {
+	args := m.Called(rule)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) CreateReward(reward *loyaltyModel.Reward) error {
+	args := m.Called(reward)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) UpdateReward(reward *loyaltyModel.Reward) error {
+	args := m.Called(reward)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) Rewards(restaurantId uuid.UUID) ([]loyaltyModel.Reward, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]loyaltyModel.Reward), args.Error(1)
+}
+
+func (m *MockLoyaltyService) EarnForOrder(order *orderModel.Order) (*loyaltyModel.Entry, error) {
+	args := m.Called(order)
+	entry, _ := args.Get(0).(*loyaltyModel.Entry)
+	return entry, args.Error(1)
+}
+
+func (m *MockLoyaltyService) QuoteReward(userId, rewardId uuid.UUID, cart pricing.Cart, codes []string) (*pricing.Breakdown, error) {
+	args := m.Called(userId, rewardId, cart, codes)
+	breakdown, _ := args.Get(0).(*pricing.Breakdown)
+	return breakdown, args.Error(1)
+}
+
+func (m *MockLoyaltyService) Redeem(order *orderModel.Order, rewardId uuid.UUID, priced *pricing.Breakdown) (*loyaltyModel.Entry, error) {
+	args := m.Called(order, rewardId, priced)
+	entry, _ := args.Get(0).(*loyaltyModel.Entry)
+	return entry, args.Error(1)
+}
+
+func (m *MockLoyaltyService) OrderReward(orderId uuid.UUID) (*loyaltyModel.Reward, error) {
+	args := m.Called(orderId)
+	reward, _ := args.Get(0).(*loyaltyModel.Reward)
+	return reward, args.Error(1)
+}
+
+func (m *MockLoyaltyService) Reverse(order *orderModel.Order, refunded int64) error {
+	args := m.Called(order, refunded)
+	return args.Error(0)
+}
+
+func (m *MockLoyaltyService) ExpirePoints(at time.Time) (int64, error) {
+	args := m.Called(at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoyaltyService) Balance(userId uuid.UUID) (*loyaltyModel.Balance, error) {
+	args := m.Called(userId)
+	balance, _ := args.Get(0).(*loyaltyModel.Balance)
+	return balance, args.Error(1)
+}
+
+func (m *MockLoyaltyService) History(userId uuid.UUID, limit, offset int) ([]loyaltyModel.Entry, error) {
+	args := m.Called(userId, limit, offset)
+	return args.Get(0).([]loyaltyModel.Entry), args.Error(1)
+}
+
+func Test_tableService_Join(t *testing.T) {
+	restaurantId, userId := uuid.New(), uuid.New()
+	table := &tableModel.Table{Id: uuid.New(), RestaurantId: restaurantId, Token: "open", Active: true}
+	stored := &tableModel.Table{Id: uuid.New(), RestaurantId: restaurantId, Token: "stored"}
+
+	repo := new(MockRepository)
+	repo.On("FindByToken", "open").Return(table, nil)
+	repo.On("FindByToken", "stored").Return(stored, nil)
+	repo.On("FindByToken", "unknown").Return(nil, tableRepo.ErrNotFound)
+	repo.On("Join", mock.MatchedBy(func(s *tableModel.Session) bool {
+		return s.TableId == table.Id && s.RestaurantId == restaurantId && s.Status == tableModel.Open
+	}), mock.MatchedBy(func(d *tableModel.Diner) bool {
+		return d.UserId == &userId && d.Name == "Ada"
+	})).Return(&tableModel.Session{Id: uuid.New(), TableId: table.Id}, nil)
+	service := NewTableService(log, repo, new(MockMenuRepository), new(MockOrderRepository), new(MockPricingService), new(MockLoyaltyService),
+		"https://order.example")
+
+	session, diner, err := service.Join("open", &userId, tableModel.JoinRequest{Name: " Ada "})
+	assert.Nil(t, err)
+	assert.Equal(t, table.Id, session.TableId)
+	assert.Equal(t, "Ada", diner.Name)
+
+	_, _, err = service.Join("stored", nil, tableModel.JoinRequest{})
+	assert.ErrorIs(t, err, tableRepo.ErrNotFound)
+	_, _, err = service.Join("unknown", nil, tableModel.JoinRequest{})
+	assert.ErrorIs(t, err, tableRepo.ErrNotFound)
+}
+
+func Test_tableService_AddItems(t *testing.T) {
+	restaurantId, tableId, sessionId, dinerId := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	session := &tableModel.Session{Id: sessionId, TableId: tableId, RestaurantId: restaurantId, Status: tableModel.Open,
+		Diners: []tableModel.Diner{{Id: dinerId, SessionId: sessionId, Name: "Guest"}}}
+	existing := []tableModel.Item{{OrderItem: orderModel.OrderItem{Id: 1, MenuId: 7, Item: "Rice", ItemType: "Main",
+		Quantity: 1, UnitPrice: 1000}, DinerId: uuid.New()}}
+
+	repo := new(MockRepository)
+	repo.On("FindSession", sessionId).Return(session, nil)
+	repo.On("FindById", tableId).Return(&tableModel.Table{Id: tableId, RestaurantId: restaurantId, Currency: "NGN"}, nil)
+	repo.On("FindItems", sessionId).Return(existing, nil)
+	// Another diner adds at the same moment once, so the order is priced twice.
+	repo.On("AddItems", sessionId, mock.Anything, 1, mock.Anything).Return(nil, tableRepo.ErrStaleItems).Once()
+	repo.On("AddItems", sessionId, mock.MatchedBy(func(o *orderModel.Order) bool {
+		return o.Total == 4400 && o.UserId == uuid.Nil && o.Currency == "NGN" && o.Status == orderModel.Pending
+	}), 1, mock.Anything).Return(session, nil)
+
+	menus := new(MockMenuRepository)
+	menus.On("FindByRestaurant", menuModel.MenuQuery{RestaurantId: restaurantId}).Return([]menuModel.MenuItem{
+		{Id: 7, Item: "Rice", ItemType: "Main", Price: "10.00"},
+		{Id: 9, Item: "Soup", ItemType: "Main", Price: "15.00"},
+	}, nil)
+
+	prices := new(MockPricingService)
+	prices.On("Quote", uuid.Nil, mock.MatchedBy(func(c pricing.Cart) bool {
+		return len(c.Lines) == 2 && c.Lines[1].MenuId == 9 && c.Lines[1].UnitPrice == ngn(1500)
+	}), []string(nil)).Return(&pricing.Breakdown{
+		Lines: []pricing.LineBreakdown{
+			{Line: 0, MenuId: 7, Item: "Rice", ItemType: "Main", Quantity: 1, UnitPrice: ngn(1000)},
+			{Line: 1, MenuId: 9, Item: "Soup", ItemType: "Main", Quantity: 2, UnitPrice: ngn(1500)},
+		},
+		Total: ngn(4400),
+	}, nil)
+	service := NewTableService(log, repo, menus, new(MockOrderRepository), prices, new(MockLoyaltyService),
+		"https://order.example")
+
+	items, err := service.AddItems(sessionId, dinerId, tableModel.AddItemsRequest{
+		Lines: []tableModel.LineRequest{{MenuId: 9, Quantity: 2}}})
+	assert.Nil(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, int64(1500), items[0].UnitPrice)
+	assert.Equal(t, dinerId, items[0].DinerId)
+	repo.AssertNumberOfCalls(t, "AddItems", 2)
+
+	_, err = service.AddItems(sessionId, uuid.New(), tableModel.AddItemsRequest{
+		Lines: []tableModel.LineRequest{{MenuId: 9, Quantity: 1}}})
+	assert.ErrorIs(t, err, tableRepo.ErrSessionNotFound)
+
+	_, err = service.AddItems(sessionId, dinerId, tableModel.AddItemsRequest{
+		Lines: []tableModel.LineRequest{{MenuId: 42, Quantity: 1}}})
+	assert.ErrorIs(t, err, menuRepo.ErrNotFound)
+}
+
+func Test_tableService_AddItems_OrderLocked(t *testing.T) {
+	restaurantId, tableId, sessionId, dinerId := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	session := &tableModel.Session{Id: sessionId, TableId: tableId, RestaurantId: restaurantId, Status: tableModel.Open,
+		Diners: []tableModel.Diner{{Id: dinerId, SessionId: sessionId, Name: "Guest"}}}
+
+	repo := new(MockRepository)
+	repo.On("FindSession", sessionId).Return(session, nil)
+	repo.On("FindById", tableId).Return(&tableModel.Table{Id: tableId, RestaurantId: restaurantId, Currency: "NGN"}, nil)
+	repo.On("FindItems", sessionId).Return([]tableModel.Item{}, nil)
+	// The kitchen accepted the order, or the table split the bill, after the session was read.
+	repo.On("AddItems", sessionId, mock.Anything, 0, mock.Anything).Return(nil, tableRepo.ErrOrderLocked)
+
+	menus := new(MockMenuRepository)
+	menus.On("FindByRestaurant", menuModel.MenuQuery{RestaurantId: restaurantId}).Return([]menuModel.MenuItem{
+		{Id: 9, Item: "Soup", ItemType: "Main", Price: "15.00"},
+	}, nil)
+	prices := new(MockPricingService)
+	prices.On("Quote", uuid.Nil, mock.Anything, []string(nil)).Return(&pricing.Breakdown{
+		Lines: []pricing.LineBreakdown{{Line: 0, MenuId: 9, Item: "Soup", ItemType: "Main", Quantity: 1,
+			UnitPrice: ngn(1500)}},
+		Total: ngn(1500),
+	}, nil)
+	service := NewTableService(log, repo, menus, new(MockOrderRepository), prices, new(MockLoyaltyService),
+		"https://order.example")
+
+	_, err := service.AddItems(sessionId, dinerId, tableModel.AddItemsRequest{
+		Lines: []tableModel.LineRequest{{MenuId: 9, Quantity: 1}}})
+	assert.ErrorIs(t, err, tableRepo.ErrOrderLocked)
+	repo.AssertNumberOfCalls(t, "AddItems", 1)
+}
+
+func Test_tableService_UseReward(t *testing.T) {
+	restaurantId, tableId, sessionId, orderId := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	ownerId, guestId, rewardId := uuid.New(), uuid.New(), uuid.New()
+	session := &tableModel.Session{Id: sessionId, TableId: tableId, RestaurantId: restaurantId, Status: tableModel.Open,
+		OrderId: &orderId, Diners: []tableModel.Diner{{Id: ownerId, UserId: &ownerId}, {Id: guestId}}}
+	order := &orderModel.Order{Id: orderId, UserId: ownerId, RestaurantId: restaurantId, Status: orderModel.Pending,
+		Currency: "NGN", Total: 2000}
+	reward := &loyaltyModel.Reward{Id: rewardId, Type: loyaltyModel.Discount, Value: 500, Currency: "NGN", Active: true}
+	items := []tableModel.Item{{OrderItem: orderModel.OrderItem{MenuId: 9, Item: "Soup", ItemType: "Main", Quantity: 1,
+		UnitPrice: 2000}}}
+	cart := pricing.Cart{RestaurantId: restaurantId, Currency: "NGN", Lines: []pricing.Line{
+		{MenuId: 9, Item: "Soup", ItemType: "Main", Quantity: 1, UnitPrice: ngn(2000)}}}
+	priced := &pricing.Breakdown{Lines: []pricing.LineBreakdown{{Line: 0, MenuId: 9, Quantity: 1, UnitPrice: ngn(2000)}},
+		Discounts: pricing.Discounts{Applied: []pricing.AppliedPromotion{{PromotionId: rewardId}}}, Total: ngn(1500)}
+
+	repo := new(MockRepository)
+	repo.On("FindSession", sessionId).Return(session, nil)
+	repo.On("FindById", tableId).Return(&tableModel.Table{Id: tableId, RestaurantId: restaurantId, Currency: "NGN"}, nil)
+	repo.On("FindItems", sessionId).Return(items, nil)
+	repo.On("AddItems", sessionId, mock.MatchedBy(func(o *orderModel.Order) bool {
+		return o.Total == 1500
+	}), 1, []tableModel.Item{}).Return(session, nil).Once()
+	repo.On("AddItems", sessionId, mock.Anything, 1, []tableModel.Item{}).Return(nil, tableRepo.ErrOrderLocked)
+	orders := new(MockOrderRepository)
+	orders.On("FindById", orderId).Return(order, nil)
+	prices := new(MockPricingService)
+	prices.On("QuoteWithReward", ownerId, cart, []string(nil), reward.Promotion()).Return(priced, nil)
+	loyalty := new(MockLoyaltyService)
+	loyalty.On("QuoteReward", ownerId, rewardId, cart, []string(nil)).Return(priced, nil)
+	loyalty.On("Redeem", order, rewardId, priced).Return(&loyaltyModel.Entry{}, nil)
+	loyalty.On("OrderReward", orderId).Return(reward, nil)
+	loyalty.On("Reverse", order, int64(2000)).Return(nil)
+	service := NewTableService(log, repo, new(MockMenuRepository), orders, prices, loyalty, "https://order.example")
+
+	got, err := service.UseReward(sessionId, ownerId, rewardId)
+	assert.Nil(t, err)
+	assert.Equal(t, ngn(1500), got.Total)
+	loyalty.AssertNotCalled(t, "Reverse", order, int64(2000))
+
+	// Only the diner who started the order spends their points on it.
+	_, err = service.UseReward(sessionId, guestId, rewardId)
+	assert.NotNil(t, err)
+	loyalty.AssertNumberOfCalls(t, "Redeem", 1)
+
+	// The kitchen accepted the order after the points were spent, so they are given back.
+	_, err = service.UseReward(sessionId, ownerId, rewardId)
+	assert.ErrorIs(t, err, tableRepo.ErrOrderLocked)
+	loyalty.AssertCalled(t, "Reverse", order, int64(2000))
+
+	_, err = service.UseReward(sessionId, uuid.New(), rewardId)
+	assert.ErrorIs(t, err, tableRepo.ErrSessionNotFound)
+}
+
+func Test_tableService_QRCode(t *testing.T) {
+	restaurantId := uuid.New()
+	table := &tableModel.Table{Id: uuid.New(), RestaurantId: restaurantId, Token: "q3Jx0c8yRkWb2mVd7nAz1w"}
+
+	repo := new(MockRepository)
+	repo.On("FindById", table.Id).Return(table, nil)
+	service := NewTableService(log, repo, new(MockMenuRepository), new(MockOrderRepository), new(MockPricingService), new(MockLoyaltyService),
+		"https://order.example")
+
+	var buf bytes.Buffer
+	assert.Nil(t, service.QRCode(restaurantId, table.Id, &buf, 8))
+	img, err := png.Decode(&buf)
+	assert.Nil(t, err)
+	assert.Greater(t, img.Bounds().Dx(), 200)
+
+	err = service.QRCode(uuid.New(), table.Id, &buf, 8)
+	assert.ErrorIs(t, err, tableRepo.ErrNotFound)
+}
@@ -8,6 +8,7 @@ import (
 	"rsm/crypto/passwordUtils"
 	"rsm/entity/userModel"
 	"rsm/repository/userRepo"
+	"strings"
 	"time"
 )
 
@@ -18,6 +19,14 @@ type ServiceInterface interface {
 	GetByUserId(id uuid.UUID) (*userModel.UserAccessModel, error)
 	DeleteUser(id uuid.UUID) error
 	GetAllUsers()
+
+	GetProfile(id uuid.UUID) (*userModel.Profile, error)
+	UpdateProfile(id uuid.UUID, request userModel.ProfileUpdateRequest) (*userModel.UserAccessModel, error)
+	ListAddresses(userId uuid.UUID) ([]userModel.Address, error)
+	AddAddress(userId uuid.UUID, request userModel.AddressRequest) (*userModel.Address, error)
+	UpdateAddress(userId, addressId uuid.UUID, request userModel.AddressRequest) (*userModel.Address, error)
+	DeleteAddress(userId, addressId uuid.UUID) error
+	SetDefaultAddress(userId, addressId uuid.UUID) error
 }
 
 func (u *userService) Login(request userModel.UserLoginRequest) (*userModel.UserAccessModel, error) {
@@ -43,6 +52,8 @@ func (u *userService) Login(request userModel.UserLoginRequest) (*userModel.User
 		FirstName: accessUser.FirstName,
 		LastName:  accessUser.LastName,
 		Email:     accessUser.Email,
+		Phone:     accessUser.Phone,
+		AvatarRef: accessUser.AvatarRef,
 	}
 	return &userAccess, nil
 }
@@ -77,6 +88,8 @@ func (u *userService) GetByEmail(email string) (*userModel.UserAccessModel, erro
 		FirstName: accessUser.FirstName,
 		LastName:  accessUser.LastName,
 		Email:     accessUser.Email,
+		Phone:     accessUser.Phone,
+		AvatarRef: accessUser.AvatarRef,
 	}
 	return &userAccess, nil
 }
@@ -92,6 +105,8 @@ func (u *userService) GetByUserId(id uuid.UUID) (*userModel.UserAccessModel, err
 		FirstName: accessUser.FirstName,
 		LastName:  accessUser.LastName,
 		Email:     accessUser.Email,
+		Phone:     accessUser.Phone,
+		AvatarRef: accessUser.AvatarRef,
 	}
 	return &userAccess, nil
 }
@@ -105,6 +120,144 @@ func (u *userService) GetAllUsers() {
 	panic("implement me")
 }
 
+func (u *userService) GetProfile(id uuid.UUID) (*userModel.Profile, error) {
+	user, err := u.GetByUserId(id)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := u.repo.FindAddresses(id)
+	if err != nil {
+		return nil, err
+	}
+	return &userModel.Profile{UserAccessModel: *user, Addresses: addresses}, nil
+}
+
+// UpdateProfile changes the user's names, phone and avatar. The email is the login and is
+// not changed here.
+func (u *userService) UpdateProfile(id uuid.UUID, request userModel.ProfileUpdateRequest) (*userModel.UserAccessModel, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		u.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	user, err := u.repo.FindById(id)
+	if err != nil {
+		return nil, err
+	}
+	_, err = u.repo.Update(&userModel.UserModel{
+		Id:        id,
+		FirstName: request.FirstName,
+		LastName:  request.LastName,
+		Email:     user.Email,
+		Phone:     request.Phone,
+		AvatarRef: request.AvatarRef,
+	})
+	if err != nil {
+		return nil, err
+	}
+	user.FirstName, user.LastName = request.FirstName, request.LastName
+	user.Phone, user.AvatarRef = request.Phone, request.AvatarRef
+	return user, nil
+}
+
+func (u *userService) ListAddresses(userId uuid.UUID) ([]userModel.Address, error) {
+	return u.repo.FindAddresses(userId)
+}
+
+// AddAddress saves a new address. The first address a user saves becomes their default.
+func (u *userService) AddAddress(userId uuid.UUID, request userModel.AddressRequest) (*userModel.Address, error) {
+	request.Label = strings.TrimSpace(request.Label)
+	err := request.ValidateInput()
+	if err != nil {
+		u.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	existing, err := u.repo.FindAddresses(userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= userModel.MaxAddresses {
+		return nil, fmt.Errorf("cannot save more than %d addresses", userModel.MaxAddresses)
+	}
+	err = checkLabel(existing, uuid.Nil, request.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	address := userModel.Address{
+		Id:        uuid.New(),
+		UserId:    userId,
+		IsDefault: request.Default,
+		CreatedAt: time.Now(),
+	}
+	address.Apply(request)
+	address.UpdatedAt = address.CreatedAt
+	err = u.repo.PersistAddress(&address)
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (u *userService) UpdateAddress(userId, addressId uuid.UUID, request userModel.AddressRequest) (*userModel.Address, error) {
+	request.Label = strings.TrimSpace(request.Label)
+	err := request.ValidateInput()
+	if err != nil {
+		u.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	existing, err := u.repo.FindAddresses(userId)
+	if err != nil {
+		return nil, err
+	}
+	var address *userModel.Address
+	for i := range existing {
+		if existing[i].Id == addressId {
+			address = &existing[i]
+		}
+	}
+	if address == nil {
+		return nil, userRepo.ErrAddressNotFound
+	}
+	err = checkLabel(existing, addressId, request.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	address.Apply(request)
+	address.UpdatedAt = time.Now()
+	err = u.repo.UpdateAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if request.Default && !address.IsDefault {
+		err = u.repo.SetDefaultAddress(userId, addressId)
+		if err != nil {
+			return nil, err
+		}
+		address.IsDefault = true
+	}
+	return address, nil
+}
+
+// checkLabel rejects a label another of the user's addresses already uses, ignoring case.
+func checkLabel(addresses []userModel.Address, self uuid.UUID, label string) error {
+	for _, a := range addresses {
+		if a.Id != self && strings.EqualFold(a.Label, label) {
+			return fmt.Errorf("an address labelled %q already exists", a.Label)
+		}
+	}
+	return nil
+}
+
+func (u *userService) DeleteAddress(userId, addressId uuid.UUID) error {
+	return u.repo.DeleteAddress(userId, addressId)
+}
+
+func (u *userService) SetDefaultAddress(userId, addressId uuid.UUID) error {
+	return u.repo.SetDefaultAddress(userId, addressId)
+}
+
 type userService struct {
 	log    *logrus.Logger
 	repo   userRepo.RepoInterface
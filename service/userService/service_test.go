@@ -11,6 +11,7 @@ import (
 	"rsm/crypto/passwordUtils"
 	"rsm/entity/userModel"
 	"rsm/repository/userRepo"
+	"strings"
 	"testing"
 	"time"
 )
@@ -50,6 +51,36 @@ func (m *MockRepository) FindByEmail(email string) (*userModel.UserModel, error)
 	return results.(*userModel.UserModel), args.Error(1)
 }
 
+func (m *MockRepository) PersistAddress(address *userModel.Address) error {
+	args := m.Called(address)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateAddress(address *userModel.Address) error {
+	args := m.Called(address)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteAddress(userId, id uuid.UUID) error {
+	args := m.Called(userId, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindAddress(userId, id uuid.UUID) (*userModel.Address, error) {
+	args := m.Called(userId, id)
+	return args.Get(0).(*userModel.Address), args.Error(1)
+}
+
+func (m *MockRepository) FindAddresses(userId uuid.UUID) ([]userModel.Address, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]userModel.Address), args.Error(1)
+}
+
+func (m *MockRepository) SetDefaultAddress(userId, id uuid.UUID) error {
+	args := m.Called(userId, id)
+	return args.Error(0)
+}
+
 type mockPasswordUtils struct {
 	mock.Mock
 }
@@ -111,6 +142,8 @@ func Test_userService_Login(t *testing.T) {
 		LastName:  "uus",
 		Email:     "b@b.com",
 		Password:  "$2a$14$2djvlayweuaxkot0fEbIsOOePfQ6Oer/IZSSb6qjSEp08gNSe8nnu",
+		Phone:     "+2348012345678",
+		AvatarRef: "avatars/bait.png",
 	}
 
 	reqCorrectCredentials := userModel.UserLoginRequest{
@@ -181,6 +214,8 @@ func Test_userService_Login(t *testing.T) {
 				FirstName: "bait",
 				LastName:  "uus",
 				Email:     "b@b.com",
+				Phone:     "+2348012345678",
+				AvatarRef: "avatars/bait.png",
 			},
 			err: nil,
 		},
@@ -451,3 +486,118 @@ func Test_userService_DeleteUser(t *testing.T) {
 		})
 	}
 }
+
+func Test_userService_UpdateProfile(t *testing.T) {
+	id := uuid.New()
+	tests := []struct {
+		name      string
+		request   userModel.ProfileUpdateRequest
+		wantPhone string
+		wantErr   bool
+	}{
+		{name: "formatted phone", request: userModel.ProfileUpdateRequest{FirstName: "Ada", LastName: "Obi",
+			Phone: "+234 (803) 123-4567", AvatarRef: "avatars/ada.jpg"}, wantPhone: "+2348031234567"},
+		{name: "no phone", request: userModel.ProfileUpdateRequest{FirstName: "Ada", LastName: "Obi"}},
+		{name: "missing country code", request: userModel.ProfileUpdateRequest{FirstName: "Ada", LastName: "Obi",
+			Phone: "08031234567"}, wantErr: true},
+		{name: "no first name", request: userModel.ProfileUpdateRequest{LastName: "Obi"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("FindById", id).Return(&userModel.UserAccessModel{Id: id, FirstName: "A", LastName: "O",
+				Email: "ada@obi.com"}, nil)
+			mockRepo.On("Update", mock.Anything).Return(&userModel.UserModel{}, nil)
+			u := NewUserService(log, mockRepo, new(mockPasswordUtils))
+
+			got, err := u.UpdateProfile(id, tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UpdateProfile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+				return
+			}
+			updated := mockRepo.Calls[1].Arguments.Get(0).(*userModel.UserModel)
+			assert.Equal(t, "ada@obi.com", updated.Email)
+			assert.Equal(t, tt.wantPhone, updated.Phone)
+			assert.Equal(t, tt.wantPhone, got.Phone)
+			assert.Equal(t, tt.request.AvatarRef, got.AvatarRef)
+		})
+	}
+}
+
+func Test_userService_AddAddress(t *testing.T) {
+	userId := uuid.New()
+	home := userModel.Address{Id: uuid.New(), UserId: userId, Label: "Home", IsDefault: true}
+	request := func(label string, isDefault bool) userModel.AddressRequest {
+		return userModel.AddressRequest{Label: label, Line1: "12 Admiralty Way", City: "Lagos", Country: "NG",
+			Latitude: 6.43, Longitude: 3.45, Default: isDefault}
+	}
+	full := make([]userModel.Address, userModel.MaxAddresses)
+	for i := range full {
+		full[i] = userModel.Address{Id: uuid.New(), Label: fmt.Sprintf("place %d", i)}
+	}
+
+	tests := []struct {
+		name     string
+		existing []userModel.Address
+		request  userModel.AddressRequest
+		wantErr  bool
+	}{
+		{name: "first address", existing: []userModel.Address{}, request: request("Home", false)},
+		{name: "new default", existing: []userModel.Address{home}, request: request(" Work ", true)},
+		{name: "label taken", existing: []userModel.Address{home}, request: request("home", false), wantErr: true},
+		{name: "address book full", existing: full, request: request("Gym", false), wantErr: true},
+		{name: "bad country", existing: []userModel.Address{}, request: userModel.AddressRequest{
+			Label: "Home", Line1: "1 Road", City: "Lagos", Country: "Nigeria"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("FindAddresses", userId).Return(tt.existing, nil)
+			mockRepo.On("PersistAddress", mock.Anything).Return(nil)
+			u := NewUserService(log, mockRepo, new(mockPasswordUtils))
+
+			got, err := u.AddAddress(userId, tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AddAddress() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				mockRepo.AssertNotCalled(t, "PersistAddress", mock.Anything)
+				return
+			}
+			assert.Equal(t, userId, got.UserId)
+			assert.Equal(t, strings.TrimSpace(tt.request.Label), got.Label)
+			assert.Equal(t, tt.request.Default, got.IsDefault)
+			assert.Equal(t, 6.43, got.Point().Latitude)
+		})
+	}
+}
+
+func Test_userService_UpdateAddress(t *testing.T) {
+	userId := uuid.New()
+	home := userModel.Address{Id: uuid.New(), UserId: userId, Label: "Home", IsDefault: true}
+	work := userModel.Address{Id: uuid.New(), UserId: userId, Label: "Work"}
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindAddresses", userId).Return([]userModel.Address{home, work}, nil)
+	mockRepo.On("UpdateAddress", mock.Anything).Return(nil)
+	mockRepo.On("SetDefaultAddress", userId, work.Id).Return(nil)
+	u := NewUserService(log, mockRepo, new(mockPasswordUtils))
+
+	request := userModel.AddressRequest{Label: "Office", Line1: "3 Broad St", City: "Lagos", Country: "NG", Default: true}
+	got, err := u.UpdateAddress(userId, work.Id, request)
+	assert.Nil(t, err)
+	assert.Equal(t, "Office", got.Label)
+	assert.True(t, got.IsDefault)
+	mockRepo.AssertCalled(t, "SetDefaultAddress", userId, work.Id)
+
+	request.Label = "HOME"
+	_, err = u.UpdateAddress(userId, work.Id, request)
+	assert.NotNil(t, err)
+
+	_, err = u.UpdateAddress(userId, uuid.New(), request)
+	assert.ErrorIs(t, err, userRepo.ErrAddressNotFound)
+}
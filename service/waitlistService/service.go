@@ -0,0 +1,219 @@
+package waitlistService
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/waitlistModel"
+	"rsm/notify"
+	"rsm/repository/waitlistRepo"
+	"strconv"
+	"time"
+)
+
+// TableReady is sent to a waiting party when the host calls them to their table.
+const TableReady notify.Kind = "table_ready"
+
+type ServiceInterface interface {
+	// Join adds a party to the restaurant's queue. userId is nil for parties the host adds.
+	Join(restaurantId uuid.UUID, userId *uuid.UUID, request waitlistModel.JoinRequest) (*waitlistModel.Entry, error)
+	// Queue is the host's view of everyone waiting, with positions and estimated waits.
+	Queue(restaurantId uuid.UUID) ([]waitlistModel.Entry, error)
+	// Position looks up one entry for the customer. Its id is the only thing they need, so
+	// it works for parties without an account too.
+	Position(entryId uuid.UUID) (*waitlistModel.Entry, error)
+	NotifyReady(restaurantId, entryId uuid.UUID) (*waitlistModel.Entry, error)
+	Seat(restaurantId, entryId uuid.UUID) (*waitlistModel.Turn, error)
+	// Remove takes a party off the queue as Cancelled, or as NoShow after they were notified.
+	Remove(restaurantId, entryId uuid.UUID, status waitlistModel.Status) (*waitlistModel.Entry, error)
+	// SeatWalkIn records a party seated without queueing, so their turn counts towards the estimates.
+	SeatWalkIn(restaurantId uuid.UUID, partySize int) (*waitlistModel.Turn, error)
+	ClearTable(restaurantId, turnId uuid.UUID) (*waitlistModel.Turn, error)
+}
+
+type waitlistService struct {
+	log      *logrus.Logger
+	repo     waitlistRepo.RepoInterface
+	notifier notify.Notifier
+}
+
+func (w *waitlistService) Join(restaurantId uuid.UUID, userId *uuid.UUID, request waitlistModel.JoinRequest) (*waitlistModel.Entry, error) {
+	err := request.ValidateInput()
+	if err != nil {
+		w.log.Errorf("Validation Error: %v", err)
+		return nil, fmt.Errorf("something went wrong while validation")
+	}
+	now := time.Now()
+	entry := &waitlistModel.Entry{
+		Id:           uuid.New(),
+		RestaurantId: restaurantId,
+		UserId:       userId,
+		Name:         request.Name,
+		Phone:        request.Phone,
+		PartySize:    request.PartySize,
+		Status:       waitlistModel.Waiting,
+		JoinedAt:     now,
+		UpdatedAt:    now,
+	}
+	err = w.repo.Persist(entry)
+	if err != nil {
+		return nil, err
+	}
+	return w.Position(entry.Id)
+}
+
+func (w *waitlistService) Queue(restaurantId uuid.UUID) ([]waitlistModel.Entry, error) {
+	queue, err := w.repo.FindQueue(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	err = w.estimate(restaurantId, queue, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return queue, nil
+}
+
+func (w *waitlistService) Position(entryId uuid.UUID) (*waitlistModel.Entry, error) {
+	entry, err := w.repo.FindById(entryId)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.Queued() {
+		return entry, nil
+	}
+	queue, err := w.Queue(entry.RestaurantId)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range queue {
+		if e.Id == entry.Id {
+			return &e, nil
+		}
+	}
+	// Seated or removed between the two reads.
+	return w.repo.FindById(entryId)
+}
+
+// NotifyReady tells the party their table is ready. The entry keeps its place until it is
+// seated, so calling a party that does not turn up does not lose the table to the next one.
+func (w *waitlistService) NotifyReady(restaurantId, entryId uuid.UUID) (*waitlistModel.Entry, error) {
+	entry, err := w.move(restaurantId, entryId, waitlistModel.Notified)
+	if err != nil {
+		return nil, err
+	}
+	n := notify.Notification{
+		Kind:         TableReady,
+		RestaurantId: &entry.RestaurantId,
+		UserId:       entry.UserId,
+		Title:        "Your table is ready",
+		Body:         fmt.Sprintf("%v, your table for %d is ready. Please come to the host stand.", entry.Name, entry.PartySize),
+		Data:         map[string]string{"entryId": entry.Id.String(), "partySize": strconv.Itoa(entry.PartySize)},
+		At:           *entry.NotifiedAt,
+	}
+	if entry.Phone != "" {
+		n.Data["phone"] = entry.Phone
+	}
+	err = w.notifier.Notify(n)
+	if err != nil {
+		w.log.Errorf("Error Sending %v Notification: %v", TableReady, err)
+	}
+	return entry, nil
+}
+
+func (w *waitlistService) Seat(restaurantId, entryId uuid.UUID) (*waitlistModel.Turn, error) {
+	entry, from, err := w.transition(restaurantId, entryId, waitlistModel.Seated)
+	if err != nil {
+		return nil, err
+	}
+	turn := &waitlistModel.Turn{
+		Id:           uuid.New(),
+		RestaurantId: restaurantId,
+		EntryId:      &entry.Id,
+		PartySize:    entry.PartySize,
+		SeatedAt:     entry.UpdatedAt,
+	}
+	err = w.repo.Seat(entry, from, turn)
+	if err != nil {
+		return nil, err
+	}
+	return turn, nil
+}
+
+func (w *waitlistService) Remove(restaurantId, entryId uuid.UUID, status waitlistModel.Status) (*waitlistModel.Entry, error) {
+	if status != waitlistModel.Cancelled && status != waitlistModel.NoShow {
+		return nil, fmt.Errorf("entries can only be removed as %v or %v", waitlistModel.Cancelled, waitlistModel.NoShow)
+	}
+	return w.move(restaurantId, entryId, status)
+}
+
+func (w *waitlistService) SeatWalkIn(restaurantId uuid.UUID, partySize int) (*waitlistModel.Turn, error) {
+	if partySize < 1 {
+		return nil, fmt.Errorf("party size must be at least 1")
+	}
+	turn := &waitlistModel.Turn{Id: uuid.New(), RestaurantId: restaurantId, PartySize: partySize, SeatedAt: time.Now()}
+	err := w.repo.PersistTurn(turn)
+	if err != nil {
+		return nil, err
+	}
+	return turn, nil
+}
+
+func (w *waitlistService) ClearTable(restaurantId, turnId uuid.UUID) (*waitlistModel.Turn, error) {
+	return w.repo.ClearTurn(restaurantId, turnId, time.Now())
+}
+
+// move changes the entry's status and saves it.
+func (w *waitlistService) move(restaurantId, entryId uuid.UUID, to waitlistModel.Status) (*waitlistModel.Entry, error) {
+	entry, from, err := w.transition(restaurantId, entryId, to)
+	if err != nil {
+		return nil, err
+	}
+	err = w.repo.UpdateStatus(entry, from)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// transition loads the restaurant's entry and moves it to the new status in memory, returning
+// the status it had. Another restaurant's entry is reported as not found.
+func (w *waitlistService) transition(restaurantId, entryId uuid.UUID, to waitlistModel.Status) (*waitlistModel.Entry, waitlistModel.Status, error) {
+	entry, err := w.repo.FindById(entryId)
+	if err != nil {
+		return nil, "", err
+	}
+	if entry.RestaurantId != restaurantId {
+		return nil, "", waitlistRepo.ErrNotFound
+	}
+	from := entry.Status
+	if !waitlistModel.CanTransition(from, to) {
+		return nil, "", fmt.Errorf("cannot move waitlist entry from %v to %v", from, to)
+	}
+	now := time.Now()
+	entry.Status, entry.UpdatedAt = to, now
+	switch to {
+	case waitlistModel.Notified:
+		entry.NotifiedAt = &now
+	case waitlistModel.Seated:
+		entry.SeatedAt = &now
+	}
+	return entry, from, nil
+}
+
+func (w *waitlistService) estimate(restaurantId uuid.UUID, queue []waitlistModel.Entry, now time.Time) error {
+	occupied, err := w.repo.FindOccupied(restaurantId)
+	if err != nil {
+		return err
+	}
+	recent, err := w.repo.FindRecentTurns(restaurantId, now.Add(-waitlistModel.RecentTurns), waitlistModel.MaxRecentTurns)
+	if err != nil {
+		return err
+	}
+	waitlistModel.Estimate(queue, occupied, recent, now)
+	return nil
+}
+
+func NewWaitlistService(log *logrus.Logger, repo waitlistRepo.RepoInterface, notifier notify.Notifier) ServiceInterface {
+	return &waitlistService{log: log, repo: repo, notifier: notifier}
+}
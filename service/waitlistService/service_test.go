@@ -0,0 +1,150 @@
+package waitlistService
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/waitlistModel"
+	"rsm/notify"
+	"rsm/repository/waitlistRepo"
+	"testing"
+	"time"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Persist(entry *waitlistModel.Entry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindById(id uuid.UUID) (*waitlistModel.Entry, error) {
+	args := m.Called(id)
+	entry, _ := args.Get(0).(*waitlistModel.Entry)
+	return entry, args.Error(1)
+}
+
+func (m *MockRepository) FindQueue(restaurantId uuid.UUID) ([]waitlistModel.Entry, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]waitlistModel.Entry), args.Error(1)
+}
+
+func (m *MockRepository) UpdateStatus(entry *waitlistModel.Entry, from waitlistModel.Status) error {
+	args := m.Called(entry, from)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Seat(entry *waitlistModel.Entry, from waitlistModel.Status, turn *waitlistModel.Turn) error {
+	args := m.Called(entry, from, turn)
+	return args.Error(0)
+}
+
+func (m *MockRepository) PersistTurn(turn *waitlistModel.Turn) error {
+	args := m.Called(turn)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ClearTurn(restaurantId, id uuid.UUID, at time.Time) (*waitlistModel.Turn, error) {
+	args := m.Called(restaurantId, id, at)
+	turn, _ := args.Get(0).(*waitlistModel.Turn)
+	return turn, args.Error(1)
+}
+
+func (m *MockRepository) FindOccupied(restaurantId uuid.UUID) ([]waitlistModel.Turn, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]waitlistModel.Turn), args.Error(1)
+}
+
+func (m *MockRepository) FindRecentTurns(restaurantId uuid.UUID, since time.Time, limit int) ([]waitlistModel.Turn, error) {
+	args := m.Called(restaurantId, since, limit)
+	return args.Get(0).([]waitlistModel.Turn), args.Error(1)
+}
+
+func Test_waitlistService_Position(t *testing.T) {
+	restaurantId := uuid.New()
+	first := waitlistModel.Entry{Id: uuid.New(), RestaurantId: restaurantId, PartySize: 2, Status: waitlistModel.Notified}
+	second := waitlistModel.Entry{Id: uuid.New(), RestaurantId: restaurantId, PartySize: 4, Status: waitlistModel.Waiting}
+	seated := waitlistModel.Entry{Id: uuid.New(), RestaurantId: restaurantId, PartySize: 3, Status: waitlistModel.Seated}
+
+	repo := new(MockRepository)
+	repo.On("FindById", second.Id).Return(&second, nil)
+	repo.On("FindById", seated.Id).Return(&seated, nil)
+	repo.On("FindQueue", restaurantId).Return([]waitlistModel.Entry{first, second}, nil)
+	repo.On("FindOccupied", restaurantId).Return([]waitlistModel.Turn{}, nil)
+	repo.On("FindRecentTurns", restaurantId, mock.Anything, waitlistModel.MaxRecentTurns).
+		Return([]waitlistModel.Turn{}, nil)
+	service := NewWaitlistService(log, repo, notify.NewMemoryNotifier())
+
+	entry, err := service.Position(second.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, entry.Position)
+	assert.Equal(t, int(waitlistModel.DefaultTurnTime.Minutes()), entry.EstimatedMinutes)
+
+	entry, err = service.Position(seated.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, entry.Position)
+}
+
+func Test_waitlistService_NotifyReady(t *testing.T) {
+	restaurantId, userId := uuid.New(), uuid.New()
+	entry := waitlistModel.Entry{Id: uuid.New(), RestaurantId: restaurantId, UserId: &userId, Name: "Ada",
+		Phone: "+2348031234567", PartySize: 2, Status: waitlistModel.Waiting}
+
+	repo := new(MockRepository)
+	repo.On("FindById", entry.Id).Return(&entry, nil)
+	repo.On("UpdateStatus", mock.Anything, waitlistModel.Waiting).Return(nil)
+	notifier := notify.NewMemoryNotifier()
+	service := NewWaitlistService(log, repo, notifier)
+
+	_, err := service.NotifyReady(uuid.New(), entry.Id)
+	assert.ErrorIs(t, err, waitlistRepo.ErrNotFound)
+
+	got, err := service.NotifyReady(restaurantId, entry.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, waitlistModel.Notified, got.Status)
+	assert.NotNil(t, got.NotifiedAt)
+
+	sent := notifier.Sent()
+	assert.Len(t, sent, 1)
+	assert.Equal(t, TableReady, sent[0].Kind)
+	assert.Equal(t, &userId, sent[0].UserId)
+	assert.Equal(t, "+2348031234567", sent[0].Data["phone"])
+}
+
+func Test_waitlistService_Seat(t *testing.T) {
+	restaurantId := uuid.New()
+	entry := waitlistModel.Entry{Id: uuid.New(), RestaurantId: restaurantId, PartySize: 5, Status: waitlistModel.Notified}
+
+	repo := new(MockRepository)
+	repo.On("FindById", entry.Id).Return(&entry, nil)
+	repo.On("Seat", mock.Anything, waitlistModel.Notified, mock.Anything).Return(nil)
+	service := NewWaitlistService(log, repo, notify.NewMemoryNotifier())
+
+	turn, err := service.Seat(restaurantId, entry.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, turn.PartySize)
+	assert.Equal(t, &entry.Id, turn.EntryId)
+	assert.Equal(t, waitlistModel.Seated, entry.Status)
+}
+
+func Test_waitlistService_Remove(t *testing.T) {
+	restaurantId := uuid.New()
+	waiting := waitlistModel.Entry{Id: uuid.New(), RestaurantId: restaurantId, Status: waitlistModel.Waiting}
+
+	repo := new(MockRepository)
+	repo.On("FindById", waiting.Id).Return(&waiting, nil)
+	service := NewWaitlistService(log, repo, notify.NewMemoryNotifier())
+
+	// Only parties that were called can fail to show up.
+	_, err := service.Remove(restaurantId, waiting.Id, waitlistModel.NoShow)
+	assert.NotNil(t, err)
+
+	_, err = service.Remove(restaurantId, waiting.Id, waitlistModel.Seated)
+	assert.NotNil(t, err)
+	repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,71 @@
+package zoneService
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"rsm/entity/zoneModel"
+	"rsm/location/geoUtils"
+	"rsm/repository/zoneRepo"
+	"time"
+)
+
+type ServiceInterface interface {
+	CreateZone(zone *zoneModel.Zone) error
+	UpdateZone(zone *zoneModel.Zone) error
+	DeleteZone(restaurantId, id uuid.UUID) error
+	ListZones(restaurantId uuid.UUID) ([]zoneModel.Zone, error)
+	Locate(restaurantId uuid.UUID, p geoUtils.Point) (*zoneModel.Zone, error)
+}
+
+type zoneService struct {
+	log  *logrus.Logger
+	repo zoneRepo.RepoInterface
+}
+
+func (z *zoneService) CreateZone(zone *zoneModel.Zone) error {
+	err := zone.ValidateInput()
+	if err != nil {
+		z.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	zone.Id = uuid.New()
+	zone.CreatedAt = time.Now()
+	zone.UpdatedAt = zone.CreatedAt
+	return z.repo.Persist(zone)
+}
+
+func (z *zoneService) UpdateZone(zone *zoneModel.Zone) error {
+	err := zone.ValidateInput()
+	if err != nil {
+		z.log.Errorf("Validation Error: %v", err)
+		return fmt.Errorf("something went wrong while validation")
+	}
+	zone.UpdatedAt = time.Now()
+	return z.repo.Update(zone)
+}
+
+func (z *zoneService) DeleteZone(restaurantId, id uuid.UUID) error {
+	return z.repo.Delete(restaurantId, id)
+}
+
+func (z *zoneService) ListZones(restaurantId uuid.UUID) ([]zoneModel.Zone, error) {
+	return z.repo.FindByRestaurant(restaurantId)
+}
+
+// Locate returns the zone that delivers to p, or zoneModel.ErrOutside. A restaurant that
+// has not drawn any zones delivers everywhere without a fee, so it gets a nil zone.
+func (z *zoneService) Locate(restaurantId uuid.UUID, p geoUtils.Point) (*zoneModel.Zone, error) {
+	zones, err := z.repo.FindByRestaurant(restaurantId)
+	if err != nil {
+		return nil, err
+	}
+	if len(zones) == 0 {
+		return nil, nil
+	}
+	return zoneModel.Match(zones, p)
+}
+
+func NewZoneService(log *logrus.Logger, repo zoneRepo.RepoInterface) ServiceInterface {
+	return &zoneService{log: log, repo: repo}
+}
@@ -0,0 +1,104 @@
+package zoneService
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"rsm/entity/zoneModel"
+	"rsm/location/geoUtils"
+	"testing"
+)
+
+var log = logrus.New()
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Persist(zone *zoneModel.Zone) error {
+	args := m.Called(zone)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Update(zone *zoneModel.Zone) error {
+	args := m.Called(zone)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Delete(restaurantId, id uuid.UUID) error {
+	args := m.Called(restaurantId, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindById(id uuid.UUID) (*zoneModel.Zone, error) {
+	args := m.Called(id)
+	return args.Get(0).(*zoneModel.Zone), args.Error(1)
+}
+
+func (m *MockRepository) FindByRestaurant(restaurantId uuid.UUID) ([]zoneModel.Zone, error) {
+	args := m.Called(restaurantId)
+	return args.Get(0).([]zoneModel.Zone), args.Error(1)
+}
+
+var triangle = geoUtils.Area{geoUtils.Polygon{geoUtils.Ring{
+	{Latitude: 6.40, Longitude: 3.30}, {Latitude: 6.40, Longitude: 3.50}, {Latitude: 6.60, Longitude: 3.50},
+}}}
+
+func Test_zoneService_CreateZone(t *testing.T) {
+	restaurantId := uuid.New()
+	tests := []struct {
+		name    string
+		zone    zoneModel.Zone
+		wantErr bool
+	}{
+		{name: "valid", zone: zoneModel.Zone{RestaurantId: restaurantId, Name: "Island", Area: triangle,
+			DeliveryFee: 800, Currency: "NGN"}},
+		{name: "no area", zone: zoneModel.Zone{RestaurantId: restaurantId, Name: "Island", Currency: "NGN"}, wantErr: true},
+		{name: "degenerate ring", zone: zoneModel.Zone{RestaurantId: restaurantId, Name: "Island", Currency: "NGN",
+			Area: geoUtils.Area{geoUtils.Polygon{triangle[0][0][:2]}}}, wantErr: true},
+		{name: "negative fee", zone: zoneModel.Zone{RestaurantId: restaurantId, Name: "Island", Area: triangle,
+			DeliveryFee: -1, Currency: "NGN"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("Persist", mock.Anything).Return(nil)
+			z := NewZoneService(log, mockRepo)
+
+			err := z.CreateZone(&tt.zone)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateZone() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				mockRepo.AssertNotCalled(t, "Persist", mock.Anything)
+				return
+			}
+			assert.NotEqual(t, uuid.Nil, tt.zone.Id)
+		})
+	}
+}
+
+func Test_zoneService_Locate(t *testing.T) {
+	zoned, unzoned := uuid.New(), uuid.New()
+	inside := geoUtils.Point{Latitude: 6.45, Longitude: 3.45}
+	outside := geoUtils.Point{Latitude: 6.55, Longitude: 3.31}
+	island := zoneModel.Zone{Id: uuid.New(), RestaurantId: zoned, Name: "Island", Area: triangle, Currency: "NGN"}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindByRestaurant", zoned).Return([]zoneModel.Zone{island}, nil)
+	mockRepo.On("FindByRestaurant", unzoned).Return([]zoneModel.Zone{}, nil)
+	z := NewZoneService(log, mockRepo)
+
+	got, err := z.Locate(zoned, inside)
+	assert.Nil(t, err)
+	assert.Equal(t, island.Id, got.Id)
+
+	_, err = z.Locate(zoned, outside)
+	assert.ErrorIs(t, err, zoneModel.ErrOutside)
+
+	got, err = z.Locate(unzoned, outside)
+	assert.Nil(t, err)
+	assert.Nil(t, got)
+}
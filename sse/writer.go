@@ -0,0 +1,66 @@
+package sse
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Event is one server-sent event. Id is echoed back by browsers in the Last-Event-ID
+// header when they reconnect.
+type Event struct {
+	Id   string
+	Type string
+	Data []byte
+}
+
+type Writer struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// NewWriter starts an event stream on w. It fails if the response cannot be flushed, since
+// events would then sit in a buffer instead of reaching the client.
+func NewWriter(w http.ResponseWriter) (*Writer, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming is not supported")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &Writer{w: w, flusher: flusher}, nil
+}
+
+func (s *Writer) Send(event Event) error {
+	var b strings.Builder
+	if event.Id != "" {
+		fmt.Fprintf(&b, "id: %s\n", event.Id)
+	}
+	if event.Type != "" {
+		fmt.Fprintf(&b, "event: %s\n", event.Type)
+	}
+	for _, line := range strings.Split(string(event.Data), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Heartbeat writes a comment line, which clients ignore, to keep idle proxies from closing
+// the connection.
+func (s *Writer) Heartbeat() error {
+	return s.write(": ping\n\n")
+}
+
+func (s *Writer) write(chunk string) error {
+	_, err := s.w.Write([]byte(chunk))
+	if err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
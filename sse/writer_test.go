@@ -0,0 +1,21 @@
+package sse
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w, err := NewWriter(rec)
+	assert.Nil(t, err)
+
+	assert.Nil(t, w.Send(Event{Id: "7", Type: "ticket", Data: []byte("{\"a\":1}\n{\"b\":2}")}))
+	assert.Nil(t, w.Heartbeat())
+	assert.Nil(t, w.Send(Event{Data: []byte("plain")}))
+
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "id: 7\nevent: ticket\ndata: {\"a\":1}\ndata: {\"b\":2}\n\n: ping\n\ndata: plain\n\n", rec.Body.String())
+	assert.True(t, rec.Flushed)
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("blob not found")
+	ErrTooLarge = errors.New("upload is too large")
+)
+
+// Object describes a stored blob, as an S3 HEAD request would.
+type Object struct {
+	Key         string    `json:"key"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"modTime"`
+}
+
+// BlobStore keeps whole objects under flat, slash-separated keys, the shape of an S3 bucket,
+// so an S3-compatible store can replace the local one without changing callers. Put replaces
+// any object already at the key.
+type BlobStore interface {
+	Put(key string, body io.Reader, contentType string) (*Object, error)
+	Get(key string) (io.ReadCloser, *Object, error)
+	Head(key string) (*Object, error)
+	Delete(key string) error
+}
+
+// ValidateKey accepts keys that are safe both as S3 keys and as relative file paths: letters,
+// digits, '-', '_', '.' and '/', with no empty, '.' or '..' segments.
+func ValidateKey(key string) error {
+	if key == "" || len(key) > 512 {
+		return fmt.Errorf("invalid key %q", key)
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_./", c)) {
+			return fmt.Errorf("invalid key %q", key)
+		}
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid key %q", key)
+		}
+	}
+	return nil
+}
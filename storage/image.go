@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	MaxImageBytes = 8 << 20
+	// MaxImagePixels stops small files that decode into huge bitmaps.
+	MaxImagePixels = 40_000_000
+)
+
+// ThumbnailSizes are the longest sides, in pixels, of the thumbnails made for every image.
+var ThumbnailSizes = []int{160, 640}
+
+// imageTypes maps the sniffed types that can be decoded to the extension they are stored with.
+// WebP and HEIC are recognised by browsers but not by the standard library, so are refused.
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Image is an upload that has been checked and decoded.
+type Image struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+	Decoded     image.Image
+}
+
+// Sniff returns the content type of data from its first bytes, ignoring whatever type the
+// client claimed.
+func Sniff(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+// ReadImage reads at most limit bytes and accepts only JPEG, PNG and GIF images, checked by
+// content rather than name, no larger than MaxImagePixels.
+func ReadImage(r io.Reader, limit int64) (*Image, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: the limit is %d bytes", ErrTooLarge, limit)
+	}
+	contentType := Sniff(data)
+	extension, ok := imageTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported image type %v, expected JPEG, PNG or GIF",
+			strings.SplitN(contentType, ";", 2)[0])
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read image: %w", err)
+	}
+	if config.Width*config.Height > MaxImagePixels {
+		return nil, fmt.Errorf("%w: %dx%d is more than %d pixels", ErrTooLarge, config.Width, config.Height,
+			MaxImagePixels)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read image: %w", err)
+	}
+	return &Image{Data: data, ContentType: contentType, Extension: extension, Decoded: decoded}, nil
+}
+
+// Thumbnail scales img down so its longest side is at most size, keeping its proportions.
+// Each output pixel is the average of the source pixels it covers. Images already small
+// enough are copied at their own size rather than enlarged.
+func Thumbnail(img image.Image, size int) *image.RGBA {
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	dw, dh := sw, sh
+	if sw > size || sh > size {
+		if sw >= sh {
+			dw, dh = size, sh*size/sw
+		} else {
+			dw, dh = sw*size/sh, size
+		}
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := span(y, sh, dh)
+		for x := 0; x < dw; x++ {
+			x0, x1 := span(x, sw, dw)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			out := dst.Pix[y*dst.Stride+x*4:]
+			for c := 0; c < 4; c++ {
+				out[c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// span returns the source pixels [from, to) covered by output pixel i, always at least one.
+func span(i, source, output int) (int, int) {
+	from, to := i*source/output, (i+1)*source/output
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
+
+// Encode writes a thumbnail as the type of the image it was made from: JPEG stays JPEG and
+// PNG and GIF become PNG so transparency is kept.
+func Encode(w io.Writer, img image.Image, contentType string) (string, error) {
+	if contentType == "image/jpeg" {
+		return "image/jpeg", jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	}
+	return "image/png", png.Encode(w, img)
+}
+
+// ThumbnailKey is where the thumbnail of the given size of the image at key is stored. Its
+// extension follows Encode: JPEG thumbnails for JPEG images, PNG for the rest.
+func ThumbnailKey(key string, size int) string {
+	extension := path.Ext(key)
+	if extension != ".jpg" {
+		extension = ".png"
+	}
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(key, path.Ext(key)), size, extension)
+}
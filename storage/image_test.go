@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	assert.Nil(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestReadImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	data := encodePNG(t, img)
+
+	got, err := ReadImage(bytes.NewReader(data), MaxImageBytes)
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", got.ContentType)
+	assert.Equal(t, ".png", got.Extension)
+	assert.Equal(t, image.Rect(0, 0, 40, 20), got.Decoded.Bounds())
+
+	var jpg bytes.Buffer
+	assert.Nil(t, jpeg.Encode(&jpg, img, nil))
+	got, err = ReadImage(&jpg, MaxImageBytes)
+	assert.Nil(t, err)
+	assert.Equal(t, ".jpg", got.Extension)
+
+	_, err = ReadImage(bytes.NewReader(data), int64(len(data)-1))
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = ReadImage(strings.NewReader("<html><body>not a photo</body></html>"), MaxImageBytes)
+	assert.NotNil(t, err)
+
+	truncated := data[:len(data)/2]
+	_, err = ReadImage(bytes.NewReader(truncated), MaxImageBytes)
+	assert.NotNil(t, err)
+}
+
+func TestReadImage_PixelLimit(t *testing.T) {
+	huge := encodePNG(t, image.NewGray(image.Rect(0, 0, 8000, 6000)))
+	_, err := ReadImage(bytes.NewReader(huge), MaxImageBytes)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestThumbnail(t *testing.T) {
+	// Left half black, right half white.
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			c := color.RGBA{A: 255}
+			if x >= 200 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	thumb := Thumbnail(img, 100)
+	assert.Equal(t, image.Rect(0, 0, 100, 50), thumb.Bounds())
+	assert.Equal(t, color.RGBA{A: 255}, thumb.RGBAAt(10, 25))
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, thumb.RGBAAt(90, 25))
+
+	odd := Thumbnail(img, 3)
+	assert.Equal(t, image.Rect(0, 0, 3, 1), odd.Bounds())
+	middle := odd.RGBAAt(1, 0)
+	assert.Equal(t, uint8(127), middle.R)
+
+	tall := Thumbnail(image.NewRGBA(image.Rect(0, 0, 50, 1000)), 160)
+	assert.Equal(t, image.Rect(0, 0, 8, 160), tall.Bounds())
+
+	small := Thumbnail(image.NewRGBA(image.Rect(10, 10, 60, 40)), 160)
+	assert.Equal(t, image.Rect(0, 0, 50, 30), small.Bounds())
+}
+
+func TestThumbnailKey(t *testing.T) {
+	assert.Equal(t, "menu/a/1/photo_160.jpg", ThumbnailKey("menu/a/1/photo.jpg", 160))
+	assert.Equal(t, "menu/a/1/photo_640.png", ThumbnailKey("menu/a/1/photo.gif", 640))
+}
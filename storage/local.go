@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LocalStore keeps blobs on the local filesystem: the bytes under root/objects and each
+// object's content type under root/meta, so Get and Head answer like an S3 bucket would.
+type LocalStore struct {
+	root string
+}
+
+type localMeta struct {
+	ContentType string `json:"contentType"`
+}
+
+// Put writes to a temporary file and renames it into place, so readers never see a partial
+// object.
+func (l *LocalStore) Put(key string, body io.Reader, contentType string) (*Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	path := l.objectPath(key)
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, body)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = l.writeMeta(key, localMeta{ContentType: contentType})
+	if err != nil {
+		return nil, err
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return nil, err
+	}
+	return &Object{Key: key, ContentType: contentType, Size: size, ModTime: time.Now()}, nil
+}
+
+func (l *LocalStore) Get(key string) (io.ReadCloser, *Object, error) {
+	object, err := l.Head(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(l.objectPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, object, nil
+}
+
+func (l *LocalStore) Head(key string) (*Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(l.objectPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	object := &Object{Key: key, ContentType: "application/octet-stream", Size: info.Size(), ModTime: info.ModTime()}
+
+	data, err := os.ReadFile(l.metaPath(key))
+	if err == nil {
+		var meta localMeta
+		if json.Unmarshal(data, &meta) == nil && meta.ContentType != "" {
+			object.ContentType = meta.ContentType
+		}
+	}
+	return object, nil
+}
+
+// Delete, like S3, succeeds when there is nothing at the key.
+func (l *LocalStore) Delete(key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(l.objectPath(key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	err = os.Remove(l.metaPath(key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *LocalStore) writeMeta(key string, meta localMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	path := l.metaPath(key)
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (l *LocalStore) objectPath(key string) string {
+	return filepath.Join(l.root, "objects", filepath.FromSlash(key))
+}
+
+func (l *LocalStore) metaPath(key string) string {
+	return filepath.Join(l.root, "meta", filepath.FromSlash(key)+".json")
+}
+
+// NewLocalStore stores blobs under root, creating it if needed.
+func NewLocalStore(root string) (*LocalStore, error) {
+	err := os.MkdirAll(root, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalStore{root: root}, nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	assert.Nil(t, err)
+
+	object, err := store.Put("menu/a/1.jpg", strings.NewReader("jpeg bytes"), "image/jpeg")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), object.Size)
+
+	head, err := store.Head("menu/a/1.jpg")
+	assert.Nil(t, err)
+	assert.Equal(t, "image/jpeg", head.ContentType)
+	assert.Equal(t, int64(10), head.Size)
+
+	body, got, err := store.Get("menu/a/1.jpg")
+	assert.Nil(t, err)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "jpeg bytes", string(data))
+	assert.Equal(t, "image/jpeg", got.ContentType)
+
+	_, err = store.Put("menu/a/1.jpg", strings.NewReader("png"), "image/png")
+	assert.Nil(t, err)
+	head, _ = store.Head("menu/a/1.jpg")
+	assert.Equal(t, "image/png", head.ContentType)
+	assert.Equal(t, int64(3), head.Size)
+
+	assert.Nil(t, store.Delete("menu/a/1.jpg"))
+	_, err = store.Head("menu/a/1.jpg")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, _, err = store.Get("menu/a/1.jpg")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, store.Delete("menu/a/1.jpg"))
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"a.jpg", "restaurants/7b1f/photo_160.png", "a-b/c.d"} {
+		assert.Nil(t, ValidateKey(key), key)
+	}
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b", "a/./b", "a b.jpg", `a\b`,
+		strings.Repeat("a", 513)} {
+		assert.NotNil(t, ValidateKey(key), key)
+	}
+}